@@ -0,0 +1,278 @@
+// Package acl evaluates client access control lists (allow_query, allow_recursion, deny).
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package acl
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"dnsplane/config"
+)
+
+// Action is the outcome of evaluating a client against the ACLs.
+type Action int
+
+const (
+	// Allow lets the client query and recurse.
+	Allow Action = iota
+	// LocalOnly lets the client receive local authoritative answers but not cache or upstream answers.
+	LocalOnly
+	// Deny refuses (or drops) every query from the client.
+	Deny
+)
+
+// Deny actions from config.ClientACLConfig.DenyAction.
+const (
+	DenyActionRefuse = "refuse"
+	DenyActionDrop   = "drop"
+)
+
+// Decision is the evaluated action plus the list and entry that produced it (for logs and the dashboard).
+type Decision struct {
+	Action Action
+	// Rule is "<list>:<entry>" (e.g. "deny:10.0.0.0/8") or "<list>:nomatch"; empty when no list applied.
+	Rule string
+}
+
+// String returns a short label such as "allow", "local_only (allow_recursion:nomatch)".
+func (d Decision) String() string {
+	var s string
+	switch d.Action {
+	case LocalOnly:
+		s = "local_only"
+	case Deny:
+		s = "deny"
+	default:
+		s = "allow"
+	}
+	if d.Rule != "" {
+		s += " (" + d.Rule + ")"
+	}
+	return s
+}
+
+type entry struct {
+	raw    string
+	negate bool
+	any    bool
+	prefix netip.Prefix
+	// group is set for named ACL references (resolved at compile time).
+	group *list
+}
+
+type list struct {
+	entries []entry
+}
+
+// match returns (matched, allowed, entry label). A negated entry that matches yields allowed=false.
+func (l *list) match(ip netip.Addr) (bool, bool, string) {
+	if l == nil {
+		return false, false, ""
+	}
+	for _, e := range l.entries {
+		var hit bool
+		switch {
+		case e.any:
+			hit = true
+		case e.group != nil:
+			m, allowed, _ := e.group.match(ip)
+			hit = m && allowed
+		case ip.IsValid():
+			hit = e.prefix.Contains(ip)
+		}
+		if hit {
+			return true, !e.negate, e.raw
+		}
+	}
+	return false, false, ""
+}
+
+// ACL is a compiled client ACL set. A nil *ACL allows everything.
+type ACL struct {
+	allowQuery     *list
+	allowRecursion *list
+	deny           *list
+	denyAction     string
+}
+
+// Compile parses cfg. Unknown group names, reference cycles, and invalid addresses are errors.
+func Compile(cfg config.ClientACLConfig) (*ACL, error) {
+	c := compiler{groups: cfg.Groups, done: map[string]*list{}, active: map[string]bool{}}
+	names := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if isReserved(name) {
+			return nil, fmt.Errorf("acl: group name %q is reserved", name)
+		}
+		if _, err := c.group(name); err != nil {
+			return nil, err
+		}
+	}
+	a := &ACL{denyAction: DenyActionRefuse}
+	if strings.EqualFold(strings.TrimSpace(cfg.DenyAction), DenyActionDrop) {
+		a.denyAction = DenyActionDrop
+	}
+	var err error
+	if a.allowQuery, err = c.list("allow_query", cfg.AllowQuery); err != nil {
+		return nil, err
+	}
+	if a.allowRecursion, err = c.list("allow_recursion", cfg.AllowRecursion); err != nil {
+		return nil, err
+	}
+	if a.deny, err = c.list("deny", cfg.Deny); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Enabled reports whether any list is configured.
+func (a *ACL) Enabled() bool {
+	return a != nil && (a.allowQuery != nil || a.allowRecursion != nil || a.deny != nil)
+}
+
+// DenyAction is DenyActionRefuse or DenyActionDrop.
+func (a *ACL) DenyAction() string {
+	if a == nil {
+		return DenyActionRefuse
+	}
+	return a.denyAction
+}
+
+// Evaluate checks clientIP against deny, then allow_query, then allow_recursion.
+// Unparseable addresses only match "any" entries.
+func (a *ACL) Evaluate(clientIP string) Decision {
+	if !a.Enabled() {
+		return Decision{Action: Allow}
+	}
+	ip, _ := netip.ParseAddr(strings.TrimSpace(clientIP))
+	ip = ip.Unmap()
+	if m, allowed, raw := a.deny.match(ip); m && allowed {
+		return Decision{Action: Deny, Rule: "deny:" + raw}
+	}
+	if a.allowQuery != nil {
+		m, allowed, raw := a.allowQuery.match(ip)
+		if !m {
+			return Decision{Action: Deny, Rule: "allow_query:nomatch"}
+		}
+		if !allowed {
+			return Decision{Action: Deny, Rule: "allow_query:" + raw}
+		}
+	}
+	if a.allowRecursion != nil {
+		m, allowed, raw := a.allowRecursion.match(ip)
+		if !m {
+			return Decision{Action: LocalOnly, Rule: "allow_recursion:nomatch"}
+		}
+		if !allowed {
+			return Decision{Action: LocalOnly, Rule: "allow_recursion:" + raw}
+		}
+		return Decision{Action: Allow, Rule: "allow_recursion:" + raw}
+	}
+	return Decision{Action: Allow}
+}
+
+type compiler struct {
+	groups map[string][]string
+	done   map[string]*list
+	active map[string]bool
+}
+
+func (c *compiler) group(name string) (*list, error) {
+	if l, ok := c.done[name]; ok {
+		return l, nil
+	}
+	if c.active[name] {
+		return nil, fmt.Errorf("acl: group %q references itself", name)
+	}
+	c.active[name] = true
+	defer delete(c.active, name)
+	l, err := c.list("group "+name, c.groups[name])
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		l = &list{}
+	}
+	c.done[name] = l
+	return l, nil
+}
+
+func (c *compiler) list(where string, items []string) (*list, error) {
+	var l *list
+	for _, item := range items {
+		raw := strings.TrimSpace(item)
+		if raw == "" {
+			continue
+		}
+		if l == nil {
+			l = &list{}
+		}
+		e := entry{raw: raw}
+		body := raw
+		if strings.HasPrefix(body, "!") {
+			e.negate = true
+			body = strings.TrimSpace(body[1:])
+		}
+		switch strings.ToLower(body) {
+		case "any":
+			e.any = true
+		case "none":
+			continue
+		case "localhost":
+			e.group = &list{entries: []entry{
+				{raw: "127.0.0.0/8", prefix: netip.MustParsePrefix("127.0.0.0/8")},
+				{raw: "::1/128", prefix: netip.MustParsePrefix("::1/128")},
+			}}
+		default:
+			p, err := parsePrefix(body)
+			if err == nil {
+				e.prefix = p
+				break
+			}
+			if _, ok := c.groups[body]; !ok {
+				return nil, fmt.Errorf("acl: %s: %q is not an IP, CIDR, or known group", where, raw)
+			}
+			g, gerr := c.group(body)
+			if gerr != nil {
+				return nil, gerr
+			}
+			e.group = g
+		}
+		l.entries = append(l.entries, e)
+	}
+	return l, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		// ::ffff:10.0.0.0/104 matches like 10.0.0.0/8 since client addresses are unmapped.
+		if a := p.Addr(); a.Is4In6() && p.Bits() >= 96 {
+			p = netip.PrefixFrom(a.Unmap(), p.Bits()-96)
+		}
+		return p.Masked(), nil
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	ip = ip.Unmap()
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
+
+func isReserved(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "any", "none", "localhost":
+		return true
+	}
+	return strings.HasPrefix(name, "!")
+}
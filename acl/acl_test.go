@@ -0,0 +1,87 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package acl
+
+import (
+	"testing"
+
+	"dnsplane/config"
+)
+
+func TestEvaluate(t *testing.T) {
+	a, err := Compile(config.ClientACLConfig{
+		Groups: map[string][]string{
+			"office":  {"!10.1.99.0/24", "10.1.0.0/16"},
+			"trusted": {"office", "192.168.1.10"},
+		},
+		AllowQuery:     []string{"trusted", "172.16.0.0/12", "::ffff:100.64.0.0/106"},
+		AllowRecursion: []string{"trusted", "localhost"},
+		Deny:           []string{"10.1.66.6"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip   string
+		want Action
+	}{
+		{"10.1.2.3", Allow},
+		{"192.168.1.10", Allow},
+		{"10.1.66.6", Deny},
+		{"10.1.99.5", Deny},       // negated inside office, so not trusted and not in allow_query
+		{"172.16.5.5", LocalOnly}, // may query, not recurse
+		{"100.64.1.1", LocalOnly}, // IPv4-mapped CIDR in config
+		{"::ffff:172.16.5.5", LocalOnly},
+		{"8.8.8.8", Deny},
+		{"unknown", Deny},
+	}
+	for _, tt := range tests {
+		if got := a.Evaluate(tt.ip); got.Action != tt.want {
+			t.Errorf("Evaluate(%q) = %s, want action %d", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateEmptyAllowsAll(t *testing.T) {
+	a, err := Compile(config.ClientACLConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Enabled() {
+		t.Fatal("empty config should not be enabled")
+	}
+	if d := a.Evaluate("203.0.113.1"); d.Action != Allow {
+		t.Fatalf("got %s", d)
+	}
+	var nilACL *ACL
+	if d := nilACL.Evaluate("203.0.113.1"); d.Action != Allow {
+		t.Fatalf("nil ACL: got %s", d)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	bad := []config.ClientACLConfig{
+		{AllowQuery: []string{"not-a-group"}},
+		{Deny: []string{"10.0.0.0/33"}},
+		{Groups: map[string][]string{"a": {"b"}, "b": {"a"}}},
+		{Groups: map[string][]string{"any": {"10.0.0.0/8"}}},
+	}
+	for i, cfg := range bad {
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestNoneDeniesEveryone(t *testing.T) {
+	a, err := Compile(config.ClientACLConfig{AllowRecursion: []string{"none"}, DenyAction: "DROP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := a.Evaluate("127.0.0.1"); d.Action != LocalOnly {
+		t.Fatalf("got %s", d)
+	}
+	if a.DenyAction() != DenyActionDrop {
+		t.Fatalf("deny action = %q", a.DenyAction())
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"dnsplane/apiauth"
+	"dnsplane/data"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// APITokenRequest is the body of POST /auth/tokens and PATCH /auth/tokens/{name}.
+type APITokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresAt is an RFC 3339 time or a duration from now ("90d"); "" or "never" for no expiry.
+	ExpiresAt *string `json:"expires_at,omitempty"`
+}
+
+func apiTokenStore(w http.ResponseWriter) *apiauth.Store {
+	s := data.GetInstance().APITokens()
+	if s == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "api tokens are not available"})
+	}
+	return s
+}
+
+func writeAPITokenError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, apiauth.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, apiauth.ErrExists):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func listAPITokensHandler(w http.ResponseWriter, r *http.Request) {
+	s := apiTokenStore(w)
+	if s == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tokens": s.List()})
+}
+
+// createAPITokenHandler adds a credential. The secret is in the response only.
+func createAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	s := apiTokenStore(w)
+	if s == nil {
+		return
+	}
+	var req APITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	scopes, err := apiauth.ParseScopes(strings.Join(req.Scopes, ","))
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+	var expires time.Time
+	if req.ExpiresAt != nil {
+		if expires, err = apiauth.ParseExpiry(*req.ExpiresAt, time.Now()); err != nil {
+			writeAPITokenError(w, err)
+			return
+		}
+	}
+	secret, c, err := s.Create(req.Name, scopes, expires)
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+	logAPITokenChange(r, "created", c.Name)
+	writeJSON(w, http.StatusCreated, map[string]any{"token": secret, "credential": c})
+}
+
+// rotateAPITokenHandler replaces the secret of a credential; the old secret stops working at once.
+func rotateAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	s := apiTokenStore(w)
+	if s == nil {
+		return
+	}
+	secret, c, err := s.Rotate(chi.URLParam(r, "name"))
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+	logAPITokenChange(r, "rotated", c.Name)
+	writeJSON(w, http.StatusOK, map[string]any{"token": secret, "credential": c})
+}
+
+// updateAPITokenHandler changes the expiry of a credential.
+func updateAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	s := apiTokenStore(w)
+	if s == nil {
+		return
+	}
+	var req APITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if req.ExpiresAt == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expires_at is required"})
+		return
+	}
+	expires, err := apiauth.ParseExpiry(*req.ExpiresAt, time.Now())
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+	c, err := s.SetExpiry(chi.URLParam(r, "name"), expires)
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+	logAPITokenChange(r, "expiry changed", c.Name)
+	writeJSON(w, http.StatusOK, map[string]any{"credential": c})
+}
+
+func deleteAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	s := apiTokenStore(w)
+	if s == nil {
+		return
+	}
+	name := chi.URLParam(r, "name")
+	if err := s.Remove(name); err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+	logAPITokenChange(r, "removed", name)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "removed", "name": name})
+}
+
+func logAPITokenChange(r *http.Request, what, name string) {
+	apiServerMu.Lock()
+	logger := apiLogger
+	apiServerMu.Unlock()
+	if logger != nil {
+		logger.Info("api token "+what, "name", name, "by", apiTokenName(r), "remote", r.RemoteAddr)
+	}
+}
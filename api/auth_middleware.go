@@ -4,33 +4,155 @@
 package api
 
 import (
+	"context"
 	"crypto/subtle"
+	"errors"
+	"fmt"
 	"net/http"
 	"path"
+	"slices"
 	"strings"
+	"time"
 
 	"dnsplane/data"
 )
 
-// apiAuthMiddleware enforces APIAuthToken when set in config (checked per request).
-// Exempt: GET/HEAD /health and /ready (for probes and orchestration).
+// defaultTokenName names api_auth_token in logs and the record history.
+const defaultTokenName = "default"
+
+// apiPrincipal is the credential that authorized a request.
+type apiPrincipal struct {
+	name   string
+	all    bool // api_auth_token: every scope
+	scopes []string
+	cert   bool // a client certificate, not a token
+}
+
+// describe names p in error messages.
+func (p apiPrincipal) describe() string {
+	if p.cert {
+		return fmt.Sprintf("client certificate %q", p.name)
+	}
+	return fmt.Sprintf("token %q", p.name)
+}
+
+func (p apiPrincipal) allows(scope string) bool {
+	return p.all || slices.Contains(p.scopes, scope)
+}
+
+type apiPrincipalKey struct{}
+
+// apiTokenName returns the name of the token that authorized r, or "" when auth is off.
+func apiTokenName(r *http.Request) string {
+	p, _ := r.Context().Value(apiPrincipalKey{}).(apiPrincipal)
+	return p.name
+}
+
+// apiAuthEnabled reports whether requests need a credential: api_auth_token is set, named credentials
+// exist, or client certificates are required.
+func apiAuthEnabled() bool {
+	if apiClientCerts.Load() != nil {
+		return true
+	}
+	dnsData := data.GetInstance()
+	return strings.TrimSpace(dnsData.GetResolverSettings().APIAuthToken) != "" || dnsData.APITokens().Enforced()
+}
+
+var (
+	errUnauthorized       = errors.New("unauthorized")
+	errClientCertRequired = errors.New("client certificate required")
+)
+
+// authenticateRequest finds the credential of r. With client certificates on, r must carry a verified
+// certificate; a token sent as well decides the scopes, otherwise the certificate's role does. secret is
+// the token the caller extracted from r.
+func authenticateRequest(r *http.Request, secret string) (apiPrincipal, error) {
+	var certPrincipal apiPrincipal
+	certMatched := false
+	if apiClientCerts.Load() != nil {
+		cert := verifiedClientCert(r)
+		if cert == nil {
+			return apiPrincipal{}, errClientCertRequired
+		}
+		certPrincipal, certMatched = clientCertPrincipal(cert, data.GetInstance().GetResolverSettings().APITLSClientRoles)
+		if !certMatched && secret == "" {
+			return apiPrincipal{}, fmt.Errorf("%s matches no role", certPrincipal.describe())
+		}
+	}
+	if secret != "" {
+		if p, ok := authenticateSecret(secret); ok {
+			return p, nil
+		}
+		return apiPrincipal{}, errUnauthorized
+	}
+	if certMatched {
+		return certPrincipal, nil
+	}
+	return apiPrincipal{}, errUnauthorized
+}
+
+// authenticateSecret matches secret against api_auth_token and the named credentials.
+func authenticateSecret(secret string) (apiPrincipal, bool) {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return apiPrincipal{}, false
+	}
+	dnsData := data.GetInstance()
+	if tok := strings.TrimSpace(dnsData.GetResolverSettings().APIAuthToken); tok != "" && subtleStringEqual(secret, tok) {
+		return apiPrincipal{name: defaultTokenName, all: true}, true
+	}
+	if c, ok := dnsData.APITokens().Authenticate(secret, time.Now()); ok {
+		return apiPrincipal{name: c.Name, scopes: c.Scopes}, true
+	}
+	return apiPrincipal{}, false
+}
+
+// requestSecret returns the token from Authorization: Bearer or X-API-Token.
+func requestSecret(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) >= 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Token"))
+}
+
+// apiAuthMiddleware authenticates every request when api_auth_token, named credentials, or client
+// certificates are configured (checked per request) and records the credential for requireScope, the
+// request log, and the record history. Exempt: GET/HEAD /health and /ready (for probes and orchestration).
 func apiAuthMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			tok := strings.TrimSpace(data.GetInstance().GetResolverSettings().APIAuthToken)
-			if tok == "" {
+			if !apiAuthEnabled() || apiAuthExempt(r) {
 				next.ServeHTTP(w, r)
 				return
 			}
-			if apiAuthExempt(r) {
+			p, err := authenticateRequest(r, requestSecret(r))
+			if err != nil {
+				writeAuthError(w, err)
+				return
+			}
+			setRequestLogToken(r, p.name)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiPrincipalKey{}, p)))
+		})
+	}
+}
+
+// requireScope rejects requests whose credential does not hold scope (403). With auth off every request
+// passes.
+func requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !apiAuthEnabled() {
 				next.ServeHTTP(w, r)
 				return
 			}
-			if !apiRequestAuthorized(r, tok) {
-				w.Header().Set("Content-Type", "application/json; charset=utf-8")
-				w.Header().Set("WWW-Authenticate", `Bearer realm="dnsplane"`)
-				w.WriteHeader(http.StatusUnauthorized)
-				_, _ = w.Write([]byte(`{"error":"unauthorized"}` + "\n"))
+			p, ok := r.Context().Value(apiPrincipalKey{}).(apiPrincipal)
+			if !ok {
+				writeUnauthorized(w)
+				return
+			}
+			if !p.allows(scope) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": fmt.Sprintf("%s lacks scope %s", p.describe(), scope)})
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -38,6 +160,26 @@ func apiAuthMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
+// writeAuthError answers a failed authenticateRequest: 401 for a missing or wrong credential, 403 for a
+// client certificate that matches no role.
+func writeAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errUnauthorized):
+		writeUnauthorized(w)
+	case errors.Is(err, errClientCertRequired):
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+}
+
+func writeUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="dnsplane"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	_, _ = w.Write([]byte(`{"error":"unauthorized"}` + "\n"))
+}
+
 func apiAuthExempt(r *http.Request) bool {
 	switch r.Method {
 	case http.MethodGet, http.MethodHead:
@@ -52,22 +194,6 @@ func apiAuthExempt(r *http.Request) bool {
 	return p == "/stats/dashboard/ws"
 }
 
-func apiRequestAuthorized(r *http.Request, want string) bool {
-	want = strings.TrimSpace(want)
-	if want == "" {
-		return true
-	}
-	auth := strings.TrimSpace(r.Header.Get("Authorization"))
-	if len(auth) >= 7 && strings.EqualFold(auth[:7], "bearer ") {
-		got := strings.TrimSpace(auth[7:])
-		return subtleStringEqual(got, want)
-	}
-	if got := strings.TrimSpace(r.Header.Get("X-API-Token")); got != "" {
-		return subtleStringEqual(got, want)
-	}
-	return false
-}
-
 func subtleStringEqual(a, b string) bool {
 	if len(a) != len(b) {
 		return false
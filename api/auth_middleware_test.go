@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"dnsplane/apiauth"
 )
 
 func TestSubtleStringEqual(t *testing.T) {
@@ -39,33 +41,25 @@ func TestApiAuthExempt(t *testing.T) {
 	}
 }
 
-func TestApiRequestAuthorized(t *testing.T) {
-	want := "secret-token"
-	{
-		r := httptest.NewRequest(http.MethodGet, "/", nil)
-		r.Header.Set("Authorization", "Bearer "+want)
-		if !apiRequestAuthorized(r, want) {
-			t.Fatal("Bearer")
-		}
+func TestRequestSecret(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "bearer  abc ")
+	if got := requestSecret(r); got != "abc" {
+		t.Fatalf("bearer: %q", got)
 	}
-	{
-		r := httptest.NewRequest(http.MethodGet, "/", nil)
-		r.Header.Set("X-API-Token", want)
-		if !apiRequestAuthorized(r, want) {
-			t.Fatal("X-API-Token")
-		}
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-API-Token", "xyz")
+	if got := requestSecret(r); got != "xyz" {
+		t.Fatalf("X-API-Token: %q", got)
 	}
-	{
-		r := httptest.NewRequest(http.MethodGet, "/", nil)
-		if apiRequestAuthorized(r, want) {
-			t.Fatal("missing auth")
-		}
+}
+
+func TestAPIPrincipalAllows(t *testing.T) {
+	p := apiPrincipal{name: "monitoring", scopes: []string{apiauth.ScopeStatsRead}}
+	if !p.allows(apiauth.ScopeStatsRead) || p.allows(apiauth.ScopeRecordsWrite) {
+		t.Fatalf("scoped principal: %+v", p)
 	}
-	{
-		r := httptest.NewRequest(http.MethodGet, "/", nil)
-		r.Header.Set("Authorization", "Bearer wrong")
-		if apiRequestAuthorized(r, want) {
-			t.Fatal("wrong token")
-		}
+	if !(apiPrincipal{name: defaultTokenName, all: true}).allows(apiauth.ScopeClusterAdmin) {
+		t.Fatal("api_auth_token must allow every scope")
 	}
 }
@@ -0,0 +1,108 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"dnsplane/acl"
+	"dnsplane/config"
+	"dnsplane/data"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type aclGroupRequest struct {
+	Entries []string `json:"entries"`
+}
+
+func getClientACLHandler(w http.ResponseWriter, r *http.Request) {
+	st := data.GetInstance().GetResolverSettings()
+	writeJSON(w, http.StatusOK, map[string]any{"client_acl": st.ClientACL})
+}
+
+// putClientACLHandler replaces the whole client_acl section (validated, then persisted to the config file).
+func putClientACLHandler(w http.ResponseWriter, r *http.Request) {
+	var cfg config.ClientACLConfig
+	if r.Body == nil || json.NewDecoder(r.Body).Decode(&cfg) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+		return
+	}
+	applyClientACL(w, cfg, "client acl updated")
+}
+
+func putClientACLGroupHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(chi.URLParam(r, "name"))
+	var req aclGroupRequest
+	if name == "" || r.Body == nil || json.NewDecoder(r.Body).Decode(&req) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+		return
+	}
+	cfg := cloneClientACL(data.GetInstance().GetResolverSettings().ClientACL)
+	if cfg.Groups == nil {
+		cfg.Groups = map[string][]string{}
+	}
+	cfg.Groups[name] = req.Entries
+	applyClientACL(w, cfg, "acl group saved")
+}
+
+func deleteClientACLGroupHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(chi.URLParam(r, "name"))
+	cfg := cloneClientACL(data.GetInstance().GetResolverSettings().ClientACL)
+	if _, ok := cfg.Groups[name]; !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "acl group not found"})
+		return
+	}
+	delete(cfg.Groups, name)
+	applyClientACL(w, cfg, "acl group removed")
+}
+
+// evaluateClientACLHandler reports the decision for ?ip= (for testing rules before clients hit them).
+func evaluateClientACLHandler(w http.ResponseWriter, r *http.Request) {
+	ip := strings.TrimSpace(r.URL.Query().Get("ip"))
+	if ip == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ip is required"})
+		return
+	}
+	a := data.GetInstance().ClientACL()
+	d := a.Evaluate(ip)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ip":          ip,
+		"decision":    d.String(),
+		"rule":        d.Rule,
+		"deny_action": a.DenyAction(),
+	})
+}
+
+func applyClientACL(w http.ResponseWriter, cfg config.ClientACLConfig, status string) {
+	if _, err := acl.Compile(cfg); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(cfg.DenyAction), acl.DenyActionDrop) {
+		cfg.DenyAction = acl.DenyActionRefuse
+	} else {
+		cfg.DenyAction = acl.DenyActionDrop
+	}
+	dnsData := data.GetInstance()
+	st := dnsData.GetResolverSettings()
+	st.ClientACL = cfg
+	dnsData.UpdateSettings(st)
+	writeJSON(w, http.StatusOK, map[string]any{"status": status, "client_acl": cfg})
+}
+
+func cloneClientACL(cfg config.ClientACLConfig) config.ClientACLConfig {
+	out := cfg
+	out.AllowQuery = append([]string(nil), cfg.AllowQuery...)
+	out.AllowRecursion = append([]string(nil), cfg.AllowRecursion...)
+	out.Deny = append([]string(nil), cfg.Deny...)
+	if cfg.Groups != nil {
+		out.Groups = make(map[string][]string, len(cfg.Groups))
+		for k, v := range cfg.Groups {
+			out.Groups[k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
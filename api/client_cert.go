@@ -0,0 +1,258 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package api
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dnsplane/apiauth"
+	"dnsplane/certmatch"
+	"dnsplane/config"
+)
+
+// apiClientCerts is set by Start when api_tls_client_ca is configured; nil means client certificates are off.
+var apiClientCerts atomic.Pointer[clientCertAuth]
+
+// clientCertAuth verifies API client certificates against the client CA bundle and, optionally, a CRL.
+type clientCertAuth struct {
+	pool    *x509.CertPool
+	cas     []*x509.Certificate
+	crlFile string
+
+	mu       sync.Mutex
+	crlMod   time.Time
+	crlSize  int64
+	revoked  map[string]struct{} // revokedKey of each listed certificate
+	crlNext  time.Time           // earliest NextUpdate, for the status display
+	crlError error               // last failed re-read; the previous list stays in use
+}
+
+// loadClientCertAuth reads the CA bundle and the CRL. Both must be valid at start.
+func loadClientCertAuth(caFile, crlFile string) (*clientCertAuth, error) {
+	raw, err := os.ReadFile(caFile) // #nosec G304 -- path from operator config
+	if err != nil {
+		return nil, fmt.Errorf("client CA: %w", err)
+	}
+	a := &clientCertAuth{pool: x509.NewCertPool(), crlFile: strings.TrimSpace(crlFile)}
+	for rest := raw; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("client CA %s: %w", caFile, err)
+		}
+		a.cas = append(a.cas, ca)
+		a.pool.AddCert(ca)
+	}
+	if len(a.cas) == 0 {
+		return nil, fmt.Errorf("client CA %s: no PEM certificates", caFile)
+	}
+	if a.crlFile != "" {
+		a.mu.Lock()
+		err := a.refreshCRLLocked()
+		a.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// tlsConfig asks for a client certificate without requiring one during the handshake, so load balancer
+// probes of /health and /ready still connect; apiAuthMiddleware refuses every other route without one.
+func (a *clientCertAuth) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		ClientAuth:       tls.VerifyClientCertIfGiven,
+		ClientCAs:        a.pool,
+		VerifyConnection: a.verifyConnection,
+	}
+}
+
+// verifyConnection ends handshakes that present a revoked certificate.
+func (a *clientCertAuth) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.VerifiedChains) == 0 {
+		return nil
+	}
+	chain := cs.VerifiedChains[0]
+	// Check the leaf and any intermediates; the CA itself is trusted by configuration.
+	return a.checkRevoked(chain[:max(len(chain)-1, 1)])
+}
+
+func (a *clientCertAuth) checkRevoked(certs []*x509.Certificate) error {
+	if a.crlFile == "" {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prev := a.crlError
+	if err := a.refreshCRLLocked(); err != nil && err != prev {
+		logClientCertWarn("client CRL not reloaded; keeping the previous list", "error", err)
+	}
+	for _, c := range certs {
+		if _, ok := a.revoked[revokedKey(c.RawIssuer, c.SerialNumber.Bytes())]; ok {
+			return fmt.Errorf("client certificate %q (serial %X) is revoked", c.Subject.CommonName, c.SerialNumber)
+		}
+	}
+	return nil
+}
+
+// refreshCRLLocked re-reads the CRL file when its size or modification time changed. On error the
+// previous list stays in use and the error is kept, so it is logged once.
+func (a *clientCertAuth) refreshCRLLocked() error {
+	fi, err := os.Stat(a.crlFile)
+	if err != nil {
+		return a.crlFailed(fmt.Errorf("client CRL: %w", err))
+	}
+	if a.revoked != nil && fi.ModTime().Equal(a.crlMod) && fi.Size() == a.crlSize {
+		return a.crlError
+	}
+	raw, err := os.ReadFile(a.crlFile) // #nosec G304 -- path from operator config
+	if err != nil {
+		return a.crlFailed(fmt.Errorf("client CRL: %w", err))
+	}
+	revoked, next, err := a.parseCRLs(raw)
+	if err != nil {
+		return a.crlFailed(fmt.Errorf("client CRL %s: %w", a.crlFile, err))
+	}
+	a.revoked, a.crlNext, a.crlError = revoked, next, nil
+	a.crlMod, a.crlSize = fi.ModTime(), fi.Size()
+	return nil
+}
+
+func (a *clientCertAuth) crlFailed(err error) error {
+	if a.crlError != nil && a.crlError.Error() == err.Error() {
+		return a.crlError
+	}
+	a.crlError = err
+	return err
+}
+
+// parseCRLs reads one DER CRL or any number of PEM "X509 CRL" blocks. Each must be signed by a client CA.
+func (a *clientCertAuth) parseCRLs(raw []byte) (map[string]struct{}, time.Time, error) {
+	var ders [][]byte
+	for rest := raw; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "X509 CRL" {
+			ders = append(ders, block.Bytes)
+		}
+	}
+	if len(ders) == 0 {
+		ders = [][]byte{raw}
+	}
+	revoked := make(map[string]struct{})
+	var next time.Time
+	for _, der := range ders {
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if !a.signedByCA(crl) {
+			return nil, time.Time{}, fmt.Errorf("CRL from %q is not signed by a client CA", crl.Issuer.String())
+		}
+		for _, e := range crl.RevokedCertificateEntries {
+			revoked[revokedKey(crl.RawIssuer, e.SerialNumber.Bytes())] = struct{}{}
+		}
+		if !crl.NextUpdate.IsZero() && (next.IsZero() || crl.NextUpdate.Before(next)) {
+			next = crl.NextUpdate
+		}
+	}
+	return revoked, next, nil
+}
+
+func (a *clientCertAuth) signedByCA(crl *x509.RevocationList) bool {
+	for _, ca := range a.cas {
+		if bytes.Equal(ca.RawSubject, crl.RawIssuer) && crl.CheckSignatureFrom(ca) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// status summarizes the revocation list for the dashboard.
+func (a *clientCertAuth) status() (revoked int, nextUpdate time.Time, err error) {
+	if a.crlFile == "" {
+		return 0, time.Time{}, nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.revoked), a.crlNext, a.crlError
+}
+
+func revokedKey(issuer, serial []byte) string {
+	return string(issuer) + "\x00" + string(serial)
+}
+
+// verifiedClientCert returns the certificate the TLS handshake verified, or nil.
+func verifiedClientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// clientCertPrincipal maps a verified certificate to the first role that matches it. Without roles every
+// certificate from the client CA holds every scope.
+func clientCertPrincipal(c *x509.Certificate, roles []config.APIClientRole) (apiPrincipal, bool) {
+	id := certmatch.Identity(c)
+	if len(roles) == 0 {
+		return apiPrincipal{name: "cert:" + id, all: true, cert: true}, true
+	}
+	for _, role := range roles {
+		if !certmatch.Match(c, role.Match) {
+			continue
+		}
+		scopes, err := apiauth.ParseScopes(strings.Join(role.Scopes, ","))
+		if err != nil {
+			continue // reported by Start
+		}
+		return apiPrincipal{name: "cert:" + role.Name + ":" + id, scopes: scopes, cert: true}, true
+	}
+	return apiPrincipal{name: "cert:" + id, cert: true}, false
+}
+
+// checkClientRoles reports roles that can never apply: no match patterns or bad scopes.
+func checkClientRoles(roles []config.APIClientRole) []error {
+	var errs []error
+	for i, role := range roles {
+		name := role.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(role.Match) == 0 {
+			errs = append(errs, fmt.Errorf("client certificate role %s has no match patterns", name))
+		}
+		if _, err := apiauth.ParseScopes(strings.Join(role.Scopes, ",")); err != nil {
+			errs = append(errs, fmt.Errorf("client certificate role %s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+func logClientCertWarn(msg string, keyValues ...any) {
+	apiServerMu.Lock()
+	logger := apiLogger
+	apiServerMu.Unlock()
+	logAPIWarn(logger, msg, keyValues...)
+}
@@ -0,0 +1,194 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dnsplane/apiauth"
+	"dnsplane/certmatch"
+	"dnsplane/config"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dnsplane test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return testCA{cert: cert, key: key}
+}
+
+func (ca testCA) issue(t *testing.T, serial int64, cn string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (ca testCA) writeCRL(t *testing.T, path string, number int64, serials ...int64) {
+	t.Helper()
+	var entries []x509.RevocationListEntry
+	for _, s := range serials {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(s), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Make the change visible even on file systems with coarse modification times.
+	later := time.Now().Add(time.Duration(number) * time.Second)
+	_ = os.Chtimes(path, later, later)
+}
+
+func TestClientCertAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	crlFile := filepath.Join(dir, "ca.crl")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ca.writeCRL(t, crlFile, 1, 3)
+
+	a, err := loadClientCertAuth(caFile, crlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles := []config.APIClientRole{{Name: "monitoring", Match: []string{"cn:prometheus"}, Scopes: []string{apiauth.ScopeStatsRead}}}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cert := verifiedClientCert(r)
+		if cert == nil {
+			_, _ = io.WriteString(w, "no certificate")
+			return
+		}
+		p, ok := clientCertPrincipal(cert, roles)
+		if !ok {
+			_, _ = io.WriteString(w, "no role for "+p.name)
+			return
+		}
+		if !p.allows(apiauth.ScopeStatsRead) || p.allows(apiauth.ScopeRecordsWrite) {
+			t.Errorf("scopes of %s: %v", p.name, p.scopes)
+		}
+		_, _ = io.WriteString(w, p.name)
+	}))
+	srv.TLS = a.tlsConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	get := func(cert *tls.Certificate) (string, error) {
+		tr := srv.Client().Transport.(*http.Transport).Clone()
+		tr.DisableKeepAlives = true
+		if cert != nil {
+			tr.TLSClientConfig.Certificates = []tls.Certificate{*cert}
+		}
+		resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	good := ca.issue(t, 2, "prometheus")
+	revoked := ca.issue(t, 3, "old-laptop")
+	other := ca.issue(t, 4, "backup")
+	if got, err := get(nil); err != nil || got != "no certificate" {
+		t.Fatalf("without certificate: %q %v", got, err)
+	}
+	if got, err := get(&good); err != nil || got != "cert:monitoring:prometheus" {
+		t.Fatalf("good certificate: %q %v", got, err)
+	}
+	if got, err := get(&other); err != nil || got != "no role for cert:backup" {
+		t.Fatalf("certificate without role: %q %v", got, err)
+	}
+	if got, err := get(&revoked); err == nil {
+		t.Fatalf("revoked certificate accepted: %q", got)
+	}
+
+	// A new CRL is picked up without a restart.
+	ca.writeCRL(t, crlFile, 2, 2, 3)
+	if got, err := get(&good); err == nil {
+		t.Fatalf("certificate revoked by the new CRL accepted: %q", got)
+	}
+
+	// A certificate from another CA fails the handshake.
+	stranger := newTestCA(t).issue(t, 2, "prometheus")
+	if got, err := get(&stranger); err == nil {
+		t.Fatalf("certificate from another CA accepted: %q", got)
+	}
+}
+
+func TestClientCertRoles(t *testing.T) {
+	ca := newTestCA(t)
+	leaf, err := x509.ParseCertificate(ca.issue(t, 2, "Prometheus").Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !certmatch.Match(leaf, []string{"cn:prometheus"}) || !certmatch.Match(leaf, []string{"dns:x", "*"}) {
+		t.Fatal("expected match")
+	}
+	if certmatch.Match(leaf, []string{"dns:prometheus", "email:prometheus", "prometheus"}) {
+		t.Fatal("unexpected match")
+	}
+	if errs := checkClientRoles([]config.APIClientRole{{Name: "bad", Scopes: []string{"records:delete"}}}); len(errs) != 2 {
+		t.Fatalf("checkClientRoles: %v", errs)
+	}
+}
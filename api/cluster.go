@@ -0,0 +1,35 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"net/http"
+
+	"dnsplane/cluster"
+)
+
+// clusterStatusHandler returns the cluster status the TUI `cluster status` shows.
+func clusterStatusHandler(w http.ResponseWriter, r *http.Request) {
+	mgr := cluster.GlobalManager()
+	if mgr == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "cluster manager not available"})
+		return
+	}
+	writeJSON(w, http.StatusOK, mgr.StatusSnapshot())
+}
+
+// clusterPullHandler pulls the records from every peer now, as `cluster pull` does, and returns the
+// status afterwards.
+func clusterPullHandler(w http.ResponseWriter, r *http.Request) {
+	mgr := cluster.GlobalManager()
+	if mgr == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "cluster manager not available"})
+		return
+	}
+	if !mgr.StatusSnapshot().Enabled {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "cluster is disabled"})
+		return
+	}
+	mgr.ForcePull()
+	writeJSON(w, http.StatusOK, mgr.StatusSnapshot())
+}
@@ -0,0 +1,60 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dnsplane/apiauth"
+	"dnsplane/cluster"
+	"dnsplane/data"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestClusterRoutes(t *testing.T) {
+	// Client certificates turn auth on without reading the settings.
+	apiClientCerts.Store(&clientCertAuth{})
+	defer apiClientCerts.Store(nil)
+	defer cluster.SetGlobalManager(nil)
+
+	router := chi.NewRouter()
+	RegisterDNSRoutes(router)
+	call := func(method, path string, scopes ...string) int {
+		req := httptest.NewRequest(method, path, nil)
+		p := apiPrincipal{name: "ops", scopes: scopes}
+		req = req.WithContext(context.WithValue(req.Context(), apiPrincipalKey{}, p))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	cluster.SetGlobalManager(nil)
+	if code := call(http.MethodGet, "/cluster/status", apiauth.ScopeClusterAdmin); code != http.StatusServiceUnavailable {
+		t.Errorf("status without a manager = %d, want 503", code)
+	}
+	if code := call(http.MethodPost, "/cluster/pull", apiauth.ScopeClusterAdmin); code != http.StatusServiceUnavailable {
+		t.Errorf("pull without a manager = %d, want 503", code)
+	}
+
+	cluster.SetGlobalManager(cluster.NewManager("", &data.DNSResolverData{}, nil))
+	if code := call(http.MethodGet, "/cluster/status", apiauth.ScopeClusterAdmin); code != http.StatusOK {
+		t.Errorf("status = %d, want 200", code)
+	}
+	if code := call(http.MethodPost, "/cluster/pull", apiauth.ScopeClusterAdmin); code != http.StatusConflict {
+		t.Errorf("pull with clustering disabled = %d, want 409", code)
+	}
+
+	for _, path := range []string{"/cluster/status", "/cluster/pull"} {
+		method := http.MethodGet
+		if path == "/cluster/pull" {
+			method = http.MethodPost
+		}
+		if code := call(method, path, apiauth.ScopeStatsRead, apiauth.ScopeConfigAdmin); code != http.StatusForbidden {
+			t.Errorf("%s %s without cluster:admin = %d, want 403", method, path, code)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"dnsplane/config"
+	"dnsplane/data"
+	"dnsplane/events"
+	"dnsplane/liveconfig"
+)
+
+// maxConfigPatchBytes bounds PATCH /config and POST /config/validate bodies.
+const maxConfigPatchBytes = 1 << 20
+
+// getConfigHandler returns the configuration in use, with secrets redacted.
+func getConfigHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, config.Redact(data.GetInstance().GetResolverSettings()))
+}
+
+// proposeConfig reads a merge patch from r and validates the configuration it yields. Secrets sent back as
+// config.Redacted keep their current value. A nil error with field errors means the patch was understood but
+// the result is invalid.
+func proposeConfig(r *http.Request) (liveconfig.Proposal, error) {
+	if r.Body == nil {
+		return liveconfig.Proposal{}, errors.New("missing body")
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxConfigPatchBytes+1))
+	if err != nil {
+		return liveconfig.Proposal{}, err
+	}
+	if len(body) > maxConfigPatchBytes {
+		return liveconfig.Proposal{}, fmt.Errorf("body larger than %d bytes", maxConfigPatchBytes)
+	}
+	old := data.GetInstance().GetResolverSettings()
+	updated, ferrs, err := config.MergePatch(old, body)
+	if err != nil {
+		return liveconfig.Proposal{}, err
+	}
+	if len(ferrs) > 0 {
+		return liveconfig.Proposal{Old: old, Errors: ferrs}, nil
+	}
+	config.KeepSecrets(&updated, old)
+	// Keys removed with null take their defaults, as when the file is loaded.
+	return liveconfig.Propose(old, updated), nil
+}
+
+// validateConfigHandler checks a merge patch without applying it and says what applying it would restart.
+func validateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := proposeConfig(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"valid":           len(p.Errors) == 0,
+		"errors":          nonNilErrors(p.Errors),
+		"changes":         nonNilChanges(p.Changes),
+		"restarts":        nonNilStrings(p.Restarts()),
+		"pending_restart": nonNilStrings(p.PendingRestart()),
+	})
+}
+
+// patchConfigHandler applies a merge patch: live settings at once, then the listener restarts they need. When a
+// listener does not come back, every change is rolled back. The API listener itself restarts after the response.
+func patchConfigHandler(w http.ResponseWriter, r *http.Request) {
+	liveconfig.Lock()
+	locked := true
+	defer func() {
+		if locked {
+			liveconfig.Unlock()
+		}
+	}()
+	p, err := proposeConfig(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(p.Errors) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid configuration", "errors": p.Errors})
+		return
+	}
+	if len(p.Changes) == 0 {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "unchanged", "changes": []config.Change{}})
+		return
+	}
+	restarts := p.Restarts()
+	now := slices.DeleteFunc(slices.Clone(restarts), func(l string) bool { return l == config.RestartAPI })
+	restarted, err := liveconfig.Apply(p, now)
+	if err != nil {
+		logAPIError(configLogger(), "config change rolled back", "error", err, "changes", p.Fields(), "by", apiTokenName(r))
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"error":       err.Error(),
+			"rolled_back": true,
+			"changes":     p.Changes,
+		})
+		return
+	}
+	data.SaveSettings(p.Updated)
+	if logger := configLogger(); logger != nil {
+		logger.Info("config changed", "changes", p.Fields(), "restarted", restarted, "by", apiTokenName(r), "remote", r.RemoteAddr)
+	}
+	events.Publish(events.ConfigReloaded, map[string]any{
+		"reason": "api", "changes": p.Fields(), "restarted": nonNilStrings(restarted), "pending_restart": nonNilStrings(p.PendingRestart()), "by": apiTokenName(r),
+	})
+	resp := map[string]any{
+		"status":          "applied",
+		"changes":         p.Changes,
+		"restarted":       nonNilStrings(restarted),
+		"pending_restart": nonNilStrings(p.PendingRestart()),
+		"config":          config.Redact(p.Updated),
+	}
+	if len(now) < len(restarts) {
+		resp["api_restart"] = "after this response"
+		writeJSON(w, http.StatusOK, resp)
+		// The restart waits for this request to finish, so it runs after the handler returns.
+		locked = false
+		go func() {
+			defer liveconfig.Unlock()
+			if err := liveconfig.RestartDeferred(p, restarted, config.RestartAPI); err != nil {
+				logAPIError(configLogger(), "API listener did not restart; config change rolled back", "error", err)
+				data.SaveSettings(p.Old)
+			}
+		}()
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func configLogger() *slog.Logger {
+	apiServerMu.Lock()
+	defer apiServerMu.Unlock()
+	return apiLogger
+}
+
+func nonNilErrors(errs []config.FieldError) []config.FieldError {
+	if errs == nil {
+		return []config.FieldError{}
+	}
+	return errs
+}
+
+func nonNilChanges(c []config.Change) []config.Change {
+	if c == nil {
+		return []config.Change{}
+	}
+	return c
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
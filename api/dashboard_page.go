@@ -81,7 +81,16 @@ func buildDashboardPayload() map[string]any {
 		upstreamHealth["unhealthy"] = bad
 	}
 
+	recordChecks := dnsData.RecordHealthStatuses()
+	recordsDown := 0
+	for i := range recordChecks {
+		if recordChecks[i].Unhealthy {
+			recordsDown++
+		}
+	}
+
 	summary := map[string]any{
+		"record_health":   map[string]any{"checks": len(recordChecks), "healthy": len(recordChecks) - recordsDown, "unhealthy": recordsDown},
 		"server_start":    stats.ServerStartTime.UTC().Format(time.RFC3339),
 		"uptime_seconds":  int64(time.Since(stats.ServerStartTime).Seconds()),
 		"cache_hit_ratio": cacheHitRatio,
@@ -853,6 +862,10 @@ const dashboardHTML = `<!DOCTYPE html>
       font-size: 0.85rem;
     }
     .fs-toolbar input[type="search"]::placeholder { color: var(--muted); }
+    #view-query.hidden, #view-query label.hidden { display: none; }
+    #view-query h2 { font-size: 1rem; margin: 1.25rem 0 0.5rem 0; }
+    #view-query .q-summary { font-size: 0.9rem; margin: 0 0 0.5rem 0; }
+    #view-query .q-wire { word-break: break-all; font-size: 0.78rem; color: var(--muted); }
     .fs-btn {
       background: var(--surface-hover);
       color: var(--text);
@@ -966,6 +979,7 @@ const dashboardHTML = `<!DOCTYPE html>
         <a href="/stats/dashboard" data-view="resolutions">Log</a>
         <a href="/stats/dashboard" data-view="fullstats">Historical</a>
         <a href="/stats/dashboard" data-view="perf">Tuning</a>
+        <a href="/stats/dashboard" data-view="query">Query</a>
         <a href="/version/page" data-embed="1">Version</a>
       </nav>
       <div class="nav-external" aria-label="Project links">
@@ -1054,6 +1068,7 @@ const dashboardHTML = `<!DOCTYPE html>
           <div class="metric-row metric-row--fluid">
             <div class="card"><h3>§Ic:forwarded§Forwarded</h3><div class="value" id="m-forwarded">—</div><div class="sub">upstream forwards</div></div>
             <div class="card"><h3>§Ic:upstreams§Upstreams</h3><div class="value" id="m-up-health">—</div><div class="sub">healthy / configured</div></div>
+            <div class="card" id="m-rec-health-card" style="display:none"><h3>§Ic:upstreams§Record checks</h3><div class="value" id="m-rec-health">—</div><div class="sub">healthy / checked values</div></div>
             <div class="card" id="cluster-wrap" style="display:none">
               <h3>§Ic:cluster§Cluster</h3>
               <div id="cluster-root" class="cluster-panel-inner"></div>
@@ -1217,6 +1232,34 @@ const dashboardHTML = `<!DOCTYPE html>
         <p id="dash-perf-err" class="err" style="display:none;margin:0 0 0.75rem 0;padding:0"></p>
         <div id="dash-perf-root"></div>
       </div>
+      <div id="view-query" class="hidden">
+        <h1>Query</h1>
+        <p class="muted-link" style="margin:-0.5rem 0 1rem 0">Resolve a name as a client would and trace each layer consulted. Nothing is counted or cached · <code>POST /dns/query</code></p>
+        <form id="q-form" class="fs-toolbar" autocomplete="off">
+          <label style="flex:1 1 14rem;min-width:10rem">Name <input type="search" id="q-name" placeholder="www.example.com" spellcheck="false" aria-label="Query name" required></label>
+          <label>Type <select id="q-type" aria-label="Query type">
+            <option>A</option><option>AAAA</option><option>CNAME</option><option>MX</option><option>TXT</option>
+            <option>NS</option><option>SOA</option><option>PTR</option><option>SRV</option><option>CAA</option>
+            <option>HTTPS</option><option>SVCB</option><option>DS</option><option>DNSKEY</option>
+          </select></label>
+          <label>Client IP <input type="search" id="q-client" placeholder="Your address" spellcheck="false" aria-label="Simulated client IP"></label>
+          <label><input type="checkbox" id="q-do"> DO</label>
+          <label>Path <select id="q-path" aria-label="Resolution path">
+            <option value="normal">Normal</option>
+            <option value="cache">Cache only</option>
+            <option value="local">Local only</option>
+            <option value="upstream">Upstream only</option>
+            <option value="custom">Specific upstream</option>
+          </select></label>
+          <label id="q-upstream-wrap" class="hidden">Upstream <input type="search" id="q-upstream" placeholder="a configured upstream: 9.9.9.9, https://…" spellcheck="false" aria-label="Upstream server"></label>
+          <label id="q-transport-wrap" class="hidden">Transport <select id="q-transport" aria-label="Upstream transport">
+            <option value="">udp</option><option value="tcp">tcp</option><option value="dot">dot</option>
+          </select></label>
+          <button type="submit" class="fs-btn" id="q-run">Run</button>
+        </form>
+        <p id="q-err" class="err" style="display:none;margin:0 0 0.75rem 0;padding:0"></p>
+        <div id="q-result"></div>
+      </div>
       <iframe id="view-embed" class="view-embed hidden" title="Embedded view" sandbox="allow-scripts allow-same-origin"></iframe>
     </main>
   </div>
@@ -1575,6 +1618,13 @@ const dashboardHTML = `<!DOCTYPE html>
       var thead = document.getElementById('res-thead');
       if (thead) thead.innerHTML = h;
     }
+    function resNotes(e) {
+      var n = [];
+      if (e.acl) n.push('acl: ' + e.acl);
+      if (e.policy_group) n.push('policy: ' + e.policy_group + (e.policy_rule ? ' (' + e.policy_rule + ')' : ''));
+      if (e.geo) n.push('geo: ' + e.geo);
+      return n.join(' · ');
+    }
     function renderResolutionsGrid() {
       renderResolutionsHead();
       const rows = resState.raw || [];
@@ -1596,7 +1646,8 @@ const dashboardHTML = `<!DOCTYPE html>
         h += '<td class="mono res-cell-filter" data-res-kind="ip" data-res-val="' + escAttr(enc(ip)) + '">' + esc(ip) + '</td>';
         h += '<td class="mono res-cell-filter" data-res-kind="qname" data-res-val="' + escAttr(enc(e.qname || '')) + '">' + esc(e.qname || '') + '</td>';
         h += '<td class="res-cell-filter" data-res-kind="qtype" data-res-val="' + escAttr(enc(e.qtype || '')) + '">' + esc(e.qtype || '') + '</td>';
-        h += '<td class="res-cell-filter" data-res-kind="outcome" data-res-val="' + escAttr(enc(e.outcome || '')) + '">' + esc(e.outcome || '') + '</td>';
+        const notes = resNotes(e);
+        h += '<td class="res-cell-filter" data-res-kind="outcome" data-res-val="' + escAttr(enc(e.outcome || '')) + '"' + (notes ? ' title="' + escAttr(notes) + '"' : '') + '>' + esc(e.outcome || '') + (notes ? '<div class="sub">' + esc(notes) + '</div>' : '') + '</td>';
         h += '<td class="mono res-cell-filter" data-res-kind="upstream" data-res-val="' + escAttr(enc(up)) + '">' + esc(up) + '</td>';
         h += '<td class="num">' + fmtMs(e.duration_ms) + '</td>';
         h += '<td class="mono res-cell-filter" data-res-kind="record" data-res-val="' + escAttr(enc(e.record || '')) + '">' + esc(e.record || '') + '</td>';
@@ -1905,6 +1956,7 @@ const dashboardHTML = `<!DOCTYPE html>
       document.getElementById('view-fullstats').classList.add('hidden');
       document.getElementById('view-resolutions').classList.add('hidden');
       document.getElementById('view-perf').classList.add('hidden');
+      document.getElementById('view-query').classList.add('hidden');
       const iframe = document.getElementById('view-embed');
       iframe.classList.add('hidden');
       iframe.src = 'about:blank';
@@ -1920,6 +1972,7 @@ const dashboardHTML = `<!DOCTYPE html>
       document.getElementById('view-fullstats').classList.add('hidden');
       document.getElementById('view-resolutions').classList.add('hidden');
       document.getElementById('view-perf').classList.add('hidden');
+      document.getElementById('view-query').classList.add('hidden');
       const iframe = document.getElementById('view-embed');
       iframe.classList.add('hidden');
       iframe.src = 'about:blank';
@@ -1935,6 +1988,7 @@ const dashboardHTML = `<!DOCTYPE html>
       document.getElementById('view-fullstats').classList.add('hidden');
       document.getElementById('view-resolutions').classList.remove('hidden');
       document.getElementById('view-perf').classList.add('hidden');
+      document.getElementById('view-query').classList.add('hidden');
       const iframe = document.getElementById('view-embed');
       iframe.classList.add('hidden');
       iframe.src = 'about:blank';
@@ -1949,6 +2003,7 @@ const dashboardHTML = `<!DOCTYPE html>
       document.getElementById('view-fullstats').classList.remove('hidden');
       document.getElementById('view-resolutions').classList.add('hidden');
       document.getElementById('view-perf').classList.add('hidden');
+      document.getElementById('view-query').classList.add('hidden');
       const iframe = document.getElementById('view-embed');
       iframe.classList.add('hidden');
       iframe.src = 'about:blank';
@@ -1965,10 +2020,26 @@ const dashboardHTML = `<!DOCTYPE html>
       document.getElementById('view-fullstats').classList.add('hidden');
       document.getElementById('view-resolutions').classList.add('hidden');
       document.getElementById('view-perf').classList.add('hidden');
+      document.getElementById('view-query').classList.add('hidden');
       const iframe = document.getElementById('view-embed');
       iframe.classList.remove('hidden');
       iframe.src = url;
     }
+    function showQuery(anchor) {
+      if (anchor) setActiveNav(anchor);
+      showShellUpdatesForEmbed(false);
+      stopAllDashboardLive();
+      document.getElementById('view-status').classList.add('hidden');
+      document.getElementById('view-dashboard').classList.add('hidden');
+      document.getElementById('view-fullstats').classList.add('hidden');
+      document.getElementById('view-resolutions').classList.add('hidden');
+      document.getElementById('view-perf').classList.add('hidden');
+      document.getElementById('view-query').classList.remove('hidden');
+      const iframe = document.getElementById('view-embed');
+      iframe.classList.add('hidden');
+      iframe.src = 'about:blank';
+      document.getElementById('q-name').focus();
+    }
     function showPerf(anchor) {
       if (anchor) setActiveNav(anchor);
       showShellUpdatesForEmbed(true);
@@ -1979,6 +2050,7 @@ const dashboardHTML = `<!DOCTYPE html>
       document.getElementById('view-fullstats').classList.add('hidden');
       document.getElementById('view-resolutions').classList.add('hidden');
       document.getElementById('view-perf').classList.remove('hidden');
+      document.getElementById('view-query').classList.add('hidden');
       const iframe = document.getElementById('view-embed');
       iframe.classList.add('hidden');
       iframe.src = 'about:blank';
@@ -2015,6 +2087,11 @@ const dashboardHTML = `<!DOCTYPE html>
         if (a.getAttribute('data-view') === 'perf') {
           e.preventDefault();
           showPerf(a);
+          return;
+        }
+        if (a.getAttribute('data-view') === 'query') {
+          e.preventDefault();
+          showQuery(a);
         }
       });
     });
@@ -2156,6 +2233,9 @@ const dashboardHTML = `<!DOCTYPE html>
           upTxt = String(uh.active || 0) + ' active';
         }
         document.getElementById('m-up-health').textContent = upTxt;
+        const rh = sum.record_health || {};
+        document.getElementById('m-rec-health-card').style.display = rh.checks > 0 ? '' : 'none';
+        document.getElementById('m-rec-health').textContent = rh.checks > 0 ? String(rh.healthy) + ' / ' + String(rh.checks) + ' OK' : '—';
         document.getElementById('m-answered').textContent = c.total_queries_answered != null ? c.total_queries_answered : '—';
         document.getElementById('m-forwarded').textContent = c.total_queries_forwarded != null ? c.total_queries_forwarded : '—';
         document.getElementById('m-perf-total').textContent = p.total != null ? p.total : '—';
@@ -2196,7 +2276,8 @@ const dashboardHTML = `<!DOCTYPE html>
           const up = e.upstream ? ' · ' + esc(e.upstream) : '';
           h += '<div class="log-item"><span class="log-time">' + esc(relTime(e.at)) + '</span><div class="top"><span class="dot ' + oc + '"></span><div style="flex:1;min-width:0">';
           h += '<div class="log-query">' + esc(e.qtype) + ' <strong>' + esc(e.qname) + '</strong> · ' + fmtMs(e.duration_ms) + ' ms</div>';
-          h += '<div class="log-meta">' + esc(e.outcome) + up + '</div>';
+          const notes = resNotes(e);
+          h += '<div class="log-meta">' + esc(e.outcome) + up + (notes ? ' · ' + esc(notes) : '') + '</div>';
           h += '<div class="log-record">' + esc(e.record) + '</div>';
           h += '</div></div></div>';
         }
@@ -2418,6 +2499,82 @@ const dashboardHTML = `<!DOCTYPE html>
       });
     }
     wireDashPerf();
+    function queryTable(head, rows) {
+      var h = '<div class="res-table-wrap"><table class="res-table"><thead><tr>';
+      head.forEach(function(c) { h += '<th' + (c.num ? ' class="num"' : '') + '>' + esc(c.label) + '</th>'; });
+      h += '</tr></thead><tbody>';
+      rows.forEach(function(r) {
+        h += '<tr>';
+        r.forEach(function(v, i) { h += '<td' + (head[i].num ? ' class="num"' : (head[i].mono ? ' class="mono"' : '')) + '>' + esc(v) + '</td>'; });
+        h += '</tr>';
+      });
+      return h + '</tbody></table></div>';
+    }
+    function renderQueryTrace(j) {
+      var t = j.trace || {};
+      var h = '<p class="q-summary"><strong>' + esc(j.rcode) + '</strong> · ' + esc(t.outcome || 'none');
+      if (t.upstream) h += ' via <code>' + esc(t.upstream) + '</code>';
+      h += ' · path ' + esc(t.path) + ' · ' + Number(t.duration_ms || 0).toFixed(2) + ' ms';
+      if (t.dnssec) h += ' · DNSSEC ' + esc(t.dnssec);
+      if (j.acl) h += ' · ACL ' + esc(j.acl);
+      h += ' · client ' + esc(j.client_ip) + '</p>';
+      h += '<h2>Steps</h2>';
+      h += queryTable([{label: 'ms', num: true}, {label: 'Name', mono: true}, {label: 'Layer'}, {label: 'Result'}, {label: 'Detail', mono: true}],
+        (t.steps || []).map(function(s) { return [Number(s.at_ms || 0).toFixed(2), s.name, s.layer, s.result, s.detail || '']; }));
+      if ((t.upstreams || []).length) {
+        h += '<h2>Upstreams</h2>';
+        h += queryTable([{label: 'Server', mono: true}, {label: 'Transport'}, {label: 'ms', num: true}, {label: 'Rcode'}, {label: 'Answers', num: true}, {label: 'Error'}, {label: 'Used'}],
+          t.upstreams.map(function(u) { return [u.server, u.transport, Number(u.duration_ms || 0).toFixed(2), u.rcode || '', u.answers, u.error || '', u.used ? 'yes' : '']; }));
+      }
+      var rrs = [];
+      (j.answer || []).forEach(function(r) { rrs.push(['answer', r]); });
+      (j.authority || []).forEach(function(r) { rrs.push(['authority', r]); });
+      (j.additional || []).forEach(function(r) { rrs.push(['additional', r]); });
+      h += '<h2>Answer</h2>';
+      h += rrs.length ? queryTable([{label: 'Section'}, {label: 'Record', mono: true}], rrs) : '<p class="muted-link">No records.</p>';
+      if (j.wire) h += '<p class="q-wire">Wire (base64): <code>' + esc(j.wire) + '</code></p>';
+      document.getElementById('q-result').innerHTML = h;
+    }
+    function wireQuery() {
+      var form = document.getElementById('q-form');
+      if (!form) return;
+      var pathSel = document.getElementById('q-path');
+      pathSel.addEventListener('change', function() {
+        var custom = pathSel.value === 'custom';
+        document.getElementById('q-upstream-wrap').classList.toggle('hidden', !custom);
+        document.getElementById('q-transport-wrap').classList.toggle('hidden', !custom);
+      });
+      form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+        var errEl = document.getElementById('q-err');
+        var btn = document.getElementById('q-run');
+        errEl.style.display = 'none';
+        var body = {
+          name: document.getElementById('q-name').value.trim(),
+          type: document.getElementById('q-type').value,
+          do: document.getElementById('q-do').checked,
+          client_ip: document.getElementById('q-client').value.trim(),
+          path: pathSel.value === 'custom' ? 'upstream' : pathSel.value
+        };
+        if (pathSel.value === 'custom') {
+          body.upstream = document.getElementById('q-upstream').value.trim();
+          body.transport = document.getElementById('q-transport').value;
+        }
+        btn.disabled = true;
+        try {
+          const r = await fetch('/dns/query', { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body) });
+          const j = await r.json();
+          if (!r.ok) throw new Error(j.error || j.message || r.statusText);
+          renderQueryTrace(j);
+        } catch (x) {
+          errEl.textContent = String(x);
+          errEl.style.display = 'block';
+        } finally {
+          btn.disabled = false;
+        }
+      });
+    }
+    wireQuery();
     initCharts();
     showStatus(null);
   </script>
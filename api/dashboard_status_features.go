@@ -8,6 +8,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"dnsplane/config"
 	"dnsplane/data"
@@ -117,14 +118,32 @@ func apiTLSFeature(cfg config.Config) dashboardStatusFeature {
 	if !ok {
 		return dashboardStatusFeature{Key: "api_tls", Label: "API HTTPS", Value: "Off (HTTP)", Variant: "neutral"}
 	}
-	return dashboardStatusFeature{Key: "api_tls", Label: "API HTTPS", Value: "On", Variant: "ok"}
+	a := apiClientCerts.Load()
+	if a == nil {
+		return dashboardStatusFeature{Key: "api_tls", Label: "API HTTPS", Value: "On", Variant: "ok"}
+	}
+	revoked, next, err := a.status()
+	switch {
+	case err != nil:
+		return dashboardStatusFeature{Key: "api_tls", Label: "API HTTPS", Value: "On · client certs · CRL error", Variant: "warn"}
+	case !next.IsZero() && time.Now().After(next):
+		return dashboardStatusFeature{Key: "api_tls", Label: "API HTTPS", Value: "On · client certs · CRL out of date", Variant: "warn"}
+	case a.crlFile != "":
+		return dashboardStatusFeature{Key: "api_tls", Label: "API HTTPS", Value: fmt.Sprintf("On · client certs · %d revoked", revoked), Variant: "ok"}
+	}
+	return dashboardStatusFeature{Key: "api_tls", Label: "API HTTPS", Value: "On · client certs", Variant: "ok"}
 }
 
 func apiAuthFeature(cfg config.Config) dashboardStatusFeature {
-	if strings.TrimSpace(cfg.APIAuthToken) == "" {
-		return dashboardStatusFeature{Key: "api_auth", Label: "API auth", Value: "Open", Variant: "neutral"}
-	}
-	return dashboardStatusFeature{Key: "api_auth", Label: "API auth", Value: "Bearer / X-API-Token", Variant: "ok"}
+	switch {
+	case apiClientCerts.Load() != nil:
+		return dashboardStatusFeature{Key: "api_auth", Label: "API auth", Value: "Client certificates", Variant: "ok"}
+	case data.GetInstance().APITokens().Enforced():
+		return dashboardStatusFeature{Key: "api_auth", Label: "API auth", Value: "Scoped tokens", Variant: "ok"}
+	case strings.TrimSpace(cfg.APIAuthToken) != "":
+		return dashboardStatusFeature{Key: "api_auth", Label: "API auth", Value: "Bearer / X-API-Token", Variant: "ok"}
+	}
+	return dashboardStatusFeature{Key: "api_auth", Label: "API auth", Value: "Open", Variant: "neutral"}
 }
 
 func pprofFeature(cfg config.Config) dashboardStatusFeature {
@@ -12,6 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"dnsplane/apiauth"
 	"dnsplane/data"
 
 	"github.com/gorilla/websocket"
@@ -61,23 +62,22 @@ func dashboardWSCheckOrigin(r *http.Request) bool {
 	return false
 }
 
-// dashboardWSAuthorized checks API token for the WebSocket handshake.
-// Browsers cannot set Authorization on WebSocket; allow access_token or token query param (use HTTPS in production).
-func dashboardWSAuthorized(r *http.Request, want string) bool {
-	want = strings.TrimSpace(want)
-	if want == "" {
-		return true
-	}
-	if apiRequestAuthorized(r, want) {
-		return true
-	}
-	q := r.URL.Query()
-	for _, key := range []string{"access_token", "token"} {
-		if got := strings.TrimSpace(q.Get(key)); got != "" && subtleStringEqual(got, want) {
-			return true
+// dashboardWSAuthorized checks the API token or client certificate for the WebSocket handshake like
+// apiAuthMiddleware, and requires stats:read. Browsers cannot set Authorization on WebSocket; allow
+// access_token or token query param (use HTTPS in production).
+func dashboardWSAuthorized(r *http.Request) (apiPrincipal, bool) {
+	secret := requestSecret(r)
+	if secret == "" {
+		q := r.URL.Query()
+		for _, key := range []string{"access_token", "token"} {
+			if got := strings.TrimSpace(q.Get(key)); got != "" {
+				secret = got
+				break
+			}
 		}
 	}
-	return false
+	p, err := authenticateRequest(r, secret)
+	return p, err == nil && p.allows(apiauth.ScopeStatsRead)
 }
 
 // dashboardWebSocketHandler pushes dashboard + resolutions JSON when payloads change (bounded tick).
@@ -90,12 +90,15 @@ func dashboardWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
-	tok := strings.TrimSpace(data.GetInstance().GetResolverSettings().APIAuthToken)
-	if tok != "" && !dashboardWSAuthorized(r, tok) {
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		w.WriteHeader(http.StatusUnauthorized)
-		_, _ = w.Write([]byte(`{"error":"unauthorized"}` + "\n"))
-		return
+	if apiAuthEnabled() {
+		p, ok := dashboardWSAuthorized(r)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized"}` + "\n"))
+			return
+		}
+		setRequestLogToken(r, p.name)
 	}
 	if atomic.LoadInt32(&dashboardWSClientCount) >= dashboardWSMaxClients {
 		http.Error(w, "too many dashboard websocket clients", http.StatusServiceUnavailable)
@@ -0,0 +1,131 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"dnsplane/events"
+)
+
+const (
+	eventStreamsMax      = 64
+	eventStreamKeepalive = 25 * time.Second
+	eventStreamWriteWait = 10 * time.Second
+	eventStreamRetryMs   = 3000
+)
+
+// eventResync is sent first when Last-Event-ID cannot be caught up from the replay buffer.
+const eventResync = "resync"
+
+var eventStreams atomic.Int32
+
+// eventsHandler streams operational events as server-sent events. A client that reconnects with
+// Last-Event-ID (or last_event_id) first gets the buffered events it missed, after a resync event when some
+// are gone; types selects event types.
+func eventsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var types []string
+	for _, t := range strings.Split(q.Get("types"), ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !events.ValidFilter(t) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown event type " + t})
+			return
+		}
+		types = append(types, t)
+	}
+	last := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if last == "" {
+		last = strings.TrimSpace(q.Get("last_event_id"))
+	}
+	var lastID uint64
+	if last != "" {
+		id, err := strconv.ParseUint(last, 10, 64)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Last-Event-ID must be an event id"})
+			return
+		}
+		lastID = id
+	}
+	if eventStreams.Add(1) > eventStreamsMax {
+		eventStreams.Add(-1)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "too many event streams"})
+		return
+	}
+	defer eventStreams.Add(-1)
+
+	apiServerMu.Lock()
+	closing := apiClosing
+	apiServerMu.Unlock()
+	replay, sub := events.Subscribe(lastID, types)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// The server's WriteTimeout would end the stream; each write gets its own deadline instead.
+	_ = rc.SetWriteDeadline(time.Time{})
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(s string) bool {
+		_ = rc.SetWriteDeadline(time.Now().Add(eventStreamWriteWait))
+		if _, err := fmt.Fprint(w, s); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	if !write(fmt.Sprintf("retry: %d\n\n", eventStreamRetryMs)) {
+		return
+	}
+	// Without an id line, so a client that drops now still asks from its own last ID.
+	if reason := sub.Missed(); reason != "" && !write(fmt.Sprintf("event: %s\ndata: {\"reason\":%q}\n\n", eventResync, reason)) {
+		return
+	}
+	for _, e := range replay {
+		if !write(sseEvent(e)) {
+			return
+		}
+	}
+	keepalive := time.NewTicker(eventStreamKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-closing:
+			return
+		case e, ok := <-sub.C():
+			if !ok {
+				// Fell behind; the client reconnects with Last-Event-ID and catches up from the buffer.
+				return
+			}
+			if !write(sseEvent(e)) {
+				return
+			}
+		case <-keepalive.C:
+			if !write(": keepalive\n\n") {
+				return
+			}
+		}
+	}
+}
+
+func sseEvent(e events.Event) string {
+	b, _ := json.Marshal(e)
+	return fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, b)
+}
+
+// webhooksStatusHandler lists the configured webhooks with their queue and last delivery.
+func webhooksStatusHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, events.Webhooks())
+}
@@ -0,0 +1,103 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"dnsplane/events"
+)
+
+func TestEventsHandler_BadFilter(t *testing.T) {
+	for _, target := range []string{"/events?types=upstream.down", "/events?types=dns.*"} {
+		rec := httptest.NewRecorder()
+		eventsHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "yesterday")
+	eventsHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad Last-Event-ID: status %d, want 400", rec.Code)
+	}
+}
+
+func TestEventsHandler_ReplayAndStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(eventsHandler))
+	defer srv.Close()
+
+	start := events.Publish(events.ConfigReloaded, nil)
+	missed := events.Publish(events.UpstreamUnhealthy, map[string]any{"server": "192.0.2.53:53"})
+	events.Publish(events.RecordsChanged, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"?types=upstream.*", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(start.ID, 10))
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type %q", ct)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	var ids []string
+	next := func() {
+		for sc.Scan() {
+			if id, ok := strings.CutPrefix(sc.Text(), "id: "); ok {
+				ids = append(ids, id)
+				return
+			}
+		}
+		t.Fatalf("stream ended: %v", sc.Err())
+	}
+	next()
+	live := events.Publish(events.UpstreamRecovered, map[string]any{"server": "192.0.2.53:53"})
+	next()
+	want := []string{strconv.FormatUint(missed.ID, 10), strconv.FormatUint(live.ID, 10)}
+	if ids[0] != want[0] || ids[1] != want[1] {
+		t.Fatalf("ids %v, want %v", ids, want)
+	}
+}
+
+func TestEventsHandler_ResyncAfterRestart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(eventsHandler))
+	defer srv.Close()
+	e := events.Publish(events.ConfigReloaded, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "1") // from a process long gone
+	client := srv.Client()
+	client.Timeout = 5 * time.Second
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	sc := bufio.NewScanner(resp.Body)
+	var lines []string
+	for sc.Scan() && !strings.HasPrefix(sc.Text(), "id: ") {
+		lines = append(lines, sc.Text())
+	}
+	frames := strings.Join(lines, "\n")
+	if !strings.Contains(frames, "event: resync\ndata: {\"reason\":\"restart\"}") {
+		t.Fatalf("no resync before the replay:\n%s", frames)
+	}
+	// The whole buffer is replayed, earlier tests' events included.
+	want := "id: " + strconv.FormatUint(e.ID, 10)
+	for sc.Text() != want {
+		if !sc.Scan() {
+			t.Fatalf("event %d not replayed: %v", e.ID, sc.Err())
+		}
+	}
+}
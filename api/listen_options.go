@@ -10,4 +10,8 @@ type ListenOptions struct {
 	TLSKeyFile     string
 	RateLimitRPS   float64
 	RateLimitBurst int
+	// ClientCAFile (PEM) requires client certificates from these CAs; needs TLSCertFile and TLSKeyFile.
+	// ClientCRLFile optionally lists revoked client certificates.
+	ClientCAFile  string
+	ClientCRLFile string
 }
@@ -0,0 +1,73 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"dnsplane/config"
+	"dnsplane/data"
+	"dnsplane/policy"
+)
+
+func getPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	st := data.GetInstance().GetResolverSettings()
+	writeJSON(w, http.StatusOK, map[string]any{"policy": st.Policy})
+}
+
+// putPolicyHandler replaces the whole policy section (compiled first so bad groups or category files are rejected).
+func putPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	var cfg config.PolicyConfig
+	if r.Body == nil || json.NewDecoder(r.Body).Decode(&cfg) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+		return
+	}
+	if _, err := policy.Compile(cfg); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	dnsData := data.GetInstance()
+	st := dnsData.GetResolverSettings()
+	st.Policy = cfg
+	dnsData.UpdateSettings(st)
+	writeJSON(w, http.StatusOK, map[string]any{"status": "policy updated", "policy": cfg})
+}
+
+// evaluatePolicyHandler reports the decision for ?ip=&name= (optional &mac= overrides the MAC lookup).
+func evaluatePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	ip := strings.TrimSpace(q.Get("ip"))
+	name := strings.TrimSpace(q.Get("name"))
+	if ip == "" || name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ip and name are required"})
+		return
+	}
+	dnsData := data.GetInstance()
+	macFor := dnsData.ClientMAC
+	if mac := strings.TrimSpace(q.Get("mac")); mac != "" {
+		macFor = func(string) string { return mac }
+	}
+	d := dnsData.PolicyEngine().Evaluate(ip, macFor, name)
+	action := "allow"
+	switch d.Action {
+	case policy.Block:
+		action = "block"
+	case policy.SafeSearch:
+		action = "safe_search"
+	}
+	upstreams := make([]string, 0, len(d.Upstreams))
+	for _, u := range d.Upstreams {
+		upstreams = append(upstreams, u.HealthKey())
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ip":        ip,
+		"name":      name,
+		"group":     d.Group,
+		"action":    action,
+		"rule":      d.Rule,
+		"target":    d.Target,
+		"upstreams": upstreams,
+	})
+}
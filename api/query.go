@@ -0,0 +1,256 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"dnsplane/acl"
+	"dnsplane/data"
+	"dnsplane/dnsservers"
+	"dnsplane/resolver"
+
+	"github.com/miekg/dns"
+)
+
+// apiResolver answers POST /dns/query; injected from main via SetResolver.
+var apiResolver *resolver.Resolver
+
+// SetResolver sets the resolver that POST /dns/query traces queries through.
+func SetResolver(r *resolver.Resolver) {
+	apiServerMu.Lock()
+	defer apiServerMu.Unlock()
+	apiResolver = r
+}
+
+// queryTestRequest is the body of POST /dns/query.
+type queryTestRequest struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	DO       bool   `json:"do"`
+	ClientIP string `json:"client_ip"`
+	// Path is normal, cache, local, or upstream (the -only suffix is accepted too).
+	Path string `json:"path"`
+	// Upstream asks this server alone: ip, ip:port, host:port, or an https:// DoH URL. It must be one of the
+	// configured upstreams (see configuredUpstreams).
+	Upstream  string `json:"upstream"`
+	Transport string `json:"transport"`
+}
+
+// queryTestResponse is the trace and the answer dnsplane would send the client.
+type queryTestResponse struct {
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	DO         bool           `json:"do"`
+	ClientIP   string         `json:"client_ip"`
+	ACL        string         `json:"acl,omitempty"`
+	Rcode      string         `json:"rcode"`
+	Answer     []string       `json:"answer"`
+	Authority  []string       `json:"authority,omitempty"`
+	Additional []string       `json:"additional,omitempty"`
+	Wire       string         `json:"wire"`
+	Trace      resolver.Trace `json:"trace"`
+}
+
+// parseQueryPath maps the path field to a resolver path.
+func parseQueryPath(s string) (resolver.Path, bool) {
+	switch strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "-only") {
+	case "", "normal":
+		return resolver.PathNormal, true
+	case "cache":
+		return resolver.PathCacheOnly, true
+	case "local":
+		return resolver.PathLocalOnly, true
+	case "upstream":
+		return resolver.PathUpstreamOnly, true
+	}
+	return "", false
+}
+
+// queryUpstreamEndpoint turns the upstream and transport fields into an endpoint, defaulting the port as
+// dnsservers.json does.
+func queryUpstreamEndpoint(upstream, transport string) (dnsservers.UpstreamEndpoint, bool) {
+	upstream = strings.TrimSpace(upstream)
+	transport = strings.ToLower(strings.TrimSpace(transport))
+	if strings.HasPrefix(strings.ToLower(upstream), "https://") {
+		if transport != "" && transport != "doh" {
+			return dnsservers.UpstreamEndpoint{}, false
+		}
+		return dnsservers.ServerToEndpoint(dnsservers.DNSServer{Address: upstream, Transport: "doh"}), true
+	}
+	switch transport {
+	case "", "udp", "tcp", "dot":
+	default:
+		return dnsservers.UpstreamEndpoint{}, false
+	}
+	host, port := upstream, ""
+	if h, p, err := net.SplitHostPort(upstream); err == nil {
+		host, port = h, p
+	}
+	if host == "" {
+		return dnsservers.UpstreamEndpoint{}, false
+	}
+	return dnsservers.ServerToEndpoint(dnsservers.DNSServer{Address: host, Port: port, Transport: transport}), true
+}
+
+// configuredUpstreams lists the endpoints dnsplane may query: the upstream servers with their fallbacks,
+// the global fallback, and the forward-zone servers.
+func configuredUpstreams() []dnsservers.UpstreamEndpoint {
+	d := data.GetInstance()
+	var out []dnsservers.UpstreamEndpoint
+	for _, s := range d.GetServers() {
+		out = append(out, dnsservers.ServerToEndpoint(s))
+		if fb, ok := dnsservers.ServerFallbackEndpoint(s); ok {
+			out = append(out, fb)
+		}
+	}
+	if st := d.GetResolverSettings(); strings.TrimSpace(st.FallbackServerIP) != "" {
+		out = append(out, dnsservers.FallbackEndpoint(st.FallbackServerIP, st.FallbackServerPort, st.FallbackServerTransport))
+	}
+	for _, z := range d.ForwardZones().Zones() {
+		out = append(out, z.Servers...)
+	}
+	return out
+}
+
+// matchConfiguredUpstream finds ep among the configured endpoints. Without an explicit transport, a plain
+// address matches a configured server on any transport, which is then used.
+func matchConfiguredUpstream(ep dnsservers.UpstreamEndpoint, transport string, configured []dnsservers.UpstreamEndpoint) (dnsservers.UpstreamEndpoint, bool) {
+	anyTransport := strings.TrimSpace(transport) == "" && ep.Transport != "doh"
+	for _, c := range configured {
+		if !strings.EqualFold(c.HealthKey(), ep.HealthKey()) {
+			continue
+		}
+		if anyTransport || strings.EqualFold(c.Transport, ep.Transport) {
+			return c, true
+		}
+	}
+	return dnsservers.UpstreamEndpoint{}, false
+}
+
+// queryTestHandler resolves one question through the resolver, as a client at client_ip would see it,
+// and returns the trace with the answer. Nothing is counted: statistics, the dashboard log, upstream
+// health, and the cache are left as they were.
+func queryTestHandler(w http.ResponseWriter, r *http.Request) {
+	var req queryTestRequest
+	if r.Body == nil || json.NewDecoder(r.Body).Decode(&req) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+	name = dns.Fqdn(name)
+	if _, ok := dns.IsDomainName(name); !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid name"})
+		return
+	}
+	qtypeName := strings.ToUpper(strings.TrimSpace(req.Type))
+	if qtypeName == "" {
+		qtypeName = "A"
+	}
+	qtype, ok := dns.StringToType[qtypeName]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown type " + req.Type})
+		return
+	}
+	path, ok := parseQueryPath(req.Path)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "path must be normal, cache, local, or upstream"})
+		return
+	}
+	var upstreams []dnsservers.UpstreamEndpoint
+	if strings.TrimSpace(req.Upstream) != "" {
+		ep, ok := queryUpstreamEndpoint(req.Upstream, req.Transport)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid upstream or transport"})
+			return
+		}
+		// Only configured upstreams: stats:read must not turn dnsplane into a relay to any address.
+		if ep, ok = matchConfiguredUpstream(ep, req.Transport, configuredUpstreams()); !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "upstream is not a configured upstream or forward-zone server"})
+			return
+		}
+		upstreams = []dnsservers.UpstreamEndpoint{ep}
+	}
+	cip := strings.TrimSpace(req.ClientIP)
+	if cip == "" {
+		cip = clientIP(r)
+	} else if net.ParseIP(cip) == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid client_ip"})
+		return
+	}
+
+	apiServerMu.Lock()
+	res := apiResolver
+	apiServerMu.Unlock()
+	if res == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "resolver not running"})
+		return
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(name, qtype)
+	msg.SetEdns0(dns.DefaultMsgSize, req.DO)
+	resp := new(dns.Msg)
+	resp.SetReply(msg)
+	out := queryTestResponse{Name: name, Type: qtypeName, DO: req.DO, ClientIP: cip}
+
+	ctx := resolver.ContextWithRequest(r.Context(), msg)
+	var trace resolver.Trace
+	denied := false
+	if a := data.GetInstance().ClientACL(); a.Enabled() {
+		d := a.Evaluate(cip)
+		out.ACL = d.String()
+		switch d.Action {
+		case acl.Deny:
+			denied = true
+			resp.SetRcode(msg, dns.RcodeRefused)
+			resolver.SetExtendedError(msg, resp, dns.ExtendedErrorCodeProhibited, "query not allowed")
+			trace = resolver.Trace{
+				Path:    path,
+				Steps:   []resolver.TraceStep{{Name: name, Layer: "acl", Result: "denied", Detail: d.Rule}},
+				Outcome: "refused",
+			}
+		case acl.LocalOnly:
+			ctx = resolver.ContextWithNoRecursion(ctx)
+		}
+		ctx = resolver.ContextWithQueryNotes(ctx, resolver.QueryNotes{ACL: d.String()})
+	}
+	if !denied {
+		ctx = resolver.ContextWithClientIP(ctx, cip)
+		trace = res.TraceQuery(ctx, msg.Question[0], path, upstreams, resp)
+	}
+	if trace.Steps == nil {
+		trace.Steps = []resolver.TraceStep{}
+	}
+	if trace.Upstreams == nil {
+		trace.Upstreams = []resolver.TraceUpstream{}
+	}
+	out.Trace = trace
+	out.Rcode = dns.RcodeToString[resp.Rcode]
+	out.Answer = rrStrings(resp.Answer)
+	out.Authority = rrStrings(resp.Ns)
+	out.Additional = rrStrings(resp.Extra)
+	if wire, err := resp.Pack(); err == nil {
+		out.Wire = base64.StdEncoding.EncodeToString(wire)
+	} else {
+		logAPIError(configLogger(), "dns query: pack answer", "name", name, "error", err)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// rrStrings renders records in zone-file form, one per entry.
+func rrStrings(rrs []dns.RR) []string {
+	out := make([]string, 0, len(rrs))
+	for _, rr := range rrs {
+		out = append(out, rr.String())
+	}
+	return out
+}
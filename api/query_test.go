@@ -0,0 +1,122 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dnsplane/apiauth"
+	"dnsplane/dnsservers"
+	"dnsplane/resolver"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestParseQueryPath(t *testing.T) {
+	for in, want := range map[string]resolver.Path{
+		"":              resolver.PathNormal,
+		"normal":        resolver.PathNormal,
+		"cache-only":    resolver.PathCacheOnly,
+		"Local":         resolver.PathLocalOnly,
+		"upstream-only": resolver.PathUpstreamOnly,
+	} {
+		if got, ok := parseQueryPath(in); !ok || got != want {
+			t.Errorf("parseQueryPath(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := parseQueryPath("recursive"); ok {
+		t.Error("parseQueryPath(recursive) accepted")
+	}
+}
+
+func TestQueryUpstreamEndpoint(t *testing.T) {
+	cases := []struct {
+		upstream, transport string
+		want                dnsservers.UpstreamEndpoint
+	}{
+		{"9.9.9.9", "", dnsservers.UpstreamEndpoint{Addr: "9.9.9.9:53", Transport: "udp"}},
+		{"9.9.9.9:5353", "tcp", dnsservers.UpstreamEndpoint{Addr: "9.9.9.9:5353", Transport: "tcp"}},
+		{"1.1.1.1", "dot", dnsservers.UpstreamEndpoint{Addr: "1.1.1.1:853", Transport: "dot"}},
+		{"[2620:fe::fe]:53", "", dnsservers.UpstreamEndpoint{Addr: "[2620:fe::fe]:53", Transport: "udp"}},
+		{"https://dns.example/dns-query", "", dnsservers.UpstreamEndpoint{Addr: "https://dns.example/dns-query", Transport: "doh"}},
+	}
+	for _, c := range cases {
+		if got, ok := queryUpstreamEndpoint(c.upstream, c.transport); !ok || got != c.want {
+			t.Errorf("queryUpstreamEndpoint(%q, %q) = %+v, %v; want %+v", c.upstream, c.transport, got, ok, c.want)
+		}
+	}
+	if _, ok := queryUpstreamEndpoint("9.9.9.9", "quic"); ok {
+		t.Error("unknown transport accepted")
+	}
+	if _, ok := queryUpstreamEndpoint("https://dns.example/dns-query", "udp"); ok {
+		t.Error("DoH URL with udp transport accepted")
+	}
+}
+
+func TestMatchConfiguredUpstream(t *testing.T) {
+	configured := []dnsservers.UpstreamEndpoint{
+		{Addr: "9.9.9.9:53", Transport: "udp"},
+		{Addr: "1.1.1.1:853", Transport: "dot"},
+		{Addr: "https://dns.example/dns-query", Transport: "doh"},
+	}
+	for _, c := range []struct {
+		upstream, transport string
+		want                dnsservers.UpstreamEndpoint
+		ok                  bool
+	}{
+		{"9.9.9.9", "", configured[0], true},
+		{"9.9.9.9", "tcp", dnsservers.UpstreamEndpoint{}, false},
+		{"1.1.1.1:853", "", configured[1], true},
+		{"1.1.1.1", "dot", configured[1], true},
+		{"https://dns.example/dns-query", "", configured[2], true},
+		{"https://evil.example/dns-query", "", dnsservers.UpstreamEndpoint{}, false},
+		{"192.0.2.1:53", "", dnsservers.UpstreamEndpoint{}, false},
+	} {
+		ep, _ := queryUpstreamEndpoint(c.upstream, c.transport)
+		if got, ok := matchConfiguredUpstream(ep, c.transport, configured); ok != c.ok || got != c.want {
+			t.Errorf("matchConfiguredUpstream(%q, %q) = %+v, %v; want %+v, %v", c.upstream, c.transport, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestQueryTestHandler_BadInput(t *testing.T) {
+	for _, body := range []string{
+		`{`,
+		`{"name":""}`,
+		`{"name":"example.com","type":"NOPE"}`,
+		`{"name":"example.com","path":"everywhere"}`,
+		`{"name":"example.com","client_ip":"not-an-ip"}`,
+		`{"name":"example.com","upstream":"9.9.9.9","transport":"quic"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/dns/query", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		queryTestHandler(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestQueryRouteScope(t *testing.T) {
+	apiClientCerts.Store(&clientCertAuth{})
+	defer apiClientCerts.Store(nil)
+	router := chi.NewRouter()
+	RegisterDNSRoutes(router)
+	for scope, want := range map[string]int{
+		apiauth.ScopeStatsRead:   http.StatusForbidden,
+		apiauth.ScopeRecordsRead: http.StatusForbidden,
+		apiauth.ScopeDNSQuery:    http.StatusBadRequest, // past the scope check, stopped by the body
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/dns/query", strings.NewReader(`{`))
+		req = req.WithContext(context.WithValue(req.Context(), apiPrincipalKey{}, apiPrincipal{name: "t", scopes: []string{scope}}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", scope, rec.Code, want)
+		}
+	}
+}
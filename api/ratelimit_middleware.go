@@ -6,13 +6,31 @@ package api
 import (
 	"net"
 	"net/http"
+	"sync/atomic"
 
 	"dnsplane/ratelimit"
 )
 
-func rateLimitMiddleware(lim *ratelimit.PerIP) func(http.Handler) http.Handler {
+// apiRateLimiter is the per-IP limiter of the running API; nil means unlimited.
+var apiRateLimiter atomic.Pointer[ratelimit.PerIP]
+
+// SetRateLimit replaces the per-IP request limit of the API (rps 0 turns it off; burst 0 means 20). Clients start
+// with a full bucket again.
+func SetRateLimit(rps float64, burst int) {
+	if rps <= 0 {
+		apiRateLimiter.Store(nil)
+		return
+	}
+	if burst <= 0 {
+		burst = 20
+	}
+	apiRateLimiter.Store(ratelimit.NewPerIP(rps, burst))
+}
+
+func rateLimitMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lim := apiRateLimiter.Load()
 			if lim == nil || lim.Allow(clientIP(r)) {
 				next.ServeHTTP(w, r)
 				return
@@ -0,0 +1,200 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"dnsplane/data"
+	"dnsplane/dnsrecords"
+)
+
+const (
+	maxRecordBatchBytes = 16 << 20
+	maxRecordBatchOps   = 1000
+)
+
+// recordBatchOp is one operation of POST /dns/records/batch. The record fields are those of POST and PUT
+// /dns/records; a delete needs only id, or name with optional type and value.
+type recordBatchOp struct {
+	Op string `json:"op"`
+	AddRecordRequest
+	// IfMatch is the ETag the update or delete expects of its record, as read before the batch.
+	IfMatch string `json:"if_match,omitempty"`
+}
+
+type recordBatchRequest struct {
+	Operations []recordBatchOp `json:"operations"`
+}
+
+// recordBatchResult reports one operation: "ok" or "failed". When any operation fails, none is applied.
+type recordBatchResult struct {
+	Index    int      `json:"index"`
+	Op       string   `json:"op"`
+	Status   string   `json:"status"`
+	ID       string   `json:"id,omitempty"`
+	ETag     string   `json:"etag,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Messages []string `json:"messages,omitempty"`
+}
+
+// errBatchFailed aborts a batch in which an operation failed; the results say which.
+var errBatchFailed = errors.New("batch not applied: an operation failed")
+
+// errBatchStale aborts a batch in which an operation's if_match failed.
+var errBatchStale = fmt.Errorf("batch not applied: %w", errPreconditionFailed)
+
+// recordsBatchHandler applies a list of add, update, and delete operations as one write: each runs on the
+// result of the ones before it, and if any fails nothing is stored. The batch is saved, journaled, and
+// pushed to cluster peers once. If-Match on the request checks the record set; if_match on an operation
+// checks its record.
+func recordsBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+		return
+	}
+	var req recordBatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRecordBatchBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxRecordBatchOps {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("a batch takes 1 to %d operations", maxRecordBatchOps)})
+		return
+	}
+
+	o := apiOrigin(r)
+	o.Note = fmt.Sprintf("batch of %d", len(req.Operations))
+	var results []recordBatchResult
+	rev, err := data.GetInstance().ModifyRecords(o, func(records []dnsrecords.DNSRecord, rev uint64) ([]dnsrecords.DNSRecord, error) {
+		if err := checkIfMatch(r, rev, nil); err != nil {
+			return nil, err
+		}
+		var err error
+		records, results, err = applyRecordBatch(records, req.Operations)
+		return records, err
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, errBatchStale):
+			status = http.StatusPreconditionFailed
+			w.Header().Set("ETag", recordsETag(rev))
+		case !errors.Is(err, errBatchFailed):
+			writeRecordsError(w, err)
+			return
+		}
+		for i := range results {
+			results[i].ID, results[i].ETag = "", ""
+		}
+		writeJSON(w, status, map[string]any{"status": "rejected", "error": err.Error(), "revision": rev, "results": results})
+		return
+	}
+	w.Header().Set("ETag", recordsETag(rev))
+	writeJSON(w, http.StatusOK, map[string]any{"status": "applied", "revision": rev, "results": results})
+}
+
+// applyRecordBatch runs ops in order on records. An operation that fails leaves records as they were (the
+// dnsrecords calls check everything before changing the slice) and the rest still run, so every failure
+// is reported. The error is errBatchStale when an if_match failed, else errBatchFailed when anything did.
+func applyRecordBatch(records []dnsrecords.DNSRecord, ops []recordBatchOp) ([]dnsrecords.DNSRecord, []recordBatchResult, error) {
+	// if_match refers to the records as the client read them, before the batch.
+	before := slices.Clone(records)
+	results := make([]recordBatchResult, len(ops))
+	var failed error
+	for i, op := range ops {
+		res := &results[i]
+		res.Index, res.Op = i, strings.ToLower(strings.TrimSpace(op.Op))
+		updated, rec, messages, err := applyRecordOp(records, before, res.Op, op)
+		res.Messages = extractRecordMessages(messages)
+		if err != nil {
+			res.Status, res.Error = "failed", err.Error()
+			if errors.Is(err, errPreconditionFailed) {
+				failed = errBatchStale
+			} else if failed == nil {
+				failed = errBatchFailed
+			}
+			continue
+		}
+		records = updated
+		res.Status = "ok"
+		if rec != nil {
+			res.ID, res.ETag = rec.ID, recordETag(*rec)
+		}
+	}
+	return records, results, failed
+}
+
+// applyRecordOp runs one batch operation through the same dnsrecords calls as the single-record routes.
+// It returns the new records and, for add and update, the record written.
+func applyRecordOp(records, before []dnsrecords.DNSRecord, kind string, op recordBatchOp) ([]dnsrecords.DNSRecord, *dnsrecords.DNSRecord, []dnsrecords.Message, error) {
+	id := strings.TrimSpace(op.ID)
+	record := op.toDNSRecord()
+	if strings.TrimSpace(op.IfMatch) != "" {
+		if kind == "add" || (kind == "delete" && id == "") {
+			return nil, nil, nil, errors.New("if_match needs an update, or a delete by id")
+		}
+		if target := findRecordToUpdate(before, id, record); target == nil || !ifMatch(op.IfMatch, recordETag(*target)) {
+			return nil, nil, nil, errPreconditionFailed
+		}
+	}
+	switch kind {
+	case "add":
+		if record.Name == "" || record.Type == "" || record.Value == "" {
+			return nil, nil, nil, errors.New("name, type, and value are required")
+		}
+		if err := op.applyMetadata(&record, nil); err != nil {
+			return nil, nil, nil, err
+		}
+		updated, messages, err := dnsrecords.AddRecord(record, records, false)
+		if err != nil {
+			return nil, nil, messages, err
+		}
+		if len(updated) == len(records) {
+			return nil, nil, messages, errors.New("record already exists")
+		}
+		return updated, &updated[len(updated)-1], messages, nil
+	case "update":
+		if record.Name == "" || record.Type == "" || record.Value == "" {
+			return nil, nil, nil, errors.New("name, type, and value are required")
+		}
+		if err := op.applyMetadata(&record, findRecordToUpdate(records, id, record)); err != nil {
+			return nil, nil, nil, err
+		}
+		var updated []dnsrecords.DNSRecord
+		var messages []dnsrecords.Message
+		var err error
+		if id != "" {
+			updated, messages, err = dnsrecords.UpdateRecordByID(id, record, records)
+		} else {
+			updated, messages, err = dnsrecords.AddRecord(record, records, true)
+		}
+		if err != nil {
+			return nil, nil, messages, err
+		}
+		return updated, findRecordToUpdate(updated, id, record), messages, nil
+	case "delete":
+		if id != "" {
+			updated, messages, err := dnsrecords.RemoveRecordByID(id, records)
+			return updated, nil, messages, err
+		}
+		if record.Name == "" {
+			return nil, nil, nil, errors.New("id or name is required")
+		}
+		args := []string{record.Name}
+		if record.Type != "" && record.Value != "" {
+			args = append(args, record.Type, record.Value)
+		} else if record.Value != "" {
+			args = append(args, record.Value)
+		}
+		updated, messages, err := dnsrecords.Remove(args, records)
+		return updated, nil, messages, err
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown op %q (want add, update, or delete)", op.Op)
+	}
+}
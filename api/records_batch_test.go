@@ -0,0 +1,86 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"dnsplane/dnsrecords"
+)
+
+func TestIfMatch(t *testing.T) {
+	for _, c := range []struct {
+		header string
+		want   bool
+	}{
+		{"", true},
+		{"*", true},
+		{`"12"`, true},
+		{`"11", "12"`, true},
+		{`12`, true},
+		{`"11"`, false},
+		{`W/"12"`, false},
+	} {
+		if got := ifMatch(c.header, `"12"`, `"abc"`); got != c.want {
+			t.Errorf("ifMatch(%q) = %v, want %v", c.header, got, c.want)
+		}
+	}
+}
+
+func batchRecords() []dnsrecords.DNSRecord {
+	return []dnsrecords.DNSRecord{
+		{ID: "a", Name: "www.example.com", Type: "A", Value: "192.0.2.1", TTL: 300},
+		{ID: "b", Name: "mail.example.com", Type: "A", Value: "192.0.2.2", TTL: 300},
+	}
+}
+
+func TestApplyRecordBatch(t *testing.T) {
+	records := batchRecords()
+	ops := []recordBatchOp{
+		{Op: "add", AddRecordRequest: AddRecordRequest{Name: "ftp.example.com", Type: "CNAME", Value: "www.example.com."}},
+		{Op: "update", AddRecordRequest: AddRecordRequest{ID: "a", Name: "www.example.com", Type: "A", Value: "192.0.2.10"}, IfMatch: recordETag(records[0])},
+		{Op: "delete", AddRecordRequest: AddRecordRequest{ID: "b"}},
+	}
+	got, results, err := applyRecordBatch(records, ops)
+	if err != nil {
+		t.Fatalf("batch failed: %v %+v", err, results)
+	}
+	if len(got) != 2 || got[0].Value != "192.0.2.10" || got[1].Name != "ftp.example.com" {
+		t.Fatalf("records = %+v", got)
+	}
+	if results[0].ID != got[1].ID || results[1].ETag != recordETag(got[0]) || results[2].Status != "ok" {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestApplyRecordBatch_AllOrNothing(t *testing.T) {
+	records := batchRecords()
+	stale := recordETag(dnsrecords.DNSRecord{ID: "b", Name: "mail.example.com", Type: "A", Value: "192.0.2.99"})
+	ops := []recordBatchOp{
+		{Op: "delete", AddRecordRequest: AddRecordRequest{ID: "a"}},
+		{Op: "add", AddRecordRequest: AddRecordRequest{Name: "bad.example.com", Type: "A", Value: "not-an-ip"}},
+		{Op: "delete", AddRecordRequest: AddRecordRequest{ID: "b"}, IfMatch: stale},
+		{Op: "rename"},
+	}
+	_, results, err := applyRecordBatch(records, ops)
+	if !errors.Is(err, errBatchStale) {
+		t.Fatalf("err = %v, want errBatchStale", err)
+	}
+	want := []string{"ok", "failed", "failed", "failed"}
+	for i, res := range results {
+		if res.Status != want[i] {
+			t.Errorf("op %d: status %q (%s), want %q", i, res.Status, res.Error, want[i])
+		}
+	}
+
+	// Without the stale if_match, a plain failure.
+	if _, _, err := applyRecordBatch(batchRecords(), ops[:2]); !errors.Is(err, errBatchFailed) {
+		t.Errorf("err = %v, want errBatchFailed", err)
+	}
+	if _, _, err := applyRecordBatch(batchRecords(), []recordBatchOp{
+		{Op: "add", AddRecordRequest: AddRecordRequest{Name: "www.example.com", Type: "A", Value: "192.0.2.1"}},
+	}); !errors.Is(err, errBatchFailed) {
+		t.Errorf("adding an existing record: err = %v", err)
+	}
+}
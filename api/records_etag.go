@@ -0,0 +1,122 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"dnsplane/data"
+	"dnsplane/dnsrecords"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// errPreconditionFailed is a write refused because If-Match named a version that is no longer current.
+var errPreconditionFailed = errors.New("precondition failed: the records changed since they were read")
+
+// recordWriteError is a record write that failed before anything was stored, with the status to answer.
+type recordWriteError struct {
+	status   int
+	err      error
+	messages []dnsrecords.Message
+}
+
+func (e *recordWriteError) Error() string { return e.err.Error() }
+
+func (e *recordWriteError) Unwrap() error { return e.err }
+
+// recordOpFailed wraps an error from a dnsrecords operation: 400 for invalid input, 500 otherwise.
+func recordOpFailed(err error, messages []dnsrecords.Message) error {
+	status := http.StatusBadRequest
+	if !errors.Is(err, dnsrecords.ErrInvalidArgs) {
+		status = http.StatusInternalServerError
+	}
+	return &recordWriteError{status: status, err: err, messages: messages}
+}
+
+// recordsETag is the ETag of the whole record set at revision rev.
+func recordsETag(rev uint64) string {
+	return `"` + strconv.FormatUint(rev, 10) + `"`
+}
+
+// recordETag is the ETag of one record: a hash of everything stored for it.
+func recordETag(rec dnsrecords.DNSRecord) string {
+	b, _ := json.Marshal(rec)
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// ifMatch reports whether an If-Match value lets a write through: empty, "*", or one of its ETags equal
+// to one of current. Weak ETags never match; unquoted ones are taken as quoted.
+func ifMatch(header string, current ...string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if !strings.HasPrefix(tag, `"`) {
+			tag = `"` + tag + `"`
+		}
+		if slices.Contains(current, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch checks the request's If-Match against the record set at rev and, when the write has a
+// single target, that record.
+func checkIfMatch(r *http.Request, rev uint64, target *dnsrecords.DNSRecord) error {
+	current := []string{recordsETag(rev)}
+	if target != nil {
+		current = append(current, recordETag(*target))
+	}
+	if !ifMatch(r.Header.Get("If-Match"), current...) {
+		return &recordWriteError{status: http.StatusPreconditionFailed, err: errPreconditionFailed}
+	}
+	return nil
+}
+
+// writeRecordsError answers a failed records write. A failed If-Match gets 412 with the current
+// revision; writes the cluster settings or a read-only records source refuse get 403.
+func writeRecordsError(w http.ResponseWriter, err error) {
+	var we *recordWriteError
+	if !errors.As(err, &we) {
+		writeJSON(w, http.StatusForbidden, map[string]any{"error": err.Error()})
+		return
+	}
+	if errors.Is(we.err, errPreconditionFailed) {
+		rev := data.RecordsRevision()
+		w.Header().Set("ETag", recordsETag(rev))
+		writeJSON(w, we.status, map[string]any{"error": we.err.Error(), "revision": rev})
+		return
+	}
+	writeJSON(w, we.status, map[string]any{"error": we.err.Error(), "messages": extractRecordMessages(we.messages)})
+}
+
+// getRecordHandler returns one record by id with its ETag, for a later PUT or DELETE with If-Match.
+func getRecordHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	// The revision is read first, so a write in between makes it older than the record, never newer.
+	rev := data.RecordsRevision()
+	records := data.GetInstance().GetRecords()
+	i := slices.IndexFunc(records, func(rec dnsrecords.DNSRecord) bool { return rec.ID == id })
+	if id == "" || i < 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no record with that id"})
+		return
+	}
+	etag := recordETag(records[i])
+	w.Header().Set("ETag", etag)
+	writeJSON(w, http.StatusOK, map[string]any{"record": records[i], "etag": etag, "revision": rev})
+}
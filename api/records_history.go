@@ -0,0 +1,117 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dnsplane/data"
+	"dnsplane/journal"
+)
+
+// apiOrigin names the caller of a record write in the record history.
+func apiOrigin(r *http.Request) journal.Origin {
+	return journal.Origin{Source: journal.SourceAPI, Actor: apiTokenName(r), Addr: r.RemoteAddr}
+}
+
+func recordJournal(w http.ResponseWriter) *journal.Journal {
+	j := data.GetInstance().RecordJournal()
+	if j == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": journal.ErrDisabled.Error()})
+	}
+	return j
+}
+
+// recordHistoryHandler lists history entries, newest first (?id=, ?name=, ?zone=, ?limit=, default 100).
+func recordHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	j := recordJournal(w)
+	if j == nil {
+		return
+	}
+	q := r.URL.Query()
+	f := journal.Filter{ID: q.Get("id"), Name: q.Get("name"), Zone: q.Get("zone"), Limit: 100}
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		f.Limit = n
+	}
+	entries, err := j.Entries(f)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []journal.Entry{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"head": j.Head(), "entries": entries})
+}
+
+// recordHistoryDiffHandler compares two points in the history (?from=, ?to=; a revision or RFC 3339
+// time, to defaults to the newest revision).
+func recordHistoryDiffHandler(w http.ResponseWriter, r *http.Request) {
+	j := recordJournal(w)
+	if j == nil {
+		return
+	}
+	q := r.URL.Query()
+	if strings.TrimSpace(q.Get("from")) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from is required"})
+		return
+	}
+	from, err := j.Resolve(q.Get("from"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	to, err := j.Resolve(q.Get("to"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	changes, err := j.DiffRevs(from, to)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	lines := journal.DiffLines(changes)
+	if changes == nil {
+		changes, lines = []journal.Change{}, []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"from": from, "to": to, "diff": lines, "changes": changes})
+}
+
+type rollbackRequest struct {
+	// To is a revision or RFC 3339 time.
+	To json.RawMessage `json:"to"`
+}
+
+// recordRollbackHandler restores the records as of {"to": rev or time}.
+func recordRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	j := recordJournal(w)
+	if j == nil {
+		return
+	}
+	var req rollbackRequest
+	if r.Body == nil || json.NewDecoder(r.Body).Decode(&req) != nil || len(req.To) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+		return
+	}
+	point := strings.Trim(string(req.To), `"`)
+	rev, err := j.Resolve(point)
+	if err != nil || strings.TrimSpace(point) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid revision or time"})
+		return
+	}
+	n, err := data.GetInstance().RollbackRecords(apiOrigin(r), rev)
+	if err != nil {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "rolled back", "to": rev, "records": n, "head": j.Head()})
+}
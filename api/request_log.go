@@ -0,0 +1,63 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package api
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// apiRequestLogger is chi's request logger with the name of the API token that authorized the request
+// appended to each line ("token=<name>").
+func apiRequestLogger() func(http.Handler) http.Handler {
+	return middleware.RequestLogger(&tokenLogFormatter{out: log.New(os.Stdout, "", log.LstdFlags)})
+}
+
+type tokenLogFormatter struct {
+	out *log.Logger
+}
+
+// tokenLogEntry lets apiAuthMiddleware, which runs inside the logger, fill in the token name.
+type tokenLogEntry struct {
+	inner middleware.LogEntry
+	token string
+}
+
+// entryPrinter receives the line chi's default formatter built and adds the token name.
+type entryPrinter struct {
+	out   *log.Logger
+	entry *tokenLogEntry
+}
+
+func (p entryPrinter) Print(v ...any) {
+	if p.entry.token != "" {
+		v = append(v, " token=", p.entry.token)
+	}
+	p.out.Print(v...)
+}
+
+func (f *tokenLogFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	e := &tokenLogEntry{}
+	e.inner = (&middleware.DefaultLogFormatter{Logger: entryPrinter{out: f.out, entry: e}}).NewLogEntry(r)
+	return e
+}
+
+func (e *tokenLogEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra any) {
+	e.inner.Write(status, bytes, header, elapsed, extra)
+}
+
+func (e *tokenLogEntry) Panic(v any, stack []byte) {
+	e.inner.Panic(v, stack)
+}
+
+// setRequestLogToken records the token name on the request's log line.
+func setRequestLogToken(r *http.Request, name string) {
+	if e, ok := middleware.GetLogEntry(r).(*tokenLogEntry); ok {
+		e.token = name
+	}
+}
@@ -4,6 +4,7 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,17 +12,18 @@ import (
 	"net"
 	"net/http"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"dnsplane/apiauth"
 	"dnsplane/daemon"
 	"dnsplane/data"
 	"dnsplane/dnsrecordcache"
 	"dnsplane/dnsrecords"
 	"dnsplane/dnsservers"
 	"dnsplane/fullstats"
-	"dnsplane/ratelimit"
 	"net/url"
 
 	"github.com/go-chi/chi/v5"
@@ -33,6 +35,9 @@ var (
 	apiServer           *http.Server
 	apiState            *daemon.State
 	apiFullStatsTracker *fullstats.Tracker
+	apiLogger           *slog.Logger
+	// apiClosing is closed when the server shuts down, to end the GET /events streams Shutdown would wait for.
+	apiClosing chan struct{}
 )
 
 // appVersion is injected from main via SetAppVersion.
@@ -97,23 +102,23 @@ func getFullStatsCounts() (total, session fullStatsCounts, ok bool) {
 // RouteRegistrar registers HTTP routes on the supplied Chi router.
 type RouteRegistrar func(chi.Router)
 
-// Start runs the REST API in the background. registrar nil uses RegisterDNSRoutes; opts nil uses zero defaults;
-// logger nil skips structured API file logging.
-func Start(state *daemon.State, port string, opts *ListenOptions, registrar RouteRegistrar, logger *slog.Logger) {
+// Start runs the REST API in the background once it listens, and returns why it did not start otherwise.
+// registrar nil uses RegisterDNSRoutes; opts nil uses zero defaults; logger nil skips structured API file logging.
+func Start(state *daemon.State, port string, opts *ListenOptions, registrar RouteRegistrar, logger *slog.Logger) error {
 	if state == nil {
 		logAPIWarn(logger, "missing daemon state; cannot start API")
-		return
+		return errors.New("missing daemon state")
 	}
 	trimmed := strings.TrimSpace(port)
 	if trimmed == "" {
 		logAPIWarn(logger, "invalid port; refusing to start")
-		return
+		return errors.New("no API port")
 	}
 	if state.APIRunning() {
 		if logger != nil {
 			logger.Info("API server already running; skipping start")
 		}
-		return
+		return nil
 	}
 	if registrar == nil {
 		registrar = RegisterDNSRoutes
@@ -121,59 +126,92 @@ func Start(state *daemon.State, port string, opts *ListenOptions, registrar Rout
 	if opts == nil {
 		opts = &ListenOptions{}
 	}
-
-	apiServerMu.Lock()
-	apiState = state
-	apiServerMu.Unlock()
-	state.SetAPIRunning(true)
+	tlsOn := strings.TrimSpace(opts.TLSCertFile) != "" && strings.TrimSpace(opts.TLSKeyFile) != ""
+	var certAuth *clientCertAuth
+	if strings.TrimSpace(opts.ClientCAFile) != "" {
+		if !tlsOn {
+			logAPIError(logger, "api_tls_client_ca needs api_tls_cert and api_tls_key; refusing to start API")
+			return errors.New("api_tls_client_ca needs api_tls_cert and api_tls_key")
+		}
+		a, err := loadClientCertAuth(opts.ClientCAFile, opts.ClientCRLFile)
+		if err != nil {
+			logAPIError(logger, "client certificates not loaded; refusing to start API", "error", err)
+			return fmt.Errorf("client certificates: %w", err)
+		}
+		for _, err := range checkClientRoles(data.GetInstance().GetResolverSettings().APITLSClientRoles) {
+			logAPIWarn(logger, "ignoring client certificate role", "error", err)
+		}
+		certAuth = a
+	}
+	var tlsCfg *tls.Config
+	if tlsOn {
+		cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			logAPIError(logger, "TLS certificate not loaded; refusing to start API", "error", err)
+			return fmt.Errorf("api_tls_cert: %w", err)
+		}
+		tlsCfg = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		if certAuth != nil {
+			tlsCfg = certAuth.tlsConfig()
+			tlsCfg.Certificates = []tls.Certificate{cert}
+		}
+	}
 	bindIP := strings.TrimSpace(opts.BindIP)
 	addr := ":" + trimmed
 	if bindIP != "" {
 		addr = net.JoinHostPort(bindIP, trimmed)
 	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		logAPIError(logger, "API server failed to listen", "addr", addr, "error", err)
+		return err
+	}
+	apiClientCerts.Store(certAuth)
+
+	apiServerMu.Lock()
+	apiState = state
+	apiLogger = logger
+	apiServerMu.Unlock()
+	state.SetAPIRunning(true)
 	if logger != nil {
-		logger.Info("API server starting", "addr", addr, "tls", opts.TLSCertFile != "" && opts.TLSKeyFile != "")
+		logger.Info("API server starting", "addr", addr, "tls", tlsOn, "client_certs", certAuth != nil)
 	}
 	router := chi.NewRouter()
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Logger)
-	var lim *ratelimit.PerIP
-	if opts.RateLimitRPS > 0 {
-		burst := opts.RateLimitBurst
-		if burst <= 0 {
-			burst = 20
-		}
-		lim = ratelimit.NewPerIP(opts.RateLimitRPS, burst)
-	}
-	if lim != nil {
-		router.Use(rateLimitMiddleware(lim))
-	}
+	router.Use(apiRequestLogger())
+	SetRateLimit(opts.RateLimitRPS, opts.RateLimitBurst)
+	router.Use(rateLimitMiddleware())
 	router.Use(apiAuthMiddleware())
 	registrar(router)
 
 	srv := &http.Server{
 		Addr:              addr,
 		Handler:           router,
+		TLSConfig:         tlsCfg,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       60 * time.Second,
 		WriteTimeout:      60 * time.Second,
 		IdleTimeout:       120 * time.Second,
 	}
+	closing := make(chan struct{})
+	srv.RegisterOnShutdown(func() { close(closing) })
 	apiServerMu.Lock()
 	apiServer = srv
+	apiClosing = closing
 	apiServerMu.Unlock()
 	go func() {
 		defer state.SetAPIRunning(false)
 		var err error
-		if strings.TrimSpace(opts.TLSCertFile) != "" && strings.TrimSpace(opts.TLSKeyFile) != "" {
-			err = srv.ListenAndServeTLS(opts.TLSCertFile, opts.TLSKeyFile)
+		if tlsCfg != nil {
+			err = srv.ServeTLS(ln, "", "")
 		} else {
-			err = srv.ListenAndServe()
+			err = srv.Serve(ln)
 		}
 		if err != nil && err != http.ErrServerClosed {
 			logAPIError(logger, "API server stopped with error", "error", err)
 		}
 	}()
+	return nil
 }
 
 // Stop shuts down the API server and updates state. No-op if not running.
@@ -183,6 +221,7 @@ func Stop(state *daemon.State) {
 	apiServer = nil
 	apiState = nil
 	apiServerMu.Unlock()
+	apiClientCerts.Store(nil)
 	if srv == nil {
 		return
 	}
@@ -213,35 +252,85 @@ func RegisterDNSRoutes(router chi.Router) {
 	router.Get("/ready", readyHandler)
 	router.Get("/version", versionHandler)
 	router.Get("/version/page", versionPageHandler)
-	router.Get("/dns/records", listRecordsHandler)
-	router.Post("/dns/records", addRecordHandler)
-	router.Post("/dns/records/reload", reloadRecordsHandler)
-	router.Put("/dns/records", updateRecordHandler)
-	router.Delete("/dns/records", deleteRecordHandler)
-	router.Get("/dns/servers", listServersHandler)
-	router.Get("/dns/upstreams/health", upstreamHealthHandler)
-	router.Post("/dns/servers", addServerHandler)
-	router.Put("/dns/servers/{address}", updateServerHandler)
-	router.Delete("/dns/servers/{address}", deleteServerHandler)
-	router.Get("/adblock/domains", listAdblockDomainsHandler)
-	router.Get("/adblock/sources", listAdblockSourcesHandler)
-	router.Post("/adblock/domains", addAdblockDomainsHandler)
-	router.Delete("/adblock/domains", deleteAdblockDomainsHandler)
-	router.Post("/adblock/clear", clearAdblockHandler)
-	router.Get("/cache", getCacheHandler)
-	router.Post("/cache/clear", clearCacheHandler)
-	router.Delete("/cache", clearCacheHandler)
-	router.Get("/stats", statsHandler)
-	router.Get("/metrics", metricsHandler)
-	router.Get("/stats/dashboard", dashboardPageHandler)
-	router.Get("/stats/dashboard/icon", dashboardIconSVGHandler)
-	router.Get("/stats/dashboard/data", dashboardDataHandler)
+
+	recordsRead := router.With(requireScope(apiauth.ScopeRecordsRead))
+	recordsRead.Get("/dns/records", listRecordsHandler)
+	recordsRead.Get("/dns/records/sources", listRecordSourcesHandler)
+	recordsRead.Get("/dns/records/health", recordHealthHandler)
+	recordsRead.Get("/dns/records/history", recordHistoryHandler)
+	recordsRead.Get("/dns/records/history/diff", recordHistoryDiffHandler)
+	recordsRead.Get("/dns/records/{id}", getRecordHandler)
+	recordsRead.Get("/dns/zones/{zone}/file", getZoneFileHandler)
+
+	recordsWrite := router.With(requireScope(apiauth.ScopeRecordsWrite))
+	recordsWrite.Post("/dns/records", addRecordHandler)
+	recordsWrite.Post("/dns/records/reload", reloadRecordsHandler)
+	recordsWrite.Post("/dns/records/rollback", recordRollbackHandler)
+	recordsWrite.Post("/dns/records/batch", recordsBatchHandler)
+	recordsWrite.Put("/dns/records", updateRecordHandler)
+	recordsWrite.Delete("/dns/records", deleteRecordHandler)
+	recordsWrite.Put("/dns/zones/{zone}/file", putZoneFileHandler)
+
+	serversWrite := router.With(requireScope(apiauth.ScopeServersWrite))
+	serversWrite.Post("/dns/servers", addServerHandler)
+	serversWrite.Put("/dns/servers/{address}", updateServerHandler)
+	serversWrite.Delete("/dns/servers/{address}", deleteServerHandler)
+	serversWrite.Put("/dns/acl", putClientACLHandler)
+	serversWrite.Put("/dns/acl/groups/{name}", putClientACLGroupHandler)
+	serversWrite.Delete("/dns/acl/groups/{name}", deleteClientACLGroupHandler)
+	serversWrite.Put("/dns/policy", putPolicyHandler)
+
+	adblockWrite := router.With(requireScope(apiauth.ScopeAdblockWrite))
+	adblockWrite.Post("/adblock/domains", addAdblockDomainsHandler)
+	adblockWrite.Delete("/adblock/domains", deleteAdblockDomainsHandler)
+	adblockWrite.Post("/adblock/clear", clearAdblockHandler)
+
+	cacheAdmin := router.With(requireScope(apiauth.ScopeCacheAdmin))
+	cacheAdmin.Get("/cache", getCacheHandler)
+	cacheAdmin.Post("/cache/clear", clearCacheHandler)
+	cacheAdmin.Delete("/cache", clearCacheHandler)
+	cacheAdmin.Post("/stats/dashboard/resolutions/purge", dashboardResolutionsPurgeHandler)
+	cacheAdmin.Post("/stats/perf/reset", perfResetHandler)
+
+	statsRead := router.With(requireScope(apiauth.ScopeStatsRead))
+	statsRead.Get("/dns/servers", listServersHandler)
+	statsRead.Get("/dns/upstreams/health", upstreamHealthHandler)
+	statsRead.Get("/dns/acl", getClientACLHandler)
+	statsRead.Get("/dns/acl/evaluate", evaluateClientACLHandler)
+	statsRead.Get("/dns/policy", getPolicyHandler)
+	statsRead.Get("/dns/policy/evaluate", evaluatePolicyHandler)
+	statsRead.Get("/events", eventsHandler)
+	statsRead.Get("/events/webhooks", webhooksStatusHandler)
+	statsRead.Get("/adblock/domains", listAdblockDomainsHandler)
+	statsRead.Get("/adblock/sources", listAdblockSourcesHandler)
+	statsRead.Get("/stats", statsHandler)
+	statsRead.Get("/metrics", metricsHandler)
+	statsRead.Get("/stats/dashboard", dashboardPageHandler)
+	statsRead.Get("/stats/dashboard/icon", dashboardIconSVGHandler)
+	statsRead.Get("/stats/dashboard/data", dashboardDataHandler)
+	statsRead.Get("/stats/dashboard/resolutions", dashboardResolutionsHandler)
+	statsRead.Get("/stats/dashboard/fullstats/data", fullstatsBrowseHandler)
+	statsRead.Get("/stats/perf", perfStatsHandler)
+	// The WebSocket checks its token itself (browsers cannot send headers on the handshake).
 	router.Get("/stats/dashboard/ws", dashboardWebSocketHandler)
-	router.Get("/stats/dashboard/resolutions", dashboardResolutionsHandler)
-	router.Post("/stats/dashboard/resolutions/purge", dashboardResolutionsPurgeHandler)
-	router.Get("/stats/dashboard/fullstats/data", fullstatsBrowseHandler)
-	router.Get("/stats/perf", perfStatsHandler)
-	router.Post("/stats/perf/reset", perfResetHandler)
+
+	tokensAdmin := router.With(requireScope(apiauth.ScopeTokensAdmin))
+	tokensAdmin.Get("/auth/tokens", listAPITokensHandler)
+	tokensAdmin.Post("/auth/tokens", createAPITokenHandler)
+	tokensAdmin.Post("/auth/tokens/{name}/rotate", rotateAPITokenHandler)
+	tokensAdmin.Patch("/auth/tokens/{name}", updateAPITokenHandler)
+	tokensAdmin.Delete("/auth/tokens/{name}", deleteAPITokenHandler)
+
+	router.With(requireScope(apiauth.ScopeDNSQuery)).Post("/dns/query", queryTestHandler)
+
+	clusterAdmin := router.With(requireScope(apiauth.ScopeClusterAdmin))
+	clusterAdmin.Get("/cluster/status", clusterStatusHandler)
+	clusterAdmin.Post("/cluster/pull", clusterPullHandler)
+
+	configAdmin := router.With(requireScope(apiauth.ScopeConfigAdmin))
+	configAdmin.Get("/config", getConfigHandler)
+	configAdmin.Patch("/config", patchConfigHandler)
+	configAdmin.Post("/config/validate", validateConfigHandler)
 }
 
 // healthHandler returns 200 when the API is up. No dependency on DNS listener.
@@ -326,6 +415,22 @@ func listServersHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// recordHealthHandler returns the health check state of local record values (read-only).
+func recordHealthHandler(w http.ResponseWriter, r *http.Request) {
+	st := data.GetInstance().RecordHealthStatuses()
+	down := 0
+	for i := range st {
+		if st[i].Unhealthy {
+			down++
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"checks":    len(st),
+		"unhealthy": down,
+		"records":   st,
+	})
+}
+
 // upstreamHealthHandler returns only upstream health probe state (read-only).
 func upstreamHealthHandler(w http.ResponseWriter, r *http.Request) {
 	dnsData := data.GetInstance()
@@ -454,6 +559,40 @@ type AddRecordRequest struct {
 	Type  string  `json:"type"`
 	Value string  `json:"value"`
 	TTL   *uint32 `json:"ttl,omitempty"`
+	// Metadata left out of an update keeps its current value; an empty value clears it.
+	Tags    []string `json:"tags,omitempty"`
+	Owner   *string  `json:"owner,omitempty"`
+	Comment *string  `json:"comment,omitempty"`
+	// ExpiresAt is an RFC 3339 time or a duration from now such as "36h" or "7d".
+	ExpiresAt *string `json:"expires_at,omitempty"`
+}
+
+// applyMetadata sets rec's tags, owner, comment, and expiry from the request, keeping those of old
+// (when not nil) for fields the request leaves out.
+func (r AddRecordRequest) applyMetadata(rec *dnsrecords.DNSRecord, old *dnsrecords.DNSRecord) error {
+	if old != nil {
+		rec.Tags, rec.Owner, rec.Comment, rec.ExpiresAt = old.Tags, old.Owner, old.Comment, old.ExpiresAt
+	}
+	if r.Tags != nil {
+		rec.Tags = r.Tags
+	}
+	if r.Owner != nil {
+		rec.Owner = *r.Owner
+	}
+	if r.Comment != nil {
+		rec.Comment = *r.Comment
+	}
+	if r.ExpiresAt != nil {
+		rec.ExpiresAt = time.Time{}
+		if strings.TrimSpace(*r.ExpiresAt) != "" {
+			t, err := dnsrecords.ParseExpiry(*r.ExpiresAt, time.Now())
+			if err != nil {
+				return err
+			}
+			rec.ExpiresAt = t
+		}
+	}
+	return nil
 }
 
 func (r AddRecordRequest) toDNSRecord() dnsrecords.DNSRecord {
@@ -482,6 +621,19 @@ func reloadRecordsHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 		return
 	}
+	if name := strings.TrimSpace(r.URL.Query().Get("source")); name != "" {
+		if !slices.ContainsFunc(data.RecordSourcesStatus(), func(s data.RecordSourceStatus) bool { return s.Name == name }) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("unknown records source %q", name)})
+			return
+		}
+		n, err := data.ReloadRecordSource(name)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": "reloaded", "source": name, "records": n})
+		return
+	}
 	n, err := data.ReloadDNSRecordsFromSource()
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -490,6 +642,12 @@ func reloadRecordsHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"status": "reloaded", "records": n})
 }
 
+// listRecordSourcesHandler returns the records sources in precedence order with their counts and
+// last refresh error.
+func listRecordSourcesHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"sources": data.RecordSourcesStatus()})
+}
+
 func addRecordHandler(w http.ResponseWriter, r *http.Request) {
 	dnsData := data.GetInstance()
 	var request AddRecordRequest
@@ -503,21 +661,36 @@ func addRecordHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	record := request.toDNSRecord()
-	records := dnsData.GetRecords()
-	updated, messages, err := dnsrecords.AddRecord(record, records, false)
-	if err != nil {
-		status := http.StatusBadRequest
-		if !errors.Is(err, dnsrecords.ErrInvalidArgs) {
-			status = http.StatusInternalServerError
-		}
-		writeJSON(w, status, map[string]any{"error": err.Error(), "messages": extractRecordMessages(messages)})
+	if err := request.applyMetadata(&record, nil); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
-	if err := dnsData.UpdateRecords(updated); err != nil {
-		writeJSON(w, http.StatusForbidden, map[string]any{"error": err.Error()})
+	var messages []dnsrecords.Message
+	var added *dnsrecords.DNSRecord
+	rev, err := dnsData.ModifyRecords(apiOrigin(r), func(records []dnsrecords.DNSRecord, rev uint64) ([]dnsrecords.DNSRecord, error) {
+		if err := checkIfMatch(r, rev, nil); err != nil {
+			return nil, err
+		}
+		updated, msgs, err := dnsrecords.AddRecord(record, records, false)
+		messages = msgs
+		if err != nil {
+			return nil, recordOpFailed(err, msgs)
+		}
+		if len(updated) > len(records) {
+			added = &updated[len(updated)-1]
+		}
+		return updated, nil
+	})
+	if err != nil {
+		writeRecordsError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, map[string]any{"status": "record added", "messages": extractRecordMessages(messages)})
+	resp := map[string]any{"status": "record added", "messages": extractRecordMessages(messages), "revision": rev}
+	if added != nil {
+		resp["id"], resp["etag"] = added.ID, recordETag(*added)
+	}
+	w.Header().Set("ETag", recordsETag(rev))
+	writeJSON(w, http.StatusCreated, resp)
 }
 
 func listRecordsQueryDetails(q url.Values) bool {
@@ -529,29 +702,50 @@ func listRecordsQueryDetails(q url.Values) bool {
 	return vd == "1" || vd == "true" || vd == "yes"
 }
 
-func listRecordsFilterDesc(nameQ, typeQ string) string {
-	switch {
-	case nameQ != "" && typeQ != "":
-		return "name=" + nameQ + "&type=" + typeQ
-	case nameQ != "":
-		return "name=" + nameQ
-	case typeQ != "":
-		return "type=" + typeQ
-	default:
-		return ""
+func listRecordsFilterDesc(nameQ, typeQ string, selectors ...string) string {
+	var parts []string
+	if nameQ != "" {
+		parts = append(parts, "name="+nameQ)
 	}
+	if typeQ != "" {
+		parts = append(parts, "type="+typeQ)
+	}
+	parts = append(parts, selectors...)
+	return strings.Join(parts, "&")
+}
+
+// listRecordsSelectors turns ?tag= (repeatable or comma-separated), ?owner=, and ?expires=true into
+// FilterRecords selectors.
+func listRecordsSelectors(q url.Values) []string {
+	var out []string
+	for _, v := range q["tag"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				out = append(out, "tag="+t)
+			}
+		}
+	}
+	if o := strings.TrimSpace(q.Get("owner")); o != "" {
+		out = append(out, "owner="+o)
+	}
+	if v := strings.ToLower(strings.TrimSpace(q.Get("expires"))); v == "1" || v == "true" {
+		out = append(out, "expires")
+	}
+	return out
 }
 
 func listRecordsHandler(w http.ResponseWriter, r *http.Request) {
 	dnsData := data.GetInstance()
+	rev := data.RecordsRevision()
 	records := dnsData.GetRecords()
 	q := r.URL.Query()
 	nameQ := strings.TrimSpace(q.Get("name"))
 	typeQ := strings.TrimSpace(q.Get("type"))
 	detailed := listRecordsQueryDetails(q)
+	selectors := listRecordsSelectors(q)
 
-	if nameQ != "" || typeQ != "" {
-		filtered, err := dnsrecords.FilterRecords(records, nameQ, typeQ)
+	if nameQ != "" || typeQ != "" || len(selectors) > 0 {
+		filtered, err := dnsrecords.FilterRecords(records, nameQ, typeQ, selectors...)
 		if err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
@@ -562,7 +756,7 @@ func listRecordsHandler(w http.ResponseWriter, r *http.Request) {
 	result := dnsrecords.ListResult{
 		Records:  records,
 		Detailed: detailed,
-		Filter:   listRecordsFilterDesc(nameQ, typeQ),
+		Filter:   listRecordsFilterDesc(nameQ, typeQ, selectors...),
 	}
 	if result.Filter != "" {
 		result.Messages = append(result.Messages, dnsrecords.Message{
@@ -580,6 +774,7 @@ func listRecordsHandler(w http.ResponseWriter, r *http.Request) {
 	resp := map[string]any{
 		"records":  result.Records,
 		"detailed": result.Detailed,
+		"revision": rev,
 	}
 	if result.Filter != "" {
 		resp["filter"] = result.Filter
@@ -587,6 +782,7 @@ func listRecordsHandler(w http.ResponseWriter, r *http.Request) {
 	if len(result.Messages) > 0 {
 		resp["messages"] = extractRecordMessages(result.Messages)
 	}
+	w.Header().Set("ETag", recordsETag(rev))
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -616,39 +812,60 @@ func updateRecordHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name, type, and value are required"})
 		return
 	}
-	record := request.toDNSRecord()
-	records := dnsData.GetRecords()
-	if id := strings.TrimSpace(request.ID); id != "" {
-		updated, messages, err := dnsrecords.UpdateRecordByID(id, record, records)
-		if err != nil {
-			status := http.StatusBadRequest
-			if !errors.Is(err, dnsrecords.ErrInvalidArgs) {
-				status = http.StatusInternalServerError
-			}
-			writeJSON(w, status, map[string]any{"error": err.Error(), "messages": extractRecordMessages(messages)})
-			return
+	var messages []dnsrecords.Message
+	var result *dnsrecords.DNSRecord
+	rev, err := dnsData.ModifyRecords(apiOrigin(r), func(records []dnsrecords.DNSRecord, rev uint64) ([]dnsrecords.DNSRecord, error) {
+		record := request.toDNSRecord()
+		old := findRecordToUpdate(records, request.ID, record)
+		if err := checkIfMatch(r, rev, old); err != nil {
+			return nil, err
 		}
-		if err := dnsData.UpdateRecords(updated); err != nil {
-			writeJSON(w, http.StatusForbidden, map[string]any{"error": err.Error()})
-			return
+		if err := request.applyMetadata(&record, old); err != nil {
+			return nil, &recordWriteError{status: http.StatusBadRequest, err: err}
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"status": "record updated", "messages": extractRecordMessages(messages)})
-		return
-	}
-	updated, messages, err := dnsrecords.AddRecord(record, records, true)
-	if err != nil {
-		status := http.StatusBadRequest
-		if !errors.Is(err, dnsrecords.ErrInvalidArgs) {
-			status = http.StatusInternalServerError
+		var updated []dnsrecords.DNSRecord
+		var err error
+		if id := strings.TrimSpace(request.ID); id != "" {
+			updated, messages, err = dnsrecords.UpdateRecordByID(id, record, records)
+		} else {
+			updated, messages, err = dnsrecords.AddRecord(record, records, true)
+		}
+		if err != nil {
+			return nil, recordOpFailed(err, messages)
 		}
-		writeJSON(w, status, map[string]any{"error": err.Error(), "messages": extractRecordMessages(messages)})
+		result = findRecordToUpdate(updated, request.ID, record)
+		return updated, nil
+	})
+	if err != nil {
+		writeRecordsError(w, err)
 		return
 	}
-	if err := dnsData.UpdateRecords(updated); err != nil {
-		writeJSON(w, http.StatusForbidden, map[string]any{"error": err.Error()})
-		return
+	resp := map[string]any{"status": "record updated", "messages": extractRecordMessages(messages), "revision": rev}
+	if result != nil {
+		resp["id"], resp["etag"] = result.ID, recordETag(*result)
+	}
+	w.Header().Set("ETag", recordsETag(rev))
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// findRecordToUpdate returns the record a PUT replaces: the one with id, else the one with the same
+// name, type, and value.
+func findRecordToUpdate(records []dnsrecords.DNSRecord, id string, rec dnsrecords.DNSRecord) *dnsrecords.DNSRecord {
+	id = strings.TrimSpace(id)
+	name := dnsrecords.NormalizeRecordNameKey(rec.Name)
+	typ := dnsrecords.NormalizeRecordType(rec.Type)
+	value := dnsrecords.NormalizeRecordValueKey(typ, rec.Value)
+	for i := range records {
+		r := &records[i]
+		if id != "" && r.ID == id {
+			return r
+		}
+		if id == "" && dnsrecords.NormalizeRecordNameKey(r.Name) == name && dnsrecords.NormalizeRecordType(r.Type) == typ &&
+			dnsrecords.NormalizeRecordValueKey(typ, r.Value) == value {
+			return r
+		}
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"status": "record updated", "messages": extractRecordMessages(messages)})
+	return nil
 }
 
 func deleteRecordHandler(w http.ResponseWriter, r *http.Request) {
@@ -683,32 +900,47 @@ func deleteRecordHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	id = strings.TrimSpace(id)
+	name = strings.TrimSpace(name)
+	if id == "" && name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+	var messages []dnsrecords.Message
+	rev, err := data.GetInstance().ModifyRecords(apiOrigin(r), func(records []dnsrecords.DNSRecord, rev uint64) ([]dnsrecords.DNSRecord, error) {
+		updated, msgs, err := removeRecords(r, records, rev, id, name, recordType, value)
+		messages = msgs
+		return updated, err
+	})
+	if err != nil {
+		writeRecordsError(w, err)
+		return
+	}
+	w.Header().Set("ETag", recordsETag(rev))
+	writeJSON(w, http.StatusOK, map[string]any{"status": "record deleted", "messages": extractRecordMessages(messages), "revision": rev})
+}
+
+// removeRecords removes the record with id, else the records matching name and the optional type and
+// value, after checking If-Match (against the record when id names one).
+func removeRecords(r *http.Request, records []dnsrecords.DNSRecord, rev uint64, id, name, recordType, value string) ([]dnsrecords.DNSRecord, []dnsrecords.Message, error) {
 	if id != "" {
-		dnsData := data.GetInstance()
-		records := dnsData.GetRecords()
+		var target *dnsrecords.DNSRecord
+		if i := slices.IndexFunc(records, func(rec dnsrecords.DNSRecord) bool { return rec.ID == id }); i >= 0 {
+			target = &records[i]
+		}
+		if err := checkIfMatch(r, rev, target); err != nil {
+			return nil, nil, err
+		}
 		updated, messages, err := dnsrecords.RemoveRecordByID(id, records)
 		if err != nil {
-			status := http.StatusBadRequest
-			if !errors.Is(err, dnsrecords.ErrInvalidArgs) {
-				status = http.StatusInternalServerError
-			}
-			writeJSON(w, status, map[string]any{"error": err.Error(), "messages": extractRecordMessages(messages)})
-			return
+			return nil, messages, recordOpFailed(err, messages)
 		}
-		if err := dnsData.UpdateRecords(updated); err != nil {
-			writeJSON(w, http.StatusForbidden, map[string]any{"error": err.Error()})
-			return
-		}
-		writeJSON(w, http.StatusOK, map[string]any{"status": "record deleted", "messages": extractRecordMessages(messages)})
-		return
+		return updated, messages, nil
+	}
+	if err := checkIfMatch(r, rev, nil); err != nil {
+		return nil, nil, err
 	}
-	name = strings.TrimSpace(name)
 	recordType = strings.TrimSpace(recordType)
 	value = strings.TrimSpace(value)
-	if name == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
-		return
-	}
 	var args []string
 	if recordType != "" && value != "" {
 		args = []string{name, recordType, value}
@@ -717,22 +949,11 @@ func deleteRecordHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		args = []string{name}
 	}
-	dnsData := data.GetInstance()
-	records := dnsData.GetRecords()
 	updated, messages, err := dnsrecords.Remove(args, records)
 	if err != nil {
-		status := http.StatusBadRequest
-		if !errors.Is(err, dnsrecords.ErrInvalidArgs) {
-			status = http.StatusInternalServerError
-		}
-		writeJSON(w, status, map[string]any{"error": err.Error(), "messages": extractRecordMessages(messages)})
-		return
-	}
-	if err := dnsData.UpdateRecords(updated); err != nil {
-		writeJSON(w, http.StatusForbidden, map[string]any{"error": err.Error()})
-		return
+		return nil, messages, recordOpFailed(err, messages)
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"status": "record deleted", "messages": extractRecordMessages(messages)})
+	return updated, messages, nil
 }
 
 type addServerRequest struct {
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"testing"
 )
 
@@ -64,3 +65,15 @@ func TestReadyHandler_WithoutState(t *testing.T) {
 		t.Errorf("readyHandler with nil state status = %d, want 503", rec.Code)
 	}
 }
+
+func TestListRecordsSelectors(t *testing.T) {
+	q := url.Values{"tag": []string{"migration,temp", "web"}, "owner": []string{"netops"}, "expires": []string{"true"}}
+	got := listRecordsSelectors(q)
+	want := []string{"tag=migration", "tag=temp", "tag=web", "owner=netops", "expires"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if desc := listRecordsFilterDesc("", "A", got...); desc != "type=A&tag=migration&tag=temp&tag=web&owner=netops&expires" {
+		t.Fatalf("desc %q", desc)
+	}
+}
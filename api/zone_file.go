@@ -0,0 +1,113 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"dnsplane/data"
+	"dnsplane/dnsrecords"
+	"dnsplane/zones"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/miekg/dns"
+)
+
+// maxZoneFileBytes bounds an uploaded zone file.
+const maxZoneFileBytes = 16 << 20
+
+func zoneFileRequest(w http.ResponseWriter, r *http.Request) (zone, format string, ok bool) {
+	zone = strings.TrimSpace(chi.URLParam(r, "zone"))
+	if _, valid := dns.IsDomainName(zone); zone == "" || !valid {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid zone"})
+		return "", "", false
+	}
+	format, err := zones.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return "", "", false
+	}
+	return zone, format, true
+}
+
+// getZoneFileHandler exports the zone's local records (?format=bind|json|csv|octodns-yaml, default bind).
+func getZoneFileHandler(w http.ResponseWriter, r *http.Request) {
+	zone, format, ok := zoneFileRequest(w, r)
+	if !ok {
+		return
+	}
+	records := data.GetInstance().GetRecords()
+	if len(zones.ZoneRecords(records, zone)) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no records in zone"})
+		return
+	}
+	var b bytes.Buffer
+	if err := zones.Export(&b, format, records, zone); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", zones.ContentType(format))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b.Bytes())
+}
+
+// putZoneFileHandler replaces the zone's local records with the request body (?format= as for GET).
+// ?dry_run=true only reports the change.
+func putZoneFileHandler(w http.ResponseWriter, r *http.Request) {
+	zone, format, ok := zoneFileRequest(w, r)
+	if !ok {
+		return
+	}
+	if r.Body == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid input"})
+		return
+	}
+	res, err := zones.Import(http.MaxBytesReader(w, r.Body, maxZoneFileBytes), format, zone)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	v := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("dry_run")))
+	dryRun := v == "1" || v == "true" || v == "yes"
+	dnsData := data.GetInstance()
+	var ch zones.Change
+	apply := func(records []dnsrecords.DNSRecord) error {
+		var err error
+		if ch, err = zones.Apply(records, res.Records, zone); err != nil {
+			return &recordWriteError{status: http.StatusBadRequest, err: err}
+		}
+		return nil
+	}
+	status := "imported"
+	if dryRun {
+		status = "dry run"
+		if err := apply(dnsData.GetRecords()); err != nil {
+			writeRecordsError(w, err)
+			return
+		}
+	} else {
+		rev, err := dnsData.ModifyRecords(apiOrigin(r), func(records []dnsrecords.DNSRecord, rev uint64) ([]dnsrecords.DNSRecord, error) {
+			if err := checkIfMatch(r, rev, nil); err != nil {
+				return nil, err
+			}
+			if err := apply(records); err != nil {
+				return nil, err
+			}
+			return ch.Records, nil
+		})
+		if err != nil {
+			writeRecordsError(w, err)
+			return
+		}
+		w.Header().Set("ETag", recordsETag(rev))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":   status,
+		"summary":  ch.Summary(),
+		"diff":     ch.DiffLines(),
+		"change":   ch,
+		"warnings": res.Warnings,
+	})
+}
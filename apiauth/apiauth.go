@@ -0,0 +1,351 @@
+// Package apiauth keeps the named, scoped API credentials. Only a SHA-256 hash of each secret is stored.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package apiauth
+
+import (
+	"cmp"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"dnsplane/dnsrecords"
+)
+
+// Scopes a credential can hold. Each API route requires one of them.
+const (
+	ScopeRecordsRead  = "records:read"  // list records, history, zone files
+	ScopeRecordsWrite = "records:write" // add, update, delete, reload, rollback records
+	ScopeServersWrite = "servers:write" // upstream servers, client ACL, policy
+	ScopeCacheAdmin   = "cache:admin"   // view and clear the cache, reset runtime counters
+	ScopeAdblockWrite = "adblock:write" // add, remove, clear blocked domains
+	ScopeStatsRead    = "stats:read"    // stats, metrics, dashboard, upstream and adblock listings
+	ScopeDNSQuery     = "dns:query"     // test queries through the resolver, which may ask the upstreams
+	ScopeClusterAdmin = "cluster:admin" // cluster status and pull from peers
+	ScopeTokensAdmin  = "tokens:admin"  // create, rotate, expire, and remove credentials
+	ScopeConfigAdmin  = "config:admin"  // read, validate, and change the runtime configuration
+)
+
+// AllScopes lists every scope in display order.
+var AllScopes = []string{
+	ScopeRecordsRead, ScopeRecordsWrite, ScopeServersWrite, ScopeCacheAdmin,
+	ScopeAdblockWrite, ScopeStatsRead, ScopeDNSQuery, ScopeClusterAdmin,
+	ScopeTokensAdmin, ScopeConfigAdmin,
+}
+
+// secretPrefix starts every generated secret, so leaked tokens are easy to search for.
+const secretPrefix = "dnsp_"
+
+var (
+	// ErrNotFound is returned for an unknown credential name.
+	ErrNotFound = errors.New("credential not found")
+	// ErrExists is returned when creating a credential whose name is taken.
+	ErrExists = errors.New("credential already exists")
+
+	validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+)
+
+// ValidScope reports whether s names a scope.
+func ValidScope(s string) bool {
+	return slices.Contains(AllScopes, s)
+}
+
+// ParseScopes splits a comma-separated scope list; "all" or "*" expands to every scope.
+func ParseScopes(s string) ([]string, error) {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		switch {
+		case part == "":
+			continue
+		case part == "all" || part == "*":
+			out = append(out, AllScopes...)
+		case ValidScope(part):
+			out = append(out, part)
+		default:
+			return nil, fmt.Errorf("unknown scope %q (want %s)", part, strings.Join(AllScopes, ", "))
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no scopes given")
+	}
+	return normalizeScopes(out), nil
+}
+
+func normalizeScopes(scopes []string) []string {
+	out := slices.Clone(scopes)
+	slices.SortFunc(out, func(a, b string) int {
+		return cmp.Compare(slices.Index(AllScopes, a), slices.Index(AllScopes, b))
+	})
+	return slices.Compact(out)
+}
+
+// ParseExpiry parses an expiry: "" or "never" for none, an RFC 3339 time, or a duration from now such
+// as "36h" or "90d". Times at or before now are rejected.
+func ParseExpiry(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "never") {
+		return time.Time{}, nil
+	}
+	t, err := dnsrecords.ParseExpiry(s, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("expiry %s is in the past", t.Format(time.RFC3339))
+	}
+	return t.UTC(), nil
+}
+
+// Credential is a named API token. Hash is never shown to clients.
+type Credential struct {
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash,omitempty"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	RotatedAt time.Time `json:"rotated_at,omitzero"`
+	// ExpiresAt, when set, is the time after which the credential is refused.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// Allows reports whether c holds scope.
+func (c Credential) Allows(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// Expired reports whether c has an expiry at or before now.
+func (c Credential) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// Store holds the credentials of one file. Changes are written to the file immediately.
+type Store struct {
+	path    string
+	mu      sync.RWMutex
+	creds   []Credential
+	loadErr error // the file could not be read: refuse every secret and change
+}
+
+type fileFormat struct {
+	Tokens []Credential `json:"tokens"`
+}
+
+// Open reads the credentials in path. A missing file is an empty store; it is created on the first change.
+// When the file cannot be read, Open returns the error together with a store that refuses every secret,
+// so a damaged file does not leave the API open.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	raw, err := os.ReadFile(path) // #nosec G304 -- path from operator config
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err == nil {
+		var f fileFormat
+		if err = json.Unmarshal(raw, &f); err == nil {
+			s.creds = f.Tokens
+			return s, nil
+		}
+	}
+	s.loadErr = fmt.Errorf("api tokens %s: %w", path, err)
+	return s, s.loadErr
+}
+
+// Path returns the file the store reads and writes.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Enforced reports whether the API needs a token: the store has credentials (expired ones included) or
+// its file could not be read.
+func (s *Store) Enforced() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.creds) > 0 || s.loadErr != nil
+}
+
+// List returns the credentials sorted by name, without hashes.
+func (s *Store) List() []Credential {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Credential, len(s.creds))
+	for i, c := range s.creds {
+		c.Hash = ""
+		c.Scopes = slices.Clone(c.Scopes)
+		out[i] = c
+	}
+	slices.SortFunc(out, func(a, b Credential) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// Authenticate returns the credential whose secret is secret, unless it has expired at now.
+func (s *Store) Authenticate(secret string, now time.Time) (Credential, bool) {
+	if s == nil || secret == "" {
+		return Credential{}, false
+	}
+	h := hashSecret(secret)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.creds {
+		if subtle.ConstantTimeCompare([]byte(c.Hash), []byte(h)) == 1 {
+			if c.Expired(now) {
+				return Credential{}, false
+			}
+			c.Hash = ""
+			return c, true
+		}
+	}
+	return Credential{}, false
+}
+
+// Create adds a credential and returns its secret, which is not stored and cannot be shown again.
+func (s *Store) Create(name string, scopes []string, expiresAt time.Time) (string, Credential, error) {
+	name = strings.TrimSpace(name)
+	if !validName.MatchString(name) {
+		return "", Credential{}, fmt.Errorf("invalid credential name %q (letters, digits, '.', '_', '-'; up to 64)", name)
+	}
+	if len(scopes) == 0 {
+		return "", Credential{}, fmt.Errorf("no scopes given")
+	}
+	for _, sc := range scopes {
+		if !ValidScope(sc) {
+			return "", Credential{}, fmt.Errorf("unknown scope %q", sc)
+		}
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return "", Credential{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadErr != nil {
+		return "", Credential{}, s.loadErr
+	}
+	if s.indexLocked(name) >= 0 {
+		return "", Credential{}, fmt.Errorf("%w: %s", ErrExists, name)
+	}
+	c := Credential{Name: name, Hash: hashSecret(secret), Scopes: normalizeScopes(scopes), CreatedAt: time.Now().UTC(), ExpiresAt: expiresAt}
+	next := append(slices.Clone(s.creds), c)
+	if err := s.saveLocked(next); err != nil {
+		return "", Credential{}, err
+	}
+	c.Hash = ""
+	return secret, c, nil
+}
+
+// Rotate replaces the secret of name and returns the new one. The old secret stops working at once.
+func (s *Store) Rotate(name string) (string, Credential, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return "", Credential{}, err
+	}
+	c, err := s.update(name, func(c *Credential) {
+		c.Hash = hashSecret(secret)
+		c.RotatedAt = time.Now().UTC()
+	})
+	if err != nil {
+		return "", Credential{}, err
+	}
+	return secret, c, nil
+}
+
+// SetExpiry sets when name expires; the zero time means never.
+func (s *Store) SetExpiry(name string, expiresAt time.Time) (Credential, error) {
+	return s.update(name, func(c *Credential) { c.ExpiresAt = expiresAt })
+}
+
+// Remove deletes the credential name.
+func (s *Store) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadErr != nil {
+		return s.loadErr
+	}
+	i := s.indexLocked(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return s.saveLocked(slices.Delete(slices.Clone(s.creds), i, i+1))
+}
+
+func (s *Store) update(name string, fn func(*Credential)) (Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadErr != nil {
+		return Credential{}, s.loadErr
+	}
+	i := s.indexLocked(name)
+	if i < 0 {
+		return Credential{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	next := slices.Clone(s.creds)
+	fn(&next[i])
+	if err := s.saveLocked(next); err != nil {
+		return Credential{}, err
+	}
+	c := next[i]
+	c.Hash = ""
+	return c, nil
+}
+
+func (s *Store) indexLocked(name string) int {
+	return slices.IndexFunc(s.creds, func(c Credential) bool { return c.Name == name })
+}
+
+// saveLocked writes creds to the file (0600, replaced atomically) and then makes them current.
+func (s *Store) saveLocked(creds []Credential) error {
+	raw, err := json.MarshalIndent(fileFormat{Tokens: creds}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".api_tokens-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	s.creds = creds
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
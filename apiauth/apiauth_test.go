@@ -0,0 +1,103 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package apiauth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_tokens.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Enforced() {
+		t.Fatal("empty store enforced")
+	}
+	secret, c, err := s.Create("monitoring", []string{ScopeStatsRead}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, secretPrefix) || c.Hash != "" {
+		t.Fatalf("create: %q %+v", secret, c)
+	}
+	if _, _, err := s.Create("monitoring", []string{ScopeStatsRead}, time.Time{}); !errors.Is(err, ErrExists) {
+		t.Fatalf("duplicate name: %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), secret) || !strings.Contains(string(raw), "sha256:") {
+		t.Fatalf("file stores the secret or no hash:\n%s", raw)
+	}
+
+	now := time.Now()
+	got, ok := s.Authenticate(secret, now)
+	if !ok || got.Name != "monitoring" || !got.Allows(ScopeStatsRead) || got.Allows(ScopeRecordsWrite) {
+		t.Fatalf("authenticate: %+v %v", got, ok)
+	}
+	if _, ok := s.Authenticate(secret+"x", now); ok {
+		t.Fatal("wrong secret accepted")
+	}
+
+	rotated, _, err := s.Rotate("monitoring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Authenticate(secret, now); ok {
+		t.Fatal("old secret accepted after rotate")
+	}
+	if _, err := s.SetExpiry("monitoring", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Authenticate(rotated, now.Add(2*time.Hour)); ok {
+		t.Fatal("expired credential accepted")
+	}
+
+	// A reopened store sees the same credentials.
+	s2, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s2.Authenticate(rotated, now); !ok || !s2.Enforced() {
+		t.Fatal("credential lost on reopen")
+	}
+	if err := s2.Remove("monitoring"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.Remove("monitoring"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("remove twice: %v", err)
+	}
+}
+
+func TestOpenDamagedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_tokens.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(path)
+	if err == nil || s == nil || !s.Enforced() {
+		t.Fatalf("damaged file: %v %v", s, err)
+	}
+	if _, _, err := s.Create("x", []string{ScopeStatsRead}, time.Time{}); err == nil {
+		t.Fatal("create on damaged store")
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	got, err := ParseScopes("stats:read, records:read,stats:read")
+	if err != nil || !slices.Equal(got, []string{ScopeRecordsRead, ScopeStatsRead}) {
+		t.Fatalf("got %v %v", got, err)
+	}
+	if all, _ := ParseScopes("all"); !slices.Equal(all, AllScopes) {
+		t.Fatalf("all: %v", all)
+	}
+	if _, err := ParseScopes("records:delete"); err == nil {
+		t.Fatal("unknown scope accepted")
+	}
+}
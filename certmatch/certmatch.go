@@ -0,0 +1,77 @@
+// Package certmatch matches X.509 client certificates against identity patterns such as cn:name or dns:host.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package certmatch
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Identity names a certificate in logs and the record history: its common name, else its first
+// subject alternative name, else its serial number.
+func Identity(c *x509.Certificate) string {
+	switch {
+	case c.Subject.CommonName != "":
+		return c.Subject.CommonName
+	case len(c.DNSNames) > 0:
+		return c.DNSNames[0]
+	case len(c.EmailAddresses) > 0:
+		return c.EmailAddresses[0]
+	case len(c.URIs) > 0:
+		return c.URIs[0].String()
+	case len(c.IPAddresses) > 0:
+		return c.IPAddresses[0].String()
+	}
+	return fmt.Sprintf("serial %X", c.SerialNumber)
+}
+
+// Match reports whether c fits any of patterns: "cn:<common name>", "dns:<name>", "email:<address>",
+// "uri:<uri>", "ip:<address>" (subject alternative names), or "*" for any certificate. Names and addresses
+// compare case-insensitively, URIs exactly.
+func Match(c *x509.Certificate, patterns []string) bool {
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "*" {
+			return true
+		}
+		kind, want, ok := strings.Cut(p, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(kind) {
+		case "cn":
+			if strings.EqualFold(c.Subject.CommonName, want) {
+				return true
+			}
+		case "dns":
+			for _, n := range c.DNSNames {
+				if strings.EqualFold(strings.TrimSuffix(n, "."), strings.TrimSuffix(want, ".")) {
+					return true
+				}
+			}
+		case "email":
+			for _, e := range c.EmailAddresses {
+				if strings.EqualFold(e, want) {
+					return true
+				}
+			}
+		case "uri":
+			for _, u := range c.URIs {
+				if u.String() == want {
+					return true
+				}
+			}
+		case "ip":
+			ip := net.ParseIP(want)
+			for _, a := range c.IPAddresses {
+				if ip != nil && a.Equal(ip) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -404,6 +404,7 @@ func (m *Manager) probeOnce(peerAddr, token string) {
 	}
 	rttMs := float64(time.Since(start).Milliseconds())
 	m.peers.recordProbe(peerAddr, true, rttMs, "")
+	m.exchangeCookieSecret(conn)
 }
 
 // NotifyLocalRecordsChanged triggers a non-blocking push to peers after local dnsrecords are saved.
@@ -562,7 +563,7 @@ func (m *Manager) pullOnce(peerAddr, token string) {
 		return
 	}
 	records := msg.Records
-	if err := m.dns.ApplyClusterRecords(records); err != nil {
+	if err := m.dns.ApplyClusterRecords(msg.NodeID, records); err != nil {
 		m.log.Warn("cluster: apply pull", "error", err)
 		m.peers.recordPull(peerAddr, false)
 		return
@@ -630,6 +631,13 @@ func (m *Manager) serveConn(ctx context.Context, conn net.Conn) {
 			m.sendCurrentSnapshot(conn)
 			continue
 		}
+		if probe.Type == TypeCookieSecret {
+			var cookieMsg CookieSecretMessage
+			if json.Unmarshal(frame, &cookieMsg) == nil {
+				m.handleCookieSecret(conn, &cookieMsg)
+			}
+			continue
+		}
 		var adminMsg AdminConfigApplyMessage
 		if json.Unmarshal(frame, &adminMsg) == nil && adminMsg.Type == TypeAdminConfigApply {
 			if err := m.applyAdminConfigFromMessage(&adminMsg); err != nil {
@@ -659,7 +667,7 @@ func (m *Manager) serveConn(ctx context.Context, conn net.Conn) {
 			m.state.CommitPeerSeq(msg.NodeID, msg.Seq)
 			continue
 		}
-		if err := m.dns.ApplyClusterRecords(records); err != nil {
+		if err := m.dns.ApplyClusterRecords(msg.NodeID, records); err != nil {
 			m.log.Warn("cluster: apply incoming", "error", err)
 			continue
 		}
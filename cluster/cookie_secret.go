@@ -0,0 +1,66 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package cluster
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// cookieSecretFrame encodes the local cookie secret, or returns nil when DNS cookies are disabled.
+func (m *Manager) cookieSecretFrame() []byte {
+	srv := m.dns.DNSCookies()
+	if srv == nil {
+		return nil
+	}
+	b, err := json.Marshal(CookieSecretMessage{Type: TypeCookieSecret, NodeID: m.state.NodeID(), Secret: srv.Snapshot()})
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// mergeCookieSecret adopts a peer's secret when it is newer than ours.
+func (m *Manager) mergeCookieSecret(msg *CookieSecretMessage) {
+	srv := m.dns.DNSCookies()
+	if srv == nil {
+		return
+	}
+	if srv.Merge(msg.Secret) {
+		m.log.Info("cluster: adopted DNS cookie secret from peer", "node_id", msg.NodeID, "rotated_unix", msg.Secret.RotatedUnix)
+	}
+}
+
+// exchangeCookieSecret sends our secret on an authenticated connection and merges the peer's reply.
+// Peers without cookies enabled (or older versions) do not answer with cookie_secret; that is not an error.
+func (m *Manager) exchangeCookieSecret(rw io.ReadWriter) {
+	out := m.cookieSecretFrame()
+	if out == nil {
+		return
+	}
+	if err := WriteFrame(rw, out); err != nil {
+		return
+	}
+	frame, err := ReadFrame(rw)
+	if err != nil {
+		return
+	}
+	var msg CookieSecretMessage
+	if json.Unmarshal(frame, &msg) != nil || msg.Type != TypeCookieSecret {
+		return
+	}
+	m.mergeCookieSecret(&msg)
+}
+
+// handleCookieSecret serves an inbound cookie_secret: merge, then reply with our (possibly updated) secret.
+func (m *Manager) handleCookieSecret(w io.Writer, msg *CookieSecretMessage) {
+	m.mergeCookieSecret(msg)
+	out := m.cookieSecretFrame()
+	if out == nil {
+		b, _ := json.Marshal(SimpleMessage{Type: TypeError, Message: "dns cookies disabled"})
+		_ = WriteFrame(w, b)
+		return
+	}
+	_ = WriteFrame(w, out)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 
+	"dnsplane/dnscookie"
 	"dnsplane/dnsrecords"
 	"dnsplane/safecast"
 )
@@ -59,6 +60,7 @@ const (
 	TypeAdminConfigApply = "admin_config_apply"
 	TypeAdminConfigOK    = "admin_config_ok"
 	TypeAdminConfigFail  = "admin_config_fail"
+	TypeCookieSecret     = "cookie_secret"
 )
 
 // AuthMessage is the first message from a client.
@@ -93,3 +95,11 @@ type AdminConfigApplyMessage struct {
 	AdminLocal   *bool    `json:"cluster_admin,omitempty"`
 	SyncInterval *int     `json:"cluster_sync_interval_seconds,omitempty"`
 }
+
+// CookieSecretMessage carries the DNS server cookie secret (RFC 9018) so every node accepts cookies issued
+// by its peers. Sent after a probe ping; the receiver merges it and answers with its own secret.
+type CookieSecretMessage struct {
+	Type   string             `json:"type"`
+	NodeID string             `json:"node_id"`
+	Secret dnscookie.Snapshot `json:"secret"`
+}
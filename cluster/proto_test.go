@@ -5,7 +5,11 @@ package cluster
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
+	"time"
+
+	"dnsplane/dnscookie"
 )
 
 func TestWriteReadFrameRoundTrip(t *testing.T) {
@@ -36,3 +40,24 @@ func TestReadFrameTooLarge(t *testing.T) {
 		t.Fatal("expected error for oversized frame length")
 	}
 }
+
+func TestCookieSecretMessageRoundTrip(t *testing.T) {
+	a := dnscookie.NewServer(time.Hour)
+	a.Rotate()
+	b, err := json.Marshal(CookieSecretMessage{Type: TypeCookieSecret, NodeID: "n1", Secret: a.Snapshot()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out CookieSecretMessage
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	peer := dnscookie.NewServer(time.Hour)
+	if out.Type != TypeCookieSecret || !peer.Merge(out.Secret) {
+		t.Fatalf("peer did not adopt secret: %+v", out)
+	}
+	client := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	if !peer.Verify(client, a.Issue(client, "192.0.2.1"), "192.0.2.1") {
+		t.Fatal("peer should verify cookies issued with the shared secret")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"dnsplane/events"
 )
 
 // PeerStatus is runtime observability for one configured cluster peer (outbound dial target).
@@ -107,14 +109,18 @@ func (t *peerTracker) recordPull(addr string, ok bool) {
 	}
 }
 
+// recordProbe notes a probe result. A peer that fails its first probe or stops answering is published as
+// cluster.peer_down, and one that answers again as cluster.peer_up.
 func (t *peerTracker) recordProbe(addr string, ok bool, rttMs float64, errStr string) {
 	a := strings.TrimSpace(addr)
 	if a == "" {
 		return
 	}
 	t.mu.Lock()
-	defer t.mu.Unlock()
 	p := t.getOrCreateLocked(a)
+	wasDown := p.LastProbeErr != ""
+	firstProbe := p.LastProbeOK == nil && !wasDown
+	wasUp := p.Reachable
 	now := time.Now()
 	if ok {
 		p.LastProbeOK = &now
@@ -125,6 +131,13 @@ func (t *peerTracker) recordProbe(addr string, ok bool, rttMs float64, errStr st
 		p.LastProbeErr = errStr
 		p.Reachable = false
 	}
+	t.mu.Unlock()
+	switch {
+	case ok && wasDown:
+		events.Publish(events.ClusterPeerUp, map[string]any{"peer": a, "rtt_ms": rttMs})
+	case !ok && (wasUp || firstProbe):
+		events.Publish(events.ClusterPeerDown, map[string]any{"peer": a, "error": errStr})
+	}
 }
 
 func (t *peerTracker) snapshot(addrs []string) []PeerStatus {
@@ -0,0 +1,41 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package cluster
+
+import (
+	"testing"
+
+	"dnsplane/events"
+)
+
+func TestRecordProbePublishesTransitions(t *testing.T) {
+	_, sub := events.Subscribe(0, []string{"cluster.*"})
+	defer sub.Close()
+	tr := newPeerTracker()
+	tr.recordProbe("10.0.0.2:7946", false, 0, "connection refused") // first probe fails: down
+	tr.recordProbe("10.0.0.2:7946", false, 0, "connection refused") // still down: nothing
+	tr.recordProbe("10.0.0.2:7946", true, 3, "")                    // up
+	tr.recordProbe("10.0.0.2:7946", true, 2, "")                    // still up: nothing
+	tr.recordProbe("10.0.0.2:7946", false, 0, "i/o timeout")        // down
+	tr.recordProbe("10.0.0.3:7946", true, 1, "")                    // first probe answers: nothing
+
+	var got []string
+	for len(sub.C()) > 0 {
+		e := <-sub.C()
+		got = append(got, e.Type+" "+e.Data["peer"].(string))
+	}
+	want := []string{
+		"cluster.peer_down 10.0.0.2:7946",
+		"cluster.peer_up 10.0.0.2:7946",
+		"cluster.peer_down 10.0.0.2:7946",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("events = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package commandhandler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"dnsplane/apiauth"
+	"dnsplane/cliutil"
+	"dnsplane/data"
+
+	tui "github.com/network-plane/planetui"
+)
+
+func tokenUsage(lines ...string) tui.CommandResult {
+	return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages(lines...)}
+}
+
+func tokenStore() (*apiauth.Store, *tui.CommandResult) {
+	s := data.GetInstance().APITokens()
+	if s == nil {
+		res := recordFileFailed("api tokens are not available", nil)
+		return nil, &res
+	}
+	return s, nil
+}
+
+// tokenSecretMessages shows a new secret once, with how to use it.
+func tokenSecretMessages(c apiauth.Credential, secret, what string) []tui.OutputMessage {
+	msgs := infoMessages(
+		fmt.Sprintf("Token %q %s. Scopes: %s", c.Name, what, strings.Join(c.Scopes, ", ")),
+		"Secret (shown only now): "+secret,
+		"Use it as 'Authorization: Bearer <secret>' or 'X-API-Token: <secret>'.",
+	)
+	if !c.ExpiresAt.IsZero() {
+		msgs = append(msgs, infoMessages("Expires: "+c.ExpiresAt.Local().Format(time.RFC3339))...)
+	}
+	return msgs
+}
+
+// runTokenList shows the API credentials without secrets.
+func runTokenList() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) > 0 {
+			return tokenUsage(
+				"Usage: token list",
+				"Description: List the named API tokens with their scopes and expiry. Secrets are never shown again.",
+			)
+		}
+		s, fail := tokenStore()
+		if fail != nil {
+			return *fail
+		}
+		creds := s.List()
+		if len(creds) == 0 {
+			return tokenUsage("No API tokens. Add one with: token add <name> <scope,...> [expires]")
+		}
+		now := time.Now()
+		var rows [][]string
+		for _, c := range creds {
+			expires := "never"
+			if !c.ExpiresAt.IsZero() {
+				expires = c.ExpiresAt.Local().Format(time.DateTime)
+				if c.Expired(now) {
+					expires += " (expired)"
+				}
+			}
+			changed := c.CreatedAt.Local().Format(time.DateTime)
+			if !c.RotatedAt.IsZero() {
+				changed = c.RotatedAt.Local().Format(time.DateTime) + " (rotated)"
+			}
+			rows = append(rows, []string{c.Name, strings.Join(c.Scopes, ", "), changed, expires})
+		}
+		out := rt.Output()
+		out.WriteTable([]string{"Name", "Scopes", "Issued", "Expires"}, rows)
+		tui.EnsureLineBreak(out)
+		return tui.CommandResult{Status: tui.StatusSuccess, Payload: creds}
+	}
+}
+
+// runTokenAdd creates an API credential and prints its secret once.
+func runTokenAdd() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) < 2 || len(input.Raw) > 3 {
+			return tokenUsage(
+				"Usage: token add <name> <scope,...|all> [expires]",
+				"Description: Create a named API token. Expires is an RFC 3339 time or a duration such as 90d (default never).",
+				"Scopes: "+strings.Join(apiauth.AllScopes, ", "),
+				"Example: token add monitoring stats:read 365d",
+			)
+		}
+		s, fail := tokenStore()
+		if fail != nil {
+			return *fail
+		}
+		scopes, err := apiauth.ParseScopes(input.Raw[1])
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		var expires time.Time
+		if len(input.Raw) == 3 {
+			if expires, err = apiauth.ParseExpiry(input.Raw[2], time.Now()); err != nil {
+				return recordFileFailed(err.Error(), err)
+			}
+		}
+		secret, c, err := s.Create(input.Raw[0], scopes, expires)
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		return tui.CommandResult{Status: tui.StatusSuccess, Messages: tokenSecretMessages(c, secret, "created")}
+	}
+}
+
+// runTokenRotate replaces the secret of an API credential.
+func runTokenRotate() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) != 1 {
+			return tokenUsage(
+				"Usage: token rotate <name>",
+				"Description: Issue a new secret for a token. The old secret stops working at once.",
+			)
+		}
+		s, fail := tokenStore()
+		if fail != nil {
+			return *fail
+		}
+		secret, c, err := s.Rotate(input.Raw[0])
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		return tui.CommandResult{Status: tui.StatusSuccess, Messages: tokenSecretMessages(c, secret, "rotated")}
+	}
+}
+
+// runTokenExpire sets or clears the expiry of an API credential.
+func runTokenExpire() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) != 2 {
+			return tokenUsage(
+				"Usage: token expire <name> <time|duration|never>",
+				"Description: Set when a token stops working: an RFC 3339 time, a duration such as 30d, or never.",
+				"Example: token expire ci 12h",
+			)
+		}
+		s, fail := tokenStore()
+		if fail != nil {
+			return *fail
+		}
+		expires, err := apiauth.ParseExpiry(input.Raw[1], time.Now())
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		c, err := s.SetExpiry(input.Raw[0], expires)
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		msg := fmt.Sprintf("Token %q never expires.", c.Name)
+		if !c.ExpiresAt.IsZero() {
+			msg = fmt.Sprintf("Token %q expires %s.", c.Name, c.ExpiresAt.Local().Format(time.RFC3339))
+		}
+		return tokenUsage(msg)
+	}
+}
+
+// runTokenRemove deletes an API credential.
+func runTokenRemove() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) != 1 {
+			return tokenUsage(
+				"Usage: token remove <name>",
+				"Description: Delete a token; requests using it are refused from now on.",
+			)
+		}
+		s, fail := tokenStore()
+		if fail != nil {
+			return *fail
+		}
+		if err := s.Remove(input.Raw[0]); err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		return tokenUsage(fmt.Sprintf("Token %q removed.", input.Raw[0]))
+	}
+}
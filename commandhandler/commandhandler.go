@@ -14,6 +14,7 @@ import (
 	"os"
 	"runtime"
 	"runtime/metrics"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -37,18 +38,17 @@ import (
 
 // Function variables for server control
 var (
-	stopDNSServerFunc       func()
-	restartDNSServerFunc    func(string)
-	getServerStatusFunc     func() bool
-	startGinAPIFunc         func(string)
-	stopAPIFunc             func()
-	startClientTCPFunc      func()
-	stopClientTCPFunc       func()
-	isCurrentSessionTCPFunc func() bool
-	getServerListenersFunc  func() ServerListenerInfo
-	serverVersionStr        string
-	clientVersionStr        string
-	fullStatsTracker        *fullstats.Tracker
+	stopDNSServerFunc      func()
+	restartDNSServerFunc   func(string)
+	getServerStatusFunc    func() bool
+	startGinAPIFunc        func(string)
+	stopAPIFunc            func()
+	startClientTCPFunc     func()
+	stopClientTCPFunc      func()
+	getServerListenersFunc func() ServerListenerInfo
+	serverVersionStr       string
+	clientVersionStr       string
+	fullStatsTracker       *fullstats.Tracker
 )
 
 // ServerListenerInfo describes runtime listener configuration for status output.
@@ -63,6 +63,8 @@ type ServerListenerInfo struct {
 	ClientTCPEndpoint   string
 	ClientTCPEnabled    bool
 	ClientTCPRunning    bool
+	ClientSSHEndpoint   string
+	ClientSSHRunning    bool
 }
 
 // RegisterServerControlHooks wires runtime control functions for server commands.
@@ -70,7 +72,6 @@ func RegisterServerControlHooks(
 	stop func(), restart func(string), status func() bool,
 	startAPI func(string), stopAPI func(),
 	startClientTCP func(), stopClientTCP func(),
-	isSessionTCP func() bool,
 	listeners func() ServerListenerInfo,
 ) {
 	stopDNSServerFunc = stop
@@ -80,7 +81,6 @@ func RegisterServerControlHooks(
 	stopAPIFunc = stopAPI
 	startClientTCPFunc = startClientTCP
 	stopClientTCPFunc = stopClientTCP
-	isCurrentSessionTCPFunc = isSessionTCP
 	getServerListenersFunc = listeners
 }
 
@@ -302,7 +302,7 @@ func newLegacyFactory(spec tui.CommandSpec, run func(tui.CommandRuntime, tui.Com
 	return &factory{spec: spec, run: wrapped}
 }
 
-func registerContexts() {
+func registerContexts(e *tui.Engine) {
 	contexts := []struct {
 		name        string
 		description string
@@ -316,13 +316,10 @@ func registerContexts() {
 		{name: "tools", description: "- Diagnostic Tools", tags: []string{"tools", "diagnostics"}},
 		{name: "adblock", description: "- Adblock Management", tags: []string{"adblock", "blocking"}},
 		{name: "cluster", description: "- Cluster (sync, peers, admin)", tags: []string{"cluster", "sync", "ha"}},
+		{name: "token", description: "- API Tokens (scopes, rotation, expiry)", tags: []string{"api", "auth"}},
 	}
 	for _, ctx := range contexts {
-		var opts []tui.ContextOption
-		if len(ctx.tags) > 0 {
-			opts = append(opts, tui.WithContextTags(ctx.tags...))
-		}
-		tui.RegisterContext(ctx.name, ctx.description, opts...)
+		e.RegisterContext(tui.ContextSpec{Name: ctx.name, Description: ctx.description, Tags: ctx.tags})
 	}
 }
 
@@ -344,7 +341,7 @@ func runRecordList() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResul
 		rt.Session().Set("record:last_count", len(listResult.Records))
 		renderRecordTable(rt.Output(), listResult.Records)
 		if listResult.Detailed {
-			renderRecordDetails(rt.Output(), listResult.Records)
+			renderRecordDetails(rt.Output(), listResult.Records, dnsData.RecordHealthStatuses())
 		}
 		return result
 	}
@@ -370,7 +367,7 @@ func runRecordAdd(allowUpdate bool) func(tui.CommandRuntime, tui.CommandInput) t
 			result.Error = commandErrorFromRecordErr(err)
 			return result
 		}
-		if err := dnsData.UpdateRecords(updated); err != nil {
+		if err := dnsData.UpdateRecords(tuiOrigin(), updated); err != nil {
 			result.Status = tui.StatusFailed
 			result.Error = &tui.CommandError{Err: err, Message: err.Error(), Severity: tui.SeverityError}
 			return result
@@ -394,7 +391,7 @@ func runRecordRemove() func(tui.CommandRuntime, tui.CommandInput) tui.CommandRes
 			result.Error = commandErrorFromRecordErr(err)
 			return result
 		}
-		if err := dnsData.UpdateRecords(updated); err != nil {
+		if err := dnsData.UpdateRecords(tuiOrigin(), updated); err != nil {
 			result.Status = tui.StatusFailed
 			result.Error = &tui.CommandError{Err: err, Message: err.Error(), Severity: tui.SeverityError}
 			return result
@@ -419,7 +416,7 @@ func runRecordClear() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResu
 			return tui.CommandResult{Status: tui.StatusFailed, Messages: msgs, Error: &tui.CommandError{Message: "unexpected arguments", Severity: tui.SeverityWarning}}
 		}
 		dnsData := data.GetInstance()
-		if err := dnsData.UpdateRecordsInMemory([]dnsrecords.DNSRecord{}); err != nil {
+		if err := dnsData.UpdateRecordsInMemory(tuiOrigin(), []dnsrecords.DNSRecord{}); err != nil {
 			return tui.CommandResult{
 				Status: tui.StatusFailed,
 				Error:  &tui.CommandError{Err: err, Message: err.Error(), Severity: tui.SeverityError},
@@ -819,15 +816,32 @@ func renderRecordTable(out tui.OutputChannel, records []dnsrecords.DNSRecord) {
 	if len(records) == 0 {
 		return
 	}
+	withMeta := slices.ContainsFunc(records, dnsrecords.DNSRecord.HasMetadata)
 	rows := make([][]string, 0, len(records))
 	for _, record := range records {
-		rows = append(rows, []string{record.Name, record.Type, record.Value, fmt.Sprintf("%d", record.TTL)})
+		row := []string{record.Name, record.Type, record.Value, fmt.Sprintf("%d", record.TTL)}
+		if withMeta {
+			expires := ""
+			if !record.ExpiresAt.IsZero() {
+				expires = record.ExpiresAt.Local().Format(time.DateTime)
+			}
+			row = append(row, record.Owner, strings.Join(record.Tags, ","), expires)
+		}
+		rows = append(rows, row)
 	}
-	out.WriteTable([]string{"Name", "Type", "Value", "TTL"}, rows)
+	headers := []string{"Name", "Type", "Value", "TTL"}
+	if withMeta {
+		headers = append(headers, "Owner", "Tags", "Expires")
+	}
+	out.WriteTable(headers, rows)
 	tui.EnsureLineBreak(out)
 }
 
-func renderRecordDetails(out tui.OutputChannel, records []dnsrecords.DNSRecord) {
+func renderRecordDetails(out tui.OutputChannel, records []dnsrecords.DNSRecord, health []data.RecordHealthStatus) {
+	byCheck := make(map[string]data.RecordHealthStatus, len(health))
+	for _, st := range health {
+		byCheck[st.Check] = st
+	}
 	for _, record := range records {
 		var details []string
 		if !record.AddedOn.IsZero() {
@@ -842,9 +856,33 @@ func renderRecordDetails(out tui.OutputChannel, records []dnsrecords.DNSRecord)
 		if record.MACAddress != "" {
 			details = append(details, fmt.Sprintf("MAC Address: %s", record.MACAddress))
 		}
+		if record.AutoSource != "" {
+			details = append(details, "Registered By: "+record.AutoSource)
+		}
 		if record.CacheRecord {
 			details = append(details, "Cache Record: true")
 		}
+		if record.Priority > 0 || record.Weight > 0 {
+			details = append(details, fmt.Sprintf("Priority: %d  Weight: %d", record.Priority, record.Weight))
+		}
+		if hc := record.HealthCheck; hc != nil {
+			details = append(details, "Health: "+recordHealthLine(hc.Key(record.Value), byCheck))
+		}
+		if record.Geo != nil {
+			details = append(details, "Geo: "+record.Geo.String())
+		}
+		if len(record.Tags) > 0 {
+			details = append(details, "Tags: "+strings.Join(record.Tags, ", "))
+		}
+		if record.Owner != "" {
+			details = append(details, "Owner: "+record.Owner)
+		}
+		if record.Comment != "" {
+			details = append(details, "Comment: "+record.Comment)
+		}
+		if !record.ExpiresAt.IsZero() {
+			details = append(details, fmt.Sprintf("Expires At: %s", record.ExpiresAt.Format(time.RFC3339)))
+		}
 		if len(details) == 0 {
 			continue
 		}
@@ -856,6 +894,21 @@ func renderRecordDetails(out tui.OutputChannel, records []dnsrecords.DNSRecord)
 	}
 }
 
+// recordHealthLine summarizes one probe for record list details.
+func recordHealthLine(key string, byCheck map[string]data.RecordHealthStatus) string {
+	st, ok := byCheck[key]
+	switch {
+	case !ok || st.LastProbeAt == "":
+		return key + " (not probed yet)"
+	case st.Unhealthy:
+		return fmt.Sprintf("%s DOWN (%d failures: %s)", key, st.ConsecutiveFailures, st.LastProbeError)
+	case st.ConsecutiveFailures > 0:
+		return fmt.Sprintf("%s up (%d failures: %s)", key, st.ConsecutiveFailures, st.LastProbeError)
+	default:
+		return key + " up"
+	}
+}
+
 func renderCacheTable(out tui.OutputChannel, cache []dnsrecordcache.CacheRecord) {
 	if len(cache) == 0 {
 		return
@@ -1245,7 +1298,12 @@ func toInterface(values []string, count int) []interface{} {
 
 // RegisterCommands registers all DNS related contexts and commands with the TUI package.
 func RegisterCommands() {
-	registerContexts()
+	registerCommands(tui.DefaultEngine())
+}
+
+// registerCommands registers the contexts and commands with e.
+func registerCommands(e *tui.Engine) {
+	registerContexts(e)
 
 	commands := []tui.CommandFactory{
 		newLegacyFactory(tui.CommandSpec{
@@ -1316,8 +1374,8 @@ func RegisterCommands() {
 			Context:     "record",
 			Name:        "add",
 			Summary:     "Add a DNS record",
-			Description: "Adds a DNS record to the in-memory store. Accepts <name> [type] <value> [ttl] syntax.",
-			Usage:       "record add <name> [type] <value> [ttl]",
+			Description: "Adds a DNS record to the in-memory store. Accepts <name> [type] <value> [ttl] syntax, followed by optional tag=, owner=, comment=, and expires= metadata.",
+			Usage:       "record add <name> [type] <value> [ttl] [tag=<tag>] [owner=<owner>] [comment=<text>] [expires=<time|duration>]",
 			Category:    "DNS Records",
 			Tags:        []string{"records", "create"},
 			Args: []tui.ArgSpec{
@@ -1326,6 +1384,8 @@ func RegisterCommands() {
 			Examples: []tui.Example{
 				{Description: "Add an A record", Command: "record add example.com A 127.0.0.1 3600"},
 				{Description: "Add record inferring type", Command: "record add example.com 127.0.0.1"},
+				{Description: "Point a zone apex at a load balancer (flattened to A/AAAA)", Command: "record add example.com ALIAS lb-1234.elb.amazonaws.com 300"},
+				{Description: "Add a temporary record that is removed after a week", Command: "record add old.example.com A 192.0.2.7 tag=migration owner=netops expires=7d"},
 			},
 		}, runRecordAdd(false)),
 		newLegacyFactory(tui.CommandSpec{
@@ -1359,16 +1419,18 @@ func RegisterCommands() {
 			Name:        "list",
 			Summary:     "List DNS records",
 			Description: "Displays configured DNS records with optional detail mode and filtering.",
-			Usage:       "record list [details|d] [filter]",
+			Usage:       "record list [details|d] [filter] [tag=<tag>] [owner=<owner>] [expires]",
 			Category:    "DNS Records",
 			Tags:        []string{"records", "list"},
 			Args: []tui.ArgSpec{
 				{Name: "mode", Description: "Use 'details' or 'd' for verbose output"},
 				{Name: "filter", Description: "Optional filter by name or type", Required: false},
+				{Name: "selector", Description: "Optional tag=, owner=, or expires selector", Required: false, Repeatable: true},
 			},
 			Examples: []tui.Example{
 				{Description: "List records", Command: "record list"},
 				{Description: "Show detailed records", Command: "record list details"},
+				{Description: "List records tagged migration owned by netops", Command: "record list tag=migration owner=netops"},
 			},
 		}, runRecordList()),
 		newLegacyFactory(tui.CommandSpec{
@@ -1401,6 +1463,87 @@ func RegisterCommands() {
 			Tags:        []string{"records", "save"},
 			Examples:    []tui.Example{{Description: "Save records", Command: "record save"}},
 		}, runRecordSave()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "record",
+			Name:        "export",
+			Summary:     "Export DNS records as a zone file",
+			Description: "Prints local records as BIND zone files grouped by apex ($ORIGIN/$TTL, SOA first, canonical order), or as JSON, CSV, or octoDNS YAML.",
+			Usage:       "record export [bind|json|csv|octodns-yaml] [zone]",
+			Category:    "DNS Records",
+			Tags:        []string{"records", "export", "zone"},
+			Args: []tui.ArgSpec{
+				{Name: "format", Description: "bind (default), json, csv, or octodns-yaml", Required: false},
+				{Name: "zone", Description: "Only the records of this zone", Required: false},
+			},
+			Examples: []tui.Example{
+				{Description: "Export every zone", Command: "record export"},
+				{Description: "Export one zone for octoDNS", Command: "record export octodns-yaml example.com"},
+			},
+		}, runRecordExport()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "record",
+			Name:        "import",
+			Summary:     "Import DNS records from a zone file",
+			Description: "Replaces the records of a zone with those in a BIND, JSON, CSV, or octoDNS YAML file on the server host and shows the diff. dry-run shows the diff without changing anything.",
+			Usage:       "record import <file> [bind|json|csv|octodns-yaml] [zone] [dry-run]",
+			Category:    "DNS Records",
+			Tags:        []string{"records", "import", "zone"},
+			Args: []tui.ArgSpec{
+				{Name: "file", Description: "Path on the server host"},
+				{Name: "options", Description: "Format, zone, and dry-run in any order", Repeatable: true},
+			},
+			Examples: []tui.Example{
+				{Description: "Preview a zone import", Command: "record import /tmp/example.com.zone example.com dry-run"},
+			},
+		}, runRecordImport()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "record",
+			Name:        "history",
+			Summary:     "Show the change history of DNS records",
+			Description: "Lists record history entries, newest first: revision, time, source, actor, and the records added, removed, or changed. Needs records_history.",
+			Usage:       "record history [name|zone=<zone>|id=<id>] [limit]",
+			Category:    "DNS Records",
+			Tags:        []string{"records", "history", "audit"},
+			Args: []tui.ArgSpec{
+				{Name: "filter", Description: "A record name, zone=<zone>, or id=<id>", Required: false},
+				{Name: "limit", Description: "Number of entries (default 20)", Required: false},
+			},
+			Examples: []tui.Example{
+				{Description: "Recent changes", Command: "record history"},
+				{Description: "Changes in a zone", Command: "record history zone=example.com"},
+			},
+		}, runRecordHistory()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "record",
+			Name:        "diff",
+			Summary:     "Compare DNS records at two points in history",
+			Description: "Shows the records removed and added between two revisions or RFC 3339 times; the second point defaults to the newest revision.",
+			Usage:       "record diff <rev|time> [rev|time]",
+			Category:    "DNS Records",
+			Tags:        []string{"records", "history", "diff"},
+			Args: []tui.ArgSpec{
+				{Name: "from", Description: "Revision or RFC 3339 time", Required: true},
+				{Name: "to", Description: "Revision or RFC 3339 time (default newest)", Required: false},
+			},
+			Examples: []tui.Example{
+				{Description: "What changed since revision 12", Command: "record diff 12"},
+			},
+		}, runRecordDiff()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "record",
+			Name:        "rollback",
+			Summary:     "Restore DNS records to an earlier revision",
+			Description: "Replaces the records with those at a revision or RFC 3339 time, saves them, and pushes them to cluster peers. The rollback is itself a new history entry.",
+			Usage:       "record rollback <rev|time>",
+			Category:    "DNS Records",
+			Tags:        []string{"records", "history", "rollback"},
+			Args: []tui.ArgSpec{
+				{Name: "to", Description: "Revision or RFC 3339 time", Required: true},
+			},
+			Examples: []tui.Example{
+				{Description: "Undo everything after revision 12", Command: "record rollback 12"},
+			},
+		}, runRecordRollback()),
 
 		newLegacyFactory(tui.CommandSpec{
 			Context:     "cache",
@@ -1535,6 +1678,29 @@ func RegisterCommands() {
 			Category:    "Upstream Servers",
 			Tags:        []string{"dns", "servers", "save"},
 		}, runDNSSave()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "dns",
+			Name:        "zones",
+			Summary:     "List forward zones",
+			Description: "Displays conditional forwarding zones from forward_zones, including generated reverse zones.",
+			Usage:       "dns zones",
+			Category:    "Upstream Servers",
+			Tags:        []string{"dns", "forward", "zones"},
+		}, runDNSZones()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "dns",
+			Name:        "route",
+			Summary:     "Show which upstream rule a name hits",
+			Description: "Reports whether a query name is sent to a forward zone (and its mode), a whitelisted server, or the global servers.",
+			Usage:       "dns route <name>",
+			Category:    "Upstream Servers",
+			Tags:        []string{"dns", "forward", "route", "diagnostics"},
+			Args:        []tui.ArgSpec{{Name: "name", Description: "Query name to test", Required: true}},
+			Examples: []tui.Example{
+				{Description: "Test an internal name", Command: "dns route host.corp.example"},
+				{Description: "Test a reverse name", Command: "dns route 5.1.20.10.in-addr.arpa"},
+			},
+		}, runDNSRoute()),
 
 		newLegacyFactory(tui.CommandSpec{
 			Context:     "server",
@@ -1560,8 +1726,8 @@ func RegisterCommands() {
 			Context:     "server",
 			Name:        "status",
 			Summary:     "Show server status",
-			Description: "Displays listener details for DNS, API, and CLI clients.",
-			Usage:       "server status [dns|api|client]",
+			Description: "Displays listener details for DNS, API, and CLI clients, and the records sources.",
+			Usage:       "server status [dns|api|client|records]",
 			Category:    "Server",
 			Tags:        []string{"server", "status"},
 			Args:        []tui.ArgSpec{{Name: "component", Description: "Component to inspect", Required: false}},
@@ -1780,10 +1946,68 @@ func RegisterCommands() {
 				{Description: "Push config to peer", Command: "cluster push config 192.168.1.5:7946"},
 			},
 		}, runClusterPush()),
+
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "token",
+			Name:        "list",
+			Summary:     "List API tokens",
+			Description: "Lists the named API tokens with their scopes, issue time, and expiry.",
+			Usage:       "token list",
+			Category:    "API Tokens",
+			Tags:        []string{"api", "auth", "list"},
+		}, runTokenList()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "token",
+			Name:        "add",
+			Summary:     "Create an API token",
+			Description: "Creates a named API token with the given scopes and prints its secret once.",
+			Usage:       "token add <name> <scope,...|all> [expires]",
+			Category:    "API Tokens",
+			Tags:        []string{"api", "auth", "add"},
+			Args: []tui.ArgSpec{
+				{Name: "name", Description: "Token name (shown in logs and record history)", Required: true},
+				{Name: "scopes", Description: "Comma-separated scopes, or all", Required: true},
+				{Name: "expires", Description: "RFC 3339 time or duration such as 90d", Required: false},
+			},
+			Examples: []tui.Example{{Description: "Read-only token for monitoring", Command: "token add monitoring stats:read"}},
+		}, runTokenAdd()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "token",
+			Name:        "rotate",
+			Summary:     "Rotate an API token",
+			Description: "Issues a new secret for a token; the old secret stops working at once.",
+			Usage:       "token rotate <name>",
+			Category:    "API Tokens",
+			Tags:        []string{"api", "auth", "rotate"},
+			Args:        []tui.ArgSpec{{Name: "name", Description: "Token name", Required: true}},
+		}, runTokenRotate()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "token",
+			Name:        "expire",
+			Summary:     "Set API token expiry",
+			Description: "Sets when a token stops working, or never.",
+			Usage:       "token expire <name> <time|duration|never>",
+			Category:    "API Tokens",
+			Tags:        []string{"api", "auth", "expire"},
+			Args: []tui.ArgSpec{
+				{Name: "name", Description: "Token name", Required: true},
+				{Name: "expires", Description: "RFC 3339 time, duration such as 30d, or never", Required: true},
+			},
+		}, runTokenExpire()),
+		newLegacyFactory(tui.CommandSpec{
+			Context:     "token",
+			Name:        "remove",
+			Summary:     "Remove an API token",
+			Description: "Deletes a named API token.",
+			Usage:       "token remove <name>",
+			Category:    "API Tokens",
+			Tags:        []string{"api", "auth", "delete"},
+			Args:        []tui.ArgSpec{{Name: "name", Description: "Token name", Required: true}},
+		}, runTokenRemove()),
 	}
 
 	for _, cmd := range commands {
-		tui.RegisterCommand(cmd)
+		e.RegisterCommand(cmd)
 	}
 }
 
@@ -1888,7 +2112,7 @@ func handleServerStop(args []string) {
 		return
 	}
 	component := strings.ToLower(args[0])
-	if component == "client" && isCurrentSessionTCPFunc != nil && isCurrentSessionTCPFunc() {
+	if component == "client" && activeSession().Transport == TransportTCP {
 		if len(args) < 2 || strings.ToLower(strings.TrimSpace(args[1])) != "confirm" {
 			fmt.Println("Warning: You are currently connected over TCP. Stopping the client service will disconnect your session.")
 			fmt.Println("Run 'server stop client confirm' to proceed.")
@@ -2016,6 +2240,41 @@ func handleServerStatus(args []string) {
 			}
 		}
 		fmt.Printf("  TCP:         %s\n", tcpStatus)
+		sshStatus := "disabled"
+		if info.ClientSSHEndpoint != "" {
+			sshStatus = info.ClientSSHEndpoint + " (stopped)"
+			if info.ClientSSHRunning {
+				sshStatus = info.ClientSSHEndpoint + " (running)"
+			}
+		}
+		fmt.Printf("  SSH:         %s\n", sshStatus)
+	}
+
+	printRecordSources := func() {
+		fmt.Println("Records Sources:")
+		for _, src := range data.RecordSourcesStatus() {
+			access := "read-only"
+			if src.Writable {
+				access = "writable"
+			}
+			fmt.Printf("  %s (%s, %s, %s): %s\n", src.Name, src.Type, src.Merge, access, src.Location)
+			fmt.Printf("    Records: %d loaded, %d served\n", src.Records, src.Served)
+			refreshed := "never"
+			if !src.RefreshedAt.IsZero() {
+				refreshed = src.RefreshedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Printf("    Refreshed: %s", refreshed)
+			if src.RefreshIntervalSeconds > 0 {
+				fmt.Printf(" (every %ds)", src.RefreshIntervalSeconds)
+			}
+			if src.Watch {
+				fmt.Print(" (watched)")
+			}
+			fmt.Println()
+			if src.LastError != "" {
+				fmt.Printf("    Last error: %s\n", src.LastError)
+			}
+		}
 	}
 
 	switch component {
@@ -2028,10 +2287,14 @@ func handleServerStatus(args []string) {
 		printAPI()
 		fmt.Println()
 		printClients()
+		fmt.Println()
+		printRecordSources()
 	case "api":
 		printAPI()
 	case "client", "clients":
 		printClients()
+	case "records", "sources":
+		printRecordSources()
 	default:
 		display := original
 		if display == "" {
@@ -2053,6 +2316,14 @@ func printAllServerConfig(settings config.Config) {
 	fmt.Println("  API:")
 	fmt.Printf("    apiport:   %s\n", settings.RESTPort)
 	fmt.Printf("    api:       %v\n", settings.APIEnabled)
+	fmt.Printf("    api_tokens_file: %s (%d tokens)\n", settings.APITokensFile, len(data.GetInstance().APITokens().List()))
+	if strings.TrimSpace(settings.APITLSClientCAFile) != "" {
+		fmt.Printf("    api_tls_client_ca:  %s\n", settings.APITLSClientCAFile)
+		fmt.Printf("    api_tls_client_crl: %s\n", settings.APITLSClientCRLFile)
+		for _, role := range settings.APITLSClientRoles {
+			fmt.Printf("    client role %s: %s -> %s\n", role.Name, strings.Join(role.Match, ", "), strings.Join(role.Scopes, ", "))
+		}
+	}
 	if strings.TrimSpace(settings.APIAuthToken) != "" {
 		fmt.Println("    api_auth_token: (set)")
 	} else {
@@ -2061,6 +2332,17 @@ func printAllServerConfig(settings config.Config) {
 	fmt.Println("  Client access:")
 	fmt.Printf("    server_socket: %s\n", settings.ClientSocketPath)
 	fmt.Printf("    server_tcp:    %s\n", settings.ClientTCPAddress)
+	if ta := settings.TUIAuth; ta.TLSEnabled() || ta.LoginRequired() {
+		fmt.Printf("    tui_auth:      tls=%v client_ca=%q users=%d (lockout after %d failures for %ds)\n",
+			ta.TLSEnabled(), ta.ClientCA, len(ta.Users), ta.MaxFailures, ta.LockoutSeconds)
+	} else {
+		fmt.Println("    tui_auth:      off (plain TCP, no login)")
+	}
+	if ssh := settings.TUISSH; strings.TrimSpace(ssh.Listen) != "" {
+		fmt.Printf("    tui_ssh:       %s (host key %s)\n", ssh.Listen, ssh.HostKey)
+	} else {
+		fmt.Println("    tui_ssh:       off")
+	}
 	fmt.Println("  Behaviour:")
 	fmt.Printf("    cache_records:        %v\n", settings.CacheRecords)
 	fmt.Printf("    local_records_enabled: %v\n", settings.LocalRecordsEnabled)
@@ -2089,7 +2371,16 @@ func printAllServerConfig(settings config.Config) {
 	fmt.Println("  File locations:")
 	fmt.Printf("    dnsservers:  %s\n", settings.FileLocations.DNSServerFile)
 	fmt.Printf("    cache:       %s\n", settings.FileLocations.CacheFile)
-	if settings.FileLocations.RecordsSource != nil {
+	if len(settings.FileLocations.RecordsSources) > 0 {
+		fmt.Println("    records_sources:")
+		for _, rs := range settings.FileLocations.RecordsSources {
+			fmt.Printf("      %s: type=%s location=%s merge=%s writable=%v", rs.Name, rs.Type, rs.Location, rs.Merge, rs.Writable)
+			if rs.RefreshIntervalSeconds > 0 {
+				fmt.Printf(" refresh_interval_seconds=%d", rs.RefreshIntervalSeconds)
+			}
+			fmt.Println()
+		}
+	} else if settings.FileLocations.RecordsSource != nil {
 		rs := settings.FileLocations.RecordsSource
 		fmt.Printf("    records_source: type=%s location=%s", rs.Type, rs.Location)
 		if rs.Type == "url" || rs.Type == "git" {
@@ -2178,6 +2469,12 @@ func applyConfigSetting(cfg *config.Config, setting, value string) (successMsg s
 	case "api_tls_key":
 		cfg.APITLSKeyFile = value
 		return fmt.Sprintf("api_tls_key set to %s", value), nil
+	case "api_tls_client_ca":
+		cfg.APITLSClientCAFile = value
+		return fmt.Sprintf("api_tls_client_ca set to %q (empty = no client certificates; restart the API to apply)", value), nil
+	case "api_tls_client_crl":
+		cfg.APITLSClientCRLFile = value
+		return fmt.Sprintf("api_tls_client_crl set to %q (restart the API to apply)", value), nil
 	case "api_rate_limit_rps":
 		f, e := strconv.ParseFloat(value, 64)
 		if e != nil || f < 0 {
@@ -2812,8 +3109,8 @@ func printServerStopUsage() {
 }
 
 func printServerStatusUsage() {
-	fmt.Println("Usage: server status [dns|api|client]")
-	fmt.Println("Description: Show listener details for DNS, API, and CLI clients. Defaults to all when omitted.")
+	fmt.Println("Usage: server status [dns|api|client|records]")
+	fmt.Println("Description: Show listener details for DNS, API, and CLI clients, and the records sources. Defaults to all when omitted.")
 	printHelpAliasesHint()
 }
 
@@ -3,8 +3,12 @@
 package commandhandler
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	"dnsplane/config"
+
 	"github.com/miekg/dns"
 )
 
@@ -133,3 +137,45 @@ func TestParseDigArguments(t *testing.T) {
 	})
 
 }
+
+func TestCommandAllowed(t *testing.T) {
+	if !CommandAllowed(config.TUIRoleReadOnly, "record", "list") || !CommandAllowed(config.TUIRoleReadOnly, "", "stats") {
+		t.Fatal("read-only role refused a listing")
+	}
+	if CommandAllowed(config.TUIRoleReadOnly, "record", "add") || CommandAllowed(config.TUIRoleReadOnly, "token", "list") {
+		t.Fatal("read-only role allowed a change or token access")
+	}
+	if !CommandAllowed(config.TUIRoleAdmin, "record", "clear") {
+		t.Fatal("admin refused")
+	}
+	if got := redactCommandArgs("server set", []string{"api_auth_token", "s3cret"}); got[1] != "(redacted)" {
+		t.Fatalf("not redacted: %v", got)
+	}
+	if got := redactCommandArgs("server set", []string{"apiport", "8080"}); got[1] != "8080" {
+		t.Fatalf("redacted: %v", got)
+	}
+}
+
+func TestExec(t *testing.T) {
+	SetVersion("9.9.9", "9.9.9")
+	var out bytes.Buffer
+	ro := NewEngine(Session{User: "alice", Role: config.TUIRoleReadOnly, Transport: TransportSSH}, &out, nil)
+	if !Exec(ro, "server version", &out, nil) || !strings.Contains(out.String(), "9.9.9") {
+		t.Fatalf("server version: %q", out.String())
+	}
+	out.Reset()
+	if Exec(ro, "server set apiport 9999", &out, nil) || !strings.Contains(out.String(), "needs the admin role") {
+		t.Fatalf("read-only server set: %q", out.String())
+	}
+	out.Reset()
+	if !Exec(ro, "help", &out, nil) || !strings.Contains(out.String(), "record") {
+		t.Fatalf("help: %q", out.String())
+	}
+	out.Reset()
+	if Exec(ro, "server bogus", &out, nil) || !strings.Contains(out.String(), "unknown command: server bogus") {
+		t.Fatalf("unknown command: %q", out.String())
+	}
+	if s := activeSession(); s.Transport == TransportSSH {
+		t.Fatalf("session still active after Exec: %+v", s)
+	}
+}
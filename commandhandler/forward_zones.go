@@ -0,0 +1,117 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package commandhandler
+
+import (
+	"fmt"
+	"strings"
+
+	"dnsplane/cliutil"
+	"dnsplane/data"
+	"dnsplane/dnsservers"
+	"dnsplane/forwardzone"
+
+	tui "github.com/network-plane/planetui"
+)
+
+func runDNSZones() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) {
+			msgs := infoMessages(
+				"Usage: dns zones",
+				"Description: List conditional forwarding zones (forward_zones), including reverse zones generated from CIDRs.",
+				"Hint: append '?', 'help', or 'h' after the command to view this usage.",
+			)
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs}
+		}
+		zones := data.GetInstance().ForwardZones().Zones()
+		if len(zones) == 0 {
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages("No forward zones configured.")}
+		}
+		rows := make([][]string, 0, len(zones))
+		for _, z := range zones {
+			rows = append(rows, []string{z.Name, dashIfEmpty(z.Label), z.Mode(), zoneTimeout(z), z.Source, endpointList(z.Servers)})
+		}
+		rt.Output().WriteTable([]string{"Zone", "Name", "Forward", "Timeout", "Source", "Servers"}, rows)
+		tui.EnsureLineBreak(rt.Output())
+		return tui.CommandResult{Status: tui.StatusSuccess, Payload: zones}
+	}
+}
+
+// runDNSRoute reports which forwarding rule a name hits: forward zone, built-in empty zone, server whitelist,
+// or global servers.
+func runDNSRoute() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) != 1 {
+			msgs := infoMessages(
+				"Usage: dns route <name>",
+				"Description: Show which upstream rule a query name hits (forward zone, empty local zone, server whitelist, or global servers).",
+				"Example: dns route host.corp.example",
+			)
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs}
+		}
+		name := strings.TrimSpace(input.Raw[0])
+		dnsData := data.GetInstance()
+		if z := dnsData.ForwardZones().Match(name); z != nil {
+			lines := []string{
+				fmt.Sprintf("%s → forward zone %s (%s, forward %s, timeout %s)", name, z.Name, dashIfEmpty(z.Label), z.Mode(), zoneTimeout(z)),
+				"Servers: " + endpointList(z.Servers),
+			}
+			if z.First {
+				lines = append(lines, "If every zone server fails: normal upstream selection.")
+			}
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages(lines...), Payload: z}
+		}
+		servers := dnsData.GetServers()
+		eps := dnsservers.GetUpstreamEndpointsForQuery(servers, name, true)
+		rule := "global servers"
+		for _, s := range servers {
+			if dnsservers.ServerMatchesQuery(s, name) {
+				rule = "server whitelist"
+				break
+			}
+		}
+		st := dnsData.GetResolverSettings()
+		zones := dnsData.LocalZones()
+		if zone := zones.Match(name); zone != "" && rule == "global servers" {
+			if zones.HasData(zone) {
+				rule = "global servers (empty zone " + zone + " disabled: local records present)"
+			} else {
+				msg := fmt.Sprintf("%s → built-in empty zone %s (NXDOMAIN + SOA, never forwarded; see local_zones)", name, zone)
+				return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages(msg)}
+			}
+		}
+		lines := []string{fmt.Sprintf("%s → %s", name, rule), "Servers: " + endpointList(eps)}
+		if rule != "server whitelist" && st.FallbackServerIP != "" {
+			fb := dnsservers.FallbackEndpoint(st.FallbackServerIP, st.FallbackServerPort, st.FallbackServerTransport)
+			lines = append(lines, "Fallback: "+fb.String())
+		}
+		return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages(lines...)}
+	}
+}
+
+func zoneTimeout(z *forwardzone.Zone) string {
+	if z.Timeout <= 0 {
+		return "default"
+	}
+	return z.Timeout.String()
+}
+
+func endpointList(eps []dnsservers.UpstreamEndpoint) string {
+	if len(eps) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(eps))
+	for _, e := range eps {
+		parts = append(parts, e.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
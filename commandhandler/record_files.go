@@ -0,0 +1,110 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package commandhandler
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"dnsplane/cliutil"
+	"dnsplane/data"
+	"dnsplane/zones"
+
+	tui "github.com/network-plane/planetui"
+)
+
+func isZoneFormat(s string) bool {
+	_, err := zones.ParseFormat(s)
+	return err == nil && strings.TrimSpace(s) != ""
+}
+
+func recordFileFailed(msg string, err error) tui.CommandResult {
+	return tui.CommandResult{Status: tui.StatusFailed, Error: &tui.CommandError{Err: err, Message: msg, Severity: tui.SeverityError}}
+}
+
+// runRecordExport prints local records as a zone file (or JSON, CSV, octoDNS YAML), optionally one zone.
+func runRecordExport() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) > 2 {
+			msgs := infoMessages(
+				"Usage: record export [bind|json|csv|octodns-yaml] [zone]",
+				"Description: Print local records in the given format (default bind), grouped by zone apex, or only the records of one zone.",
+				"Example: record export bind example.com",
+			)
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs}
+		}
+		format, zone := zones.FormatBIND, ""
+		for _, a := range input.Raw {
+			if isZoneFormat(a) {
+				format, _ = zones.ParseFormat(a)
+			} else {
+				zone = strings.TrimSpace(a)
+			}
+		}
+		records := data.GetInstance().GetRecords()
+		if zone != "" && len(zones.ZoneRecords(records, zone)) == 0 {
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages("No records in zone " + zone + ".")}
+		}
+		var b bytes.Buffer
+		if err := zones.Export(&b, format, records, zone); err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages(strings.Split(strings.TrimRight(b.String(), "\n"), "\n")...)}
+	}
+}
+
+// runRecordImport replaces records from a file on the server host; with dry-run it only shows the diff.
+func runRecordImport() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) == 0 || len(input.Raw) > 4 {
+			msgs := infoMessages(
+				"Usage: record import <file> [bind|json|csv|octodns-yaml] [zone] [dry-run]",
+				"Description: Replace the records of a zone (or of the zones whose SOA the file holds, or all records) with a file on the server host. The format defaults from the file extension.",
+				"Example: record import /etc/dnsplane/example.com.zone example.com dry-run",
+			)
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs}
+		}
+		path := input.Raw[0]
+		format, zone, dryRun := zones.FormatForPath(path), "", false
+		for _, a := range input.Raw[1:] {
+			switch {
+			case strings.EqualFold(a, "dry-run") || strings.EqualFold(a, "dryrun"):
+				dryRun = true
+			case isZoneFormat(a):
+				format, _ = zones.ParseFormat(a)
+			default:
+				zone = strings.TrimSpace(a)
+			}
+		}
+		f, err := os.Open(path) // #nosec G304 -- operator-supplied import path
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		defer func() { _ = f.Close() }()
+		res, err := zones.Import(f, format, zone)
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		dnsData := data.GetInstance()
+		ch, err := zones.Apply(dnsData.GetRecords(), res.Records, zone)
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		msgs := warnMessages(res.Warnings...)
+		msgs = append(msgs, infoMessages(ch.DiffLines()...)...)
+		switch {
+		case dryRun:
+			msgs = append(msgs, infoMessages("Dry run: "+ch.Summary())...)
+		case ch.Empty():
+			msgs = append(msgs, infoMessages("No changes: "+ch.Summary())...)
+		default:
+			if err := dnsData.UpdateRecords(tuiOrigin(), ch.Records); err != nil {
+				return recordFileFailed(err.Error(), err)
+			}
+			msgs = append(msgs, infoMessages("Imported: "+ch.Summary())...)
+		}
+		return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs, Payload: ch}
+	}
+}
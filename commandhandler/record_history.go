@@ -0,0 +1,157 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package commandhandler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dnsplane/cliutil"
+	"dnsplane/data"
+	"dnsplane/journal"
+
+	tui "github.com/network-plane/planetui"
+)
+
+// tuiOrigin names the TUI session in the record history.
+func tuiOrigin() journal.Origin {
+	return journal.Origin{Source: journal.SourceTUI, Actor: activeSession().actor()}
+}
+
+func historyJournal() (*journal.Journal, *tui.CommandResult) {
+	j := data.GetInstance().RecordJournal()
+	if j == nil {
+		res := recordFileFailed(journal.ErrDisabled.Error(), journal.ErrDisabled)
+		return nil, &res
+	}
+	return j, nil
+}
+
+// runRecordHistory lists history entries, one row per changed record.
+func runRecordHistory() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) > 2 {
+			msgs := infoMessages(
+				"Usage: record history [name|zone=<zone>|id=<id>] [limit]",
+				"Description: List record changes, newest first (default 20 entries).",
+				"Example: record history zone=example.com 50",
+			)
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs}
+		}
+		j, fail := historyJournal()
+		if fail != nil {
+			return *fail
+		}
+		f := journal.Filter{Limit: 20}
+		for _, a := range input.Raw {
+			a = strings.TrimSpace(a)
+			if n, err := strconv.Atoi(a); err == nil && n > 0 {
+				f.Limit = n
+				continue
+			}
+			switch {
+			case strings.HasPrefix(a, "zone="):
+				f.Zone = strings.TrimPrefix(a, "zone=")
+			case strings.HasPrefix(a, "id="):
+				f.ID = strings.TrimPrefix(a, "id=")
+			default:
+				f.Name = a
+			}
+		}
+		entries, err := j.Entries(f)
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		if len(entries) == 0 {
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages("No history entries.")}
+		}
+		var rows [][]string
+		for _, e := range entries {
+			who := e.Actor
+			if e.Note != "" {
+				who = strings.TrimSpace(who + " (" + e.Note + ")")
+			}
+			for i, line := range journal.DiffLines(e.Changes) {
+				if i == 0 {
+					rows = append(rows, []string{strconv.FormatUint(e.Rev, 10), e.Time.Local().Format(time.DateTime), e.Source, who, line})
+				} else {
+					rows = append(rows, []string{"", "", "", "", line})
+				}
+			}
+		}
+		out := rt.Output()
+		out.WriteTable([]string{"Rev", "Time", "Source", "Actor", "Change"}, rows)
+		tui.EnsureLineBreak(out)
+		return tui.CommandResult{Status: tui.StatusSuccess, Payload: entries}
+	}
+}
+
+// runRecordDiff shows the records removed and added between two points in history.
+func runRecordDiff() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) == 0 || len(input.Raw) > 2 {
+			msgs := infoMessages(
+				"Usage: record diff <rev|time> [rev|time]",
+				"Description: Show the record changes between two revisions or RFC 3339 times (default to the newest revision).",
+				"Example: record diff 12 15",
+			)
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs}
+		}
+		j, fail := historyJournal()
+		if fail != nil {
+			return *fail
+		}
+		from, err := j.Resolve(input.Raw[0])
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		var to string
+		if len(input.Raw) == 2 {
+			to = input.Raw[1]
+		}
+		toRev, err := j.Resolve(to)
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		changes, err := j.DiffRevs(from, toRev)
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		if len(changes) == 0 {
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages(fmt.Sprintf("No differences between rev %d and rev %d.", from, toRev))}
+		}
+		msgs := infoMessages(fmt.Sprintf("Rev %d -> rev %d:", from, toRev))
+		msgs = append(msgs, infoMessages(journal.DiffLines(changes)...)...)
+		return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs, Payload: changes}
+	}
+}
+
+// runRecordRollback restores the records at a revision or time.
+func runRecordRollback() func(tui.CommandRuntime, tui.CommandInput) tui.CommandResult {
+	return func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		if cliutil.IsHelpRequest(input.Raw) || len(input.Raw) != 1 {
+			msgs := infoMessages(
+				"Usage: record rollback <rev|time>",
+				"Description: Restore the records as of a revision or RFC 3339 time; the change is saved and sent to cluster peers.",
+				"Example: record rollback 12",
+			)
+			return tui.CommandResult{Status: tui.StatusSuccess, Messages: msgs}
+		}
+		j, fail := historyJournal()
+		if fail != nil {
+			return *fail
+		}
+		rev, err := j.Resolve(input.Raw[0])
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		n, err := data.GetInstance().RollbackRecords(tuiOrigin(), rev)
+		if err != nil {
+			return recordFileFailed(err.Error(), err)
+		}
+		return tui.CommandResult{Status: tui.StatusSuccess, Messages: infoMessages(fmt.Sprintf("Rolled back to rev %d: %d records (now rev %d).", rev, n, j.Head()))}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package commandhandler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"dnsplane/config"
+
+	tui "github.com/network-plane/planetui"
+)
+
+// Transports a TUI session can arrive on.
+const (
+	TransportUnix = "unix"
+	TransportTCP  = "tcp"
+	TransportSSH  = "ssh"
+)
+
+// Session is who runs TUI commands: a logged-in remote user, an anonymous remote client, or the local
+// socket.
+type Session struct {
+	User      string // "" when the listener has no logins
+	Role      string // config.TUIRoleAdmin or config.TUIRoleReadOnly
+	Addr      string
+	Transport string // TransportUnix, TransportTCP or TransportSSH
+}
+
+// actor names the session in the record history: its address, prefixed by the user when logged in.
+func (s Session) actor() string {
+	if s.User == "" || s.Transport == TransportUnix {
+		return s.Addr
+	}
+	return s.User + "@" + s.Addr
+}
+
+const sessionKey = "dnsplane.session"
+
+var (
+	// currentSession is the session on the default engine, which serves one client at a time.
+	currentSession atomic.Pointer[Session]
+
+	// Commands from all sessions run one at a time, so handlers can keep using process-wide state;
+	// runningSession is the session whose command is running.
+	commandMu      sync.Mutex
+	runningSession atomic.Pointer[Session]
+)
+
+// SetSession records the session whose commands run next on the default engine.
+func SetSession(s Session) {
+	currentSession.Store(&s)
+}
+
+// ClearSession forgets the default engine's session when it ends.
+func ClearSession() {
+	currentSession.Store(nil)
+}
+
+func activeSession() Session {
+	if s := runningSession.Load(); s != nil {
+		return *s
+	}
+	if s := currentSession.Load(); s != nil {
+		return *s
+	}
+	return Session{Role: config.TUIRoleAdmin}
+}
+
+// sessionOf returns the session of the engine running rt: the one given to NewEngine, else the default
+// engine's.
+func sessionOf(rt tui.CommandRuntime) Session {
+	if rt != nil && rt.Session() != nil {
+		if v, ok := rt.Session().Get(sessionKey); ok {
+			if s, ok := v.(Session); ok {
+				return s
+			}
+		}
+	}
+	if s := currentSession.Load(); s != nil {
+		return *s
+	}
+	return Session{Role: config.TUIRoleAdmin}
+}
+
+// NewEngine returns an engine with every command for one session, so several sessions can run side by
+// side, each with its own context and output. Output goes to out.
+func NewEngine(s Session, out io.Writer, log *slog.Logger) *tui.Engine {
+	e := tui.NewEngine(
+		tui.WithPrompt("dnsplane> "),
+		tui.WithOutputWriter(out),
+		tui.WithMiddleware(SessionMiddleware(log)),
+	)
+	e.Session().Set(sessionKey, s)
+	registerCommands(e)
+	return e
+}
+
+// Exec runs one command line ("[context] command [args]") on e without the interactive loop, as for
+// ssh host record list, writes its output to w, and reports whether it succeeded.
+func Exec(e *tui.Engine, line string, w io.Writer, log *slog.Logger) bool {
+	out := tui.NewOutputChannel(w)
+	tokens := strings.Fields(line)
+	if len(tokens) == 0 {
+		out.Error("no command given")
+		return false
+	}
+	ctx := ""
+	if canonical, ok := e.Registry().ResolveContextName(tokens[0]); ok && canonical != "" {
+		ctx = canonical
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		out.Error(fmt.Sprintf("%s needs a command; commands: %s", ctx, strings.Join(commandNames(e, ctx), ", ")))
+		return false
+	}
+	entry, ok := e.Registry().Resolve(ctx, tokens[0])
+	if !ok {
+		out.Error(fmt.Sprintf("unknown command: %s", strings.TrimSpace(ctx+" "+tokens[0])))
+		return false
+	}
+	args, flags, err := tui.NewArgsParser().Parse(tokens[1:], entry.Spec)
+	if err != nil {
+		out.Error(err.Error())
+		out.Info("usage: " + entry.Spec.Usage)
+		return false
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rt := &execRuntime{engine: e, ctx: runCtx, output: out}
+	input := tui.CommandInput{Context: runCtx, Raw: tokens[1:], Args: args, Flags: flags}
+	run := func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		cmd, err := entry.Factory.New(rt)
+		if err != nil {
+			return tui.CommandResult{Status: tui.StatusFailed, Error: &tui.CommandError{Err: err, Message: "failed to create command"}}
+		}
+		return cmd.Execute(rt, input)
+	}
+	res := tui.RecoveryMiddleware(rt, input, entry, func(rt tui.CommandRuntime, input tui.CommandInput) tui.CommandResult {
+		return SessionMiddleware(log)(rt, input, entry, run)
+	})
+	tui.AggregateMessages(out, res.Messages)
+	if res.Error != nil {
+		msg := res.Error.Message
+		if msg == "" && res.Error.Err != nil {
+			msg = res.Error.Err.Error()
+		}
+		out.Error(msg)
+	}
+	tui.EnsureLineBreak(out)
+	return res.Status != tui.StatusFailed && res.Error == nil
+}
+
+func commandNames(e *tui.Engine, ctx string) []string {
+	var names []string
+	for _, spec := range e.Registry().Commands(ctx, false) {
+		names = append(names, spec.Name)
+	}
+	return names
+}
+
+// execRuntime is the runtime of a command run by Exec; there is no context to move into.
+type execRuntime struct {
+	engine   *tui.Engine
+	ctx      context.Context
+	output   tui.OutputChannel
+	pipeline any
+}
+
+func (r *execRuntime) Session() tui.SessionStore                  { return r.engine.Session() }
+func (r *execRuntime) Services() tui.ServiceRegistry              { return r.engine.Services() }
+func (r *execRuntime) Output() tui.OutputChannel                  { return r.output }
+func (r *execRuntime) ContextManager() *tui.ContextManager        { return r.engine.Contexts() }
+func (r *execRuntime) TaskManager() *tui.TaskManager              { return tui.NewTaskManager(r.output) }
+func (r *execRuntime) Cancellation() context.Context              { return r.ctx }
+func (r *execRuntime) NavigateTo(name string, payload any) error  { return nil }
+func (r *execRuntime) PushContext(name string, payload any) error { return nil }
+func (r *execRuntime) PopContext() error                          { return nil }
+func (r *execRuntime) PipelineData() any                          { return r.pipeline }
+func (r *execRuntime) SetPipelineData(v any)                      { r.pipeline = v }
+
+// readOnlyCommands lists the commands the read-only role may run ("<context> <name>"); they only show state.
+var readOnlyCommands = map[string]bool{
+	" help":                 true,
+	" stats":                true,
+	" tasks":                true,
+	"adblock domains":       true,
+	"adblock list":          true,
+	"cache list":            true,
+	"cluster status":        true,
+	"dns list":              true,
+	"dns route":             true,
+	"dns zones":             true,
+	"record diff":           true,
+	"record export":         true,
+	"record history":        true,
+	"record list":           true,
+	"server config":         true,
+	"server status":         true,
+	"server version":        true,
+	"statistics domains":    true,
+	"statistics requesters": true,
+	"tools dig":             true,
+}
+
+// CommandAllowed reports whether role may run the command name in context ctx.
+func CommandAllowed(role, ctx, name string) bool {
+	return role == config.TUIRoleAdmin || readOnlyCommands[ctx+" "+name]
+}
+
+// SessionMiddleware refuses commands the session's role does not allow, runs the rest one at a time across
+// sessions, and writes every command, with its user, address, and outcome, to log.
+func SessionMiddleware(log *slog.Logger) tui.Middleware {
+	return func(rt tui.CommandRuntime, input tui.CommandInput, entry tui.CommandEntry, next tui.NextFunc) tui.CommandResult {
+		s := sessionOf(rt)
+		command := strings.TrimSpace(entry.Spec.Context + " " + entry.Spec.Name)
+		if !CommandAllowed(s.Role, entry.Spec.Context, entry.Spec.Name) {
+			if log != nil {
+				log.Warn("TUI command refused", "user", s.User, "role", s.Role, "addr", s.Addr, "transport", s.Transport, "command", command)
+			}
+			return recordFileFailed(fmt.Sprintf("%s needs the %s role", command, config.TUIRoleAdmin), nil)
+		}
+		commandMu.Lock()
+		runningSession.Store(&s)
+		res := func() tui.CommandResult {
+			defer func() {
+				runningSession.Store(nil)
+				commandMu.Unlock()
+			}()
+			return next(rt, input)
+		}()
+		if log != nil {
+			log.Info("TUI command", "user", s.User, "role", s.Role, "addr", s.Addr, "transport", s.Transport, "command", command,
+				"args", strings.Join(redactCommandArgs(command, input.Raw), " "), "status", res.Status)
+		}
+		return res
+	}
+}
+
+// redactCommandArgs hides the value of settings that hold secrets, such as server set api_auth_token.
+func redactCommandArgs(command string, args []string) []string {
+	if command != "server set" || len(args) < 2 {
+		return args
+	}
+	key := strings.ToLower(args[0])
+	for _, secret := range []string{"token", "secret", "password"} {
+		if strings.Contains(key, secret) {
+			return []string{args[0], "(redacted)"}
+		}
+	}
+	return args
+}
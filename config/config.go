@@ -26,24 +26,118 @@ const (
 	RecordsSourceBindDir = "bind_dir"
 )
 
-// RecordsSourceConfig describes where to load DNS records from (single source: file, URL, git, or bind_dir).
+// How a source in records_sources combines with the sources listed before it.
+const (
+	RecordsMergeOverride = "override" // its records replace earlier records with the same name and type
+	RecordsMergeUnion    = "union"    // its records are added to the earlier ones
+)
+
+// RecordsSourceConfig describes where to load DNS records from (file, URL, git, or bind_dir).
 // When Type is "file", Location is the local path (read/write). When "url" or "git", Location is the URL and records are read-only; RefreshIntervalSeconds controls re-fetch interval.
 // When Type is "bind_dir", Location is a directory of zone files; IncludePattern globs under it unless NamedConf lists paths.
 type RecordsSourceConfig struct {
 	Type                   string `json:"type"`                               // "file", "url", "git", or "bind_dir"
 	Location               string `json:"location"`                           // path, http(s) URL, git repo URL, or zone directory
-	RefreshIntervalSeconds int    `json:"refresh_interval_seconds,omitempty"` // for url/git: how often to check for changes (seconds)
+	RefreshIntervalSeconds int    `json:"refresh_interval_seconds,omitempty"` // how often to reload the source (seconds; url/git default 60)
 	IncludePattern         string `json:"include_pattern,omitempty"`          // bind_dir: glob under Location (default "*.db")
 	NamedConf              string `json:"named_conf,omitempty"`               // bind_dir: optional path to named.conf fragment with zone { file "..."; }
-	Watch                  bool   `json:"watch,omitempty"`                    // bind_dir: watch zone files and reload (debounced)
+	Watch                  bool   `json:"watch,omitempty"`                    // file/bind_dir: watch the file or zone files and reload (debounced)
+	// Name, Writable, and Merge apply to entries of records_sources.
+	Name     string `json:"name,omitempty"`     // label in server status and the API (default "<type>-<position>")
+	Writable bool   `json:"writable,omitempty"` // API/TUI record writes go to this source; at most one, and only a file
+	Merge    string `json:"merge,omitempty"`    // "override" (default) or "union" with the sources listed before it
+}
+
+func (rs *RecordsSourceConfig) applyDefaults(configDir string) {
+	t := strings.ToLower(strings.TrimSpace(rs.Type))
+	switch {
+	case t == RecordsSourceURL || t == RecordsSourceGit:
+		rs.Type = t
+		if rs.RefreshIntervalSeconds <= 0 {
+			rs.RefreshIntervalSeconds = 60
+		}
+	case t == RecordsSourceBindDir:
+		rs.Type = RecordsSourceBindDir
+		rs.Location = ensureAbsolutePath(configDir, rs.Location, "zones")
+		if nc := strings.TrimSpace(rs.NamedConf); nc != "" {
+			rs.NamedConf = ensureAbsolutePath(configDir, nc, "")
+		}
+	default:
+		// type "file" or empty: treat as file, make location absolute
+		rs.Type = RecordsSourceFile
+		rs.Location = ensureAbsolutePath(configDir, rs.Location, "dnsrecords.json")
+	}
 }
 
 // FileLocations describes the JSON data files used by dnsplane.
-// Records are always loaded from records_source (type "file", "url", or "git"); dnsservers and cache are paths.
+// Records are loaded from records_sources when set, else from records_source; dnsservers and cache are paths.
 type FileLocations struct {
 	DNSServerFile string               `json:"dnsservers"`
 	CacheFile     string               `json:"cache"`
-	RecordsSource *RecordsSourceConfig `json:"records_source"` // required: type "file"|"url"|"git"|"bind_dir", location = path, URL, repo, or zone directory
+	RecordsSource *RecordsSourceConfig `json:"records_source"` // type "file"|"url"|"git"|"bind_dir", location = path, URL, repo, or zone directory
+	// RecordsSources merges several sources, later entries taking precedence; it replaces records_source when set.
+	RecordsSources []RecordsSourceConfig `json:"records_sources,omitempty"`
+}
+
+// Sources returns the records sources in precedence order (later sources win). A lone records_source
+// is a single source, writable when it is a file.
+func (fl FileLocations) Sources() []RecordsSourceConfig {
+	if len(fl.RecordsSources) > 0 {
+		return fl.RecordsSources
+	}
+	if fl.RecordsSource == nil {
+		return nil
+	}
+	rs := *fl.RecordsSource
+	rs.Writable = rs.Type == RecordsSourceFile
+	if rs.Name == "" {
+		rs.Name = rs.Type
+	}
+	if rs.Merge == "" {
+		rs.Merge = RecordsMergeOverride
+	}
+	return []RecordsSourceConfig{rs}
+}
+
+// WritableSource returns the source that takes API/TUI record writes, or nil when every source is read-only.
+func (fl FileLocations) WritableSource() *RecordsSourceConfig {
+	for _, rs := range fl.Sources() {
+		if rs.Writable && rs.Type == RecordsSourceFile {
+			return &rs
+		}
+	}
+	return nil
+}
+
+// ValidateRecordsSources checks records_sources: valid types and merge modes, unique names, and at most
+// one writable source, which must be a file.
+func (fl FileLocations) ValidateRecordsSources() error {
+	seen := make(map[string]bool)
+	writable := 0
+	for _, rs := range fl.Sources() {
+		switch rs.Type {
+		case RecordsSourceFile, RecordsSourceURL, RecordsSourceGit, RecordsSourceBindDir:
+		default:
+			return fmt.Errorf("records source %q: unknown type %q", rs.Name, rs.Type)
+		}
+		if rs.Merge != "" && rs.Merge != RecordsMergeOverride && rs.Merge != RecordsMergeUnion {
+			return fmt.Errorf("records source %q: unknown merge %q (want override or union)", rs.Name, rs.Merge)
+		}
+		if seen[rs.Name] {
+			return fmt.Errorf("records source name %q is used twice", rs.Name)
+		}
+		seen[rs.Name] = true
+		if rs.Writable {
+			if rs.Type != RecordsSourceFile {
+				return fmt.Errorf("records source %q: only a file source can be writable", rs.Name)
+			}
+			writable++
+		}
+	}
+	if writable > 1 {
+		return fmt.Errorf("records_sources: at most one source can be writable")
+	}
+	return nil
 }
 
 // DNSRecordSettings mirrors record handling settings persisted in the config.
@@ -53,6 +147,192 @@ type DNSRecordSettings struct {
 	AddUpdatesRecords bool `json:"add_updates_records,omitempty"`
 }
 
+// ClientACLConfig restricts which clients may query dnsplane and which may use it as a recursive forwarder.
+// Entries are IPs, CIDRs, "any", "none", "localhost", or names from Groups; a leading "!" negates an entry
+// and the first matching entry wins. Empty lists mean no restriction.
+type ClientACLConfig struct {
+	Groups         map[string][]string `json:"groups,omitempty"`          // named ACLs usable from the lists below
+	AllowQuery     []string            `json:"allow_query,omitempty"`     // clients allowed to query at all
+	AllowRecursion []string            `json:"allow_recursion,omitempty"` // clients allowed cache/upstream answers; others get local records only
+	Deny           []string            `json:"deny,omitempty"`            // clients always refused (checked first)
+	DenyAction     string              `json:"deny_action,omitempty"`     // "refuse" (default, REFUSED + EDE) or "drop"
+}
+
+// PolicyConfig defines per-client policy groups evaluated by the resolver (parental controls, schedules,
+// per-group upstreams). Groups are checked in order; the first group whose Clients match applies.
+type PolicyConfig struct {
+	Categories map[string]PolicyCategory `json:"categories,omitempty"` // named block lists referenced by groups and schedules
+	Groups     []PolicyGroup             `json:"groups,omitempty"`
+	Timezone   string                    `json:"timezone,omitempty"` // IANA zone for schedules (empty = server local time)
+}
+
+// PolicyCategory is a named block list: inline domains plus hosts/adblock-format files (subdomains match).
+type PolicyCategory struct {
+	Domains []string `json:"domains,omitempty"`
+	Files   []string `json:"files,omitempty"`
+}
+
+// PolicyGroup applies block categories, safe search, schedules, and optional upstreams to matching clients.
+type PolicyGroup struct {
+	Name            string           `json:"name"`
+	Clients         []string         `json:"clients"`                    // IPs, CIDRs, or MAC addresses (aa:bb:cc:dd:ee:ff)
+	BlockCategories []string         `json:"block_categories,omitempty"` // always blocked for this group
+	SafeSearch      bool             `json:"safe_search,omitempty"`      // CNAME Google/Bing/YouTube/DuckDuckGo to their safe endpoints
+	Schedules       []PolicySchedule `json:"schedules,omitempty"`
+	Upstreams       []PolicyUpstream `json:"upstreams,omitempty"` // replaces dnsservers for this group (answers are not cached)
+}
+
+// PolicySchedule blocks extra categories during a daily time window (From > To wraps past midnight).
+type PolicySchedule struct {
+	Name            string   `json:"name,omitempty"`
+	Days            []string `json:"days,omitempty"` // mon..sun; empty = every day
+	From            string   `json:"from"`           // "22:00"
+	To              string   `json:"to"`             // "07:00"
+	BlockCategories []string `json:"block_categories"`
+}
+
+// PolicyUpstream is an upstream resolver for a policy group (same fields as dnsservers.json rows).
+type PolicyUpstream struct {
+	Address   string `json:"address"`
+	Port      string `json:"port,omitempty"`
+	Transport string `json:"transport,omitempty"` // udp (default), tcp, dot, doh
+	DoHURL    string `json:"doh_url,omitempty"`
+}
+
+// ForwardZone sends queries at or below Zones (and the reverse zones of ReverseCIDRs) to Servers.
+// The longest matching zone across all entries wins; forward zones take precedence over server whitelists.
+type ForwardZone struct {
+	Name         string          `json:"name,omitempty"`          // label shown in the TUI and logs
+	Zones        []string        `json:"zones,omitempty"`         // e.g. corp.example
+	ReverseCIDRs []string        `json:"reverse_cidrs,omitempty"` // e.g. 10.20.0.0/16 → 20.10.in-addr.arpa
+	Servers      []ForwardServer `json:"servers"`
+	Forward      string          `json:"forward,omitempty"`    // "only" (default): never fall back; "first": use normal upstreams if these fail
+	Transport    string          `json:"transport,omitempty"`  // default transport for Servers that do not set one
+	TimeoutMs    int             `json:"timeout_ms,omitempty"` // per-zone upstream timeout (0 = resolver default)
+}
+
+// ForwardServer is a forward-zone upstream (same fields as dnsservers.json rows).
+type ForwardServer struct {
+	Address   string `json:"address"`
+	Port      string `json:"port,omitempty"`
+	Transport string `json:"transport,omitempty"` // udp, tcp, dot, doh
+	DoHURL    string `json:"doh_url,omitempty"`
+}
+
+// LocalZonesConfig controls the built-in empty zones (RFC 6303 reverse zones, RFC 6761/8375 special-use names)
+// answered locally with NXDOMAIN + SOA. Zones holding local records or covered by a forwarder are skipped automatically.
+type LocalZonesConfig struct {
+	Disabled bool     `json:"disabled,omitempty"` // turn every built-in zone off
+	Exclude  []string `json:"exclude,omitempty"`  // built-in zones to resolve normally (e.g. "local", "10.in-addr.arpa")
+	Include  []string `json:"include,omitempty"`  // extra zones to serve empty
+}
+
+// GeoConfig locates clients for local records with geo selectors (see docs/geo.md).
+type GeoConfig struct {
+	Databases []string            `json:"databases,omitempty"` // MaxMind-format .mmdb files (country, city, ASN); earlier files win
+	Regions   map[string][]string `json:"regions,omitempty"`   // region name -> CIDRs; the longest matching prefix wins
+}
+
+// DHCPConfig registers hostnames from DHCP server lease files as local records (see docs/dhcp.md).
+type DHCPConfig struct {
+	Leases         []DHCPLeaseFile `json:"leases,omitempty"`
+	Domain         string          `json:"domain,omitempty"`          // records are <hostname>.<domain>
+	ConflictPolicy string          `json:"conflict_policy,omitempty"` // first-wins (default), suffix-mac, or reject
+	NoPTR          bool            `json:"no_ptr,omitempty"`          // do not register PTR records
+	MaxTTL         uint32          `json:"max_ttl,omitempty"`         // cap on the lease lifetime used as TTL (default 3600)
+}
+
+// DHCPLeaseFile is one lease file to follow.
+type DHCPLeaseFile struct {
+	Format string `json:"format"` // isc, dnsmasq, or kea
+	Path   string `json:"path"`
+}
+
+// Enabled reports whether lease files and a domain are configured.
+func (c DHCPConfig) Enabled() bool {
+	return len(c.Leases) > 0 && strings.TrimSpace(c.Domain) != ""
+}
+
+// TracingConfig exports a trace per DNS query to an OpenTelemetry collector over OTLP/HTTP (see docs/tracing.md).
+// A query is kept when head sampling picks it or, whatever the sampling, when it is slower than SlowMs or failed.
+type TracingConfig struct {
+	Enabled     bool              `json:"enabled,omitempty"`
+	Endpoint    string            `json:"endpoint,omitempty"`     // OTLP/HTTP traces URL (default http://localhost:4318/v1/traces)
+	Headers     map[string]string `json:"headers,omitempty"`      // sent with every export, e.g. a collector API key
+	ServiceName string            `json:"service_name,omitempty"` // service.name resource attribute (default dnsplane)
+	SampleRatio float64           `json:"sample_ratio"`           // fraction of queries kept up front, 0-1 (default 1)
+	SlowMs      int               `json:"slow_ms,omitempty"`      // also keep queries at least this slow (0 = off)
+	Errors      bool              `json:"errors,omitempty"`       // also keep SERVFAIL answers and failed upstream queries
+}
+
+// EventsConfig sets up the operational event stream and its outbound webhooks (see docs/events.md).
+type EventsConfig struct {
+	Webhooks         []Webhook `json:"webhooks,omitempty"`
+	QueueFile        string    `json:"queue_file,omitempty"`         // undelivered webhook events, kept across restarts (default webhooks_queue.json)
+	LimiterDropSpike int       `json:"limiter_drop_spike,omitempty"` // rate-limited queries in 10 seconds that raise limiter.drop_spike (default 1000)
+}
+
+// Webhook receives the events its Events filter selects as signed JSON POSTs.
+type Webhook struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"` // HMAC-SHA256 key for the X-Dnsplane-Signature header
+	Events []string `json:"events,omitempty"` // event types, or "family.*"; empty sends every event
+}
+
+// Roles of remote TUI users.
+const (
+	TUIRoleAdmin    = "admin"     // every command
+	TUIRoleReadOnly = "read-only" // commands that only show state
+)
+
+// TUIAuthConfig secures the remote TUI listener (see docs/tui-auth.md). TLSCert and TLSKey turn on TLS;
+// Users turns on logins and needs TLS.
+type TUIAuthConfig struct {
+	TLSCert        string    `json:"tls_cert,omitempty"`
+	TLSKey         string    `json:"tls_key,omitempty"`
+	ClientCA       string    `json:"client_ca,omitempty"` // PEM CAs whose client certificates can log in users with cert_match
+	Users          []TUIUser `json:"users,omitempty"`
+	MaxFailures    int       `json:"max_failures,omitempty"`    // failed logins from one address or for one user before lockout (default 5)
+	LockoutSeconds int       `json:"lockout_seconds,omitempty"` // how long a lockout lasts (default 300)
+}
+
+// TUIUser is a remote TUI login.
+type TUIUser struct {
+	Name         string   `json:"name"`
+	Role         string   `json:"role,omitempty"`          // admin or read-only (default)
+	PasswordHash string   `json:"password_hash,omitempty"` // from "dnsplane hash-password"
+	CertMatch    []string `json:"cert_match,omitempty"`    // client certificate identities, as in api_tls_client_roles
+	// AuthorizedKeys is an OpenSSH authorized_keys file whose keys log in as this user over tui_ssh.
+	AuthorizedKeys string `json:"authorized_keys,omitempty"`
+}
+
+// TUISSHConfig serves the TUI over SSH (see docs/tui-ssh.md). Logins use the tui_auth users that have
+// authorized_keys.
+type TUISSHConfig struct {
+	Listen  string `json:"listen,omitempty"`   // e.g. ":2222"; empty turns SSH off
+	HostKey string `json:"host_key,omitempty"` // private host key, created (ed25519) when missing
+}
+
+// TLSEnabled reports whether the TUI listener uses TLS.
+func (c TUIAuthConfig) TLSEnabled() bool {
+	return strings.TrimSpace(c.TLSCert) != "" && strings.TrimSpace(c.TLSKey) != ""
+}
+
+// LoginRequired reports whether remote TUI clients must log in.
+func (c TUIAuthConfig) LoginRequired() bool {
+	return len(c.Users) > 0
+}
+
+// APIClientRole grants API scopes to client certificates (see docs/api-mtls.md).
+type APIClientRole struct {
+	Name string `json:"name"`
+	// Match lists certificate identities: "cn:<common name>", "dns:<name>", "email:<address>", "uri:<uri>",
+	// "ip:<address>" (subject alternative names), or "*" for any certificate.
+	Match  []string `json:"match"`
+	Scopes []string `json:"scopes"` // API scopes as for tokens, or "all"
+}
+
 // LogRotationMode is the log rotation strategy: "none", "size", or "time".
 type LogRotationMode string
 
@@ -82,6 +362,8 @@ type Config struct {
 	APIEnabled         bool   `json:"api"`
 	// APIAuthToken when non-empty requires Authorization: Bearer <token> or X-API-Token for all routes except GET/HEAD /health and /ready.
 	APIAuthToken string `json:"api_auth_token,omitempty"`
+	// APITokensFile holds the named, scoped API credentials (hashed). Once it has any, every route needs a token.
+	APITokensFile string `json:"api_tokens_file,omitempty"`
 	// DNSBind is the IP address to bind for DNS UDP/TCP listeners (e.g. "127.0.0.1"). Empty binds all interfaces.
 	DNSBind string `json:"dns_bind,omitempty"`
 	// APIBind is the IP address to bind for the REST API (e.g. "127.0.0.1"). Empty binds all interfaces.
@@ -89,6 +371,15 @@ type Config struct {
 	// APITLSCertFile and APITLSKeyFile when both non-empty enable HTTPS for the REST API (ListenAndServeTLS).
 	APITLSCertFile string `json:"api_tls_cert,omitempty"`
 	APITLSKeyFile  string `json:"api_tls_key,omitempty"`
+	// APITLSClientCAFile is a PEM bundle of CAs. When set (with api_tls_cert/api_tls_key), every API route
+	// except GET/HEAD /health and /ready needs a client certificate signed by one of them.
+	APITLSClientCAFile string `json:"api_tls_client_ca,omitempty"`
+	// APITLSClientCRLFile is a CRL (PEM or DER) issued by a client CA; certificates it lists are refused.
+	// The file is read again when it changes.
+	APITLSClientCRLFile string `json:"api_tls_client_crl,omitempty"`
+	// APITLSClientRoles maps client certificates to API scopes; the first matching role wins. When empty,
+	// any certificate from the client CA holds every scope.
+	APITLSClientRoles []APIClientRole `json:"api_tls_client_roles,omitempty"`
 	// APIRateLimitPerIP is max sustained HTTP requests per second per client IP (0 = disabled). Uses token bucket.
 	APIRateLimitPerIP float64 `json:"api_rate_limit_rps,omitempty"`
 	// APIRateLimitBurst is max burst size when API rate limiting is enabled (default 20).
@@ -128,6 +419,19 @@ type Config struct {
 	DNSRRLWindowSeconds int     `json:"dns_rrl_window_seconds,omitempty"`
 	DNSRRLSlip          float64 `json:"dns_rrl_slip,omitempty"`
 
+	// DNSCookiesEnabled answers EDNS COOKIE options (RFC 7873) with RFC 9018 server cookies. Clients presenting a
+	// valid server cookie skip the response limiters (dns_response_limit_mode).
+	DNSCookiesEnabled bool `json:"dns_cookies_enabled,omitempty"`
+	// DNSCookieSecretRotationSeconds is how often the server cookie secret rotates (default 3600, minimum 300).
+	DNSCookieSecretRotationSeconds int `json:"dns_cookie_secret_rotation_seconds,omitempty"`
+	// DNSCookieUnverifiedRPS is the per-IP UDP query rate allowed without a valid server cookie (0 = not enforced).
+	// Above it, clients with only a client cookie get BADCOOKIE and cookieless clients get TC=1.
+	DNSCookieUnverifiedRPS float64 `json:"dns_cookie_unverified_rps,omitempty"`
+	// DNSCookieUnverifiedBurst is the burst for DNSCookieUnverifiedRPS (default 20).
+	DNSCookieUnverifiedBurst int `json:"dns_cookie_unverified_burst,omitempty"`
+	// UpstreamDNSCookies sends client cookies to upstream servers and echoes their server cookies (restart to apply).
+	UpstreamDNSCookies bool `json:"upstream_dns_cookies,omitempty"`
+
 	// DNSSECValidate enables best-effort RRSIG verification when DNSKEYs are present in upstream replies.
 	DNSSECValidate        bool   `json:"dnssec_validate,omitempty"`
 	DNSSECValidateStrict  bool   `json:"dnssec_validate_strict,omitempty"`
@@ -148,14 +452,24 @@ type Config struct {
 	DNSMaxEDNSUDPPayload uint16 `json:"dns_max_edns_udp_payload,omitempty"`
 	CacheRecords         bool   `json:"cache_records"`
 	// LocalRecordsEnabled when false skips dnsrecords (and PTR from local zone) for DNS answers — pure forwarder to upstreams. Records file still loaded for API/TUI unless cluster rejects writes. Builtin localhost (RFC 6761) still answers when name is localhost. Default true.
-	LocalRecordsEnabled bool              `json:"local_records_enabled,omitempty"`
-	FullStats           bool              `json:"full_stats"`
-	FullStatsDir        string            `json:"full_stats_dir"`
-	ClientSocketPath    string            `json:"server_socket"`
-	ClientTCPAddress    string            `json:"server_tcp"`
-	FileLocations       FileLocations     `json:"file_locations"`
-	DNSRecordSettings   DNSRecordSettings `json:"DNSRecordSettings"`
-	Log                 LogConfig         `json:"log"`
+	LocalRecordsEnabled bool   `json:"local_records_enabled,omitempty"`
+	FullStats           bool   `json:"full_stats"`
+	FullStatsDir        string `json:"full_stats_dir"`
+	// RecordsHistory keeps a journal of every change to the local records (who, when, before and after) in RecordsHistoryDir for history, diff, and rollback.
+	RecordsHistory    bool   `json:"records_history,omitempty"`
+	RecordsHistoryDir string `json:"records_history_dir,omitempty"`
+	ClientSocketPath  string `json:"server_socket"`
+	ClientTCPAddress  string `json:"server_tcp"`
+	// TUIAuth adds TLS and logins with roles to the remote TUI listener (server_tcp).
+	TUIAuth TUIAuthConfig `json:"tui_auth,omitzero"`
+	// TUISSH serves the TUI over SSH to the tui_auth users, several sessions at a time.
+	TUISSH            TUISSHConfig      `json:"tui_ssh,omitzero"`
+	FileLocations     FileLocations     `json:"file_locations"`
+	DNSRecordSettings DNSRecordSettings `json:"DNSRecordSettings"`
+	Log               LogConfig         `json:"log"`
+	// ConfigWatch reloads this file, the DNS servers file, and the adblock list files when they change on disk,
+	// as on SIGHUP.
+	ConfigWatch bool `json:"config_watch,omitempty"`
 	// AdblockListFiles is a list of paths to adblock list files (e.g. hosts-style). Loaded in order at startup and merged into a single block list.
 	AdblockListFiles []string `json:"adblock_list_files,omitempty"`
 	// UpstreamHealthCheckEnabled runs periodic probes and excludes failing upstreams from forwarding until they recover.
@@ -226,6 +540,22 @@ type Config struct {
 	AXFREnabled bool `json:"axfr_enabled,omitempty"`
 	// AXFRAllowedNetworks lists CIDRs allowed to request AXFR (e.g. "127.0.0.0/8", "::1/128"). Empty with AXFREnabled still means refuse all until configured.
 	AXFRAllowedNetworks []string `json:"axfr_allowed_networks,omitempty"`
+	// ClientACL holds allow_query / allow_recursion / deny lists evaluated before resolution (see docs/security-public-dns.md).
+	ClientACL ClientACLConfig `json:"client_acl"`
+	// Policy holds per-client group policies (see docs/policy.md).
+	Policy PolicyConfig `json:"policy"`
+	// ForwardZones is the conditional forwarding table (see docs/forward-zones.md).
+	ForwardZones []ForwardZone `json:"forward_zones,omitempty"`
+	// LocalZones toggles the built-in empty zones for private and special-use names (see docs/resolution.md).
+	LocalZones LocalZonesConfig `json:"local_zones"`
+	// Geo holds the client location sources for geo-selected local records (see docs/geo.md).
+	Geo GeoConfig `json:"geo"`
+	// DHCP follows DHCP lease files and registers the leased hostnames (see docs/dhcp.md).
+	DHCP DHCPConfig `json:"dhcp"`
+	// Tracing exports per-query spans over OTLP/HTTP (see docs/tracing.md).
+	Tracing TracingConfig `json:"tracing"`
+	// Events configures GET /events and the webhooks (see docs/events.md).
+	Events EventsConfig `json:"events"`
 }
 
 // Loaded contains the configuration together with metadata about the source file.
@@ -423,6 +753,8 @@ func defaultConfig(baseDir string) *Config {
 		LocalRecordsEnabled: true,
 		FullStats:           false,
 		FullStatsDir:        filepath.Join(baseDir, "fullstats"),
+		RecordsHistoryDir:   filepath.Join(baseDir, "history"),
+		APITokensFile:       filepath.Join(baseDir, "api_tokens.json"),
 		ClientSocketPath:    defaultSocketPath(),
 		ClientTCPAddress:    "0.0.0.0:8053",
 		FileLocations: FileLocations{
@@ -475,11 +807,31 @@ func (c *Config) applyDefaults(configDir string) {
 	if c.ClientTCPAddress == "" {
 		c.ClientTCPAddress = "0.0.0.0:8053"
 	}
+	if c.TUIAuth.MaxFailures <= 0 {
+		c.TUIAuth.MaxFailures = 5
+	}
+	if c.TUIAuth.LockoutSeconds <= 0 {
+		c.TUIAuth.LockoutSeconds = 300
+	}
+	for i := range c.TUIAuth.Users {
+		if strings.TrimSpace(c.TUIAuth.Users[i].Role) == "" {
+			c.TUIAuth.Users[i].Role = TUIRoleReadOnly
+		}
+	}
+	if strings.TrimSpace(c.TUISSH.Listen) != "" {
+		c.TUISSH.HostKey = ensureAbsolutePath(configDir, c.TUISSH.HostKey, "ssh_host_ed25519_key")
+	}
 	if c.FullStatsDir == "" {
 		c.FullStatsDir = filepath.Join(configDir, "fullstats")
 	} else {
 		c.FullStatsDir = ensureAbsolutePath(configDir, c.FullStatsDir, "fullstats")
 	}
+	if c.RecordsHistoryDir == "" {
+		c.RecordsHistoryDir = filepath.Join(configDir, "history")
+	} else {
+		c.RecordsHistoryDir = ensureAbsolutePath(configDir, c.RecordsHistoryDir, "history")
+	}
+	c.APITokensFile = ensureAbsolutePath(configDir, c.APITokensFile, "api_tokens.json")
 	if c.UpstreamHealthCheckFailures < 0 {
 		c.UpstreamHealthCheckFailures = 0
 	}
@@ -531,9 +883,34 @@ func (c *Config) applyDefaults(configDir string) {
 	if c.DNSSlidingWindowSeconds < 0 {
 		c.DNSSlidingWindowSeconds = 0
 	}
+	if c.DNSCookieSecretRotationSeconds <= 0 {
+		c.DNSCookieSecretRotationSeconds = 3600
+	} else if c.DNSCookieSecretRotationSeconds < 300 {
+		c.DNSCookieSecretRotationSeconds = 300
+	}
+	if c.DNSCookieUnverifiedRPS < 0 {
+		c.DNSCookieUnverifiedRPS = 0
+	}
+	if c.DNSCookieUnverifiedBurst <= 0 {
+		c.DNSCookieUnverifiedBurst = 20
+	}
 	if c.PprofEnabled && strings.TrimSpace(c.PprofListen) == "" {
 		c.PprofListen = "127.0.0.1:6060"
 	}
+	switch strings.ToLower(strings.TrimSpace(c.ClientACL.DenyAction)) {
+	case "drop":
+		c.ClientACL.DenyAction = "drop"
+	default:
+		c.ClientACL.DenyAction = "refuse"
+	}
+	// Unused while tracing is off; keeps "enabled": true alone meaning every query.
+	if !c.Tracing.Enabled && c.Tracing.SampleRatio == 0 {
+		c.Tracing.SampleRatio = 1
+	}
+	c.Events.QueueFile = ensureAbsolutePath(configDir, c.Events.QueueFile, "webhooks_queue.json")
+	if c.Events.LimiterDropSpike <= 0 {
+		c.Events.LimiterDropSpike = 1000
+	}
 	if c.DOTPort == "" && c.DOTEnabled {
 		c.DOTPort = "853"
 	}
@@ -566,20 +943,16 @@ func (c *Config) applyDefaults(configDir string) {
 		rs = &RecordsSourceConfig{Type: RecordsSourceFile, Location: filepath.Join(configDir, "dnsrecords.json")}
 		c.FileLocations.RecordsSource = rs
 	}
-	if rs.Type == RecordsSourceURL || rs.Type == RecordsSourceGit {
-		if rs.RefreshIntervalSeconds <= 0 {
-			rs.RefreshIntervalSeconds = 60
+	rs.applyDefaults(configDir)
+	for i := range c.FileLocations.RecordsSources {
+		src := &c.FileLocations.RecordsSources[i]
+		src.applyDefaults(configDir)
+		if strings.TrimSpace(src.Name) == "" {
+			src.Name = fmt.Sprintf("%s-%d", src.Type, i+1)
 		}
-	} else if strings.EqualFold(strings.TrimSpace(rs.Type), RecordsSourceBindDir) {
-		rs.Type = RecordsSourceBindDir
-		rs.Location = ensureAbsolutePath(configDir, rs.Location, "zones")
-		if nc := strings.TrimSpace(rs.NamedConf); nc != "" {
-			rs.NamedConf = ensureAbsolutePath(configDir, nc, "")
+		if src.Merge == "" {
+			src.Merge = RecordsMergeOverride
 		}
-	} else {
-		// type "file" or empty: treat as file, make location absolute
-		rs.Type = RecordsSourceFile
-		rs.Location = ensureAbsolutePath(configDir, rs.Location, "dnsrecords.json")
 	}
 
 	if c.Log.Dir == "" {
@@ -686,6 +1059,11 @@ func (fl *FileLocations) UnmarshalJSON(data []byte) error {
 			return err
 		}
 	}
+	if r, ok := raw["records_sources"]; ok && len(r) > 0 {
+		if err := json.Unmarshal(r, &fl.RecordsSources); err != nil {
+			return err
+		}
+	}
 	// Legacy: no records_source but dnsrecords path present → treat as file source
 	if fl.RecordsSource == nil {
 		legacyPath := getStr("dnsrecords", "dnsrecords_file")
@@ -742,6 +1120,42 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	if r, ok := raw["api_auth_token"]; ok {
 		_ = json.Unmarshal(r, &c.APIAuthToken)
 	}
+	if r, ok := raw["api_tokens_file"]; ok {
+		_ = json.Unmarshal(r, &c.APITokensFile)
+	}
+	if r, ok := raw["dns_bind"]; ok {
+		_ = json.Unmarshal(r, &c.DNSBind)
+	}
+	if r, ok := raw["api_bind"]; ok {
+		_ = json.Unmarshal(r, &c.APIBind)
+	}
+	if r, ok := raw["api_tls_cert"]; ok {
+		_ = json.Unmarshal(r, &c.APITLSCertFile)
+	}
+	if r, ok := raw["api_tls_key"]; ok {
+		_ = json.Unmarshal(r, &c.APITLSKeyFile)
+	}
+	if r, ok := raw["api_tls_client_ca"]; ok {
+		_ = json.Unmarshal(r, &c.APITLSClientCAFile)
+	}
+	if r, ok := raw["api_tls_client_crl"]; ok {
+		_ = json.Unmarshal(r, &c.APITLSClientCRLFile)
+	}
+	if r, ok := raw["api_tls_client_roles"]; ok {
+		_ = json.Unmarshal(r, &c.APITLSClientRoles)
+	}
+	if r, ok := raw["api_rate_limit_rps"]; ok {
+		_ = json.Unmarshal(r, &c.APIRateLimitPerIP)
+	}
+	if r, ok := raw["api_rate_limit_burst"]; ok {
+		_ = json.Unmarshal(r, &c.APIRateLimitBurst)
+	}
+	if r, ok := raw["dns_rate_limit_rps"]; ok {
+		_ = json.Unmarshal(r, &c.DNSRateLimitPerIP)
+	}
+	if r, ok := raw["dns_rate_limit_burst"]; ok {
+		_ = json.Unmarshal(r, &c.DNSRateLimitBurst)
+	}
 	if r, ok := raw["cache_records"]; ok {
 		_ = json.Unmarshal(r, &c.CacheRecords)
 	}
@@ -754,8 +1168,20 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	if r, ok := raw["full_stats_dir"]; ok {
 		_ = json.Unmarshal(r, &c.FullStatsDir)
 	}
+	if r, ok := raw["records_history"]; ok {
+		_ = json.Unmarshal(r, &c.RecordsHistory)
+	}
+	if r, ok := raw["records_history_dir"]; ok {
+		_ = json.Unmarshal(r, &c.RecordsHistoryDir)
+	}
 	c.ClientSocketPath = getStr("server_socket", "client_socket_path")
 	c.ClientTCPAddress = getStr("server_tcp", "client_tcp_address")
+	if r, ok := raw["tui_auth"]; ok {
+		_ = json.Unmarshal(r, &c.TUIAuth)
+	}
+	if r, ok := raw["tui_ssh"]; ok {
+		_ = json.Unmarshal(r, &c.TUISSH)
+	}
 	if r, ok := raw["file_locations"]; ok {
 		_ = json.Unmarshal(r, &c.FileLocations)
 	}
@@ -765,6 +1191,9 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	if r, ok := raw["log"]; ok {
 		_ = json.Unmarshal(r, &c.Log)
 	}
+	if r, ok := raw["config_watch"]; ok {
+		_ = json.Unmarshal(r, &c.ConfigWatch)
+	}
 	if r, ok := raw["adblock_list_files"]; ok {
 		_ = json.Unmarshal(r, &c.AdblockListFiles)
 	}
@@ -890,5 +1319,46 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	if r, ok := raw["axfr_allowed_networks"]; ok {
 		_ = json.Unmarshal(r, &c.AXFRAllowedNetworks)
 	}
+	if r, ok := raw["client_acl"]; ok {
+		_ = json.Unmarshal(r, &c.ClientACL)
+	}
+	if r, ok := raw["policy"]; ok {
+		_ = json.Unmarshal(r, &c.Policy)
+	}
+	if r, ok := raw["forward_zones"]; ok {
+		_ = json.Unmarshal(r, &c.ForwardZones)
+	}
+	if r, ok := raw["local_zones"]; ok {
+		_ = json.Unmarshal(r, &c.LocalZones)
+	}
+	if r, ok := raw["geo"]; ok {
+		_ = json.Unmarshal(r, &c.Geo)
+	}
+	if r, ok := raw["dhcp"]; ok {
+		_ = json.Unmarshal(r, &c.DHCP)
+	}
+	if r, ok := raw["tracing"]; ok {
+		c.Tracing = TracingConfig{SampleRatio: 1}
+		_ = json.Unmarshal(r, &c.Tracing)
+	}
+	if r, ok := raw["events"]; ok {
+		c.Events = EventsConfig{}
+		_ = json.Unmarshal(r, &c.Events)
+	}
+	if r, ok := raw["dns_cookies_enabled"]; ok {
+		_ = json.Unmarshal(r, &c.DNSCookiesEnabled)
+	}
+	if r, ok := raw["dns_cookie_secret_rotation_seconds"]; ok {
+		_ = json.Unmarshal(r, &c.DNSCookieSecretRotationSeconds)
+	}
+	if r, ok := raw["dns_cookie_unverified_rps"]; ok {
+		_ = json.Unmarshal(r, &c.DNSCookieUnverifiedRPS)
+	}
+	if r, ok := raw["dns_cookie_unverified_burst"]; ok {
+		_ = json.Unmarshal(r, &c.DNSCookieUnverifiedBurst)
+	}
+	if r, ok := raw["upstream_dns_cookies"]; ok {
+		_ = json.Unmarshal(r, &c.UpstreamDNSCookies)
+	}
 	return nil
 }
@@ -80,3 +80,203 @@ func TestApplyDefaults_DashboardResolutionLogCap(t *testing.T) {
 		t.Fatalf("DashboardResolutionLogCap = %d, want clamp 1000000", c3.DashboardResolutionLogCap)
 	}
 }
+
+func TestUnmarshalJSON_ClientACL(t *testing.T) {
+	raw := []byte(`{"client_acl":{"groups":{"lan":["192.168.0.0/16"]},"allow_recursion":["lan"],"deny_action":"DROP"}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.ClientACL.Groups["lan"]) != 1 || len(c.ClientACL.AllowRecursion) != 1 {
+		t.Fatalf("client_acl not read: %+v", c.ClientACL)
+	}
+	c.applyDefaults(t.TempDir())
+	if c.ClientACL.DenyAction != "drop" {
+		t.Fatalf("deny_action = %q, want drop", c.ClientACL.DenyAction)
+	}
+}
+
+func TestUnmarshalJSON_Policy(t *testing.T) {
+	raw := []byte(`{"policy":{"categories":{"games":{"domains":["roblox.com"]}},"groups":[{"name":"kids","clients":["192.168.1.0/24"],"safe_search":true,"schedules":[{"days":["weekdays"],"from":"22:00","to":"07:00","block_categories":["games"]}]}],"timezone":"UTC"}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Policy.Groups) != 1 || !c.Policy.Groups[0].SafeSearch || len(c.Policy.Groups[0].Schedules) != 1 {
+		t.Fatalf("policy not read: %+v", c.Policy)
+	}
+	if got := c.Policy.Categories["games"].Domains; len(got) != 1 || c.Policy.Timezone != "UTC" {
+		t.Fatalf("policy categories/timezone not read: %+v", c.Policy)
+	}
+}
+
+func TestUnmarshalJSON_LocalZones(t *testing.T) {
+	raw := []byte(`{"local_zones":{"exclude":["local"],"include":["corp.lan"]}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.LocalZones.Disabled || len(c.LocalZones.Exclude) != 1 || len(c.LocalZones.Include) != 1 {
+		t.Fatalf("local_zones not read: %+v", c.LocalZones)
+	}
+}
+
+func TestUnmarshalJSON_Geo(t *testing.T) {
+	raw := []byte(`{"geo":{"databases":["/var/lib/GeoIP/GeoLite2-Country.mmdb"],"regions":{"eu":["10.1.0.0/16"]}}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Geo.Databases) != 1 || len(c.Geo.Regions["eu"]) != 1 {
+		t.Fatalf("geo not read: %+v", c.Geo)
+	}
+}
+
+func TestUnmarshalJSON_DHCP(t *testing.T) {
+	raw := []byte(`{"dhcp":{"leases":[{"format":"isc","path":"/var/lib/dhcp/dhcpd.leases"}],"domain":"lan.example.com","conflict_policy":"suffix-mac","max_ttl":600}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.DHCP.Enabled() || c.DHCP.Leases[0].Format != "isc" || c.DHCP.ConflictPolicy != "suffix-mac" || c.DHCP.MaxTTL != 600 {
+		t.Fatalf("dhcp not read: %+v", c.DHCP)
+	}
+}
+
+func TestUnmarshalJSON_Tracing(t *testing.T) {
+	raw := []byte(`{"tracing":{"enabled":true,"endpoint":"http://otel:4318/v1/traces","headers":{"x-api-key":"k"},"slow_ms":250,"errors":true}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	tc := c.Tracing
+	if !tc.Enabled || tc.Endpoint != "http://otel:4318/v1/traces" || tc.Headers["x-api-key"] != "k" || tc.SlowMs != 250 || !tc.Errors {
+		t.Fatalf("tracing not read: %+v", tc)
+	}
+	if tc.SampleRatio != 1 {
+		t.Errorf("sample_ratio = %v, want 1 when left out", tc.SampleRatio)
+	}
+}
+
+func TestUnmarshalJSON_Events(t *testing.T) {
+	raw := []byte(`{"events":{"webhooks":[{"name":"ops","url":"https://hooks.example/dns","secret":"s","events":["upstream.*"]}],"queue_file":"queue.json"}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Events.Webhooks) != 1 || c.Events.Webhooks[0].Secret != "s" || c.Events.Webhooks[0].Events[0] != "upstream.*" {
+		t.Fatalf("webhooks not read: %+v", c.Events)
+	}
+	dir := t.TempDir()
+	c.applyDefaults(dir)
+	if want := filepath.Join(dir, "queue.json"); c.Events.QueueFile != want {
+		t.Errorf("queue_file = %q, want %q", c.Events.QueueFile, want)
+	}
+	if c.Events.LimiterDropSpike != 1000 {
+		t.Errorf("limiter_drop_spike = %d, want default 1000", c.Events.LimiterDropSpike)
+	}
+}
+
+func TestUnmarshalJSON_DNSCookies(t *testing.T) {
+	raw := []byte(`{"dns_cookies_enabled":true,"dns_cookie_secret_rotation_seconds":60,"dns_cookie_unverified_rps":5,"upstream_dns_cookies":true}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.DNSCookiesEnabled || !c.UpstreamDNSCookies || c.DNSCookieUnverifiedRPS != 5 {
+		t.Fatalf("dns cookie keys not read: %+v", c)
+	}
+	c.applyDefaults(t.TempDir())
+	if c.DNSCookieSecretRotationSeconds != 300 {
+		t.Fatalf("rotation = %d, want clamp to 300", c.DNSCookieSecretRotationSeconds)
+	}
+	if c.DNSCookieUnverifiedBurst != 20 {
+		t.Fatalf("burst = %d, want default 20", c.DNSCookieUnverifiedBurst)
+	}
+}
+
+func TestUnmarshalJSON_RecordsHistory(t *testing.T) {
+	raw := []byte(`{"records_history":true,"records_history_dir":"journal"}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.RecordsHistory {
+		t.Fatal("records_history not read")
+	}
+	dir := t.TempDir()
+	c.applyDefaults(dir)
+	if want := filepath.Join(dir, "journal"); c.RecordsHistoryDir != want {
+		t.Fatalf("records_history_dir = %q, want %q", c.RecordsHistoryDir, want)
+	}
+}
+
+func TestUnmarshalJSON_RecordsSources(t *testing.T) {
+	raw := []byte(`{"file_locations":{"records_sources":[{"type":"url","location":"https://example.com/r.json"},{"type":"file","location":"local.json","writable":true,"merge":"union"}]}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	c.applyDefaults(dir)
+	src := c.FileLocations.Sources()
+	if len(src) != 2 || src[0].Name != "url-1" || src[0].RefreshIntervalSeconds != 60 || src[0].Merge != RecordsMergeOverride {
+		t.Fatalf("sources: %+v", src)
+	}
+	if w := c.FileLocations.WritableSource(); w == nil || w.Location != filepath.Join(dir, "local.json") || w.Merge != RecordsMergeUnion {
+		t.Fatalf("writable: %+v", w)
+	}
+	if err := c.FileLocations.ValidateRecordsSources(); err != nil {
+		t.Fatal(err)
+	}
+	c.FileLocations.RecordsSources[0].Writable = true
+	if err := c.FileLocations.ValidateRecordsSources(); err == nil {
+		t.Fatal("writable url source accepted")
+	}
+
+	// A lone records_source is one source, writable when it is a file.
+	single := FileLocations{RecordsSource: &RecordsSourceConfig{Type: RecordsSourceFile, Location: "/tmp/r.json"}}
+	if s := single.Sources(); len(s) != 1 || !s[0].Writable || single.WritableSource() == nil {
+		t.Fatalf("single source: %+v", s)
+	}
+}
+
+func TestUnmarshalJSON_APITLS(t *testing.T) {
+	raw := []byte(`{"api_bind":"127.0.0.1","api_tls_cert":"server.pem","api_tls_key":"server.key","api_tls_client_ca":"ca.pem","api_tls_client_crl":"ca.crl","api_tls_client_roles":[{"name":"monitoring","match":["cn:prometheus"],"scopes":["stats:read"]}],"api_rate_limit_rps":5}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.APIBind != "127.0.0.1" || c.APITLSCertFile != "server.pem" || c.APITLSKeyFile != "server.key" || c.APIRateLimitPerIP != 5 {
+		t.Fatalf("api listener keys not read: %+v", c)
+	}
+	if c.APITLSClientCAFile != "ca.pem" || c.APITLSClientCRLFile != "ca.crl" || len(c.APITLSClientRoles) != 1 || c.APITLSClientRoles[0].Match[0] != "cn:prometheus" {
+		t.Fatalf("client certificate keys not read: %+v", c)
+	}
+}
+
+func TestUnmarshalJSON_TUIAuth(t *testing.T) {
+	raw := []byte(`{"tui_auth":{"tls_cert":"tui.pem","tls_key":"tui.key","users":[{"name":"alice","password_hash":"x"},{"name":"ops","role":"admin","cert_match":["cn:ops"]}]}}`)
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	c.applyDefaults(t.TempDir())
+	ta := c.TUIAuth
+	if !ta.TLSEnabled() || !ta.LoginRequired() || len(ta.Users) != 2 || ta.Users[1].CertMatch[0] != "cn:ops" {
+		t.Fatalf("tui_auth not read: %+v", ta)
+	}
+	if ta.Users[0].Role != TUIRoleReadOnly || ta.MaxFailures != 5 || ta.LockoutSeconds != 300 {
+		t.Fatalf("tui_auth defaults: %+v", ta)
+	}
+}
+
+func TestUnmarshalJSON_ConfigWatch(t *testing.T) {
+	var c Config
+	if err := json.Unmarshal([]byte(`{"config_watch":true}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.ConfigWatch {
+		t.Fatal("config_watch not read")
+	}
+}
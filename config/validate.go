@@ -0,0 +1,604 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldError is a problem with one configuration key, named by its JSON path (e.g. "tui_auth.users[0].role").
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Redacted stands in for secret values in Redact output.
+const Redacted = "(redacted)"
+
+// What a changed key needs before it takes effect (Change.Restart).
+const (
+	RestartDNS     = "dns"     // DNS listeners: UDP/TCP, DoT and DoH
+	RestartAPI     = "api"     // REST API listener
+	RestartTUI     = "tui"     // TCP and SSH TUI listeners
+	RestartProcess = "process" // read once at start; applies when dnsplane restarts
+)
+
+// Change is a top-level key that differs between two configurations.
+type Change struct {
+	Field   string `json:"field"`
+	Restart string `json:"restart,omitempty"` // "" when the change applies live, else a Restart* value
+}
+
+// restartKeys lists the keys that are not picked up live, and what they need. Every other key is read where it
+// is used, or recompiled by the settings update.
+var restartKeys = map[string]string{
+	"port":               RestartDNS,
+	"dns_bind":           RestartDNS,
+	"dot_enabled":        RestartDNS,
+	"dot_bind":           RestartDNS,
+	"dot_port":           RestartDNS,
+	"dot_cert_file":      RestartDNS,
+	"dot_key_file":       RestartDNS,
+	"doh_enabled":        RestartDNS,
+	"doh_bind":           RestartDNS,
+	"doh_port":           RestartDNS,
+	"doh_path":           RestartDNS,
+	"doh_cert_file":      RestartDNS,
+	"doh_key_file":       RestartDNS,
+	"api":                RestartAPI,
+	"apiport":            RestartAPI,
+	"api_bind":           RestartAPI,
+	"api_tls_cert":       RestartAPI,
+	"api_tls_key":        RestartAPI,
+	"api_tls_client_ca":  RestartAPI,
+	"api_tls_client_crl": RestartAPI,
+	"server_tcp":         RestartTUI,
+	"tui_auth":           RestartTUI,
+	"tui_ssh":            RestartTUI,
+
+	"server_socket":                      RestartProcess,
+	"file_locations":                     RestartProcess,
+	"log":                                RestartProcess,
+	"full_stats":                         RestartProcess,
+	"full_stats_dir":                     RestartProcess,
+	"records_history":                    RestartProcess,
+	"records_history_dir":                RestartProcess,
+	"api_tokens_file":                    RestartProcess,
+	"upstream_dns_cookies":               RestartProcess,
+	"dnssec_sign_enabled":                RestartProcess,
+	"dnssec_sign_zone":                   RestartProcess,
+	"dnssec_sign_key_file":               RestartProcess,
+	"dnssec_sign_private_key_file":       RestartProcess,
+	"pprof_enabled":                      RestartProcess,
+	"pprof_listen":                       RestartProcess,
+	"cluster_enabled":                    RestartProcess,
+	"cluster_listen_addr":                RestartProcess,
+	"cluster_sync_interval_seconds":      RestartProcess,
+	"cluster_discovery_srv":              RestartProcess,
+	"cluster_discovery_interval_seconds": RestartProcess,
+	"dhcp":                               RestartProcess,
+}
+
+// Changes lists the top-level keys whose values differ between old and updated, in field order, with what each
+// needs to take effect.
+func Changes(old, updated Config) []Change {
+	var out []Change
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(updated)
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonName(t.Field(i))
+		if name == "" || reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+		out = append(out, Change{Field: name, Restart: restartKeys[name]})
+	}
+	return out
+}
+
+// Restarts returns the listeners that changes need restarted, without RestartProcess, in the order DNS, API, TUI.
+func Restarts(changes []Change) []string {
+	var out []string
+	for _, l := range []string{RestartDNS, RestartAPI, RestartTUI} {
+		if slices.ContainsFunc(changes, func(c Change) bool { return c.Restart == l }) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// MergePatch applies an RFC 7396 JSON merge patch to c: objects merge key by key, null removes a key (resets it
+// to its zero value), anything else replaces. Keys the configuration does not have and values of the wrong type
+// are returned as field errors and nothing is applied.
+func MergePatch(c Config, patch []byte) (Config, []FieldError, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return c, nil, fmt.Errorf("config: patch is not valid JSON: %w", err)
+	}
+	obj, ok := p.(map[string]any)
+	if !ok {
+		return c, nil, fmt.Errorf("config: patch must be a JSON object")
+	}
+	if errs := checkPatch(reflect.TypeOf(c), obj, ""); len(errs) > 0 {
+		return c, errs, nil
+	}
+	cur, err := json.Marshal(c)
+	if err != nil {
+		return c, nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(cur, &doc); err != nil {
+		return c, nil, err
+	}
+	merged, err := json.Marshal(mergePatch(doc, obj))
+	if err != nil {
+		return c, nil, err
+	}
+	// Decode by field tags: Config.UnmarshalJSON is for config files and also accepts legacy key names.
+	type plain Config
+	var out plain
+	if err := json.Unmarshal(merged, &out); err != nil {
+		return c, nil, err
+	}
+	return Config(out), nil, nil
+}
+
+func mergePatch(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// checkPatch reports keys of obj that t has no field for, and values that do not decode into their field.
+func checkPatch(t reflect.Type, obj map[string]any, prefix string) []FieldError {
+	var errs []FieldError
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		v := obj[k]
+		path := prefix + k
+		f, ok := fieldByJSONName(t, k)
+		if !ok {
+			errs = append(errs, FieldError{Field: path, Message: "unknown field"})
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sub, isObj := v.(map[string]any); isObj && ft.Kind() == reflect.Struct {
+			errs = append(errs, checkPatch(ft, sub, path+".")...)
+			continue
+		}
+		raw, _ := json.Marshal(v)
+		if err := json.Unmarshal(raw, reflect.New(f.Type).Interface()); err != nil {
+			errs = append(errs, FieldError{Field: path, Message: "want " + kindName(f.Type)})
+		}
+	}
+	return errs
+}
+
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); jsonName(f) == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func jsonName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Pointer:
+		return kindName(t.Elem())
+	}
+	return "an object"
+}
+
+// Redact returns c with tokens, password hashes, tracing export headers, and webhook secrets replaced by
+// Redacted, for showing the configuration to API clients.
+func Redact(c Config) Config {
+	for _, s := range []*string{&c.APIAuthToken, &c.ClusterAuthToken, &c.ClusterAdminToken} {
+		if *s != "" {
+			*s = Redacted
+		}
+	}
+	if len(c.TUIAuth.Users) > 0 {
+		c.TUIAuth.Users = slices.Clone(c.TUIAuth.Users)
+		for i := range c.TUIAuth.Users {
+			if c.TUIAuth.Users[i].PasswordHash != "" {
+				c.TUIAuth.Users[i].PasswordHash = Redacted
+			}
+		}
+	}
+	if len(c.Tracing.Headers) > 0 {
+		headers := make(map[string]string, len(c.Tracing.Headers))
+		for k := range c.Tracing.Headers {
+			headers[k] = Redacted
+		}
+		c.Tracing.Headers = headers
+	}
+	if len(c.Events.Webhooks) > 0 {
+		c.Events.Webhooks = slices.Clone(c.Events.Webhooks)
+		for i := range c.Events.Webhooks {
+			if c.Events.Webhooks[i].Secret != "" {
+				c.Events.Webhooks[i].Secret = Redacted
+			}
+		}
+	}
+	return c
+}
+
+// KeepSecrets puts the secrets of old back where c still holds Redacted, so a configuration read through Redact
+// can be sent back without knowing them. TUI users and webhooks are matched by name, tracing headers by key.
+func KeepSecrets(c *Config, old Config) {
+	keep := func(s *string, prev string) {
+		if *s == Redacted {
+			*s = prev
+		}
+	}
+	keep(&c.APIAuthToken, old.APIAuthToken)
+	keep(&c.ClusterAuthToken, old.ClusterAuthToken)
+	keep(&c.ClusterAdminToken, old.ClusterAdminToken)
+	if len(c.Tracing.Headers) > 0 {
+		headers := make(map[string]string, len(c.Tracing.Headers))
+		for k, h := range c.Tracing.Headers {
+			if h == Redacted {
+				h = old.Tracing.Headers[k]
+			}
+			headers[k] = h
+		}
+		c.Tracing.Headers = headers
+	}
+	if len(c.Events.Webhooks) > 0 {
+		c.Events.Webhooks = slices.Clone(c.Events.Webhooks)
+		for i := range c.Events.Webhooks {
+			h := &c.Events.Webhooks[i]
+			if h.Secret != Redacted {
+				continue
+			}
+			h.Secret = ""
+			for _, o := range old.Events.Webhooks {
+				if o.Name == h.Name {
+					h.Secret = o.Secret
+					break
+				}
+			}
+		}
+	}
+	if len(c.TUIAuth.Users) == 0 {
+		return
+	}
+	c.TUIAuth.Users = slices.Clone(c.TUIAuth.Users)
+	for i := range c.TUIAuth.Users {
+		u := &c.TUIAuth.Users[i]
+		if u.PasswordHash != Redacted {
+			continue
+		}
+		u.PasswordHash = ""
+		for _, o := range old.TUIAuth.Users {
+			if o.Name == u.Name {
+				u.PasswordHash = o.PasswordHash
+				break
+			}
+		}
+	}
+}
+
+// Validate checks values that the JSON types alone do not: ports, addresses, limits, modes, and settings that
+// need each other. It returns one error per offending key.
+func Validate(c Config) []FieldError {
+	v := &validator{}
+	v.port("port", c.DNSPort, true)
+	v.port("apiport", c.RESTPort, c.APIEnabled)
+	v.port("fallback_server_port", c.FallbackServerPort, false)
+	v.ip("fallback_server_ip", c.FallbackServerIP)
+	v.oneOf("fallback_server_transport", c.FallbackServerTransport, "", "udp", "tcp", "dot", "doh")
+	v.nonNegative("timeout", c.Timeout)
+
+	v.ip("dns_bind", c.DNSBind)
+	v.ip("api_bind", c.APIBind)
+	v.pair("api_tls_cert", c.APITLSCertFile, "api_tls_key", c.APITLSKeyFile)
+	if strings.TrimSpace(c.APITLSClientCAFile) != "" && strings.TrimSpace(c.APITLSCertFile) == "" {
+		v.add("api_tls_client_ca", "needs api_tls_cert and api_tls_key")
+	}
+	if strings.TrimSpace(c.APITLSClientCRLFile) != "" && strings.TrimSpace(c.APITLSClientCAFile) == "" {
+		v.add("api_tls_client_crl", "needs api_tls_client_ca")
+	}
+	for i, r := range c.APITLSClientRoles {
+		if len(r.Match) == 0 {
+			v.add(fmt.Sprintf("api_tls_client_roles[%d].match", i), "must not be empty")
+		}
+	}
+
+	v.nonNegativeFloat("api_rate_limit_rps", c.APIRateLimitPerIP)
+	v.nonNegative("api_rate_limit_burst", c.APIRateLimitBurst)
+	v.nonNegativeFloat("dns_rate_limit_rps", c.DNSRateLimitPerIP)
+	v.nonNegative("dns_rate_limit_burst", c.DNSRateLimitBurst)
+	v.nonNegative("dns_amplification_max_ratio", c.DNSAmplificationMaxRatio)
+	v.oneOf("dns_response_limit_mode", c.DNSResponseLimitMode, "", "sliding_window", "rrl")
+	v.nonNegative("dns_sliding_window_seconds", c.DNSSlidingWindowSeconds)
+	v.nonNegative("dns_max_responses_per_ip_window", c.DNSMaxResponsesPerIPWindow)
+	v.nonNegative("dns_rrl_max_per_bucket", c.DNSRRLMaxPerBucket)
+	v.nonNegative("dns_rrl_window_seconds", c.DNSRRLWindowSeconds)
+	if c.DNSRRLSlip < 0 || c.DNSRRLSlip > 1 {
+		v.add("dns_rrl_slip", "must be between 0 and 1")
+	}
+	v.nonNegative("dns_cookie_secret_rotation_seconds", c.DNSCookieSecretRotationSeconds)
+	v.nonNegativeFloat("dns_cookie_unverified_rps", c.DNSCookieUnverifiedRPS)
+	v.nonNegative("dns_cookie_unverified_burst", c.DNSCookieUnverifiedBurst)
+
+	if c.DOTEnabled {
+		v.ip("dot_bind", c.DOTBind)
+		v.port("dot_port", c.DOTPort, false)
+		v.required("dot_cert_file", c.DOTCertFile)
+		v.required("dot_key_file", c.DOTKeyFile)
+	}
+	if c.DOHEnabled {
+		v.ip("doh_bind", c.DOHBind)
+		v.port("doh_port", c.DOHPort, false)
+		if p := strings.TrimSpace(c.DOHPath); p != "" && !strings.HasPrefix(p, "/") {
+			v.add("doh_path", "must start with /")
+		}
+		v.pair("doh_cert_file", c.DOHCertFile, "doh_key_file", c.DOHKeyFile)
+	}
+	if c.DNSSECSignEnabled {
+		v.required("dnssec_sign_zone", c.DNSSECSignZone)
+		v.required("dnssec_sign_key_file", c.DNSSECSignKeyFile)
+		v.required("dnssec_sign_private_key_file", c.DNSSECSignPrivateKeyFile)
+	}
+
+	v.nonNegative("min_cache_ttl_seconds", c.MinCacheTTLSeconds)
+	v.nonNegative("cache_warm_interval_seconds", c.CacheWarmIntervalSeconds)
+	v.nonNegative("cache_compact_interval_seconds", c.CacheCompactIntervalSeconds)
+	v.nonNegative("dashboard_resolution_log_cap", c.DashboardResolutionLogCap)
+	v.nonNegative("upstream_health_check_failures", c.UpstreamHealthCheckFailures)
+	v.nonNegative("upstream_health_check_interval_seconds", c.UpstreamHealthCheckIntervalSeconds)
+	if strings.ContainsAny(strings.TrimSpace(c.UpstreamHealthCheckQueryName), " \t") {
+		v.add("upstream_health_check_query_name", "must be a domain name")
+	}
+	for i, p := range c.AdblockListFiles {
+		if strings.TrimSpace(p) == "" {
+			v.add(fmt.Sprintf("adblock_list_files[%d]", i), "must not be empty")
+		}
+	}
+
+	v.hostPort("server_tcp", c.ClientTCPAddress)
+	v.tuiAuth(c.TUIAuth)
+	if len(c.TUIAuth.Users) > 0 && !c.TUIAuth.TLSEnabled() && strings.TrimSpace(c.ClientTCPAddress) != "" {
+		v.add("tui_auth.users", "logins on server_tcp need tui_auth.tls_cert and tui_auth.tls_key")
+	}
+	if v.hostPort("tui_ssh.listen", c.TUISSH.Listen) && strings.TrimSpace(c.TUISSH.Listen) != "" &&
+		!slices.ContainsFunc(c.TUIAuth.Users, func(u TUIUser) bool { return strings.TrimSpace(u.AuthorizedKeys) != "" }) {
+		v.add("tui_ssh.listen", "needs tui_auth users with authorized_keys")
+	}
+
+	if err := c.FileLocations.ValidateRecordsSources(); err != nil {
+		v.add("file_locations", err.Error())
+	}
+	v.oneOf("log.log_severity", strings.ToLower(c.Log.Severity), "", "none", "debug", "info", "warn", "warning", "error")
+	v.oneOf("log.log_rotation", string(c.Log.Rotation), "", string(LogRotationNone), string(LogRotationSize), string(LogRotationTime))
+	v.nonNegative("log.log_rotation_size_mb", c.Log.RotationSizeMB)
+	v.nonNegative("log.log_rotation_time_days", c.Log.RotationDays)
+
+	if c.PprofEnabled {
+		v.hostPort("pprof_listen", c.PprofListen)
+	}
+	if c.ClusterEnabled {
+		v.hostPort("cluster_listen_addr", c.ClusterListenAddr)
+	}
+	v.oneOf("cluster_sync_policy", c.ClusterSyncPolicy, "", "lww_per_node", "primary_writer", "global_lww")
+	v.nonNegative("cluster_sync_interval_seconds", c.ClusterSyncIntervalSeconds)
+	v.nonNegative("cluster_discovery_interval_seconds", c.ClusterDiscoveryIntervalSeconds)
+	if c.Tracing.Enabled {
+		if e := strings.TrimSpace(c.Tracing.Endpoint); e != "" {
+			if u, err := url.Parse(e); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				v.add("tracing.endpoint", "must be an http or https URL")
+			}
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			v.add("tracing.sample_ratio", "must be between 0 and 1")
+		}
+		v.nonNegative("tracing.slow_ms", c.Tracing.SlowMs)
+	}
+	hooks := make(map[string]bool, len(c.Events.Webhooks))
+	for i, h := range c.Events.Webhooks {
+		name := strings.TrimSpace(h.Name)
+		switch {
+		case name == "":
+			v.add(fmt.Sprintf("events.webhooks[%d].name", i), "is required")
+		case hooks[name]:
+			v.add(fmt.Sprintf("events.webhooks[%d].name", i), "duplicate webhook "+name)
+		}
+		hooks[name] = true
+		if u, err := url.Parse(strings.TrimSpace(h.URL)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add(fmt.Sprintf("events.webhooks[%d].url", i), "must be an http or https URL")
+		}
+	}
+	v.nonNegative("events.limiter_drop_spike", c.Events.LimiterDropSpike)
+	for i, n := range c.AXFRAllowedNetworks {
+		if !validIPOrCIDR(n) {
+			v.add(fmt.Sprintf("axfr_allowed_networks[%d]", i), "must be an IP address or CIDR")
+		}
+	}
+	return v.errs
+}
+
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) add(field, msg string) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: msg})
+}
+
+func (v *validator) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *validator) port(field, value string, required bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+		v.add(field, "must be a port number (1-65535)")
+	}
+}
+
+func (v *validator) ip(field, value string) {
+	if value = strings.TrimSpace(value); value != "" && net.ParseIP(value) == nil {
+		v.add(field, "must be an IP address")
+	}
+}
+
+// hostPort checks an optional "host:port" listen address and reports whether it is valid.
+func (v *validator) hostPort(field, value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return true
+	}
+	_, port, err := net.SplitHostPort(value)
+	if err == nil {
+		var n int
+		n, err = strconv.Atoi(port)
+		if err == nil && (n < 0 || n > 65535) {
+			err = fmt.Errorf("bad port")
+		}
+	}
+	if err != nil {
+		v.add(field, "must be host:port")
+		return false
+	}
+	return true
+}
+
+func (v *validator) pair(aField, a, bField, b string) {
+	hasA, hasB := strings.TrimSpace(a) != "", strings.TrimSpace(b) != ""
+	if hasA && !hasB {
+		v.add(bField, "is required with "+aField)
+	}
+	if hasB && !hasA {
+		v.add(aField, "is required with "+bField)
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	if !slices.Contains(allowed, strings.TrimSpace(value)) {
+		var named []string
+		for _, a := range allowed {
+			if a != "" {
+				named = append(named, a)
+			}
+		}
+		v.add(field, "must be one of "+strings.Join(named, ", "))
+	}
+}
+
+func (v *validator) nonNegative(field string, n int) {
+	if n < 0 {
+		v.add(field, "must not be negative")
+	}
+}
+
+func (v *validator) nonNegativeFloat(field string, f float64) {
+	if f < 0 {
+		v.add(field, "must not be negative")
+	}
+}
+
+func (v *validator) tuiAuth(a TUIAuthConfig) {
+	v.pair("tui_auth.tls_cert", a.TLSCert, "tui_auth.tls_key", a.TLSKey)
+	v.nonNegative("tui_auth.max_failures", a.MaxFailures)
+	v.nonNegative("tui_auth.lockout_seconds", a.LockoutSeconds)
+	seen := map[string]bool{}
+	for i, u := range a.Users {
+		field := fmt.Sprintf("tui_auth.users[%d]", i)
+		name := strings.TrimSpace(u.Name)
+		switch {
+		case name == "":
+			v.add(field+".name", "is required")
+		case seen[name]:
+			v.add(field+".name", fmt.Sprintf("duplicate user %q", name))
+		}
+		seen[name] = true
+		switch strings.ToLower(strings.TrimSpace(u.Role)) {
+		case "", TUIRoleAdmin, TUIRoleReadOnly, "readonly", "ro":
+		default:
+			v.add(field+".role", fmt.Sprintf("must be %s or %s", TUIRoleAdmin, TUIRoleReadOnly))
+		}
+		if u.PasswordHash == "" && len(u.CertMatch) == 0 && strings.TrimSpace(u.AuthorizedKeys) == "" {
+			v.add(field, "needs password_hash, cert_match or authorized_keys")
+		}
+	}
+}
+
+func validIPOrCIDR(s string) bool {
+	s = strings.TrimSpace(s)
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func testConfig(t *testing.T) Config {
+	t.Helper()
+	c := defaultConfig(t.TempDir())
+	c.applyDefaults(t.TempDir())
+	return *c
+}
+
+func errorFields(errs []FieldError) []string {
+	var out []string
+	for _, e := range errs {
+		out = append(out, e.Field)
+	}
+	return out
+}
+
+func TestValidate_DefaultConfig(t *testing.T) {
+	if errs := Validate(testConfig(t)); len(errs) > 0 {
+		t.Fatalf("default config has errors: %v", errs)
+	}
+}
+
+func TestValidate_FieldErrors(t *testing.T) {
+	c := testConfig(t)
+	c.DNSPort = "70000"
+	c.APIBind = "not-an-ip"
+	c.DNSRateLimitPerIP = -1
+	c.DNSRRLSlip = 2
+	c.APITLSCertFile = "/etc/cert.pem"
+	c.Log.Severity = "loud"
+	c.TUIAuth.Users = []TUIUser{{Name: "a", Role: "root", PasswordHash: "x"}, {Name: "a", PasswordHash: "x"}}
+	c.TUISSH.Listen = "2222"
+	c.Tracing = TracingConfig{Enabled: true, Endpoint: "collector:4318", SampleRatio: 2}
+	c.Events.Webhooks = []Webhook{{Name: "ops", URL: "https://hooks.example/dns"}, {Name: "ops", URL: "hooks.example"}}
+	got := errorFields(Validate(c))
+	want := []string{
+		"port", "api_bind", "api_tls_key", "dns_rate_limit_rps", "dns_rrl_slip",
+		"tui_auth.users[0].role", "tui_auth.users[1].name", "tui_auth.users",
+		"tui_ssh.listen", "log.log_severity", "tracing.endpoint", "tracing.sample_ratio",
+		"events.webhooks[1].name", "events.webhooks[1].url",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	c := testConfig(t)
+	c.AdblockListFiles = []string{"/a", "/b"}
+	got, errs, err := MergePatch(c, []byte(`{
+		"dns_rate_limit_rps": 20,
+		"adblock_list_files": ["/c"],
+		"tui_ssh": {"listen": ":2222"},
+		"client_acl": {"deny": ["10.0.0.1"]},
+		"dot_enabled": true,
+		"api_auth_token": null
+	}`))
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("MergePatch: %v %v", err, errs)
+	}
+	if got.DNSRateLimitPerIP != 20 || !slices.Equal(got.AdblockListFiles, []string{"/c"}) || got.TUISSH.Listen != ":2222" {
+		t.Fatalf("patch not applied: %+v", got)
+	}
+	if got.TUISSH.HostKey != c.TUISSH.HostKey {
+		t.Errorf("tui_ssh.host_key = %q, want it kept", got.TUISSH.HostKey)
+	}
+	if !slices.Equal(got.ClientACL.Deny, []string{"10.0.0.1"}) || !got.DOTEnabled {
+		t.Errorf("nested or untagged-by-UnmarshalJSON keys lost: %+v %v", got.ClientACL, got.DOTEnabled)
+	}
+	if got.DNSPort != c.DNSPort || got.FileLocations.CacheFile != c.FileLocations.CacheFile {
+		t.Error("keys outside the patch changed")
+	}
+}
+
+func TestMergePatch_Errors(t *testing.T) {
+	c := testConfig(t)
+	_, errs, err := MergePatch(c, []byte(`{"port": 53, "nope": 1, "tui_ssh": {"listen": true, "extra": "x"}, "timeout": "2"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"nope", "port", "timeout", "tui_ssh.extra", "tui_ssh.listen"}
+	if got := errorFields(errs); !slices.Equal(got, want) {
+		t.Fatalf("fields = %v, want %v", got, want)
+	}
+	if _, _, err := MergePatch(c, []byte(`[1]`)); err == nil {
+		t.Error("non-object patch accepted")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	old := testConfig(t)
+	updated := old
+	updated.DNSRateLimitPerIP = 5
+	updated.RESTPort = "9090"
+	updated.TUISSH.Listen = ":2222"
+	updated.Log.Severity = "debug"
+	got := Changes(old, updated)
+	want := []Change{
+		{Field: "apiport", Restart: RestartAPI},
+		{Field: "dns_rate_limit_rps"},
+		{Field: "tui_ssh", Restart: RestartTUI},
+		{Field: "log", Restart: RestartProcess},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Changes = %v, want %v", got, want)
+	}
+	if r := Restarts(got); !slices.Equal(r, []string{RestartAPI, RestartTUI}) {
+		t.Errorf("Restarts = %v", r)
+	}
+}
+
+func TestRedactKeepSecrets(t *testing.T) {
+	c := testConfig(t)
+	c.APIAuthToken = "secret"
+	c.TUIAuth.Users = []TUIUser{{Name: "alice", PasswordHash: "hash"}, {Name: "ops", CertMatch: []string{"cn:ops"}}}
+	c.Tracing.Headers = map[string]string{"x-api-key": "key"}
+	c.Events.Webhooks = []Webhook{{Name: "ops", URL: "https://hooks.example/dns", Secret: "hmac"}}
+	r := Redact(c)
+	if r.APIAuthToken != Redacted || r.TUIAuth.Users[0].PasswordHash != Redacted || r.TUIAuth.Users[1].PasswordHash != "" {
+		t.Fatalf("Redact = %+v", r)
+	}
+	if r.Tracing.Headers["x-api-key"] != Redacted {
+		t.Fatalf("tracing headers = %v", r.Tracing.Headers)
+	}
+	if r.Events.Webhooks[0].Secret != Redacted {
+		t.Fatalf("webhooks = %+v", r.Events.Webhooks)
+	}
+	if c.TUIAuth.Users[0].PasswordHash != "hash" || c.Tracing.Headers["x-api-key"] != "key" || c.Events.Webhooks[0].Secret != "hmac" {
+		t.Fatal("Redact changed its argument")
+	}
+	r.TUIAuth.Users = append(r.TUIAuth.Users, TUIUser{Name: "bob", PasswordHash: Redacted})
+	r.Tracing.Headers["x-tenant"] = "blue"
+	r.Events.Webhooks = append(r.Events.Webhooks, Webhook{Name: "new", URL: "https://new.example", Secret: Redacted})
+	KeepSecrets(&r, c)
+	if r.APIAuthToken != "secret" || r.TUIAuth.Users[0].PasswordHash != "hash" || r.TUIAuth.Users[2].PasswordHash != "" {
+		t.Fatalf("KeepSecrets = %+v", r)
+	}
+	if r.Tracing.Headers["x-api-key"] != "key" || r.Tracing.Headers["x-tenant"] != "blue" {
+		t.Fatalf("KeepSecrets tracing headers = %v", r.Tracing.Headers)
+	}
+	if r.Events.Webhooks[0].Secret != "hmac" || r.Events.Webhooks[1].Secret != "" {
+		t.Fatalf("KeepSecrets webhooks = %+v", r.Events.Webhooks)
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import "dnsplane/apiauth"
+
+// SetAPITokens sets the named API credentials checked by the REST API.
+func (d *DNSResolverData) SetAPITokens(s *apiauth.Store) {
+	d.apiTokens.Store(s)
+}
+
+// APITokens returns the named API credentials, or nil when none were opened.
+func (d *DNSResolverData) APITokens() *apiauth.Store {
+	return d.apiTokens.Load()
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"dnsplane/dnsrecordcache"
+	"dnsplane/events"
 )
 
 // CacheCompactInterval returns the effective periodic compaction duration (seconds from config; if < 60, 1800).
@@ -72,7 +73,7 @@ func (d *DNSResolverData) SetNextCacheCompactAt(t time.Time) {
 	d.cacheCompactScheduleMu.Unlock()
 }
 
-// NoteCacheCompactRun records completion of a compaction pass.
+// NoteCacheCompactRun records completion of a compaction pass and publishes it as an event.
 func (d *DNSResolverData) NoteCacheCompactRun(removed int) {
 	if d == nil {
 		return
@@ -81,6 +82,7 @@ func (d *DNSResolverData) NoteCacheCompactRun(removed int) {
 	d.lastCacheCompactAt = time.Now().UTC()
 	d.lastCacheCompactRemoved = removed
 	d.cacheCompactScheduleMu.Unlock()
+	events.Publish(events.CacheCompacted, map[string]any{"removed": removed, "remaining": d.CacheRecordCount()})
 }
 
 // CacheCompactSnapshot returns scheduling metadata for dashboards and APIs.
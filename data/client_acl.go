@@ -0,0 +1,29 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"dnsplane/acl"
+	"dnsplane/config"
+)
+
+// ClientACL returns the compiled client ACL (allow_query / allow_recursion / deny) for the current settings.
+// Nil means no ACL is configured; the result is safe to use without holding d.mu.
+func (d *DNSResolverData) ClientACL() *acl.ACL {
+	return d.clientACL.Load()
+}
+
+// compileClientACL builds the ACL for a settings change. ok is false for an invalid client_acl, which
+// keeps the previous ACL.
+func compileClientACL(cfg config.ClientACLConfig) (a *acl.ACL, ok bool) {
+	a, err := acl.Compile(cfg)
+	if err != nil {
+		resolverSlog().Warn("client_acl: invalid configuration, keeping previous ACL", "error", err)
+		return nil, false
+	}
+	if !a.Enabled() {
+		a = nil
+	}
+	return a, true
+}
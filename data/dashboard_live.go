@@ -56,6 +56,13 @@ type DashboardResolution struct {
 	Upstream   string    `json:"upstream,omitempty"`
 	Record     string    `json:"record"`
 	DurationMs float64   `json:"duration_ms"`
+	// ACL is the client ACL decision when client_acl is configured (e.g. "local_only (allow_recursion:nomatch)").
+	ACL string `json:"acl,omitempty"`
+	// PolicyGroup and PolicyRule are set when a policy group matched the client (rule is empty for a plain allow).
+	PolicyGroup string `json:"policy_group,omitempty"`
+	PolicyRule  string `json:"policy_rule,omitempty"`
+	// Geo is the location choice for geo-selected local values (e.g. "region eu", "country DE", "default").
+	Geo string `json:"geo,omitempty"`
 }
 
 // DashboardMinutePoint is one minute bucket for charts (replies count + avg latency).
@@ -5,11 +5,19 @@
 package data
 
 import (
+	"dnsplane/acl"
 	"dnsplane/adblock"
+	"dnsplane/apiauth"
 	"dnsplane/config"
+	"dnsplane/dnscookie"
 	"dnsplane/dnsrecordcache"
 	"dnsplane/dnsrecords"
 	"dnsplane/dnsservers"
+	"dnsplane/forwardzone"
+	"dnsplane/geo"
+	"dnsplane/journal"
+	"dnsplane/localzone"
+	"dnsplane/policy"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -34,8 +42,9 @@ type DNSResolverData struct {
 	DNSServers            []dnsservers.DNSServer
 	DNSRecords            []dnsrecords.DNSRecord
 	CacheRecords          []dnsrecordcache.CacheRecord
-	cacheRecordIdx        map[string][]int // normalized name|type -> CacheRecords indices
-	dnsRecordIdx          map[string][]int // normalized name|type -> DNSRecords indices (non-PTR)
+	cacheRecordIdx        map[string][]int  // normalized name|type -> CacheRecords indices
+	dnsRecordIdx          map[string][]int  // normalized name|type -> DNSRecords indices (non-PTR)
+	clientMACs            map[string]string // A/AAAA address -> "mac" of its record, for ClientMAC
 	BlockList             *adblock.BlockList
 	AdblockSources        []AdblockSource // loaded files/URLs and count per source (order preserved)
 	mu                    sync.RWMutex
@@ -43,6 +52,21 @@ type DNSResolverData struct {
 	persistWg             sync.WaitGroup
 	persistCloseOnce      sync.Once
 	upstreamHealth        *UpstreamHealthTracker
+	recordHealth          *RecordHealthTracker
+	recordRotation        *recordRotation
+	clientACL             atomic.Pointer[acl.ACL]
+	dnsCookies            atomic.Pointer[dnscookie.Server]
+	policyEngine          atomic.Pointer[policy.Engine]
+	forwardZones          atomic.Pointer[forwardzone.Table]
+	localZones            atomic.Pointer[localzone.Set] // localZonesCompiled with the zones local records disable
+	localZonesCompiled    atomic.Pointer[localzone.Set]
+	geoLocator            atomic.Pointer[geo.Locator]
+	recordJournal         atomic.Pointer[journal.Journal]
+	apiTokens             atomic.Pointer[apiauth.Store]
+	hasGeoRecords         atomic.Bool
+	recordsGeneration     atomic.Uint64     // raised with every rebuild of dnsRecordIdx
+	dnsCookieSecret       *dnscookie.Server // kept while cookies are toggled off so the secret survives
+	cookieSecretOnce      sync.Once
 	statsCacheHits        atomic.Int64
 	statsQueriesAnswered  atomic.Int64
 	statsTotalQueries     atomic.Int64
@@ -118,6 +142,7 @@ func SetConfig(loaded *config.Loaded) {
 	defer configStateMu.Unlock()
 	clone := *loaded
 	configState = &clone
+	resetRecordSources()
 	SetDashboardResolutionLogCap(clone.Config.DashboardResolutionLogCap)
 }
 
@@ -130,6 +155,11 @@ func currentConfig() config.Loaded {
 	return *configState
 }
 
+// ConfigPath returns the path of the configuration file in use.
+func ConfigPath() string {
+	return currentConfig().Path
+}
+
 func updateStoredConfig(cfgPath string, cfg config.Config) {
 	configStateMu.Lock()
 	defer configStateMu.Unlock()
@@ -161,12 +191,11 @@ func GetInstance() *DNSResolverData {
 
 // Initialize loads all data from JSON files
 func (d *DNSResolverData) Initialize() error {
+	cfg := currentConfig()
+	d.applySettings(cfg.Config)
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	cfg := currentConfig()
-	d.Settings = cfg.Config
-
 	servers, err := LoadDNSServers()
 	if err != nil {
 		return fmt.Errorf("load dns servers: %w", err)
@@ -186,24 +215,9 @@ func (d *DNSResolverData) Initialize() error {
 	d.rebuildDNSRecordIndexLocked()
 	d.rebuildCacheIndexLocked()
 	d.upstreamHealth = NewUpstreamHealthTracker()
-	d.BlockList = adblock.NewBlockList()
-	d.AdblockSources = nil
-
-	// Load adblock list files from config one by one; merge into single block list and log added each time
-	for _, path := range cfg.Config.AdblockListFiles {
-		path = strings.TrimSpace(path)
-		if path == "" {
-			continue
-		}
-		before := d.BlockList.Count()
-		if err := adblock.LoadFromFile(d.BlockList, path); err != nil {
-			resolverSlog().Warn("adblock: failed to load list file", "path", path, "error", err)
-			continue
-		}
-		after := d.BlockList.Count()
-		d.AdblockSources = append(d.AdblockSources, AdblockSource{Source: path, Count: after - before})
-		resolverSlog().Info("adblock: loaded list file", "added", after-before, "path", path, "total_domains", after)
-	}
+	d.recordHealth = NewRecordHealthTracker()
+	d.recordRotation = &recordRotation{}
+	d.BlockList, d.AdblockSources = loadAdblockListFiles(cfg.Config.AdblockListFiles)
 
 	d.Stats = DNSStats{ServerStartTime: time.Now()}
 
@@ -212,40 +226,23 @@ func (d *DNSResolverData) Initialize() error {
 	d.persistWg.Add(1)
 	go d.cachePersistWorker()
 
-	// When records are loaded from URL or git, refresh periodically
-	if rs := cfg.Config.FileLocations.RecordsSource; rs != nil {
-		t := strings.ToLower(strings.TrimSpace(rs.Type))
-		if (t == config.RecordsSourceURL || t == config.RecordsSourceGit) && rs.RefreshIntervalSeconds > 0 {
-			interval := time.Duration(rs.RefreshIntervalSeconds) * time.Second
-			go d.recordsRefreshLoop(interval)
-		}
-		if t == config.RecordsSourceBindDir && rs.RefreshIntervalSeconds > 0 {
-			interval := time.Duration(rs.RefreshIntervalSeconds) * time.Second
-			go d.recordsRefreshLoop(interval)
-		}
-		if t == config.RecordsSourceBindDir && rs.Watch {
-			wdir := strings.TrimSpace(rs.Location)
-			if wdir != "" {
-				d.stopRecordsSourceWatch = startBindDirWatch(d, wdir)
-			}
-		}
-	}
+	// Sources with refresh_interval_seconds or watch reload on their own.
+	d.startRecordSourceRefresh(cfg.Config.FileLocations.Sources())
 
 	return nil
 }
 
-// recordsRefreshLoop re-loads records from the remote source (url/git) at the given interval.
-func (d *DNSResolverData) recordsRefreshLoop(interval time.Duration) {
+// recordsRefreshLoop re-loads the records source named name at the given interval.
+func (d *DNSResolverData) recordsRefreshLoop(name string, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	for range ticker.C {
-		records, err := LoadDNSRecords()
+		n, err := d.refreshRecordSource(name)
 		if err != nil {
-			resolverSlog().Warn("records refresh failed", "error", err)
+			resolverSlog().Warn("records refresh failed", "source", name, "error", err)
 			continue
 		}
-		d.storeRecords(records, false)
-		resolverSlog().Info("records refresh completed", "records", len(records))
+		resolverSlog().Info("records refresh completed", "source", name, "records", n)
 	}
 }
 
@@ -273,6 +270,11 @@ func (d *DNSResolverData) Close() {
 		d.stopRecordsSourceWatch()
 		d.stopRecordsSourceWatch = nil
 	}
+	if j := d.recordJournal.Swap(nil); j != nil {
+		if err := j.Close(); err != nil {
+			resolverSlog().Warn("record history: close", "error", err)
+		}
+	}
 	d.persistCloseOnce.Do(func() {
 		if d.persistCh != nil {
 			close(d.persistCh)
@@ -290,17 +292,44 @@ func (d *DNSResolverData) GetResolverSettings() DNSResolverSettings {
 
 // UpdateSettings updates the DNS server settings
 func (d *DNSResolverData) UpdateSettings(settings DNSResolverSettings) {
-	d.mu.Lock()
-	d.Settings = settings
-	d.mu.Unlock()
+	d.applySettings(settings)
 	SaveSettings(settings)
 }
 
 // UpdateSettingsInMemory replaces the settings without persisting them to disk.
 func (d *DNSResolverData) UpdateSettingsInMemory(settings DNSResolverSettings) {
+	d.applySettings(settings)
+}
+
+// applySettings makes settings current. Everything derived from them (ACL, policy with its category files,
+// forward and empty zones, the geo databases) is compiled first, without d.mu, so lookups do not wait on
+// file reads; the lock is held only to swap the results in. A part that fails to compile keeps its
+// previous value.
+func (d *DNSResolverData) applySettings(settings DNSResolverSettings) {
+	clientACL, aclOK := compileClientACL(settings.ClientACL)
+	engine, policyOK := compilePolicy(settings.Policy)
+	fwd, fwdOK := compileForwardZones(settings.ForwardZones)
+	zones := compileLocalZones(settings.LocalZones)
+	locator, geoOK := d.compileGeo(settings.Geo)
+	d.configureDNSCookies(settings)
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	d.Settings = settings
+	if aclOK {
+		d.clientACL.Store(clientACL)
+	}
+	if policyOK {
+		d.policyEngine.Store(engine)
+	}
+	if fwdOK {
+		d.forwardZones.Store(fwd)
+	}
+	if geoOK {
+		d.geoLocator.Store(locator)
+	}
+	d.localZonesCompiled.Store(zones)
+	d.refreshLocalZonesLocked()
 }
 
 // GetStats returns the current DNS statistics
@@ -345,6 +374,13 @@ func (d *DNSResolverData) UpdateServers(servers []dnsservers.DNSServer) {
 	}
 }
 
+// ReplaceServers swaps the DNS servers in memory without saving them, for servers just read from the file.
+func (d *DNSResolverData) ReplaceServers(servers []dnsservers.DNSServer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.DNSServers = servers
+}
+
 // GetRecords returns the current DNS records
 func (d *DNSResolverData) GetRecords() []dnsrecords.DNSRecord {
 	d.mu.RLock()
@@ -352,23 +388,48 @@ func (d *DNSResolverData) GetRecords() []dnsrecords.DNSRecord {
 	return copyDNSRecords(d.DNSRecords)
 }
 
-// UpdateRecords updates the DNS records
-func (d *DNSResolverData) UpdateRecords(records []dnsrecords.DNSRecord) error {
+// UpdateRecords updates the DNS records. o names who made the change in the record history.
+func (d *DNSResolverData) UpdateRecords(o journal.Origin, records []dnsrecords.DNSRecord) error {
+	_, err := d.ModifyRecords(o, func([]dnsrecords.DNSRecord, uint64) ([]dnsrecords.DNSRecord, error) {
+		return records, nil
+	})
+	return err
+}
+
+// ModifyRecords replaces the DNS records with what fn makes of a copy of them, given the current
+// RecordsRevision. No other write can land between the read and the save, so fn may check the revision
+// or a record it is about to change. An error from fn is returned as is and nothing is stored. A result
+// equal to the current records is not saved. It returns the revision after the write.
+func (d *DNSResolverData) ModifyRecords(o journal.Origin, fn func(records []dnsrecords.DNSRecord, rev uint64) ([]dnsrecords.DNSRecord, error)) (uint64, error) {
 	d.mu.RLock()
 	reject := d.Settings.ClusterRejectLocalWrites
 	d.mu.RUnlock()
 	if reject {
-		return fmt.Errorf("cluster: local record writes are disabled on this node (cluster_reject_local_writes)")
+		return 0, fmt.Errorf("cluster: local record writes are disabled on this node (cluster_reject_local_writes)")
 	}
 	if RecordsSourceIsReadOnly() {
-		return fmt.Errorf("records source is read-only")
+		return 0, fmt.Errorf("records source is read-only")
 	}
-	d.storeRecords(records, true)
-	return nil
+	recordSources.storeMu.Lock()
+	defer recordSources.storeMu.Unlock()
+	rev := RecordsRevision()
+	current := d.GetRecords()
+	records, err := fn(copyDNSRecords(current), rev)
+	if err != nil {
+		return rev, err
+	}
+	if len(records) == len(current) && len(journal.Diff(current, records)) == 0 {
+		return rev, nil
+	}
+	if err := recordSources.checkWrite(records, time.Now()); err != nil {
+		return rev, err
+	}
+	d.storeRecordsLocked(records, true, o)
+	return RecordsRevision(), nil
 }
 
 // UpdateRecordsInMemory replaces DNS records without writing to disk.
-func (d *DNSResolverData) UpdateRecordsInMemory(records []dnsrecords.DNSRecord) error {
+func (d *DNSResolverData) UpdateRecordsInMemory(o journal.Origin, records []dnsrecords.DNSRecord) error {
 	d.mu.RLock()
 	reject := d.Settings.ClusterRejectLocalWrites
 	d.mu.RUnlock()
@@ -378,7 +439,10 @@ func (d *DNSResolverData) UpdateRecordsInMemory(records []dnsrecords.DNSRecord)
 	if RecordsSourceIsReadOnly() {
 		return fmt.Errorf("records source is read-only")
 	}
-	d.storeRecords(records, false)
+	if err := recordSources.checkWrite(records, time.Now()); err != nil {
+		return err
+	}
+	d.storeRecords(records, false, o)
 	return nil
 }
 
@@ -446,6 +510,37 @@ func (d *DNSResolverData) IncrementQueriesAnswered() {
 	d.statsQueriesAnswered.Add(1)
 }
 
+// loadAdblockListFiles loads the adblock list files one by one into a new block list, logging what each added.
+func loadAdblockListFiles(paths []string) (*adblock.BlockList, []AdblockSource) {
+	bl := adblock.NewBlockList()
+	var sources []AdblockSource
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		before := bl.Count()
+		if err := adblock.LoadFromFile(bl, path); err != nil {
+			resolverSlog().Warn("adblock: failed to load list file", "path", path, "error", err)
+			continue
+		}
+		after := bl.Count()
+		sources = append(sources, AdblockSource{Source: path, Count: after - before})
+		resolverSlog().Info("adblock: loaded list file", "added", after-before, "path", path, "total_domains", after)
+	}
+	return bl, sources
+}
+
+// ReloadAdblockLists replaces the block list with the contents of the given list files, as at startup. Domains
+// added by hand or from URLs since then are dropped.
+func (d *DNSResolverData) ReloadAdblockLists(paths []string) {
+	bl, sources := loadAdblockListFiles(paths)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.BlockList = bl
+	d.AdblockSources = sources
+}
+
 // GetBlockList returns the adblock list
 func (d *DNSResolverData) GetBlockList() *adblock.BlockList {
 	d.mu.RLock()
@@ -543,13 +638,13 @@ func SaveDNSServers(dnsServers []dnsservers.DNSServer) error {
 	return SaveToJSON(paths.DNSServerFile, data)
 }
 
-// ReloadRecordsFromSource reloads records from the configured source into memory without persisting to a JSON file.
+// ReloadRecordsFromSource reloads records from every configured source into memory without persisting to a JSON file.
 func (d *DNSResolverData) ReloadRecordsFromSource() (int, error) {
 	records, err := LoadDNSRecords()
 	if err != nil {
 		return 0, err
 	}
-	d.storeRecords(records, false)
+	d.storeRecords(records, false, journal.Origin{Source: journal.SourceReload})
 	return len(records), nil
 }
 
@@ -558,58 +653,68 @@ func ReloadDNSRecordsFromSource() (int, error) {
 	return GetInstance().ReloadRecordsFromSource()
 }
 
-// LoadDNSRecords loads DNS records from the configured records_source (file, URL, git, or bind_dir).
-// Record names are canonicalized (trailing dot stripped) for consistent display.
+// LoadDNSRecords loads DNS records from every configured records source (file, URL, git, or bind_dir)
+// and returns them merged by precedence. Record names are canonicalized (trailing dot stripped) for
+// consistent display.
 func LoadDNSRecords() ([]dnsrecords.DNSRecord, error) {
 	paths := currentConfig().Config.FileLocations
-	rs := paths.RecordsSource
-	if rs == nil {
+	sources := paths.Sources()
+	if len(sources) == 0 {
 		return nil, fmt.Errorf("records_source not configured")
 	}
-	t := strings.ToLower(strings.TrimSpace(rs.Type))
-	loc := strings.TrimSpace(rs.Location)
-	if loc == "" {
-		return nil, fmt.Errorf("records_source location is empty")
-	}
-	switch t {
-	case config.RecordsSourceURL:
-		return loadRecordsFromURL(loc)
-	case config.RecordsSourceGit:
-		return loadRecordsFromGit(loc)
-	case config.RecordsSourceBindDir:
-		return loadRecordsBindDir(rs)
-	case config.RecordsSourceFile:
-		fallthrough
-	default:
-		type recordsType struct {
-			Records []dnsrecords.DNSRecord `json:"records"`
-		}
-		records, err := LoadFromJSON[recordsType](loc)
+	if err := paths.ValidateRecordsSources(); err != nil {
+		return nil, err
+	}
+	layers := make([]recordLayer, len(sources))
+	writable := -1
+	var rev uint64
+	now := time.Now()
+	for i, rs := range sources {
+		records, layerRev, err := loadRecordSource(rs)
 		if err != nil {
+			if len(sources) > 1 {
+				return nil, fmt.Errorf("records source %q: %w", rs.Name, err)
+			}
 			return nil, err
 		}
-		for i := range records.Records {
-			records.Records[i].Name = dnsrecords.CanonicalizeRecordNameForStorage(records.Records[i].Name)
+		layers[i] = recordLayer{cfg: rs, records: records, refreshedAt: now}
+		if rs.Writable {
+			writable, rev = i, layerRev
 		}
-		return records.Records, nil
 	}
+	s := recordSources
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.layers = layers
+	s.writable = writable
+	s.revision = max(s.revision, rev)
+	return s.mergeLocked(now), nil
 }
 
-// SaveDNSRecords saves the DNS records to the records source path.
-// No-op when records source is URL or git (read-only).
+// SaveDNSRecords saves the DNS records to the writable records source, leaving out the records that
+// come from read-only sources. No-op when every source is read-only.
 func SaveDNSRecords(gDNSRecords []dnsrecords.DNSRecord) error {
 	if RecordsSourceIsReadOnly() {
 		return nil
 	}
-	paths := currentConfig().Config.FileLocations
-	if paths.RecordsSource == nil {
-		return fmt.Errorf("records_source not configured")
+	s := recordSources
+	s.mu.Lock()
+	layer := gDNSRecords
+	if s.writable >= 0 {
+		layer = s.splitLocked(gDNSRecords)
 	}
-	type recordsType struct {
-		Records []dnsrecords.DNSRecord `json:"records"`
+	s.mu.Unlock()
+	return saveWritableRecords(layer)
+}
+
+func saveWritableRecords(records []dnsrecords.DNSRecord) error {
+	rs := currentConfig().Config.FileLocations.WritableSource()
+	if rs == nil {
+		return fmt.Errorf("records_source not configured")
 	}
-	data := recordsType{Records: gDNSRecords}
-	return SaveToJSON(paths.RecordsSource.Location, data)
+	return SaveToJSON(rs.Location, recordsJSON{Revision: recordSources.nextRevision(), Records: records})
 }
 
 // LoadCacheRecords reads the dnscache.json file and returns the list of cache records
@@ -636,14 +741,18 @@ func SaveCacheRecords(cacheRecords []dnsrecordcache.CacheRecord) error {
 	return writeJSONFile(cfg.FileLocations.CacheFile, data, cfg.PrettyJSON)
 }
 
-func (d *DNSResolverData) storeRecords(records []dnsrecords.DNSRecord, persist bool) {
-	dnsIdx := buildDNSRecordIndex(records)
-	d.mu.Lock()
-	d.DNSRecords = records
-	d.dnsRecordIdx = dnsIdx
-	d.mu.Unlock()
+func (d *DNSResolverData) storeRecords(records []dnsrecords.DNSRecord, persist bool, o journal.Origin) {
+	recordSources.storeMu.Lock()
+	defer recordSources.storeMu.Unlock()
+	d.storeRecordsLocked(records, persist, o)
+}
+
+// storeRecordsLocked is storeRecords for a caller holding recordSources.storeMu.
+func (d *DNSResolverData) storeRecordsLocked(records []dnsrecords.DNSRecord, persist bool, o journal.Origin) {
+	merged, layer := recordSources.apply(records, time.Now())
+	d.publishRecords(merged, o)
 	if persist {
-		if err := SaveDNSRecords(records); err != nil {
+		if err := saveWritableRecords(layer); err != nil {
 			resolverSlog().Error("failed to save DNS records", "error", err)
 		} else if atomic.LoadInt32(&clusterSkipNotify) == 0 {
 			callClusterRecordsNotify()
@@ -651,9 +760,25 @@ func (d *DNSResolverData) storeRecords(records []dnsrecords.DNSRecord, persist b
 	}
 }
 
-// ApplyClusterRecords replaces in-memory and persisted DNS records from a cluster peer.
+// publishRecords makes records the served set, journals the change, and publishes it as an event.
+func (d *DNSResolverData) publishRecords(records []dnsrecords.DNSRecord, o journal.Origin) {
+	dnsIdx := buildDNSRecordIndex(records)
+	d.mu.Lock()
+	before := d.DNSRecords
+	d.DNSRecords = records
+	d.dnsRecordIdx = dnsIdx
+	d.recordsIndexedLocked()
+	d.mu.Unlock()
+	e, ok := d.journalRecords(o, records)
+	if !ok {
+		e = journal.Entry{Origin: o, Changes: journal.Diff(before, records)}
+	}
+	publishRecordsChanged(e, len(records))
+}
+
+// ApplyClusterRecords replaces in-memory and persisted DNS records from cluster peer nodeID.
 // Returns an error if the records source is read-only (URL/git).
-func (d *DNSResolverData) ApplyClusterRecords(records []dnsrecords.DNSRecord) error {
+func (d *DNSResolverData) ApplyClusterRecords(nodeID string, records []dnsrecords.DNSRecord) error {
 	if RecordsSourceIsReadOnly() {
 		return fmt.Errorf("cluster: records source is read-only")
 	}
@@ -663,7 +788,7 @@ func (d *DNSResolverData) ApplyClusterRecords(records []dnsrecords.DNSRecord) er
 	}
 	atomic.StoreInt32(&clusterSkipNotify, 1)
 	defer atomic.StoreInt32(&clusterSkipNotify, 0)
-	d.storeRecords(rec, true)
+	d.storeRecords(rec, true, journal.Origin{Source: journal.SourceCluster, Actor: nodeID})
 	return nil
 }
 
@@ -709,12 +834,12 @@ func copyCacheRecords(src []dnsrecordcache.CacheRecord) []dnsrecordcache.CacheRe
 	return dst
 }
 
-// InitializeJSONFiles creates JSON files if missing. URL/git record sources have no local records file.
+// InitializeJSONFiles creates JSON files if missing. Only the writable records source gets a local records file.
 func InitializeJSONFiles() {
 	paths := currentConfig().Config.FileLocations
 	CreateFileIfNotExists(paths.DNSServerFile, `{"dnsservers":[{"address": "1.1.1.1","port": "53","active": false,"local_resolver": false,"adblocker": false }]}`)
-	if !RecordsSourceIsReadOnly() && paths.RecordsSource != nil {
-		CreateFileIfNotExists(paths.RecordsSource.Location, `{"records": [{"name": "example.com.", "type": "A", "value": "93.184.216.34", "ttl": 3600, "last_query": "0001-01-01T00:00:00Z"}]}`)
+	if rs := paths.WritableSource(); rs != nil {
+		CreateFileIfNotExists(rs.Location, `{"records": [{"name": "example.com.", "type": "A", "value": "93.184.216.34", "ttl": 3600, "last_query": "0001-01-01T00:00:00Z"}]}`)
 	}
 	CreateFileIfNotExists(paths.CacheFile, `{"cache": [{"dns_record": {"name": "example.com","type": "A","value": "192.168.1.1","ttl": 3600,"added_on": "2024-05-01T12:00:00Z","updated_on": "2024-05-05T18:30:00Z","mac": "00:1A:2B:3C:4D:5E","last_query": "2024-05-07T15:45:00Z"},"expiry": "2024-05-10T12:00:00Z","timestamp": "2024-05-07T12:30:00Z","last_query": "2024-05-07T14:00:00Z"}]}`)
 }
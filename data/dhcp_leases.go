@@ -0,0 +1,53 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"dnsplane/dhcplease"
+	"dnsplane/journal"
+)
+
+// SyncDHCPLeases reads the configured lease files and brings the records registered from DHCP in line
+// with the leases active at now. A lease file that cannot be read leaves every record as it is, so a
+// server rewriting its file does not drop registrations. Changes are saved, journaled, and pushed to
+// cluster peers like any local write (read-only records sources keep them in memory only); nodes with
+// cluster_reject_local_writes leave registration to the node that accepts writes.
+func (d *DNSResolverData) SyncDHCPLeases(now time.Time) (dhcplease.Result, error) {
+	settings := d.GetResolverSettings()
+	cfg := settings.DHCP
+	if !cfg.Enabled() || settings.ClusterRejectLocalWrites {
+		return dhcplease.Result{}, nil
+	}
+	if !dhcplease.ValidPolicy(cfg.ConflictPolicy) {
+		return dhcplease.Result{}, fmt.Errorf("dhcp: unknown conflict_policy %q (want first-wins, suffix-mac, or reject)", cfg.ConflictPolicy)
+	}
+	var leases []dhcplease.Lease
+	for _, f := range cfg.Leases {
+		l, err := dhcplease.ParseFile(f.Format, f.Path)
+		if err != nil {
+			return dhcplease.Result{}, fmt.Errorf("dhcp: %w", err)
+		}
+		leases = append(leases, l...)
+	}
+	res := dhcplease.Reconcile(d.GetRecords(), leases, dhcplease.Options{
+		Domain: cfg.Domain,
+		Policy: cfg.ConflictPolicy,
+		PTR:    !cfg.NoPTR,
+		MaxTTL: cfg.MaxTTL,
+	}, now)
+	if !res.Changed() {
+		return res, nil
+	}
+	o := journal.Origin{Source: journal.SourceDHCP}
+	if RecordsSourceIsReadOnly() {
+		d.storeRecords(res.Records, false, o)
+		return res, nil
+	}
+	if err := d.UpdateRecords(o, res.Records); err != nil {
+		return dhcplease.Result{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"time"
+
+	"dnsplane/config"
+	"dnsplane/dnscookie"
+)
+
+// DNSCookies returns the server cookie issuer/verifier, or nil when dns_cookies_enabled is false.
+// The secret is shared with cluster peers (see cluster cookie_secret messages).
+func (d *DNSResolverData) DNSCookies() *dnscookie.Server {
+	return d.dnsCookies.Load()
+}
+
+// configureDNSCookies applies dns_cookies_* settings. The secret is created once and reused when
+// cookies are toggled so issued cookies stay valid.
+func (d *DNSResolverData) configureDNSCookies(cfg config.Config) {
+	if !cfg.DNSCookiesEnabled {
+		d.dnsCookies.Store(nil)
+		return
+	}
+	interval := time.Duration(cfg.DNSCookieSecretRotationSeconds) * time.Second
+	d.cookieSecretOnce.Do(func() {
+		d.dnsCookieSecret = dnscookie.NewServer(interval)
+	})
+	d.dnsCookieSecret.SetRotationInterval(interval)
+	d.dnsCookies.Store(d.dnsCookieSecret)
+}
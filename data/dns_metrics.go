@@ -17,6 +17,14 @@ var (
 	limiterDropQueryRate  atomic.Uint64
 	limiterDropSliding    atomic.Uint64
 	limiterDropRRL        atomic.Uint64
+	aclDeniedRefused      atomic.Uint64
+	aclDeniedDropped      atomic.Uint64
+	cookieValid           atomic.Uint64
+	cookieIssued          atomic.Uint64
+	cookieMissing         atomic.Uint64
+	cookieBadCookie       atomic.Uint64
+	cookieTruncated       atomic.Uint64
+	cookieMalformed       atomic.Uint64
 )
 
 // RecordDNSSECOutcome increments counters for dnssecvalidate outcomes.
@@ -49,6 +57,43 @@ func RecordLimiterDrop(reason string) {
 	}
 }
 
+// LimiterDrops returns the queries refused by each limiter since start, by reason.
+func LimiterDrops() map[string]uint64 {
+	return map[string]uint64{
+		"query_rate":       limiterDropQueryRate.Load(),
+		"response_sliding": limiterDropSliding.Load(),
+		"response_rrl":     limiterDropRRL.Load(),
+	}
+}
+
+// RecordACLDenied counts a query rejected by the client ACL (dropped or answered REFUSED).
+func RecordACLDenied(dropped bool) {
+	if dropped {
+		aclDeniedDropped.Add(1)
+	} else {
+		aclDeniedRefused.Add(1)
+	}
+}
+
+// RecordDNSCookie counts inbound DNS cookie handling: valid, issued (client cookie only or stale server
+// cookie), missing, badcookie and truncated (unverified over dns_cookie_unverified_rps), malformed.
+func RecordDNSCookie(outcome string) {
+	switch outcome {
+	case "valid":
+		cookieValid.Add(1)
+	case "issued":
+		cookieIssued.Add(1)
+	case "missing":
+		cookieMissing.Add(1)
+	case "badcookie":
+		cookieBadCookie.Add(1)
+	case "truncated":
+		cookieTruncated.Add(1)
+	case "malformed":
+		cookieMalformed.Add(1)
+	}
+}
+
 // WriteDNSAbusePrometheus writes counters for DNSSEC and limiter drops.
 func WriteDNSAbusePrometheus(w io.Writer) {
 	_, _ = io.WriteString(w, "# HELP dnsplane_dnssec_outcomes_total DNSSEC validation outcomes\n")
@@ -67,4 +112,21 @@ func WriteDNSAbusePrometheus(w io.Writer) {
 	_, _ = io.WriteString(w, `dnsplane_dns_limiter_drops_total{reason="query_rate"} `+strconv.FormatUint(limiterDropQueryRate.Load(), 10)+"\n")
 	_, _ = io.WriteString(w, `dnsplane_dns_limiter_drops_total{reason="response_sliding"} `+strconv.FormatUint(limiterDropSliding.Load(), 10)+"\n")
 	_, _ = io.WriteString(w, `dnsplane_dns_limiter_drops_total{reason="response_rrl"} `+strconv.FormatUint(limiterDropRRL.Load(), 10)+"\n")
+
+	_, _ = io.WriteString(w, "# HELP dnsplane_dns_acl_denied_total Queries rejected by client_acl\n")
+	_, _ = io.WriteString(w, "# TYPE dnsplane_dns_acl_denied_total counter\n")
+	_, _ = io.WriteString(w, `dnsplane_dns_acl_denied_total{action="refuse"} `+strconv.FormatUint(aclDeniedRefused.Load(), 10)+"\n")
+	_, _ = io.WriteString(w, `dnsplane_dns_acl_denied_total{action="drop"} `+strconv.FormatUint(aclDeniedDropped.Load(), 10)+"\n")
+
+	_, _ = io.WriteString(w, "# HELP dnsplane_dns_cookies_total Inbound DNS cookie outcomes\n")
+	_, _ = io.WriteString(w, "# TYPE dnsplane_dns_cookies_total counter\n")
+	writeCookie := func(label string, v uint64) {
+		_, _ = io.WriteString(w, `dnsplane_dns_cookies_total{outcome="`+label+`"} `+strconv.FormatUint(v, 10)+"\n")
+	}
+	writeCookie("valid", cookieValid.Load())
+	writeCookie("issued", cookieIssued.Load())
+	writeCookie("missing", cookieMissing.Load())
+	writeCookie("badcookie", cookieBadCookie.Load())
+	writeCookie("truncated", cookieTruncated.Load())
+	writeCookie("malformed", cookieMalformed.Load())
 }
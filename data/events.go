@@ -0,0 +1,88 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"slices"
+	"time"
+
+	"dnsplane/dnsrecords"
+	"dnsplane/events"
+	"dnsplane/journal"
+)
+
+// recordsChangedNames caps the names listed in a records.changed event; a zone reload can touch thousands.
+const recordsChangedNames = 20
+
+// publishRecordsChanged publishes e as a records.changed event when it changed anything. total is the size of
+// the new record set.
+func publishRecordsChanged(e journal.Entry, total int) {
+	if len(e.Changes) == 0 {
+		return
+	}
+	counts := map[string]int{}
+	var names []string
+	for _, c := range e.Changes {
+		counts[c.Kind()]++
+		r := c.After
+		if r == nil {
+			r = c.Before
+		}
+		if n := dnsrecords.CanonicalizeRecordNameForStorage(r.Name); !slices.Contains(names, n) && len(names) < recordsChangedNames {
+			names = append(names, n)
+		}
+	}
+	slices.Sort(names)
+	data := map[string]any{
+		"source":  e.Source,
+		"added":   counts["added"],
+		"updated": counts["updated"],
+		"removed": counts["removed"],
+		"names":   names,
+		"records": total,
+	}
+	if e.Actor != "" {
+		data["actor"] = e.Actor
+	}
+	if e.Rev > 0 {
+		data["history_rev"] = e.Rev
+	}
+	events.Publish(events.RecordsChanged, data)
+}
+
+// LimiterSpikeWatch raises limiter.drop_spike when the DNS rate limiters refuse at least a threshold of queries
+// between two checks. One event per spike: it is raised again only after a check below the threshold.
+type LimiterSpikeWatch struct {
+	last    map[string]uint64
+	spiking bool
+}
+
+// Check compares the drop counters with the last check, window ago, and publishes when a spike starts.
+func (w *LimiterSpikeWatch) Check(threshold int, window time.Duration) {
+	now := LimiterDrops()
+	last := w.last
+	w.last = now
+	if last == nil || threshold <= 0 {
+		return
+	}
+	var total uint64
+	data := map[string]any{}
+	for reason, n := range now {
+		d := n - last[reason]
+		total += d
+		data[reason] = d
+	}
+	if total < uint64(threshold) {
+		w.spiking = false
+		return
+	}
+	if w.spiking {
+		return
+	}
+	w.spiking = true
+	data["drops"] = total
+	data["threshold"] = threshold
+	data["window_seconds"] = int(window.Seconds())
+	events.Publish(events.LimiterDropSpike, data)
+}
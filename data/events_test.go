@@ -0,0 +1,114 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/events"
+	"dnsplane/journal"
+)
+
+func drain(sub *events.Subscription) []events.Event {
+	var out []events.Event
+	for len(sub.C()) > 0 {
+		out = append(out, <-sub.C())
+	}
+	return out
+}
+
+func TestRecordsChangedEvent(t *testing.T) {
+	dir := t.TempDir()
+	SetConfig(&config.Loaded{
+		Path: filepath.Join(dir, "dnsplane.json"),
+		Config: config.Config{FileLocations: config.FileLocations{
+			RecordsSource: &config.RecordsSourceConfig{Type: config.RecordsSourceFile, Location: filepath.Join(dir, "dnsrecords.json")},
+		}},
+	})
+	defer func() {
+		configStateMu.Lock()
+		configState = nil
+		configStateMu.Unlock()
+	}()
+	_, sub := events.Subscribe(0, []string{events.RecordsChanged})
+	defer sub.Close()
+
+	d := &DNSResolverData{}
+	v1 := []dnsrecords.DNSRecord{
+		{ID: "a", Name: "www.example.com", Type: "A", Value: "192.0.2.1", TTL: 300},
+		{ID: "b", Name: "mail.example.com", Type: "A", Value: "192.0.2.2", TTL: 300},
+	}
+	v2 := []dnsrecords.DNSRecord{
+		{ID: "a", Name: "www.example.com", Type: "A", Value: "192.0.2.10", TTL: 300},
+		{ID: "c", Name: "ftp.example.com", Type: "CNAME", Value: "www.example.com.", TTL: 300},
+	}
+	api := journal.Origin{Source: journal.SourceAPI, Actor: "deploy"}
+	if err := d.UpdateRecordsInMemory(api, v1); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.UpdateRecordsInMemory(api, v2); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.UpdateRecordsInMemory(api, v2); err != nil {
+		t.Fatal(err)
+	}
+
+	got := drain(sub)
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2 (none for an unchanged set)", len(got))
+	}
+	e := got[1].Data
+	if e["source"] != "api" || e["actor"] != "deploy" || e["added"] != 1 || e["updated"] != 1 || e["removed"] != 1 || e["records"] != 2 {
+		t.Errorf("event data = %v", e)
+	}
+	if names := e["names"].([]string); !slices.Equal(names, []string{"ftp.example.com", "mail.example.com", "www.example.com"}) {
+		t.Errorf("names = %v", names)
+	}
+}
+
+func TestUpstreamHealthEvents(t *testing.T) {
+	_, sub := events.Subscribe(0, []string{"upstream.*"})
+	defer sub.Close()
+	d := &DNSResolverData{Settings: config.Config{UpstreamHealthCheckEnabled: true, UpstreamHealthCheckFailures: 2}}
+	d.upstreamHealth = NewUpstreamHealthTracker()
+	for _, ok := range []bool{false, false, false, true, true} {
+		d.ApplyUpstreamProbeResult("192.0.2.53:53", ok, "timeout", nil)
+	}
+	got := drain(sub)
+	if len(got) != 2 || got[0].Type != events.UpstreamUnhealthy || got[1].Type != events.UpstreamRecovered || got[1].Data["server"] != "192.0.2.53:53" {
+		t.Fatalf("events = %+v", got)
+	}
+}
+
+func TestLimiterSpikeWatch(t *testing.T) {
+	_, sub := events.Subscribe(0, []string{events.LimiterDropSpike})
+	defer sub.Close()
+	var w LimiterSpikeWatch
+	w.Check(5, 10*time.Second) // baseline
+	drops := func(n int) {
+		for range n {
+			RecordLimiterDrop("query_rate")
+		}
+	}
+	drops(6)
+	w.Check(5, 10*time.Second) // spike starts
+	drops(9)
+	w.Check(5, 10*time.Second) // same spike
+	drops(1)
+	w.Check(5, 10*time.Second) // over
+	drops(5)
+	w.Check(5, 10*time.Second) // a new one
+
+	got := drain(sub)
+	if len(got) != 2 {
+		t.Fatalf("got %d spike events, want 2", len(got))
+	}
+	if e := got[0].Data; e["drops"] != uint64(6) || e["query_rate"] != uint64(6) || e["window_seconds"] != 10 {
+		t.Errorf("event data = %v", e)
+	}
+}
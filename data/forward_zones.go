@@ -0,0 +1,28 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"dnsplane/config"
+	"dnsplane/forwardzone"
+)
+
+// ForwardZones returns the compiled conditional forwarding table, or nil when none is configured.
+func (d *DNSResolverData) ForwardZones() *forwardzone.Table {
+	return d.forwardZones.Load()
+}
+
+// compileForwardZones builds the forwarding table for a settings change. ok is false for an invalid
+// table, which keeps the previous one.
+func compileForwardZones(entries []config.ForwardZone) (t *forwardzone.Table, ok bool) {
+	t, err := forwardzone.Compile(entries)
+	if err != nil {
+		resolverSlog().Warn("forward_zones: invalid configuration, keeping previous table", "error", err)
+		return nil, false
+	}
+	if t.Len() == 0 {
+		t = nil
+	}
+	return t, true
+}
@@ -0,0 +1,149 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/geo"
+)
+
+// GeoLocator returns the compiled client locator, or nil when no regions or databases are configured.
+func (d *DNSResolverData) GeoLocator() *geo.Locator {
+	return d.geoLocator.Load()
+}
+
+// compileGeo builds the locator for a settings change, opening its databases. ok is false for an invalid
+// configuration, which keeps the previous locator.
+func (d *DNSResolverData) compileGeo(cfg config.GeoConfig) (l *geo.Locator, ok bool) {
+	l, err := geo.Compile(cfg, d.geoLocator.Load())
+	if err != nil {
+		resolverSlog().Warn("geo: invalid configuration, keeping previous locator", "error", err)
+		return nil, false
+	}
+	if !l.Enabled() {
+		l = nil
+	}
+	return l, true
+}
+
+func anyGeoRecords(records []dnsrecords.DNSRecord) bool {
+	for i := range records {
+		if records[i].Geo != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// geoMatch ranks how specifically sel matches loc (0 = no match) and names the matching criterion.
+func geoMatch(sel *dnsrecords.GeoSelector, loc geo.Location) (rank int, label string) {
+	if loc.Region != "" {
+		for _, r := range sel.Regions {
+			if strings.EqualFold(strings.TrimSpace(r), loc.Region) {
+				return 4, "region " + loc.Region
+			}
+		}
+	}
+	if loc.ASN != 0 {
+		for _, a := range sel.ASNs {
+			if a == loc.ASN {
+				return 3, "AS" + strconv.FormatUint(uint64(a), 10)
+			}
+		}
+	}
+	if loc.Country != "" {
+		for _, c := range sel.Countries {
+			if strings.EqualFold(strings.TrimSpace(c), loc.Country) {
+				return 2, "country " + loc.Country
+			}
+		}
+	}
+	if loc.Continent != "" {
+		for _, c := range sel.Continents {
+			if strings.EqualFold(strings.TrimSpace(c), loc.Continent) {
+				return 1, "continent " + loc.Continent
+			}
+		}
+	}
+	return 0, ""
+}
+
+// geoDefaults returns the values of a name/type without a geo selector, or all of them when every value
+// has one.
+func geoDefaults(recs []dnsrecords.DNSRecord, idxs []int) []int {
+	var out []int
+	for _, i := range idxs {
+		if recs[i].Geo == nil {
+			out = append(out, i)
+		}
+	}
+	if len(out) == 0 {
+		return idxs
+	}
+	return out
+}
+
+// selectGeo returns the values of idxs that best match loc, falling back to geoDefaults. label describes
+// the choice for logs and the dashboard ("region eu", "country DE", "default").
+func selectGeo(recs []dnsrecords.DNSRecord, idxs []int, loc geo.Location) (out []int, label string) {
+	best := 0
+	for _, i := range idxs {
+		sel := recs[i].Geo
+		if sel == nil {
+			continue
+		}
+		rank, l := geoMatch(sel, loc)
+		switch {
+		case rank == 0 || rank < best:
+		case rank > best:
+			best, label, out = rank, l, []int{i}
+		default:
+			out = append(out, i)
+		}
+	}
+	if best > 0 {
+		return out, label
+	}
+	return geoDefaults(recs, idxs), "default"
+}
+
+// LookupGeo answers name/type for a client when that name/type has geo-selected values. ok is false when
+// it has none, so the caller answers as usual. rrs is empty when health checks withheld every chosen value.
+// clientIP is the client address or the ECS subnet address.
+func (d *DNSResolverData) LookupGeo(name, recordType, clientIP string) (rrs []dns.RR, label string, ok bool) {
+	if !d.hasGeoRecords.Load() {
+		return nil, "", false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.Settings.LocalRecordsEnabled {
+		return nil, "", false
+	}
+	k := dnsCacheIdxKey(name, recordType)
+	idxs := d.dnsRecordIdx[k]
+	geoSet := false
+	for _, i := range idxs {
+		if i >= 0 && i < len(d.DNSRecords) && d.DNSRecords[i].Geo != nil {
+			geoSet = true
+			break
+		}
+	}
+	if !geoSet {
+		return nil, "", false
+	}
+	var loc geo.Location
+	if ip, err := netip.ParseAddr(strings.TrimSpace(clientIP)); err == nil {
+		loc = d.geoLocator.Load().Locate(ip)
+	}
+	chosen, label := selectGeo(d.DNSRecords, idxs, loc)
+	chosen = d.selectLocalRecordsLocked(k+"\x00"+label, chosen)
+	return d.localRRsLocked(chosen), label, true
+}
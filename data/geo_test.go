@@ -0,0 +1,48 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+)
+
+func TestLookupGeoSelectsByLocation(t *testing.T) {
+	d := newRecordHealthData([]dnsrecords.DNSRecord{
+		{Name: "www.corp", Type: "A", Value: "10.9.9.9", TTL: 60},
+		{Name: "www.corp", Type: "A", Value: "10.1.0.5", TTL: 60, Geo: &dnsrecords.GeoSelector{Regions: []string{"eu"}}},
+		{Name: "www.corp", Type: "A", Value: "10.1.2.5", TTL: 60, Geo: &dnsrecords.GeoSelector{Regions: []string{"office"}}},
+		{Name: "plain.corp", Type: "A", Value: "10.0.0.1", TTL: 60},
+	}, nil)
+	l, ok := d.compileGeo(config.GeoConfig{Regions: map[string][]string{"eu": {"10.1.0.0/16"}, "office": {"10.1.2.0/24"}}})
+	if !ok {
+		t.Fatal("compileGeo rejected the regions")
+	}
+	d.geoLocator.Store(l)
+
+	tests := []struct {
+		client, want, label string
+	}{
+		{"10.1.2.3", "10.1.2.5", "region office"},
+		{"10.1.7.7", "10.1.0.5", "region eu"},
+		{"192.0.2.1", "10.9.9.9", "default"},
+		{"", "10.9.9.9", "default"},
+	}
+	for _, tt := range tests {
+		rrs, label, ok := d.LookupGeo("www.corp.", "A", tt.client)
+		if !ok || len(rrs) != 1 || rrs[0].(*dns.A).A.String() != tt.want || label != tt.label {
+			t.Errorf("client %q: ok=%v rrs=%v label=%q, want %s (%s)", tt.client, ok, rrs, label, tt.want, tt.label)
+		}
+	}
+	if _, _, ok := d.LookupGeo("plain.corp.", "A", "10.1.2.3"); ok {
+		t.Fatal("name without selectors handled as geo")
+	}
+	if got := localValues(t, d, "www.corp."); len(got) != 1 || got[0] != "10.9.9.9" {
+		t.Fatalf("location-free lookup = %v, want the default value", got)
+	}
+}
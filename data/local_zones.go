@@ -0,0 +1,36 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/localzone"
+)
+
+// LocalZones returns the built-in empty zones after the local_zones toggles, or nil when none remain.
+// HasData on the set reports the zones that local records disable.
+func (d *DNSResolverData) LocalZones() *localzone.Set {
+	return d.localZones.Load()
+}
+
+// compileLocalZones builds the zone list for a settings change; refreshLocalZonesLocked then adds the
+// zones local records disable.
+func compileLocalZones(cfg config.LocalZonesConfig) *localzone.Set {
+	s := localzone.Compile(cfg)
+	if s.Len() == 0 {
+		s = nil
+	}
+	return s
+}
+
+// refreshLocalZonesLocked works out which empty zones hold local records, so the resolver need not scan
+// the records per query. Requires d.mu held for writing; runs whenever the records or settings change.
+func (d *DNSResolverData) refreshLocalZonesLocked() {
+	var records []dnsrecords.DNSRecord
+	if d.Settings.LocalRecordsEnabled {
+		records = d.DNSRecords
+	}
+	d.localZones.Store(d.localZonesCompiled.Load().WithLocalData(records, d.Settings.DNSRecordSettings.AutoBuildPTRFromA))
+}
@@ -0,0 +1,41 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"testing"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+)
+
+func TestLocalZonesFollowRecordsAndSettings(t *testing.T) {
+	d := &DNSResolverData{}
+	settings := DNSResolverSettings{LocalRecordsEnabled: true}
+	d.UpdateSettingsInMemory(settings)
+	if d.LocalZones().HasData("home.arpa") {
+		t.Fatal("home.arpa disabled with no records")
+	}
+
+	d.mu.Lock()
+	d.DNSRecords = []dnsrecords.DNSRecord{{Name: "nas.home.arpa", Type: "A", Value: "192.168.1.10", TTL: 60}}
+	d.rebuildDNSRecordIndexLocked()
+	d.mu.Unlock()
+	if zones := d.LocalZones(); !zones.HasData("home.arpa") || zones.HasData("168.192.in-addr.arpa") {
+		t.Fatal("records not reflected in the empty zones")
+	}
+
+	settings.DNSRecordSettings.AutoBuildPTRFromA = true
+	d.UpdateSettingsInMemory(settings)
+	if !d.LocalZones().HasData("168.192.in-addr.arpa") {
+		t.Fatal("auto PTR from A not reflected")
+	}
+
+	settings.LocalRecordsEnabled = false
+	settings.LocalZones = config.LocalZonesConfig{Exclude: []string{"10.in-addr.arpa"}}
+	d.UpdateSettingsInMemory(settings)
+	if zones := d.LocalZones(); zones.HasData("home.arpa") || zones.Match("1.2.3.10.in-addr.arpa.") != "" {
+		t.Fatalf("settings change not reflected: %+v", zones)
+	}
+}
@@ -102,6 +102,17 @@ func (d *DNSResolverData) rebuildCacheIndexLocked() {
 
 func (d *DNSResolverData) rebuildDNSRecordIndexLocked() {
 	d.dnsRecordIdx = buildDNSRecordIndex(d.DNSRecords)
+	d.recordsIndexedLocked()
+}
+
+// recordsIndexedLocked refreshes what derives from d.DNSRecords once d.dnsRecordIdx matches them.
+// Requires d.mu held.
+func (d *DNSResolverData) recordsIndexedLocked() {
+	d.recordsGeneration.Add(1)
+	d.hasGeoRecords.Store(anyGeoRecords(d.DNSRecords))
+	d.clientMACs = buildClientMACs(d.DNSRecords)
+	d.refreshLocalZonesLocked()
+	d.recordRotation.prune(d.dnsRecordIdx, d.DNSRecords)
 }
 
 // lookupCacheRRLocked requires d.mu RLock held.
@@ -234,16 +245,37 @@ func (d *DNSResolverData) LookupCacheRR(qname, recordType string) *dns.RR {
 	return rr
 }
 
-// lookupLocalNonPTRLocked requires d.mu RLock held; not for PTR qtype.
-func (d *DNSResolverData) lookupLocalNonPTRLocked(qname, recordType string) []dns.RR {
+// LookupCacheRRSet returns the full cached upstream answer (CNAME chain + final records) for name+type, if
+// one is cached and fresh.
+func (d *DNSResolverData) LookupCacheRRSet(qname, recordType string) []dns.RR {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rrs, _ := d.lookupRRSetCacheLocked(qname, recordType, time.Now(), false)
+	return rrs
+}
+
+// lookupLocalNonPTRLocked requires d.mu RLock held; not for PTR qtype. withheld is true when the name/type
+// has local values but health checks took all of them out of the answer.
+func (d *DNSResolverData) lookupLocalNonPTRLocked(qname, recordType string) (out []dns.RR, withheld bool) {
 	if len(d.DNSRecords) == 0 {
-		return nil
+		return nil, false
 	}
 	k := dnsCacheIdxKey(qname, recordType)
 	idxs := d.dnsRecordIdx[k]
 	if len(idxs) == 0 {
-		return nil
+		return nil, false
+	}
+	if d.hasGeoRecords.Load() {
+		idxs = geoDefaults(d.DNSRecords, idxs)
+	}
+	if idxs = d.selectLocalRecordsLocked(k, idxs); len(idxs) == 0 {
+		return nil, true
 	}
+	return d.localRRsLocked(idxs), false
+}
+
+// localRRsLocked converts the local records at idxs to RRs. Requires d.mu RLock held.
+func (d *DNSResolverData) localRRsLocked(idxs []int) []dns.RR {
 	var out []dns.RR
 	for _, i := range idxs {
 		if i < 0 || i >= len(d.DNSRecords) {
@@ -270,10 +302,15 @@ func (d *DNSResolverData) TryFastLocalOrCache(qname, recordType string, qtypePTR
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 	if d.Settings.LocalRecordsEnabled && len(d.DNSRecords) > 0 {
-		local = d.lookupLocalNonPTRLocked(qname, recordType)
+		var withheld bool
+		local, withheld = d.lookupLocalNonPTRLocked(qname, recordType)
 		if len(local) > 0 {
 			return true, local, nil, nil, false
 		}
+		// A cached copy of a withheld value must not bring it back.
+		if withheld {
+			return false, nil, nil, nil, false
+		}
 	}
 	if !d.Settings.CacheRecords || len(d.CacheRecords) == 0 {
 		return false, nil, nil, nil, false
@@ -308,7 +345,26 @@ func (d *DNSResolverData) LookupLocalRRs(qname, recordType string, autoBuildPTRF
 	if rt == "PTR" {
 		return dnsrecords.FindAllRecords(d.DNSRecords, qname, recordType, autoBuildPTRFromA)
 	}
-	return d.lookupLocalNonPTRLocked(qname, recordType)
+	rrs, _ := d.lookupLocalNonPTRLocked(qname, recordType)
+	return rrs
+}
+
+// LookupALIAS returns the target and TTL of the ALIAS/ANAME record at name, if there is one.
+func (d *DNSResolverData) LookupALIAS(name string) (target string, ttl uint32, ok bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.Settings.LocalRecordsEnabled || len(d.DNSRecords) == 0 {
+		return "", 0, false
+	}
+	for _, rt := range []string{"ALIAS", "ANAME"} {
+		for _, i := range d.dnsRecordIdx[dnsCacheIdxKey(name, rt)] {
+			if i >= 0 && i < len(d.DNSRecords) {
+				rec := d.DNSRecords[i]
+				return dns.Fqdn(strings.TrimSpace(rec.Value)), rec.TTL, true
+			}
+		}
+	}
+	return "", 0, false
 }
 
 func dnsRecordToRRForLookup(dr *dnsrecords.DNSRecord, ttl uint32, errLog func(msg string, kv ...any)) *dns.RR {
@@ -0,0 +1,71 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"net"
+	"strings"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/policy"
+)
+
+// PolicyEngine returns the compiled per-client policy groups, or nil when none are configured.
+func (d *DNSResolverData) PolicyEngine() *policy.Engine {
+	return d.policyEngine.Load()
+}
+
+// compilePolicy builds the policy engine for a settings change, reading its category files. ok is false
+// for an invalid policy, which keeps the previous engine.
+func compilePolicy(cfg config.PolicyConfig) (e *policy.Engine, ok bool) {
+	e, err := policy.Compile(cfg)
+	if err != nil {
+		resolverSlog().Warn("policy: invalid configuration, keeping previous policy", "error", err)
+		return nil, false
+	}
+	if !e.Enabled() {
+		e = nil
+	}
+	return e, true
+}
+
+// ClientMAC returns the MAC address for a client IP: first from local A/AAAA records carrying a
+// "mac" field, then from the kernel neighbour table. Empty when unknown.
+func (d *DNSResolverData) ClientMAC(ip string) string {
+	ip = strings.TrimSpace(ip)
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+	d.mu.RLock()
+	mac := d.clientMACs[addr.String()]
+	d.mu.RUnlock()
+	if mac != "" {
+		return mac
+	}
+	return policy.NeighborMAC(ip)
+}
+
+// buildClientMACs maps the address of each A/AAAA record with a "mac" field to that MAC; the first record
+// for an address wins.
+func buildClientMACs(records []dnsrecords.DNSRecord) map[string]string {
+	var out map[string]string
+	for _, r := range records {
+		if r.MACAddress == "" || (r.Type != "A" && r.Type != "AAAA") {
+			continue
+		}
+		v := net.ParseIP(strings.TrimSpace(r.Value))
+		if v == nil {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		if _, ok := out[v.String()]; !ok {
+			out[v.String()] = r.MACAddress
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"testing"
+
+	"dnsplane/dnsrecords"
+)
+
+func TestClientMACFromRecords(t *testing.T) {
+	d := newRecordHealthData([]dnsrecords.DNSRecord{
+		{Name: "printer.lan", Type: "A", Value: "192.0.2.5", TTL: 60, MACAddress: "aa:bb:cc:00:00:05"},
+		{Name: "printer2.lan", Type: "A", Value: "192.0.2.5", TTL: 60, MACAddress: "aa:bb:cc:00:00:99"},
+		{Name: "nas.lan", Type: "AAAA", Value: "2001:db8::5", TTL: 60, MACAddress: "aa:bb:cc:00:00:06"},
+		{Name: "web.lan", Type: "A", Value: "192.0.2.7", TTL: 60},
+	}, nil)
+	for ip, want := range map[string]string{
+		"192.0.2.5":        "aa:bb:cc:00:00:05",
+		"::ffff:192.0.2.5": "aa:bb:cc:00:00:05",
+		"2001:0db8:0::5":   "aa:bb:cc:00:00:06",
+		" 2001:db8::5 ":    "aa:bb:cc:00:00:06",
+		"192.0.2.7":        "",
+		"not-an-address":   "",
+	} {
+		if got := d.ClientMAC(ip); got != want {
+			t.Errorf("ClientMAC(%q) = %q, want %q", ip, got, want)
+		}
+	}
+
+	// The map follows the records when the index is rebuilt.
+	d.mu.Lock()
+	d.DNSRecords = d.DNSRecords[2:]
+	d.rebuildDNSRecordIndexLocked()
+	d.mu.Unlock()
+	if got := d.ClientMAC("192.0.2.5"); got != "" {
+		t.Errorf("ClientMAC after removing the record = %q", got)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import (
+	"time"
+
+	"dnsplane/dnsrecords"
+	"dnsplane/journal"
+)
+
+// ExpireRecords removes the records whose expires_at is at or before now and returns them. The removal
+// is saved, journaled, and pushed to cluster peers like any local write. With a read-only records source
+// the records are only dropped from memory (a reload brings them back until they expire again); nodes
+// with cluster_reject_local_writes leave expiry to the node that accepts writes.
+func (d *DNSResolverData) ExpireRecords(now time.Time) ([]dnsrecords.DNSRecord, error) {
+	d.mu.RLock()
+	reject := d.Settings.ClusterRejectLocalWrites
+	d.mu.RUnlock()
+	if reject {
+		return nil, nil
+	}
+	records := d.GetRecords()
+	kept := make([]dnsrecords.DNSRecord, 0, len(records))
+	var expired []dnsrecords.DNSRecord
+	for _, r := range records {
+		if r.Expired(now) {
+			expired = append(expired, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+	o := journal.Origin{Source: journal.SourceExpiry}
+	if RecordsSourceIsReadOnly() {
+		d.storeRecords(kept, false, o)
+		return expired, nil
+	}
+	if err := d.UpdateRecords(o, kept); err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/journal"
+)
+
+func TestExpireRecords(t *testing.T) {
+	dir := t.TempDir()
+	SetConfig(&config.Loaded{
+		Path: filepath.Join(dir, "dnsplane.json"),
+		Config: config.Config{FileLocations: config.FileLocations{
+			RecordsSource: &config.RecordsSourceConfig{Type: config.RecordsSourceFile, Location: filepath.Join(dir, "dnsrecords.json")},
+		}},
+	})
+	defer func() {
+		configStateMu.Lock()
+		configState = nil
+		configStateMu.Unlock()
+	}()
+	j, err := journal.Open(filepath.Join(dir, "history"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &DNSResolverData{}
+	d.SetRecordJournal(j)
+	defer d.Close()
+
+	now := time.Now()
+	if err := d.UpdateRecords(journal.Origin{Source: journal.SourceAPI}, []dnsrecords.DNSRecord{
+		{ID: "a", Name: "old.example.com", Type: "A", Value: "192.0.2.7", TTL: 300, ExpiresAt: now.Add(time.Minute)},
+		{ID: "b", Name: "www.example.com", Type: "A", Value: "192.0.2.8", TTL: 300},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	notified := 0
+	SetClusterRecordsNotify(func() { notified++ })
+	defer SetClusterRecordsNotify(nil)
+
+	if expired, err := d.ExpireRecords(now); err != nil || len(expired) != 0 {
+		t.Fatalf("before expiry: %+v %v", expired, err)
+	}
+	expired, err := d.ExpireRecords(now.Add(time.Minute))
+	if err != nil || len(expired) != 1 || expired[0].ID != "a" {
+		t.Fatalf("expire: %+v %v", expired, err)
+	}
+	if got := d.GetRecords(); len(got) != 1 || got[0].ID != "b" {
+		t.Fatalf("records after expiry: %+v", got)
+	}
+	if notified != 1 {
+		t.Fatalf("cluster notified %d times, want 1", notified)
+	}
+	if entries, _ := j.Entries(journal.Filter{Limit: 1}); len(entries) != 1 || entries[0].Source != journal.SourceExpiry {
+		t.Fatalf("expiry entry: %+v", entries)
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dnsplane/dnsrecords"
+)
+
+// RecordHealthTracker tracks probe outcomes for health checks attached to local records, keyed by
+// HealthCheck.Key. Values start healthy until FailThreshold probes in a row fail.
+type RecordHealthTracker struct {
+	mu sync.RWMutex
+	by map[string]*recordHealthEntry
+}
+
+type recordHealthEntry struct {
+	unhealthy bool
+	failures  int
+	successes int
+	lastProbe time.Time
+	lastErr   string
+	lastOK    time.Time
+}
+
+// NewRecordHealthTracker creates an empty tracker.
+func NewRecordHealthTracker() *RecordHealthTracker {
+	return &RecordHealthTracker{by: make(map[string]*recordHealthEntry)}
+}
+
+func (t *RecordHealthTracker) ensure(key string) *recordHealthEntry {
+	if t.by[key] == nil {
+		t.by[key] = &recordHealthEntry{}
+	}
+	return t.by[key]
+}
+
+// ProbeOK records a successful probe. Returns true if the value transitioned back to healthy.
+func (t *RecordHealthTracker) ProbeOK(key string, rise int) (nowHealthy bool) {
+	if t == nil || key == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.ensure(key)
+	e.failures = 0
+	e.successes++
+	e.lastProbe = time.Now()
+	e.lastErr = ""
+	e.lastOK = e.lastProbe
+	if e.unhealthy && e.successes >= rise {
+		e.unhealthy = false
+		return true
+	}
+	return false
+}
+
+// ProbeFail records a failed probe. Returns true if the value transitioned to unhealthy.
+func (t *RecordHealthTracker) ProbeFail(key, errStr string, threshold int) (nowUnhealthy bool) {
+	if t == nil || key == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.ensure(key)
+	was := e.unhealthy
+	e.successes = 0
+	e.failures++
+	e.lastProbe = time.Now()
+	e.lastErr = errStr
+	if e.failures >= threshold {
+		e.unhealthy = true
+	}
+	return e.unhealthy && !was
+}
+
+// IsUnhealthy reports whether the probe key is currently marked down.
+func (t *RecordHealthTracker) IsUnhealthy(key string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e := t.by[key]
+	return e != nil && e.unhealthy
+}
+
+// RecordHealthStatus is JSON for API responses and the TUI.
+type RecordHealthStatus struct {
+	Check               string `json:"check"`
+	Name                string `json:"name"`
+	Type                string `json:"type"`
+	Value               string `json:"value"`
+	Unhealthy           bool   `json:"unhealthy"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastProbeAt         string `json:"last_probe_at,omitempty"`
+	LastProbeError      string `json:"last_probe_error,omitempty"`
+	LastSuccessAt       string `json:"last_success_at,omitempty"`
+}
+
+// RecordHealthJob is one probe to run: the check of a record value, deduplicated by Key.
+type RecordHealthJob struct {
+	Key   string
+	Name  string
+	Value string
+	Check dnsrecords.HealthCheck
+}
+
+// RecordHealthJobs returns the distinct health checks attached to local records.
+func (d *DNSResolverData) RecordHealthJobs() []RecordHealthJob {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	seen := make(map[string]bool)
+	var out []RecordHealthJob
+	for i := range d.DNSRecords {
+		r := &d.DNSRecords[i]
+		if r.HealthCheck == nil {
+			continue
+		}
+		key := r.HealthCheck.Key(r.Value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, RecordHealthJob{Key: key, Name: r.Name, Value: r.Value, Check: *r.HealthCheck})
+	}
+	return out
+}
+
+// RecordsGeneration changes whenever the in-memory local records change, including reloads of read-only
+// sources that leave RecordsRevision alone. Read it before RecordHealthJobs to tell when the jobs are stale.
+func (d *DNSResolverData) RecordsGeneration() uint64 {
+	return d.recordsGeneration.Load()
+}
+
+// ApplyRecordProbeResult records one probe outcome. warn is called when a value goes down; info when it
+// comes back.
+func (d *DNSResolverData) ApplyRecordProbeResult(job RecordHealthJob, ok bool, errStr string, warn, info func(msg string, kv ...any)) {
+	if d == nil {
+		return
+	}
+	d.mu.RLock()
+	h := d.recordHealth
+	d.mu.RUnlock()
+	fail, rise := job.Check.Thresholds()
+	if ok {
+		if h.ProbeOK(job.Key, rise) && info != nil {
+			info("record value healthy again", "name", job.Name, "value", job.Value, "check", job.Key)
+		}
+		return
+	}
+	if h.ProbeFail(job.Key, errStr, fail) && warn != nil {
+		warn("record value withheld after repeated probe failures", "name", job.Name, "value", job.Value, "check", job.Key, "error", errStr, "threshold", fail)
+	}
+}
+
+// RecordHealthStatuses returns one row per local record with a health check, sorted by name.
+func (d *DNSResolverData) RecordHealthStatuses() []RecordHealthStatus {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	h := d.recordHealth
+	var out []RecordHealthStatus
+	for i := range d.DNSRecords {
+		r := &d.DNSRecords[i]
+		if r.HealthCheck != nil {
+			out = append(out, RecordHealthStatus{Check: r.HealthCheck.Key(r.Value), Name: r.Name, Type: r.Type, Value: r.Value})
+		}
+	}
+	d.mu.RUnlock()
+	if h != nil {
+		h.mu.RLock()
+		for i := range out {
+			e := h.by[out[i].Check]
+			if e == nil {
+				continue
+			}
+			out[i].Unhealthy = e.unhealthy
+			out[i].ConsecutiveFailures = e.failures
+			if !e.lastProbe.IsZero() {
+				out[i].LastProbeAt = e.lastProbe.UTC().Format(time.RFC3339Nano)
+			}
+			out[i].LastProbeError = e.lastErr
+			if !e.lastOK.IsZero() {
+				out[i].LastSuccessAt = e.lastOK.UTC().Format(time.RFC3339Nano)
+			}
+		}
+		h.mu.RUnlock()
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out
+}
+
+// recordRotation is smooth weighted round-robin state per name/type (nginx-style: every value gains its
+// weight each pick, the largest is chosen and pays back the total).
+type recordRotation struct {
+	mu      sync.Mutex
+	current map[string]map[string]int
+}
+
+func (r *recordRotation) next(setKey string, recs []dnsrecords.DNSRecord, group []int) int {
+	if r == nil {
+		return group[0]
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == nil {
+		r.current = make(map[string]map[string]int)
+	}
+	cur := r.current[setKey]
+	if cur == nil {
+		cur = make(map[string]int)
+		r.current[setKey] = cur
+	}
+	total, best := 0, -1
+	for _, i := range group {
+		w := recs[i].Weight
+		if w <= 0 {
+			w = 1
+		}
+		v := recs[i].Value
+		cur[v] += w
+		total += w
+		if best < 0 || cur[v] > cur[recs[best].Value] {
+			best = i
+		}
+	}
+	cur[recs[best].Value] -= total
+	return best
+}
+
+// prune drops the state of name/types and values that are gone from records, whose index is idx. It runs
+// whenever the record index is rebuilt.
+func (r *recordRotation) prune(idx map[string][]int, records []dnsrecords.DNSRecord) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, cur := range r.current {
+		idxs, ok := idx[k]
+		if !ok {
+			delete(r.current, k)
+			continue
+		}
+		for v := range cur {
+			if !slices.ContainsFunc(idxs, func(i int) bool { return records[i].Value == v }) {
+				delete(cur, v)
+			}
+		}
+	}
+}
+
+// selectRecordValues applies health, priority, and weight to the indices of one name/type. It returns
+// nil when every value is down and none of them fails open.
+func selectRecordValues(recs []dnsrecords.DNSRecord, idxs []int, h *RecordHealthTracker, rot *recordRotation, setKey string) []int {
+	up := make([]int, 0, len(idxs))
+	failOpen := false
+	for _, i := range idxs {
+		r := &recs[i]
+		if hc := r.HealthCheck; hc != nil {
+			failOpen = failOpen || hc.FailOpen
+			if h.IsUnhealthy(hc.Key(r.Value)) {
+				continue
+			}
+		}
+		up = append(up, i)
+	}
+	if len(up) == 0 {
+		if !failOpen {
+			return nil
+		}
+		up = idxs
+	}
+	best := recs[up[0]].Priority
+	for _, i := range up[1:] {
+		best = min(best, recs[i].Priority)
+	}
+	group := up[:0:0]
+	weighted := false
+	for _, i := range up {
+		if recs[i].Priority == best {
+			group = append(group, i)
+			weighted = weighted || recs[i].Weight > 0
+		}
+	}
+	if !weighted || len(group) == 1 {
+		return group
+	}
+	return []int{rot.next(setKey, recs, group)}
+}
+
+// selectLocalRecordsLocked narrows idxs for name/type key k when any value has a health check, weight, or
+// priority; plain record sets are returned unchanged. Requires d.mu RLock held.
+func (d *DNSResolverData) selectLocalRecordsLocked(k string, idxs []int) []int {
+	for _, i := range idxs {
+		if i < 0 || i >= len(d.DNSRecords) {
+			continue
+		}
+		if r := &d.DNSRecords[i]; r.HealthCheck != nil || r.Weight > 0 || r.Priority > 0 {
+			return selectRecordValues(d.DNSRecords, idxs, d.recordHealth, d.recordRotation, k)
+		}
+	}
+	return idxs
+}
@@ -0,0 +1,150 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecordcache"
+	"dnsplane/dnsrecords"
+)
+
+func newRecordHealthData(records []dnsrecords.DNSRecord, cache []dnsrecordcache.CacheRecord) *DNSResolverData {
+	d := &DNSResolverData{
+		Settings:       config.Config{LocalRecordsEnabled: true, CacheRecords: true},
+		DNSRecords:     records,
+		CacheRecords:   cache,
+		recordHealth:   NewRecordHealthTracker(),
+		recordRotation: &recordRotation{},
+	}
+	d.WarmIndexes()
+	return d
+}
+
+func localValues(t *testing.T, d *DNSResolverData, name string) []string {
+	t.Helper()
+	_, loc, _, _, _ := d.TryFastLocalOrCache(name, "A", false)
+	var out []string
+	for _, rr := range loc {
+		out = append(out, rr.(*dns.A).A.String())
+	}
+	return out
+}
+
+func failJob(d *DNSResolverData, value string) {
+	for _, job := range d.RecordHealthJobs() {
+		if job.Value == value {
+			for i := 0; i < 3; i++ {
+				d.ApplyRecordProbeResult(job, false, "connection refused", nil, nil)
+			}
+		}
+	}
+}
+
+func TestRecordHealthWithholdsAndFailsOpen(t *testing.T) {
+	hc := &dnsrecords.HealthCheck{Type: "tcp", Port: 443}
+	d := newRecordHealthData([]dnsrecords.DNSRecord{
+		{Name: "app.corp", Type: "A", Value: "10.0.0.1", TTL: 60, HealthCheck: hc},
+		{Name: "app.corp", Type: "A", Value: "10.0.0.2", TTL: 60, HealthCheck: hc},
+	}, []dnsrecordcache.CacheRecord{{
+		DNSRecord: dnsrecords.DNSRecord{Name: "app.corp.", Type: "A", Value: "10.0.0.1", TTL: 60},
+		Expiry:    time.Now().Add(time.Hour),
+	}})
+	if got := localValues(t, d, "app.corp."); len(got) != 2 {
+		t.Fatalf("unprobed values = %v, want both", got)
+	}
+	failJob(d, "10.0.0.1")
+	if got := localValues(t, d, "app.corp."); len(got) != 1 || got[0] != "10.0.0.2" {
+		t.Fatalf("after 10.0.0.1 down = %v", got)
+	}
+	failJob(d, "10.0.0.2")
+	if handled, loc, crr, _, _ := d.TryFastLocalOrCache("app.corp.", "A", false); handled || len(loc) != 0 || crr != nil {
+		t.Fatalf("all down: handled=%v local=%v cache=%v (cached copy must not be served)", handled, loc, crr)
+	}
+	if rrs := d.LookupLocalRRs("app.corp.", "A", false); len(rrs) != 0 {
+		t.Fatalf("all down: LookupLocalRRs = %v", rrs)
+	}
+	st := d.RecordHealthStatuses()
+	if len(st) != 2 || !st[0].Unhealthy || st[0].ConsecutiveFailures != 3 || st[0].LastProbeError == "" {
+		t.Fatalf("statuses = %+v", st)
+	}
+
+	// Rise threshold: one success is not enough.
+	job := d.RecordHealthJobs()[0]
+	d.ApplyRecordProbeResult(job, true, "", nil, nil)
+	if got := localValues(t, d, "app.corp."); len(got) != 0 {
+		t.Fatalf("after one success = %v", got)
+	}
+	d.ApplyRecordProbeResult(job, true, "", nil, nil)
+	if got := localValues(t, d, "app.corp."); len(got) != 1 || got[0] != job.Value {
+		t.Fatalf("after rise = %v", got)
+	}
+
+	open := &dnsrecords.HealthCheck{Type: "tcp", Port: 443, FailOpen: true}
+	d = newRecordHealthData([]dnsrecords.DNSRecord{
+		{Name: "web.corp", Type: "A", Value: "10.0.1.1", TTL: 60, HealthCheck: open},
+		{Name: "web.corp", Type: "A", Value: "10.0.1.2", TTL: 60, HealthCheck: open},
+	}, nil)
+	failJob(d, "10.0.1.1")
+	failJob(d, "10.0.1.2")
+	if got := localValues(t, d, "web.corp."); len(got) != 2 {
+		t.Fatalf("fail open = %v, want both", got)
+	}
+}
+
+func TestRecordPriorityAndWeights(t *testing.T) {
+	hc := &dnsrecords.HealthCheck{Type: "tcp", Port: 443}
+	d := newRecordHealthData([]dnsrecords.DNSRecord{
+		{Name: "db.corp", Type: "A", Value: "10.0.0.1", TTL: 60, Priority: 0, HealthCheck: hc},
+		{Name: "db.corp", Type: "A", Value: "10.0.0.2", TTL: 60, Priority: 10},
+		{Name: "lb.corp", Type: "A", Value: "10.0.2.1", TTL: 60, Weight: 3},
+		{Name: "lb.corp", Type: "A", Value: "10.0.2.2", TTL: 60, Weight: 1},
+	}, nil)
+	if got := localValues(t, d, "db.corp."); len(got) != 1 || got[0] != "10.0.0.1" {
+		t.Fatalf("primary = %v", got)
+	}
+	failJob(d, "10.0.0.1")
+	if got := localValues(t, d, "db.corp."); len(got) != 1 || got[0] != "10.0.0.2" {
+		t.Fatalf("failover = %v", got)
+	}
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		got := localValues(t, d, "lb.corp.")
+		if len(got) != 1 {
+			t.Fatalf("weighted answer = %v, want one value", got)
+		}
+		counts[got[0]]++
+	}
+	if counts["10.0.2.1"] != 6 || counts["10.0.2.2"] != 2 {
+		t.Fatalf("weighted rotation = %v, want 6:2", counts)
+	}
+
+	// Rebuilding the index forgets rotation state of removed names and values.
+	lbKey := dnsCacheIdxKey("lb.corp.", "A")
+	if len(d.recordRotation.current[lbKey]) != 2 {
+		t.Fatalf("rotation state = %v", d.recordRotation.current)
+	}
+	gen := d.RecordsGeneration()
+	d.mu.Lock()
+	d.DNSRecords = append(d.DNSRecords[:2:2], dnsrecords.DNSRecord{Name: "lb.corp", Type: "A", Value: "10.0.2.3", TTL: 60, Weight: 1})
+	d.rebuildDNSRecordIndexLocked()
+	d.mu.Unlock()
+	if cur := d.recordRotation.current[lbKey]; len(cur) != 0 {
+		t.Fatalf("rotation state of removed values: %v", cur)
+	}
+	if d.RecordsGeneration() == gen {
+		t.Fatal("records generation unchanged by an index rebuild")
+	}
+	d.mu.Lock()
+	d.DNSRecords = d.DNSRecords[:2]
+	d.rebuildDNSRecordIndexLocked()
+	d.mu.Unlock()
+	if n := len(d.recordRotation.current); n != 0 {
+		t.Fatalf("rotation state after removing lb.corp: %v", d.recordRotation.current)
+	}
+}
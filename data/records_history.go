@@ -0,0 +1,57 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import (
+	"fmt"
+
+	"dnsplane/dnsrecords"
+	"dnsplane/journal"
+)
+
+// SetRecordJournal starts keeping record history in j and journals the records loaded at startup, so
+// edits made while dnsplane was stopped show up as one entry. Pass nil to stop.
+func (d *DNSResolverData) SetRecordJournal(j *journal.Journal) {
+	d.recordJournal.Store(j)
+	if j != nil {
+		d.journalRecords(journal.Origin{Source: journal.SourceStartup}, d.GetRecords())
+	}
+}
+
+// RecordJournal returns the record history, or nil when records_history is off.
+func (d *DNSResolverData) RecordJournal() *journal.Journal {
+	return d.recordJournal.Load()
+}
+
+// journalRecords appends records to the history. ok is false when history is off or the append failed, so
+// the entry holds no diff.
+func (d *DNSResolverData) journalRecords(o journal.Origin, records []dnsrecords.DNSRecord) (e journal.Entry, ok bool) {
+	j := d.recordJournal.Load()
+	if j == nil {
+		return journal.Entry{}, false
+	}
+	e, _, err := j.Record(o, records)
+	if err != nil {
+		resolverSlog().Warn("record history: append failed", "source", o.Source, "error", err)
+		return journal.Entry{}, false
+	}
+	return e, true
+}
+
+// RollbackRecords restores the records as of history revision rev. The rollback is saved and journaled
+// like any other change and pushed to cluster peers. It returns the number of records restored.
+func (d *DNSResolverData) RollbackRecords(o journal.Origin, rev uint64) (int, error) {
+	j := d.recordJournal.Load()
+	if j == nil {
+		return 0, journal.ErrDisabled
+	}
+	records, err := j.StateAt(rev)
+	if err != nil {
+		return 0, err
+	}
+	o.Note = fmt.Sprintf("rollback to rev %d", rev)
+	if err := d.UpdateRecords(o, records); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
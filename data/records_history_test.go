@@ -0,0 +1,72 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import (
+	"path/filepath"
+	"testing"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/journal"
+)
+
+func TestRollbackRecords(t *testing.T) {
+	dir := t.TempDir()
+	SetConfig(&config.Loaded{
+		Path: filepath.Join(dir, "dnsplane.json"),
+		Config: config.Config{FileLocations: config.FileLocations{
+			RecordsSource: &config.RecordsSourceConfig{Type: config.RecordsSourceFile, Location: filepath.Join(dir, "dnsrecords.json")},
+		}},
+	})
+	defer func() {
+		configStateMu.Lock()
+		configState = nil
+		configStateMu.Unlock()
+	}()
+	j, err := journal.Open(filepath.Join(dir, "history"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &DNSResolverData{}
+	d.SetRecordJournal(j)
+	defer d.Close()
+
+	v1 := []dnsrecords.DNSRecord{{ID: "a", Name: "www.example.com", Type: "A", Value: "192.0.2.1", TTL: 300}}
+	v2 := []dnsrecords.DNSRecord{{ID: "b", Name: "mail.example.com", Type: "A", Value: "192.0.2.2", TTL: 300}}
+	tui := journal.Origin{Source: journal.SourceTUI, Actor: "127.0.0.1:40000"}
+	if err := d.UpdateRecords(tui, v1); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.UpdateRecords(tui, v2); err != nil {
+		t.Fatal(err)
+	}
+
+	notified := 0
+	SetClusterRecordsNotify(func() { notified++ })
+	defer SetClusterRecordsNotify(nil)
+	n, err := d.RollbackRecords(journal.Origin{Source: journal.SourceAPI, Actor: "default"}, 1)
+	if err != nil || n != 1 {
+		t.Fatalf("rollback: %d %v", n, err)
+	}
+	if got := d.GetRecords(); len(got) != 1 || got[0].ID != "a" {
+		t.Fatalf("records after rollback: %+v", got)
+	}
+	if notified != 1 {
+		t.Fatalf("cluster notified %d times, want 1", notified)
+	}
+	entries, err := j.Entries(journal.Filter{Limit: 1})
+	if err != nil || len(entries) != 1 || entries[0].Rev != 3 || entries[0].Note != "rollback to rev 1" || entries[0].Source != journal.SourceAPI {
+		t.Fatalf("rollback entry: %+v %v", entries, err)
+	}
+
+	if err := d.ApplyClusterRecords("node-b", v2); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := j.Entries(journal.Filter{Limit: 1}); len(entries) != 1 || entries[0].Actor != "node-b" || entries[0].Source != journal.SourceCluster {
+		t.Fatalf("cluster entry: %+v", entries)
+	}
+	if notified != 1 {
+		t.Fatal("cluster apply notified peers")
+	}
+}
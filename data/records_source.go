@@ -14,7 +14,6 @@ import (
 	"strings"
 	"time"
 
-	"dnsplane/config"
 	"dnsplane/dnsrecords"
 
 	"github.com/go-git/go-git/v5"
@@ -26,7 +25,9 @@ const (
 )
 
 type recordsJSON struct {
-	Records []dnsrecords.DNSRecord `json:"records"`
+	// Revision counts dnsplane's writes to the file; see RecordsRevision.
+	Revision uint64                 `json:"revision,omitempty"`
+	Records  []dnsrecords.DNSRecord `json:"records"`
 }
 
 // loadRecordsFromURL fetches JSON from url and returns parsed records. Canonicalizes names.
@@ -118,12 +119,12 @@ func sanitizeForDir(s string) string {
 	return s
 }
 
-// RecordsSourceIsReadOnly returns true if the current config uses a read-only records source (url, git, or bind_dir).
+// RecordsSourceIsReadOnly returns true if no configured records source is writable (only url, git, or
+// bind_dir sources, or records_sources without a writable file).
 func RecordsSourceIsReadOnly() bool {
-	cfg := currentConfig().Config.FileLocations
-	if cfg.RecordsSource == nil {
+	fl := currentConfig().Config.FileLocations
+	if len(fl.Sources()) == 0 {
 		return false
 	}
-	t := strings.ToLower(strings.TrimSpace(cfg.RecordsSource.Type))
-	return t == config.RecordsSourceURL || t == config.RecordsSourceGit || t == config.RecordsSourceBindDir
+	return fl.WritableSource() == nil
 }
@@ -0,0 +1,385 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/journal"
+)
+
+// recordLayer is one records source in precedence order.
+type recordLayer struct {
+	cfg         config.RecordsSourceConfig
+	records     []dnsrecords.DNSRecord
+	served      int
+	refreshedAt time.Time
+	err         string
+}
+
+type layerRef struct {
+	layer, index int
+}
+
+// recordSourceSet holds the records loaded from each configured source and what the merge made of them.
+// API and TUI writes replace the writable layer; read-only layers change only when their source is
+// reloaded.
+type recordSourceSet struct {
+	mu       sync.Mutex
+	layers   []recordLayer
+	writable int // index into layers, -1 when every source is read-only
+	// visible holds the read-only records served after the merge, by name|type|value.
+	visible map[string]visibleRecord
+	// hidden holds writable records that a later override source hides; they are kept on save.
+	hidden []dnsrecords.DNSRecord
+	// revision is that of the writable records file, raised by each save.
+	revision uint64
+	// storeMu serializes replacing the served records, so a source refresh and a write cannot publish
+	// out of order.
+	storeMu sync.Mutex
+}
+
+type visibleRecord struct {
+	record dnsrecords.DNSRecord
+	source string
+}
+
+var recordSources = &recordSourceSet{writable: -1}
+
+func resetRecordSources() {
+	recordSources.mu.Lock()
+	defer recordSources.mu.Unlock()
+	recordSources.layers = nil
+	recordSources.writable = -1
+	recordSources.visible = nil
+	recordSources.hidden = nil
+	recordSources.revision = 0
+}
+
+func sourceRecordKey(r dnsrecords.DNSRecord) string {
+	t := dnsrecords.NormalizeRecordType(r.Type)
+	return dnsrecords.NormalizeRecordNameKey(r.Name) + "|" + t + "|" + dnsrecords.NormalizeRecordValueKey(t, r.Value)
+}
+
+func sourceNameTypeKey(r dnsrecords.DNSRecord) string {
+	return dnsrecords.NormalizeRecordNameKey(r.Name) + "|" + dnsrecords.NormalizeRecordType(r.Type)
+}
+
+// sameSourceRecord compares the JSON forms, ignoring LastQuery.
+func sameSourceRecord(a, b dnsrecords.DNSRecord) bool {
+	a.LastQuery, b.LastQuery = time.Time{}, time.Time{}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// mergeRecordLayers combines layers in order. An override layer hides earlier records with the same
+// name and type; a union layer only replaces earlier records with the same name, type, and value.
+// Expired records of read-only layers are left out, since they cannot be removed from their source.
+// It returns the merged records and, for each, the layer and index it came from.
+func mergeRecordLayers(layers []recordLayer, now time.Time) ([]dnsrecords.DNSRecord, []layerRef) {
+	var out []dnsrecords.DNSRecord
+	var refs []layerRef
+	for li, l := range layers {
+		var recs []dnsrecords.DNSRecord
+		var idx []int
+		for i, r := range l.records {
+			if !l.cfg.Writable && r.Expired(now) {
+				continue
+			}
+			recs = append(recs, r)
+			idx = append(idx, i)
+		}
+		drop := make(map[string]bool, len(recs))
+		for _, r := range recs {
+			if l.cfg.Merge == config.RecordsMergeUnion {
+				drop[sourceRecordKey(r)] = true
+			} else {
+				drop[sourceNameTypeKey(r)] = true
+			}
+		}
+		keptOut, keptRefs := out[:0], refs[:0]
+		for i, r := range out {
+			key := sourceNameTypeKey(r)
+			if l.cfg.Merge == config.RecordsMergeUnion {
+				key = sourceRecordKey(r)
+			}
+			if drop[key] {
+				continue
+			}
+			keptOut = append(keptOut, r)
+			keptRefs = append(keptRefs, refs[i])
+		}
+		out, refs = keptOut, keptRefs
+		for i, r := range recs {
+			out = append(out, r)
+			refs = append(refs, layerRef{layer: li, index: idx[i]})
+		}
+	}
+	return out, refs
+}
+
+// mergeLocked re-merges the layers and updates served counts, visible, and hidden. Caller holds s.mu.
+func (s *recordSourceSet) mergeLocked(now time.Time) []dnsrecords.DNSRecord {
+	merged, refs := mergeRecordLayers(s.layers, now)
+	s.visible = make(map[string]visibleRecord)
+	s.hidden = nil
+	for i := range s.layers {
+		s.layers[i].served = 0
+	}
+	shown := make(map[int]bool)
+	for i, ref := range refs {
+		l := &s.layers[ref.layer]
+		l.served++
+		if ref.layer == s.writable {
+			shown[ref.index] = true
+			continue
+		}
+		s.visible[sourceRecordKey(merged[i])] = visibleRecord{record: merged[i], source: l.cfg.Name}
+	}
+	if s.writable >= 0 {
+		for i, r := range s.layers[s.writable].records {
+			if !shown[i] {
+				s.hidden = append(s.hidden, r)
+			}
+		}
+	}
+	return merged
+}
+
+// splitLocked returns the records of the writable layer: records minus the read-only ones served as
+// they are, plus the writable records a later source hides. Caller holds s.mu.
+func (s *recordSourceSet) splitLocked(records []dnsrecords.DNSRecord) []dnsrecords.DNSRecord {
+	out := make([]dnsrecords.DNSRecord, 0, len(records)+len(s.hidden))
+	for _, r := range records {
+		if v, ok := s.visible[sourceRecordKey(r)]; ok && sameSourceRecord(v.record, r) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return append(out, s.hidden...)
+}
+
+// apply makes records the new served set: the writable layer becomes the records that do not come from
+// read-only sources, and the result is the re-merged set. With no writable source (or no sources
+// loaded) records are returned as they are.
+func (s *recordSourceSet) apply(records []dnsrecords.DNSRecord, now time.Time) ([]dnsrecords.DNSRecord, []dnsrecords.DNSRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writable < 0 || s.writable >= len(s.layers) {
+		return records, records
+	}
+	layer := s.splitLocked(records)
+	s.layers[s.writable].records = layer
+	return s.mergeLocked(now), layer
+}
+
+// checkWrite returns an error when records drop or change a record served from a read-only source.
+// Expired read-only records may be dropped.
+func (s *recordSourceSet) checkWrite(records []dnsrecords.DNSRecord, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.visible) == 0 {
+		return nil
+	}
+	present := make(map[string][]dnsrecords.DNSRecord, len(records))
+	for _, r := range records {
+		k := sourceRecordKey(r)
+		present[k] = append(present[k], r)
+	}
+	for k, v := range s.visible {
+		if v.record.Expired(now) {
+			continue
+		}
+		ok := false
+		for _, r := range present[k] {
+			if sameSourceRecord(v.record, r) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("record %s %s %s comes from read-only records source %q", v.record.Name, v.record.Type, v.record.Value, v.source)
+		}
+	}
+	return nil
+}
+
+func (s *recordSourceSet) hasWritable() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writable >= 0
+}
+
+// loadRecordSource reads all records of one source, with the revision saved in a records file (0 for
+// other sources).
+func loadRecordSource(rs config.RecordsSourceConfig) ([]dnsrecords.DNSRecord, uint64, error) {
+	loc := strings.TrimSpace(rs.Location)
+	if loc == "" {
+		return nil, 0, fmt.Errorf("records source %q: location is empty", rs.Name)
+	}
+	var records []dnsrecords.DNSRecord
+	var err error
+	switch strings.ToLower(strings.TrimSpace(rs.Type)) {
+	case config.RecordsSourceURL:
+		records, err = loadRecordsFromURL(loc)
+	case config.RecordsSourceGit:
+		records, err = loadRecordsFromGit(loc)
+	case config.RecordsSourceBindDir:
+		records, err = loadRecordsBindDir(&rs)
+	default:
+		file, err := LoadFromJSON[recordsJSON](loc)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range file.Records {
+			file.Records[i].Name = dnsrecords.CanonicalizeRecordNameForStorage(file.Records[i].Name)
+		}
+		return file.Records, file.Revision, nil
+	}
+	return records, 0, err
+}
+
+// nextRevision raises the records revision for a save of the writable records file.
+func (s *recordSourceSet) nextRevision() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revision++
+	return s.revision
+}
+
+// RecordsRevision returns the revision of the local records. It is saved with the writable records file
+// and goes up by one with every save, so it changes whenever a write through the API, the TUI, a zone
+// import, a rollback, or a cluster peer changes the records. Reloading the file takes the revision
+// written in it when that is higher.
+func RecordsRevision() uint64 {
+	recordSources.mu.Lock()
+	defer recordSources.mu.Unlock()
+	return recordSources.revision
+}
+
+// refreshRecordSource reloads the source named name and re-merges. On error the source keeps its
+// previous records and the error is shown in its status.
+func (d *DNSResolverData) refreshRecordSource(name string) (int, error) {
+	s := recordSources
+	s.mu.Lock()
+	li := -1
+	var rs config.RecordsSourceConfig
+	for i, l := range s.layers {
+		if l.cfg.Name == name {
+			li, rs = i, l.cfg
+		}
+	}
+	s.mu.Unlock()
+	if li < 0 {
+		return 0, fmt.Errorf("unknown records source %q", name)
+	}
+	records, rev, err := loadRecordSource(rs)
+
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+	s.mu.Lock()
+	if li >= len(s.layers) || s.layers[li].cfg.Name != name {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("records source %q was reconfigured", name)
+	}
+	if err != nil {
+		s.layers[li].err = err.Error()
+		s.mu.Unlock()
+		return 0, err
+	}
+	s.layers[li].records = records
+	if li == s.writable {
+		s.revision = max(s.revision, rev)
+	}
+	s.layers[li].err = ""
+	s.layers[li].refreshedAt = time.Now()
+	merged := s.mergeLocked(time.Now())
+	s.mu.Unlock()
+	d.publishRecords(merged, journal.Origin{Source: journal.SourceReload, Actor: name})
+	return len(records), nil
+}
+
+// ReloadRecordSource reloads one records source by name into the singleton resolver.
+func ReloadRecordSource(name string) (int, error) {
+	return GetInstance().refreshRecordSource(name)
+}
+
+// startRecordSourceRefresh starts the refresh loops and watchers of every source that asks for them.
+func (d *DNSResolverData) startRecordSourceRefresh(sources []config.RecordsSourceConfig) {
+	var stops []func()
+	for _, rs := range sources {
+		if rs.RefreshIntervalSeconds > 0 {
+			go d.recordsRefreshLoop(rs.Name, time.Duration(rs.RefreshIntervalSeconds)*time.Second)
+		}
+		if !rs.Watch || strings.TrimSpace(rs.Location) == "" {
+			continue
+		}
+		switch rs.Type {
+		case config.RecordsSourceBindDir:
+			stops = append(stops, startRecordsSourceWatch(d, rs.Name, rs.Location, ""))
+		case config.RecordsSourceFile:
+			stops = append(stops, startRecordsSourceWatch(d, rs.Name, "", rs.Location))
+		}
+	}
+	if len(stops) > 0 {
+		d.stopRecordsSourceWatch = func() {
+			for _, stop := range stops {
+				stop()
+			}
+		}
+	}
+}
+
+// RecordSourceStatus describes one records source for server status and the API.
+type RecordSourceStatus struct {
+	Name                   string    `json:"name"`
+	Type                   string    `json:"type"`
+	Location               string    `json:"location"`
+	Writable               bool      `json:"writable"`
+	Merge                  string    `json:"merge"`
+	Records                int       `json:"records"`
+	Served                 int       `json:"served"`
+	RefreshedAt            time.Time `json:"refreshed_at,omitzero"`
+	LastError              string    `json:"last_error,omitempty"`
+	RefreshIntervalSeconds int       `json:"refresh_interval_seconds,omitempty"`
+	Watch                  bool      `json:"watch,omitempty"`
+}
+
+// RecordSourcesStatus returns the records sources in precedence order with their record counts
+// (loaded and served after the merge) and last refresh error.
+func RecordSourcesStatus() []RecordSourceStatus {
+	s := recordSources
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RecordSourceStatus, 0, len(s.layers))
+	for _, l := range s.layers {
+		loc := l.cfg.Location
+		if l.cfg.Type == config.RecordsSourceURL || l.cfg.Type == config.RecordsSourceGit {
+			if u, err := url.Parse(loc); err == nil {
+				loc = u.Redacted()
+			}
+		}
+		out = append(out, RecordSourceStatus{
+			Name:                   l.cfg.Name,
+			Type:                   l.cfg.Type,
+			Location:               loc,
+			Writable:               l.cfg.Writable,
+			Merge:                  l.cfg.Merge,
+			Records:                len(l.records),
+			Served:                 l.served,
+			RefreshedAt:            l.refreshedAt,
+			LastError:              l.err,
+			RefreshIntervalSeconds: l.cfg.RefreshIntervalSeconds,
+			Watch:                  l.cfg.Watch,
+		})
+	}
+	return out
+}
@@ -0,0 +1,161 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/journal"
+)
+
+func TestMergeRecordLayers(t *testing.T) {
+	now := time.Now()
+	base := recordLayer{cfg: config.RecordsSourceConfig{Name: "base"}, records: []dnsrecords.DNSRecord{
+		{Name: "www.example.com", Type: "A", Value: "192.0.2.1"},
+		{Name: "www.example.com", Type: "A", Value: "192.0.2.2"},
+		{Name: "mail.example.com", Type: "A", Value: "192.0.2.3"},
+		{Name: "old.example.com", Type: "A", Value: "192.0.2.4", ExpiresAt: now.Add(-time.Minute)},
+	}}
+	override := recordLayer{cfg: config.RecordsSourceConfig{Name: "override", Merge: config.RecordsMergeOverride}, records: []dnsrecords.DNSRecord{
+		{Name: "www.example.com", Type: "A", Value: "198.51.100.1"},
+	}}
+	union := recordLayer{cfg: config.RecordsSourceConfig{Name: "union", Merge: config.RecordsMergeUnion}, records: []dnsrecords.DNSRecord{
+		{Name: "mail.example.com", Type: "A", Value: "192.0.2.3", TTL: 60},
+		{Name: "mail.example.com", Type: "A", Value: "192.0.2.5"},
+	}}
+
+	got, refs := mergeRecordLayers([]recordLayer{base, override, union}, now)
+	var lines []string
+	for i, r := range got {
+		lines = append(lines, r.Name+" "+r.Value+" "+[]string{"base", "override", "union"}[refs[i].layer])
+	}
+	want := []string{
+		"www.example.com 198.51.100.1 override",
+		"mail.example.com 192.0.2.3 union",
+		"mail.example.com 192.0.2.5 union",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("merged:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRecordSourcesWrite(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.json")
+	local := filepath.Join(dir, "local.json")
+	if err := os.WriteFile(shared, []byte(`{"records":[{"id":"s1","name":"www.example.com","type":"A","value":"192.0.2.1","ttl":300},{"id":"s2","name":"db.example.com","type":"A","value":"192.0.2.2","ttl":300}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(local, []byte(`{"records":[{"id":"l1","name":"db.example.com","type":"A","value":"10.0.0.2","ttl":300}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	SetConfig(&config.Loaded{
+		Path: filepath.Join(dir, "dnsplane.json"),
+		Config: config.Config{FileLocations: config.FileLocations{RecordsSources: []config.RecordsSourceConfig{
+			{Name: "local", Type: config.RecordsSourceFile, Location: local, Writable: true, Merge: config.RecordsMergeOverride},
+			{Name: "shared", Type: config.RecordsSourceFile, Location: shared, Merge: config.RecordsMergeOverride},
+		}}},
+	})
+	defer func() {
+		configStateMu.Lock()
+		configState = nil
+		configStateMu.Unlock()
+		resetRecordSources()
+	}()
+
+	records, err := LoadDNSRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// shared comes later, so its db.example.com hides the local one.
+	if len(records) != 2 || records[0].ID != "s1" || records[1].ID != "s2" {
+		t.Fatalf("merged: %+v", records)
+	}
+	d := &DNSResolverData{}
+	d.publishRecords(records, journal.Origin{Source: journal.SourceReload})
+
+	if err := d.UpdateRecords(journal.Origin{Source: journal.SourceAPI}, records[1:]); err == nil || !strings.Contains(err.Error(), `"shared"`) {
+		t.Fatalf("deleting a shared record: %v", err)
+	}
+	added := append(d.GetRecords(), dnsrecords.DNSRecord{ID: "l2", Name: "new.example.com", Type: "A", Value: "10.0.0.3", TTL: 300})
+	if err := d.UpdateRecords(journal.Origin{Source: journal.SourceAPI}, added); err != nil {
+		t.Fatal(err)
+	}
+	if got := d.GetRecords(); len(got) != 3 {
+		t.Fatalf("records after add: %+v", got)
+	}
+	saved, err := LoadFromJSON[recordsJSON](local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hidden local record is kept and the shared records are not copied into the local file.
+	if len(saved.Records) != 2 || saved.Records[0].ID != "l2" || saved.Records[1].ID != "l1" {
+		t.Fatalf("local file: %+v", saved.Records)
+	}
+	status := RecordSourcesStatus()
+	if len(status) != 2 || status[0].Records != 2 || status[0].Served != 1 || status[1].Served != 2 {
+		t.Fatalf("status: %+v", status)
+	}
+}
+
+func TestModifyRecordsRevision(t *testing.T) {
+	dir := t.TempDir()
+	local := filepath.Join(dir, "dnsrecords.json")
+	if err := os.WriteFile(local, []byte(`{"revision":7,"records":[{"id":"a","name":"www.example.com","type":"A","value":"192.0.2.1","ttl":300}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	SetConfig(&config.Loaded{
+		Path: filepath.Join(dir, "dnsplane.json"),
+		Config: config.Config{FileLocations: config.FileLocations{
+			RecordsSource: &config.RecordsSourceConfig{Type: config.RecordsSourceFile, Location: local},
+		}},
+	})
+	pushes := 0
+	SetClusterRecordsNotify(func() { pushes++ })
+	defer func() {
+		SetClusterRecordsNotify(nil)
+		configStateMu.Lock()
+		configState = nil
+		configStateMu.Unlock()
+		resetRecordSources()
+	}()
+
+	records, err := LoadDNSRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev := RecordsRevision(); rev != 7 {
+		t.Fatalf("revision after load = %d, want 7", rev)
+	}
+	d := &DNSResolverData{DNSRecords: records}
+	add := func(records []dnsrecords.DNSRecord, rev uint64) ([]dnsrecords.DNSRecord, error) {
+		if rev != 7 {
+			t.Errorf("fn saw revision %d", rev)
+		}
+		return append(records, dnsrecords.DNSRecord{ID: "b", Name: "mail.example.com", Type: "A", Value: "192.0.2.2", TTL: 300}), nil
+	}
+	if rev, err := d.ModifyRecords(journal.Origin{Source: journal.SourceAPI}, add); err != nil || rev != 8 {
+		t.Fatalf("ModifyRecords = %d, %v; want 8", rev, err)
+	}
+	same := func(records []dnsrecords.DNSRecord, _ uint64) ([]dnsrecords.DNSRecord, error) { return records, nil }
+	if rev, err := d.ModifyRecords(journal.Origin{Source: journal.SourceAPI}, same); err != nil || rev != 8 {
+		t.Fatalf("unchanged write = %d, %v; want 8 and no save", rev, err)
+	}
+	refused := func([]dnsrecords.DNSRecord, uint64) ([]dnsrecords.DNSRecord, error) { return nil, os.ErrExist }
+	if _, err := d.ModifyRecords(journal.Origin{Source: journal.SourceAPI}, refused); err != os.ErrExist {
+		t.Fatalf("fn error = %v", err)
+	}
+	if pushes != 1 || len(d.GetRecords()) != 2 {
+		t.Fatalf("pushes = %d, records = %d; want 1, 2", pushes, len(d.GetRecords()))
+	}
+	saved, err := LoadFromJSON[recordsJSON](local)
+	if err != nil || saved.Revision != 8 || len(saved.Records) != 2 {
+		t.Fatalf("saved revision %d with %d records (%v)", saved.Revision, len(saved.Records), err)
+	}
+}
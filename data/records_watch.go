@@ -4,6 +4,7 @@
 package data
 
 import (
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -12,16 +13,21 @@ import (
 
 const bindDirWatchDebounce = 500 * time.Millisecond
 
-// startBindDirWatch watches zoneDir for changes and reloads records after a short debounce.
-// The returned function stops the watcher (idempotent).
-func startBindDirWatch(d *DNSResolverData, zoneDir string) func() {
+// startRecordsSourceWatch watches the records source named name and reloads it after a short debounce:
+// the zone directory dir of a bind_dir source, or the records file of a file source (through its
+// directory, since editors replace the file). The returned function stops the watcher (idempotent).
+func startRecordsSourceWatch(d *DNSResolverData, name, dir, file string) func() {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		resolverSlog().Error("bind_dir watch: fsnotify init failed", "error", err)
+		resolverSlog().Error("records watch: fsnotify init failed", "source", name, "error", err)
 		return func() {}
 	}
-	if err := watcher.Add(zoneDir); err != nil {
-		resolverSlog().Error("bind_dir watch: add path failed", "path", zoneDir, "error", err)
+	if file != "" {
+		file = filepath.Clean(file)
+		dir = filepath.Dir(file)
+	}
+	if err := watcher.Add(dir); err != nil {
+		resolverSlog().Error("records watch: add path failed", "source", name, "path", dir, "error", err)
 		_ = watcher.Close()
 		return func() {}
 	}
@@ -37,12 +43,12 @@ func startBindDirWatch(d *DNSResolverData, zoneDir string) func() {
 			pending.Stop()
 		}
 		pending = time.AfterFunc(bindDirWatchDebounce, func() {
-			n, err := d.ReloadRecordsFromSource()
+			n, err := d.refreshRecordSource(name)
 			if err != nil {
-				resolverSlog().Warn("bind_dir watch: reload failed", "error", err)
+				resolverSlog().Warn("records watch: reload failed", "source", name, "error", err)
 				return
 			}
-			resolverSlog().Info("bind_dir watch: reload completed", "records", n)
+			resolverSlog().Info("records watch: reload completed", "source", name, "records", n)
 		})
 	}
 
@@ -61,6 +67,9 @@ func startBindDirWatch(d *DNSResolverData, zoneDir string) func() {
 				if !ok {
 					return
 				}
+				if file != "" && filepath.Clean(ev.Name) != file {
+					continue
+				}
 				if ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write) || ev.Has(fsnotify.Rename) || ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Chmod) {
 					schedule()
 				}
@@ -69,7 +78,7 @@ func startBindDirWatch(d *DNSResolverData, zoneDir string) func() {
 					return
 				}
 				if err != nil {
-					resolverSlog().Warn("bind_dir watch: watcher error", "error", err)
+					resolverSlog().Warn("records watch: watcher error", "source", name, "error", err)
 				}
 			}
 		}
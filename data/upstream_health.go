@@ -9,6 +9,7 @@ import (
 
 	"dnsplane/config"
 	"dnsplane/dnsservers"
+	"dnsplane/events"
 )
 
 // UpstreamHealthTracker tracks per-upstream probe/forward outcomes when health checks are enabled.
@@ -60,21 +61,23 @@ func (t *UpstreamHealthTracker) Filter(cfg *config.Config, addrs []string) []str
 
 // RecordForwardSuccess marks the upstream healthy after a successful client query.
 func (t *UpstreamHealthTracker) RecordForwardSuccess(key string) {
+	t.ProbeOK(key)
+}
+
+// ProbeOK records a successful health probe. Returns true if the server was marked down until now.
+func (t *UpstreamHealthTracker) ProbeOK(key string) (nowHealthy bool) {
 	if t == nil || key == "" {
-		return
+		return false
 	}
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	e := t.ensure(key)
+	was := e.unhealthy
 	e.unhealthy = false
 	e.failures = 0
 	e.lastErr = ""
 	e.lastOK = time.Now()
-}
-
-// ProbeOK records a successful health probe.
-func (t *UpstreamHealthTracker) ProbeOK(key string) {
-	t.RecordForwardSuccess(key)
+	return was
 }
 
 // ProbeFail increments failures and marks unhealthy after threshold. Returns true if transitioned to unhealthy.
@@ -227,7 +230,8 @@ func (d *DNSResolverData) UpstreamHealthStatuses() ([]UpstreamHealthStatus, bool
 	return st, enabled
 }
 
-// ApplyUpstreamProbeResult records one probe outcome. warn is called when an upstream becomes unhealthy.
+// ApplyUpstreamProbeResult records one probe outcome. warn is called when an upstream becomes unhealthy; that and
+// its recovery are published as events.
 func (d *DNSResolverData) ApplyUpstreamProbeResult(key string, ok bool, errStr string, warn func(msg string, kv ...any)) {
 	if d == nil {
 		return
@@ -244,10 +248,15 @@ func (d *DNSResolverData) ApplyUpstreamProbeResult(key string, ok bool, errStr s
 		th = 3
 	}
 	if ok {
-		h.ProbeOK(key)
+		if h.ProbeOK(key) {
+			events.Publish(events.UpstreamRecovered, map[string]any{"server": key})
+		}
 		return
 	}
-	if h.ProbeFail(key, errStr, th) && warn != nil {
-		warn("upstream marked unhealthy after repeated probe failures", "server", key, "error", errStr, "threshold", th)
+	if h.ProbeFail(key, errStr, th) {
+		if warn != nil {
+			warn("upstream marked unhealthy after repeated probe failures", "server", key, "error", errStr, "threshold", th)
+		}
+		events.Publish(events.UpstreamUnhealthy, map[string]any{"server": key, "error": errStr, "failures": th})
 	}
 }
@@ -0,0 +1,52 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dhcplease
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDnsmasq reads a dnsmasq lease file: "<expiry> <mac> <ip> <hostname> <client-id>" per line, with
+// an expiry of 0 for infinite leases and "*" for no hostname. IPv6 leases follow a "duid" line and carry
+// an IAID instead of a MAC address.
+func parseDnsmasq(r io.Reader) ([]Lease, error) {
+	var set leaseSet
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		f := strings.Fields(sc.Text())
+		if len(f) == 0 || f[0] == "duid" {
+			continue
+		}
+		if len(f) < 4 {
+			return nil, fmt.Errorf("line %d: want <expiry> <mac> <ip> <hostname>", lineNo)
+		}
+		expiry, err := strconv.ParseInt(f[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", lineNo, f[0])
+		}
+		ip, err := netip.ParseAddr(f[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid address %q", lineNo, f[2])
+		}
+		l := Lease{IP: ip, MAC: normalizeMAC(f[1])}
+		if f[3] != "*" {
+			l.Hostname = f[3]
+		}
+		if expiry > 0 {
+			l.End = time.Unix(expiry, 0).UTC()
+		}
+		set.put(l, true)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return set.leases(), nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dhcplease
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseISC reads an ISC dhcpd.leases file. Only "lease <ipv4> { ... }" blocks are used; dhcpd appends a
+// new block on every change, so later blocks replace earlier ones for the same address.
+func parseISC(r io.Reader) ([]Lease, error) {
+	var set leaseSet
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var (
+		cur    *Lease
+		active bool
+		depth  int // brace depth of the block being skipped or read
+		lineNo int
+	)
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.IndexByte(line, '#'); i >= 0 && !strings.Contains(line[:i], `"`) {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		if depth == 0 {
+			if rest, ok := strings.CutPrefix(line, "lease "); ok && strings.HasSuffix(rest, "{") {
+				ip, err := netip.ParseAddr(strings.TrimSpace(strings.TrimSuffix(rest, "{")))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid lease address: %w", lineNo, err)
+				}
+				cur, active = &Lease{IP: ip}, true
+			}
+			depth += strings.Count(line, "{") - strings.Count(line, "}")
+			continue
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth == 0 {
+			if cur != nil {
+				set.put(*cur, active)
+			}
+			cur = nil
+			continue
+		}
+		if cur == nil || depth > 1 {
+			continue
+		}
+		stmt := strings.TrimSuffix(line, ";")
+		switch {
+		case strings.HasPrefix(stmt, "starts "):
+			t, err := parseISCTime(strings.TrimPrefix(stmt, "starts "))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			cur.Start = t
+		case strings.HasPrefix(stmt, "ends "):
+			t, err := parseISCTime(strings.TrimPrefix(stmt, "ends "))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			cur.End = t
+		case strings.HasPrefix(stmt, "binding state "):
+			active = strings.TrimSpace(strings.TrimPrefix(stmt, "binding state ")) == "active"
+		case strings.HasPrefix(stmt, "hardware ethernet "):
+			cur.MAC = normalizeMAC(strings.TrimPrefix(stmt, "hardware ethernet "))
+		case strings.HasPrefix(stmt, "client-hostname "):
+			if s, err := strconv.Unquote(strings.TrimSpace(strings.TrimPrefix(stmt, "client-hostname "))); err == nil {
+				cur.Hostname = s
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return set.leases(), nil
+}
+
+// parseISCTime parses "never", "epoch <unix>", or "<weekday> yyyy/mm/dd hh:mm:ss" (UTC).
+func parseISCTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "never" {
+		return time.Time{}, nil
+	}
+	if rest, ok := strings.CutPrefix(s, "epoch "); ok {
+		sec, err := strconv.ParseInt(strings.Fields(rest)[0], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid lease time %q", s)
+		}
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	f := strings.Fields(s)
+	if len(f) != 3 {
+		return time.Time{}, fmt.Errorf("invalid lease time %q", s)
+	}
+	t, err := time.Parse("2006/01/02 15:04:05", f[1]+" "+f[2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid lease time %q", s)
+	}
+	return t, nil
+}
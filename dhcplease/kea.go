@@ -0,0 +1,79 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dhcplease
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keaInfiniteLifetime is the valid_lifetime Kea writes for leases that never end.
+const keaInfiniteLifetime = 0xffffffff
+
+// parseKea reads a Kea memfile lease file (kea-leases4.csv or kea-leases6.csv). Columns are found by
+// the header row. Kea appends a row on every change, so later rows replace earlier ones; rows with a
+// state other than 0 (default) and IPv6 prefix delegations are not active leases.
+func parseKea(r io.Reader) ([]Lease, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.TrimSpace(h)] = i
+	}
+	for _, c := range []string{"address", "valid_lifetime", "expire", "hostname"} {
+		if _, ok := col[c]; !ok {
+			return nil, fmt.Errorf("missing %q column", c)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	var set leaseSet
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ip, err := netip.ParseAddr(field(row, "address"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid address %q", line, field(row, "address"))
+		}
+		lifetime, err1 := strconv.ParseUint(field(row, "valid_lifetime"), 10, 32)
+		expire, err2 := strconv.ParseInt(field(row, "expire"), 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("line %d: invalid valid_lifetime or expire", line)
+		}
+		l := Lease{
+			IP:       ip,
+			MAC:      normalizeMAC(field(row, "hwaddr")),
+			Hostname: strings.ReplaceAll(field(row, "hostname"), "&#x2c", ","),
+		}
+		if lifetime != keaInfiniteLifetime {
+			l.End = time.Unix(expire, 0).UTC()
+			l.Start = l.End.Add(-time.Duration(lifetime) * time.Second)
+		}
+		state := field(row, "state")
+		leaseType := field(row, "lease_type")
+		set.put(l, (state == "" || state == "0") && (leaseType == "" || leaseType == "0"))
+	}
+	return set.leases(), nil
+}
@@ -0,0 +1,132 @@
+// Package dhcplease reads DHCP server lease files (ISC dhcpd, dnsmasq, Kea memfile) and turns the active
+// leases into local A/AAAA and PTR records.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dhcplease
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+)
+
+// Lease file formats.
+const (
+	FormatISC     = "isc"     // ISC dhcpd dhcpd.leases (IPv4)
+	FormatDnsmasq = "dnsmasq" // dnsmasq.leases (IPv4 and IPv6)
+	FormatKea     = "kea"     // Kea memfile CSV (kea-leases4.csv / kea-leases6.csv)
+)
+
+// Lease is one active lease from a lease file.
+type Lease struct {
+	IP       netip.Addr
+	MAC      string    // lowercase, colon separated; empty when the file has none (dnsmasq and Kea IPv6)
+	Hostname string    // as sent by the client
+	Start    time.Time // zero when the file does not record it
+	End      time.Time // zero for leases that never end
+}
+
+// ActiveAt reports whether the lease is still running at now.
+func (l Lease) ActiveAt(now time.Time) bool {
+	return l.End.IsZero() || l.End.After(now)
+}
+
+// Parse reads the leases in r. Leases the server has released, expired, or declined are left out; when a
+// file lists an address several times the last entry wins.
+func Parse(format string, r io.Reader) ([]Lease, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatISC:
+		return parseISC(r)
+	case FormatDnsmasq:
+		return parseDnsmasq(r)
+	case FormatKea:
+		return parseKea(r)
+	default:
+		return nil, fmt.Errorf("dhcp: unknown lease format %q (want isc, dnsmasq, or kea)", format)
+	}
+}
+
+// ParseFile reads the leases in the file at path.
+func ParseFile(format, path string) ([]Lease, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	leases, err := Parse(format, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return leases, nil
+}
+
+// ValidFormat reports whether format names a supported lease file format.
+func ValidFormat(format string) bool {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatISC, FormatDnsmasq, FormatKea:
+		return true
+	}
+	return false
+}
+
+// leaseSet keeps the last lease seen per address in file order.
+type leaseSet struct {
+	order []netip.Addr
+	byIP  map[netip.Addr]Lease
+}
+
+func (s *leaseSet) put(l Lease, active bool) {
+	if s.byIP == nil {
+		s.byIP = make(map[netip.Addr]Lease)
+	}
+	if _, ok := s.byIP[l.IP]; !ok {
+		s.order = append(s.order, l.IP)
+	}
+	if active {
+		s.byIP[l.IP] = l
+	} else {
+		s.byIP[l.IP] = Lease{}
+	}
+}
+
+func (s *leaseSet) leases() []Lease {
+	out := make([]Lease, 0, len(s.order))
+	for _, ip := range s.order {
+		if l := s.byIP[ip]; l.IP.IsValid() {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// normalizeMAC returns s as a lowercase colon-separated hardware address, or "" when it is not one.
+func normalizeMAC(s string) string {
+	hw, err := net.ParseMAC(strings.TrimSpace(s))
+	if err != nil {
+		return ""
+	}
+	return hw.String()
+}
+
+// HostLabel turns a client-supplied hostname into a DNS label: the first label, lowercased, with anything
+// but letters, digits, and '-' replaced by '-'. It returns "" when nothing usable is left.
+func HostLabel(hostname string) string {
+	hostname, _, _ = strings.Cut(strings.TrimSpace(hostname), ".")
+	var b strings.Builder
+	for _, c := range strings.ToLower(hostname) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	label := strings.Trim(b.String(), "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	return label
+}
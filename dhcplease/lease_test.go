@@ -0,0 +1,181 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dhcplease
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"dnsplane/dnsrecords"
+)
+
+const iscLeases = `# The format of this file is documented in the dhcpd.leases(5) manual page.
+authoring-byte-order little-endian;
+
+lease 192.168.1.10 {
+  starts 6 2026/10/17 10:00:00;
+  ends 6 2026/10/17 22:00:00;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:55;
+  client-hostname "Laptop";
+}
+lease 192.168.1.11 {
+  starts 6 2026/10/17 10:00:00;
+  ends 6 2026/10/17 22:00:00;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:66;
+  client-hostname "printer";
+}
+lease 192.168.1.11 {
+  starts 6 2026/10/17 10:00:00;
+  ends 6 2026/10/17 11:00:00;
+  binding state free;
+  hardware ethernet 00:11:22:33:44:66;
+}
+lease 192.168.1.12 {
+  starts epoch 1792224000; # Sat Oct 17 08:00:00 2026
+  ends never;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:77;
+  client-hostname "nas";
+  set vendor-class-identifier = "x";
+}
+failover peer "dhcp" state {
+  my state normal at 6 2026/10/17 10:00:00;
+}
+`
+
+func TestParse(t *testing.T) {
+	isc, err := Parse(FormatISC, strings.NewReader(iscLeases))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(isc) != 2 {
+		t.Fatalf("isc: got %d leases, want 2: %+v", len(isc), isc)
+	}
+	if l := isc[0]; l.IP != netip.MustParseAddr("192.168.1.10") || l.MAC != "00:11:22:33:44:55" || l.Hostname != "Laptop" ||
+		!l.Start.Equal(time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)) || !l.End.Equal(time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC)) {
+		t.Fatalf("isc lease: %+v", l)
+	}
+	if l := isc[1]; l.Hostname != "nas" || !l.End.IsZero() || l.Start.Unix() != 1792224000 {
+		t.Fatalf("isc never-ending lease: %+v", l)
+	}
+
+	dm, err := Parse(FormatDnsmasq, strings.NewReader("1792260000 00:11:22:33:44:55 192.168.1.10 laptop 01:00:11:22:33:44:55\n"+
+		"0 00:11:22:33:44:66 192.168.1.11 * *\n"+
+		"duid 00:01:00:01:2c:aa:bb:cc:00:11:22:33:44:55\n"+
+		"1792260000 12345 2001:db8::10 laptop 00:01:00:01\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dm) != 3 || dm[0].MAC != "00:11:22:33:44:55" || dm[0].End.Unix() != 1792260000 || dm[1].Hostname != "" || !dm[1].End.IsZero() ||
+		dm[2].IP != netip.MustParseAddr("2001:db8::10") || dm[2].MAC != "" {
+		t.Fatalf("dnsmasq: %+v", dm)
+	}
+
+	kea, err := Parse(FormatKea, strings.NewReader("address,hwaddr,client_id,valid_lifetime,expire,subnet_id,fqdn_fwd,fqdn_rev,hostname,state,user_context,pool_id\n"+
+		"192.168.1.10,00:11:22:33:44:55,,3600,1792260000,1,0,0,laptop.lan.,0,,0\n"+
+		"192.168.1.11,00:11:22:33:44:66,,3600,1792260000,1,0,0,printer,0,,0\n"+
+		"192.168.1.11,00:11:22:33:44:66,,0,1792260000,1,0,0,printer,2,,0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kea) != 1 || kea[0].Hostname != "laptop.lan." || kea[0].End.Sub(kea[0].Start) != time.Hour {
+		t.Fatalf("kea: %+v", kea)
+	}
+
+	if _, err := Parse("dhcpcd", strings.NewReader("")); err == nil {
+		t.Fatal("unknown format: want error")
+	}
+}
+
+func TestHostLabel(t *testing.T) {
+	for in, want := range map[string]string{
+		"Laptop":                "laptop",
+		"laptop.lan.":           "laptop",
+		"Bob's iPhone":          "bob-s-iphone",
+		"--":                    "",
+		strings.Repeat("a", 70): strings.Repeat("a", 63),
+	} {
+		if got := HostLabel(in); got != want {
+			t.Errorf("HostLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+	lease := func(ip, mac, host string, start time.Time) Lease {
+		return Lease{IP: netip.MustParseAddr(ip), MAC: mac, Hostname: host, Start: start, End: start.Add(2 * time.Hour)}
+	}
+	manual := dnsrecords.DNSRecord{ID: "m", Name: "router.lan.example.com", Type: "A", Value: "192.168.1.1", TTL: 3600}
+	leases := []Lease{
+		lease("192.168.1.10", "00:11:22:33:44:55", "laptop", start),
+		lease("192.168.1.20", "00:11:22:33:44:66", "laptop", start.Add(time.Minute)),
+		lease("192.168.1.30", "00:11:22:33:44:77", "router", start),
+		lease("192.168.1.40", "00:11:22:33:44:88", "old", now.Add(-3*time.Hour)),
+	}
+	opt := Options{Domain: "lan.example.com.", PTR: true}
+
+	res := Reconcile([]dnsrecords.DNSRecord{manual}, leases, opt, now)
+	got := recordLines(res.Records)
+	want := []string{
+		"router.lan.example.com A 192.168.1.1 3600 ",
+		"192.168.1.10 PTR laptop.lan.example.com. 3600 dhcp",
+		"laptop.lan.example.com A 192.168.1.10 3600 dhcp",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("first-wins records:\n%s", strings.Join(got, "\n"))
+	}
+	if res.Added != 2 || res.Removed != 0 || len(res.Conflicts) != 2 {
+		t.Fatalf("first-wins result: +%d -%d %q", res.Added, res.Removed, res.Conflicts)
+	}
+
+	// The holder keeps the name even when a client with an earlier lease turns up, and unchanged
+	// records keep their id.
+	id := res.Records[2].ID
+	leases[0].Start = start.Add(time.Hour)
+	res2 := Reconcile(res.Records, leases, opt, now)
+	if res2.Changed() || res2.Records[2].ID != id {
+		t.Fatalf("resync changed records: +%d -%d %v", res2.Added, res2.Removed, recordLines(res2.Records))
+	}
+
+	// A record edited by hand is no longer DHCP's: the lease is a conflict and the record stays.
+	edited := append([]dnsrecords.DNSRecord(nil), res.Records...)
+	edited[2].AutoSource, edited[2].TTL = "", 60
+	res3 := Reconcile(edited, leases, opt, now)
+	if res3.Added != 0 || res3.Removed != 1 || res3.Records[1].TTL != 60 {
+		t.Fatalf("edited record: +%d -%d %v", res3.Added, res3.Removed, recordLines(res3.Records))
+	}
+
+	// Without a holder the earliest lease wins; the other client gets its MAC as a suffix.
+	opt.Policy, opt.PTR = PolicySuffixMAC, false
+	got = recordLines(Reconcile([]dnsrecords.DNSRecord{manual}, leases, opt, now).Records)
+	want = []string{
+		"router.lan.example.com A 192.168.1.1 3600 ",
+		"laptop-001122334455.lan.example.com A 192.168.1.10 3600 dhcp",
+		"laptop.lan.example.com A 192.168.1.20 3600 dhcp",
+		"router-001122334477.lan.example.com A 192.168.1.30 3600 dhcp",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("suffix-mac records:\n%s", strings.Join(got, "\n"))
+	}
+
+	opt.Policy = PolicyReject
+	res = Reconcile(res.Records, leases, opt, now)
+	if len(res.Records) != 1 || res.Removed != 2 || len(res.Conflicts) != 2 {
+		t.Fatalf("reject: -%d %q %v", res.Removed, res.Conflicts, recordLines(res.Records))
+	}
+}
+
+func recordLines(records []dnsrecords.DNSRecord) []string {
+	out := make([]string, len(records))
+	for i, r := range records {
+		out[i] = strings.Join([]string{r.Name, r.Type, r.Value, strconv.FormatUint(uint64(r.TTL), 10), r.AutoSource}, " ")
+	}
+	return out
+}
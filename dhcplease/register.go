@@ -0,0 +1,259 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dhcplease
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"dnsplane/dnsrecords"
+
+	"github.com/miekg/dns"
+)
+
+// Conflict policies for a hostname wanted by several clients, or by a client and a manual record.
+const (
+	PolicyFirstWins = "first-wins" // the client that registered the name first keeps it; others get nothing
+	PolicySuffixMAC = "suffix-mac" // others are registered as <hostname>-<mac>
+	PolicyReject    = "reject"     // a contested name is not registered for anyone
+)
+
+// DefaultMaxTTL caps the TTL of DHCP records when Options.MaxTTL is 0.
+const DefaultMaxTTL = 3600
+
+// ValidPolicy reports whether p names a conflict policy ("" means first-wins).
+func ValidPolicy(p string) bool {
+	switch p {
+	case "", PolicyFirstWins, PolicySuffixMAC, PolicyReject:
+		return true
+	}
+	return false
+}
+
+// Options control how leases become records.
+type Options struct {
+	Domain string // records are named <hostname>.<domain>
+	Policy string // conflict policy; "" is first-wins
+	PTR    bool   // also register a PTR record per address
+	MaxTTL uint32 // upper bound for the TTL, which is otherwise the lease lifetime
+}
+
+// Result is the outcome of Reconcile.
+type Result struct {
+	// Records holds the records not registered from DHCP, in their original order, followed by the
+	// DHCP records for the active leases.
+	Records        []dnsrecords.DNSRecord
+	Added, Removed int
+	// Conflicts describes, one line per hostname, leases that were not registered as requested.
+	Conflicts []string
+}
+
+// Changed reports whether Reconcile added or removed any record.
+func (r Result) Changed() bool {
+	return r.Added > 0 || r.Removed > 0
+}
+
+type client struct {
+	id     string // MAC address, or the IP address when the lease file has no MAC
+	mac    string
+	leases []Lease
+}
+
+// Reconcile replaces the DHCP records in records with those for the leases active at now. Records
+// without AutoSourceDHCP (added by hand, or DHCP records edited since) are never changed, and a lease
+// whose name such a record already uses is handled by the conflict policy. DHCP records that match a
+// lease keep their id and TTL.
+func Reconcile(records []dnsrecords.DNSRecord, leases []Lease, opt Options, now time.Time) Result {
+	domain := strings.Trim(strings.ToLower(strings.TrimSpace(opt.Domain)), ".")
+	maxTTL := opt.MaxTTL
+	if maxTTL == 0 {
+		maxTTL = DefaultMaxTTL
+	}
+	var res Result
+	manualNames := make(map[string]bool)
+	manualPTRs := make(map[string]bool)
+	old := make(map[string]dnsrecords.DNSRecord)
+	holders := make(map[string]string)
+	for _, r := range records {
+		typ := dnsrecords.NormalizeRecordType(r.Type)
+		name := dnsrecords.NormalizeRecordNameKey(r.Name)
+		if r.AutoSource == dnsrecords.AutoSourceDHCP {
+			old[recordKey(r)] = r
+			if typ == "A" || typ == "AAAA" {
+				holders[name] = cmp.Or(r.MACAddress, r.Value)
+			}
+			continue
+		}
+		res.Records = append(res.Records, r)
+		switch typ {
+		case "A", "AAAA", "CNAME", "ALIAS", "ANAME":
+			manualNames[name] = true
+		case "PTR":
+			manualPTRs[name] = true
+		}
+	}
+
+	byLabel := make(map[string][]*client)
+	for _, l := range leases {
+		label := HostLabel(l.Hostname)
+		if !l.ActiveAt(now) || label == "" || !l.IP.IsValid() {
+			continue
+		}
+		id := cmp.Or(l.MAC, l.IP.String())
+		i := slices.IndexFunc(byLabel[label], func(c *client) bool { return c.id == id })
+		if i < 0 {
+			byLabel[label] = append(byLabel[label], &client{id: id, mac: l.MAC})
+			i = len(byLabel[label]) - 1
+		}
+		byLabel[label][i].leases = append(byLabel[label][i].leases, l)
+	}
+
+	var registered []dnsrecords.DNSRecord
+	register := func(name string, c *client) {
+		for _, l := range c.leases {
+			typ := "A"
+			if l.IP.Is6() {
+				typ = "AAAA"
+			}
+			ttl := leaseTTL(l, maxTTL, now)
+			registered = append(registered, dnsrecords.DNSRecord{Name: name, Type: typ, Value: l.IP.String(), TTL: ttl, MACAddress: c.mac})
+			if opt.PTR && !manualPTRs[l.IP.String()] {
+				registered = append(registered, dnsrecords.DNSRecord{Name: l.IP.String(), Type: "PTR", Value: dns.Fqdn(name), TTL: ttl, MACAddress: c.mac})
+			}
+		}
+	}
+	suffixed := func(label string, c *client) {
+		if c.mac == "" {
+			res.Conflicts = append(res.Conflicts, fmt.Sprintf("%s.%s: %s has no MAC address to add as a suffix; not registered", label, domain, c.id))
+			return
+		}
+		hex := strings.ReplaceAll(c.mac, ":", "")
+		name := strings.TrimRight(label[:min(len(label), 62-len(hex))], "-") + "-" + hex + "." + domain
+		if manualNames[name] {
+			res.Conflicts = append(res.Conflicts, fmt.Sprintf("%s: already used by a manual record; not registered", name))
+			return
+		}
+		register(name, c)
+	}
+
+	labels := make([]string, 0, len(byLabel))
+	for label := range byLabel {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+	for _, label := range labels {
+		name := label + "." + domain
+		clients := byLabel[label]
+		holder := holders[name]
+		slices.SortStableFunc(clients, func(a, b *client) int {
+			if (a.id == holder) != (b.id == holder) {
+				if a.id == holder {
+					return -1
+				}
+				return 1
+			}
+			return cmp.Or(compareStart(earliestStart(a), earliestStart(b)), strings.Compare(a.id, b.id))
+		})
+		switch {
+		case manualNames[name] && opt.Policy == PolicySuffixMAC:
+			for _, c := range clients {
+				suffixed(label, c)
+			}
+		case manualNames[name]:
+			res.Conflicts = append(res.Conflicts, fmt.Sprintf("%s: already used by a manual record; lease of %s not registered", name, clientIDs(clients)))
+		case len(clients) == 1:
+			register(name, clients[0])
+		case opt.Policy == PolicyReject:
+			res.Conflicts = append(res.Conflicts, fmt.Sprintf("%s: requested by %s; not registered", name, clientIDs(clients)))
+		case opt.Policy == PolicySuffixMAC:
+			register(name, clients[0])
+			for _, c := range clients[1:] {
+				suffixed(label, c)
+			}
+		default:
+			register(name, clients[0])
+			for _, c := range clients[1:] {
+				res.Conflicts = append(res.Conflicts, fmt.Sprintf("%s: held by %s; lease of %s not registered", name, clients[0].id, c.id))
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(registered))
+	auto := make([]dnsrecords.DNSRecord, 0, len(registered))
+	for _, r := range registered {
+		key := recordKey(r)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if prev, ok := old[key]; ok && prev.MACAddress == r.MACAddress {
+			auto = append(auto, prev)
+			delete(old, key)
+			continue
+		}
+		r.ID = dnsrecords.NewRecordID()
+		r.AddedOn = now
+		r.AutoSource = dnsrecords.AutoSourceDHCP
+		auto = append(auto, r)
+		res.Added++
+	}
+	res.Removed = len(old)
+	slices.SortFunc(auto, func(a, b dnsrecords.DNSRecord) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.Type, b.Type), strings.Compare(a.Value, b.Value))
+	})
+	res.Records = append(res.Records, auto...)
+	return res
+}
+
+func recordKey(r dnsrecords.DNSRecord) string {
+	typ := dnsrecords.NormalizeRecordType(r.Type)
+	return dnsrecords.NormalizeRecordNameKey(r.Name) + "|" + typ + "|" + dnsrecords.NormalizeRecordValueKey(typ, r.Value)
+}
+
+func clientIDs(clients []*client) string {
+	ids := make([]string, len(clients))
+	for i, c := range clients {
+		ids[i] = c.id
+	}
+	return strings.Join(ids, ", ")
+}
+
+func earliestStart(c *client) time.Time {
+	var t time.Time
+	for _, l := range c.leases {
+		if !l.Start.IsZero() && (t.IsZero() || l.Start.Before(t)) {
+			t = l.Start
+		}
+	}
+	return t
+}
+
+// compareStart orders lease start times, unknown (zero) ones last.
+func compareStart(a, b time.Time) int {
+	switch {
+	case a.IsZero() && b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	}
+	return a.Compare(b)
+}
+
+// leaseTTL is the lease lifetime in seconds (the remaining time when the start is unknown), between 1
+// and maxTTL.
+func leaseTTL(l Lease, maxTTL uint32, now time.Time) uint32 {
+	if l.End.IsZero() {
+		return maxTTL
+	}
+	d := l.End.Sub(l.Start)
+	if l.Start.IsZero() {
+		d = l.End.Sub(now)
+	}
+	secs := int64(d / time.Second)
+	return uint32(max(1, min(secs, int64(maxTTL))))
+}
@@ -0,0 +1,130 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dnscookie
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestSipHash24Vectors(t *testing.T) {
+	// Reference vectors from the SipHash paper: key 00..0f, message 00..(n-1).
+	k0 := uint64(0x0706050403020100)
+	k1 := uint64(0x0f0e0d0c0b0a0908)
+	msg := make([]byte, 15)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	if got := sipHash24(k0, k1, nil); got != 0x726fdb47dd0e0e31 {
+		t.Fatalf("empty: got %#x", got)
+	}
+	if got := sipHash24(k0, k1, msg); got != 0xa129ca6149be45e5 {
+		t.Fatalf("15 bytes: got %#x", got)
+	}
+}
+
+func TestServerIssueVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := NewServer(time.Hour)
+	s.now = func() time.Time { return now }
+	client := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	sc := s.Issue(client, "192.0.2.1")
+	if len(sc) != ServerCookieLen || sc[0] != 1 {
+		t.Fatalf("bad cookie %x", sc)
+	}
+	if !s.Verify(client, sc, "192.0.2.1") {
+		t.Fatal("fresh cookie should verify")
+	}
+	if !s.Verify(client, sc, "::ffff:192.0.2.1") {
+		t.Fatal("mapped address should verify like the IPv4 address")
+	}
+	if s.Verify(client, sc, "192.0.2.2") {
+		t.Fatal("cookie must be bound to client IP")
+	}
+	if s.Verify([]byte{8, 7, 6, 5, 4, 3, 2, 1}, sc, "192.0.2.1") {
+		t.Fatal("cookie must be bound to client cookie")
+	}
+
+	// One rotation keeps the old cookie valid; a second one retires it.
+	now = now.Add(30 * time.Minute)
+	s.Rotate()
+	if !s.Verify(client, sc, "192.0.2.1") {
+		t.Fatal("previous secret should still verify")
+	}
+	s.Rotate()
+	if s.Verify(client, sc, "192.0.2.1") {
+		t.Fatal("secret two rotations old should not verify")
+	}
+
+	now = now.Add(2 * time.Hour)
+	old := s.Issue(client, "192.0.2.1")
+	now = now.Add(61 * time.Minute)
+	if s.Verify(client, old, "192.0.2.1") {
+		t.Fatal("cookie older than one hour should not verify")
+	}
+}
+
+func TestServerMergeConverges(t *testing.T) {
+	a := NewServer(time.Hour)
+	b := NewServer(time.Hour)
+	client := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	fromA := a.Issue(client, "198.51.100.7")
+
+	a.Rotate()
+	if !b.Merge(a.Snapshot()) {
+		t.Fatal("b should adopt a's rotated secret")
+	}
+	if a.Merge(b.Snapshot()) {
+		t.Fatal("a already has the newest secret")
+	}
+	if !b.Verify(client, fromA, "198.51.100.7") {
+		t.Fatal("b should verify cookies issued by a before rotation")
+	}
+	if !bytes.Equal(a.Snapshot().Current, b.Snapshot().Current) {
+		t.Fatal("secrets did not converge")
+	}
+	if b.Merge(Snapshot{Current: []byte{1}, RotatedUnix: 1 << 40}) {
+		t.Fatal("short secret must be rejected")
+	}
+}
+
+func TestParseAndJar(t *testing.T) {
+	q := new(dns.Msg)
+	q.SetQuestion("example.com.", dns.TypeA)
+	if _, _, ok, _ := Parse(q); ok {
+		t.Fatal("no OPT means no cookie")
+	}
+
+	jar := NewJar()
+	client := jar.Prepare(q, "192.0.2.53:53", 1232)
+	c, s, ok, err := Parse(q)
+	if !ok || err != nil || !bytes.Equal(c, client) || s != nil {
+		t.Fatalf("first query: client=%x server=%x ok=%v err=%v", c, s, ok, err)
+	}
+
+	srv := NewServer(time.Hour)
+	resp := new(dns.Msg)
+	resp.SetReply(q)
+	resp.SetEdns0(1232, false)
+	sc := srv.Issue(client, "203.0.113.9")
+	SetOption(resp, client, sc)
+	jar.Observe("192.0.2.53:53", client, resp)
+
+	q2 := new(dns.Msg)
+	q2.SetQuestion("example.com.", dns.TypeA)
+	jar.Prepare(q2, "192.0.2.53:53", 1232)
+	if _, s2, _, _ := Parse(q2); !bytes.Equal(s2, sc) {
+		t.Fatalf("second query should echo server cookie, got %x", s2)
+	}
+
+	bad := new(dns.Msg)
+	bad.SetEdns0(1232, false)
+	bad.IsEdns0().Option = append(bad.IsEdns0().Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708aa"})
+	if _, _, ok, err := Parse(bad); !ok || err != ErrMalformed {
+		t.Fatalf("1-byte server cookie: ok=%v err=%v", ok, err)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dnscookie
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// maxJarEntries caps per-server state; new servers beyond it get a fresh cookie on every query.
+const maxJarEntries = 4096
+
+// Jar holds outbound client cookies per upstream server and remembers the server cookie each
+// upstream returned, so later queries can present both (RFC 7873 §5.1).
+type Jar struct {
+	mu sync.Mutex
+	m  map[string]*jarEntry
+}
+
+type jarEntry struct {
+	client []byte
+	server []byte
+}
+
+// NewJar creates an empty client cookie jar.
+func NewJar() *Jar {
+	return &Jar{m: make(map[string]*jarEntry)}
+}
+
+// Prepare adds a COOKIE option for server to msg (adding EDNS with udpSize if msg has none) and
+// returns the client cookie used, for Observe.
+func (j *Jar) Prepare(msg *dns.Msg, server string, udpSize uint16) []byte {
+	if j == nil || msg == nil {
+		return nil
+	}
+	j.mu.Lock()
+	e := j.m[server]
+	if e == nil {
+		e = &jarEntry{client: randomBytes(ClientCookieLen)}
+		if len(j.m) < maxJarEntries {
+			j.m[server] = e
+		}
+	}
+	client := e.client
+	srv := e.server
+	j.mu.Unlock()
+
+	if msg.IsEdns0() == nil {
+		msg.SetEdns0(udpSize, false)
+	}
+	SetOption(msg, client, srv)
+	return client
+}
+
+// Observe records the server cookie from resp when it echoes client. A response whose client
+// cookie does not match is ignored (it may be spoofed).
+func (j *Jar) Observe(server string, client []byte, resp *dns.Msg) {
+	if j == nil || resp == nil || client == nil {
+		return
+	}
+	c, s, ok, err := Parse(resp)
+	if !ok || err != nil || !bytes.Equal(c, client) || s == nil {
+		return
+	}
+	j.mu.Lock()
+	if e := j.m[server]; e != nil && bytes.Equal(e.client, client) {
+		e.server = append([]byte(nil), s...)
+	}
+	j.mu.Unlock()
+}
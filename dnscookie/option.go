@@ -0,0 +1,62 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dnscookie
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/miekg/dns"
+)
+
+// ErrMalformed is returned by Parse for a COOKIE option with invalid lengths (answered with FORMERR).
+var ErrMalformed = errors.New("dnscookie: malformed COOKIE option")
+
+// Parse returns the client and server cookies from m's EDNS COOKIE option. ok is false when the
+// message carries no COOKIE option; server is nil for a client-cookie-only query.
+func Parse(m *dns.Msg) (client, server []byte, ok bool, err error) {
+	if m == nil {
+		return nil, nil, false, nil
+	}
+	opt := m.IsEdns0()
+	if opt == nil {
+		return nil, nil, false, nil
+	}
+	for _, o := range opt.Option {
+		c, isCookie := o.(*dns.EDNS0_COOKIE)
+		if !isCookie {
+			continue
+		}
+		raw, err := hex.DecodeString(c.Cookie)
+		if err != nil || len(raw) < ClientCookieLen {
+			return nil, nil, true, ErrMalformed
+		}
+		client = raw[:ClientCookieLen]
+		if rest := raw[ClientCookieLen:]; len(rest) > 0 {
+			if len(rest) < MinServerCookieLen || len(rest) > MaxServerCookieLen {
+				return nil, nil, true, ErrMalformed
+			}
+			server = rest
+		}
+		return client, server, true, nil
+	}
+	return nil, nil, false, nil
+}
+
+// SetOption replaces any COOKIE option in m with client||server. m must already carry an OPT record.
+func SetOption(m *dns.Msg, client, server []byte) {
+	opt := m.IsEdns0()
+	if opt == nil {
+		return
+	}
+	kept := opt.Option[:0]
+	for _, o := range opt.Option {
+		if _, isCookie := o.(*dns.EDNS0_COOKIE); !isCookie {
+			kept = append(kept, o)
+		}
+	}
+	raw := make([]byte, 0, len(client)+len(server))
+	raw = append(raw, client...)
+	raw = append(raw, server...)
+	opt.Option = append(kept, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: hex.EncodeToString(raw)})
+}
@@ -0,0 +1,197 @@
+// Package dnscookie implements DNS Cookies (RFC 7873) with interoperable server cookies (RFC 9018).
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dnscookie
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cookie sizes. Server cookies issued here are always the 16-byte RFC 9018 format; RFC 7873 allows 8–32.
+const (
+	ClientCookieLen    = 8
+	ServerCookieLen    = 16
+	MinServerCookieLen = 8
+	MaxServerCookieLen = 32
+	SecretLen          = 16
+)
+
+// DefaultRotation is the secret rotation interval when none is configured.
+const DefaultRotation = time.Hour
+
+const (
+	cookieVersion = 1
+	// RFC 9018 §4.3: reject cookies older than one hour or more than five minutes in the future.
+	maxCookieAge    = time.Hour
+	maxCookieFuture = 5 * time.Minute
+)
+
+// Snapshot is the exportable secret state shared between cluster nodes.
+type Snapshot struct {
+	Current  []byte `json:"current"`
+	Previous []byte `json:"previous,omitempty"`
+	// RotatedUnix is when Current was generated; 0 for the boot secret (any rotated secret wins).
+	RotatedUnix int64 `json:"rotated_unix,omitempty"`
+}
+
+// Server issues and verifies server cookies. The secret rotates lazily on Issue once the interval
+// has elapsed; cookies made with the previous secret stay valid until the next rotation.
+type Server struct {
+	mu          sync.RWMutex
+	current     []byte
+	previous    []byte
+	rotatedUnix int64
+	since       time.Time
+	interval    time.Duration
+	now         func() time.Time
+}
+
+// NewServer creates a Server with a random secret. interval <= 0 uses DefaultRotation.
+func NewServer(interval time.Duration) *Server {
+	s := &Server{now: time.Now}
+	s.current = randomBytes(SecretLen)
+	s.since = s.now()
+	s.SetRotationInterval(interval)
+	return s
+}
+
+// SetRotationInterval changes how often the secret rotates (<= 0 uses DefaultRotation).
+func (s *Server) SetRotationInterval(d time.Duration) {
+	if d <= 0 {
+		d = DefaultRotation
+	}
+	s.mu.Lock()
+	s.interval = d
+	s.mu.Unlock()
+}
+
+// Rotate replaces the current secret immediately, keeping the old one as previous.
+func (s *Server) Rotate() {
+	s.mu.Lock()
+	s.rotateLocked(s.now())
+	s.mu.Unlock()
+}
+
+func (s *Server) rotateLocked(now time.Time) {
+	s.previous = s.current
+	s.current = randomBytes(SecretLen)
+	s.since = now
+	s.rotatedUnix = now.Unix()
+}
+
+// Issue returns a fresh 16-byte server cookie for clientCookie and clientIP.
+func (s *Server) Issue(clientCookie []byte, clientIP string) []byte {
+	now := s.now()
+	s.mu.RLock()
+	due := now.Sub(s.since) >= s.interval
+	s.mu.RUnlock()
+	if due {
+		s.mu.Lock()
+		if now.Sub(s.since) >= s.interval {
+			s.rotateLocked(now)
+		}
+		s.mu.Unlock()
+	}
+	s.mu.RLock()
+	secret := s.current
+	s.mu.RUnlock()
+	return makeCookie(secret, clientCookie, clientIP, uint32(now.Unix()))
+}
+
+// Verify reports whether serverCookie was issued by this server (current or previous secret)
+// for clientCookie and clientIP within the allowed time window.
+func (s *Server) Verify(clientCookie, serverCookie []byte, clientIP string) bool {
+	if len(clientCookie) != ClientCookieLen || len(serverCookie) != ServerCookieLen || serverCookie[0] != cookieVersion {
+		return false
+	}
+	ts := binary.BigEndian.Uint32(serverCookie[4:8])
+	// Serial-number arithmetic (RFC 1982) so the check survives the 2106 wrap.
+	now := uint32(s.now().Unix())
+	age := int64(int32(now - ts))
+	if age > int64(maxCookieAge/time.Second) || -age > int64(maxCookieFuture/time.Second) {
+		return false
+	}
+	s.mu.RLock()
+	cur, prev := s.current, s.previous
+	s.mu.RUnlock()
+	if bytes.Equal(makeCookie(cur, clientCookie, clientIP, ts), serverCookie) {
+		return true
+	}
+	return prev != nil && bytes.Equal(makeCookie(prev, clientCookie, clientIP, ts), serverCookie)
+}
+
+// Snapshot returns a copy of the secret state for cluster sharing.
+func (s *Server) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Snapshot{
+		Current:     append([]byte(nil), s.current...),
+		Previous:    append([]byte(nil), s.previous...),
+		RotatedUnix: s.rotatedUnix,
+	}
+}
+
+// Merge adopts a peer's secret when it is newer (by RotatedUnix, ties broken by comparing secrets) so
+// every node converges on the same secret. Reports whether the local state changed.
+func (s *Server) Merge(o Snapshot) bool {
+	if len(o.Current) != SecretLen || (len(o.Previous) != 0 && len(o.Previous) != SecretLen) {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o.RotatedUnix < s.rotatedUnix {
+		return false
+	}
+	if o.RotatedUnix == s.rotatedUnix && bytes.Compare(o.Current, s.current) <= 0 {
+		return false
+	}
+	prev := append([]byte(nil), o.Previous...)
+	if len(prev) == 0 {
+		// Keep honouring cookies we already handed out with our own secret.
+		prev = s.current
+	}
+	s.current = append([]byte(nil), o.Current...)
+	s.previous = prev
+	s.rotatedUnix = o.RotatedUnix
+	if o.RotatedUnix > 0 {
+		s.since = time.Unix(o.RotatedUnix, 0)
+	}
+	return true
+}
+
+// makeCookie builds Version | Reserved | Timestamp | SipHash-2-4(Client Cookie | Version | Reserved | Timestamp | Client-IP).
+func makeCookie(secret, clientCookie []byte, clientIP string, ts uint32) []byte {
+	out := make([]byte, ServerCookieLen)
+	out[0] = cookieVersion
+	binary.BigEndian.PutUint32(out[4:8], ts)
+
+	in := make([]byte, 0, len(clientCookie)+8+16)
+	in = append(in, clientCookie...)
+	in = append(in, out[:8]...)
+	in = append(in, ipBytes(clientIP)...)
+
+	k0 := binary.LittleEndian.Uint64(secret[0:8])
+	k1 := binary.LittleEndian.Uint64(secret[8:16])
+	binary.LittleEndian.PutUint64(out[8:], sipHash24(k0, k1, in))
+	return out
+}
+
+func ipBytes(s string) []byte {
+	ip, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return []byte(s)
+	}
+	return ip.Unmap().AsSlice()
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return b
+}
@@ -33,7 +33,26 @@ type DNSRecord struct {
 	MACAddress  string    `json:"mac,omitempty"`
 	CacheRecord bool      `json:"cache_record,omitempty"`
 	LastQuery   time.Time `json:"last_query,omitempty"`
-}
+	// Weight and Priority choose among several values of one name and type: the lowest priority with a
+	// healthy value is served, one value per answer in weighted rotation once any weight is set (default 1).
+	Weight      int          `json:"weight,omitempty"`
+	Priority    int          `json:"priority,omitempty"`
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+	// Geo answers this value only to clients from matching locations (A, AAAA, CNAME).
+	Geo *GeoSelector `json:"geo,omitempty"`
+	// Tags, Owner, and Comment are free-form notes for people; they never affect answers.
+	Tags    []string `json:"tags,omitempty"`
+	Owner   string   `json:"owner,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+	// ExpiresAt, when set, is the time after which the record is removed.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	// AutoSource names the subsystem that registered the record (AutoSourceDHCP). Editing the record
+	// through the API or TUI clears it, and the subsystem leaves the record alone from then on.
+	AutoSource string `json:"auto_source,omitempty"`
+}
+
+// AutoSourceDHCP marks records registered from DHCP leases.
+const AutoSourceDHCP = "dhcp"
 
 var (
 	// ErrHelpRequested indicates the caller asked for usage information.
@@ -114,7 +133,35 @@ func findDNSRecordIndex(dnsRecords []DNSRecord, name, recordType, value string)
 }
 
 func normalizeRecordType(recordType string) string {
-	return strings.ToUpper(strings.TrimSpace(recordType))
+	t := strings.ToUpper(strings.TrimSpace(recordType))
+	if code, ok := genericTypeCode(t); ok {
+		if name := dns.TypeToString[code]; name != "" {
+			return name
+		}
+	}
+	return t
+}
+
+// IsFlattenedType reports whether recordType is the ALIAS/ANAME pseudo type: the target is resolved and
+// served as A/AAAA at the owner name, so it may sit at a zone apex where CNAME is not allowed.
+func IsFlattenedType(recordType string) bool {
+	switch normalizeRecordType(recordType) {
+	case "ALIAS", "ANAME":
+		return true
+	}
+	return false
+}
+
+// IsValidRecordType reports whether recordType is a DNS type or a pseudo type stored in dnsrecords.json.
+func IsValidRecordType(recordType string) bool {
+	nt := normalizeRecordType(recordType)
+	if _, ok := dns.StringToType[nt]; ok {
+		return true
+	}
+	if _, ok := genericTypeCode(nt); ok {
+		return true
+	}
+	return IsFlattenedType(nt)
 }
 
 // canonicalizeRecordNameForStorage trims space and strips trailing dots so stored names are consistent (no FQDN trailing dot).
@@ -141,11 +188,14 @@ func normalizeRecordValueKey(recordType, value string) string {
 		return value
 	}
 	switch recordType {
-	case "CNAME", "NS", "PTR":
+	case "CNAME", "DNAME", "NS", "PTR", "ALIAS", "ANAME":
 		return normalizeRecordNameKey(value)
 	case "A", "AAAA":
 		return strings.ToLower(value)
 	default:
+		if canon, err := ParseRData(recordType, value); err == nil {
+			return canon
+		}
 		return value
 	}
 }
@@ -163,25 +213,32 @@ func NormalizeRecordValueKey(recordType, value string) string {
 	return normalizeRecordValueKey(recordType, value)
 }
 
-// FilterRecords returns records matching optional name substring and optional exact DNS type.
+// FilterRecords returns records matching optional name substring, optional exact DNS type, and selectors.
 // nameSubstr: if non-empty, the normalized record owner name must contain the normalized substring.
 // typ: if non-empty, must be a valid DNS type (case-insensitive); the record's type must match.
-// Both conditions are combined with AND when both are set.
-func FilterRecords(records []DNSRecord, nameSubstr, typ string) ([]DNSRecord, error) {
+// selectors: "tag=<tag>" (the record carries the tag), "owner=<owner>" (case-insensitive), or "expires"
+// (the record has an expiry).
+// All conditions are combined with AND.
+func FilterRecords(records []DNSRecord, nameSubstr, typ string, selectors ...string) ([]DNSRecord, error) {
 	typ = strings.TrimSpace(typ)
 	if typ != "" {
 		nt := normalizeRecordType(typ)
-		if _, ok := dns.StringToType[nt]; !ok {
+		if !IsValidRecordType(nt) {
 			return nil, fmt.Errorf("%w: invalid DNS type %q", ErrInvalidArgs, typ)
 		}
 		typ = nt
 	}
+	sels, err := parseSelectors(selectors)
+	if err != nil {
+		return nil, err
+	}
 	needle := strings.TrimSpace(nameSubstr)
 	var nameKeyNeedle string
 	if needle != "" {
 		nameKeyNeedle = normalizeRecordNameKey(needle)
 	}
 	out := make([]DNSRecord, 0)
+records:
 	for _, r := range records {
 		if typ != "" && normalizeRecordType(r.Type) != typ {
 			continue
@@ -189,6 +246,11 @@ func FilterRecords(records []DNSRecord, nameSubstr, typ string) ([]DNSRecord, er
 		if nameKeyNeedle != "" && !strings.Contains(normalizeRecordNameKey(r.Name), nameKeyNeedle) {
 			continue
 		}
+		for _, s := range sels {
+			if !s.match(r) {
+				continue records
+			}
+		}
 		out = append(out, r)
 	}
 	return out, nil
@@ -202,7 +264,7 @@ func ApplyLegacyListFilter(records []DNSRecord, filter string) []DNSRecord {
 		return records
 	}
 	if t := normalizeRecordType(filter); t != "" {
-		if _, ok := dns.StringToType[t]; ok {
+		if IsValidRecordType(t) {
 			out, _ := FilterRecords(records, "", t)
 			return out
 		}
@@ -237,7 +299,7 @@ func AddRecord(record DNSRecord, dnsRecords []DNSRecord, allowUpdate bool) ([]DN
 		return dnsRecords, []Message{msg}, ErrInvalidArgs
 	}
 
-	if _, ok := dns.StringToType[record.Type]; !ok {
+	if !IsValidRecordType(record.Type) {
 		msg := Message{Level: LevelError, Text: fmt.Sprintf("invalid DNS record type: %s", record.Type)}
 		return dnsRecords, []Message{msg}, ErrInvalidArgs
 	}
@@ -250,6 +312,12 @@ func AddRecord(record DNSRecord, dnsRecords []DNSRecord, allowUpdate bool) ([]DN
 		msg := Message{Level: LevelError, Text: err.Error()}
 		return dnsRecords, []Message{msg}, ErrInvalidArgs
 	}
+	if err := validateSelection(record); err != nil {
+		return dnsRecords, []Message{{Level: LevelError, Text: err.Error()}}, ErrInvalidArgs
+	}
+	if err := normalizeMetadata(&record, time.Now()); err != nil {
+		return dnsRecords, []Message{{Level: LevelError, Text: err.Error()}}, ErrInvalidArgs
+	}
 
 	record.AddedOn = time.Now()
 	return addRecordInternal(record, dnsRecords, allowUpdate)
@@ -327,6 +395,17 @@ func List(dnsRecords []DNSRecord, args []string) (ListResult, error) {
 		return result, ErrHelpRequested
 	}
 
+	var selectors []string
+	rest := make([]string, 0, len(args))
+	for _, a := range args {
+		if IsSelector(a) {
+			selectors = append(selectors, a)
+		} else {
+			rest = append(rest, a)
+		}
+	}
+	args = rest
+
 	if len(args) > 0 {
 		if args[0] == "details" || args[0] == "d" {
 			result.Detailed = true
@@ -342,6 +421,15 @@ func List(dnsRecords []DNSRecord, args []string) (ListResult, error) {
 		result.Messages = append(result.Messages, Message{Level: LevelInfo, Text: fmt.Sprintf("Filtering records by: %s", result.Filter)})
 		result.Records = ApplyLegacyListFilter(result.Records, result.Filter)
 	}
+	if len(selectors) > 0 {
+		selected, err := FilterRecords(result.Records, "", "", selectors...)
+		if err != nil {
+			result.Messages = append(result.Messages, Message{Level: LevelError, Text: err.Error()})
+			return result, err
+		}
+		result.Messages = append(result.Messages, Message{Level: LevelInfo, Text: fmt.Sprintf("Selecting records by: %s", strings.Join(selectors, " "))})
+		result.Records = selected
+	}
 
 	if len(result.Records) == 0 {
 		result.Messages = append(result.Messages, Message{Level: LevelInfo, Text: "No records found."})
@@ -454,7 +542,7 @@ func UpdateRecordByID(id string, in DNSRecord, dnsRecords []DNSRecord) ([]DNSRec
 	if in.Name == "" || in.Type == "" || in.Value == "" {
 		return dnsRecords, []Message{{Level: LevelError, Text: "name, type, and value are required"}}, ErrInvalidArgs
 	}
-	if _, ok := dns.StringToType[in.Type]; !ok {
+	if !IsValidRecordType(in.Type) {
 		return dnsRecords, []Message{{Level: LevelError, Text: fmt.Sprintf("invalid DNS record type: %s", in.Type)}}, ErrInvalidArgs
 	}
 	if in.TTL == 0 {
@@ -463,6 +551,12 @@ func UpdateRecordByID(id string, in DNSRecord, dnsRecords []DNSRecord) ([]DNSRec
 	if err := validateRecordValue(in.Type, in.Value); err != nil {
 		return dnsRecords, []Message{{Level: LevelError, Text: err.Error()}}, ErrInvalidArgs
 	}
+	if err := validateSelection(in); err != nil {
+		return dnsRecords, []Message{{Level: LevelError, Text: err.Error()}}, ErrInvalidArgs
+	}
+	if err := normalizeMetadata(&in, time.Now()); err != nil {
+		return dnsRecords, []Message{{Level: LevelError, Text: err.Error()}}, ErrInvalidArgs
+	}
 
 	other := findDNSRecordIndex(dnsRecords, in.Name, in.Type, in.Value)
 	if other != -1 && other != idx {
@@ -509,11 +603,13 @@ func checkHelpCommand(fullCommand []string) bool {
 
 func usageAdd() []Message {
 	msgs := []Message{
-		{Level: LevelInfo, Text: "Usage  : add <Name> [Type] <Value> [TTL]"},
+		{Level: LevelInfo, Text: "Usage  : add <Name> [Type] <Value> [TTL] [tag=<tag>[,<tag>]] [owner=<owner>] [comment=<text>] [expires=<time|duration>]"},
 		{Level: LevelInfo, Text: "Examples:"},
 		{Level: LevelInfo, Text: "  add example.com 127.0.0.1"},
 		{Level: LevelInfo, Text: "  add example.com A 127.0.0.1"},
 		{Level: LevelInfo, Text: "  add example.com A 127.0.0.1 3600"},
+		{Level: LevelInfo, Text: "  add example.com ALIAS lb-1234.elb.amazonaws.com 300"},
+		{Level: LevelInfo, Text: "  add old.example.com A 192.0.2.7 tag=migration owner=netops expires=7d"},
 	}
 	return append(msgs, helpHint())
 }
@@ -530,8 +626,8 @@ func usageRemove() []Message {
 
 func usageList() []Message {
 	msgs := []Message{
-		{Level: LevelInfo, Text: "Usage  : record list [details|d] [filter]"},
-		{Level: LevelInfo, Text: "Description: List DNS records. Use 'details' to include timestamps, or provide a filter by name/type."},
+		{Level: LevelInfo, Text: "Usage  : record list [details|d] [filter] [tag=<tag>] [owner=<owner>] [expires]"},
+		{Level: LevelInfo, Text: "Description: List DNS records. Use 'details' to include timestamps and notes, provide a filter by name/type, or select by tag, owner, or expiry."},
 	}
 	return append(msgs, helpHint())
 }
@@ -576,6 +672,12 @@ func validateIPAndDomain(arg1, arg2 string) (string, string, string) {
 
 // Helper function to parse DNS record arguments and return a DNSRecord struct.
 func parseDNSRecordArgs(args []string) (DNSRecord, error) {
+	var meta DNSRecord
+	now := time.Now()
+	args, err := splitMetadataArgs(args, &meta, now)
+	if err != nil {
+		return DNSRecord{}, err
+	}
 	if len(args) < 2 {
 		return DNSRecord{}, fmt.Errorf("invalid DNS record format. Please enter the DNS record in the format: <Name> [Type] <Value> [TTL]")
 	}
@@ -613,7 +715,7 @@ func parseDNSRecordArgs(args []string) (DNSRecord, error) {
 	recordType = normalizeRecordType(recordType)
 
 	// Validate DNS record type against known types
-	if _, ok := dns.StringToType[recordType]; !ok {
+	if !IsValidRecordType(recordType) {
 		return DNSRecord{}, fmt.Errorf("invalid DNS record type: %s", recordType)
 	}
 
@@ -629,10 +731,17 @@ func parseDNSRecordArgs(args []string) (DNSRecord, error) {
 	ttl := uint32(ttl64)
 
 	dnsRecord := DNSRecord{
-		Name:  name,
-		Type:  recordType,
-		Value: value,
-		TTL:   ttl,
+		Name:      name,
+		Type:      recordType,
+		Value:     value,
+		TTL:       ttl,
+		Tags:      meta.Tags,
+		Owner:     meta.Owner,
+		Comment:   meta.Comment,
+		ExpiresAt: meta.ExpiresAt,
+	}
+	if err := normalizeMetadata(&dnsRecord, now); err != nil {
+		return DNSRecord{}, err
 	}
 
 	return dnsRecord, nil
@@ -644,10 +753,14 @@ func validateRecordValue(recordType, value string) error {
 		if !ipvalidator.IsValidIP(value) {
 			return fmt.Errorf("invalid IP address: %s", value)
 		}
-	case "CNAME", "NS", "PTR", "TXT", "SRV", "SOA", "MX", "NAPTR", "CAA", "TLSA", "DS", "DNSKEY", "RRSIG", "NSEC", "NSEC3", "NSEC3PARAM":
+	case "CNAME", "DNAME", "ALIAS", "ANAME", "NS", "PTR":
 		if _, ok := dns.IsDomainName(value); !ok {
 			return fmt.Errorf("invalid domain name: %s", value)
 		}
+	default:
+		if _, err := ParseRData(recordType, value); err != nil {
+			return fmt.Errorf("invalid %s value %q: %w", recordType, value, err)
+		}
 	}
 	return nil
 }
@@ -671,6 +784,8 @@ func FindAllRecords(dnsRecords []DNSRecord, lookupRecord, recordType string, aut
 
 	// For PTR queries, convert reverse DNS format to IP address
 	var lookupIP string
+	// ptrSeen drops a PTR target already answered, e.g. an explicit PTR that repeats one built from A.
+	ptrSeen := make(map[string]bool)
 	if recordType == "PTR" {
 		lookupIP = converters.ConvertReverseDNSToIP(lookupRecord)
 	}
@@ -686,6 +801,10 @@ func FindAllRecords(dnsRecords []DNSRecord, lookupRecord, recordType string, aut
 					if !strings.HasSuffix(ptrDomain, ".") {
 						ptrDomain += "."
 					}
+					if ptrSeen[strings.ToLower(ptrDomain)] {
+						continue
+					}
+					ptrSeen[strings.ToLower(ptrDomain)] = true
 					recordString := fmt.Sprintf("%s %d IN PTR %s", lookupRecord, record.TTL, ptrDomain)
 					rr, err := dns.NewRR(recordString)
 					if err == nil {
@@ -703,6 +822,10 @@ func FindAllRecords(dnsRecords []DNSRecord, lookupRecord, recordType string, aut
 					if !strings.HasSuffix(ptrDomain, ".") {
 						ptrDomain += "."
 					}
+					if ptrSeen[strings.ToLower(ptrDomain)] {
+						continue
+					}
+					ptrSeen[strings.ToLower(ptrDomain)] = true
 					recordString := fmt.Sprintf("%s %d IN PTR %s", lookupRecord, record.TTL, ptrDomain)
 					rr, err := dns.NewRR(recordString)
 					if err == nil {
@@ -5,6 +5,7 @@ package dnsrecords_test
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"dnsplane/dnsrecords"
 )
@@ -94,6 +95,39 @@ func TestAddRecordRejectsInvalidType(t *testing.T) {
 	}
 }
 
+func TestAddRecordAcceptsALIAS(t *testing.T) {
+	for _, typ := range []string{"ALIAS", "aname"} {
+		record := dnsrecords.DNSRecord{Name: "example.com", Type: typ, Value: "lb-1234.elb.amazonaws.com."}
+		updated, _, err := dnsrecords.AddRecord(record, nil, false)
+		if err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		if !dnsrecords.IsFlattenedType(updated[0].Type) {
+			t.Fatalf("%s: stored type %q", typ, updated[0].Type)
+		}
+	}
+	bad := dnsrecords.DNSRecord{Name: "example.com", Type: "ALIAS", Value: "bad..name"}
+	if _, _, err := dnsrecords.AddRecord(bad, nil, false); err == nil {
+		t.Fatal("expected error for invalid ALIAS target")
+	}
+}
+
+func TestAddRecordValidatesGeo(t *testing.T) {
+	ok := dnsrecords.DNSRecord{Name: "www.example.com", Type: "A", Value: "10.1.0.5", Geo: &dnsrecords.GeoSelector{Countries: []string{"DE"}}}
+	if _, _, err := dnsrecords.AddRecord(ok, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []dnsrecords.DNSRecord{
+		{Name: "www.example.com", Type: "MX", Value: "10 mx.example.com.", Geo: &dnsrecords.GeoSelector{Countries: []string{"DE"}}},
+		{Name: "www.example.com", Type: "A", Value: "10.1.0.5", Geo: &dnsrecords.GeoSelector{}},
+		{Name: "www.example.com", Type: "A", Value: "10.1.0.5", Geo: &dnsrecords.GeoSelector{Countries: []string{"Germany"}}},
+	} {
+		if _, _, err := dnsrecords.AddRecord(r, nil, false); err == nil {
+			t.Errorf("AddRecord accepted geo selector %+v on %s", r.Geo, r.Type)
+		}
+	}
+}
+
 func TestAddRecordRejectsEmptyRequired(t *testing.T) {
 	for _, r := range []dnsrecords.DNSRecord{
 		{Name: "", Type: "A", Value: "127.0.0.1"},
@@ -243,6 +277,73 @@ func TestFilterRecords(t *testing.T) {
 	}
 }
 
+func TestFilterRecordsSelectors(t *testing.T) {
+	soon := time.Now().Add(time.Hour)
+	records := []dnsrecords.DNSRecord{
+		{Name: "old.example.com", Type: "A", Value: "192.0.2.7", Tags: []string{"migration", "temp"}, Owner: "netops", ExpiresAt: soon},
+		{Name: "www.example.com", Type: "A", Value: "192.0.2.8", Tags: []string{"Migration"}, Owner: "web"},
+		{Name: "mail.example.com", Type: "MX", Value: "10 mx.example.com."},
+	}
+	for _, tc := range []struct {
+		selectors []string
+		want      int
+	}{
+		{[]string{"tag=migration"}, 2},
+		{[]string{"tag=migration", "owner=NetOps"}, 1},
+		{[]string{"tag=temp", "tag=migration"}, 1},
+		{[]string{"expires"}, 1},
+		{[]string{"owner=nobody"}, 0},
+	} {
+		got, err := dnsrecords.FilterRecords(records, "", "", tc.selectors...)
+		if err != nil || len(got) != tc.want {
+			t.Errorf("%v: got %d records (%v), want %d", tc.selectors, len(got), err, tc.want)
+		}
+	}
+	if _, err := dnsrecords.FilterRecords(records, "", "", "colour=red"); err == nil {
+		t.Fatal("unknown selector: want error")
+	}
+}
+
+func TestAddParsesMetadata(t *testing.T) {
+	before := time.Now()
+	got, _, err := dnsrecords.Add([]string{"old.example.com", "A", "192.0.2.7", "300", "tag=migration,temp", "tag=temp", "owner=netops", "comment=remove after cutover", "expires=7d"}, nil, false)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Add: %v %+v", err, got)
+	}
+	r := got[0]
+	if r.TTL != 300 || strings.Join(r.Tags, ",") != "migration,temp" || r.Owner != "netops" || r.Comment != "remove after cutover" {
+		t.Fatalf("metadata: %+v", r)
+	}
+	if d := r.ExpiresAt.Sub(before); d < 7*24*time.Hour || d > 7*24*time.Hour+time.Minute {
+		t.Fatalf("expires_at %v", r.ExpiresAt)
+	}
+	if _, _, err := dnsrecords.Add([]string{"x.example.com", "A", "192.0.2.1", "expires=2001-01-01T00:00:00Z"}, nil, false); err == nil {
+		t.Fatal("past expiry: want error")
+	}
+	if _, _, err := dnsrecords.Add([]string{"x.example.com", "A", "192.0.2.1", "expires=soon"}, nil, false); err == nil {
+		t.Fatal("bad expiry: want error")
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for in, want := range map[string]time.Time{
+		"2026-03-02T00:00:00Z": time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		"36h":                  now.Add(36 * time.Hour),
+		"2d":                   now.AddDate(0, 0, 2),
+	} {
+		got, err := dnsrecords.ParseExpiry(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseExpiry(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0d", "-1h", "tomorrow"} {
+		if _, err := dnsrecords.ParseExpiry(in, now); err == nil {
+			t.Errorf("ParseExpiry(%q): want error", in)
+		}
+	}
+}
+
 func TestListAppliesStringFilter(t *testing.T) {
 	records := []dnsrecords.DNSRecord{
 		{Name: "a.com", Type: "A", Value: "1.1.1.1", TTL: 3600},
@@ -0,0 +1,88 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package dnsrecords
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GeoSelector limits a record value to clients from matching locations. Values of the same name and type
+// without a selector are the default for clients no selector matches. When several selectors match, the
+// most specific kind wins: region, then ASN, then country, then continent.
+type GeoSelector struct {
+	// Regions are names from the geo.regions map in dnsplane.json.
+	Regions []string `json:"regions,omitempty"`
+	// Countries are ISO 3166-1 alpha-2 codes and Continents two-letter continent codes (EU, NA, ...).
+	Countries  []string `json:"countries,omitempty"`
+	Continents []string `json:"continents,omitempty"`
+	ASNs       []uint32 `json:"asns,omitempty"`
+}
+
+// Empty reports whether the selector has no criteria.
+func (g *GeoSelector) Empty() bool {
+	return g == nil || len(g.Regions)+len(g.Countries)+len(g.Continents)+len(g.ASNs) == 0
+}
+
+// String lists the criteria, e.g. "regions eu; countries DE,FR".
+func (g *GeoSelector) String() string {
+	if g == nil {
+		return ""
+	}
+	var parts []string
+	add := func(kind string, vals []string) {
+		if len(vals) > 0 {
+			parts = append(parts, kind+" "+strings.Join(vals, ","))
+		}
+	}
+	add("regions", g.Regions)
+	add("countries", g.Countries)
+	add("continents", g.Continents)
+	asns := make([]string, len(g.ASNs))
+	for i, a := range g.ASNs {
+		asns[i] = "AS" + strconv.FormatUint(uint64(a), 10)
+	}
+	add("asns", asns)
+	return strings.Join(parts, "; ")
+}
+
+// IsGeoType reports whether records of type t may carry a geo selector.
+func IsGeoType(t string) bool {
+	switch t {
+	case "A", "AAAA", "CNAME":
+		return true
+	}
+	return false
+}
+
+// validateGeo checks the geo selector of record.
+func validateGeo(record DNSRecord) error {
+	g := record.Geo
+	if g == nil {
+		return nil
+	}
+	if !IsGeoType(record.Type) {
+		return fmt.Errorf("geo is only supported on A, AAAA, and CNAME records")
+	}
+	if g.Empty() {
+		return fmt.Errorf("geo needs at least one of regions, countries, continents, or asns")
+	}
+	for _, c := range g.Countries {
+		if len(strings.TrimSpace(c)) != 2 {
+			return fmt.Errorf("geo country %q: want a two-letter ISO code", c)
+		}
+	}
+	for _, c := range g.Continents {
+		if len(strings.TrimSpace(c)) != 2 {
+			return fmt.Errorf("geo continent %q: want a two-letter code", c)
+		}
+	}
+	for _, r := range g.Regions {
+		if strings.TrimSpace(r) == "" {
+			return fmt.Errorf("geo region names must not be empty")
+		}
+	}
+	return nil
+}
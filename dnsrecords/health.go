@@ -0,0 +1,157 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package dnsrecords
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Health check types.
+const (
+	HealthCheckTCP   = "tcp"
+	HealthCheckHTTP  = "http"
+	HealthCheckHTTPS = "https"
+	HealthCheckDNS   = "dns"
+)
+
+// HealthCheck probes the address in an A/AAAA record's value. While the check is failing the value is
+// withheld from answers.
+type HealthCheck struct {
+	Type string `json:"type"`
+	// Port defaults to 80 (http), 443 (https), or 53 (dns); required for tcp.
+	Port int `json:"port,omitempty"`
+	// Path and Host are for http/https (Host also sets TLS SNI). ExpectStatus 0 accepts any 2xx/3xx.
+	Path          string `json:"path,omitempty"`
+	Host          string `json:"host,omitempty"`
+	ExpectStatus  int    `json:"expect_status,omitempty"`
+	TLSSkipVerify bool   `json:"tls_skip_verify,omitempty"`
+	// QueryName is the name asked by dns probes (default: the record name).
+	QueryName       string `json:"query_name,omitempty"`
+	IntervalSeconds int    `json:"interval_seconds,omitempty"`
+	TimeoutMs       int    `json:"timeout_ms,omitempty"`
+	// FailThreshold consecutive failures mark the value down (default 3); RiseThreshold successes bring it
+	// back (default 2).
+	FailThreshold int `json:"fail_threshold,omitempty"`
+	RiseThreshold int `json:"rise_threshold,omitempty"`
+	// FailOpen serves every value of the name/type when all of them are down.
+	FailOpen bool `json:"fail_open,omitempty"`
+}
+
+// Interval returns the probe interval (default 30s, minimum 1s).
+func (h *HealthCheck) Interval() time.Duration {
+	if h.IntervalSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(h.IntervalSeconds) * time.Second
+}
+
+// Timeout returns the per-probe timeout (default 2s).
+func (h *HealthCheck) Timeout() time.Duration {
+	if h.TimeoutMs <= 0 {
+		return 2 * time.Second
+	}
+	return time.Duration(h.TimeoutMs) * time.Millisecond
+}
+
+// Thresholds returns the fail and rise thresholds with defaults applied.
+func (h *HealthCheck) Thresholds() (fail, rise int) {
+	fail, rise = h.FailThreshold, h.RiseThreshold
+	if fail <= 0 {
+		fail = 3
+	}
+	if rise <= 0 {
+		rise = 2
+	}
+	return fail, rise
+}
+
+// Addr returns host:port for probing ip.
+func (h *HealthCheck) Addr(ip string) string {
+	port := h.Port
+	if port == 0 {
+		switch strings.ToLower(h.Type) {
+		case HealthCheckHTTP:
+			port = 80
+		case HealthCheckHTTPS:
+			port = 443
+		case HealthCheckDNS:
+			port = 53
+		}
+	}
+	return net.JoinHostPort(strings.TrimSpace(ip), strconv.Itoa(port))
+}
+
+// Key identifies the probe for value; records with identical probes share health state.
+func (h *HealthCheck) Key(value string) string {
+	addr := h.Addr(value)
+	switch t := strings.ToLower(h.Type); t {
+	case HealthCheckHTTP, HealthCheckHTTPS:
+		return t + "://" + addr + h.path()
+	case HealthCheckDNS:
+		return "dns " + addr + " " + h.QueryName
+	default:
+		return t + " " + addr
+	}
+}
+
+func (h *HealthCheck) path() string {
+	p := strings.TrimSpace(h.Path)
+	if p == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(p, "/") {
+		return "/" + p
+	}
+	return p
+}
+
+// URL returns the http(s) probe URL for ip.
+func (h *HealthCheck) URL(ip string) string {
+	return strings.ToLower(h.Type) + "://" + h.Addr(ip) + h.path()
+}
+
+// validateSelection checks weight, priority, health check, and geo settings of record.
+func validateSelection(record DNSRecord) error {
+	if err := validateGeo(record); err != nil {
+		return err
+	}
+	if record.Weight < 0 || record.Priority < 0 {
+		return fmt.Errorf("weight and priority must not be negative")
+	}
+	h := record.HealthCheck
+	if h == nil {
+		return nil
+	}
+	if record.Type != "A" && record.Type != "AAAA" {
+		return fmt.Errorf("health_check is only supported on A and AAAA records")
+	}
+	switch strings.ToLower(h.Type) {
+	case HealthCheckTCP:
+		if h.Port <= 0 {
+			return fmt.Errorf("health_check type tcp needs a port")
+		}
+	case HealthCheckHTTP, HealthCheckHTTPS:
+		if h.ExpectStatus != 0 && (h.ExpectStatus < 100 || h.ExpectStatus > 599) {
+			return fmt.Errorf("health_check expect_status %d is not an HTTP status", h.ExpectStatus)
+		}
+	case HealthCheckDNS:
+		if h.QueryName != "" {
+			if _, ok := dns.IsDomainName(h.QueryName); !ok {
+				return fmt.Errorf("health_check query_name %q is not a domain name", h.QueryName)
+			}
+		}
+	default:
+		return fmt.Errorf("health_check type %q: want tcp, http, https, or dns", h.Type)
+	}
+	if h.Port < 0 || h.Port > 65535 || h.IntervalSeconds < 0 || h.TimeoutMs < 0 || h.FailThreshold < 0 || h.RiseThreshold < 0 {
+		return fmt.Errorf("health_check port, interval, timeout, and thresholds must be in range")
+	}
+	return nil
+}
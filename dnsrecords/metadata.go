@@ -0,0 +1,152 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dnsrecords
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HasMetadata reports whether r carries tags, an owner, a comment, or an expiry.
+func (r DNSRecord) HasMetadata() bool {
+	return len(r.Tags) > 0 || r.Owner != "" || r.Comment != "" || !r.ExpiresAt.IsZero()
+}
+
+// HasTag reports whether r carries tag (case-insensitive).
+func (r DNSRecord) HasTag(tag string) bool {
+	return containsFold(r.Tags, tag)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired reports whether r has an expiry at or before now.
+func (r DNSRecord) Expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !r.ExpiresAt.After(now)
+}
+
+// normalizeMetadata trims and deduplicates tags and checks that tags hold no spaces or commas, the
+// owner and comment fit on one line, and the expiry is still ahead of now.
+func normalizeMetadata(r *DNSRecord, now time.Time) error {
+	var tags []string
+	for _, t := range r.Tags {
+		t = strings.TrimSpace(t)
+		if t == "" || containsFold(tags, t) {
+			continue
+		}
+		if strings.ContainsAny(t, " \t\r\n,") {
+			return fmt.Errorf("invalid tag %q: tags cannot contain spaces or commas", t)
+		}
+		tags = append(tags, t)
+	}
+	r.Tags = tags
+	r.Owner = strings.TrimSpace(r.Owner)
+	r.Comment = strings.TrimSpace(r.Comment)
+	if strings.ContainsAny(r.Owner, "\r\n") || strings.ContainsAny(r.Comment, "\r\n") {
+		return fmt.Errorf("owner and comment must be a single line")
+	}
+	if r.Expired(now) {
+		return fmt.Errorf("expires_at %s is in the past", r.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ParseExpiry parses an expires_at value: an RFC 3339 time, or a duration from now such as "36h" or "7d".
+func ParseExpiry(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("invalid expiry %q (want an RFC 3339 time or a duration such as 36h or 7d)", s)
+	}
+	return now.Add(d), nil
+}
+
+// selector is one parsed FilterRecords selector.
+type selector struct {
+	key, value string
+}
+
+func parseSelectors(selectors []string) ([]selector, error) {
+	out := make([]selector, 0, len(selectors))
+	for _, s := range selectors {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(s, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch {
+		case (key == "tag" || key == "owner") && value != "":
+		case key == "expires" && value == "":
+		default:
+			return nil, fmt.Errorf("%w: invalid selector %q (want tag=<tag>, owner=<owner>, or expires)", ErrInvalidArgs, s)
+		}
+		out = append(out, selector{key: key, value: value})
+	}
+	return out, nil
+}
+
+func (s selector) match(r DNSRecord) bool {
+	switch s.key {
+	case "tag":
+		return r.HasTag(s.value)
+	case "owner":
+		return strings.EqualFold(r.Owner, s.value)
+	case "expires":
+		return !r.ExpiresAt.IsZero()
+	}
+	return false
+}
+
+// IsSelector reports whether arg is a FilterRecords selector rather than a name or type filter.
+func IsSelector(arg string) bool {
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	return strings.HasPrefix(arg, "tag=") || strings.HasPrefix(arg, "owner=") || arg == "expires"
+}
+
+// splitMetadataArgs removes tag=, owner=, comment=, and expires= arguments from args and applies them
+// to r. Several tag= arguments, or one with a comma-separated list, add several tags.
+func splitMetadataArgs(args []string, r *DNSRecord, now time.Time) ([]string, error) {
+	rest := make([]string, 0, len(args))
+	for _, a := range args {
+		key, value, ok := strings.Cut(a, "=")
+		if !ok {
+			rest = append(rest, a)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "tag", "tags":
+			r.Tags = append(r.Tags, strings.Split(value, ",")...)
+		case "owner":
+			r.Owner = value
+		case "comment":
+			r.Comment = value
+		case "expires", "expires_at":
+			t, err := ParseExpiry(value, now)
+			if err != nil {
+				return nil, err
+			}
+			r.ExpiresAt = t
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return rest, nil
+}
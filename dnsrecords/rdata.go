@@ -0,0 +1,67 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package dnsrecords
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// genericTypeCode returns the code of an RFC 3597 type name such as TYPE65534.
+func genericTypeCode(recordType string) (uint16, bool) {
+	rest, ok := strings.CutPrefix(recordType, "TYPE")
+	if !ok || rest == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(rest, 10, 16)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return uint16(n), true
+}
+
+// RDataString returns the presentation-format RDATA of rr (the record text after name, TTL, class, and type).
+func RDataString(rr dns.RR) string {
+	parts := strings.SplitN(rr.String(), "\t", 5)
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[4]
+}
+
+// ParseRData parses value as the RDATA of recordType and returns it in canonical presentation format,
+// e.g. `0 issue "letsencrypt.org"` for CAA or `\# 4 0a000001` for TYPE65534. Relative names in the RDATA
+// are taken as absolute.
+func ParseRData(recordType, value string) (string, error) {
+	recordType = normalizeRecordType(recordType)
+	rr, err := dns.NewRR(fmt.Sprintf(". 0 IN %s %s", recordType, strings.TrimSpace(value)))
+	if err != nil {
+		return "", err
+	}
+	if rr == nil {
+		return "", fmt.Errorf("empty %s value", recordType)
+	}
+	return RDataString(rr), nil
+}
+
+// RecordToRR builds the RR for a stored record, with a lowercase absolute owner name and TTL 3600 when
+// the record has none. ALIAS/ANAME have no wire form and return an error.
+func RecordToRR(rec DNSRecord) (dns.RR, error) {
+	name := strings.TrimSpace(rec.Name)
+	if name == "" {
+		return nil, fmt.Errorf("empty name")
+	}
+	typ := normalizeRecordType(rec.Type)
+	if IsFlattenedType(typ) {
+		return nil, fmt.Errorf("%s has no wire form", typ)
+	}
+	ttl := rec.TTL
+	if ttl == 0 {
+		ttl = 3600
+	}
+	return dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.CanonicalName(name), ttl, typ, strings.TrimSpace(rec.Value)))
+}
@@ -4,6 +4,7 @@
 package dnsserve
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"sort"
@@ -56,7 +57,11 @@ func tryServeAXFR(req *dns.Msg, meta ServeMeta, dep Dependencies) (*dns.Msg, boo
 	if dep.LocalRecords != nil {
 		recs = dep.LocalRecords()
 	}
-	rrs, err := axfrRecordsForZone(recs, zone)
+	var flatten func(owner string) []dns.RR
+	if dep.Resolver != nil {
+		flatten = func(owner string) []dns.RR { return dep.Resolver.FlattenALIAS(context.Background(), owner) }
+	}
+	rrs, err := axfrRecordsForZone(recs, zone, flatten)
 	if err != nil {
 		resp := new(dns.Msg)
 		resp.SetReply(req)
@@ -104,7 +109,9 @@ func clientIPAllowed(ip string, nets []*net.IPNet) bool {
 	return false
 }
 
-func axfrRecordsForZone(recs []dnsrecords.DNSRecord, zone string) ([]dns.RR, error) {
+// axfrRecordsForZone returns the zone's records framed by its SOA. ALIAS/ANAME records are emitted as the
+// A/AAAA returned by flatten (none when flatten is nil).
+func axfrRecordsForZone(recs []dnsrecords.DNSRecord, zone string, flatten func(owner string) []dns.RR) ([]dns.RR, error) {
 	var inZone []dnsrecords.DNSRecord
 	for _, r := range recs {
 		n := dns.CanonicalName(r.Name)
@@ -142,49 +149,28 @@ func axfrRecordsForZone(recs []dnsrecords.DNSRecord, zone string) ([]dns.RR, err
 	})
 
 	out := make([]dns.RR, 0, len(others)+2)
-	first, err := dnsRecordToRR(soa)
+	first, err := dnsrecords.RecordToRR(soa)
 	if err != nil {
 		return nil, err
 	}
 	out = append(out, first)
 	for _, r := range others {
-		rr, err := dnsRecordToRR(r)
+		if dnsrecords.IsFlattenedType(r.Type) {
+			if flatten != nil {
+				out = append(out, flatten(r.Name)...)
+			}
+			continue
+		}
+		rr, err := dnsrecords.RecordToRR(r)
 		if err != nil {
 			continue
 		}
 		out = append(out, rr)
 	}
-	last, err := dnsRecordToRR(soa)
+	last, err := dnsrecords.RecordToRR(soa)
 	if err != nil {
 		return nil, err
 	}
 	out = append(out, last)
 	return out, nil
 }
-
-func dnsRecordToRR(rec dnsrecords.DNSRecord) (dns.RR, error) {
-	name := strings.TrimSpace(rec.Name)
-	if name == "" {
-		return nil, fmt.Errorf("empty name")
-	}
-	name = dns.CanonicalName(name)
-	ttl := rec.TTL
-	if ttl == 0 {
-		ttl = 3600
-	}
-	typ := strings.ToUpper(strings.TrimSpace(rec.Type))
-	val := strings.TrimSpace(rec.Value)
-	switch typ {
-	case "NS", "CNAME", "PTR":
-		if val != "" {
-			val = dns.CanonicalName(val)
-		}
-	case "MX":
-		parts := strings.SplitN(val, " ", 2)
-		if len(parts) == 2 {
-			val = strings.TrimSpace(parts[0]) + " " + dns.CanonicalName(strings.TrimSpace(parts[1]))
-		}
-	}
-	line := fmt.Sprintf("%s %d IN %s %s", name, ttl, typ, val)
-	return dns.NewRR(line)
-}
@@ -5,10 +5,13 @@ package dnsserve
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"testing"
 
 	"dnsplane/config"
 	"dnsplane/dnsrecords"
+	"dnsplane/zones"
 
 	"github.com/miekg/dns"
 )
@@ -42,7 +45,7 @@ func TestAxfrRecordsForZoneOrdering(t *testing.T) {
 		{Name: "www.example.com", Type: "A", Value: "192.0.2.1", TTL: 60},
 		{Name: "example.com", Type: "SOA", Value: "ns1.example.com. hostmaster.example.com. 1 7200 900 1209600 3600", TTL: 3600},
 	}
-	rrs, err := axfrRecordsForZone(recs, "example.com.")
+	rrs, err := axfrRecordsForZone(recs, "example.com.", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -53,3 +56,60 @@ func TestAxfrRecordsForZoneOrdering(t *testing.T) {
 		t.Fatal("expected SOA first and last")
 	}
 }
+
+func TestAxfrRecordsForZoneFlattensALIAS(t *testing.T) {
+	recs := []dnsrecords.DNSRecord{
+		{Name: "example.com", Type: "SOA", Value: "ns1.example.com. hostmaster.example.com. 1 7200 900 1209600 3600", TTL: 3600},
+		{Name: "example.com", Type: "ALIAS", Value: "lb.cloud.example.net.", TTL: 300},
+	}
+	flatten := func(owner string) []dns.RR {
+		rr, _ := dns.NewRR(dns.Fqdn(owner) + " 60 IN A 198.51.100.7")
+		return []dns.RR{rr}
+	}
+	rrs, err := axfrRecordsForZone(recs, "example.com.", flatten)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rrs) != 3 || rrs[1].Header().Rrtype != dns.TypeA || rrs[1].Header().Name != "example.com." {
+		t.Fatalf("got %v", rrs)
+	}
+}
+
+func TestAxfrRecordsForZoneRoundTripsAllTypes(t *testing.T) {
+	res, err := zones.ParseFile(filepath.Join("..", "zones", "testdata", "alltypes.zone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{}
+	for _, r := range res.Records {
+		if !dns.IsSubDomain("example.com.", dns.Fqdn(r.Name)) {
+			continue
+		}
+		line, err := zones.FormatRecord(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[line] = true
+	}
+	rrs, err := axfrRecordsForZone(res.Records, "example.com.", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, rr := range rrs[:len(rrs)-1] {
+		h := rr.Header()
+		typ := dns.TypeToString[h.Rrtype]
+		if typ == "" {
+			typ = fmt.Sprintf("TYPE%d", h.Rrtype)
+		}
+		got[fmt.Sprintf("%s\t%d\tIN\t%s\t%s", h.Name, h.Ttl, typ, dnsrecords.RDataString(rr))] = true
+	}
+	if len(got) != len(want) {
+		t.Fatalf("AXFR has %d distinct RRs, zone has %d", len(got), len(want))
+	}
+	for line := range want {
+		if !got[line] {
+			t.Errorf("AXFR missing %q", line)
+		}
+	}
+}
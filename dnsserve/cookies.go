@@ -0,0 +1,77 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package dnsserve
+
+import (
+	"dnsplane/dnscookie"
+
+	"github.com/miekg/dns"
+)
+
+// cookieState is the request's DNS cookie (RFC 7873) as seen by ServeDNS.
+type cookieState struct {
+	srv    *dnscookie.Server
+	client []byte
+	// valid is true when the request carried a server cookie we issued (exempt from response limiters).
+	valid bool
+}
+
+// checkCookie parses the COOKIE option. It returns done=true when resp is final: FORMERR for a malformed
+// option, or BADCOOKIE / TC=1 when an unverified UDP client is over dep.CookieLimiter.
+func checkCookie(req, resp *dns.Msg, clientIP string, meta ServeMeta, dep Dependencies, srv *dnscookie.Server) (cookieState, bool) {
+	st := cookieState{srv: srv}
+	client, server, present, err := dnscookie.Parse(req)
+	if err != nil {
+		resp.SetRcode(req, dns.RcodeFormatError)
+		onCookie(dep, "malformed")
+		return st, true
+	}
+	if !present {
+		onCookie(dep, "missing")
+	} else {
+		st.client = client
+		st.valid = server != nil && srv.Verify(client, server, clientIP)
+		if st.valid {
+			onCookie(dep, "valid")
+			return st, false
+		}
+	}
+	if meta.Protocol != ProtoUDP || dep.CookieLimiter == nil || dep.CookieLimiter.Allow(clientIP) {
+		if present {
+			onCookie(dep, "issued")
+		}
+		return st, false
+	}
+	if present {
+		// The client understands cookies: hand it a fresh server cookie and let it retry.
+		resp.SetRcode(req, dns.RcodeBadCookie)
+		onCookie(dep, "badcookie")
+	} else {
+		// Cookieless clients are pushed to TCP, where the source address cannot be spoofed.
+		resp.Truncated = true
+		onCookie(dep, "truncated")
+	}
+	return st, true
+}
+
+// attach adds client||fresh server cookie to resp (RFC 7873 §5.2: every reply to a cookie-bearing query).
+// Only the first call on a request does anything, so ServeDNS can attach before its size checks and still
+// defer attach for its early returns.
+func (c *cookieState) attach(req, resp *dns.Msg, clientIP string, maxPayload uint16) {
+	if resp == nil || c.srv == nil || c.client == nil {
+		return
+	}
+	srv := c.srv
+	c.srv = nil
+	if resp.IsEdns0() == nil {
+		resp.SetEdns0(req.IsEdns0().UDPSize(), false)
+		clampEDNS(resp, maxPayload)
+	}
+	dnscookie.SetOption(resp, c.client, srv.Issue(c.client, clientIP))
+}
+
+func onCookie(dep Dependencies, outcome string) {
+	if dep.OnCookie != nil {
+		dep.OnCookie(outcome)
+	}
+}
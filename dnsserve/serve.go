@@ -5,11 +5,15 @@ package dnsserve
 
 import (
 	"context"
+	"strconv"
 	"strings"
 
+	"dnsplane/acl"
 	"dnsplane/config"
+	"dnsplane/dnscookie"
 	"dnsplane/dnsrecords"
 	"dnsplane/resolver"
+	"dnsplane/tracing"
 
 	"github.com/miekg/dns"
 )
@@ -40,6 +44,16 @@ type Dependencies struct {
 	QueryLimiter QueryLimiter
 	// OnLimiterDrop is called when a limiter refuses (reason: query_rate, response_sliding, response_rrl).
 	OnLimiterDrop func(reason string)
+	// ClientACL returns the compiled client ACL (nil = allow all).
+	ClientACL func() *acl.ACL
+	// OnACLDenied is called when the client ACL refuses or drops a query (for dashboard/metrics).
+	OnACLDenied func(clientIP, qname, qtype string, d acl.Decision, dropped bool)
+	// Cookies returns the DNS cookie server (nil = cookies disabled).
+	Cookies func() *dnscookie.Server
+	// CookieLimiter allows UDP queries without a valid server cookie per client IP (nil = no enforcement).
+	CookieLimiter QueryLimiter
+	// OnCookie is called with the cookie outcome (valid, issued, missing, badcookie, truncated, malformed).
+	OnCookie func(outcome string)
 }
 
 // QueryLimiter matches ratelimit.PerIP.Allow.
@@ -54,8 +68,9 @@ type ResponseLimiter interface {
 }
 
 // ServeDNS builds the response for one DNS message. Caller writes wire to the client.
-func ServeDNS(ctx context.Context, req *dns.Msg, meta ServeMeta, dep Dependencies) *dns.Msg {
-	resp := new(dns.Msg)
+// A nil response means the query must be dropped silently (client ACL deny_action "drop").
+func ServeDNS(ctx context.Context, req *dns.Msg, meta ServeMeta, dep Dependencies) (resp *dns.Msg) {
+	resp = new(dns.Msg)
 	resp.SetReply(req)
 	resp.Authoritative = false
 
@@ -64,7 +79,52 @@ func ServeDNS(ctx context.Context, req *dns.Msg, meta ServeMeta, dep Dependencie
 		clientIP = "unknown"
 	}
 
+	ctx, span := tracing.Start(ctx, "dns.query", tracing.KindServer)
+	if span != nil {
+		span.SetAttr("dns.question.name", primaryQname(req))
+		span.SetAttr("dns.question.type", primaryQtype(req))
+		span.SetAttr("client.address", clientIP)
+		span.SetAttr("network.transport", meta.Protocol)
+		defer func() { endQuerySpan(span, resp) }()
+	}
+
+	var decision acl.Decision
+	if dep.ClientACL != nil {
+		if a := dep.ClientACL(); a.Enabled() {
+			decision = a.Evaluate(clientIP)
+			span.SetAttr("dnsplane.acl", decision.String())
+			if decision.Action == acl.Deny {
+				dropped := a.DenyAction() == acl.DenyActionDrop
+				if dep.OnACLDenied != nil {
+					dep.OnACLDenied(clientIP, primaryQname(req), primaryQtype(req), decision, dropped)
+				}
+				if dropped {
+					return nil
+				}
+				resp.SetRcode(req, dns.RcodeRefused)
+				resolver.SetExtendedError(req, resp, dns.ExtendedErrorCodeProhibited, "query not allowed")
+				return resp
+			}
+			ctx = resolver.ContextWithQueryNotes(ctx, resolver.QueryNotes{ACL: decision.String()})
+			if decision.Action == acl.LocalOnly {
+				ctx = resolver.ContextWithNoRecursion(ctx)
+			}
+		}
+	}
+
 	st := dep.Settings()
+	var cookies cookieState
+	if dep.Cookies != nil {
+		if srv := dep.Cookies(); srv != nil {
+			var done bool
+			cookies, done = checkCookie(req, resp, clientIP, meta, dep, srv)
+			defer func() { cookies.attach(req, resp, clientIP, st.DNSMaxEDNSUDPPayload) }()
+			if done {
+				return resp
+			}
+		}
+	}
+
 	if req.Opcode == dns.OpcodeUpdate {
 		resp.SetRcode(req, dns.RcodeNotImplemented)
 		return resp
@@ -76,6 +136,7 @@ func ServeDNS(ctx context.Context, req *dns.Msg, meta ServeMeta, dep Dependencie
 	}
 
 	if dep.QueryLimiter != nil && !dep.QueryLimiter.Allow(clientIP) {
+		span.SetAttr("dnsplane.limited", "query_rate")
 		resp.SetRcode(req, dns.RcodeRefused)
 		if dep.OnLimiterDrop != nil {
 			dep.OnLimiterDrop("query_rate")
@@ -84,7 +145,13 @@ func ServeDNS(ctx context.Context, req *dns.Msg, meta ServeMeta, dep Dependencie
 	}
 
 	qname := primaryQname(req)
-	if dep.ResponseLimiter != nil && !dep.ResponseLimiter.Allow(clientIP, qname) {
+	// Clients presenting a valid server cookie cannot be spoofed sources, so response limiting is skipped.
+	limiter := dep.ResponseLimiter
+	if cookies.valid {
+		limiter = nil
+	}
+	if limiter != nil && !limiter.Allow(clientIP, qname) {
+		span.SetAttr("dnsplane.limited", "response_rate")
 		resp.SetRcode(req, dns.RcodeRefused)
 		if dep.OnLimiterDrop != nil {
 			if st.DNSResponseLimitMode == "rrl" {
@@ -99,8 +166,8 @@ func ServeDNS(ctx context.Context, req *dns.Msg, meta ServeMeta, dep Dependencie
 	if axfrResp, ok := tryServeAXFR(req, meta, dep); ok {
 		clampEDNS(axfrResp, st.DNSMaxEDNSUDPPayload)
 		// Intentionally skip clampAmplified: AXFR legitimately returns many RRs vs a small query.
-		if dep.ResponseLimiter != nil {
-			dep.ResponseLimiter.RecordResponse(clientIP, qname)
+		if limiter != nil {
+			limiter.RecordResponse(clientIP, qname)
 		}
 		return axfrResp
 	}
@@ -112,19 +179,36 @@ func ServeDNS(ctx context.Context, req *dns.Msg, meta ServeMeta, dep Dependencie
 		}
 	}
 
+	// The cookie goes in first so the amplification check sees the reply at its full size.
+	cookies.attach(req, resp, clientIP, st.DNSMaxEDNSUDPPayload)
 	clampEDNS(resp, st.DNSMaxEDNSUDPPayload)
 
 	if st.DNSAmplificationMaxRatio > 0 {
 		clampAmplified(req, resp, st.DNSAmplificationMaxRatio)
 	}
 
-	if dep.ResponseLimiter != nil {
-		dep.ResponseLimiter.RecordResponse(clientIP, qname)
+	if limiter != nil {
+		limiter.RecordResponse(clientIP, qname)
 	}
 
 	return resp
 }
 
+// endQuerySpan ends the span of an inbound query with its response code; a SERVFAIL marks it failed.
+func endQuerySpan(span *tracing.Span, resp *dns.Msg) {
+	if resp == nil {
+		span.SetAttr("dnsplane.dropped", true)
+	} else {
+		rcode := dns.RcodeToString[resp.Rcode]
+		span.SetAttr("dns.response.code", rcode)
+		span.SetAttr("dns.answers", len(resp.Answer))
+		if resp.Rcode == dns.RcodeServerFailure {
+			span.SetError(rcode)
+		}
+	}
+	span.End()
+}
+
 func hasANYQuestion(req *dns.Msg) bool {
 	for _, q := range req.Question {
 		if q.Qtype == dns.TypeANY {
@@ -141,6 +225,17 @@ func primaryQname(req *dns.Msg) string {
 	return "."
 }
 
+func primaryQtype(req *dns.Msg) string {
+	if len(req.Question) == 0 {
+		return ""
+	}
+	qt := req.Question[0].Qtype
+	if s := dns.TypeToString[qt]; s != "" {
+		return s
+	}
+	return "TYPE" + strconv.Itoa(int(qt))
+}
+
 func clampAmplified(req, resp *dns.Msg, maxRatio int) {
 	if maxRatio <= 0 {
 		return
@@ -153,9 +248,14 @@ func clampAmplified(req, resp *dns.Msg, maxRatio int) {
 	if len(pw) <= maxRatio*len(rw) {
 		return
 	}
+	// The OPT record stays, with the DNS cookie and the EDNS payload size.
+	opt := resp.IsEdns0()
 	resp.Answer = nil
 	resp.Ns = nil
 	resp.Extra = nil
+	if opt != nil {
+		resp.Extra = []dns.RR{opt}
+	}
 	resp.SetRcode(req, dns.RcodeServerFailure)
 }
 
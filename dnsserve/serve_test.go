@@ -0,0 +1,170 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package dnsserve
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"dnsplane/abuse"
+	"dnsplane/acl"
+	"dnsplane/config"
+	"dnsplane/dnscookie"
+
+	"github.com/miekg/dns"
+)
+
+func aclDeps(t *testing.T, cfg config.ClientACLConfig, denied *int) Dependencies {
+	t.Helper()
+	a, err := acl.Compile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Dependencies{
+		Settings:  func() config.Config { return config.Config{} },
+		ClientACL: func() *acl.ACL { return a },
+		OnACLDenied: func(string, string, string, acl.Decision, bool) {
+			*denied++
+		},
+	}
+}
+
+func TestServeDNSClientACLRefused(t *testing.T) {
+	var denied int
+	dep := aclDeps(t, config.ClientACLConfig{AllowQuery: []string{"10.0.0.0/8"}}, &denied)
+	req := new(dns.Msg)
+	req.SetQuestion("example.com.", dns.TypeA)
+	req.SetEdns0(1232, false)
+	resp := ServeDNS(context.Background(), req, ServeMeta{ClientIP: "192.0.2.1", Protocol: ProtoUDP}, dep)
+	if resp == nil || resp.Rcode != dns.RcodeRefused {
+		t.Fatalf("want REFUSED, got %v", resp)
+	}
+	opt := resp.IsEdns0()
+	if opt == nil || len(opt.Option) == 0 {
+		t.Fatal("expected EDE option")
+	}
+	if denied != 1 {
+		t.Fatalf("OnACLDenied calls = %d", denied)
+	}
+}
+
+func TestServeDNSClientACLDrop(t *testing.T) {
+	var denied int
+	dep := aclDeps(t, config.ClientACLConfig{Deny: []string{"192.0.2.0/24"}, DenyAction: "drop"}, &denied)
+	req := new(dns.Msg)
+	req.SetQuestion("example.com.", dns.TypeA)
+	if resp := ServeDNS(context.Background(), req, ServeMeta{ClientIP: "192.0.2.1", Protocol: ProtoUDP}, dep); resp != nil {
+		t.Fatalf("want nil (drop), got rcode %s", dns.RcodeToString[resp.Rcode])
+	}
+	if denied != 1 {
+		t.Fatalf("OnACLDenied calls = %d", denied)
+	}
+}
+
+func TestServeDNSClientACLAllowed(t *testing.T) {
+	var denied int
+	dep := aclDeps(t, config.ClientACLConfig{AllowQuery: []string{"10.0.0.0/8"}}, &denied)
+	req := new(dns.Msg)
+	req.SetQuestion("example.com.", dns.TypeA)
+	resp := ServeDNS(context.Background(), req, ServeMeta{ClientIP: "10.2.3.4", Protocol: ProtoUDP}, dep)
+	if resp == nil || resp.Rcode != dns.RcodeSuccess || denied != 0 {
+		t.Fatalf("want allowed, got %v denied=%d", resp, denied)
+	}
+}
+
+// denyAll is a QueryLimiter that refuses everything (forces cookie enforcement).
+type denyAll struct{}
+
+func (denyAll) Allow(string) bool { return false }
+
+func cookieQuery(client, server []byte) *dns.Msg {
+	req := new(dns.Msg)
+	req.SetQuestion("example.com.", dns.TypeA)
+	req.SetEdns0(1232, false)
+	if client != nil {
+		dnscookie.SetOption(req, client, server)
+	}
+	return req
+}
+
+func TestServeDNSCookies(t *testing.T) {
+	srv := dnscookie.NewServer(time.Hour)
+	outcomes := map[string]int{}
+	dep := Dependencies{
+		Settings:        func() config.Config { return config.Config{} },
+		Cookies:         func() *dnscookie.Server { return srv },
+		CookieLimiter:   denyAll{},
+		ResponseLimiter: abuse.NewSlidingWindow(time.Minute, 1, 0),
+		OnCookie:        func(o string) { outcomes[o]++ },
+	}
+	udp := ServeMeta{ClientIP: "192.0.2.1", Protocol: ProtoUDP}
+	client := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	// Cookieless UDP over the allowance: TC=1 pushes the client to TCP.
+	resp := ServeDNS(context.Background(), cookieQuery(nil, nil), udp, dep)
+	if !resp.Truncated || resp.IsEdns0() != nil {
+		t.Fatalf("cookieless: want TC without cookie, got %v", resp)
+	}
+
+	// Client cookie only: BADCOOKIE with a server cookie to retry with.
+	resp = ServeDNS(context.Background(), cookieQuery(client, nil), udp, dep)
+	if resp.Rcode != dns.RcodeBadCookie {
+		t.Fatalf("client cookie only: rcode %s", dns.RcodeToString[resp.Rcode])
+	}
+	c, sc, ok, err := dnscookie.Parse(resp)
+	if !ok || err != nil || !bytes.Equal(c, client) || len(sc) != dnscookie.ServerCookieLen {
+		t.Fatalf("BADCOOKIE reply cookie: %x %x %v %v", c, sc, ok, err)
+	}
+	if _, err := resp.Pack(); err != nil {
+		t.Fatalf("pack BADCOOKIE: %v", err)
+	}
+
+	// Valid cookie: answered, and the one-response sliding window does not apply.
+	for i := 0; i < 3; i++ {
+		resp = ServeDNS(context.Background(), cookieQuery(client, sc), udp, dep)
+		if resp.Rcode != dns.RcodeSuccess || resp.Truncated {
+			t.Fatalf("valid cookie #%d: rcode %s tc=%v", i, dns.RcodeToString[resp.Rcode], resp.Truncated)
+		}
+	}
+	if outcomes["valid"] != 3 || outcomes["badcookie"] != 1 || outcomes["truncated"] != 1 {
+		t.Fatalf("outcomes = %v", outcomes)
+	}
+
+	// The same cookie from another address is not valid.
+	resp = ServeDNS(context.Background(), cookieQuery(client, sc), ServeMeta{ClientIP: "192.0.2.2", Protocol: ProtoUDP}, dep)
+	if resp.Rcode != dns.RcodeBadCookie {
+		t.Fatalf("foreign address: rcode %s", dns.RcodeToString[resp.Rcode])
+	}
+
+	// TCP is never challenged.
+	resp = ServeDNS(context.Background(), cookieQuery(nil, nil), ServeMeta{ClientIP: "192.0.2.3", Protocol: ProtoTCP}, dep)
+	if resp.Truncated || resp.Rcode != dns.RcodeSuccess {
+		t.Fatalf("tcp: %v", resp)
+	}
+
+	bad := cookieQuery(nil, nil)
+	bad.IsEdns0().Option = append(bad.IsEdns0().Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102"})
+	if resp = ServeDNS(context.Background(), bad, udp, dep); resp.Rcode != dns.RcodeFormatError {
+		t.Fatalf("malformed: rcode %s", dns.RcodeToString[resp.Rcode])
+	}
+}
+
+func TestServeDNSCookieCountsTowardsAmplification(t *testing.T) {
+	srv := dnscookie.NewServer(time.Hour)
+	dep := Dependencies{
+		Settings: func() config.Config { return config.Config{DNSAmplificationMaxRatio: 1} },
+		Cookies:  func() *dnscookie.Server { return srv },
+	}
+	// The reply without a cookie is smaller than the query; with client and server cookie it is larger.
+	req := cookieQuery([]byte{1, 2, 3, 4, 5, 6, 7, 8}, nil)
+	resp := ServeDNS(context.Background(), req, ServeMeta{ClientIP: "192.0.2.1", Protocol: ProtoTCP}, dep)
+	if resp.Rcode != dns.RcodeServerFailure {
+		t.Fatalf("rcode %s, want SERVFAIL from the amplification clamp", dns.RcodeToString[resp.Rcode])
+	}
+	if _, sc, ok, err := dnscookie.Parse(resp); !ok || err != nil || len(sc) != dnscookie.ServerCookieLen {
+		t.Fatalf("clamped reply lost its cookie: %x %v %v", sc, ok, err)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+// Package events is the bus for operational events: upstreams going down and coming back, record changes,
+// cluster peers lost, rate-limit drop spikes, bogus DNSSEC answers, cache compactions, and configuration
+// reloads. The API streams them on GET /events, and configured webhooks receive them as signed POSTs.
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	UpstreamUnhealthy = "upstream.unhealthy" // health probes marked an upstream down
+	UpstreamRecovered = "upstream.recovered" // a probe succeeded against an upstream marked down
+	RecordsChanged    = "records.changed"    // the served records changed
+	ClusterPeerDown   = "cluster.peer_down"  // a cluster peer stopped answering probes
+	ClusterPeerUp     = "cluster.peer_up"    // a cluster peer that was down answers again
+	LimiterDropSpike  = "limiter.drop_spike" // the DNS rate limiters refused more than limiter_drop_spike queries in a window
+	DNSSECBogus       = "dnssec.bogus"       // validation failed for an answer
+	CacheCompacted    = "cache.compacted"    // expired cache entries were removed
+	ConfigReloaded    = "config.reloaded"    // the configuration was reloaded or patched
+)
+
+// Types lists every event type.
+var Types = []string{
+	UpstreamUnhealthy, UpstreamRecovered, RecordsChanged, ClusterPeerDown, ClusterPeerUp,
+	LimiterDropSpike, DNSSECBogus, CacheCompacted, ConfigReloaded,
+}
+
+// replaySize is how many recent events GET /events can replay after a reconnect.
+const replaySize = 1024
+
+// subscriberBuffer is how far a stream may fall behind before it is closed; the client reconnects with
+// Last-Event-ID and catches up from the replay buffer.
+const subscriberBuffer = 256
+
+// Event is one occurrence. IDs increase, across restarts too: they are microseconds since the epoch, bumped
+// when two events share one. The replay buffer lives in memory, so only IDs of the running process can be
+// replayed; see Subscription.Missed.
+type Event struct {
+	ID   uint64         `json:"id"`
+	Type string         `json:"type"`
+	Time time.Time      `json:"time"`
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Bus keeps the recent events and fans new ones out to subscribers.
+type Bus struct {
+	mu      sync.Mutex
+	boot    uint64 // IDs below this come from an earlier process
+	lastID  uint64
+	evicted uint64 // ID of the newest event pushed out of the ring
+	ring    []Event
+	next    int
+	subs    map[*Subscription]struct{}
+	notify  func(Event)
+}
+
+// Reasons Subscription.Missed gives for events that cannot be replayed.
+const (
+	MissedRestart  = "restart"  // the ID is from before this process started
+	MissedOverflow = "overflow" // events after the ID have left the replay buffer
+)
+
+// NewBus returns an empty bus. notify, when set, is called with each event after subscribers have it.
+func NewBus(notify func(Event)) *Bus {
+	boot := uint64(time.Now().UnixMicro())
+	return &Bus{
+		boot:   boot,
+		lastID: boot, // IDs stay above boot even if the clock steps back
+		ring:   make([]Event, 0, replaySize),
+		subs:   make(map[*Subscription]struct{}),
+		notify: notify,
+	}
+}
+
+var std = NewBus(func(e Event) {
+	if d := dispatch.Load(); d != nil {
+		d.enqueue(e)
+	}
+})
+
+// Publish records an event on the process bus, streams it, and queues it for the webhooks that want it.
+func Publish(typ string, data map[string]any) Event {
+	return std.Publish(typ, data)
+}
+
+// Subscribe follows the process bus; see Bus.Subscribe.
+func Subscribe(lastID uint64, types []string) ([]Event, *Subscription) {
+	return std.Subscribe(lastID, types)
+}
+
+// Publish records an event and hands it to subscribers.
+func (b *Bus) Publish(typ string, data map[string]any) Event {
+	now := time.Now().UTC()
+	b.mu.Lock()
+	id := uint64(now.UnixMicro())
+	if id <= b.lastID {
+		id = b.lastID + 1
+	}
+	b.lastID = id
+	e := Event{ID: id, Type: typ, Time: now, Data: data}
+	if len(b.ring) < replaySize {
+		b.ring = append(b.ring, e)
+	} else {
+		b.evicted = b.ring[b.next].ID
+		b.ring[b.next] = e
+		b.next = (b.next + 1) % replaySize
+	}
+	for s := range b.subs {
+		if !s.match(typ) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			// Too far behind: end the stream rather than skip events silently.
+			delete(b.subs, s)
+			close(s.ch)
+		}
+	}
+	b.mu.Unlock()
+	if b.notify != nil {
+		b.notify(e)
+	}
+	return e
+}
+
+// Subscribe returns the buffered events after lastID that match types (all types when empty; "upstream.*"
+// matches a family), and a subscription for the ones that follow. lastID 0 replays nothing. When events
+// after lastID are gone, the subscription's Missed says why and the whole buffer is replayed.
+func (b *Bus) Subscribe(lastID uint64, types []string) ([]Event, *Subscription) {
+	s := &Subscription{bus: b, ch: make(chan Event, subscriberBuffer), types: types}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var replay []Event
+	if lastID > 0 {
+		switch {
+		// An ID ahead of the newest one comes from an earlier process whose clock was ahead.
+		case lastID < b.boot || lastID > b.lastID:
+			s.missed, lastID = MissedRestart, 0
+		case lastID < b.evicted:
+			s.missed, lastID = MissedOverflow, 0
+		}
+		n := len(b.ring)
+		for i := range n {
+			e := b.ring[(b.next+i)%n]
+			if e.ID > lastID && s.match(e.Type) {
+				replay = append(replay, e)
+			}
+		}
+	}
+	b.subs[s] = struct{}{}
+	return replay, s
+}
+
+// Subscription is a live view of the bus.
+type Subscription struct {
+	bus    *Bus
+	ch     chan Event
+	types  []string
+	missed string
+}
+
+// Missed is MissedRestart or MissedOverflow when the replay could not start right after the requested ID, so
+// the client must reload its state rather than apply the replay as a diff; "" otherwise.
+func (s *Subscription) Missed() string { return s.missed }
+
+// C delivers events; it is closed when the subscriber fell too far behind or Close was called.
+func (s *Subscription) C() <-chan Event { return s.ch }
+
+// Close stops the subscription.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.ch)
+	}
+}
+
+func (s *Subscription) match(typ string) bool {
+	return Match(s.types, typ)
+}
+
+// Match reports whether typ is selected by filters: empty or "*" selects all, "family.*" a family, anything
+// else one type.
+func Match(filters []string, typ string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		if f == "*" || f == typ {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(f, ".*"); ok && strings.HasPrefix(typ, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidFilter reports whether f selects at least one known type.
+func ValidFilter(f string) bool {
+	for _, t := range Types {
+		if Match([]string{f}, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Throttle lets one event per key through per interval, for sources that could fire on every query.
+type Throttle struct {
+	every time.Duration
+	mu    sync.Mutex
+	last  map[string]time.Time
+}
+
+// NewThrottle returns a Throttle allowing one event per key every interval.
+func NewThrottle(every time.Duration) *Throttle {
+	return &Throttle{every: every, last: make(map[string]time.Time)}
+}
+
+// Allow reports whether an event for key may be published now.
+func (t *Throttle) Allow(key string) bool {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if last, ok := t.last[key]; ok && now.Sub(last) < t.every {
+		return false
+	}
+	if len(t.last) >= 4096 {
+		for k, at := range t.last {
+			if now.Sub(at) >= t.every {
+				delete(t.last, k)
+			}
+		}
+		if len(t.last) >= 4096 {
+			return false
+		}
+	}
+	t.last[key] = now
+	return true
+}
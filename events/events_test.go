@@ -0,0 +1,132 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBusReplayAfterLastID(t *testing.T) {
+	b := NewBus(nil)
+	first := b.Publish(UpstreamUnhealthy, map[string]any{"server": "9.9.9.9:53"})
+	second := b.Publish(RecordsChanged, nil)
+	third := b.Publish(UpstreamRecovered, map[string]any{"server": "9.9.9.9:53"})
+	if !(first.ID < second.ID && second.ID < third.ID) {
+		t.Fatalf("ids not increasing: %d %d %d", first.ID, second.ID, third.ID)
+	}
+
+	replay, sub := b.Subscribe(first.ID, []string{"upstream.*"})
+	defer sub.Close()
+	if len(replay) != 1 || replay[0].ID != third.ID {
+		t.Fatalf("replay = %+v, want the recovery only", replay)
+	}
+	if none, s := b.Subscribe(0, nil); len(none) != 0 {
+		t.Errorf("lastID 0 replayed %d events", len(none))
+	} else {
+		s.Close()
+	}
+
+	b.Publish(CacheCompacted, nil)
+	b.Publish(UpstreamUnhealthy, nil)
+	select {
+	case e := <-sub.C():
+		if e.Type != UpstreamUnhealthy {
+			t.Errorf("got %s through an upstream.* filter", e.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
+
+func TestBusReplayWrapsRing(t *testing.T) {
+	b := NewBus(nil)
+	start := b.Publish(ConfigReloaded, nil)
+	for range replaySize + 10 {
+		b.Publish(RecordsChanged, nil)
+	}
+	replay, sub := b.Subscribe(start.ID, nil)
+	sub.Close()
+	if len(replay) != replaySize || sub.Missed() != MissedOverflow {
+		t.Fatalf("replayed %d (missed %q), want %d after an overflow", len(replay), sub.Missed(), replaySize)
+	}
+	for i := 1; i < len(replay); i++ {
+		if replay[i].ID <= replay[i-1].ID {
+			t.Fatalf("replay out of order at %d", i)
+		}
+	}
+}
+
+func TestBusMissedAcrossRestart(t *testing.T) {
+	old := NewBus(nil)
+	before := old.Publish(RecordsChanged, nil)
+	time.Sleep(time.Millisecond)
+	b := NewBus(nil)
+	first := b.Publish(ConfigReloaded, nil)
+	b.Publish(RecordsChanged, nil)
+
+	for _, tc := range []struct {
+		last   uint64
+		missed string
+		replay int
+	}{
+		{first.ID, "", 1},
+		{before.ID, MissedRestart, 2},
+		{first.ID + uint64(time.Hour.Microseconds()), MissedRestart, 2}, // a clock that was ahead
+	} {
+		replay, sub := b.Subscribe(tc.last, nil)
+		sub.Close()
+		if sub.Missed() != tc.missed || len(replay) != tc.replay {
+			t.Errorf("Subscribe(%d): missed %q, %d replayed; want %q, %d", tc.last, sub.Missed(), len(replay), tc.missed, tc.replay)
+		}
+	}
+}
+
+func TestBusClosesLaggingSubscriber(t *testing.T) {
+	b := NewBus(nil)
+	_, sub := b.Subscribe(0, nil)
+	for range subscriberBuffer + 1 {
+		b.Publish(RecordsChanged, nil)
+	}
+	n := 0
+	for range sub.C() {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Fatalf("read %d before close, want %d", n, subscriberBuffer)
+	}
+	sub.Close() // already closed by the bus
+}
+
+func TestMatchAndValidFilter(t *testing.T) {
+	tests := []struct {
+		filters []string
+		typ     string
+		want    bool
+	}{
+		{nil, DNSSECBogus, true},
+		{[]string{"*"}, DNSSECBogus, true},
+		{[]string{"cluster.*"}, ClusterPeerDown, true},
+		{[]string{"cluster.*"}, CacheCompacted, false},
+		{[]string{RecordsChanged, ConfigReloaded}, ConfigReloaded, true},
+		{[]string{"config"}, ConfigReloaded, false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.filters, tt.typ); got != tt.want {
+			t.Errorf("Match(%v, %s) = %v", tt.filters, tt.typ, got)
+		}
+	}
+	for f, want := range map[string]bool{"*": true, "upstream.*": true, DNSSECBogus: true, "dns.*": false, "upstream.down": false} {
+		if got := ValidFilter(f); got != want {
+			t.Errorf("ValidFilter(%q) = %v", f, got)
+		}
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	th := NewThrottle(time.Hour)
+	if !th.Allow("example.com./A") || th.Allow("example.com./A") || !th.Allow("example.org./A") {
+		t.Fatal("one event per key per interval")
+	}
+}
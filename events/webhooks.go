@@ -0,0 +1,474 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dnsplane/config"
+)
+
+// Webhook request headers.
+const (
+	HeaderEvent     = "X-Dnsplane-Event"     // the event type
+	HeaderDelivery  = "X-Dnsplane-Delivery"  // the event ID, the same on every retry
+	HeaderSignature = "X-Dnsplane-Signature" // "sha256=" and the hex HMAC-SHA256 of the body, when the webhook has a secret
+)
+
+const (
+	webhookTimeout = 10 * time.Second
+	maxPending     = 10000          // per webhook; the oldest events go first when a receiver is down that long
+	maxAge         = 24 * time.Hour // an event still undelivered after this is given up
+	saveEvery      = time.Second
+)
+
+// Retry backoff: retryBase after the first failure, doubling up to retryMax. Variables for the tests.
+var (
+	retryBase = 2 * time.Second
+	retryMax  = 10 * time.Minute
+)
+
+var (
+	dispatch   atomic.Pointer[dispatcher]
+	dispatchMu sync.Mutex
+)
+
+// Configure starts the webhook dispatcher, or updates the running one to cfg. Events still queued for a
+// webhook that is kept (matched by name) stay queued; those of a removed webhook are dropped. At start the
+// queue saved in cfg.QueueFile by the last run is loaded.
+func Configure(cfg config.EventsConfig, logger *slog.Logger) {
+	dispatchMu.Lock()
+	defer dispatchMu.Unlock()
+	if d := dispatch.Load(); d != nil {
+		d.reconfigure(cfg)
+		return
+	}
+	d := &dispatcher{
+		file:   cfg.QueueFile,
+		logger: logger,
+		client: &http.Client{Timeout: webhookTimeout},
+		hooks:  make(map[string]*hook),
+		stop:   make(chan struct{}),
+		saved:  make(chan struct{}),
+	}
+	d.reconfigure(cfg)
+	d.load()
+	go d.saveLoop()
+	dispatch.Store(d)
+}
+
+// Shutdown stops delivering and saves the undelivered events, waiting until ctx is done at most.
+func Shutdown(ctx context.Context) {
+	dispatchMu.Lock()
+	defer dispatchMu.Unlock()
+	if d := dispatch.Swap(nil); d != nil {
+		d.shutdown(ctx)
+	}
+}
+
+// WebhookStatus is the delivery state of one webhook.
+type WebhookStatus struct {
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	Events        []string   `json:"events,omitempty"`
+	Pending       int        `json:"pending"`
+	Delivered     uint64     `json:"delivered"`
+	Failed        uint64     `json:"failed"`
+	LastDelivered *time.Time `json:"last_delivered,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// Webhooks returns the state of each configured webhook, by name.
+func Webhooks() []WebhookStatus {
+	d := dispatch.Load()
+	if d == nil {
+		return []WebhookStatus{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]WebhookStatus, 0, len(d.hooks))
+	for _, h := range d.hooks {
+		s := WebhookStatus{
+			Name:      h.cfg.Name,
+			URL:       h.cfg.URL,
+			Events:    h.cfg.Events,
+			Pending:   len(h.pending),
+			Delivered: h.delivered,
+			Failed:    h.failed,
+			LastError: h.lastErr,
+		}
+		if !h.lastOK.IsZero() {
+			t := h.lastOK
+			s.LastDelivered = &t
+		}
+		out = append(out, s)
+	}
+	slices.SortFunc(out, func(a, b WebhookStatus) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// delivery is one event on its way to one webhook; the queue file holds a list of them.
+type delivery struct {
+	Hook      string    `json:"hook"`
+	Event     Event     `json:"event"`
+	Attempts  int       `json:"attempts,omitempty"`
+	NextAt    time.Time `json:"next_at"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+type queueFile struct {
+	Deliveries []*delivery `json:"deliveries"`
+}
+
+type dispatcher struct {
+	logger *slog.Logger
+	client *http.Client
+	stop   chan struct{}
+	saved  chan struct{}
+
+	mu    sync.Mutex
+	file  string
+	hooks map[string]*hook
+	dirty bool
+}
+
+// hook is one webhook and its queue, delivered in order by its own goroutine. Fields other than the channels
+// and cancel are guarded by dispatcher.mu.
+type hook struct {
+	cfg     config.Webhook
+	pending []*delivery
+	wake    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	delivered, failed uint64
+	lastOK            time.Time
+	lastErr           string
+	failing           bool
+}
+
+func (d *dispatcher) reconfigure(cfg config.EventsConfig) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cfg.QueueFile != "" && cfg.QueueFile != d.file {
+		d.file = cfg.QueueFile
+		d.dirty = true
+	}
+	keep := make(map[string]bool, len(cfg.Webhooks))
+	for _, wc := range cfg.Webhooks {
+		keep[wc.Name] = true
+		if h := d.hooks[wc.Name]; h != nil {
+			h.cfg = wc
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		h := &hook{cfg: wc, wake: make(chan struct{}, 1), ctx: ctx, cancel: cancel, done: make(chan struct{})}
+		d.hooks[wc.Name] = h
+		go d.run(h)
+	}
+	for name, h := range d.hooks {
+		if keep[name] {
+			continue
+		}
+		h.cancel()
+		if n := len(h.pending); n > 0 {
+			d.logf(slog.LevelWarn, "webhook removed; dropping its undelivered events", "webhook", name, "events", n)
+		}
+		delete(d.hooks, name)
+		d.dirty = true
+	}
+}
+
+// load queues the deliveries saved by the last run for the webhooks that are still configured.
+func (d *dispatcher) load() {
+	b, err := os.ReadFile(d.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	var q queueFile
+	if err == nil {
+		err = json.Unmarshal(b, &q)
+	}
+	if err != nil {
+		d.logf(slog.LevelWarn, "webhook queue not read; starting empty", "file", d.file, "error", err)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	loaded, dropped := 0, 0
+	for _, dl := range q.Deliveries {
+		h := d.hooks[dl.Hook]
+		if h == nil {
+			dropped++
+			continue
+		}
+		h.pending = append(h.pending, dl)
+		loaded++
+	}
+	for _, h := range d.hooks {
+		if len(h.pending) > 0 {
+			wake(h)
+		}
+	}
+	if loaded+dropped > 0 {
+		d.logf(slog.LevelInfo, "webhook queue loaded", "file", d.file, "events", loaded, "dropped_for_removed_webhooks", dropped)
+	}
+}
+
+func (d *dispatcher) enqueue(e Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for name, h := range d.hooks {
+		if !Match(h.cfg.Events, e.Type) {
+			continue
+		}
+		if len(h.pending) >= maxPending {
+			h.pending = slices.Delete(h.pending, 0, 1)
+			h.failed++
+		}
+		h.pending = append(h.pending, &delivery{Hook: name, Event: e, NextAt: e.Time})
+		d.dirty = true
+		wake(h)
+	}
+}
+
+func wake(h *hook) {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers h's queue in order; a failing event holds back the ones after it until it is delivered or
+// given up.
+func (d *dispatcher) run(h *hook) {
+	defer close(h.done)
+	for {
+		d.mu.Lock()
+		var next *delivery
+		if len(h.pending) > 0 {
+			next = h.pending[0]
+		}
+		cfg := h.cfg
+		d.mu.Unlock()
+
+		if next == nil {
+			select {
+			case <-h.ctx.Done():
+				return
+			case <-h.wake:
+			}
+			continue
+		}
+		if wait := time.Until(next.NextAt); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-h.ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+			continue
+		}
+
+		permanent, err := d.post(h.ctx, cfg, next.Event)
+		if h.ctx.Err() != nil {
+			// Shutting down; the event stays queued and is sent again on the next start.
+			return
+		}
+		d.finish(h, next, err, permanent)
+	}
+}
+
+func (d *dispatcher) finish(h *hook, dl *delivery, err error, permanent bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dirty = true
+	remove := func() {
+		if i := slices.Index(h.pending, dl); i >= 0 {
+			h.pending = slices.Delete(h.pending, i, i+1)
+		}
+	}
+	if err == nil {
+		remove()
+		h.delivered++
+		h.lastOK = time.Now().UTC()
+		if h.failing {
+			h.failing = false
+			d.logf(slog.LevelInfo, "webhook delivering again", "webhook", h.cfg.Name, "pending", len(h.pending))
+		}
+		return
+	}
+	dl.Attempts++
+	dl.LastError = err.Error()
+	h.lastErr = dl.LastError
+	if permanent || time.Since(dl.Event.Time) >= maxAge {
+		remove()
+		h.failed++
+		d.logf(slog.LevelWarn, "webhook: giving up on event", "webhook", h.cfg.Name, "event", dl.Event.Type, "id", dl.Event.ID, "attempts", dl.Attempts, "error", err)
+		return
+	}
+	backoff := retryMax
+	if dl.Attempts < 20 {
+		backoff = min(retryBase<<(dl.Attempts-1), retryMax)
+	}
+	dl.NextAt = time.Now().Add(backoff)
+	if !h.failing {
+		h.failing = true
+		d.logf(slog.LevelWarn, "webhook delivery failed; retrying", "webhook", h.cfg.Name, "url", h.cfg.URL, "pending", len(h.pending), "error", err)
+	}
+}
+
+// post sends e to the webhook. permanent is true for a refusal that a retry will not change: a 4xx other than
+// 408 and 429.
+func (d *dispatcher) post(ctx context.Context, cfg config.Webhook, e Event) (permanent bool, err error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return true, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsplane-webhook")
+	req.Header.Set(HeaderEvent, e.Type)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(e.ID, 10))
+	if cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(cfg.Secret, body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	c := resp.StatusCode
+	return c/100 == 4 && c != http.StatusRequestTimeout && c != http.StatusTooManyRequests, fmt.Errorf("receiver returned %s", resp.Status)
+}
+
+// Sign returns the X-Dnsplane-Signature value for body: "sha256=" and the hex HMAC-SHA256 with secret.
+func Sign(secret string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
+
+func (d *dispatcher) saveLoop() {
+	defer close(d.saved)
+	t := time.NewTicker(saveEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-d.stop:
+			d.save()
+			return
+		case <-t.C:
+			d.save()
+		}
+	}
+}
+
+// save writes the queue when it changed since the last write.
+func (d *dispatcher) save() {
+	d.mu.Lock()
+	if !d.dirty {
+		d.mu.Unlock()
+		return
+	}
+	d.dirty = false
+	file := d.file
+	q := queueFile{Deliveries: []*delivery{}}
+	for _, h := range d.hooks {
+		for _, dl := range h.pending {
+			c := *dl
+			q.Deliveries = append(q.Deliveries, &c)
+		}
+	}
+	d.mu.Unlock()
+	slices.SortStableFunc(q.Deliveries, func(a, b *delivery) int {
+		switch {
+		case a.Event.ID < b.Event.ID:
+			return -1
+		case a.Event.ID > b.Event.ID:
+			return 1
+		}
+		return 0
+	})
+	if err := writeQueue(file, q); err != nil {
+		d.mu.Lock()
+		d.dirty = true
+		d.mu.Unlock()
+		d.logf(slog.LevelWarn, "webhook queue not saved", "file", file, "error", err)
+	}
+}
+
+func writeQueue(file string, q queueFile) error {
+	b, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func (d *dispatcher) shutdown(ctx context.Context) {
+	d.mu.Lock()
+	hooks := make([]*hook, 0, len(d.hooks))
+	for _, h := range d.hooks {
+		hooks = append(hooks, h)
+	}
+	d.mu.Unlock()
+	for _, h := range hooks {
+		h.cancel()
+	}
+	for _, h := range hooks {
+		select {
+		case <-h.done:
+		case <-ctx.Done():
+		}
+	}
+	close(d.stop)
+	select {
+	case <-d.saved:
+	case <-ctx.Done():
+	}
+}
+
+func (d *dispatcher) logf(level slog.Level, msg string, kv ...any) {
+	if d.logger != nil {
+		d.logger.Log(context.Background(), level, msg, kv...)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dnsplane/config"
+)
+
+// receiver is a local webhook endpoint; status decides the answer to each request.
+type receiver struct {
+	*httptest.Server
+	status atomic.Int32
+	mu     sync.Mutex
+	got    []Event
+	bodies [][]byte
+	header []http.Header
+}
+
+func newReceiver(t *testing.T) *receiver {
+	rc := &receiver{}
+	rc.status.Store(http.StatusNoContent)
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		status := int(rc.status.Load())
+		if status/100 == 2 {
+			var e Event
+			if err := json.Unmarshal(body, &e); err != nil || r.Header.Get(HeaderEvent) != e.Type {
+				http.Error(w, "bad event", http.StatusBadRequest)
+				return
+			}
+			rc.mu.Lock()
+			rc.got = append(rc.got, e)
+			rc.bodies = append(rc.bodies, body)
+			rc.header = append(rc.header, r.Header.Clone())
+			rc.mu.Unlock()
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *receiver) events() []Event {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]Event(nil), rc.got...)
+}
+
+func (rc *receiver) waitFor(t *testing.T, n int) []Event {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if got := rc.events(); len(got) >= n {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("receiver got %d events, want %d", len(rc.events()), n)
+	return nil
+}
+
+func fastRetries(t *testing.T) {
+	base, maxWait := retryBase, retryMax
+	retryBase, retryMax = 10*time.Millisecond, 50*time.Millisecond
+	t.Cleanup(func() { retryBase, retryMax = base, maxWait })
+}
+
+func stop(t *testing.T) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	Shutdown(ctx)
+}
+
+func TestWebhookSignedAndFiltered(t *testing.T) {
+	fastRetries(t)
+	rc := newReceiver(t)
+	Configure(config.EventsConfig{
+		QueueFile: filepath.Join(t.TempDir(), "queue.json"),
+		Webhooks:  []config.Webhook{{Name: "ops", URL: rc.URL, Secret: "s3cret", Events: []string{"upstream.*"}}},
+	}, nil)
+	defer stop(t)
+
+	Publish(RecordsChanged, nil)
+	down := Publish(UpstreamUnhealthy, map[string]any{"server": "192.0.2.53:53"})
+	got := rc.waitFor(t, 1)
+	if got[0].ID != down.ID || got[0].Data["server"] != "192.0.2.53:53" {
+		t.Fatalf("delivered %+v", got[0])
+	}
+	rc.mu.Lock()
+	body, h := rc.bodies[0], rc.header[0]
+	rc.mu.Unlock()
+	if sig, want := h.Get(HeaderSignature), Sign("s3cret", body); sig != want || !strings.HasPrefix(sig, "sha256=") {
+		t.Errorf("signature %q, want %q", sig, want)
+	}
+	if id := h.Get(HeaderDelivery); id != strconv.FormatUint(down.ID, 10) {
+		t.Errorf("%s = %q, want %d", HeaderDelivery, id, down.ID)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(rc.events()); n != 1 {
+		t.Errorf("records.changed went through the upstream.* filter (%d events)", n)
+	}
+}
+
+func TestWebhookRetriesInOrder(t *testing.T) {
+	fastRetries(t)
+	rc := newReceiver(t)
+	rc.status.Store(http.StatusServiceUnavailable)
+	Configure(config.EventsConfig{
+		QueueFile: filepath.Join(t.TempDir(), "queue.json"),
+		Webhooks:  []config.Webhook{{Name: "ops", URL: rc.URL}},
+	}, nil)
+	defer stop(t)
+
+	a := Publish(ClusterPeerDown, nil)
+	b := Publish(ClusterPeerUp, nil)
+	time.Sleep(100 * time.Millisecond)
+	if st := Webhooks(); len(st) != 1 || st[0].Pending != 2 || st[0].LastError == "" {
+		t.Fatalf("status while failing = %+v", st)
+	}
+	rc.status.Store(http.StatusOK)
+	got := rc.waitFor(t, 2)
+	if got[0].ID != a.ID || got[1].ID != b.ID {
+		t.Fatalf("delivered %d, %d; want %d, %d", got[0].ID, got[1].ID, a.ID, b.ID)
+	}
+}
+
+func TestWebhookGivesUpOnClientError(t *testing.T) {
+	fastRetries(t)
+	rc := newReceiver(t)
+	rc.status.Store(http.StatusGone)
+	Configure(config.EventsConfig{
+		QueueFile: filepath.Join(t.TempDir(), "queue.json"),
+		Webhooks:  []config.Webhook{{Name: "ops", URL: rc.URL}},
+	}, nil)
+	defer stop(t)
+
+	Publish(DNSSECBogus, nil)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if st := Webhooks(); st[0].Failed == 1 && st[0].Pending == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("410 not given up: %+v", Webhooks())
+}
+
+func TestWebhookQueueSurvivesRestart(t *testing.T) {
+	fastRetries(t)
+	rc := newReceiver(t)
+	rc.status.Store(http.StatusServiceUnavailable)
+	queue := filepath.Join(t.TempDir(), "queue.json")
+	cfg := config.EventsConfig{
+		QueueFile: queue,
+		Webhooks: []config.Webhook{
+			{Name: "ops", URL: rc.URL},
+			{Name: "gone", URL: rc.URL, Events: []string{CacheCompacted}},
+		},
+	}
+	Configure(cfg, nil)
+	e := Publish(CacheCompacted, map[string]any{"removed": 12})
+	time.Sleep(50 * time.Millisecond)
+	stop(t)
+
+	var q queueFile
+	b, err := os.ReadFile(queue)
+	if err == nil {
+		err = json.Unmarshal(b, &q)
+	}
+	if err != nil || len(q.Deliveries) != 2 || q.Deliveries[0].Attempts == 0 {
+		t.Fatalf("saved queue = %s (%v)", b, err)
+	}
+
+	// The next run delivers it; the webhook removed in between loses its copy.
+	rc.status.Store(http.StatusOK)
+	cfg.Webhooks = cfg.Webhooks[:1]
+	Configure(cfg, nil)
+	defer stop(t)
+	got := rc.waitFor(t, 1)
+	if got[0].ID != e.ID || got[0].Data["removed"] != float64(12) {
+		t.Fatalf("delivered %+v", got[0])
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(rc.events()); n != 1 {
+		t.Errorf("delivered %d events, want 1", n)
+	}
+}
@@ -0,0 +1,181 @@
+// Package forwardzone implements the conditional forwarding table: longest-suffix zone matching,
+// forward-only vs forward-first semantics, and reverse zones generated from CIDRs.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package forwardzone
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"dnsplane/config"
+	"dnsplane/dnsservers"
+)
+
+// Forward modes.
+const (
+	ModeOnly  = "only"
+	ModeFirst = "first"
+)
+
+// Zone is one compiled forward zone.
+type Zone struct {
+	// Name is the zone apex (lowercase, no trailing dot).
+	Name string
+	// Label is the config entry name (may be empty).
+	Label string
+	// Source is "zone" for configured names or "cidr <prefix>" for generated reverse zones.
+	Source  string
+	Servers []dnsservers.UpstreamEndpoint
+	// First means forward-first: fall back to the normal upstreams when every zone server fails.
+	First bool
+	// Timeout overrides the resolver upstream timeout when > 0.
+	Timeout time.Duration
+}
+
+// Mode returns ModeFirst or ModeOnly.
+func (z *Zone) Mode() string {
+	if z.First {
+		return ModeFirst
+	}
+	return ModeOnly
+}
+
+// Table maps zone apexes to forward zones. A nil *Table matches nothing.
+type Table struct {
+	zones map[string]*Zone
+}
+
+// Compile builds a Table from config entries. Duplicate zones, entries without servers, bad CIDRs,
+// or unknown forward modes are errors.
+func Compile(entries []config.ForwardZone) (*Table, error) {
+	t := &Table{zones: map[string]*Zone{}}
+	for i, fz := range entries {
+		label := strings.TrimSpace(fz.Name)
+		if label == "" {
+			label = fmt.Sprintf("#%d", i)
+		}
+		first := false
+		switch strings.ToLower(strings.TrimSpace(fz.Forward)) {
+		case "", ModeOnly:
+		case ModeFirst:
+			first = true
+		default:
+			return nil, fmt.Errorf("forward zone %s: forward must be %q or %q, got %q", label, ModeOnly, ModeFirst, fz.Forward)
+		}
+		if fz.TimeoutMs < 0 {
+			return nil, fmt.Errorf("forward zone %s: timeout_ms must not be negative", label)
+		}
+		servers, err := endpoints(label, fz)
+		if err != nil {
+			return nil, err
+		}
+		add := func(name, source string) error {
+			name = normalize(name)
+			if name == "" {
+				return fmt.Errorf("forward zone %s: empty zone name", label)
+			}
+			if prev, dup := t.zones[name]; dup {
+				return fmt.Errorf("forward zone %s: zone %s already defined by %s", label, name, prev.Label)
+			}
+			t.zones[name] = &Zone{
+				Name:    name,
+				Label:   strings.TrimSpace(fz.Name),
+				Source:  source,
+				Servers: servers,
+				First:   first,
+				Timeout: time.Duration(fz.TimeoutMs) * time.Millisecond,
+			}
+			return nil
+		}
+		for _, z := range fz.Zones {
+			if err := add(z, "zone"); err != nil {
+				return nil, err
+			}
+		}
+		for _, cidr := range fz.ReverseCIDRs {
+			names, err := ReverseZones(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("forward zone %s: %w", label, err)
+			}
+			for _, n := range names {
+				if err := add(n, "cidr "+strings.TrimSpace(cidr)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(fz.Zones) == 0 && len(fz.ReverseCIDRs) == 0 {
+			return nil, fmt.Errorf("forward zone %s: no zones or reverse_cidrs", label)
+		}
+	}
+	return t, nil
+}
+
+func endpoints(label string, fz config.ForwardZone) ([]dnsservers.UpstreamEndpoint, error) {
+	if len(fz.Servers) == 0 {
+		return nil, fmt.Errorf("forward zone %s: no servers", label)
+	}
+	out := make([]dnsservers.UpstreamEndpoint, 0, len(fz.Servers))
+	for _, s := range fz.Servers {
+		if strings.TrimSpace(s.Address) == "" && strings.TrimSpace(s.DoHURL) == "" {
+			return nil, fmt.Errorf("forward zone %s: server without address", label)
+		}
+		transport := s.Transport
+		if strings.TrimSpace(transport) == "" {
+			transport = fz.Transport
+		}
+		out = append(out, dnsservers.ServerToEndpoint(dnsservers.DNSServer{
+			Address:   s.Address,
+			Port:      s.Port,
+			Transport: transport,
+			DoHURL:    s.DoHURL,
+		}))
+	}
+	return out, nil
+}
+
+// Len returns the number of zones (including generated reverse zones).
+func (t *Table) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.zones)
+}
+
+// Match returns the longest zone that equals or contains qname, or nil.
+func (t *Table) Match(qname string) *Zone {
+	if t.Len() == 0 {
+		return nil
+	}
+	name := normalize(qname)
+	for name != "" {
+		if z, ok := t.zones[name]; ok {
+			return z
+		}
+		_, rest, found := strings.Cut(name, ".")
+		if !found {
+			break
+		}
+		name = rest
+	}
+	return nil
+}
+
+// Zones returns every zone sorted by name.
+func (t *Table) Zones() []*Zone {
+	if t.Len() == 0 {
+		return nil
+	}
+	out := make([]*Zone, 0, len(t.zones))
+	for _, z := range t.zones {
+		out = append(out, z)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
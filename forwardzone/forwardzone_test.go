@@ -0,0 +1,103 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package forwardzone
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"dnsplane/config"
+)
+
+func TestReverseZones(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want []string
+	}{
+		{"10.20.0.0/16", []string{"20.10.in-addr.arpa"}},
+		{"10.0.0.0/8", []string{"10.in-addr.arpa"}},
+		{"192.168.1.0/24", []string{"1.168.192.in-addr.arpa"}},
+		{"192.168.0.0/23", []string{"0.168.192.in-addr.arpa", "1.168.192.in-addr.arpa"}},
+		{"fd00::/8", []string{"d.f.ip6.arpa"}},
+		{"2001:db8::/30", []string{"8.b.d.0.1.0.0.2.ip6.arpa", "9.b.d.0.1.0.0.2.ip6.arpa", "a.b.d.0.1.0.0.2.ip6.arpa", "b.b.d.0.1.0.0.2.ip6.arpa"}},
+	}
+	for _, tt := range tests {
+		got, err := ReverseZones(tt.cidr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.cidr, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReverseZones(%s) = %v, want %v", tt.cidr, got, tt.want)
+		}
+	}
+	if got, _ := ReverseZones("172.16.0.0/12"); len(got) != 16 || got[0] != "16.172.in-addr.arpa" || got[15] != "31.172.in-addr.arpa" {
+		t.Errorf("172.16.0.0/12 = %v", got)
+	}
+	for _, bad := range []string{"nonsense", "10.0.0.0/33", "::ffff:10.0.0.0/104"} {
+		if _, err := ReverseZones(bad); err == nil {
+			t.Errorf("ReverseZones(%s): expected error", bad)
+		}
+	}
+}
+
+func TestMatchLongestSuffix(t *testing.T) {
+	tbl, err := Compile([]config.ForwardZone{
+		{Name: "corp", Zones: []string{"corp.example."}, Servers: []config.ForwardServer{{Address: "10.0.0.53"}}},
+		{Name: "lab", Zones: []string{"lab.corp.example"}, Servers: []config.ForwardServer{{Address: "10.9.0.53", Port: "5353"}}, Forward: "first", Transport: "tcp", TimeoutMs: 500},
+		{Name: "rfc1918", ReverseCIDRs: []string{"10.20.0.0/16"}, Servers: []config.ForwardServer{{Address: "10.0.0.53"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Len() != 3 {
+		t.Fatalf("Len = %d", tbl.Len())
+	}
+	cases := map[string]string{
+		"corp.example.":           "corp.example",
+		"www.corp.example.":       "corp.example",
+		"host.lab.corp.example.":  "lab.corp.example",
+		"LAB.CORP.EXAMPLE":        "lab.corp.example",
+		"5.1.20.10.in-addr.arpa.": "20.10.in-addr.arpa",
+		"5.1.21.10.in-addr.arpa.": "",
+		"othercorp.example.":      "",
+		"example.":                "",
+	}
+	for name, want := range cases {
+		got := ""
+		if z := tbl.Match(name); z != nil {
+			got = z.Name
+		}
+		if got != want {
+			t.Errorf("Match(%s) = %q, want %q", name, got, want)
+		}
+	}
+	lab := tbl.Match("x.lab.corp.example.")
+	if lab.Mode() != ModeFirst || lab.Timeout != 500*time.Millisecond || lab.Servers[0].Addr != "10.9.0.53:5353" || lab.Servers[0].Transport != "tcp" {
+		t.Fatalf("lab zone = %+v", lab)
+	}
+	if z := tbl.Match("x.corp.example."); z.Mode() != ModeOnly || z.Servers[0].Transport != "udp" {
+		t.Fatalf("corp zone = %+v", z)
+	}
+	var nilTable *Table
+	if nilTable.Match("corp.example.") != nil {
+		t.Fatal("nil table matched")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	srv := []config.ForwardServer{{Address: "10.0.0.53"}}
+	bad := [][]config.ForwardZone{
+		{{Zones: []string{"a.example"}}},
+		{{Servers: srv}},
+		{{Zones: []string{"a.example"}, Servers: srv, Forward: "sometimes"}},
+		{{Zones: []string{"a.example"}, Servers: srv}, {Zones: []string{"A.example."}, Servers: srv}},
+		{{ReverseCIDRs: []string{"10.0.0.0/99"}, Servers: srv}},
+		{{Zones: []string{"a.example"}, Servers: []config.ForwardServer{{Port: "53"}}}},
+	}
+	for i, cfg := range bad {
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
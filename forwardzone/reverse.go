@@ -0,0 +1,66 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package forwardzone
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// maxReverseZones caps how many zones one CIDR may expand to.
+const maxReverseZones = 256
+
+// ReverseZones returns the in-addr.arpa / ip6.arpa zones covering cidr. Prefixes that do not fall on an
+// octet (IPv4) or nibble (IPv6) boundary expand to every zone at the next boundary, so 172.16.0.0/12
+// yields 16.172.in-addr.arpa through 31.172.in-addr.arpa.
+func ReverseZones(cidr string) ([]string, error) {
+	p, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	if err != nil {
+		return nil, fmt.Errorf("reverse cidr %q: %w", cidr, err)
+	}
+	p = p.Masked()
+	addr := p.Addr()
+	if addr.Is4In6() {
+		return nil, fmt.Errorf("reverse cidr %q: use the plain IPv4 form", cidr)
+	}
+	unit, suffix := 4, "ip6.arpa"
+	if addr.Is4() {
+		unit, suffix = 8, "in-addr.arpa"
+	}
+	labels := (p.Bits() + unit - 1) / unit
+	spread := labels*unit - p.Bits()
+	count := 1 << spread
+	if count > maxReverseZones {
+		return nil, fmt.Errorf("reverse cidr %q expands to too many zones", cidr)
+	}
+	b := addr.AsSlice()
+	unitValue := func(i int) int {
+		if unit == 8 {
+			return int(b[i])
+		}
+		if i%2 == 0 {
+			return int(b[i/2] >> 4)
+		}
+		return int(b[i/2] & 0x0f)
+	}
+	out := make([]string, 0, count)
+	for n := 0; n < count; n++ {
+		parts := make([]string, 0, labels+1)
+		for i := labels - 1; i >= 0; i-- {
+			v := unitValue(i)
+			if i == labels-1 {
+				v += n
+			}
+			if unit == 8 {
+				parts = append(parts, strconv.Itoa(v))
+			} else {
+				parts = append(parts, strconv.FormatInt(int64(v), 16))
+			}
+		}
+		parts = append(parts, suffix)
+		out = append(out, strings.Join(parts, "."))
+	}
+	return out, nil
+}
@@ -0,0 +1,168 @@
+// Package geo locates DNS clients by operator-defined CIDR regions and MaxMind-format databases, for
+// local records that answer differently per client location.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package geo
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"dnsplane/config"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// Location is what is known about a client address. Empty fields are unknown.
+type Location struct {
+	// Region is the name of the most specific configured region containing the address.
+	Region string
+	// Country is the ISO 3166-1 alpha-2 code (upper case) and Continent the two-letter continent code.
+	Country   string
+	Continent string
+	ASN       uint32
+}
+
+type regionPrefix struct {
+	prefix netip.Prefix
+	name   string
+}
+
+type database struct {
+	path    string
+	modTime time.Time
+	size    int64
+	db      *maxminddb.Reader
+}
+
+// record is the part of a GeoLite2/GeoIP2 Country, City, or ASN record a Location is built from.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	Continent struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"continent"`
+	ASN uint32 `maxminddb:"autonomous_system_number"`
+}
+
+// Locator maps client addresses to Locations. A nil *Locator knows nothing.
+type Locator struct {
+	regions []regionPrefix // longest prefix first
+	dbs     []*database
+}
+
+// Compile builds a Locator from cfg. Databases unchanged on disk since prev was compiled are reused
+// instead of read again. Unknown paths, unreadable files, and bad CIDRs are errors.
+func Compile(cfg config.GeoConfig, prev *Locator) (*Locator, error) {
+	l := &Locator{}
+	for name, cidrs := range cfg.Regions {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("geo: region with empty name")
+		}
+		for _, c := range cidrs {
+			p, err := netip.ParsePrefix(strings.TrimSpace(c))
+			if err != nil {
+				return nil, fmt.Errorf("geo: region %q: %w", name, err)
+			}
+			l.regions = append(l.regions, regionPrefix{prefix: p.Masked(), name: name})
+		}
+	}
+	sort.SliceStable(l.regions, func(i, j int) bool {
+		if a, b := l.regions[i].prefix.Bits(), l.regions[j].prefix.Bits(); a != b {
+			return a > b
+		}
+		return l.regions[i].name < l.regions[j].name
+	})
+	for _, path := range cfg.Databases {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		st, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("geo: %w", err)
+		}
+		if db := prev.reusable(path, st); db != nil {
+			l.dbs = append(l.dbs, db)
+			continue
+		}
+		m, err := openDatabase(path)
+		if err != nil {
+			return nil, fmt.Errorf("geo: %s: %w", path, err)
+		}
+		l.dbs = append(l.dbs, &database{path: path, modTime: st.ModTime(), size: st.Size(), db: m})
+	}
+	return l, nil
+}
+
+// openDatabase reads the whole file rather than mapping it, so a database replaced in place cannot change
+// under a Locator that is still answering queries.
+func openDatabase(path string) (*maxminddb.Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return maxminddb.OpenBytes(buf)
+}
+
+func (l *Locator) reusable(path string, st os.FileInfo) *database {
+	if l == nil {
+		return nil
+	}
+	for _, d := range l.dbs {
+		if d.path == path && d.size == st.Size() && d.modTime.Equal(st.ModTime()) {
+			return d
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether any region or database is configured.
+func (l *Locator) Enabled() bool {
+	return l != nil && (len(l.regions) > 0 || len(l.dbs) > 0)
+}
+
+// Locate returns what the regions and databases know about ip. Earlier databases win for fields
+// several of them provide.
+func (l *Locator) Locate(ip netip.Addr) Location {
+	var loc Location
+	if l == nil || !ip.IsValid() {
+		return loc
+	}
+	ip = ip.Unmap()
+	for _, r := range l.regions {
+		if r.prefix.Contains(ip) {
+			loc.Region = r.name
+			break
+		}
+	}
+	for _, d := range l.dbs {
+		var rec record
+		res := d.db.Lookup(ip)
+		if !res.Found() || res.Decode(&rec) != nil {
+			continue
+		}
+		if loc.Country == "" {
+			loc.Country = strings.ToUpper(rec.Country.ISOCode)
+			if loc.Country == "" {
+				loc.Country = strings.ToUpper(rec.RegisteredCountry.ISOCode)
+			}
+		}
+		if loc.Continent == "" {
+			loc.Continent = strings.ToUpper(rec.Continent.Code)
+		}
+		if loc.ASN == 0 {
+			loc.ASN = rec.ASN
+		}
+	}
+	return loc
+}
@@ -0,0 +1,111 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package geo
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dnsplane/config"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// writeTestDB writes a database laid out like GeoLite2 Country and ASN, built with MaxMind's own writer.
+func writeTestDB(t *testing.T) string {
+	t.Helper()
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: "GeoLite2-Country", IncludeReservedNetworks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cidr, rec := range map[string]mmdbtype.Map{
+		"81.2.69.0/24": {
+			"country": mmdbtype.Map{
+				"iso_code": mmdbtype.String("gb"),
+				"names":    mmdbtype.Map{"en": mmdbtype.String("United Kingdom")},
+			},
+			"continent": mmdbtype.Map{"code": mmdbtype.String("EU")},
+		},
+		"2001:db8:1::/48": {
+			"registered_country":       mmdbtype.Map{"iso_code": mmdbtype.String("DE")},
+			"autonomous_system_number": mmdbtype.Uint32(64500),
+		},
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Insert(network, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := tree.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenDatabase(t *testing.T) {
+	db, err := openDatabase(writeTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Metadata.DatabaseType != "GeoLite2-Country" {
+		t.Fatalf("database_type = %q", db.Metadata.DatabaseType)
+	}
+	if res := db.Lookup(netip.MustParseAddr("81.2.70.1")); res.Found() {
+		t.Fatal("address outside every network found")
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.mmdb")
+	if err := os.WriteFile(bad, []byte("not a database"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Compile(config.GeoConfig{Databases: []string{bad}}, nil); err == nil {
+		t.Fatal("garbage database accepted")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	path := writeTestDB(t)
+	l, err := Compile(config.GeoConfig{
+		Databases: []string{path},
+		Regions:   map[string][]string{"eu": {"10.1.0.0/16"}, "office": {"10.1.2.0/24"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{"10.1.2.3", Location{Region: "office"}},
+		{"10.1.9.9", Location{Region: "eu"}},
+		{"::ffff:81.2.69.1", Location{Country: "GB", Continent: "EU"}},
+		{"2001:db8:1::53", Location{Country: "DE", ASN: 64500}},
+		{"192.0.2.1", Location{}},
+	}
+	for _, tt := range tests {
+		if got := l.Locate(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("Locate(%s) = %+v, want %+v", tt.ip, got, tt.want)
+		}
+	}
+
+	again, err := Compile(config.GeoConfig{Databases: []string{path}}, l)
+	if err != nil || again.dbs[0] != l.dbs[0] {
+		t.Fatalf("unchanged database not reused: err=%v", err)
+	}
+	if _, err := Compile(config.GeoConfig{Regions: map[string][]string{"x": {"10.0.0.0/33"}}}, nil); err == nil {
+		t.Fatal("bad CIDR accepted")
+	}
+}
@@ -0,0 +1,95 @@
+// Package healthcheck runs the probes attached to local records (TCP connect, HTTP(S) GET, DNS query).
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/dnsrecords"
+)
+
+// Probe checks ip with hc and returns nil when healthy. name is the record name, used as the default
+// query name of dns probes.
+func Probe(ctx context.Context, ip, name string, hc dnsrecords.HealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, hc.Timeout())
+	defer cancel()
+	switch strings.ToLower(hc.Type) {
+	case dnsrecords.HealthCheckTCP:
+		return probeTCP(ctx, hc.Addr(ip))
+	case dnsrecords.HealthCheckHTTP, dnsrecords.HealthCheckHTTPS:
+		return probeHTTP(ctx, ip, hc)
+	case dnsrecords.HealthCheckDNS:
+		qname := hc.QueryName
+		if qname == "" {
+			qname = name
+		}
+		return probeDNS(ctx, hc.Addr(ip), qname)
+	default:
+		return fmt.Errorf("unknown health check type %q", hc.Type)
+	}
+}
+
+func probeTCP(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func probeHTTP(ctx context.Context, ip string, hc dnsrecords.HealthCheck) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hc.URL(ip), nil)
+	if err != nil {
+		return err
+	}
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: hc.TLSSkipVerify} // #nosec G402 -- opt-in per check
+	if hc.Host != "" {
+		req.Host = hc.Host
+		tlsCfg.ServerName = hc.Host
+	}
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsCfg, DisableKeepAlives: true},
+		// A redirect is an answer; the probe does not follow it.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+	if hc.ExpectStatus != 0 {
+		if resp.StatusCode != hc.ExpectStatus {
+			return fmt.Errorf("status %d, want %d", resp.StatusCode, hc.ExpectStatus)
+		}
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func probeDNS(ctx context.Context, addr, qname string) error {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(qname), dns.TypeA)
+	client := &dns.Client{Net: "udp"}
+	resp, _, err := client.ExchangeContext(ctx, m, addr)
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return fmt.Errorf("rcode %s", dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
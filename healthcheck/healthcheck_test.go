@@ -0,0 +1,108 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/dnsrecords"
+)
+
+func splitPort(t *testing.T, addr string) (string, int) {
+	t.Helper()
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(p)
+	return host, port
+}
+
+func TestProbeHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if r.Host != "app.example" {
+				w.WriteHeader(http.StatusMisdirectedRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/teapot":
+			w.WriteHeader(http.StatusTeapot)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	ip, port := splitPort(t, srv.Listener.Addr().String())
+	ok := dnsrecords.HealthCheck{Type: "http", Port: port, Path: "/health", Host: "app.example"}
+	if err := Probe(context.Background(), ip, "app.example.", ok); err != nil {
+		t.Fatalf("healthy endpoint: %v", err)
+	}
+	if err := Probe(context.Background(), ip, "app.example.", dnsrecords.HealthCheck{Type: "http", Port: port, Path: "/down"}); err == nil {
+		t.Fatal("503 counted as healthy")
+	}
+	teapot := dnsrecords.HealthCheck{Type: "http", Port: port, Path: "/teapot", ExpectStatus: http.StatusTeapot}
+	if err := Probe(context.Background(), ip, "app.example.", teapot); err != nil {
+		t.Fatalf("expect_status: %v", err)
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip, port := splitPort(t, ln.Addr().String())
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.Close()
+		}
+	}()
+	hc := dnsrecords.HealthCheck{Type: "tcp", Port: port, TimeoutMs: 500}
+	if err := Probe(context.Background(), ip, "x.", hc); err != nil {
+		t.Fatalf("listening port: %v", err)
+	}
+	_ = ln.Close()
+	if err := Probe(context.Background(), ip, "x.", hc); err == nil {
+		t.Fatal("closed port counted as healthy")
+	}
+}
+
+func TestProbeDNS(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := dns.NewServeMux()
+	mux.HandleFunc(".", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		if r.Question[0].Name == "broken.example." {
+			m.Rcode = dns.RcodeServerFailure
+		}
+		_ = w.WriteMsg(m)
+	})
+	srv := &dns.Server{PacketConn: pc, Handler: mux}
+	go func() { _ = srv.ActivateAndServe() }()
+	defer func() { _ = srv.Shutdown() }()
+	ip, port := splitPort(t, pc.LocalAddr().String())
+	if err := Probe(context.Background(), ip, "ns1.example.", dnsrecords.HealthCheck{Type: "dns", Port: port}); err != nil {
+		t.Fatalf("answering server: %v", err)
+	}
+	bad := dnsrecords.HealthCheck{Type: "dns", Port: port, QueryName: "broken.example"}
+	if err := Probe(context.Background(), ip, "ns1.example.", bad); err == nil {
+		t.Fatal("SERVFAIL counted as healthy")
+	}
+}
@@ -0,0 +1,417 @@
+// Package journal keeps an append-only history of changes to the local DNS records in bbolt.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package journal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dnsplane/dnsrecords"
+
+	"github.com/miekg/dns"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	entriesBucket = "entries"
+	dbFileName    = "history.db"
+)
+
+// ErrDisabled is returned when record history is not enabled.
+var ErrDisabled = errors.New("record history is disabled (records_history)")
+
+// Sources of a change.
+const (
+	SourceAPI     = "api"
+	SourceTUI     = "tui"
+	SourceCluster = "cluster"
+	SourceReload  = "reload"
+	SourceStartup = "startup"
+	SourceExpiry  = "expiry"
+	SourceDHCP    = "dhcp"
+)
+
+// Origin says who made a change. Actor is the API token name, TUI session address, cluster node ID, or
+// RFC 2136 key name, depending on Source; Addr is the client address when known.
+type Origin struct {
+	Source string `json:"source"`
+	Actor  string `json:"actor,omitempty"`
+	Addr   string `json:"addr,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// Change is one record before and after an entry: Before is nil for an added record, After for a
+// removed one.
+type Change struct {
+	Before *dnsrecords.DNSRecord `json:"before,omitempty"`
+	After  *dnsrecords.DNSRecord `json:"after,omitempty"`
+}
+
+// Kind returns "added", "removed", or "updated".
+func (c Change) Kind() string {
+	switch {
+	case c.Before == nil:
+		return "added"
+	case c.After == nil:
+		return "removed"
+	}
+	return "updated"
+}
+
+// Entry is one revision of the record set.
+type Entry struct {
+	Rev  uint64    `json:"rev"`
+	Time time.Time `json:"time"`
+	Origin
+	Changes []Change `json:"changes"`
+}
+
+// Filter selects entries and, within them, changes. Zero fields match everything.
+type Filter struct {
+	ID    string
+	Name  string
+	Zone  string
+	Limit int
+}
+
+// Journal is an open history database. The state at the newest revision is kept in memory, so each
+// Record only diffs against it.
+type Journal struct {
+	mu   sync.Mutex
+	db   *bbolt.DB
+	rev  uint64
+	head *state
+}
+
+// Open opens or creates the history database in dir and replays it.
+func Open(dir string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("journal: create directory: %w", err)
+	}
+	db, err := bbolt.Open(filepath.Join(dir, dbFileName), 0o600, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("journal: open database: %w", err)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(entriesBucket))
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("journal: create bucket: %w", err)
+	}
+	j := &Journal{db: db, head: newState()}
+	rev, err := j.replay(math.MaxUint64, j.head)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	j.rev = rev
+	return j, nil
+}
+
+// Close closes the database.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.db.Close()
+}
+
+// Head returns the newest revision (0 when the journal is empty).
+func (j *Journal) Head() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.rev
+}
+
+// Record appends an entry for the changes from the newest revision to records. It reports false and
+// writes nothing when records are unchanged.
+func (j *Journal) Record(o Origin, records []dnsrecords.DNSRecord) (Entry, bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	changes := Diff(j.head.records(), records)
+	if len(changes) == 0 {
+		return Entry{}, false, nil
+	}
+	e := Entry{Time: time.Now().UTC(), Origin: o, Changes: changes}
+	err := j.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(entriesBucket))
+		rev, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		e.Rev = rev
+		v, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		return b.Put(revKey(rev), v)
+	})
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("journal: append: %w", err)
+	}
+	j.rev = e.Rev
+	j.head.apply(e.Changes)
+	return e, true, nil
+}
+
+// Entries returns matching entries, newest first, each holding only its matching changes.
+func (j *Journal) Entries(f Filter) ([]Entry, error) {
+	zone := ""
+	if z := strings.TrimSpace(f.Zone); z != "" {
+		zone = dns.CanonicalName(z)
+	}
+	name := ""
+	if n := strings.TrimSpace(f.Name); n != "" {
+		name = dns.CanonicalName(n)
+	}
+	match := func(r *dnsrecords.DNSRecord) bool {
+		if r == nil {
+			return false
+		}
+		owner := dns.CanonicalName(strings.TrimSpace(r.Name))
+		return (f.ID == "" || r.ID == f.ID) &&
+			(name == "" || owner == name) &&
+			(zone == "" || dns.IsSubDomain(zone, owner))
+	}
+	var out []Entry
+	err := j.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte(entriesBucket)).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("journal: rev %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			if f.ID != "" || name != "" || zone != "" {
+				kept := e.Changes[:0]
+				for _, ch := range e.Changes {
+					if match(ch.Before) || match(ch.After) {
+						kept = append(kept, ch)
+					}
+				}
+				if len(kept) == 0 {
+					continue
+				}
+				e.Changes = kept
+			}
+			out = append(out, e)
+			if f.Limit > 0 && len(out) >= f.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+// StateAt returns the records as of rev. Revision 0 is the empty set before the first entry.
+func (j *Journal) StateAt(rev uint64) ([]dnsrecords.DNSRecord, error) {
+	j.mu.Lock()
+	head := j.rev
+	if rev == head {
+		out := j.head.records()
+		j.mu.Unlock()
+		return out, nil
+	}
+	j.mu.Unlock()
+	if rev > head {
+		return nil, fmt.Errorf("journal: revision %d does not exist (newest is %d)", rev, head)
+	}
+	s := newState()
+	if _, err := j.replay(rev, s); err != nil {
+		return nil, err
+	}
+	return s.records(), nil
+}
+
+// Resolve turns a point in time into a revision: "" or "head" is the newest revision, a number is a
+// revision, and an RFC 3339 time is the newest revision at or before it.
+func (j *Journal) Resolve(point string) (uint64, error) {
+	point = strings.TrimSpace(point)
+	if point == "" || strings.EqualFold(point, "head") {
+		return j.Head(), nil
+	}
+	if rev, err := strconv.ParseUint(point, 10, 64); err == nil {
+		if head := j.Head(); rev > head {
+			return 0, fmt.Errorf("revision %d does not exist (newest is %d)", rev, head)
+		}
+		return rev, nil
+	}
+	t, err := time.Parse(time.RFC3339, point)
+	if err != nil {
+		return 0, fmt.Errorf("invalid revision or time %q (want a number or RFC 3339 time)", point)
+	}
+	var rev uint64
+	err = j.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte(entriesBucket)).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e struct {
+				Time time.Time `json:"time"`
+			}
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if !e.Time.After(t) {
+				rev = binary.BigEndian.Uint64(k)
+				return nil
+			}
+		}
+		return nil
+	})
+	return rev, err
+}
+
+// DiffRevs returns the changes that turn the records at from into the records at to.
+func (j *Journal) DiffRevs(from, to uint64) ([]Change, error) {
+	a, err := j.StateAt(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := j.StateAt(to)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(a, b), nil
+}
+
+// replay applies the entries up to and including rev to s and returns the last revision applied.
+func (j *Journal) replay(rev uint64, s *state) (uint64, error) {
+	var last uint64
+	err := j.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte(entriesBucket)).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			r := binary.BigEndian.Uint64(k)
+			if r > rev {
+				return nil
+			}
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("journal: rev %d: %w", r, err)
+			}
+			s.apply(e.Changes)
+			last = r
+		}
+		return nil
+	})
+	return last, err
+}
+
+func revKey(rev uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, rev)
+	return k
+}
+
+// Diff returns the changes that turn before into after. Records are matched by id, or by name, type,
+// and value when they have none; last_query is ignored.
+func Diff(before, after []dnsrecords.DNSRecord) []Change {
+	old := make(map[string]dnsrecords.DNSRecord, len(before))
+	for _, r := range before {
+		old[recordKey(r)] = r
+	}
+	var out []Change
+	seen := make(map[string]bool, len(after))
+	for _, r := range after {
+		key := recordKey(r)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		a := r
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			out = append(out, Change{After: &a})
+		case !sameRecord(prev, r):
+			b := prev
+			out = append(out, Change{Before: &b, After: &a})
+		}
+	}
+	gone := make(map[string]bool)
+	for _, r := range before {
+		key := recordKey(r)
+		if seen[key] || gone[key] {
+			continue
+		}
+		gone[key] = true
+		b := r
+		out = append(out, Change{Before: &b})
+	}
+	return out
+}
+
+func recordKey(r dnsrecords.DNSRecord) string {
+	if r.ID != "" {
+		return "id:" + r.ID
+	}
+	t := dnsrecords.NormalizeRecordType(r.Type)
+	return dnsrecords.NormalizeRecordNameKey(r.Name) + "|" + t + "|" + dnsrecords.NormalizeRecordValueKey(t, r.Value)
+}
+
+// sameRecord compares the JSON forms so times read back from the database equal their originals.
+func sameRecord(a, b dnsrecords.DNSRecord) bool {
+	a.LastQuery, b.LastQuery = time.Time{}, time.Time{}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// state is a record set keyed like Diff, in insertion order.
+type state struct {
+	order []string
+	recs  map[string]dnsrecords.DNSRecord
+}
+
+func newState() *state {
+	return &state{recs: make(map[string]dnsrecords.DNSRecord)}
+}
+
+func (s *state) apply(changes []Change) {
+	for _, ch := range changes {
+		if ch.Before != nil && (ch.After == nil || recordKey(*ch.Before) != recordKey(*ch.After)) {
+			delete(s.recs, recordKey(*ch.Before))
+		}
+		if ch.After != nil {
+			key := recordKey(*ch.After)
+			if _, ok := s.recs[key]; !ok {
+				s.order = append(s.order, key)
+			}
+			s.recs[key] = *ch.After
+		}
+	}
+	if len(s.order) > 2*len(s.recs)+64 {
+		s.order = s.keys()
+	}
+}
+
+// keys returns the live keys in order, once each.
+func (s *state) keys() []string {
+	out := make([]string, 0, len(s.recs))
+	seen := make(map[string]bool, len(s.recs))
+	for _, k := range s.order {
+		if _, ok := s.recs[k]; ok && !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func (s *state) records() []dnsrecords.DNSRecord {
+	keys := s.keys()
+	out := make([]dnsrecords.DNSRecord, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, s.recs[k])
+	}
+	return out
+}
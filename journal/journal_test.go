@@ -0,0 +1,83 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package journal
+
+import (
+	"testing"
+	"time"
+
+	"dnsplane/dnsrecords"
+)
+
+func TestRecordReplayAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := []dnsrecords.DNSRecord{
+		{ID: "a", Name: "www.example.com", Type: "A", Value: "192.0.2.1", TTL: 300, AddedOn: time.Now()},
+		{Name: "mail.example.com", Type: "A", Value: "192.0.2.2", TTL: 300},
+	}
+	if _, ok, err := j.Record(Origin{Source: SourceStartup}, v1); err != nil || !ok {
+		t.Fatalf("record v1: %v %v", ok, err)
+	}
+	if _, ok, _ := j.Record(Origin{Source: SourceAPI}, v1); ok {
+		t.Fatal("unchanged records were journaled")
+	}
+	v2 := []dnsrecords.DNSRecord{v1[0], {Name: "ftp.example.org", Type: "A", Value: "198.51.100.1", TTL: 60}}
+	v2[0].TTL = 60
+	e, ok, err := j.Record(Origin{Source: SourceTUI, Actor: "127.0.0.1:5000"}, v2)
+	if err != nil || !ok || e.Rev != 2 {
+		t.Fatalf("record v2: rev %d %v %v", e.Rev, ok, err)
+	}
+	kinds := map[string]int{}
+	for _, ch := range e.Changes {
+		kinds[ch.Kind()]++
+	}
+	if kinds["added"] != 1 || kinds["removed"] != 1 || kinds["updated"] != 1 {
+		t.Fatalf("changes %v", kinds)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = j.Close() }()
+	if j.Head() != 2 {
+		t.Fatalf("head %d after reopen", j.Head())
+	}
+	got, err := j.StateAt(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(Diff(got, v1)) != 0 {
+		t.Fatalf("state at 1: %+v", got)
+	}
+	if got, _ := j.StateAt(0); len(got) != 0 {
+		t.Fatalf("state at 0: %+v", got)
+	}
+	changes, err := j.DiffRevs(1, 2)
+	if err != nil || len(changes) != 3 {
+		t.Fatalf("diff 1..2: %d %v", len(changes), err)
+	}
+
+	entries, err := j.Entries(Filter{Zone: "example.org"})
+	if err != nil || len(entries) != 1 || entries[0].Rev != 2 || len(entries[0].Changes) != 1 {
+		t.Fatalf("zone history: %+v %v", entries, err)
+	}
+	entries, _ = j.Entries(Filter{ID: "a"})
+	if len(entries) != 2 || entries[0].Actor != "127.0.0.1:5000" {
+		t.Fatalf("id history: %+v", entries)
+	}
+
+	if rev, err := j.Resolve(time.Now().Add(time.Hour).Format(time.RFC3339)); err != nil || rev != 2 {
+		t.Fatalf("resolve time: %d %v", rev, err)
+	}
+	if _, err := j.Resolve("9"); err == nil {
+		t.Fatal("future revision resolved")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package journal
+
+import (
+	"fmt"
+	"strings"
+
+	"dnsplane/dnsrecords"
+	"dnsplane/zones"
+
+	"github.com/miekg/dns"
+)
+
+// DiffLines returns changes as zone file lines: "- " for a removed record, "+ " for an added one, and
+// both for an updated one.
+func DiffLines(changes []Change) []string {
+	var out []string
+	for _, ch := range changes {
+		if ch.Before != nil {
+			out = append(out, "- "+recordLine(*ch.Before))
+		}
+		if ch.After != nil {
+			out = append(out, "+ "+recordLine(*ch.After))
+		}
+	}
+	return out
+}
+
+func recordLine(r dnsrecords.DNSRecord) string {
+	line, err := zones.FormatRecord(r)
+	if err != nil {
+		return fmt.Sprintf("%s\t%d\tIN\t%s\t%s", dns.Fqdn(strings.TrimSpace(r.Name)), r.TTL, r.Type, r.Value)
+	}
+	return strings.TrimPrefix(line, "; ")
+}
@@ -0,0 +1,175 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+// Package liveconfig applies a new configuration to the running server, for PATCH /config and for reloads of
+// dnsplane.json. Settings read where they are used take effect at once; the listeners a change needs restart,
+// and when one does not come back the old configuration is put back.
+package liveconfig
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"dnsplane/acl"
+	"dnsplane/config"
+	"dnsplane/data"
+	"dnsplane/events"
+	"dnsplane/forwardzone"
+	"dnsplane/policy"
+)
+
+// Hooks gives the package what main owns. Restart restarts one listener (config.RestartDNS, RestartAPI or
+// RestartTUI) with the settings in memory; ApplyLive rebuilds state kept outside the settings, such as the rate
+// limiters.
+type Hooks struct {
+	Restart   func(listener string) error
+	ApplyLive func(st config.Config)
+}
+
+var (
+	hooks atomic.Pointer[Hooks]
+	// applyMu lets one configuration change, with its restarts and any rollback, finish before the next.
+	applyMu sync.Mutex
+)
+
+// SetHooks registers the hooks Apply uses.
+func SetHooks(h Hooks) {
+	hooks.Store(&h)
+}
+
+// Lock serializes configuration changes. Hold it from reading the running settings until the change, with the
+// restarts it needs, is done.
+func Lock() { applyMu.Lock() }
+
+// Unlock releases Lock.
+func Unlock() { applyMu.Unlock() }
+
+// Proposal is a configuration checked against the one in use.
+type Proposal struct {
+	Old, Updated config.Config
+	Errors       []config.FieldError
+	Changes      []config.Change
+}
+
+// Propose normalizes updated as when the file is loaded, validates it, and diffs it against old.
+func Propose(old, updated config.Config) Proposal {
+	updated.Normalize(filepath.Dir(data.ConfigPath()))
+	return Proposal{
+		Old:     old,
+		Updated: updated,
+		Errors:  append(config.Validate(updated), compileErrors(updated)...),
+		Changes: config.Changes(old, updated),
+	}
+}
+
+// Restarts lists the listeners the change restarts.
+func (p Proposal) Restarts() []string {
+	return config.Restarts(p.Changes)
+}
+
+// PendingRestart lists the changed keys that only take effect when dnsplane restarts.
+func (p Proposal) PendingRestart() []string {
+	var out []string
+	for _, c := range p.Changes {
+		if c.Restart == config.RestartProcess {
+			out = append(out, c.Field)
+		}
+	}
+	return out
+}
+
+// Fields lists the changed keys.
+func (p Proposal) Fields() []string {
+	out := make([]string, 0, len(p.Changes))
+	for _, c := range p.Changes {
+		out = append(out, c.Field)
+	}
+	return out
+}
+
+// compileErrors reports sections that the settings update would reject and silently keep as they were, and
+// webhook filters that select no event.
+func compileErrors(st config.Config) []config.FieldError {
+	var errs []config.FieldError
+	if _, err := acl.Compile(st.ClientACL); err != nil {
+		errs = append(errs, config.FieldError{Field: "client_acl", Message: err.Error()})
+	}
+	if _, err := policy.Compile(st.Policy); err != nil {
+		errs = append(errs, config.FieldError{Field: "policy", Message: err.Error()})
+	}
+	if _, err := forwardzone.Compile(st.ForwardZones); err != nil {
+		errs = append(errs, config.FieldError{Field: "forward_zones", Message: err.Error()})
+	}
+	for i, h := range st.Events.Webhooks {
+		for _, f := range h.Events {
+			if !events.ValidFilter(f) {
+				errs = append(errs, config.FieldError{Field: fmt.Sprintf("events.webhooks[%d].events", i), Message: "unknown event type " + f})
+			}
+		}
+	}
+	return errs
+}
+
+// Apply puts p.Updated in place and restarts the listeners named in restarts, returning the ones it restarted.
+// When one fails, the old settings go back, the listeners restarted so far start again with them, and the
+// error says what failed, including listeners that did not come back on the old settings either. The
+// settings are not saved.
+func Apply(p Proposal, restarts []string) ([]string, error) {
+	setLive(p.Updated, p.Old)
+	var done []string
+	for _, l := range restarts {
+		if err := restartListener(l); err != nil {
+			err = fmt.Errorf("restart %s listener: %w", l, err)
+			return done, errors.Join(err, rollback(p, append(done, l)))
+		}
+		done = append(done, l)
+	}
+	return done, nil
+}
+
+// RestartDeferred restarts a listener Apply was told to leave alone, for a change already applied with
+// restarted listeners. On failure the whole change is rolled back as in Apply.
+func RestartDeferred(p Proposal, restarted []string, listener string) error {
+	if err := restartListener(listener); err != nil {
+		err = fmt.Errorf("restart %s listener: %w", listener, err)
+		return errors.Join(err, rollback(p, append(slices.Clone(restarted), listener)))
+	}
+	return nil
+}
+
+func rollback(p Proposal, listeners []string) error {
+	setLive(p.Old, p.Updated)
+	var errs []error
+	for _, l := range listeners {
+		if err := restartListener(l); err != nil {
+			errs = append(errs, fmt.Errorf("rollback: restart %s listener: %w", l, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setLive replaces the settings in memory with st and refreshes what is derived from them. prev is the
+// configuration being replaced, to reload the adblock lists only when they changed.
+func setLive(st, prev config.Config) {
+	dnsData := data.GetInstance()
+	dnsData.UpdateSettingsInMemory(st)
+	data.SetDashboardResolutionLogCap(st.DashboardResolutionLogCap)
+	if !slices.Equal(st.AdblockListFiles, prev.AdblockListFiles) {
+		dnsData.ReloadAdblockLists(st.AdblockListFiles)
+	}
+	if h := hooks.Load(); h != nil && h.ApplyLive != nil {
+		h.ApplyLive(st)
+	}
+}
+
+func restartListener(l string) error {
+	h := hooks.Load()
+	if h == nil || h.Restart == nil {
+		return errors.New("listener restarts are not available")
+	}
+	return h.Restart(l)
+}
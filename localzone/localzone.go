@@ -0,0 +1,233 @@
+// Package localzone serves the built-in empty zones of RFC 6303 (private and special-use reverse zones)
+// and special-use names from RFC 6761, RFC 6762, RFC 7686, and RFC 8375, so they never leak upstream.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package localzone
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+	"dnsplane/forwardzone"
+)
+
+// defaultCIDRs have their reverse zones served empty (RFC 6303 section 4, RFC 7793 for 100.64.0.0/10).
+var defaultCIDRs = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"0.0.0.0/8",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"192.0.2.0/24",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"255.255.255.255/32",
+	"100.64.0.0/10",
+	"::/128",
+	"::1/128",
+	"fd00::/8",
+	"fe80::/10",
+	"2001:db8::/32",
+}
+
+// defaultNames are special-use domains answered locally.
+var defaultNames = []string{
+	"home.arpa", // RFC 8375
+	"invalid",   // RFC 6761
+	"test",      // RFC 6761
+	"local",     // RFC 6762 (mDNS, never unicast DNS)
+	"onion",     // RFC 7686
+	"internal",  // ICANN private-use TLD
+}
+
+// SOA timers from RFC 6303 section 3.
+const (
+	soaRName   = "nobody.invalid."
+	soaRefresh = 3600
+	soaRetry   = 1200
+	soaExpire  = 604800
+	soaMinTTL  = 10800
+)
+
+// Defaults returns the built-in zone list (reverse zones first, then names).
+func Defaults() []string {
+	var out []string
+	for _, cidr := range defaultCIDRs {
+		zones, err := forwardzone.ReverseZones(cidr)
+		if err != nil {
+			continue
+		}
+		out = append(out, zones...)
+	}
+	return append(out, defaultNames...)
+}
+
+// Set is the compiled list of empty zones. A nil *Set matches nothing.
+type Set struct {
+	zones map[string]bool
+	// withData holds the zones that local records disable (see WithLocalData).
+	withData map[string]bool
+}
+
+// Compile applies cfg to the built-in list. Include entries that are not valid domain names are skipped.
+func Compile(cfg config.LocalZonesConfig) *Set {
+	s := &Set{zones: map[string]bool{}}
+	if !cfg.Disabled {
+		for _, z := range Defaults() {
+			s.zones[z] = true
+		}
+	}
+	for _, z := range cfg.Exclude {
+		delete(s.zones, normalize(z))
+	}
+	for _, z := range cfg.Include {
+		n := normalize(z)
+		if _, ok := dns.IsDomainName(n); ok && n != "" {
+			s.zones[n] = true
+		}
+	}
+	return s
+}
+
+// Len returns the number of zones.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.zones)
+}
+
+// Match returns the closest enclosing empty zone for qname, or "".
+func (s *Set) Match(qname string) string {
+	if s.Len() == 0 {
+		return ""
+	}
+	name := normalize(qname)
+	for name != "" {
+		if s.zones[name] {
+			return name
+		}
+		_, rest, found := strings.Cut(name, ".")
+		if !found {
+			break
+		}
+		name = rest
+	}
+	return ""
+}
+
+// Zones returns every zone sorted by name.
+func (s *Set) Zones() []string {
+	if s.Len() == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(s.zones))
+	for z := range s.zones {
+		out = append(out, z)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// WithLocalData returns a copy of s that knows which of its zones hold local records, so HasData is a map
+// lookup on the query path. Rebuild it whenever the records, the zones, or autoPTR change.
+func (s *Set) WithLocalData(records []dnsrecords.DNSRecord, autoPTR bool) *Set {
+	if s.Len() == 0 {
+		return s
+	}
+	out := &Set{zones: s.zones, withData: map[string]bool{}}
+	mark := func(name string) {
+		for name = normalize(name); name != ""; {
+			if s.zones[name] {
+				out.withData[name] = true
+			}
+			_, rest, found := strings.Cut(name, ".")
+			if !found {
+				break
+			}
+			name = rest
+		}
+	}
+	for _, r := range records {
+		name := r.Name
+		switch strings.ToUpper(r.Type) {
+		case "PTR":
+			if rev, err := dns.ReverseAddr(strings.TrimSpace(r.Name)); err == nil {
+				name = rev
+			}
+		case "A", "AAAA":
+			if autoPTR {
+				mark(reverseOf(r.Value))
+			}
+		}
+		mark(name)
+	}
+	return out
+}
+
+// HasData reports whether local records exist at or below zone, as found by WithLocalData.
+func (s *Set) HasData(zone string) bool {
+	return s != nil && s.withData[zone]
+}
+
+// Answer fills response with the empty-zone reply for q: SOA/NS at the apex, NODATA for other apex types,
+// and NXDOMAIN below it. The zone SOA goes in the authority section of negative answers.
+func Answer(q dns.Question, zone string, response *dns.Msg) {
+	soa := SOA(zone)
+	response.Authoritative = true
+	apex := normalize(q.Name) == zone
+	switch {
+	case apex && q.Qtype == dns.TypeSOA:
+		response.Answer = append(response.Answer, soa)
+	case apex && q.Qtype == dns.TypeNS:
+		response.Answer = append(response.Answer, &dns.NS{
+			Hdr: dns.RR_Header{Name: dns.Fqdn(zone), Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: soaMinTTL},
+			Ns:  dns.Fqdn(zone),
+		})
+	case apex:
+		response.Ns = append(response.Ns, soa)
+	default:
+		response.Rcode = dns.RcodeNameError
+		response.Ns = append(response.Ns, soa)
+	}
+}
+
+// SOA returns the synthesized SOA for zone.
+func SOA(zone string) *dns.SOA {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: dns.Fqdn(zone), Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: soaMinTTL},
+		Ns:      dns.Fqdn(zone),
+		Mbox:    soaRName,
+		Serial:  1,
+		Refresh: soaRefresh,
+		Retry:   soaRetry,
+		Expire:  soaExpire,
+		Minttl:  soaMinTTL,
+	}
+}
+
+func reverseOf(ip string) string {
+	if net.ParseIP(strings.TrimSpace(ip)) == nil {
+		return ""
+	}
+	rev, err := dns.ReverseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return ""
+	}
+	return rev
+}
+
+func inZone(name, zone string) bool {
+	n := normalize(name)
+	return n != "" && (n == zone || strings.HasSuffix(n, "."+zone))
+}
+
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
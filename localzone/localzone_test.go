@@ -0,0 +1,106 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package localzone
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecords"
+)
+
+func TestMatchDefaults(t *testing.T) {
+	s := Compile(config.LocalZonesConfig{})
+	cases := map[string]string{
+		"5.1.168.192.in-addr.arpa.": "168.192.in-addr.arpa",
+		"1.0.20.172.in-addr.arpa.":  "20.172.in-addr.arpa",
+		"1.0.32.172.in-addr.arpa.":  "",
+		"9.9.100.100.in-addr.arpa.": "100.100.in-addr.arpa",
+		"1.0.0.127.in-addr.arpa.":   "127.in-addr.arpa",
+		"8.8.8.8.in-addr.arpa.":     "",
+		"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa.": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa",
+		"1.2.3.4.b.e.f.ip6.arpa.": "b.e.f.ip6.arpa",
+		"printer.local.":          "local",
+		"router.home.arpa.":       "home.arpa",
+		"nas.internal.":           "internal",
+		"foo.INVALID":             "invalid",
+		"example.com.":            "",
+		"local.example.com.":      "",
+	}
+	for name, want := range cases {
+		if got := s.Match(name); got != want {
+			t.Errorf("Match(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCompileToggles(t *testing.T) {
+	s := Compile(config.LocalZonesConfig{Exclude: []string{"local.", "10.in-addr.arpa"}, Include: []string{"corp.lan", "bad..name"}})
+	if s.Match("printer.local.") != "" || s.Match("1.0.0.10.in-addr.arpa.") != "" {
+		t.Fatal("excluded zones still match")
+	}
+	if s.Match("x.corp.lan.") != "corp.lan" || s.Match("x.test.") != "test" {
+		t.Fatal("included or default zone missing")
+	}
+	if off := Compile(config.LocalZonesConfig{Disabled: true, Include: []string{"corp.lan"}}); off.Len() != 1 {
+		t.Fatalf("disabled set has %d zones, want only the include", off.Len())
+	}
+	var nilSet *Set
+	if nilSet.Match("x.local.") != "" {
+		t.Fatal("nil set matched")
+	}
+}
+
+func TestWithLocalData(t *testing.T) {
+	records := []dnsrecords.DNSRecord{
+		{Name: "nas.home.arpa", Type: "A", Value: "192.168.1.10"},
+		{Name: "10.1.2.3", Type: "PTR", Value: "gw.example."},
+	}
+	zones := Compile(config.LocalZonesConfig{})
+	set := zones.WithLocalData(records, false)
+	if !set.HasData("home.arpa") {
+		t.Error("home.arpa: A record not detected")
+	}
+	if !set.HasData("10.in-addr.arpa") {
+		t.Error("10.in-addr.arpa: PTR row not detected")
+	}
+	if set.HasData("168.192.in-addr.arpa") {
+		t.Error("168.192.in-addr.arpa: matched without auto PTR")
+	}
+	set = zones.WithLocalData(records, true)
+	if !set.HasData("168.192.in-addr.arpa") {
+		t.Error("168.192.in-addr.arpa: auto PTR from A not detected")
+	}
+	if set.HasData("internal") {
+		t.Error("internal: unexpected match")
+	}
+	if zones.HasData("home.arpa") || set.Match("x.home.arpa") != "home.arpa" {
+		t.Error("WithLocalData changed the set it was built from")
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	zone := "168.192.in-addr.arpa"
+	m := new(dns.Msg)
+	Answer(dns.Question{Name: "5.1.168.192.in-addr.arpa.", Qtype: dns.TypePTR, Qclass: dns.ClassINET}, zone, m)
+	if m.Rcode != dns.RcodeNameError || len(m.Ns) != 1 || len(m.Answer) != 0 {
+		t.Fatalf("below apex: rcode=%d ns=%v answer=%v", m.Rcode, m.Ns, m.Answer)
+	}
+	if soa, ok := m.Ns[0].(*dns.SOA); !ok || soa.Hdr.Name != zone+"." || soa.Mbox != soaRName {
+		t.Fatalf("authority = %v", m.Ns[0])
+	}
+
+	m = new(dns.Msg)
+	Answer(dns.Question{Name: zone + ".", Qtype: dns.TypeSOA, Qclass: dns.ClassINET}, zone, m)
+	if m.Rcode != dns.RcodeSuccess || len(m.Answer) != 1 {
+		t.Fatalf("apex SOA: rcode=%d answer=%v", m.Rcode, m.Answer)
+	}
+
+	m = new(dns.Msg)
+	Answer(dns.Question{Name: zone + ".", Qtype: dns.TypeA, Qclass: dns.ClassINET}, zone, m)
+	if m.Rcode != dns.RcodeSuccess || len(m.Answer) != 0 || len(m.Ns) != 1 {
+		t.Fatalf("apex NODATA: rcode=%d answer=%v ns=%v", m.Rcode, m.Answer, m.Ns)
+	}
+}
@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"dnsplane/apiauth"
 	"fmt"
 	"log/slog"
 	"net"
@@ -22,10 +23,13 @@ import (
 	"dnsplane/daemon"
 	"dnsplane/data"
 	"dnsplane/dnssecsign"
+	"dnsplane/events"
 	"dnsplane/fullstats"
+	"dnsplane/journal"
+	"dnsplane/liveconfig"
 	"dnsplane/logger"
-	"dnsplane/ratelimit"
 	"dnsplane/resolver"
+	"dnsplane/tracing"
 
 	"github.com/chzyer/readline"
 	"github.com/inconshreveable/mousetrap"
@@ -40,6 +44,8 @@ const (
 	tuiBannerPrefix  = "dnsplane-tui"
 	tuiBannerBusy    = "dnsplane-tui-busy"
 	tuiClientKillCmd = "dnsplane-kill"
+	tuiAuthCmd       = "dnsplane-auth"
+	tuiBannerDenied  = "dnsplane-tui-denied"
 )
 
 // Default release string for normal builds; RPM/DEB may override with -ldflags "-X main.appVersion=..." (see packaging/version.sh).
@@ -54,7 +60,6 @@ var (
 	tuiLogger        *slog.Logger
 	clientLogger     *slog.Logger
 	asyncLogQueue    *logger.AsyncLogQueue
-	dnsQueryLimiter  *ratelimit.PerIP
 
 	// defaultSocketPath is set in init() from config.DefaultClientSocketPath().
 	defaultSocketPath string
@@ -135,6 +140,11 @@ func init() {
 	// Client flags
 	clientCmd.Flags().String("log-file", "", "Path to log file or directory for client (writes dnsplaneclient.log when set)")
 	clientCmd.Flags().Bool("kill", false, "Disconnect the current TUI client and take over the session")
+	clientCmd.Flags().Bool("tls", false, "Connect with TLS (same as a tls:// target)")
+	clientCmd.Flags().String("ca", "", "PEM file of the CA that signed the server certificate (default: system roots)")
+	clientCmd.Flags().String("cert", "", "Client certificate (PEM) to log in with")
+	clientCmd.Flags().String("key", "", "Key of --cert (default: read from the --cert file)")
+	clientCmd.Flags().String("user", "", "User to log in as; the password is read from DNSPLANE_TUI_PASSWORD or prompted")
 
 	api.SetAppVersion(appVersion)
 }
@@ -179,6 +189,7 @@ func runServer(cmd *cobra.Command, args []string) error {
 	loadedCfg.Config.FileLocations.DNSServerFile = resolveDataPath(dnsservers, "dnsservers.json", cwd)
 	if dnsrecords != "" {
 		loadedCfg.Config.FileLocations.RecordsSource = &config.RecordsSourceConfig{Type: config.RecordsSourceFile, Location: resolveDataPath(dnsrecords, "dnsrecords.json", cwd)}
+		loadedCfg.Config.FileLocations.RecordsSources = nil
 	}
 	loadedCfg.Config.FileLocations.CacheFile = resolveDataPath(cache, "dnscache.json", cwd)
 	data.SetConfig(loadedCfg)
@@ -267,16 +278,33 @@ func runServer(cmd *cobra.Command, args []string) error {
 	settings.RESTPort = apiport
 	dnsData.UpdateSettingsInMemory(settings)
 
-	if settings.DNSRateLimitPerIP > 0 {
-		burst := settings.DNSRateLimitBurst
-		if burst <= 0 {
-			burst = 50
+	flagLocations := loadedCfg.Config.FileLocations
+	reloadOverrides = func(c *config.Config) {
+		c.FileLocations.DNSServerFile = flagLocations.DNSServerFile
+		c.FileLocations.CacheFile = flagLocations.CacheFile
+		if dnsrecords != "" {
+			c.FileLocations.RecordsSource = flagLocations.RecordsSource
+			c.FileLocations.RecordsSources = nil
+		}
+		if cmd.Flags().Changed("port") {
+			c.DNSPort = port
+		}
+		if cmd.Flags().Changed("server-socket") {
+			c.ClientSocketPath = serverSocket
+		}
+		if cmd.Flags().Changed("server-tcp") {
+			c.ClientTCPAddress = normalisedTCP
+		}
+		c.ClientTCPAddress = normalizeTCPAddress(strings.TrimSpace(c.ClientTCPAddress))
+		if cmd.Flags().Changed("api") {
+			c.APIEnabled = apiMode
+		}
+		if cmd.Flags().Changed("apiport") {
+			c.RESTPort = apiport
 		}
-		dnsQueryLimiter = ratelimit.NewPerIP(settings.DNSRateLimitPerIP, burst)
-	} else {
-		dnsQueryLimiter = nil
 	}
-	responseLimiter = buildResponseLimiter(settings)
+
+	rebuildDNSLimiters(settings)
 
 	// Initialize full stats tracker if enabled
 	if settings.FullStats {
@@ -289,16 +317,32 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if settings.RecordsHistory {
+		j, err := journal.Open(settings.RecordsHistoryDir)
+		if err != nil {
+			dnsLogger.Warn("Failed to open record history; history disabled", "error", err)
+		} else {
+			dnsData.SetRecordJournal(j)
+			dnsLogger.Info("Record history enabled", "dir", settings.RecordsHistoryDir, "rev", j.Head())
+		}
+	}
+
+	tokens, err := apiauth.Open(settings.APITokensFile)
+	if err != nil {
+		dnsLogger.Error("Failed to read API tokens; only api_auth_token is accepted until the file is fixed", "error", err)
+	}
+	dnsData.SetAPITokens(tokens)
+
 	commandhandler.RegisterCommands()
+	tui.UseMiddleware(commandhandler.SessionMiddleware(tuiLogger))
 	commandhandler.RegisterServerControlHooks(
 		func() { stopDNSServer(appState) },
-		func(p string) { restartDNSServer(appState, p) },
+		func(p string) { _ = restartDNSServer(appState, p) },
 		func() bool { return getServerStatus(appState) },
-		func(p string) { startAPIAsync(appState, p, apiLogger) },
+		func(p string) { _ = startAPIAsync(appState, p, apiLogger) },
 		func() { stopAPIAsync(appState) },
 		func() { startClientTCPListener(appState, tuiLogger) },
 		func() { stopClientTCPListener(tuiLogger) },
-		func() bool { return appState.IsTUIClientTCP() },
 		func() commandhandler.ServerListenerInfo { return currentServerListeners(appState) },
 	)
 	commandhandler.SetVersion(appVersion, appVersion)
@@ -308,8 +352,13 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	appState.SetDaemonMode(true)
 
+	liveconfig.SetHooks(liveconfig.Hooks{
+		Restart:   func(l string) error { return restartConfigListener(appState, l) },
+		ApplyLive: applyLiveConfig,
+	})
+
 	if apiMode {
-		startAPIAsync(appState, apiport, apiLogger)
+		_ = startAPIAsync(appState, apiport, apiLogger)
 	}
 
 	if dnsResolver == nil {
@@ -328,10 +377,20 @@ func runServer(cmd *cobra.Command, args []string) error {
 				}
 			}
 		}
+		upstreamClient := resolver.NewDNSClient(2 * time.Second)
+		if signSt.UpstreamDNSCookies {
+			upstreamClient.EnableCookies()
+		}
 		dnsResolver = resolver.New(resolver.Config{
 			Store:        dnsData,
-			Upstream:     resolver.NewDNSClient(2 * time.Second),
+			Upstream:     upstreamClient,
 			DNSSECSigner: dnssecZSK,
+			Policy:       dnsData.PolicyEngine,
+			ClientMAC:    dnsData.ClientMAC,
+			ForwardZones: dnsData.ForwardZones,
+			LocalZones:   dnsData.LocalZones,
+			ALIASTarget:  dnsData.LookupALIAS,
+			GeoLookup:    dnsData.LookupGeo,
 			Logger: func(format string, args ...interface{}) {
 				if asyncLogQueue != nil {
 					asyncLogQueue.Enqueue(func() {
@@ -346,20 +405,24 @@ func runServer(cmd *cobra.Command, args []string) error {
 					asyncLogQueue.Enqueue(func() { dnsLogger.Error(msg, keyValues...) })
 				}
 			},
-			QueryObserver: func(qname, qtype, outcome, upstream, recordSummary string, elapsed time.Duration, clientIP string) {
+			QueryObserver: func(qname, qtype, outcome, upstream, recordSummary string, elapsed time.Duration, clientIP string, notes resolver.QueryNotes) {
 				ms := elapsed.Seconds() * 1000
 				cip := strings.TrimSpace(clientIP)
 				if cip == "" {
 					cip = "unknown"
 				}
 				data.RecordDashboardResolution(data.DashboardResolution{
-					ClientIP:   cip,
-					Qname:      qname,
-					Qtype:      qtype,
-					Outcome:    outcome,
-					Upstream:   upstream,
-					Record:     recordSummary,
-					DurationMs: ms,
+					ClientIP:    cip,
+					Qname:       qname,
+					Qtype:       qtype,
+					Outcome:     outcome,
+					Upstream:    upstream,
+					Record:      recordSummary,
+					DurationMs:  ms,
+					ACL:         notes.ACL,
+					PolicyGroup: notes.PolicyGroup,
+					PolicyRule:  notes.PolicyRule,
+					Geo:         notes.Geo,
 				})
 				if fullStatsTracker != nil {
 					key := fmt.Sprintf("%s:%s", qname, qtype)
@@ -382,6 +445,9 @@ func runServer(cmd *cobra.Command, args []string) error {
 			UpstreamTimeout: 2 * time.Second,
 		})
 	}
+	api.SetResolver(dnsResolver)
+	tracing.Configure(dnsData.GetResolverSettings().Tracing, dnsLogger)
+	events.Configure(dnsData.GetResolverSettings().Events, dnsLogger)
 
 	startedCh, dnsErrCh := startDNSServer(appState, port)
 
@@ -411,11 +477,19 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	monitorDNSErrors()
 
-	go startInboundDNSListeners(appState.StopChannel())
+	if err := startInboundDNSListeners(appState.StopChannel()); err != nil {
+		dnsLogger.Error("Error starting DNS listeners", "error", err)
+	}
 
 	go runUpstreamHealthProbeLoop(dnsData, dnsLogger)
+	probeCtx, probeCancel := context.WithCancel(context.Background())
+	defer probeCancel()
+	go runRecordHealthProbeLoop(probeCtx, dnsData, dnsLogger)
+	go runRecordExpiryLoop(dnsData, dnsLogger)
+	go runDHCPLeaseLoop(dnsData, dnsLogger)
 	go runCacheWarmLoop(dnsData, port)
 	go runCacheCompactLoop(dnsData, dnsLogger)
+	go runLimiterSpikeLoop(dnsData)
 
 	if s := dnsData.GetResolverSettings(); s.PprofEnabled {
 		addr := strings.TrimSpace(s.PprofListen)
@@ -445,10 +519,10 @@ func runServer(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("unix socket listener error: %w", err)
 		}
 		unixListener = listener
-		go acceptInteractiveSessions(listener, tuiLogger)
+		go acceptInteractiveSessions(listener, nil, tuiLogger)
 	}
 	if serverTCP != "" {
-		listener, err := startTCPTerminalListener(serverTCP, tuiLogger)
+		listener, auth, err := startTCPTerminalListener(serverTCP, tuiLogger)
 		if err != nil {
 			if tuiLogger != nil {
 				tuiLogger.Error("failed to start TCP TUI listener", "address", serverTCP, "error", err)
@@ -459,7 +533,13 @@ func runServer(cmd *cobra.Command, args []string) error {
 		tcpTUIListenerMu.Lock()
 		tcpTUIListener = listener
 		tcpTUIListenerMu.Unlock()
-		go acceptInteractiveSessions(listener, tuiLogger)
+		go acceptInteractiveSessions(listener, auth, tuiLogger)
+	}
+	if _, err := startSSHTUIListener(tuiLogger); err != nil {
+		if tuiLogger != nil {
+			tuiLogger.Error("failed to start SSH TUI listener", "error", err)
+		}
+		return fmt.Errorf("ssh listener error: %w", err)
 	}
 
 	clusterCtx, clusterCancel := context.WithCancel(context.Background())
@@ -473,6 +553,8 @@ func runServer(cmd *cobra.Command, args []string) error {
 		data.SetClusterRecordsNotify(clusterMgr.NotifyLocalRecordsChanged)
 	}
 
+	startConfigReload()
+
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
@@ -486,11 +568,16 @@ func runServer(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println("Shutting down.")
 	stopPprof()
+	probeCancel()
 	clusterCancel()
 	clusterMgr.Stop()
 	cluster.SetGlobalManager(nil)
 	data.SetClusterRecordsNotify(nil)
 	stopDNSServer(appState)
+	traceCtx, traceCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	tracing.Shutdown(traceCtx)
+	events.Shutdown(traceCtx)
+	traceCancel()
 	if fullStatsTracker != nil {
 		done := make(chan struct{})
 		go func() {
@@ -528,6 +615,7 @@ func runServer(cmd *cobra.Command, args []string) error {
 			tuiLogger.Debug("TCP TUI listener closed")
 		}
 	}
+	stopSSHTUIListener()
 	if serverSocket != "" {
 		_ = syscall.Unlink(serverSocket)
 	}
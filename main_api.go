@@ -4,12 +4,14 @@
 package main
 
 import (
+	"fmt"
 	"log/slog"
 	"net"
 	"strings"
 
 	"dnsplane/api"
 	"dnsplane/commandhandler"
+	"dnsplane/config"
 	"dnsplane/daemon"
 	"dnsplane/data"
 )
@@ -48,6 +50,8 @@ func currentServerListeners(state *daemon.State) commandhandler.ServerListenerIn
 		ClientTCPEndpoint:   tcp,
 		ClientTCPEnabled:    tcp != "",
 		ClientTCPRunning:    isClientTCPListenerRunning(),
+		ClientSSHEndpoint:   strings.TrimSpace(settings.TUISSH.Listen),
+		ClientSSHRunning:    isSSHTUIListenerRunning(),
 		APIEndpoint:         apiEndpoint,
 		APIEnabled:          listener.APIEnabled,
 		APIRunning:          state.APIRunning(),
@@ -55,7 +59,7 @@ func currentServerListeners(state *daemon.State) commandhandler.ServerListenerIn
 	return info
 }
 
-func startAPIAsync(state *daemon.State, port string, log *slog.Logger) {
+func startAPIAsync(state *daemon.State, port string, log *slog.Logger) error {
 	if port == "" {
 		port = data.GetInstance().GetResolverSettings().RESTPort
 	}
@@ -74,7 +78,7 @@ func startAPIAsync(state *daemon.State, port string, log *slog.Logger) {
 		info.APIEnabled = true
 	})
 	if state.APIRunning() {
-		return
+		return nil
 	}
 	opts := &api.ListenOptions{
 		BindIP:         st.APIBind,
@@ -82,6 +86,29 @@ func startAPIAsync(state *daemon.State, port string, log *slog.Logger) {
 		TLSKeyFile:     st.APITLSKeyFile,
 		RateLimitRPS:   st.APIRateLimitPerIP,
 		RateLimitBurst: st.APIRateLimitBurst,
+		ClientCAFile:   st.APITLSClientCAFile,
+		ClientCRLFile:  st.APITLSClientCRLFile,
 	}
-	api.Start(state, trimmed, opts, nil, log)
+	return api.Start(state, trimmed, opts, nil, log)
+}
+
+// restartConfigListener restarts one listener for PATCH /config with the settings now in memory.
+func restartConfigListener(state *daemon.State, listener string) error {
+	st := data.GetInstance().GetResolverSettings()
+	switch listener {
+	case config.RestartDNS:
+		return restartDNSServer(state, st.DNSPort)
+	case config.RestartAPI:
+		stopAPIAsync(state)
+		if !st.APIEnabled {
+			state.UpdateListener(func(info *daemon.ListenerSettings) {
+				info.APIEnabled = false
+			})
+			return nil
+		}
+		return startAPIAsync(state, st.RESTPort, apiLogger)
+	case config.RestartTUI:
+		return restartTUIListeners(state, tuiLogger)
+	}
+	return fmt.Errorf("unknown listener %q", listener)
 }
@@ -5,6 +5,7 @@ package main
 
 import (
 	"bufio"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"math"
@@ -32,16 +33,106 @@ func runClient(cmd *cobra.Command, args []string) error {
 		clientLogger.Info("client starting", "target", clientTarget)
 	}
 	killOther, _ := cmd.Flags().GetBool("kill")
-	connectToInteractiveEndpoint(clientTarget, killOther)
+	var sec tuiClientSecurity
+	sec.TLS, _ = cmd.Flags().GetBool("tls")
+	sec.CAFile, _ = cmd.Flags().GetString("ca")
+	sec.CertFile, _ = cmd.Flags().GetString("cert")
+	sec.KeyFile, _ = cmd.Flags().GetString("key")
+	sec.User, _ = cmd.Flags().GetString("user")
+	connectToInteractiveEndpoint(clientTarget, killOther, sec)
 	if clientLogger != nil {
 		clientLogger.Debug("client exiting")
 	}
 	return nil
 }
 
-func connectToInteractiveEndpoint(target string, killOther bool) {
+// tuiClientSecurity holds the TLS and login options of dnsplane client.
+type tuiClientSecurity struct {
+	TLS      bool
+	CAFile   string // server CA; empty uses the system roots
+	CertFile string // client certificate for certificate login
+	KeyFile  string // its key; empty reads the key from CertFile
+	User     string
+}
+
+// tlsConfig builds the client TLS configuration for address.
+func (s tuiClientSecurity) tlsConfig(address string) (*tls.Config, error) {
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		tc.ServerName = host
+	}
+	if s.CAFile != "" {
+		pool, err := loadCertPool(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("--ca: %w", err)
+		}
+		tc.RootCAs = pool
+	}
+	if s.CertFile != "" {
+		key := s.KeyFile
+		if key == "" {
+			key = s.CertFile
+		}
+		cert, err := tls.LoadX509KeyPair(s.CertFile, key)
+		if err != nil {
+			return nil, fmt.Errorf("--cert: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
+
+// password returns the login password from DNSPLANE_TUI_PASSWORD or a terminal prompt. With a client
+// certificate and no variable set, it is empty and the certificate logs in.
+func (s tuiClientSecurity) password(address string) (string, error) {
+	if pw, ok := os.LookupEnv("DNSPLANE_TUI_PASSWORD"); ok {
+		return pw, nil
+	}
+	if s.User == "" || s.CertFile != "" {
+		return "", nil
+	}
+	fd := int(os.Stdin.Fd()) // #nosec G115 -- stdin descriptor
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to ask for the password; set DNSPLANE_TUI_PASSWORD")
+	}
+	fmt.Fprintf(os.Stderr, "Password for %s@%s: ", s.User, address)
+	pw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(pw), err
+}
+
+func connectToInteractiveEndpoint(target string, killOther bool, sec tuiClientSecurity) {
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(target), "tls://"); ok {
+		sec.TLS = true
+		target = rest
+	}
 	network, address := resolveInteractiveTarget(target)
-	conn, err := net.Dial(network, address)
+	login := sec.TLS || sec.User != "" || sec.CertFile != ""
+	if login && (!sec.TLS || network != "tcp") {
+		fmt.Fprintln(os.Stderr, "Error: --user and --cert need a TLS connection (tls://host:port or --tls with a TCP address)")
+		return
+	}
+	var (
+		conn      net.Conn
+		err       error
+		helloLine string
+	)
+	if login {
+		pw, perr := sec.password(address)
+		if perr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", perr)
+			return
+		}
+		helloLine = formatTUIHello(sec.User, pw, killOther)
+		tc, terr := sec.tlsConfig(address)
+		if terr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", terr)
+			return
+		}
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, network, address, tc)
+	} else {
+		conn, err = net.Dial(network, address)
+	}
 	if err != nil {
 		if clientLogger != nil {
 			clientLogger.Error("connection failed", "target", address, "error", err)
@@ -51,13 +142,23 @@ func connectToInteractiveEndpoint(target string, killOther bool) {
 	}
 	defer func() { _ = conn.Close() }()
 
-	if killOther {
+	switch {
+	case helloLine != "":
+		_ = conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
+		_, _ = fmt.Fprintf(conn, "%s\n", helloLine)
+		_ = conn.SetWriteDeadline(time.Time{})
+	case killOther:
 		_ = conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
 		_, _ = fmt.Fprintf(conn, "%s\n", tuiClientKillCmd)
 		_ = conn.SetWriteDeadline(time.Time{})
 	}
 
-	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	bannerWait := 5 * time.Second
+	if login {
+		// A password check takes a moment on the server.
+		bannerWait = 15 * time.Second
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(bannerWait))
 	reader := bufio.NewReader(conn)
 	banner, err := reader.ReadString('\n')
 	_ = conn.SetReadDeadline(time.Time{})
@@ -66,9 +167,19 @@ func connectToInteractiveEndpoint(target string, killOther bool) {
 			clientLogger.Error("failed to read server banner", "address", address, "error", err)
 		}
 		fmt.Fprintf(os.Stderr, "Error: not a dnsplane server at %s (could not read banner: %v)\n", address, err)
+		if network == "tcp" && !sec.TLS {
+			fmt.Fprintln(os.Stderr, "If the server uses TLS, connect with tls://host:port.")
+		}
 		return
 	}
 	banner = strings.TrimSpace(banner)
+	if reason, ok := strings.CutPrefix(banner, tuiBannerDenied); ok {
+		if clientLogger != nil {
+			clientLogger.Error("login refused", "address", address, "reason", strings.TrimSpace(reason))
+		}
+		fmt.Fprintf(os.Stderr, "Error: login refused: %s\n", strings.TrimSpace(reason))
+		return
+	}
 	if strings.HasPrefix(banner, tuiBannerBusy) {
 		rest := strings.TrimSpace(strings.TrimPrefix(banner, tuiBannerBusy))
 		parts := strings.SplitN(rest, " ", 2)
@@ -0,0 +1,110 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"dnsplane/data"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// dhcpLeaseDebounce waits for a DHCP server to finish rewriting its lease file.
+	dhcpLeaseDebounce = time.Second
+	// dhcpLeaseResync re-reads the lease files even without a change event, so leases that run out are
+	// removed and files created after startup are picked up.
+	dhcpLeaseResync = 30 * time.Second
+)
+
+// runDHCPLeaseLoop registers hostnames from the lease files in the dhcp config section. The directories
+// holding the files are watched (servers replace the file on rewrite), and the files are re-read every
+// dhcpLeaseResync as well.
+func runDHCPLeaseLoop(dnsData *data.DNSResolverData, dnsLogger *slog.Logger) {
+	cfg := dnsData.GetResolverSettings().DHCP
+	if !cfg.Enabled() {
+		return
+	}
+	logf := func(level slog.Level, msg string, kv ...any) {
+		if dnsLogger != nil {
+			dnsLogger.Log(context.Background(), level, msg, kv...)
+		}
+	}
+	files := make(map[string]bool, len(cfg.Leases))
+	var (
+		events chan fsnotify.Event
+		errs   chan error
+	)
+	if watcher, err := fsnotify.NewWatcher(); err != nil {
+		logf(slog.LevelWarn, "dhcp: fsnotify init failed; re-reading lease files on a timer only", "error", err)
+	} else {
+		defer watcher.Close()
+		for _, f := range cfg.Leases {
+			path := filepath.Clean(f.Path)
+			files[path] = true
+			if err := watcher.Add(filepath.Dir(path)); err != nil {
+				logf(slog.LevelWarn, "dhcp: watch failed", "path", filepath.Dir(path), "error", err)
+			}
+		}
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	// Conflicts and errors are logged when they first appear, not on every resync.
+	seen := map[string]bool{}
+	lastErr := ""
+	resync := func() {
+		res, err := dnsData.SyncDHCPLeases(time.Now())
+		if err != nil {
+			if err.Error() != lastErr {
+				logf(slog.LevelWarn, "dhcp: lease sync failed", "error", err)
+			}
+			lastErr = err.Error()
+			return
+		}
+		lastErr = ""
+		if res.Changed() {
+			logf(slog.LevelInfo, "dhcp: records updated from leases", "added", res.Added, "removed", res.Removed)
+		}
+		now := make(map[string]bool, len(res.Conflicts))
+		for _, c := range res.Conflicts {
+			now[c] = true
+			if !seen[c] {
+				logf(slog.LevelWarn, "dhcp: hostname conflict", "detail", c)
+			}
+		}
+		seen = now
+	}
+	resync()
+
+	ticker := time.NewTicker(dhcpLeaseResync)
+	defer ticker.Stop()
+	debounce := time.NewTimer(dhcpLeaseDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if files[filepath.Clean(ev.Name)] {
+				debounce.Reset(dhcpLeaseDebounce)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			logf(slog.LevelWarn, "dhcp: watcher error", "error", err)
+		case <-debounce.C:
+			resync()
+		case <-ticker.C:
+			resync()
+		}
+	}
+}
@@ -12,19 +12,53 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"dnsplane/abuse"
+	"dnsplane/acl"
 	"dnsplane/config"
 	"dnsplane/data"
+	"dnsplane/dnscookie"
 	"dnsplane/dnsrecords"
 	"dnsplane/dnsserve"
+	"dnsplane/ratelimit"
 	"dnsplane/resolver"
 
 	"github.com/miekg/dns"
 )
 
-var responseLimiter dnsserve.ResponseLimiter
+// The limiters are rebuilt from the settings on start, restart, and config changes; limitersMu guards them.
+var (
+	limitersMu      sync.RWMutex
+	dnsQueryLimiter *ratelimit.PerIP
+	responseLimiter dnsserve.ResponseLimiter
+	// cookieLimiter budgets UDP queries without a valid DNS server cookie (nil = not enforced).
+	cookieLimiter dnsserve.QueryLimiter
+)
+
+// rebuildDNSLimiters replaces the query, response, and cookie limiters with new ones for st.
+func rebuildDNSLimiters(st config.Config) {
+	var query *ratelimit.PerIP
+	if st.DNSRateLimitPerIP > 0 {
+		burst := st.DNSRateLimitBurst
+		if burst <= 0 {
+			burst = 50
+		}
+		query = ratelimit.NewPerIP(st.DNSRateLimitPerIP, burst)
+	}
+	response, cookie := buildResponseLimiter(st), buildCookieLimiter(st)
+	limitersMu.Lock()
+	dnsQueryLimiter, responseLimiter, cookieLimiter = query, response, cookie
+	limitersMu.Unlock()
+}
+
+func buildCookieLimiter(st config.Config) dnsserve.QueryLimiter {
+	if !st.DNSCookiesEnabled || st.DNSCookieUnverifiedRPS <= 0 {
+		return nil
+	}
+	return ratelimit.NewPerIP(st.DNSCookieUnverifiedRPS, st.DNSCookieUnverifiedBurst)
+}
 
 func buildResponseLimiter(st config.Config) dnsserve.ResponseLimiter {
 	mode := strings.ToLower(strings.TrimSpace(st.DNSResponseLimitMode))
@@ -55,6 +89,42 @@ func buildResponseLimiter(st config.Config) dnsserve.ResponseLimiter {
 	return abuse.NewSlidingWindow(time.Duration(wsec)*time.Second, st.DNSMaxResponsesPerIPWindow, 0)
 }
 
+// dnsServeDependencies wires dnsserve to the data singleton and the shared limiters.
+func dnsServeDependencies() dnsserve.Dependencies {
+	limitersMu.RLock()
+	defer limitersMu.RUnlock()
+	return dnsserve.Dependencies{
+		Resolver:        dnsResolver,
+		Settings:        func() config.Config { return data.GetInstance().GetResolverSettings() },
+		LocalRecords:    func() []dnsrecords.DNSRecord { return data.GetInstance().GetRecords() },
+		ResponseLimiter: responseLimiter,
+		QueryLimiter:    dnsQueryLimiter,
+		OnLimiterDrop:   data.RecordLimiterDrop,
+		ClientACL:       func() *acl.ACL { return data.GetInstance().ClientACL() },
+		OnACLDenied:     recordACLDenied,
+		Cookies:         func() *dnscookie.Server { return data.GetInstance().DNSCookies() },
+		CookieLimiter:   cookieLimiter,
+		OnCookie:        data.RecordDNSCookie,
+	}
+}
+
+// recordACLDenied counts the ACL refusal and adds a row to the dashboard resolution log.
+func recordACLDenied(clientIP, qname, qtype string, d acl.Decision, dropped bool) {
+	outcome, summary := "refused", "REFUSED (client acl)"
+	if dropped {
+		outcome, summary = "dropped", "dropped (client acl)"
+	}
+	data.RecordACLDenied(dropped)
+	data.RecordDashboardResolution(data.DashboardResolution{
+		ClientIP: clientIP,
+		Qname:    qname,
+		Qtype:    qtype,
+		Outcome:  outcome,
+		Record:   summary,
+		ACL:      d.String(),
+	})
+}
+
 func handleRequestProto(w dns.ResponseWriter, request *dns.Msg, proto string) {
 	_ = proto // reserved for metrics / future per-protocol stats
 	t0 := time.Now()
@@ -68,16 +138,13 @@ func handleRequestProto(w dns.ResponseWriter, request *dns.Msg, proto string) {
 	}
 
 	ctx := resolver.ContextWithRequest(context.Background(), request)
-	dep := dnsserve.Dependencies{
-		Resolver:        dnsResolver,
-		Settings:        func() config.Config { return data.GetInstance().GetResolverSettings() },
-		LocalRecords:    func() []dnsrecords.DNSRecord { return data.GetInstance().GetRecords() },
-		ResponseLimiter: responseLimiter,
-		QueryLimiter:    dnsQueryLimiter,
-		OnLimiterDrop:   data.RecordLimiterDrop,
-	}
+	dep := dnsServeDependencies()
 	response := dnsserve.ServeDNS(ctx, request, dnsserve.ServeMeta{ClientIP: requesterIP, Protocol: proto}, dep)
 	resolveDone := time.Since(t0)
+	if response == nil {
+		go data.GetInstance().IncrementTotalQueries()
+		return
+	}
 
 	err := w.WriteMsg(response)
 	total := time.Since(t0)
@@ -100,23 +167,27 @@ func handleRequestProto(w dns.ResponseWriter, request *dns.Msg, proto string) {
 	}()
 }
 
-func startInboundDNSListeners(stopCh <-chan struct{}) {
+// startInboundDNSListeners starts the DoT and DoH listeners that are configured and returns once they listen,
+// or the first error; both stop when stopCh closes.
+func startInboundDNSListeners(stopCh <-chan struct{}) error {
 	st := data.GetInstance().GetResolverSettings()
 	if st.DOTEnabled && strings.TrimSpace(st.DOTCertFile) != "" && strings.TrimSpace(st.DOTKeyFile) != "" {
-		go runDotServer(stopCh, st)
+		if err := runDotServer(stopCh, st); err != nil {
+			return fmt.Errorf("DoT: %w", err)
+		}
 	}
 	if st.DOHEnabled && strings.TrimSpace(st.DOHCertFile) != "" && strings.TrimSpace(st.DOHKeyFile) != "" {
-		go runDoHServer(stopCh, st)
+		if err := runDoHServer(stopCh, st); err != nil {
+			return fmt.Errorf("DoH: %w", err)
+		}
 	}
+	return nil
 }
 
-func runDotServer(stopCh <-chan struct{}, st config.Config) {
+func runDotServer(stopCh <-chan struct{}, st config.Config) error {
 	cert, err := tls.LoadX509KeyPair(st.DOTCertFile, st.DOTKeyFile)
 	if err != nil {
-		if dnsLogger != nil {
-			dnsLogger.Error("DoT TLS load failed", "error", err)
-		}
-		return
+		return err
 	}
 	port := strings.TrimSpace(st.DOTPort)
 	if port == "" {
@@ -127,14 +198,17 @@ func runDotServer(stopCh <-chan struct{}, st config.Config) {
 	if bind != "" {
 		addr = net.JoinHostPort(bind, port)
 	}
+	ln, err := tls.Listen("tcp", addr, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		return err
+	}
 	mux := dns.NewServeMux()
 	mux.HandleFunc(".", func(w dns.ResponseWriter, r *dns.Msg) {
 		handleRequestProto(w, r, dnsserve.ProtoDoT)
 	})
 	srv := &dns.Server{
-		Addr:         addr,
+		Listener:     ln,
 		Net:          "tcp-tls",
-		TLSConfig:    &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
 		Handler:      mux,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
@@ -143,7 +217,7 @@ func runDotServer(stopCh <-chan struct{}, st config.Config) {
 		dnsLogger.Info("Starting DoT listener", "addr", addr)
 	}
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && dnsLogger != nil {
+		if err := srv.ActivateAndServe(); err != nil && dnsLogger != nil {
 			dnsLogger.Error("DoT server stopped", "error", err)
 		}
 	}()
@@ -153,9 +227,14 @@ func runDotServer(stopCh <-chan struct{}, st config.Config) {
 		defer cancel()
 		_ = srv.ShutdownContext(shutdownCtx)
 	}()
+	return nil
 }
 
-func runDoHServer(stopCh <-chan struct{}, st config.Config) {
+func runDoHServer(stopCh <-chan struct{}, st config.Config) error {
+	cert, err := tls.LoadX509KeyPair(st.DOHCertFile, st.DOHKeyFile)
+	if err != nil {
+		return err
+	}
 	port := strings.TrimSpace(st.DOHPort)
 	if port == "" {
 		port = "8443"
@@ -172,13 +251,17 @@ func runDoHServer(stopCh <-chan struct{}, st config.Config) {
 	if bind != "" {
 		addr = net.JoinHostPort(bind, port)
 	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
 	mux := http.NewServeMux()
 	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
 		doHHandler(w, r, path)
 	})
 	srv := &http.Server{
-		Addr:         addr,
 		Handler:      mux,
+		TLSConfig:    &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
@@ -186,7 +269,7 @@ func runDoHServer(stopCh <-chan struct{}, st config.Config) {
 		dnsLogger.Info("Starting DoH listener", "addr", addr, "path", path)
 	}
 	go func() {
-		if err := srv.ListenAndServeTLS(st.DOHCertFile, st.DOHKeyFile); err != nil && err != http.ErrServerClosed && dnsLogger != nil {
+		if err := srv.ServeTLS(ln, "", ""); err != nil && err != http.ErrServerClosed && dnsLogger != nil {
 			dnsLogger.Error("DoH server stopped", "error", err)
 		}
 	}()
@@ -196,6 +279,7 @@ func runDoHServer(stopCh <-chan struct{}, st config.Config) {
 		defer cancel()
 		_ = srv.Shutdown(shutdownCtx)
 	}()
+	return nil
 }
 
 func doHHandler(w http.ResponseWriter, r *http.Request, path string) {
@@ -252,15 +336,13 @@ func doHHandler(w http.ResponseWriter, r *http.Request, path string) {
 		}
 	}
 	ctx := resolver.ContextWithRequest(context.Background(), req)
-	dep := dnsserve.Dependencies{
-		Resolver:        dnsResolver,
-		Settings:        func() config.Config { return data.GetInstance().GetResolverSettings() },
-		LocalRecords:    func() []dnsrecords.DNSRecord { return data.GetInstance().GetRecords() },
-		ResponseLimiter: responseLimiter,
-		QueryLimiter:    dnsQueryLimiter,
-		OnLimiterDrop:   data.RecordLimiterDrop,
-	}
+	dep := dnsServeDependencies()
 	resp := dnsserve.ServeDNS(ctx, req, dnsserve.ServeMeta{ClientIP: requesterIP, Protocol: dnsserve.ProtoDoH}, dep)
+	if resp == nil {
+		// DoH has no silent drop; a dropped client gets a bare 403 instead of a DNS message.
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
 	out, err := resp.Pack()
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
@@ -109,7 +109,9 @@ func startDNSServer(state *daemon.State, port string) (<-chan struct{}, <-chan e
 	return startedCh, errCh
 }
 
-func restartDNSServer(state *daemon.State, port string) {
+// restartDNSServer stops the DNS listeners and starts them again on port with the current settings, including
+// DoT and DoH, and returns the first listener that failed to start.
+func restartDNSServer(state *daemon.State, port string) error {
 	if state.ServerStatus() {
 		stopDNSServer(state)
 	}
@@ -118,12 +120,18 @@ func restartDNSServer(state *daemon.State, port string) {
 	select {
 	case <-startedCh:
 	case err := <-errCh:
+		// Shut down whichever of UDP and TCP did start.
+		state.SignalStop()
 		dnsLogger.Error("Error restarting DNS server", "error", err)
 		fmt.Fprintf(os.Stderr, "Error restarting DNS server: %v\n", err)
-		return
+		return err
+	}
+	rebuildDNSLimiters(data.GetInstance().GetResolverSettings())
+	if err := startInboundDNSListeners(state.StopChannel()); err != nil {
+		dnsLogger.Error("Error restarting DNS listeners", "error", err)
+		return err
 	}
-	responseLimiter = buildResponseLimiter(data.GetInstance().GetResolverSettings())
-	go startInboundDNSListeners(state.StopChannel())
+	return nil
 }
 
 const dnsShutdownWaitTimeout = 20 * time.Second
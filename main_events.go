@@ -0,0 +1,24 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package main
+
+import (
+	"time"
+
+	"dnsplane/data"
+)
+
+// limiterSpikeWindow is how often the rate-limit drop counters are compared for limiter.drop_spike.
+const limiterSpikeWindow = 10 * time.Second
+
+// runLimiterSpikeLoop publishes limiter.drop_spike when the DNS rate limiters refuse more than
+// events.limiter_drop_spike queries in a window.
+func runLimiterSpikeLoop(dnsData *data.DNSResolverData) {
+	var w data.LimiterSpikeWatch
+	ticker := time.NewTicker(limiterSpikeWindow)
+	defer ticker.Stop()
+	for range ticker.C {
+		w.Check(dnsData.GetResolverSettings().Events.LimiterDropSpike, limiterSpikeWindow)
+	}
+}
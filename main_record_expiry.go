@@ -0,0 +1,34 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"dnsplane/data"
+)
+
+// recordExpiryInterval is how often local records are checked for a passed expires_at.
+const recordExpiryInterval = 10 * time.Second
+
+// runRecordExpiryLoop removes local records once their expires_at has passed.
+func runRecordExpiryLoop(dnsData *data.DNSResolverData, dnsLogger *slog.Logger) {
+	ticker := time.NewTicker(recordExpiryInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		expired, err := dnsData.ExpireRecords(now)
+		if dnsLogger == nil {
+			continue
+		}
+		if err != nil {
+			dnsLogger.Warn("record expiry: remove failed", "error", err)
+			continue
+		}
+		for _, r := range expired {
+			dnsLogger.Info("record expired and removed", "name", r.Name, "type", r.Type, "value", r.Value,
+				"expires_at", r.ExpiresAt.Format(time.RFC3339), "owner", r.Owner)
+		}
+	}
+}
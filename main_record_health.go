@@ -0,0 +1,85 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"dnsplane/data"
+	"dnsplane/healthcheck"
+)
+
+// runRecordHealthProbeLoop runs the health checks attached to local records, each on its own interval,
+// until ctx is done. A probe still in flight is not started again. The job list is rebuilt only when the
+// records change.
+func runRecordHealthProbeLoop(ctx context.Context, dnsData *data.DNSResolverData, dnsLogger *slog.Logger) {
+	warn := func(msg string, kv ...any) {
+		if dnsLogger != nil {
+			dnsLogger.Warn(msg, kv...)
+		}
+	}
+	info := func(msg string, kv ...any) {
+		if dnsLogger != nil {
+			dnsLogger.Info(msg, kv...)
+		}
+	}
+	var mu sync.Mutex
+	busy := make(map[string]bool)
+	next := make(map[string]time.Time)
+	var jobs []data.RecordHealthJob
+	gen, loaded := uint64(0), false
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if g := dnsData.RecordsGeneration(); !loaded || g != gen {
+			gen, loaded = g, true
+			jobs = dnsData.RecordHealthJobs()
+			current := make(map[string]bool, len(jobs))
+			for _, job := range jobs {
+				current[job.Key] = true
+			}
+			for key := range next {
+				if !current[key] {
+					delete(next, key)
+				}
+			}
+		}
+		now := time.Now()
+		for _, job := range jobs {
+			if now.Before(next[job.Key]) {
+				continue
+			}
+			mu.Lock()
+			inFlight := busy[job.Key]
+			busy[job.Key] = true
+			mu.Unlock()
+			if inFlight {
+				continue
+			}
+			next[job.Key] = now.Add(job.Check.Interval())
+			go func(job data.RecordHealthJob) {
+				err := healthcheck.Probe(ctx, job.Value, job.Name, job.Check)
+				if ctx.Err() != nil {
+					return
+				}
+				errStr := ""
+				if err != nil {
+					errStr = err.Error()
+				}
+				dnsData.ApplyRecordProbeResult(job, err == nil, errStr, warn, info)
+				mu.Lock()
+				delete(busy, job.Key)
+				mu.Unlock()
+			}(job)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"dnsplane/config"
+	"dnsplane/data"
+	"dnsplane/zones"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	recordsCmd = &cobra.Command{
+		Use:   "records",
+		Short: "Export or import local DNS records as zone files",
+	}
+	recordsExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Write local records as BIND zone files, JSON, CSV, or octoDNS YAML",
+		Args:  cobra.NoArgs,
+		RunE:  runRecordsExport,
+	}
+	recordsImportCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "Replace local records of a zone from a zone file, showing the diff",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runRecordsImport,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(recordsCmd)
+	recordsCmd.AddCommand(recordsExportCmd, recordsImportCmd)
+	for _, c := range []*cobra.Command{recordsExportCmd, recordsImportCmd} {
+		c.Flags().String("config", "", "Path to config file (default: standard search order)")
+		c.Flags().String("dnsrecords", "", "Path to dnsrecords.json file (overrides config)")
+		c.Flags().String("zone", "", "Only this zone (required for octodns-yaml)")
+	}
+	recordsExportCmd.Flags().String("format", zones.FormatBIND, "Output format: bind, json, csv, or octodns-yaml")
+	recordsExportCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+	recordsImportCmd.Flags().String("format", "", "Input format: bind, json, csv, or octodns-yaml (default: from the file extension)")
+	recordsImportCmd.Flags().Bool("dry-run", false, "Show the diff without writing dnsrecords.json")
+}
+
+// loadRecordsConfig loads the config like the server does (with the --dnsrecords override) so
+// data.LoadDNSRecords reads the configured records source.
+func loadRecordsConfig(cmd *cobra.Command) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	var loaded *config.Loaded
+	var err error
+	if configPath != "" {
+		loaded, err = config.LoadFromPath(configPath)
+	} else {
+		loaded, err = config.Load()
+	}
+	if err != nil {
+		return err
+	}
+	if dnsrecords, _ := cmd.Flags().GetString("dnsrecords"); dnsrecords != "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		loaded.Config.FileLocations.RecordsSource = &config.RecordsSourceConfig{Type: config.RecordsSourceFile, Location: resolveDataPath(dnsrecords, "dnsrecords.json", cwd)}
+		loaded.Config.FileLocations.RecordsSources = nil
+	}
+	data.SetConfig(loaded)
+	return nil
+}
+
+func runRecordsExport(cmd *cobra.Command, args []string) error {
+	if err := loadRecordsConfig(cmd); err != nil {
+		return err
+	}
+	f, _ := cmd.Flags().GetString("format")
+	format, err := zones.ParseFormat(f)
+	if err != nil {
+		return err
+	}
+	zone, _ := cmd.Flags().GetString("zone")
+	records, err := data.LoadDNSRecords()
+	if err != nil {
+		return err
+	}
+	var out io.Writer = cmd.OutOrStdout()
+	if path, _ := cmd.Flags().GetString("output"); path != "" {
+		file, err := os.Create(path) // #nosec G304 -- operator-supplied output path
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		out = file
+	}
+	return zones.Export(out, format, records, zone)
+}
+
+func runRecordsImport(cmd *cobra.Command, args []string) error {
+	if err := loadRecordsConfig(cmd); err != nil {
+		return err
+	}
+	path := args[0]
+	format := zones.FormatForPath(path)
+	if f, _ := cmd.Flags().GetString("format"); f != "" {
+		var err error
+		if format, err = zones.ParseFormat(f); err != nil {
+			return err
+		}
+	}
+	zone, _ := cmd.Flags().GetString("zone")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if !dryRun && data.RecordsSourceIsReadOnly() {
+		return fmt.Errorf("records source is read-only; import needs a file records source (use --dry-run to see the diff)")
+	}
+
+	file, err := os.Open(path) // #nosec G304 -- operator-supplied import path
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	res, err := zones.Import(file, format, zone)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	current, err := data.LoadDNSRecords()
+	if err != nil {
+		return err
+	}
+	ch, err := zones.Apply(current, res.Records, zone)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	for _, warn := range res.Warnings {
+		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "warning:", warn)
+	}
+	for _, line := range ch.DiffLines() {
+		_, _ = fmt.Fprintln(w, line)
+	}
+	switch {
+	case dryRun:
+		_, _ = fmt.Fprintln(w, "Dry run:", ch.Summary())
+		return nil
+	case ch.Empty():
+		_, _ = fmt.Fprintln(w, "No changes:", ch.Summary())
+		return nil
+	}
+	if err := data.SaveDNSRecords(ch.Records); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(w, "Imported:", ch.Summary())
+	_, _ = fmt.Fprintln(w, "A running server picks up the change on `record load` or POST /dns/records/reload.")
+	return nil
+}
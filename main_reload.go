@@ -0,0 +1,289 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"dnsplane/api"
+	"dnsplane/config"
+	"dnsplane/data"
+	"dnsplane/dnsservers"
+	"dnsplane/events"
+	"dnsplane/liveconfig"
+	"dnsplane/tracing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const configWatchDebounce = 500 * time.Millisecond
+
+var (
+	// reloadOverrides puts the command-line flags back over a configuration re-read from the file, so a reload
+	// does not move a listener the flags placed.
+	reloadOverrides = func(*config.Config) {}
+
+	// adblockStampMu guards adblockStamp, the size and modification time of the adblock list files when they
+	// were last loaded.
+	adblockStampMu sync.Mutex
+	adblockStamp   string
+
+	configWatchMu sync.Mutex
+	configWatch   *configWatcher
+)
+
+// applyLiveConfig rebuilds what main keeps outside the settings after they change.
+func applyLiveConfig(st config.Config) {
+	rebuildDNSLimiters(st)
+	api.SetRateLimit(st.APIRateLimitPerIP, st.APIRateLimitBurst)
+	setConfigWatch(st)
+	tracing.Configure(st.Tracing, dnsLogger)
+	events.Configure(st.Events, dnsLogger)
+}
+
+// startConfigReload reloads the configuration on SIGHUP and, with config_watch, when the files change.
+func startConfigReload() {
+	st := data.GetInstance().GetResolverSettings()
+	adblockStampMu.Lock()
+	adblockStamp = fileStamp(st.AdblockListFiles)
+	adblockStampMu.Unlock()
+	setConfigWatch(st)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			sshAuthorizedKeys.reset()
+			reloadConfig("sighup")
+		}
+	}()
+}
+
+// reloadConfig re-reads dnsplane.json, the DNS servers file, and the adblock list files, and applies what changed
+// as PATCH /config would: live settings at once, listener restarts only for the ports and binds that moved. A
+// file that does not parse or validate leaves everything as it was. On SIGHUP the records sources are reloaded
+// too; with config_watch they have their own per-source watch.
+func reloadConfig(reason string) {
+	liveconfig.Lock()
+	defer liveconfig.Unlock()
+	logger := dnsLogger
+	path := data.ConfigPath()
+	cfg, err := config.Read(path)
+	if err != nil {
+		logger.Error("config reload failed; keeping the running configuration", "reason", reason, "path", path, "error", err)
+		return
+	}
+	reloadOverrides(cfg)
+	dnsData := data.GetInstance()
+	p := liveconfig.Propose(dnsData.GetResolverSettings(), *cfg)
+	if len(p.Errors) > 0 {
+		msgs := make([]string, 0, len(p.Errors))
+		for _, e := range p.Errors {
+			msgs = append(msgs, e.Field+": "+e.Message)
+		}
+		logger.Error("config reload rejected; keeping the running configuration", "reason", reason, "path", path, "errors", msgs)
+		return
+	}
+
+	summary := []any{"reason", reason}
+	changed := false
+	adblockBefore := dnsData.GetBlockList().Count()
+	if len(p.Changes) > 0 {
+		restarted, err := liveconfig.Apply(p, p.Restarts())
+		if err != nil {
+			logger.Error("config reload rolled back", "reason", reason, "changes", p.Fields(), "error", err)
+			return
+		}
+		changed = true
+		summary = append(summary, "changes", p.Fields(), "restarted", restarted, "pending_restart", p.PendingRestart())
+	}
+	st := dnsData.GetResolverSettings()
+
+	if servers, err := data.LoadDNSServers(); err != nil {
+		logger.Warn("config reload: DNS servers file not read; keeping the running servers", "reason", reason, "error", err)
+	} else if added, removed, updated := diffServers(dnsData.GetServers(), servers); added+removed+updated > 0 {
+		dnsData.ReplaceServers(servers)
+		changed = true
+		summary = append(summary, "servers_added", added, "servers_removed", removed, "servers_changed", updated)
+	}
+
+	stamp := fileStamp(st.AdblockListFiles)
+	pathsChanged := !slices.Equal(st.AdblockListFiles, p.Old.AdblockListFiles)
+	adblockStampMu.Lock()
+	if stamp != adblockStamp || pathsChanged {
+		// Apply reloaded the lists if their paths changed; this catches files edited in place.
+		if !pathsChanged {
+			dnsData.ReloadAdblockLists(st.AdblockListFiles)
+		}
+		adblockStamp = stamp
+		changed = true
+		summary = append(summary, "adblock_domains_before", adblockBefore, "adblock_domains", dnsData.GetBlockList().Count())
+	}
+	adblockStampMu.Unlock()
+
+	if reason == "sighup" {
+		if n, err := data.ReloadDNSRecordsFromSource(); err != nil {
+			logger.Warn("config reload: records not reloaded", "reason", reason, "error", err)
+		} else {
+			changed = true
+			summary = append(summary, "records", n)
+		}
+	}
+
+	if !changed {
+		logger.Debug("config reloaded; nothing changed", "reason", reason)
+		return
+	}
+	logger.Info("config reloaded", summary...)
+	// The event carries the fields of the summary line.
+	ev := map[string]any{}
+	for i := 0; i+1 < len(summary); i += 2 {
+		ev[summary[i].(string)] = summary[i+1]
+	}
+	events.Publish(events.ConfigReloaded, ev)
+}
+
+// diffServers counts the upstreams added, removed, and changed between two lists, matching rows by address,
+// port, transport, and DoH URL.
+func diffServers(old, updated []dnsservers.DNSServer) (added, removed, changed int) {
+	key := func(s dnsservers.DNSServer) string {
+		return strings.Join([]string{s.Address, s.Port, s.Transport, s.DoHURL}, "|")
+	}
+	prev := make(map[string]dnsservers.DNSServer, len(old))
+	for _, s := range old {
+		prev[key(s)] = s
+	}
+	for _, s := range updated {
+		o, ok := prev[key(s)]
+		switch {
+		case !ok:
+			added++
+		case fmt.Sprint(o) != fmt.Sprint(s):
+			changed++
+		}
+		delete(prev, key(s))
+	}
+	return added, removed + len(prev), changed
+}
+
+// fileStamp sums up the size and modification time of files, to tell whether any changed.
+func fileStamp(files []string) string {
+	var b strings.Builder
+	for _, f := range files {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if info, err := os.Stat(f); err == nil {
+			fmt.Fprintf(&b, "%s %d %d\n", f, info.Size(), info.ModTime().UnixNano())
+		} else {
+			fmt.Fprintf(&b, "%s missing\n", f)
+		}
+	}
+	return b.String()
+}
+
+// configWatcher reloads the configuration when one of its files changes. The files are watched through their
+// directories, since editors replace a file rather than write it in place.
+type configWatcher struct {
+	files   []string
+	watcher *fsnotify.Watcher
+}
+
+// watchedFiles lists the files config_watch follows for st.
+func watchedFiles(st config.Config) []string {
+	files := []string{data.ConfigPath(), st.FileLocations.DNSServerFile}
+	files = append(files, st.AdblockListFiles...)
+	var out []string
+	for _, f := range files {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, filepath.Clean(f))
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// setConfigWatch starts, stops, or retargets the config_watch watcher to match st.
+func setConfigWatch(st config.Config) {
+	configWatchMu.Lock()
+	defer configWatchMu.Unlock()
+	files := watchedFiles(st)
+	if cw := configWatch; cw != nil {
+		if st.ConfigWatch && slices.Equal(cw.files, files) {
+			return
+		}
+		_ = cw.watcher.Close()
+		configWatch = nil
+	}
+	if !st.ConfigWatch {
+		return
+	}
+	cw, err := newConfigWatcher(files)
+	if err != nil {
+		dnsLogger.Error("config watch: not started", "error", err)
+		return
+	}
+	configWatch = cw
+}
+
+func newConfigWatcher(files []string) (*configWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("fsnotify init: %w", err)
+	}
+	var dirs []string
+	for _, f := range files {
+		dirs = append(dirs, filepath.Dir(f))
+	}
+	slices.Sort(dirs)
+	for _, dir := range slices.Compact(dirs) {
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+	cw := &configWatcher{files: files, watcher: watcher}
+	go cw.run()
+	return cw, nil
+}
+
+// run collects events until the watcher is closed. The reload runs from a timer, outside this loop, so that
+// the reload can close this watcher when the set of files changes.
+func (cw *configWatcher) run() {
+	var pending *time.Timer
+	events, errs := cw.watcher.Events, cw.watcher.Errors
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				if pending != nil {
+					pending.Stop()
+				}
+				return
+			}
+			if !slices.Contains(cw.files, filepath.Clean(ev.Name)) || ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if pending != nil {
+				pending.Stop()
+			}
+			pending = time.AfterFunc(configWatchDebounce, func() { reloadConfig("watch") })
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			dnsLogger.Warn("config watch error", "error", err)
+		}
+	}
+}
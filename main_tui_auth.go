@@ -0,0 +1,200 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+
+	"dnsplane/config"
+	"dnsplane/tuiauth"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var hashPasswordCmd = &cobra.Command{
+	Use:   "hash-password",
+	Short: "Print a password_hash for a tui_auth user (reads the password from the terminal or stdin)",
+	Args:  cobra.NoArgs,
+	RunE:  runHashPassword,
+}
+
+func init() {
+	rootCmd.AddCommand(hashPasswordCmd)
+}
+
+func runHashPassword(cmd *cobra.Command, args []string) error {
+	var password string
+	fd := int(os.Stdin.Fd()) // #nosec G115 -- stdin descriptor
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		first, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stderr, "Again: ")
+		second, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		if string(first) != string(second) {
+			return errors.New("passwords do not match")
+		}
+		password = string(first)
+	} else {
+		line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("reading password from stdin: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	hash, err := tuiauth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), hash)
+	return nil
+}
+
+// tuiHello is the first line a TUI client sends: nothing, the kill command, or a login
+// ("dnsplane-auth <user|-> <base64 password|-> [kill]").
+type tuiHello struct {
+	user     string
+	password string
+	kill     bool
+}
+
+func parseTUIHello(line string) tuiHello {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return tuiHello{}
+	}
+	if fields[0] == tuiClientKillCmd {
+		return tuiHello{kill: true}
+	}
+	if fields[0] != tuiAuthCmd {
+		return tuiHello{}
+	}
+	var h tuiHello
+	if len(fields) > 1 && fields[1] != "-" {
+		h.user = fields[1]
+	}
+	if len(fields) > 2 && fields[2] != "-" {
+		if b, err := base64.StdEncoding.DecodeString(fields[2]); err == nil {
+			h.password = string(b)
+		}
+	}
+	h.kill = len(fields) > 3 && fields[3] == "kill"
+	return h
+}
+
+// formatTUIHello builds the login line for parseTUIHello.
+func formatTUIHello(user, password string, kill bool) string {
+	u, p := "-", "-"
+	if user != "" {
+		u = user
+	}
+	if password != "" {
+		p = base64.StdEncoding.EncodeToString([]byte(password))
+	}
+	line := fmt.Sprintf("%s %s %s", tuiAuthCmd, u, p)
+	if kill {
+		line += " kill"
+	}
+	return line
+}
+
+// secureTUIListener wraps the TCP TUI listener in TLS when tui_auth has a certificate and returns the
+// authenticator when users are configured. Users without TLS are refused so passwords never cross the
+// network in clear text.
+func secureTUIListener(l net.Listener, cfg config.TUIAuthConfig, log *slog.Logger) (net.Listener, *tuiauth.Authenticator, error) {
+	if !cfg.TLSEnabled() {
+		if cfg.LoginRequired() {
+			return nil, nil, errors.New("tui_auth users need tui_auth.tls_cert and tui_auth.tls_key")
+		}
+		return l, nil, nil
+	}
+	tc, err := tuiServerTLSConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var auth *tuiauth.Authenticator
+	if cfg.LoginRequired() {
+		auth = tuiauth.New(cfg)
+		for _, err := range auth.Check() {
+			if log != nil {
+				log.Warn("tui_auth user problem", "error", err)
+			}
+		}
+	}
+	return tls.NewListener(l, tc), auth, nil
+}
+
+func tuiServerTLSConfig(cfg config.TUIAuthConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("tui_auth certificate: %w", err)
+	}
+	tc := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+	if ca := strings.TrimSpace(cfg.ClientCA); ca != "" {
+		pool, err := loadCertPool(ca)
+		if err != nil {
+			return nil, fmt.Errorf("tui_auth client_ca: %w", err)
+		}
+		tc.ClientCAs = pool
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tc, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	raw, err := os.ReadFile(path) // #nosec G304 -- path from operator config or command line
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(raw) {
+		return nil, fmt.Errorf("%s: no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// tuiPeerCertificate completes the TLS handshake of conn, if it is TLS, and returns the verified client
+// certificate, if any.
+func tuiPeerCertificate(conn net.Conn) (*x509.Certificate, error) {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil, nil
+	}
+	if err := tc.Handshake(); err != nil {
+		return nil, err
+	}
+	if chains := tc.ConnectionState().VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+		return chains[0][0], nil
+	}
+	return nil, nil
+}
+
+func connHost(conn net.Conn) string {
+	return addrHost(conn.RemoteAddr())
+}
+
+func addrHost(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
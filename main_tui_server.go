@@ -17,7 +17,11 @@ import (
 	"syscall"
 	"time"
 
+	"dnsplane/commandhandler"
+	"dnsplane/config"
 	"dnsplane/daemon"
+	"dnsplane/data"
+	"dnsplane/tuiauth"
 
 	"github.com/chzyer/readline"
 	tui "github.com/network-plane/planetui"
@@ -44,7 +48,7 @@ func startClientTCPListener(state *daemon.State, log *slog.Logger) {
 		addr = defaultTCPTerminalAddr
 	}
 	tcpTUIListenerMu.Unlock()
-	listener, err := startTCPTerminalListener(addr, log)
+	listener, auth, err := startTCPTerminalListener(addr, log)
 	if err != nil {
 		if log != nil {
 			log.Error("failed to start TCP TUI listener", "address", addr, "error", err)
@@ -55,7 +59,7 @@ func startClientTCPListener(state *daemon.State, log *slog.Logger) {
 	tcpTUIListenerMu.Lock()
 	tcpTUIListener = listener
 	tcpTUIListenerMu.Unlock()
-	go acceptInteractiveSessions(listener, log)
+	go acceptInteractiveSessions(listener, auth, log)
 	state.UpdateListener(func(info *daemon.ListenerSettings) {
 		info.ClientTCPAddress = addr
 	})
@@ -81,6 +85,37 @@ func stopClientTCPListener(log *slog.Logger) {
 	fmt.Println("Client TCP listener stopped.")
 }
 
+// restartTUIListeners starts the TCP and SSH TUI listeners again with the current settings, after a change to
+// server_tcp, tui_auth, or tui_ssh. Connected sessions stay up.
+func restartTUIListeners(state *daemon.State, log *slog.Logger) error {
+	addr := normalizeTCPAddress(strings.TrimSpace(data.GetInstance().GetResolverSettings().ClientTCPAddress))
+	tcpTUIListenerMu.Lock()
+	old := tcpTUIListener
+	tcpTUIListener = nil
+	tcpTUIListenerMu.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
+	state.UpdateListener(func(info *daemon.ListenerSettings) {
+		info.ClientTCPAddress = addr
+	})
+	if addr != "" {
+		listener, auth, err := startTCPTerminalListener(addr, log)
+		if err != nil {
+			return fmt.Errorf("tcp: %w", err)
+		}
+		tcpTUIListenerMu.Lock()
+		tcpTUIListener = listener
+		tcpTUIListenerMu.Unlock()
+		go acceptInteractiveSessions(listener, auth, log)
+	}
+	stopSSHTUIListener()
+	if _, err := startSSHTUIListener(log); err != nil {
+		return fmt.Errorf("ssh: %w", err)
+	}
+	return nil
+}
+
 func isClientTCPListenerRunning() bool {
 	tcpTUIListenerMu.Lock()
 	running := tcpTUIListener != nil
@@ -110,18 +145,26 @@ func startUnixSocketListener(socketPath string, log *slog.Logger) (net.Listener,
 	return listener, nil
 }
 
-func startTCPTerminalListener(address string, log *slog.Logger) (net.Listener, error) {
+// startTCPTerminalListener listens for remote TUI clients, with TLS and logins when tui_auth asks for them.
+func startTCPTerminalListener(address string, log *slog.Logger) (net.Listener, *tuiauth.Authenticator, error) {
+	cfg := data.GetInstance().GetResolverSettings().TUIAuth
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	secured, auth, err := secureTUIListener(listener, cfg, log)
+	if err != nil {
+		_ = listener.Close()
+		return nil, nil, err
 	}
 	if log != nil {
-		log.Info("Listening on TCP address for TUI clients", "address", address)
+		log.Info("Listening on TCP address for TUI clients", "address", address, "tls", cfg.TLSEnabled(), "login", auth != nil)
 	}
-	return listener, nil
+	return secured, auth, nil
 }
 
-func acceptInteractiveSessions(listener net.Listener, log *slog.Logger) {
+// acceptInteractiveSessions serves TUI clients from listener. auth is nil when clients need not log in.
+func acceptInteractiveSessions(listener net.Listener, auth *tuiauth.Authenticator, log *slog.Logger) {
 	defer func() { _ = listener.Close() }()
 	for {
 		conn, err := listener.Accept()
@@ -140,56 +183,160 @@ func acceptInteractiveSessions(listener net.Listener, log *slog.Logger) {
 			}
 			return
 		}
-		go serveInteractiveSession(conn, log)
+		go serveInteractiveSession(conn, auth, log)
 	}
 }
 
-func serveInteractiveSession(conn net.Conn, log *slog.Logger) {
+func serveInteractiveSession(conn net.Conn, auth *tuiauth.Authenticator, log *slog.Logger) {
 	defer func() { _ = conn.Close() }()
 
 	addr := formatConnAddr(conn)
 	if log != nil {
 		log.Info("TUI client connected", "addr", addr)
-		defer func() { log.Debug("TUI client disconnected", "addr", addr) }()
 	}
 
-	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	peerCert, err := tuiPeerCertificate(conn)
+	if err != nil {
+		if log != nil {
+			log.Warn("TUI TLS handshake failed", "addr", addr, "error", err)
+		}
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	wait := 2 * time.Second
+	if auth != nil {
+		wait = 10 * time.Second
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(wait))
 	clientLine, _ := bufio.NewReader(conn).ReadString('\n')
 	_ = conn.SetReadDeadline(time.Time{})
-	clientLine = strings.TrimSpace(clientLine)
+	hello := parseTUIHello(clientLine)
 
-	tuiLock := appState.TUISessionMutex()
-	if strings.TrimSpace(clientLine) == tuiClientKillCmd {
-		appState.DisconnectCurrentTUIClient()
-		tuiLock.Lock()
-	} else {
-		if !tuiLock.TryLock() {
-			curAddr, curSince := appState.GetTUIClientInfo()
+	session := commandhandler.Session{Role: config.TUIRoleAdmin, Addr: addr, Transport: commandhandler.TransportTCP}
+	if conn.RemoteAddr() != nil && conn.RemoteAddr().Network() == "unix" {
+		session.User, session.Transport = "local", commandhandler.TransportUnix
+	}
+	if auth != nil {
+		user, err := auth.Login(hello.user, hello.password, peerCert, connHost(conn), time.Now())
+		if err == nil && hello.kill && user.Role != config.TUIRoleAdmin {
+			err = fmt.Errorf("taking over a session needs the %s role", config.TUIRoleAdmin)
+		}
+		if err != nil {
+			if log != nil {
+				log.Warn("TUI login refused", "addr", addr, "user", hello.user, "certificate", peerCert != nil, "error", err)
+			}
+			if werr := conn.SetWriteDeadline(time.Now().Add(3 * time.Second)); werr == nil {
+				_, _ = fmt.Fprintf(conn, "%s %s\n", tuiBannerDenied, err)
+			}
+			return
+		}
+		session.User, session.Role = user.Name, user.Role
+		if log != nil {
+			log.Info("TUI login", "addr", addr, "user", user.Name, "role", user.Role, "certificate", peerCert != nil && hello.password == "")
+		}
+	}
+
+	writeLine := func(format string, args ...any) {
+		if err := conn.SetWriteDeadline(time.Now().Add(3 * time.Second)); err == nil {
+			_, _ = fmt.Fprintf(conn, format, args...)
+			_ = conn.SetWriteDeadline(time.Time{})
+		}
+	}
+	runTUISession(tuiSession{
+		Session:  session,
+		conn:     conn,
+		out:      &crlfWriter{w: conn},
+		lockConn: conn,
+		takeOver: hello.kill,
+		busy: func(curAddr string, curSince time.Time) {
 			sinceStr := ""
 			if !curSince.IsZero() {
 				sinceStr = curSince.Format(time.RFC3339)
 			}
-			if err := conn.SetWriteDeadline(time.Now().Add(3 * time.Second)); err == nil {
-				_, _ = fmt.Fprintf(conn, "%s %s %s\n", tuiBannerBusy, curAddr, sinceStr)
-				_ = conn.SetWriteDeadline(time.Time{})
+			writeLine("%s %s %s\n", tuiBannerBusy, curAddr, sinceStr)
+		},
+		greet: func() { writeLine("%s %s\n", tuiBannerPrefix, appVersion) },
+	}, log)
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+	}
+}
+
+// tuiSession is a logged-in TUI client on any transport.
+type tuiSession struct {
+	commandhandler.Session
+	conn io.ReadWriteCloser // the client's terminal
+	out  io.Writer          // command output to conn
+	term remoteTerminal
+
+	// lockConn, set by the socket and TCP listeners, makes the session take the single-client lock and
+	// run on the default engine; --kill closes it. Without it (SSH) the session gets an engine of its
+	// own and runs beside the others.
+	lockConn net.Conn
+	takeOver bool                               // displace the client holding the lock instead of giving up
+	busy     func(addr string, since time.Time) // tells the client who holds the lock
+
+	greet   func() // writes the transport's welcome once the session runs
+	exec    bool   // run command instead of the interactive loop
+	command string
+}
+
+// runTUISession runs s until the client quits or disconnects, or runs its one command. It reports false
+// when the session could not start or the command failed.
+func runTUISession(s tuiSession, log *slog.Logger) bool {
+	if log != nil {
+		log.Info("TUI session started", "addr", s.Addr, "user", s.User, "role", s.Role, "transport", s.Transport, "exec", s.exec)
+		defer func() { log.Debug("TUI session ended", "addr", s.Addr, "user", s.User, "transport", s.Transport) }()
+	}
+	var engine *tui.Engine
+	if s.lockConn == nil {
+		engine = commandhandler.NewEngine(s.Session, s.out, log)
+	} else {
+		tuiLock := appState.TUISessionMutex()
+		if s.takeOver {
+			appState.DisconnectCurrentTUIClient()
+			tuiLock.Lock()
+		} else if !tuiLock.TryLock() {
+			if s.busy != nil {
+				s.busy(appState.GetTUIClientInfo())
 			}
-			return
+			return false
 		}
-	}
-	defer tuiLock.Unlock()
+		defer tuiLock.Unlock()
 
-	appState.SetTUIClientSession(conn, addr)
-	defer appState.ClearTUIClientSession()
+		appState.SetTUIClientSession(s.lockConn, s.Addr)
+		defer appState.ClearTUIClientSession()
+		commandhandler.SetSession(s.Session)
+		defer commandhandler.ClearSession()
 
-	if err := conn.SetWriteDeadline(time.Now().Add(3 * time.Second)); err == nil {
-		_, _ = fmt.Fprintf(conn, "%s %s\n", tuiBannerPrefix, appVersion)
-		_ = conn.SetWriteDeadline(time.Time{})
+		prevOutputWriter := tui.SetOutputWriter(s.out)
+		defer tui.SetOutputWriter(prevOutputWriter)
+		defer resetTUIState()
+		resetTUIState()
+		engine = tui.DefaultEngine()
 	}
 
-	crlfConn := &crlfWriter{w: conn}
-	prevOutputWriter := tui.SetOutputWriter(crlfConn)
-	defer tui.SetOutputWriter(prevOutputWriter)
+	if s.greet != nil {
+		s.greet()
+	}
+	if s.exec {
+		return commandhandler.Exec(engine, s.command, s.out, log)
+	}
+	runTUITerminal(engine, s.conn, s.term)
+	return true
+}
+
+// remoteTerminal is the size of a client terminal; nil means a fixed 80 columns.
+type remoteTerminal interface {
+	Width() int
+	OnResize(func())
+}
 
+// runTUITerminal runs the command loop of engine on a remote client's terminal until the client quits or
+// disconnects.
+func runTUITerminal(engine *tui.Engine, conn io.ReadWriteCloser, term remoteTerminal) {
 	cfg := appState.ReadlineConfig()
 	cfg.Stdin = conn
 	cfg.Stdout = conn
@@ -202,6 +349,10 @@ func serveInteractiveSession(conn net.Conn, log *slog.Logger) {
 	cfg.FuncExitRaw = func() error { return nil }
 	cfg.FuncIsTerminal = func() bool { return true }
 	cfg.FuncGetWidth = func() int { return 80 }
+	if term != nil {
+		cfg.FuncGetWidth = term.Width
+		cfg.FuncOnWidthChanged = term.OnResize
+	}
 	cfg.ForceUseInteractive = true
 
 	rl, err := readline.NewEx(&cfg)
@@ -210,17 +361,12 @@ func serveInteractiveSession(conn net.Conn, log *slog.Logger) {
 		return
 	}
 	defer func() { _ = rl.Close() }()
-	defer resetTUIState()
-	resetTUIState()
 
-	if err := tui.Run(rl); err != nil {
+	if err := engine.Run(rl); err != nil {
 		_, _ = fmt.Fprintf(conn, "\r\nSession terminated: %v\r\n", err)
 	} else {
 		_, _ = fmt.Fprint(conn, "\rShutting down session.\r\n")
 	}
-	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
-		_ = cw.CloseWrite()
-	}
 }
 
 func formatConnAddr(conn net.Conn) string {
@@ -0,0 +1,380 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"dnsplane/commandhandler"
+	"dnsplane/config"
+	"dnsplane/data"
+	"dnsplane/tuiauth"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	sshTUIListenerMu sync.Mutex
+	sshTUIListener   net.Listener
+)
+
+// startSSHTUIListener serves the TUI over SSH when tui_ssh.listen is set. Every connection may run
+// several sessions, and sessions from different connections run side by side; they do not take the
+// single-client lock of the socket and TCP listeners.
+func startSSHTUIListener(log *slog.Logger) (net.Listener, error) {
+	settings := data.GetInstance().GetResolverSettings()
+	addr := strings.TrimSpace(settings.TUISSH.Listen)
+	if addr == "" {
+		return nil, nil
+	}
+	if len(sshUsers(settings.TUIAuth.Users)) == 0 {
+		return nil, errors.New("tui_ssh needs tui_auth users with authorized_keys")
+	}
+	signer, created, err := loadOrCreateHostKey(settings.TUISSH.HostKey)
+	if err != nil {
+		return nil, fmt.Errorf("tui_ssh host key: %w", err)
+	}
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: sshPublicKeyCallback(tuiauth.New(settings.TUIAuth), log),
+		MaxAuthTries:      6,
+		ServerVersion:     "SSH-2.0-dnsplane",
+		AuthLogCallback: func(conn ssh.ConnMetadata, method string, err error) {
+			if err != nil && method != "none" && log != nil {
+				log.Warn("SSH TUI login refused", "addr", conn.RemoteAddr().String(), "user", conn.User(), "method", method, "error", err)
+			}
+		},
+	}
+	cfg.AddHostKey(signer)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if log != nil {
+		log.Info("Listening for SSH TUI sessions", "address", addr, "host_key", ssh.FingerprintSHA256(signer.PublicKey()), "host_key_created", created)
+	}
+	sshTUIListenerMu.Lock()
+	sshTUIListener = listener
+	sshTUIListenerMu.Unlock()
+	go acceptSSHSessions(listener, cfg, log)
+	return listener, nil
+}
+
+func isSSHTUIListenerRunning() bool {
+	sshTUIListenerMu.Lock()
+	defer sshTUIListenerMu.Unlock()
+	return sshTUIListener != nil
+}
+
+func stopSSHTUIListener() {
+	sshTUIListenerMu.Lock()
+	l := sshTUIListener
+	sshTUIListener = nil
+	sshTUIListenerMu.Unlock()
+	if l != nil {
+		_ = l.Close()
+	}
+}
+
+// sshUsers returns the tui_auth users that can log in over SSH.
+func sshUsers(users []config.TUIUser) []config.TUIUser {
+	var out []config.TUIUser
+	for _, u := range users {
+		if strings.TrimSpace(u.AuthorizedKeys) != "" {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// sshPublicKeyCallback accepts a key listed in the authorized_keys file of the tui_auth user named by the
+// client. Refused keys count towards the tui_auth lockout of the remote host and the user name, and a
+// locked-out client is refused before its key is looked at.
+func sshPublicKeyCallback(auth *tuiauth.Authenticator, log *slog.Logger) func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+	return func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		user, err := auth.LoginKey(conn.User(), addrHost(conn.RemoteAddr()), time.Now(), func(u config.TUIUser) bool {
+			ok, err := sshAuthorizedKeys.contains(u.AuthorizedKeys, key, log)
+			if err != nil && log != nil {
+				log.Error("SSH TUI authorized_keys unreadable", "user", u.Name, "path", u.AuthorizedKeys, "error", err)
+			}
+			return ok
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &ssh.Permissions{Extensions: map[string]string{"role": user.Role, "key": ssh.FingerprintSHA256(key)}}, nil
+	}
+}
+
+// sshAuthorizedKeys caches the authorized_keys files of the SSH users.
+var sshAuthorizedKeys authorizedKeysCache
+
+// authorizedKeysCache keeps parsed authorized_keys files. A file is read again when its modification time
+// or size changes, and reset (on SIGHUP) drops them all.
+type authorizedKeysCache struct {
+	mu    sync.Mutex
+	files map[string]authorizedKeysFile
+}
+
+type authorizedKeysFile struct {
+	modTime time.Time
+	size    int64
+	keys    map[string]bool // by ssh.PublicKey.Marshal
+}
+
+func (c *authorizedKeysCache) reset() {
+	c.mu.Lock()
+	c.files = nil
+	c.mu.Unlock()
+}
+
+// contains reports whether key is listed in the authorized_keys file at path.
+func (c *authorizedKeysCache) contains(path string, key ssh.PublicKey, log *slog.Logger) (bool, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.files[path]
+	if !ok || !f.modTime.Equal(fi.ModTime()) || f.size != fi.Size() {
+		keys, err := readAuthorizedKeys(path, log)
+		if err != nil {
+			return false, err
+		}
+		f = authorizedKeysFile{modTime: fi.ModTime(), size: fi.Size(), keys: keys}
+		if c.files == nil {
+			c.files = make(map[string]authorizedKeysFile)
+		}
+		c.files[path] = f
+	}
+	return f.keys[string(key.Marshal())], nil
+}
+
+// readAuthorizedKeys returns the keys listed in the authorized_keys file at path. Entries with options
+// (from=, command=, ...) are skipped, since dnsplane cannot enforce them.
+func readAuthorizedKeys(path string, log *slog.Logger) (map[string]bool, error) {
+	raw, err := os.ReadFile(path) // #nosec G304 -- path from operator config
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for len(bytes.TrimSpace(raw)) > 0 {
+		pub, comment, options, rest, err := ssh.ParseAuthorizedKey(raw)
+		if err != nil {
+			// No more parseable keys; ParseAuthorizedKey skips bad lines itself.
+			break
+		}
+		raw = rest
+		if len(options) > 0 {
+			if log != nil {
+				log.Warn("SSH TUI authorized_keys entry with options skipped", "path", path, "comment", comment, "options", strings.Join(options, ","))
+			}
+			continue
+		}
+		keys[string(pub.Marshal())] = true
+	}
+	return keys, nil
+}
+
+// loadOrCreateHostKey reads the host key at path, creating an ed25519 key there when the file is missing.
+func loadOrCreateHostKey(path string) (ssh.Signer, bool, error) {
+	raw, err := os.ReadFile(path) // #nosec G304 -- path from operator config
+	if err == nil {
+		signer, err := ssh.ParsePrivateKey(raw)
+		return signer, false, err
+	}
+	if !os.IsNotExist(err) {
+		return nil, false, err
+	}
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, false, err
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "dnsplane host key")
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, false, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) // #nosec G304 -- path from operator config
+	if err != nil {
+		return nil, false, err
+	}
+	if err := pem.Encode(f, block); err != nil {
+		_ = f.Close()
+		return nil, false, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, false, err
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	return signer, true, err
+}
+
+func acceptSSHSessions(listener net.Listener, cfg *ssh.ServerConfig, log *slog.Logger) {
+	defer func() { _ = listener.Close() }()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			if !errors.Is(err, net.ErrClosed) && log != nil {
+				log.Error("Error accepting SSH connection", "error", err)
+			}
+			return
+		}
+		go serveSSHConn(conn, cfg, log)
+	}
+}
+
+func serveSSHConn(nc net.Conn, cfg *ssh.ServerConfig, log *slog.Logger) {
+	defer func() { _ = nc.Close() }()
+	addr := formatConnAddr(nc)
+	_ = nc.SetDeadline(time.Now().Add(30 * time.Second))
+	sc, chans, reqs, err := ssh.NewServerConn(nc, cfg)
+	if err != nil {
+		if log != nil {
+			log.Debug("SSH TUI handshake failed", "addr", addr, "error", err)
+		}
+		return
+	}
+	_ = nc.SetDeadline(time.Time{})
+	defer func() { _ = sc.Close() }()
+	go ssh.DiscardRequests(reqs)
+
+	session := commandhandler.Session{
+		User:      sc.User(),
+		Role:      sc.Permissions.Extensions["role"],
+		Addr:      addr,
+		Transport: commandhandler.TransportSSH,
+	}
+	if log != nil {
+		log.Info("TUI login", "addr", addr, "user", session.User, "role", session.Role, "transport", session.Transport, "key", sc.Permissions.Extensions["key"])
+	}
+	for nch := range chans {
+		if nch.ChannelType() != "session" {
+			_ = nch.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		ch, chReqs, err := nch.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSSHChannel(ch, chReqs, session, log)
+	}
+}
+
+// sshTerminal tracks the size of a session's pseudo-terminal for readline.
+type sshTerminal struct {
+	mu       sync.Mutex
+	width    int
+	onResize func()
+}
+
+func (t *sshTerminal) Width() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.width
+}
+
+func (t *sshTerminal) OnResize(f func()) {
+	t.mu.Lock()
+	t.onResize = f
+	t.mu.Unlock()
+}
+
+func (t *sshTerminal) resize(cols uint32) {
+	if cols == 0 {
+		return
+	}
+	t.mu.Lock()
+	t.width = int(cols)
+	f := t.onResize
+	t.mu.Unlock()
+	if f != nil {
+		f()
+	}
+}
+
+// serveSSHChannel answers the requests of one session channel: an optional pty-req and window-change,
+// then either shell (the interactive TUI) or exec (one command).
+func serveSSHChannel(ch ssh.Channel, reqs <-chan *ssh.Request, session commandhandler.Session, log *slog.Logger) {
+	term := &sshTerminal{width: 80}
+	pty, started := false, false
+	for req := range reqs {
+		switch req.Type {
+		case "pty-req":
+			var p struct {
+				Term                   string
+				Cols, Rows, PixW, PixH uint32
+				Modes                  string
+			}
+			if err := ssh.Unmarshal(req.Payload, &p); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			pty = true
+			term.resize(p.Cols)
+			_ = req.Reply(true, nil)
+		case "window-change":
+			var w struct{ Cols, Rows, PixW, PixH uint32 }
+			if ssh.Unmarshal(req.Payload, &w) == nil {
+				term.resize(w.Cols)
+			}
+		case "shell", "exec":
+			if started {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			var command string
+			if req.Type == "exec" {
+				var e struct{ Command string }
+				if err := ssh.Unmarshal(req.Payload, &e); err != nil {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				command = e.Command
+			}
+			started = true
+			_ = req.Reply(true, nil)
+			go runSSHSession(ch, session, term, pty, req.Type == "exec", command, log)
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+func runSSHSession(ch ssh.Channel, session commandhandler.Session, term *sshTerminal, pty, exec bool, command string, log *slog.Logger) {
+	defer func() { _ = ch.Close() }()
+	var out io.Writer = ch
+	if pty {
+		out = &crlfWriter{w: ch}
+	}
+	s := tuiSession{Session: session, conn: ch, out: out, term: term, exec: exec, command: command}
+	if pty && !exec {
+		s.greet = func() {
+			_, _ = fmt.Fprintf(out, "dnsplane %s; logged in as %s (%s)\n", appVersion, session.User, session.Role)
+		}
+	}
+	status := uint32(0)
+	if !runTUISession(s, log) {
+		status = 1
+	}
+	_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+}
@@ -0,0 +1,52 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package policy
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// arpTablePath is the Linux neighbour table; other platforms simply have no entries.
+const arpTablePath = "/proc/net/arp"
+
+const arpRefresh = 30 * time.Second
+
+var arpCache struct {
+	mu      sync.Mutex
+	at      time.Time
+	entries map[string]string
+}
+
+// NeighborMAC returns the MAC address the kernel ARP table holds for ip, or "" (re-read at most every 30s).
+func NeighborMAC(ip string) string {
+	arpCache.mu.Lock()
+	defer arpCache.mu.Unlock()
+	if arpCache.entries == nil || time.Since(arpCache.at) > arpRefresh {
+		arpCache.entries = readARPTable(arpTablePath)
+		arpCache.at = time.Now()
+	}
+	return arpCache.entries[strings.TrimSpace(ip)]
+}
+
+func readARPTable(path string) map[string]string {
+	out := map[string]string{}
+	f, err := os.Open(path) // #nosec G304 -- fixed kernel path
+	if err != nil {
+		return out
+	}
+	defer func() { _ = f.Close() }()
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 4 || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+		out[fields[0]] = fields[3]
+	}
+	return out
+}
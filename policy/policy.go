@@ -0,0 +1,277 @@
+// Package policy evaluates per-client group policies: block categories, time-of-day schedules,
+// safe-search rewriting, and per-group upstreams.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package policy
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"dnsplane/adblock"
+	"dnsplane/config"
+	"dnsplane/dnsservers"
+)
+
+// Action is what the resolver should do with a query after policy evaluation.
+type Action int
+
+const (
+	// Allow resolves normally (optionally via the group's Upstreams).
+	Allow Action = iota
+	// Block answers like adblock (0.0.0.0 / :: for A/AAAA, NXDOMAIN otherwise).
+	Block
+	// SafeSearch answers with a CNAME to Target and resolves the target.
+	SafeSearch
+)
+
+// Decision is the policy outcome for one query. Group is empty when no group matched the client.
+type Decision struct {
+	Action Action
+	Group  string
+	// Rule names what fired: "category:games", "schedule:bedtime category:games", "safe_search:google", "upstreams".
+	Rule string
+	// Target is the safe-search CNAME target (FQDN) when Action is SafeSearch.
+	Target string
+	// Upstreams replace dnsservers for this client when non-empty.
+	Upstreams []dnsservers.UpstreamEndpoint
+}
+
+// Matched reports whether a policy group applied to the client.
+func (d Decision) Matched() bool {
+	return d.Group != ""
+}
+
+// Engine is a compiled policy set. A nil *Engine matches no client.
+type Engine struct {
+	groups     []*group
+	categories map[string]*adblock.BlockList
+	loc        *time.Location
+	usesMAC    bool
+	now        func() time.Time
+}
+
+type group struct {
+	name       string
+	prefixes   []netip.Prefix
+	macs       map[string]bool
+	block      []string
+	safeSearch bool
+	schedules  []schedule
+	upstreams  []dnsservers.UpstreamEndpoint
+}
+
+// Compile builds an Engine from cfg, loading category files. Unknown categories, bad client entries,
+// schedules, or unreadable files are errors.
+func Compile(cfg config.PolicyConfig) (*Engine, error) {
+	e := &Engine{categories: map[string]*adblock.BlockList{}, loc: time.Local, now: time.Now}
+	if tz := strings.TrimSpace(cfg.Timezone); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("policy: timezone %q: %w", tz, err)
+		}
+		e.loc = loc
+	}
+	for name, c := range cfg.Categories {
+		bl := adblock.NewBlockList()
+		bl.AddDomains(c.Domains)
+		for _, f := range c.Files {
+			if err := adblock.LoadFromFile(bl, f); err != nil {
+				return nil, fmt.Errorf("policy: category %q: %w", name, err)
+			}
+		}
+		e.categories[strings.ToLower(strings.TrimSpace(name))] = bl
+	}
+	seen := map[string]bool{}
+	for i, gc := range cfg.Groups {
+		name := strings.TrimSpace(gc.Name)
+		if name == "" {
+			return nil, fmt.Errorf("policy: group %d has no name", i)
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("policy: duplicate group %q", name)
+		}
+		seen[strings.ToLower(name)] = true
+		g, err := e.compileGroup(name, gc)
+		if err != nil {
+			return nil, err
+		}
+		e.groups = append(e.groups, g)
+	}
+	return e, nil
+}
+
+func (e *Engine) compileGroup(name string, gc config.PolicyGroup) (*group, error) {
+	g := &group{name: name, safeSearch: gc.SafeSearch}
+	for _, raw := range gc.Clients {
+		c := strings.TrimSpace(raw)
+		if c == "" {
+			continue
+		}
+		if mac, err := net.ParseMAC(c); err == nil {
+			if g.macs == nil {
+				g.macs = map[string]bool{}
+			}
+			g.macs[mac.String()] = true
+			e.usesMAC = true
+			continue
+		}
+		p, err := parsePrefix(c)
+		if err != nil {
+			return nil, fmt.Errorf("policy: group %q: client %q is not an IP, CIDR, or MAC", name, c)
+		}
+		g.prefixes = append(g.prefixes, p)
+	}
+	var err error
+	if g.block, err = e.categoryNames(name, gc.BlockCategories); err != nil {
+		return nil, err
+	}
+	for _, sc := range gc.Schedules {
+		s, err := parseSchedule(sc)
+		if err != nil {
+			return nil, fmt.Errorf("policy: group %q: %w", name, err)
+		}
+		if s.categories, err = e.categoryNames(name, sc.BlockCategories); err != nil {
+			return nil, err
+		}
+		g.schedules = append(g.schedules, s)
+	}
+	for _, u := range gc.Upstreams {
+		if strings.TrimSpace(u.Address) == "" && strings.TrimSpace(u.DoHURL) == "" {
+			return nil, fmt.Errorf("policy: group %q: upstream without address", name)
+		}
+		g.upstreams = append(g.upstreams, dnsservers.ServerToEndpoint(dnsservers.DNSServer{
+			Address:   u.Address,
+			Port:      u.Port,
+			Transport: u.Transport,
+			DoHURL:    u.DoHURL,
+		}))
+	}
+	return g, nil
+}
+
+func (e *Engine) categoryNames(groupName string, names []string) ([]string, error) {
+	var out []string
+	for _, n := range names {
+		key := strings.ToLower(strings.TrimSpace(n))
+		if key == "" {
+			continue
+		}
+		if _, ok := e.categories[key]; !ok {
+			return nil, fmt.Errorf("policy: group %q: unknown category %q", groupName, n)
+		}
+		out = append(out, key)
+	}
+	return out, nil
+}
+
+// Enabled reports whether any group is configured.
+func (e *Engine) Enabled() bool {
+	return e != nil && len(e.groups) > 0
+}
+
+// UsesMAC reports whether any group lists MAC addresses (callers can skip MAC lookups otherwise).
+func (e *Engine) UsesMAC() bool {
+	return e != nil && e.usesMAC
+}
+
+// Evaluate returns the decision for qname from clientIP. macFor resolves the client's MAC address and is
+// only called when a MAC-based group is reached; it may be nil.
+func (e *Engine) Evaluate(clientIP string, macFor func(ip string) string, qname string) Decision {
+	g := e.match(clientIP, macFor)
+	if g == nil {
+		return Decision{}
+	}
+	d := Decision{Group: g.name}
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(qname)), ".")
+	for _, c := range g.block {
+		if e.categories[c].IsBlocked(domain) {
+			d.Action, d.Rule = Block, "category:"+c
+			return d
+		}
+	}
+	now := e.now().In(e.loc)
+	for _, s := range g.schedules {
+		if !s.active(now) {
+			continue
+		}
+		for _, c := range s.categories {
+			if e.categories[c].IsBlocked(domain) {
+				d.Action, d.Rule = Block, "schedule:"+s.label()+" category:"+c
+				return d
+			}
+		}
+	}
+	if g.safeSearch {
+		if target, provider := SafeSearchTarget(domain); target != "" {
+			d.Action, d.Rule, d.Target = SafeSearch, "safe_search:"+provider, target
+			return d
+		}
+	}
+	if len(g.upstreams) > 0 {
+		d.Rule = "upstreams"
+		d.Upstreams = g.upstreams
+	}
+	return d
+}
+
+// GroupFor returns the name of the group matching the client, or "".
+func (e *Engine) GroupFor(clientIP string, macFor func(ip string) string) string {
+	if g := e.match(clientIP, macFor); g != nil {
+		return g.name
+	}
+	return ""
+}
+
+func (e *Engine) match(clientIP string, macFor func(ip string) string) *group {
+	if !e.Enabled() {
+		return nil
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(clientIP))
+	if err == nil {
+		ip = ip.Unmap()
+	}
+	var mac string
+	macLooked := false
+	for _, g := range e.groups {
+		if ip.IsValid() {
+			for _, p := range g.prefixes {
+				if p.Contains(ip) {
+					return g
+				}
+			}
+		}
+		if len(g.macs) == 0 || macFor == nil {
+			continue
+		}
+		if !macLooked {
+			macLooked = true
+			if hw, err := net.ParseMAC(strings.TrimSpace(macFor(clientIP))); err == nil {
+				mac = hw.String()
+			}
+		}
+		if mac != "" && g.macs[mac] {
+			return g
+		}
+	}
+	return nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return p.Masked(), nil
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	ip = ip.Unmap()
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dnsplane/config"
+)
+
+func testEngine(t *testing.T) *Engine {
+	t.Helper()
+	dir := t.TempDir()
+	gamesFile := filepath.Join(dir, "games.txt")
+	if err := os.WriteFile(gamesFile, []byte("0.0.0.0 steampowered.com\n0.0.0.0 roblox.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := Compile(config.PolicyConfig{
+		Categories: map[string]config.PolicyCategory{
+			"adult": {Domains: []string{"adult.example"}},
+			"games": {Files: []string{gamesFile}},
+		},
+		Groups: []config.PolicyGroup{
+			{
+				Name:            "kids",
+				Clients:         []string{"192.168.1.50", "aa:bb:cc:dd:ee:ff"},
+				BlockCategories: []string{"adult"},
+				SafeSearch:      true,
+				Schedules: []config.PolicySchedule{
+					{Name: "bedtime", Days: []string{"weekdays"}, From: "22:00", To: "07:00", BlockCategories: []string{"games"}},
+				},
+			},
+			{
+				Name:      "office",
+				Clients:   []string{"10.0.0.0/8"},
+				Upstreams: []config.PolicyUpstream{{Address: "9.9.9.9"}},
+			},
+		},
+		Timezone: "UTC",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEvaluateGroups(t *testing.T) {
+	e := testEngine(t)
+	// Tuesday 23:30 UTC: bedtime is active.
+	e.now = func() time.Time { return time.Date(2026, 3, 3, 23, 30, 0, 0, time.UTC) }
+	macs := func(ip string) string {
+		if ip == "192.168.1.77" {
+			return "AA-BB-CC-DD-EE-FF"
+		}
+		return ""
+	}
+	tests := []struct {
+		ip, qname string
+		group     string
+		action    Action
+		rule      string
+	}{
+		{"192.168.1.50", "www.adult.example.", "kids", Block, "category:adult"},
+		{"192.168.1.77", "store.steampowered.com.", "kids", Block, "schedule:bedtime category:games"},
+		{"192.168.1.50", "www.google.co.uk.", "kids", SafeSearch, "safe_search:google"},
+		{"192.168.1.50", "www.youtube.com.", "kids", SafeSearch, "safe_search:youtube"},
+		{"192.168.1.50", "example.org.", "kids", Allow, ""},
+		{"10.1.2.3", "roblox.com.", "office", Allow, "upstreams"},
+		{"172.16.0.1", "www.adult.example.", "", Allow, ""},
+	}
+	for _, tt := range tests {
+		d := e.Evaluate(tt.ip, macs, tt.qname)
+		if d.Group != tt.group || d.Action != tt.action || d.Rule != tt.rule {
+			t.Errorf("Evaluate(%s, %s) = %+v, want group=%q action=%d rule=%q", tt.ip, tt.qname, d, tt.group, tt.action, tt.rule)
+		}
+	}
+	if d := e.Evaluate("10.1.2.3", nil, "x."); len(d.Upstreams) != 1 || d.Upstreams[0].Addr != "9.9.9.9:53" {
+		t.Fatalf("office upstreams = %+v", d.Upstreams)
+	}
+}
+
+func TestScheduleWindows(t *testing.T) {
+	e := testEngine(t)
+	cases := []struct {
+		at      time.Time
+		blocked bool
+	}{
+		{time.Date(2026, 3, 3, 21, 59, 0, 0, time.UTC), false}, // Tue before window
+		{time.Date(2026, 3, 4, 6, 59, 0, 0, time.UTC), true},   // Wed morning, window started Tue
+		{time.Date(2026, 3, 4, 7, 0, 0, 0, time.UTC), false},   // window end is exclusive
+		{time.Date(2026, 3, 7, 23, 0, 0, 0, time.UTC), false},  // Saturday night: weekdays only
+		{time.Date(2026, 3, 7, 1, 0, 0, 0, time.UTC), true},    // Saturday 01:00 continues Friday's window
+	}
+	for _, c := range cases {
+		e.now = func() time.Time { return c.at }
+		d := e.Evaluate("192.168.1.50", nil, "roblox.com.")
+		if (d.Action == Block) != c.blocked {
+			t.Errorf("%s: action=%d rule=%q, want blocked=%v", c.at.Format(time.RFC1123), d.Action, d.Rule, c.blocked)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	bad := []config.PolicyConfig{
+		{Groups: []config.PolicyGroup{{Name: "a", Clients: []string{"not-an-ip"}}}},
+		{Groups: []config.PolicyGroup{{Name: "a", BlockCategories: []string{"missing"}}}},
+		{Groups: []config.PolicyGroup{{Name: "a"}, {Name: "A"}}},
+		{Groups: []config.PolicyGroup{{Name: "a", Schedules: []config.PolicySchedule{{From: "25:00", To: "07:00"}}}}},
+		{Categories: map[string]config.PolicyCategory{"x": {Files: []string{"/nonexistent/list.txt"}}}},
+		{Timezone: "Not/AZone"},
+	}
+	for i, cfg := range bad {
+		if _, err := Compile(cfg); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestSafeSearchTarget(t *testing.T) {
+	for domain, want := range map[string]string{
+		"www.google.com":             googleSafe,
+		"google.com.br":              googleSafe,
+		"forcesafesearch.google.com": "",
+		"mail.google.com":            "",
+		"www.bing.com":               bingSafe,
+		"example.com":                "",
+	} {
+		if got, _ := SafeSearchTarget(domain); got != want {
+			t.Errorf("SafeSearchTarget(%q) = %q, want %q", domain, got, want)
+		}
+	}
+}
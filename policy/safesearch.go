@@ -0,0 +1,56 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package policy
+
+import "strings"
+
+// Safe-search endpoints published by each provider for network-level enforcement.
+const (
+	googleSafe     = "forcesafesearch.google.com."
+	bingSafe       = "strict.bing.com."
+	youtubeSafe    = "restrict.youtube.com."
+	duckduckgoSafe = "safe.duckduckgo.com."
+)
+
+var safeSearchHosts = map[string]struct{ target, provider string }{
+	"www.bing.com":             {bingSafe, "bing"},
+	"bing.com":                 {bingSafe, "bing"},
+	"www.youtube.com":          {youtubeSafe, "youtube"},
+	"m.youtube.com":            {youtubeSafe, "youtube"},
+	"youtubei.googleapis.com":  {youtubeSafe, "youtube"},
+	"youtube.googleapis.com":   {youtubeSafe, "youtube"},
+	"www.youtube-nocookie.com": {youtubeSafe, "youtube"},
+	"duckduckgo.com":           {duckduckgoSafe, "duckduckgo"},
+	"www.duckduckgo.com":       {duckduckgoSafe, "duckduckgo"},
+}
+
+// SafeSearchTarget returns the CNAME target and provider for a search host (lowercase, no trailing dot),
+// or "" when domain is not a known search front end. Google is matched on every country domain.
+func SafeSearchTarget(domain string) (target, provider string) {
+	if h, ok := safeSearchHosts[domain]; ok {
+		return h.target, h.provider
+	}
+	if isGoogleSearchHost(domain) {
+		return googleSafe, "google"
+	}
+	return "", ""
+}
+
+// isGoogleSearchHost matches google.<tld> and www.google.<tld> (e.g. www.google.co.uk).
+func isGoogleSearchHost(domain string) bool {
+	rest := strings.TrimPrefix(domain, "www.")
+	tld, ok := strings.CutPrefix(rest, "google.")
+	if !ok || tld == "" {
+		return false
+	}
+	labels := strings.Split(tld, ".")
+	if len(labels) > 2 {
+		return false
+	}
+	for _, l := range labels {
+		if l == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dnsplane/config"
+)
+
+type schedule struct {
+	name       string
+	days       [7]bool // indexed by time.Weekday
+	from, to   int     // minutes since midnight
+	categories []string
+}
+
+func parseSchedule(sc config.PolicySchedule) (schedule, error) {
+	s := schedule{name: strings.TrimSpace(sc.Name)}
+	var err error
+	if s.from, err = parseClock(sc.From); err != nil {
+		return s, fmt.Errorf("schedule %q: from: %w", sc.Name, err)
+	}
+	if s.to, err = parseClock(sc.To); err != nil {
+		return s, fmt.Errorf("schedule %q: to: %w", sc.Name, err)
+	}
+	if len(sc.Days) == 0 {
+		for i := range s.days {
+			s.days[i] = true
+		}
+		return s, nil
+	}
+	for _, d := range sc.Days {
+		switch strings.ToLower(strings.TrimSpace(d)) {
+		case "weekdays":
+			for i := time.Monday; i <= time.Friday; i++ {
+				s.days[i] = true
+			}
+		case "weekends":
+			s.days[time.Saturday], s.days[time.Sunday] = true, true
+		default:
+			wd, ok := weekdays[strings.ToLower(strings.TrimSpace(d))]
+			if !ok {
+				return s, fmt.Errorf("schedule %q: unknown day %q", sc.Name, d)
+			}
+			s.days[wd] = true
+		}
+	}
+	return s, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// parseClock parses "HH:MM" (00:00–24:00) into minutes since midnight.
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hh < 0 || mm < 0 || mm > 59 || hh > 24 || (hh == 24 && mm != 0) {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return hh*60 + mm, nil
+}
+
+// active reports whether t falls in the window. Days name the day a window starts, so a
+// Friday 22:00–07:00 window still applies at 01:00 on Saturday. from == to covers the whole day.
+func (s schedule) active(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	switch {
+	case s.from == s.to:
+		return s.days[today]
+	case s.from < s.to:
+		return s.days[today] && m >= s.from && m < s.to
+	default:
+		yesterday := (today + 6) % 7
+		return (s.days[today] && m >= s.from) || (s.days[yesterday] && m < s.to)
+	}
+}
+
+func (s schedule) label() string {
+	if s.name != "" {
+		return s.name
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", s.from/60, s.from%60, s.to/60, s.to%60)
+}
@@ -0,0 +1,177 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"cmp"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/data"
+	"dnsplane/safecast"
+)
+
+// maxAliasChainDepth bounds how many CNAME/DNAME hops are followed for one query.
+const maxAliasChainDepth = 8
+
+// localAlias returns the alias step for name from local records: a CNAME at name, or a DNAME at an ancestor
+// plus the CNAME it synthesizes (RFC 6672). target is "" when local data has no alias for name. geoLabel is
+// set when the CNAME was chosen by client location.
+func (r *Resolver) localAlias(ctx context.Context, name string) (step []dns.RR, target, geoLabel string) {
+	rrs, geoLabel := r.localLookup(ctx, name, "CNAME")
+	if len(rrs) > 0 {
+		if c, ok := rrs[0].(*dns.CNAME); ok {
+			return []dns.RR{c}, dns.Fqdn(c.Target), geoLabel
+		}
+	}
+	fq := dns.Fqdn(name)
+	labels := dns.SplitDomainName(fq)
+	for i := 1; i < len(labels); i++ {
+		owner := dns.Fqdn(strings.Join(labels[i:], "."))
+		rrs := r.store.LookupLocalRRs(owner, "DNAME", false)
+		if len(rrs) == 0 {
+			continue
+		}
+		d, ok := rrs[0].(*dns.DNAME)
+		if !ok {
+			continue
+		}
+		target := fq[:len(fq)-len(owner)] + dns.Fqdn(d.Target)
+		if _, ok := dns.IsDomainName(target); !ok {
+			return nil, "", ""
+		}
+		cname := &dns.CNAME{
+			Hdr:    dns.RR_Header{Name: fq, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: d.Hdr.Ttl},
+			Target: target,
+		}
+		return []dns.RR{d, cname}, target, ""
+	}
+	return nil, "", ""
+}
+
+// resolveLocalAlias answers question through a chain of local CNAME/DNAME records. Hops are followed
+// through local data first; where the chain leaves local data the target is resolved like any other query
+// (cache, then upstream), and an upstream answer that ends in a CNAME back into local data is followed too.
+// Composite answers that involved upstream data are cached as one RRset. Loops and chains longer than
+// maxAliasChainDepth get SERVFAIL. Returns false when question.Name has no local alias.
+func (r *Resolver) resolveLocalAlias(ctx context.Context, question dns.Question, response *dns.Msg, t0 time.Time, skipCache bool) bool {
+	recordType := recordTypeString(question.Qtype)
+	name := dns.Fqdn(question.Name)
+	seen := map[string]bool{}
+	var chain []dns.RR
+	rcode := dns.RcodeSuccess
+	outcome, upstream := "local", ""
+	viaUpstream := false
+	geoLabel := ""
+	for depth := 0; ; depth++ {
+		key := strings.ToLower(name)
+		if seen[key] || depth > maxAliasChainDepth {
+			r.failAliasChain(ctx, question, response, seen[key], t0)
+			return true
+		}
+		seen[key] = true
+		if depth > 0 {
+			if final, label := r.localLookup(ctx, name, recordType); len(final) > 0 {
+				chain = append(chain, final...)
+				geoLabel = cmp.Or(geoLabel, label)
+				break
+			}
+		}
+		if step, target, label := r.localAlias(ctx, name); target != "" {
+			chain = append(chain, step...)
+			geoLabel = cmp.Or(geoLabel, label)
+			name, viaUpstream = target, false
+			continue
+		}
+		if depth == 0 {
+			return false
+		}
+		// The chain leaves local data here. Local-only clients get the partial chain, like an
+		// authoritative server would send.
+		if viaUpstream || NoRecursionFromContext(ctx) {
+			break
+		}
+		sub := new(dns.Msg)
+		sink := &observeSink{outcome: "local"}
+		inner := dns.Question{Name: name, Qtype: question.Qtype, Qclass: question.Qclass}
+		r.resolveFastPath(context.WithValue(ctx, observeSinkCtxKey{}, sink), inner, sub)
+		chain = append(chain, sub.Answer...)
+		rcode = sub.Rcode
+		if sink.outcome != "local" {
+			outcome, upstream = sink.outcome, sink.upstream
+		}
+		geoLabel = cmp.Or(geoLabel, sink.geo)
+		next := danglingAliasTarget(sub.Answer, name, question.Qtype)
+		if next == "" {
+			break
+		}
+		name, viaUpstream = next, true
+	}
+
+	response.Answer = append(response.Answer, chain...)
+	response.Rcode = rcode
+	if geoLabel != "" {
+		// Other clients may get a different chain, so it is neither cached as a whole nor scoped wider
+		// than the client subnet.
+		ctx = withGeoNote(ctx, geoLabel)
+		echoClientSubnet(ctx, response)
+	}
+	summary := "no answer"
+	if len(chain) > 0 {
+		summary = rrOneLine(chain[0])
+	}
+	if outcome == "local" {
+		response.Authoritative = true
+		r.log("Query: %s, Reply: %d record(s), Method: dnsrecords.json (alias chain)\n", question.Name, len(chain))
+		prep := safecast.DurationToUint64(time.Since(t0))
+		r.recordResolve(data.PerfOutcomeLocal, prep, prep, 0, 0, 0, perfQTypeString(question))
+	} else if rcode == dns.RcodeSuccess && !skipCache && geoLabel == "" && shouldCacheRRSetForQuestion(question, chain) {
+		cacheSyntheticRRSetAnswer(r.store, question, chain)
+	}
+	r.observeQuery(ctx, question, outcome, upstream, summary, t0)
+	return true
+}
+
+// failAliasChain answers SERVFAIL for a chain that loops or runs past maxAliasChainDepth.
+func (r *Resolver) failAliasChain(ctx context.Context, question dns.Question, response *dns.Msg, loop bool, t0 time.Time) {
+	text := "CNAME chain too long"
+	if loop {
+		text = "CNAME loop"
+	}
+	response.Answer = nil
+	response.Rcode = dns.RcodeServerFailure
+	SetExtendedError(RequestFromContext(ctx), response, dns.ExtendedErrorCodeOther, text)
+	r.log("Query: %s, %s\n", question.Name, text)
+	r.observeQuery(ctx, question, "none", "", text, t0)
+}
+
+// danglingAliasTarget returns the final CNAME target in answer when the chain starting at name has no
+// record of qtype at its end, or "".
+func danglingAliasTarget(answer []dns.RR, name string, qtype uint16) string {
+	cur := strings.ToLower(dns.Fqdn(name))
+	for hop := 0; hop <= len(answer); hop++ {
+		next := ""
+		for _, rr := range answer {
+			if !strings.EqualFold(rr.Header().Name, cur) {
+				continue
+			}
+			if rr.Header().Rrtype == qtype {
+				return ""
+			}
+			if c, ok := rr.(*dns.CNAME); ok {
+				next = strings.ToLower(dns.Fqdn(c.Target))
+			}
+		}
+		if next == "" {
+			if hop == 0 {
+				return ""
+			}
+			return cur
+		}
+		cur = next
+	}
+	return ""
+}
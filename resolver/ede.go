@@ -0,0 +1,19 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import "github.com/miekg/dns"
+
+// SetExtendedError adds an RFC 8914 Extended DNS Error option to response when the request used EDNS.
+// Clients without EDNS get the plain rcode only (EDE requires an OPT record).
+func SetExtendedError(req, response *dns.Msg, code uint16, text string) {
+	if req == nil || response == nil || req.IsEdns0() == nil {
+		return
+	}
+	opt := response.IsEdns0()
+	if opt == nil {
+		response.SetEdns0(req.IsEdns0().UDPSize(), false)
+		opt = response.IsEdns0()
+	}
+	opt.Option = append(opt.Option, &dns.EDNS0_EDE{InfoCode: code, ExtraText: text})
+}
@@ -0,0 +1,221 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/data"
+	"dnsplane/safecast"
+)
+
+// flattenNegativeTTL caps how long an ALIAS whose target has no addresses is remembered.
+const flattenNegativeTTL = 30
+
+// flattenSweepInterval is how often put drops expired entries, so owners whose ALIAS was deleted or
+// retargeted do not stay in memory.
+const flattenSweepInterval = time.Minute
+
+// flattenEntry is the flattened A or AAAA RRset of one ALIAS owner.
+type flattenEntry struct {
+	target     string
+	rrs        []dns.RR
+	ttl        uint32
+	expires    time.Time
+	refreshing bool
+}
+
+// flattenTable keeps flattened ALIAS answers keyed by owner and qtype.
+type flattenTable struct {
+	mu      sync.Mutex
+	entries map[string]*flattenEntry
+	swept   time.Time
+}
+
+func flattenKey(owner string, qtype uint16) string {
+	return strings.ToLower(dns.Fqdn(owner)) + "|" + dns.TypeToString[qtype]
+}
+
+// get returns the entry's RRs with the remaining TTL. refresh is true once, when less than a quarter of
+// the TTL is left, so the caller can re-resolve in the background before the entry expires.
+func (t *flattenTable) get(key, target string, now time.Time) (rrs []dns.RR, refresh, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.entries[key]
+	if e == nil {
+		return nil, false, false
+	}
+	if e.target != target || !now.Before(e.expires) {
+		delete(t.entries, key)
+		return nil, false, false
+	}
+	remaining := safecast.IntToUint32Clamp(int(e.expires.Sub(now) / time.Second))
+	if remaining == 0 {
+		remaining = 1
+	}
+	if !e.refreshing && remaining*4 < e.ttl {
+		e.refreshing = true
+		refresh = true
+	}
+	return withTTL(e.rrs, remaining), refresh, true
+}
+
+func (t *flattenTable) put(key, target string, rrs []dns.RR, ttl uint32, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.entries == nil {
+		t.entries = make(map[string]*flattenEntry)
+	}
+	if now.Sub(t.swept) >= flattenSweepInterval {
+		for k, e := range t.entries {
+			if !now.Before(e.expires) {
+				delete(t.entries, k)
+			}
+		}
+		t.swept = now
+	}
+	t.entries[key] = &flattenEntry{
+		target:  target,
+		rrs:     rrs,
+		ttl:     ttl,
+		expires: now.Add(time.Duration(ttl) * time.Second),
+	}
+}
+
+func withTTL(rrs []dns.RR, ttl uint32) []dns.RR {
+	out := make([]dns.RR, len(rrs))
+	for i, rr := range rrs {
+		out[i] = dns.Copy(rr)
+		out[i].Header().Ttl = ttl
+	}
+	return out
+}
+
+// resolveFlattened answers an A/AAAA question at an ALIAS/ANAME owner with the target's addresses under the
+// owner name. Returns false when the name has no ALIAS record.
+func (r *Resolver) resolveFlattened(ctx context.Context, question dns.Question, response *dns.Msg, t0 time.Time, skipCache bool) bool {
+	if r.aliasTarget == nil {
+		return false
+	}
+	target, ttl, ok := r.aliasTarget(question.Name)
+	if !ok {
+		return false
+	}
+	rrs, outcome, upstream := r.flatten(ctx, question, target, ttl, skipCache)
+	response.Answer = append(response.Answer, rrs...)
+	response.Rcode = dns.RcodeSuccess
+	response.Authoritative = true
+	summary := "no answer (ALIAS " + target + ")"
+	if len(rrs) > 0 {
+		summary = rrOneLine(rrs[0])
+	}
+	r.log("Query: %s, Reply: %d record(s), Method: ALIAS %s\n", question.Name, len(rrs), target)
+	if outcome == "local" {
+		prep := safecast.DurationToUint64(time.Since(t0))
+		r.recordResolve(data.PerfOutcomeLocal, prep, prep, 0, 0, 0, perfQTypeString(question))
+	}
+	r.observeQuery(ctx, question, outcome, upstream, summary, t0)
+	return true
+}
+
+// FlattenALIAS returns the current A and AAAA records for an ALIAS/ANAME owner (for zone transfers),
+// resolving the target when the flattened answer is missing or expired.
+func (r *Resolver) FlattenALIAS(ctx context.Context, owner string) []dns.RR {
+	if r == nil || r.aliasTarget == nil {
+		return nil
+	}
+	target, ttl, ok := r.aliasTarget(owner)
+	if !ok {
+		return nil
+	}
+	var out []dns.RR
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		q := dns.Question{Name: dns.Fqdn(owner), Qtype: qtype, Qclass: dns.ClassINET}
+		rrs, _, _ := r.flatten(ctx, q, target, ttl, false)
+		out = append(out, rrs...)
+	}
+	return out
+}
+
+// flatten serves the owner's RRset from the table, or resolves target when the entry is missing, expired, or
+// was built for an older target. Nothing is stored for policy groups with their own upstreams or for
+// local-only clients, which get an empty answer on a miss. A lookup that failed (no upstream answered, or
+// SERVFAIL) is not stored either: a background refresh keeps the entry it had, and a miss is retried by
+// the next query.
+func (r *Resolver) flatten(ctx context.Context, question dns.Question, target string, ttl uint32, skipCache bool) ([]dns.RR, string, string) {
+	key := flattenKey(question.Name, question.Qtype)
+	if !skipCache {
+		if rrs, refresh, ok := r.flattened.get(key, target, time.Now()); ok {
+			if refresh {
+				go func() {
+					rrs, minTTL, _, _, ok := r.resolveALIASTarget(context.WithoutCancel(ctx), question, target)
+					if ok {
+						r.flattened.put(key, target, rrs, flattenTTL(ttl, minTTL), time.Now())
+					}
+				}()
+			}
+			return rrs, "local", ""
+		}
+	}
+	if NoRecursionFromContext(ctx) {
+		return nil, "local", ""
+	}
+	rrs, minTTL, outcome, upstream, ok := r.resolveALIASTarget(ctx, question, target)
+	ttl = flattenTTL(ttl, minTTL)
+	if !skipCache && ok {
+		r.flattened.put(key, target, rrs, ttl, time.Now())
+	}
+	return withTTL(rrs, ttl), outcome, upstream
+}
+
+type flattenDepthCtxKey struct{}
+
+// resolveALIASTarget resolves target like any other query and renames its address records to the owner.
+// minTTL is the lowest TTL in the target's answer, or flattenNegativeTTL when there are no addresses.
+// ALIAS targets that lead back to ALIAS owners stop after maxAliasChainDepth nested lookups. definitive is
+// false when the lookup failed rather than found no addresses: nothing answered, or the answer was an error
+// other than NXDOMAIN.
+func (r *Resolver) resolveALIASTarget(ctx context.Context, question dns.Question, target string) (rrs []dns.RR, minTTL uint32, outcome, upstream string, definitive bool) {
+	depth, _ := ctx.Value(flattenDepthCtxKey{}).(int)
+	if depth >= maxAliasChainDepth {
+		return nil, flattenNegativeTTL, "none", "", true
+	}
+	ctx = context.WithValue(ctx, flattenDepthCtxKey{}, depth+1)
+	sub := new(dns.Msg)
+	sink := &observeSink{outcome: "local"}
+	inner := dns.Question{Name: target, Qtype: question.Qtype, Qclass: question.Qclass}
+	r.resolveFastPath(context.WithValue(ctx, observeSinkCtxKey{}, sink), inner, sub)
+	owner := dns.Fqdn(question.Name)
+	for _, rr := range sub.Answer {
+		if t := rr.Header().Ttl; minTTL == 0 || t < minTTL {
+			minTTL = t
+		}
+		if rr.Header().Rrtype != question.Qtype {
+			continue
+		}
+		cp := dns.Copy(rr)
+		cp.Header().Name = owner
+		rrs = append(rrs, cp)
+	}
+	if len(rrs) == 0 {
+		minTTL = flattenNegativeTTL
+	}
+	definitive = len(rrs) > 0 || (sink.outcome != "none" && (sub.Rcode == dns.RcodeSuccess || sub.Rcode == dns.RcodeNameError))
+	return rrs, minTTL, sink.outcome, sink.upstream, definitive
+}
+
+// flattenTTL is the lower of the ALIAS record TTL and the target TTL (a zero record TTL defers to the target).
+func flattenTTL(recordTTL, targetTTL uint32) uint32 {
+	if recordTTL == 0 || (targetTTL > 0 && targetTTL < recordTTL) {
+		recordTTL = targetTTL
+	}
+	if recordTTL == 0 {
+		recordTTL = 1
+	}
+	return recordTTL
+}
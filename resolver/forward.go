@@ -0,0 +1,46 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/dnsservers"
+	"dnsplane/forwardzone"
+)
+
+// forwardZone returns the forward zone covering name, or nil.
+func (r *Resolver) forwardZone(name string) *forwardzone.Zone {
+	if r.forwardZones == nil {
+		return nil
+	}
+	return r.forwardZones().Match(name)
+}
+
+// raceUpstreams queries servers in parallel and returns the first successful answer, or nil when all fail.
+// Used for the second round of forward-first zones.
+func (r *Resolver) raceUpstreams(ctx context.Context, question dns.Question, servers []dnsservers.UpstreamEndpoint) *upstreamResult {
+	if len(servers) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.upstreamTimeout)
+	defer cancel()
+	ch := make(chan *upstreamResult, len(servers))
+	tr := traceFromContext(ctx)
+	for _, srv := range servers {
+		tr.upstreamStart()
+		go func() {
+			resp, err := r.queryUpstream(ctx, question, srv)
+			ch <- &upstreamResult{endpoint: srv, msg: resp, err: err}
+		}()
+	}
+	for range servers {
+		up := <-ch
+		if up.err == nil && up.msg != nil && up.msg.Rcode == dns.RcodeSuccess && len(up.msg.Answer) > 0 {
+			return up
+		}
+	}
+	return nil
+}
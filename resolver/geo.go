@@ -0,0 +1,114 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"context"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/data"
+	"dnsplane/safecast"
+)
+
+// clientSubnet returns the EDNS Client Subnet option of the request, if it carries one with a source prefix.
+func clientSubnet(ctx context.Context) *dns.EDNS0_SUBNET {
+	req := RequestFromContext(ctx)
+	if req == nil {
+		return nil
+	}
+	opt := req.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+	for _, o := range opt.Option {
+		if s, ok := o.(*dns.EDNS0_SUBNET); ok && s.SourceNetmask > 0 && s.Address != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// geoLocalRRs returns the geo-selected local values of name/type for the client (the ECS subnet address when
+// present, else the client IP). label is "" when the name/type has no geo selectors.
+func (r *Resolver) geoLocalRRs(ctx context.Context, name, recordType string) (rrs []dns.RR, label string) {
+	if r.geoLookup == nil {
+		return nil, ""
+	}
+	client := ClientIPFromContext(ctx)
+	if s := clientSubnet(ctx); s != nil {
+		client = s.Address.String()
+	}
+	rrs, label, ok := r.geoLookup(name, recordType, client)
+	if !ok {
+		return nil, ""
+	}
+	return rrs, label
+}
+
+// localLookup returns local RRs for name/type, geo-selected for the client when the name/type has geo
+// selectors (label is then non-empty).
+func (r *Resolver) localLookup(ctx context.Context, name, recordType string) ([]dns.RR, string) {
+	rrs, label := r.geoLocalRRs(ctx, name, recordType)
+	if len(rrs) > 0 {
+		return rrs, label
+	}
+	return r.store.LookupLocalRRs(name, recordType, false), label
+}
+
+// withGeoNote records the geo choice for the QueryObserver.
+func withGeoNote(ctx context.Context, label string) context.Context {
+	notes := QueryNotesFromContext(ctx)
+	notes.Geo = label
+	return ContextWithQueryNotes(ctx, notes)
+}
+
+// echoClientSubnet returns the request's ECS option with the scope set to the source prefix, so caches in
+// front of dnsplane keep the geo answer to that subnet (RFC 7871 section 7.2.1).
+func echoClientSubnet(ctx context.Context, response *dns.Msg) {
+	s := clientSubnet(ctx)
+	if s == nil {
+		return
+	}
+	opt := response.IsEdns0()
+	if opt == nil {
+		response.SetEdns0(RequestFromContext(ctx).IsEdns0().UDPSize(), false)
+		opt = response.IsEdns0()
+	}
+	for _, o := range opt.Option {
+		if _, ok := o.(*dns.EDNS0_SUBNET); ok {
+			return
+		}
+	}
+	opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        s.Family,
+		SourceNetmask: s.SourceNetmask,
+		SourceScope:   s.SourceNetmask,
+		Address:       s.Address,
+	})
+}
+
+// resolveGeo answers question from local values chosen by client location. Returns false when the
+// name/type has no geo selectors or health checks withheld every chosen value.
+func (r *Resolver) resolveGeo(ctx context.Context, question dns.Question, response *dns.Msg, t0 time.Time) bool {
+	rrs, label := r.geoLocalRRs(ctx, question.Name, recordTypeString(question.Qtype))
+	if len(rrs) == 0 {
+		return false
+	}
+	ctx = withGeoNote(ctx, label)
+	traceFromContext(ctx).step(question.Name, "geo", "hit", label)
+	response.Answer = append(response.Answer, rrs...)
+	if r.dnssecSigner != nil {
+		r.dnssecSigner.SignLocalAnswerIfDO(RequestFromContext(ctx), question, rrs, response)
+		traceSigned(ctx, response)
+	}
+	response.Authoritative = true
+	echoClientSubnet(ctx, response)
+	r.log("Query: %s, Reply: %d record(s), Method: dnsrecords.json (geo %s)\n", question.Name, len(rrs), label)
+	prep := safecast.DurationToUint64(time.Since(t0))
+	r.recordResolve(data.PerfOutcomeLocal, prep, prep, 0, 0, 0, perfQTypeString(question))
+	r.observeQuery(ctx, question, "local", "", rrOneLine(rrs[0]), t0)
+	return true
+}
@@ -0,0 +1,36 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"github.com/miekg/dns"
+
+	"dnsplane/dnsservers"
+)
+
+// emptyZone returns the built-in empty zone answering name, or "". A zone is skipped when a forward zone or
+// whitelisted server covers name, or when local records exist anywhere in the zone (precomputed by the
+// LocalZones set, see localzone.Set.WithLocalData).
+func (r *Resolver) emptyZone(name string) string {
+	if r.localZones == nil {
+		return ""
+	}
+	zones := r.localZones()
+	zone := zones.Match(name)
+	if zone == "" || zones.HasData(zone) || r.forwardZone(name) != nil {
+		return ""
+	}
+	for _, s := range r.store.GetServers() {
+		if s.Active && dnsservers.ServerMatchesQuery(s, name) {
+			return ""
+		}
+	}
+	return zone
+}
+
+func emptyZoneSummary(response *dns.Msg, zone string) string {
+	if len(response.Answer) > 0 {
+		return rrOneLine(response.Answer[0])
+	}
+	return dns.RcodeToString[response.Rcode] + " (empty zone " + zone + ")"
+}
@@ -0,0 +1,54 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"context"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/policy"
+)
+
+// safeSearchTTL is the TTL of the synthesized safe-search CNAME.
+const safeSearchTTL = 300
+
+// evaluatePolicy runs the per-client policy engine for question (zero Decision when no group matches).
+func (r *Resolver) evaluatePolicy(ctx context.Context, question dns.Question) policy.Decision {
+	if r.policy == nil {
+		return policy.Decision{}
+	}
+	e := r.policy()
+	if !e.Enabled() {
+		return policy.Decision{}
+	}
+	var macFor func(string) string
+	if e.UsesMAC() {
+		macFor = r.clientMAC
+	}
+	return e.Evaluate(ClientIPFromContext(ctx), macFor, question.Name)
+}
+
+// resolveSafeSearch answers with a CNAME to the provider's safe-search host and appends the target's
+// answers, resolved like any other query from this client.
+func (r *Resolver) resolveSafeSearch(ctx context.Context, question dns.Question, target string, response *dns.Msg, t0 time.Time) {
+	cname := &dns.CNAME{
+		Hdr:    dns.RR_Header{Name: question.Name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: safeSearchTTL},
+		Target: target,
+	}
+	response.Answer = append(response.Answer, cname)
+	response.Rcode = dns.RcodeSuccess
+	sink := &observeSink{outcome: "local"}
+	if question.Qtype != dns.TypeCNAME {
+		sub := new(dns.Msg)
+		inner := dns.Question{Name: target, Qtype: question.Qtype, Qclass: question.Qclass}
+		r.resolveFastPath(context.WithValue(ctx, observeSinkCtxKey{}, sink), inner, sub)
+		response.Answer = append(response.Answer, sub.Answer...)
+		if sub.Rcode != dns.RcodeSuccess {
+			response.Rcode = sub.Rcode
+		}
+	}
+	r.log("Query: %s, Safe search: CNAME %s\n", question.Name, target)
+	r.observeQuery(ctx, question, sink.outcome, sink.upstream, rrOneLine(cname), t0)
+}
@@ -42,3 +42,79 @@ func ClientIPFromContext(ctx context.Context) string {
 	v, _ := ctx.Value(clientIPCtxKey{}).(string)
 	return v
 }
+
+type noRecursionCtxKey struct{}
+type queryNotesCtxKey struct{}
+
+// ContextWithNoRecursion marks the request as local-only (client not in allow_recursion): local records
+// are answered, but cache and upstream forwarding are refused.
+func ContextWithNoRecursion(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRecursionCtxKey{}, true)
+}
+
+// NoRecursionFromContext reports whether ContextWithNoRecursion was applied.
+func NoRecursionFromContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(noRecursionCtxKey{}).(bool)
+	return v
+}
+
+// QueryNotes carries per-request policy annotations passed through to the QueryObserver (dashboard, logs).
+type QueryNotes struct {
+	// ACL is the client ACL decision (e.g. "allow", "local_only (allow_recursion:nomatch)").
+	ACL string
+	// PolicyGroup and PolicyRule are the matching policy group and the rule that fired (e.g. "category:games").
+	PolicyGroup string
+	PolicyRule  string
+	// Geo describes the geo choice for local values (e.g. "region eu", "country DE", "default").
+	Geo string
+}
+
+// ContextWithQueryNotes attaches notes for observeQuery; later calls replace earlier notes.
+func ContextWithQueryNotes(ctx context.Context, notes QueryNotes) context.Context {
+	return context.WithValue(ctx, queryNotesCtxKey{}, notes)
+}
+
+// QueryNotesFromContext returns notes set by ContextWithQueryNotes (zero value if none).
+func QueryNotesFromContext(ctx context.Context) QueryNotes {
+	if ctx == nil {
+		return QueryNotes{}
+	}
+	v, _ := ctx.Value(queryNotesCtxKey{}).(QueryNotes)
+	return v
+}
+
+type cacheBypassCtxKey struct{}
+type observeSinkCtxKey struct{}
+
+// contextWithCacheBypass keeps the shared cache out of this request (policy groups with their own upstreams
+// may get different answers than everyone else).
+func contextWithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassCtxKey{}, true)
+}
+
+func cacheBypassFromContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(cacheBypassCtxKey{}).(bool)
+	return v
+}
+
+// observeSink captures the outcome of an inner resolve (e.g. a safe-search target) instead of reporting
+// it to the QueryObserver, so the client's original question is observed once.
+type observeSink struct {
+	outcome  string
+	upstream string
+	geo      string
+}
+
+func observeSinkFromContext(ctx context.Context) *observeSink {
+	if ctx == nil {
+		return nil
+	}
+	v, _ := ctx.Value(observeSinkCtxKey{}).(*observeSink)
+	return v
+}
@@ -21,7 +21,12 @@ import (
 	"dnsplane/dnssecsign"
 	"dnsplane/dnssecvalidate"
 	"dnsplane/dnsservers"
+	"dnsplane/events"
+	"dnsplane/forwardzone"
+	"dnsplane/localzone"
+	"dnsplane/policy"
 	"dnsplane/safecast"
+	"dnsplane/tracing"
 )
 
 // ErrorLogger logs errors (e.g. conversion failures). Optional.
@@ -62,8 +67,9 @@ type QueryLogger func(format string, args ...interface{})
 // QueryObserver receives structured fields after each fast-path resolve (optional; for slog/metrics).
 // outcome is one of: local, cache, upstream, none, blocked. upstream is address:port when outcome is upstream.
 // recordSummary is a short text for the first answer (or "no answer", blocked reply, etc.).
-// clientIP is from ContextWithClientIP (empty if not set).
-type QueryObserver func(qname, qtype, outcome, upstream, recordSummary string, elapsed time.Duration, clientIP string)
+// clientIP is from ContextWithClientIP (empty if not set); notes from ContextWithQueryNotes.
+// outcome is "refused" when a local-only client (see ContextWithNoRecursion) asks for a non-local name.
+type QueryObserver func(qname, qtype, outcome, upstream, recordSummary string, elapsed time.Duration, clientIP string, notes QueryNotes)
 
 // Config defines the resolver dependencies.
 type Config struct {
@@ -75,6 +81,19 @@ type Config struct {
 	UpstreamTimeout time.Duration
 	// DNSSECSigner when non-nil signs local authoritative answers when the client sets DO (EDNS).
 	DNSSECSigner *dnssecsign.Signer
+	// Policy returns the per-client policy engine (nil func or nil engine = no policies).
+	Policy func() *policy.Engine
+	// ClientMAC resolves a client IP to a MAC address for MAC-based policy groups (optional).
+	ClientMAC func(ip string) string
+	// ForwardZones returns the conditional forwarding table (nil func or nil table = none).
+	ForwardZones func() *forwardzone.Table
+	// LocalZones returns the built-in empty zones (nil func or nil set = none).
+	LocalZones func() *localzone.Set
+	// ALIASTarget returns the ALIAS/ANAME target and record TTL for an owner name (optional).
+	ALIASTarget func(name string) (target string, ttl uint32, ok bool)
+	// GeoLookup returns the local values of name/type chosen for clientIP when the name/type has geo
+	// selectors (ok=false otherwise), with a label describing the choice (optional).
+	GeoLookup func(name, recordType, clientIP string) (rrs []dns.RR, label string, ok bool)
 }
 
 // Resolver answers DNS questions using local records, cache, and upstream servers.
@@ -86,6 +105,15 @@ type Resolver struct {
 	queryObserver   QueryObserver
 	upstreamTimeout time.Duration
 	dnssecSigner    *dnssecsign.Signer
+	policy          func() *policy.Engine
+	clientMAC       func(ip string) string
+	forwardZones    func() *forwardzone.Table
+	localZones      func() *localzone.Set
+	aliasTarget     func(name string) (string, uint32, bool)
+	geoLookup       func(name, recordType, clientIP string) ([]dns.RR, string, bool)
+	flattened       flattenTable
+	// quiet resolvers (TraceQuery) leave the performance and DNSSEC counters alone.
+	quiet bool
 }
 
 // New constructs a Resolver using the provided configuration.
@@ -102,6 +130,12 @@ func New(cfg Config) *Resolver {
 		queryObserver:   cfg.QueryObserver,
 		upstreamTimeout: timeout,
 		dnssecSigner:    cfg.DNSSECSigner,
+		policy:          cfg.Policy,
+		clientMAC:       cfg.ClientMAC,
+		forwardZones:    cfg.ForwardZones,
+		localZones:      cfg.LocalZones,
+		aliasTarget:     cfg.ALIASTarget,
+		geoLookup:       cfg.GeoLookup,
 	}
 }
 
@@ -149,49 +183,89 @@ func perfQTypeString(q dns.Question) string {
 	return "T" + strconv.Itoa(int(q.Qtype))
 }
 
+// recordTypeString returns the type mnemonic, or the RFC 3597 form (TYPE65534) that local records use for
+// types without one.
+func recordTypeString(qtype uint16) string {
+	if s := dns.TypeToString[qtype]; s != "" {
+		return s
+	}
+	return "TYPE" + strconv.Itoa(int(qtype))
+}
+
 // resolveFastPath runs local, cache, and upstreams in parallel. Priority: local > cache > first upstream success.
 //
 // A/AAAA: local/cache before adblock so cache hits skip blocklist work. A positive cache entry for a blocked
 // name is still served until TTL expires.
 func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, response *dns.Msg) {
 	t0 := time.Now()
-	recordType := dns.TypeToString[question.Qtype]
-	if recordType == "" {
-		recordType = perfQTypeString(question)
-	}
+	recordType := recordTypeString(question.Qtype)
 	qtypeKey := perfQTypeString(question)
 	isPTR := question.Qtype == dns.TypePTR
+	noRecursion := NoRecursionFromContext(ctx)
+	tr := traceFromContext(ctx)
+
+	var policyUpstreams []dnsservers.UpstreamEndpoint
+	if d := r.evaluatePolicy(ctx, question); d.Matched() {
+		notes := QueryNotesFromContext(ctx)
+		notes.PolicyGroup, notes.PolicyRule = d.Group, d.Rule
+		ctx = ContextWithQueryNotes(ctx, notes)
+		tr.step(question.Name, "policy", policyActionString(d), "group "+d.Group+" rule "+d.Rule)
+		switch d.Action {
+		case policy.Block:
+			r.processBlockedDomain(question, response, "policy "+d.Group)
+			r.observeQuery(ctx, question, "blocked", "", msgAnswerSummary(response), t0)
+			return
+		case policy.SafeSearch:
+			r.resolveSafeSearch(ctx, question, d.Target, response, t0)
+			return
+		}
+		if len(d.Upstreams) > 0 {
+			policyUpstreams = d.Upstreams
+			ctx = contextWithCacheBypass(ctx)
+		}
+	}
+	skipCache := noRecursion || cacheBypassFromContext(ctx)
+
+	// Geo-selected local values depend on the client, so they go before the shared local/cache lookup.
+	if !isPTR && r.resolveGeo(ctx, question, response, t0) {
+		return
+	}
 
 	// Local/cache first without loading settings — one RLock (TryFastLocalOrCache) instead of
 	// GetResolverSettings + TryFastLocalOrCache; matches the old dedicated A/cache hot path.
 	if !isPTR {
+		_, lookup := tracing.Start(ctx, "dns.lookup", tracing.KindInternal)
 		handled, loc, crr, crs, isStale := r.store.TryFastLocalOrCache(question.Name, recordType, false)
+		tr.fastLookup(question.Name, loc, crr, crs, isStale, skipCache)
+		endLookupSpan(lookup, loc, crr, crs, isStale, skipCache)
 		if handled {
 			if len(loc) > 0 {
 				r.processCachedRecords(ctx, question, loc, response)
 				prep := safecast.DurationToUint64(time.Since(t0))
-				data.RecordResolverAResolve(data.PerfOutcomeLocal, prep, prep, 0, 0, 0, qtypeKey)
+				r.recordResolve(data.PerfOutcomeLocal, prep, prep, 0, 0, 0, qtypeKey)
 				r.observeQuery(ctx, question, "local", "", rrOneLine(loc[0]), t0)
 				return
 			}
-			if len(crs) > 0 {
+			// Cached answers came from upstream; local-only clients must not see them, and policy
+			// groups with their own upstreams must not share them.
+			if len(crs) > 0 && !skipCache {
 				r.store.IncrementCacheHits()
 				r.processCachedUpstreamRRs(question, crs, response)
 				prep := safecast.DurationToUint64(time.Since(t0))
-				data.RecordResolverAResolve(data.PerfOutcomeCache, prep, prep, 0, 0, 0, qtypeKey)
+				r.recordResolve(data.PerfOutcomeCache, prep, prep, 0, 0, 0, qtypeKey)
 				r.observeQuery(ctx, question, "cache", "", rrOneLine(crs[0]), t0)
-				if isStale {
+				if isStale && !r.quiet {
 					go r.backgroundRefresh(context.WithoutCancel(ctx), question)
 				}
 				return
 			}
-			if crr != nil {
+			if crr != nil && !skipCache {
 				r.store.IncrementCacheHits()
 				r.processCacheRecord(question, crr, response)
 				prep := safecast.DurationToUint64(time.Since(t0))
-				data.RecordResolverAResolve(data.PerfOutcomeCache, prep, prep, 0, 0, 0, qtypeKey)
+				r.recordResolve(data.PerfOutcomeCache, prep, prep, 0, 0, 0, qtypeKey)
 				r.observeQuery(ctx, question, "cache", "", rrOneLine(*crr), t0)
-				if isStale {
+				if isStale && !r.quiet {
 					go r.backgroundRefresh(context.WithoutCancel(ctx), question)
 				}
 				return
@@ -199,60 +273,90 @@ func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, r
 		}
 	}
 
+	// ALIAS/ANAME owners: A/AAAA synthesized from the target's addresses.
+	if (question.Qtype == dns.TypeA || question.Qtype == dns.TypeAAAA) && r.resolveFlattened(ctx, question, response, t0, skipCache) {
+		tr.step(question.Name, "alias", "hit", "ALIAS/ANAME")
+		return
+	}
+
+	// Local CNAME/DNAME chains: the exact qtype missed above, so follow the alias through local data and
+	// resolve the target (cache, upstream) where the chain leaves it.
+	if !isPTR && question.Qtype != dns.TypeCNAME && question.Qtype != dns.TypeDNAME && r.store.HasAnyLocalRecords() {
+		if r.resolveLocalAlias(ctx, question, response, t0, skipCache) {
+			tr.step(question.Name, "alias", "hit", "local CNAME/DNAME chain")
+			return
+		}
+	}
+
 	// Built-in localhost (RFC 6761): never forward to public DNS. Local dnsrecords + cache win above.
 	if !isPTR {
 		if loc := builtinLocalhostRRs(question); len(loc) > 0 {
+			tr.step(question.Name, "local", "hit", "built-in localhost")
 			response.Answer = append(response.Answer, loc...)
 			response.Rcode = dns.RcodeSuccess
 			prep := safecast.DurationToUint64(time.Since(t0))
-			data.RecordResolverAResolve(data.PerfOutcomeLocal, prep, prep, 0, 0, 0, qtypeKey)
+			r.recordResolve(data.PerfOutcomeLocal, prep, prep, 0, 0, 0, qtypeKey)
 			r.observeQuery(ctx, question, "local", "", rrOneLine(loc[0]), t0)
 			return
 		}
 	}
 
+	// Built-in empty zones (RFC 6303/6761/8375): private and special-use names never go upstream.
+	if zone := r.emptyZone(question.Name); zone != "" {
+		tr.step(question.Name, "local_zone", "hit", zone)
+		localzone.Answer(question, zone, response)
+		prep := safecast.DurationToUint64(time.Since(t0))
+		r.recordResolve(data.PerfOutcomeLocal, prep, prep, 0, 0, 0, qtypeKey)
+		r.observeQuery(ctx, question, "local", "", emptyZoneSummary(response, zone), t0)
+		return
+	}
+
 	if question.Qtype == dns.TypeA || question.Qtype == dns.TypeAAAA {
-		if r.checkBlocked(question) {
-			r.processBlockedDomain(question, response)
+		_, adblock := tracing.Start(ctx, "dns.adblock", tracing.KindInternal)
+		blocked := r.checkBlocked(question)
+		adblock.SetAttr("dnsplane.blocked", blocked)
+		adblock.End()
+		if blocked {
+			tr.step(question.Name, "adblock", "blocked", "")
+			r.processBlockedDomain(question, response, "adblock")
 			r.observeQuery(ctx, question, "blocked", "", msgAnswerSummary(response), t0)
 			return
 		}
+		tr.step(question.Name, "adblock", "allowed", "")
 	}
 
-	settings := r.store.GetResolverSettings()
-	allServers := r.store.GetServers()
-	dnsServers := dnsservers.GetUpstreamEndpointsForQuery(allServers, question.Name, true)
-	if len(dnsServers) > 0 {
-		dnsServers = dnsservers.AppendPerServerFallbacks(dnsServers, allServers)
-	}
-	useWhitelist := false
-	for _, s := range allServers {
-		if s.Active && dnsservers.ServerMatchesQuery(s, question.Name) {
-			useWhitelist = true
-			break
-		}
-	}
-	var fallbackEp dnsservers.UpstreamEndpoint
-	if !useWhitelist && settings.FallbackServerIP != "" && settings.FallbackServerPort != "" {
-		fallbackEp = dnsservers.FallbackEndpoint(settings.FallbackServerIP, settings.FallbackServerPort, settings.FallbackServerTransport)
+	if noRecursion {
+		tr.step(question.Name, "recursion", "refused", "local answers only")
+		r.refuseRecursion(ctx, question, response)
+		r.observeQuery(ctx, question, "refused", "", "recursion not allowed", t0)
+		return
 	}
-	serversToQuery := dnsServers
-	if fallbackEp.Addr != "" {
-		already := false
-		for _, s := range dnsServers {
-			if s.HealthKey() == fallbackEp.HealthKey() {
-				already = true
-				break
-			}
+
+	settings := r.store.GetResolverSettings()
+	serversToQuery := policyUpstreams
+	if len(serversToQuery) == 0 {
+		serversToQuery = r.selectUpstreams(settings, question)
+	}
+	// Forward zones win over policy upstreams and server whitelists; forward-first keeps the
+	// normal selection as a second round.
+	timeout := r.upstreamTimeout
+	var forwardFirst []dnsservers.UpstreamEndpoint
+	if zone := r.forwardZone(question.Name); zone != nil {
+		tr.step(question.Name, "forward_zone", "match", zone.Name)
+		if zone.First {
+			forwardFirst = serversToQuery
 		}
-		if !already {
-			serversToQuery = append(append([]dnsservers.UpstreamEndpoint(nil), dnsServers...), fallbackEp)
+		serversToQuery = zone.Servers
+		if zone.Timeout > 0 {
+			timeout = zone.Timeout
 		}
 	}
 	serversToQuery = r.store.FilterHealthyUpstreamEndpoints(serversToQuery)
 	nUp := len(serversToQuery)
+	tr.step(question.Name, "upstream", "selected", endpointList(serversToQuery))
 
-	ctx, cancel := context.WithTimeout(ctx, r.upstreamTimeout)
+	baseCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	capCh := 2 + nUp
@@ -260,16 +364,22 @@ func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, r
 
 	if isPTR {
 		go func() {
+			_, span := tracing.Start(ctx, "dns.lookup.local", tracing.KindInternal)
 			t1 := time.Now()
 			lr := r.store.LookupLocalRRs(question.Name, recordType, settings.DNSRecordSettings.AutoBuildPTRFromA)
+			span.SetAttr("dnsplane.result", hitOrMiss(len(lr) > 0))
+			span.End()
 			ch <- parMsg{kind: parKindLocal, local: lr, elapsed: time.Since(t1)}
 		}()
-		if !settings.CacheRecords {
+		if !settings.CacheRecords || skipCache {
 			ch <- parMsg{kind: parKindCache, elapsed: 0}
 		} else if r.store.HasAnyCachedRecords() {
 			go func() {
+				_, span := tracing.Start(ctx, "dns.lookup.cache", tracing.KindInternal)
 				t1 := time.Now()
 				cr := r.store.LookupCacheRR(question.Name, recordType)
+				span.SetAttr("dnsplane.result", hitOrMiss(cr != nil))
+				span.End()
 				ch <- parMsg{kind: parKindCache, cache: cr, elapsed: time.Since(t1)}
 			}()
 		} else {
@@ -282,8 +392,9 @@ func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, r
 	}
 	for _, srv := range serversToQuery {
 		srv := srv
+		tr.upstreamStart()
 		go func() {
-			resp, err := r.upstream.Query(ctx, question, srv)
+			resp, err := r.queryUpstream(ctx, question, srv)
 			if err != nil && r != nil && ctx.Err() == nil && !errors.Is(err, context.Canceled) {
 				r.log("Query: %s, Error querying DNS server (%s): %v\n", question.Name, srv.String(), err)
 			}
@@ -305,7 +416,7 @@ func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, r
 	upstreamTotal := nUp
 
 	recordPerf := func(outcome int, totalNs, prepNs, maxUpstreamNs, waitNs uint64) {
-		data.RecordResolverAResolve(outcome, totalNs, prepNs, maxUpstreamNs, waitNs, nUp, qtypeKey)
+		r.recordResolve(outcome, totalNs, prepNs, maxUpstreamNs, waitNs, nUp, qtypeKey)
 	}
 
 	for i := 0; i < capCh; i++ {
@@ -315,6 +426,7 @@ func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, r
 			localDone = true
 			localNs = safecast.DurationToUint64(pr.elapsed)
 			if len(pr.local) > 0 {
+				tr.step(question.Name, "local", "hit", rrOneLine(pr.local[0]))
 				cancel()
 				r.processCachedRecords(ctx, question, pr.local, response)
 				prep := localNs
@@ -354,6 +466,7 @@ func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, r
 			return p
 		}
 		if localDone && cacheDone && cacheHit != nil {
+			tr.step(question.Name, "cache", "hit", rrOneLine(*cacheHit))
 			cancel()
 			r.store.IncrementCacheHits()
 			r.processCacheRecord(question, cacheHit, response)
@@ -377,6 +490,16 @@ func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, r
 		}
 		if localDone && cacheDone && cacheHit == nil && upstreamSeen == upstreamTotal && firstUp == nil {
 			cancel()
+			if len(forwardFirst) > 0 {
+				if up := r.raceUpstreams(baseCtx, question, r.store.FilterHealthyUpstreamEndpoints(forwardFirst)); up != nil {
+					r.store.RecordUpstreamForwardSuccess(up.endpoint.HealthKey())
+					r.processUpstreamAnswer(baseCtx, question, up.msg, response)
+					p := prepNs()
+					recordPerf(data.PerfOutcomeUpstream, safecast.DurationToUint64(time.Since(t0)), p, maxUpNs, 0)
+					r.observeQuery(baseCtx, question, "upstream", up.endpoint.HealthKey(), firstAnswerSummary(up.msg), t0)
+					return
+				}
+			}
 			r.log("Query: %s, No response\n", question.Name)
 			recordPerf(data.PerfOutcomeNone, safecast.DurationToUint64(time.Since(t0)), prepNs(), maxUpNs, 0)
 			r.observeQuery(ctx, question, "none", "", "no answer", t0)
@@ -385,11 +508,52 @@ func (r *Resolver) resolveFastPath(ctx context.Context, question dns.Question, r
 	}
 }
 
+// selectUpstreams returns whitelist-matching servers (plus their fallbacks) and the global fallback.
+func (r *Resolver) selectUpstreams(settings data.DNSResolverSettings, question dns.Question) []dnsservers.UpstreamEndpoint {
+	allServers := r.store.GetServers()
+	dnsServers := dnsservers.GetUpstreamEndpointsForQuery(allServers, question.Name, true)
+	if len(dnsServers) > 0 {
+		dnsServers = dnsservers.AppendPerServerFallbacks(dnsServers, allServers)
+	}
+	useWhitelist := false
+	for _, s := range allServers {
+		if s.Active && dnsservers.ServerMatchesQuery(s, question.Name) {
+			useWhitelist = true
+			break
+		}
+	}
+	var fallbackEp dnsservers.UpstreamEndpoint
+	if !useWhitelist && settings.FallbackServerIP != "" && settings.FallbackServerPort != "" {
+		fallbackEp = dnsservers.FallbackEndpoint(settings.FallbackServerIP, settings.FallbackServerPort, settings.FallbackServerTransport)
+	}
+	if fallbackEp.Addr == "" {
+		return dnsServers
+	}
+	for _, s := range dnsServers {
+		if s.HealthKey() == fallbackEp.HealthKey() {
+			return dnsServers
+		}
+	}
+	return append(append([]dnsservers.UpstreamEndpoint(nil), dnsServers...), fallbackEp)
+}
+
+// refuseRecursion answers REFUSED with EDE "Prohibited" for a client that may only receive local answers.
+func (r *Resolver) refuseRecursion(ctx context.Context, question dns.Question, response *dns.Msg) {
+	if req := RequestFromContext(ctx); req != nil {
+		response.SetRcode(req, dns.RcodeRefused)
+		SetExtendedError(req, response, dns.ExtendedErrorCodeProhibited, "recursion not allowed")
+	} else {
+		response.Rcode = dns.RcodeRefused
+	}
+	r.log("Query: %s, Refused (recursion not allowed for client)\n", question.Name)
+}
+
 func (r *Resolver) handlePTRQuestion(ctx context.Context, question dns.Question, response *dns.Msg) {
 	t0 := time.Now()
 	settings := r.store.GetResolverSettings()
 	ptrRecords := r.store.LookupLocalRRs(question.Name, "PTR", settings.DNSRecordSettings.AutoBuildPTRFromA)
 	if len(ptrRecords) > 0 {
+		traceFromContext(ctx).step(question.Name, "local", "hit", rrOneLine(ptrRecords[0]))
 		r.processCachedRecords(ctx, question, ptrRecords, response)
 		r.observeQuery(ctx, question, "local", "", rrOneLine(ptrRecords[0]), t0)
 		return
@@ -398,13 +562,36 @@ func (r *Resolver) handlePTRQuestion(ctx context.Context, question dns.Question,
 	r.resolveFastPath(ctx, question, response)
 }
 
+// bogusEvents publishes a failing name once a minute, not on every query for it.
+var bogusEvents = events.NewThrottle(time.Minute)
+
 // processUpstreamAnswer appends the upstream answer to the response and caches it.
 // The response's Authoritative and Rcode are set from the upstream message.
 func (r *Resolver) processUpstreamAnswer(ctx context.Context, question dns.Question, answer *dns.Msg, response *dns.Msg) {
 	req := RequestFromContext(ctx)
 	settings := r.store.GetResolverSettings()
+	var validate *tracing.Span
+	if settings.DNSSECValidate {
+		_, validate = tracing.Start(ctx, "dns.dnssec.validate", tracing.KindInternal)
+	}
 	outcome, servfail := dnssecvalidate.ApplyToUpstreamAnswer(req, answer, question, settings)
-	data.RecordDNSSECOutcome(outcome)
+	if validate != nil {
+		validate.SetAttr("dnsplane.dnssec", outcome)
+		if outcome == dnssecvalidate.OutcomeBogus {
+			validate.SetError("bogus")
+		}
+		validate.End()
+	}
+	traceFromContext(ctx).dnssec(outcome)
+	if !r.quiet {
+		data.RecordDNSSECOutcome(outcome)
+		if outcome == dnssecvalidate.OutcomeBogus {
+			qtype := dns.TypeToString[question.Qtype]
+			if bogusEvents.Allow(question.Name + "/" + qtype) {
+				events.Publish(events.DNSSECBogus, map[string]any{"name": question.Name, "type": qtype, "servfail": servfail})
+			}
+		}
+	}
 	if servfail {
 		if req != nil {
 			response.SetRcode(req, dns.RcodeServerFailure)
@@ -425,7 +612,9 @@ func (r *Resolver) processUpstreamAnswer(ctx context.Context, question dns.Quest
 		}
 		r.log("Query: %s, Reply: %s, Method: DNS server: %s\n", question.Name, record.String(), name)
 	}
-	cacheUpstreamAnswerAfterSuccess(r.store, question, answer.Answer)
+	if !cacheBypassFromContext(ctx) {
+		cacheUpstreamAnswerAfterSuccess(r.store, question, answer.Answer)
+	}
 }
 
 func (r *Resolver) processCachedRecords(ctx context.Context, question dns.Question, cachedRecords []dns.RR, response *dns.Msg) {
@@ -436,6 +625,7 @@ func (r *Resolver) processCachedRecords(ctx context.Context, question dns.Questi
 	if r.dnssecSigner != nil {
 		req := RequestFromContext(ctx)
 		r.dnssecSigner.SignLocalAnswerIfDO(req, question, cachedRecords, response)
+		traceSigned(ctx, response)
 	}
 	response.Authoritative = true
 	if len(cachedRecords) > 0 {
@@ -578,7 +768,9 @@ func (r *Resolver) backgroundRefresh(detachCtx context.Context, question dns.Que
 	settings := r.store.GetResolverSettings()
 	allServers := r.store.GetServers()
 	servers := dnsservers.GetUpstreamEndpointsForQuery(allServers, question.Name, true)
-	if len(servers) == 0 {
+	if zone := r.forwardZone(question.Name); zone != nil {
+		servers = zone.Servers
+	} else if len(servers) == 0 {
 		if settings.FallbackServerIP != "" && settings.FallbackServerPort != "" {
 			servers = []dnsservers.UpstreamEndpoint{
 				dnsservers.FallbackEndpoint(settings.FallbackServerIP, settings.FallbackServerPort, settings.FallbackServerTransport),
@@ -600,6 +792,14 @@ func (r *Resolver) backgroundRefresh(detachCtx context.Context, question dns.Que
 	}
 }
 
+// recordResolve feeds the resolver performance counters (data.RecordResolverAResolve).
+func (r *Resolver) recordResolve(outcome int, totalNs, prepNs, maxUpstreamNs, waitNs uint64, upstreams int, qtype string) {
+	if r.quiet {
+		return
+	}
+	data.RecordResolverAResolve(outcome, totalNs, prepNs, maxUpstreamNs, waitNs, upstreams, qtype)
+}
+
 func (r *Resolver) log(format string, args ...interface{}) {
 	if r == nil || r.logger == nil {
 		return
@@ -608,11 +808,23 @@ func (r *Resolver) log(format string, args ...interface{}) {
 }
 
 func (r *Resolver) observeQuery(ctx context.Context, question dns.Question, outcome, upstream, recordSummary string, t0 time.Time) {
+	if sink := observeSinkFromContext(ctx); sink != nil {
+		sink.outcome, sink.upstream = outcome, upstream
+		sink.geo = QueryNotesFromContext(ctx).Geo
+		return
+	}
+	traceFromContext(ctx).finish(outcome, upstream, recordSummary)
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		span.SetAttr("dnsplane.outcome", outcome)
+		if upstream != "" {
+			span.SetAttr("dnsplane.upstream", upstream)
+		}
+	}
 	if r == nil || r.queryObserver == nil {
 		return
 	}
 	ip := ClientIPFromContext(ctx)
-	r.queryObserver(question.Name, perfQTypeString(question), outcome, upstream, recordSummary, time.Since(t0), ip)
+	r.queryObserver(question.Name, perfQTypeString(question), outcome, upstream, recordSummary, time.Since(t0), ip, QueryNotesFromContext(ctx))
 }
 
 func isLocalhostQNAME(name string) bool {
@@ -686,8 +898,8 @@ func (r *Resolver) checkBlocked(question dns.Question) bool {
 	return blockList.IsBlocked(domain)
 }
 
-// processBlockedDomain returns a blocked response (0.0.0.0 for A, :: for AAAA).
-func (r *Resolver) processBlockedDomain(question dns.Question, response *dns.Msg) {
+// processBlockedDomain returns a blocked response (0.0.0.0 for A, :: for AAAA). reason is logged ("adblock", "policy <group>").
+func (r *Resolver) processBlockedDomain(question dns.Question, response *dns.Msg, reason string) {
 	if r == nil || r.store == nil {
 		return
 	}
@@ -708,7 +920,7 @@ func (r *Resolver) processBlockedDomain(question dns.Question, response *dns.Msg
 	default:
 		// For other types, return NXDOMAIN or empty response
 		response.Rcode = dns.RcodeNameError
-		r.log("Query: %s, Blocked (adblock), Type: %s\n", question.Name, dns.TypeToString[question.Qtype])
+		r.log("Query: %s, Blocked (%s), Type: %s\n", question.Name, reason, dns.TypeToString[question.Qtype])
 		return
 	}
 
@@ -721,5 +933,5 @@ func (r *Resolver) processBlockedDomain(question dns.Question, response *dns.Msg
 	}
 
 	response.Answer = append(response.Answer, rr)
-	r.log("Query: %s, Blocked (adblock), Reply: %s\n", question.Name, rr.String())
+	r.log("Query: %s, Blocked (%s), Reply: %s\n", question.Name, reason, rr.String())
 }
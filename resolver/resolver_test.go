@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"net"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -15,9 +16,13 @@ import (
 	"dnsplane/adblock"
 	"dnsplane/config"
 	"dnsplane/data"
+	"dnsplane/dnscookie"
 	"dnsplane/dnsrecordcache"
 	"dnsplane/dnsrecords"
 	"dnsplane/dnsservers"
+	"dnsplane/forwardzone"
+	"dnsplane/localzone"
+	"dnsplane/policy"
 )
 
 // recordingUpstream records each (question name, server) Query call and returns a minimal success response.
@@ -380,6 +385,40 @@ func (s *upstreamOnlyStore) TryFastLocalOrCache(string, string, bool) (bool, []d
 	return false, nil, nil, nil, false
 }
 
+// withheldStore has local A records for app.corp. whose health checks withheld every value: the data
+// store's lookups come back empty for them, as they do when selectRecordValues returns nil.
+type withheldStore struct {
+	upstreamOnlyStore
+}
+
+func (s *withheldStore) GetRecords() []dnsrecords.DNSRecord {
+	return []dnsrecords.DNSRecord{{Name: "app.corp.", Type: "A", Value: "10.0.0.1", TTL: 60,
+		HealthCheck: &dnsrecords.HealthCheck{Type: "tcp", Port: 443}}}
+}
+func (s *withheldStore) HasAnyLocalRecords() bool { return true }
+
+// TestResolver_AllValuesWithheld pins the answer for a name whose health-checked values are all down and
+// none fails open: neither NODATA nor SERVFAIL, but whatever the upstreams answer.
+func TestResolver_AllValuesWithheld(t *testing.T) {
+	srv := dnsservers.DNSServer{Address: "9.9.9.9", Port: "53", Active: true}
+	store := &withheldStore{upstreamOnlyStore{servers: []dnsservers.DNSServer{srv}}}
+	rec := &recordingUpstream{}
+	r := New(Config{Store: store, Upstream: rec, UpstreamTimeout: 2 * time.Second})
+	q := dns.Question{Name: "app.corp.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+	msg := &dns.Msg{}
+	msg.SetQuestion(q.Name, q.Qtype)
+	r.HandleQuestion(context.Background(), q, msg)
+	if msg.Rcode != dns.RcodeSuccess || len(msg.Answer) != 1 {
+		t.Fatalf("rcode %s, answers %v; want the upstream answer", dns.RcodeToString[msg.Rcode], msg.Answer)
+	}
+	if a, ok := msg.Answer[0].(*dns.A); !ok || a.A.String() != "1.2.3.4" {
+		t.Fatalf("answer %v, want the upstream's 1.2.3.4", msg.Answer[0])
+	}
+	if got := rec.recorded(); len(got) != 1 || got[0].server != "9.9.9.9:53" {
+		t.Fatalf("upstream queries = %+v", got)
+	}
+}
+
 func TestResolver_AAAA_upstreamFastPath(t *testing.T) {
 	srv := dnsservers.DNSServer{Address: "8.8.8.8", Port: "53", Active: true}
 	store := &upstreamOnlyStore{servers: []dnsservers.DNSServer{srv}, config: config.Config{}}
@@ -597,3 +636,636 @@ func TestResolver_CNAMEChain_SecondQueryUsesSynthCache(t *testing.T) {
 		t.Fatalf("second response: want 2 answers, got %d", len(msg2.Answer))
 	}
 }
+
+func TestResolver_NoRecursionRefusesUpstream(t *testing.T) {
+	srv := dnsservers.DNSServer{Address: "8.8.8.8", Port: "53", Active: true}
+	store := &upstreamOnlyStore{servers: []dnsservers.DNSServer{srv}, config: config.Config{}}
+	rec := &recordingUpstream{}
+	r := New(Config{Store: store, Upstream: rec, UpstreamTimeout: 2 * time.Second})
+	q := dns.Question{Name: "wide.example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+	req := new(dns.Msg)
+	req.SetQuestion(q.Name, q.Qtype)
+	req.SetEdns0(1232, false)
+	msg := new(dns.Msg)
+	msg.SetReply(req)
+	ctx := ContextWithNoRecursion(ContextWithRequest(context.Background(), req))
+	r.HandleQuestion(ctx, q, msg)
+	if msg.Rcode != dns.RcodeRefused {
+		t.Fatalf("rcode = %s, want REFUSED", dns.RcodeToString[msg.Rcode])
+	}
+	if len(rec.recorded()) != 0 {
+		t.Fatalf("upstream must not be queried: %+v", rec.recorded())
+	}
+	opt := msg.IsEdns0()
+	if opt == nil || len(opt.Option) != 1 {
+		t.Fatal("expected EDE option on refused response")
+	}
+	if ede, ok := opt.Option[0].(*dns.EDNS0_EDE); !ok || ede.InfoCode != dns.ExtendedErrorCodeProhibited {
+		t.Fatalf("option = %#v", opt.Option[0])
+	}
+}
+
+func TestResolver_NoRecursionStillAnswersLocal(t *testing.T) {
+	store := &localRecordStore{
+		records: []dnsrecords.DNSRecord{{Name: "test.example.com.", Type: "A", Value: "1.2.3.4", TTL: 60}},
+	}
+	r := New(Config{Store: store, Upstream: &recordingUpstream{}, UpstreamTimeout: 2 * time.Second})
+	q := dns.Question{Name: "test.example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+	msg := &dns.Msg{}
+	msg.SetQuestion(q.Name, q.Qtype)
+	r.HandleQuestion(ContextWithNoRecursion(context.Background()), q, msg)
+	if len(msg.Answer) != 1 || msg.Rcode != dns.RcodeSuccess {
+		t.Fatalf("want local answer, got rcode=%s answers=%d", dns.RcodeToString[msg.Rcode], len(msg.Answer))
+	}
+}
+
+func TestResolver_PolicyGroups(t *testing.T) {
+	eng, err := policy.Compile(config.PolicyConfig{
+		Categories: map[string]config.PolicyCategory{"games": {Domains: []string{"games.example"}}},
+		Groups: []config.PolicyGroup{
+			{Name: "kids", Clients: []string{"192.168.1.0/24"}, BlockCategories: []string{"games"}, SafeSearch: true},
+			{Name: "office", Clients: []string{"10.0.0.0/8"}, Upstreams: []config.PolicyUpstream{{Address: "9.9.9.9"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := dnsservers.DNSServer{Address: "8.8.8.8", Port: "53", Active: true}
+	store := &upstreamOnlyStore{servers: []dnsservers.DNSServer{srv}, config: config.Config{}}
+	rec := &recordingUpstream{}
+	var notes QueryNotes
+	var outcome string
+	r := New(Config{
+		Store:           store,
+		Upstream:        rec,
+		UpstreamTimeout: 2 * time.Second,
+		Policy:          func() *policy.Engine { return eng },
+		QueryObserver: func(_, _, oc, _, _ string, _ time.Duration, _ string, n QueryNotes) {
+			outcome, notes = oc, n
+		},
+	})
+	ask := func(ip, name string) *dns.Msg {
+		q := dns.Question{Name: name, Qtype: dns.TypeA, Qclass: dns.ClassINET}
+		msg := new(dns.Msg)
+		msg.SetQuestion(q.Name, q.Qtype)
+		r.HandleQuestion(ContextWithClientIP(context.Background(), ip), q, msg)
+		return msg
+	}
+
+	msg := ask("192.168.1.20", "www.games.example.")
+	if outcome != "blocked" || notes.PolicyGroup != "kids" || notes.PolicyRule != "category:games" || len(rec.recorded()) != 0 {
+		t.Fatalf("block: outcome=%s notes=%+v upstream=%v", outcome, notes, rec.recorded())
+	}
+	if a, ok := msg.Answer[0].(*dns.A); !ok || !a.A.Equal(net.IPv4zero) {
+		t.Fatalf("block answer = %v", msg.Answer)
+	}
+
+	msg = ask("192.168.1.20", "www.google.com.")
+	if len(msg.Answer) != 2 || notes.PolicyRule != "safe_search:google" || outcome != "upstream" {
+		t.Fatalf("safe search: answers=%v outcome=%s notes=%+v", msg.Answer, outcome, notes)
+	}
+	if c, ok := msg.Answer[0].(*dns.CNAME); !ok || c.Target != "forcesafesearch.google.com." {
+		t.Fatalf("safe search CNAME = %v", msg.Answer[0])
+	}
+	if got := rec.recorded(); len(got) != 1 || got[0].name != "forcesafesearch.google.com." {
+		t.Fatalf("safe search upstream queries = %+v", got)
+	}
+
+	rec.reset()
+	ask("10.1.1.1", "www.games.example.")
+	if got := rec.recorded(); len(got) != 1 || got[0].server != "9.9.9.9:53" || notes.PolicyGroup != "office" {
+		t.Fatalf("group upstream: queries=%+v notes=%+v", got, notes)
+	}
+
+	rec.reset()
+	ask("172.16.0.1", "www.games.example.")
+	if got := rec.recorded(); len(got) != 1 || got[0].server != "8.8.8.8:53" || notes.PolicyGroup != "" {
+		t.Fatalf("no group: queries=%+v notes=%+v", got, notes)
+	}
+}
+
+func TestResolver_ForwardZones(t *testing.T) {
+	tbl, err := forwardzone.Compile([]config.ForwardZone{
+		{Name: "corp", Zones: []string{"corp.example"}, Servers: []config.ForwardServer{{Address: "10.0.0.53"}}},
+		{Name: "lab", Zones: []string{"lab.corp.example"}, Servers: []config.ForwardServer{{Address: "10.9.0.53"}}, Forward: "first"},
+		{Name: "lan", ReverseCIDRs: []string{"10.20.0.0/16"}, Servers: []config.ForwardServer{{Address: "10.0.0.53"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := dnsservers.DNSServer{Address: "8.8.8.8", Port: "53", Active: true}
+	store := &upstreamOnlyStore{servers: []dnsservers.DNSServer{srv}, config: config.Config{}}
+	rec := &recordingUpstream{}
+	up := &upstreamFailAddrs{fail: map[string]struct{}{"10.9.0.53:53": {}, "10.0.0.53:53": {}}, rec: rec}
+	r := New(Config{
+		Store:           store,
+		Upstream:        up,
+		UpstreamTimeout: 2 * time.Second,
+		ForwardZones:    func() *forwardzone.Table { return tbl },
+	})
+	ask := func(name string, qtype uint16) *dns.Msg {
+		q := dns.Question{Name: name, Qtype: qtype, Qclass: dns.ClassINET}
+		msg := new(dns.Msg)
+		msg.SetQuestion(q.Name, q.Qtype)
+		r.HandleQuestion(context.Background(), q, msg)
+		return msg
+	}
+	servers := func() []string {
+		var out []string
+		for _, q := range rec.recorded() {
+			out = append(out, q.server)
+		}
+		rec.reset()
+		return out
+	}
+
+	// Forward-only: the zone server fails and nothing else is asked.
+	if msg := ask("www.corp.example.", dns.TypeA); len(msg.Answer) != 0 {
+		t.Fatalf("forward-only answered: %v", msg.Answer)
+	}
+	if got := servers(); len(got) != 1 || got[0] != "10.0.0.53:53" {
+		t.Fatalf("forward-only servers = %v", got)
+	}
+	// Forward-first: the longer zone wins, fails, then the global server answers.
+	if msg := ask("host.lab.corp.example.", dns.TypeA); len(msg.Answer) != 1 {
+		t.Fatalf("forward-first answer = %v", msg.Answer)
+	}
+	if got := servers(); len(got) != 2 || got[0] != "10.9.0.53:53" || got[1] != "8.8.8.8:53" {
+		t.Fatalf("forward-first servers = %v", got)
+	}
+	// Generated reverse zone.
+	ask("5.1.20.10.in-addr.arpa.", dns.TypePTR)
+	if got := servers(); len(got) != 1 || got[0] != "10.0.0.53:53" {
+		t.Fatalf("reverse zone servers = %v", got)
+	}
+	ask("example.org.", dns.TypeA)
+	if got := servers(); len(got) != 1 || got[0] != "8.8.8.8:53" {
+		t.Fatalf("unmatched servers = %v", got)
+	}
+}
+
+func TestResolver_LocalZones(t *testing.T) {
+	zones := localzone.Compile(config.LocalZonesConfig{})
+	tbl, err := forwardzone.Compile([]config.ForwardZone{{Zones: []string{"10.in-addr.arpa"}, Servers: []config.ForwardServer{{Address: "10.0.0.53"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &whitelistIntegrationStore{servers: []dnsservers.DNSServer{
+		{Address: "8.8.8.8", Port: "53", Active: true},
+		{Address: "192.168.5.5", Port: "53", Active: true, DomainWhitelist: []string{"corp.internal"}},
+	}}
+	rec := &recordingUpstream{}
+	var outcome string
+	r := New(Config{
+		Store:           store,
+		Upstream:        rec,
+		UpstreamTimeout: 2 * time.Second,
+		LocalZones:      func() *localzone.Set { return zones },
+		ForwardZones:    func() *forwardzone.Table { return tbl },
+		QueryObserver: func(_, _, oc, _, _ string, _ time.Duration, _ string, _ QueryNotes) {
+			outcome = oc
+		},
+	})
+	ask := func(name string, qtype uint16) *dns.Msg {
+		rec.reset()
+		q := dns.Question{Name: name, Qtype: qtype, Qclass: dns.ClassINET}
+		msg := new(dns.Msg)
+		msg.SetQuestion(q.Name, q.Qtype)
+		r.HandleQuestion(context.Background(), q, msg)
+		return msg
+	}
+
+	for _, q := range []struct {
+		name  string
+		qtype uint16
+	}{
+		{"5.1.168.192.in-addr.arpa.", dns.TypePTR},
+		{"printer.local.", dns.TypeA},
+		{"nas.internal.", dns.TypeAAAA},
+	} {
+		msg := ask(q.name, q.qtype)
+		if msg.Rcode != dns.RcodeNameError || len(msg.Ns) != 1 || outcome != "local" || len(rec.recorded()) != 0 {
+			t.Fatalf("%s: rcode=%d ns=%v outcome=%s upstream=%v", q.name, msg.Rcode, msg.Ns, outcome, rec.recorded())
+		}
+	}
+	// A whitelisted server or a forward zone takes the name back from the empty zone.
+	ask("host.corp.internal.", dns.TypeA)
+	if got := rec.recorded(); len(got) != 1 || got[0].server != "192.168.5.5:53" {
+		t.Fatalf("whitelisted name: upstream=%v", got)
+	}
+	ask("4.3.2.10.in-addr.arpa.", dns.TypePTR)
+	if got := rec.recorded(); len(got) != 1 || got[0].server != "10.0.0.53:53" {
+		t.Fatalf("forwarded reverse zone: upstream=%v", got)
+	}
+
+	// Local records anywhere in a zone disable it.
+	local := &localRecordStore{records: []dnsrecords.DNSRecord{{Name: "nas.home.arpa", Type: "A", Value: "192.168.1.10", TTL: 60}}}
+	withData := zones.WithLocalData(local.records, false)
+	r = New(Config{Store: local, Upstream: rec, LocalZones: func() *localzone.Set { return withData }})
+	q := dns.Question{Name: "other.home.arpa.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+	msg := new(dns.Msg)
+	msg.SetQuestion(q.Name, q.Qtype)
+	r.HandleQuestion(context.Background(), q, msg)
+	if msg.Rcode == dns.RcodeNameError || len(msg.Ns) != 0 {
+		t.Fatalf("home.arpa with local data answered from empty zone: %v", msg)
+	}
+}
+
+func TestDNSClient_UpstreamCookies(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("udp listen:", err)
+	}
+	srv := dnscookie.NewServer(time.Hour)
+	var mu sync.Mutex
+	var withServerCookie, badCookies int
+	mux := dns.NewServeMux()
+	mux.HandleFunc(".", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.SetEdns0(1232, false)
+		host, _, _ := net.SplitHostPort(w.RemoteAddr().String())
+		client, server, ok, _ := dnscookie.Parse(r)
+		mu.Lock()
+		switch {
+		case ok && server != nil && srv.Verify(client, server, host):
+			withServerCookie++
+			m.Answer = append(m.Answer, &dns.A{Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.IPv4(192, 0, 2, 7)})
+		default:
+			badCookies++
+			m.Rcode = dns.RcodeBadCookie
+		}
+		mu.Unlock()
+		if ok {
+			dnscookie.SetOption(m, client, srv.Issue(client, host))
+		}
+		_ = w.WriteMsg(m)
+	})
+	server := &dns.Server{PacketConn: pc, Handler: mux}
+	go func() { _ = server.ActivateAndServe() }()
+	defer func() { _ = server.Shutdown() }()
+
+	c := NewDNSClient(2 * time.Second).EnableCookies()
+	ep := dnsservers.UpstreamEndpoint{Addr: pc.LocalAddr().String(), Transport: "udp"}
+	q := dns.Question{Name: "cookie.example.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+	for i := 0; i < 2; i++ {
+		resp, err := c.Query(context.Background(), q, ep)
+		if err != nil || resp == nil || len(resp.Answer) != 1 {
+			t.Fatalf("query %d: resp=%v err=%v", i, resp, err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// First query had no server cookie (BADCOOKIE + retry); the second reused the learned cookie.
+	if badCookies != 1 || withServerCookie != 2 {
+		t.Fatalf("badCookies=%d withServerCookie=%d", badCookies, withServerCookie)
+	}
+}
+
+// aliasStore serves local records and upstream servers and keeps what the resolver writes to the cache.
+type aliasStore struct {
+	localRecordStore
+	servers []dnsservers.DNSServer
+	mu      sync.Mutex
+	cache   []dnsrecordcache.CacheRecord
+}
+
+func (s *aliasStore) GetServers() []dnsservers.DNSServer { return s.servers }
+func (s *aliasStore) GetCacheRecords() []dnsrecordcache.CacheRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]dnsrecordcache.CacheRecord(nil), s.cache...)
+}
+func (s *aliasStore) UpdateCacheRecords(c []dnsrecordcache.CacheRecord) {
+	s.mu.Lock()
+	s.cache = c
+	s.mu.Unlock()
+}
+
+func TestResolver_LocalAliasChains(t *testing.T) {
+	store := &aliasStore{
+		localRecordStore: localRecordStore{
+			records: []dnsrecords.DNSRecord{
+				{Name: "app.corp", Type: "CNAME", Value: "web01.corp.", TTL: 300},
+				{Name: "web01.corp", Type: "A", Value: "10.0.0.5", TTL: 300},
+				{Name: "old.corp", Type: "DNAME", Value: "corp.", TTL: 300},
+				{Name: "loop1.corp", Type: "CNAME", Value: "loop2.corp.", TTL: 300},
+				{Name: "loop2.corp", Type: "CNAME", Value: "loop1.corp.", TTL: 300},
+				{Name: "ext.corp", Type: "CNAME", Value: "www.example.com.", TTL: 300},
+			},
+			config: config.Config{CacheRecords: true},
+		},
+		servers: []dnsservers.DNSServer{{Address: "8.8.8.8", Port: "53", Active: true}},
+	}
+	rec := &recordingUpstream{}
+	var outcome string
+	r := New(Config{
+		Store:           store,
+		Upstream:        rec,
+		UpstreamTimeout: 2 * time.Second,
+		QueryObserver: func(_, _, oc, _, _ string, _ time.Duration, _ string, _ QueryNotes) {
+			outcome = oc
+		},
+	})
+	ask := func(name string) *dns.Msg {
+		rec.reset()
+		q := dns.Question{Name: name, Qtype: dns.TypeA, Qclass: dns.ClassINET}
+		msg := new(dns.Msg)
+		msg.SetQuestion(q.Name, q.Qtype)
+		r.HandleQuestion(context.Background(), q, msg)
+		return msg
+	}
+
+	msg := ask("app.corp.")
+	if len(msg.Answer) != 2 || !msg.Authoritative || outcome != "local" || len(rec.recorded()) != 0 {
+		t.Fatalf("local chain: answer=%v outcome=%s upstream=%v", msg.Answer, outcome, rec.recorded())
+	}
+	if a, ok := msg.Answer[1].(*dns.A); !ok || a.A.String() != "10.0.0.5" {
+		t.Fatalf("local chain: final = %v", msg.Answer[1])
+	}
+
+	msg = ask("web01.old.corp.")
+	if len(msg.Answer) != 3 || outcome != "local" {
+		t.Fatalf("DNAME: answer=%v outcome=%s", msg.Answer, outcome)
+	}
+	if c, ok := msg.Answer[1].(*dns.CNAME); !ok || c.Hdr.Name != "web01.old.corp." || c.Target != "web01.corp." {
+		t.Fatalf("DNAME: synthesized CNAME = %v", msg.Answer[1])
+	}
+
+	msg = ask("loop1.corp.")
+	if msg.Rcode != dns.RcodeServerFailure || len(msg.Answer) != 0 || len(rec.recorded()) != 0 {
+		t.Fatalf("loop: rcode=%d answer=%v upstream=%v", msg.Rcode, msg.Answer, rec.recorded())
+	}
+
+	msg = ask("ext.corp.")
+	if got := rec.recorded(); len(got) != 1 || got[0].name != "www.example.com." {
+		t.Fatalf("upstream target: queries=%v", got)
+	}
+	if len(msg.Answer) != 2 || msg.Authoritative || outcome != "upstream" {
+		t.Fatalf("upstream target: answer=%v outcome=%s", msg.Answer, outcome)
+	}
+	var composite bool
+	for _, c := range store.GetCacheRecords() {
+		if c.DNSRecord.Name == "ext.corp." && c.DNSRecord.Type == "A" && strings.HasPrefix(c.DNSRecord.Value, data.RRSetCachePrefix) {
+			composite = true
+		}
+	}
+	if !composite {
+		t.Fatalf("composite answer not cached: %+v", store.GetCacheRecords())
+	}
+}
+
+func TestResolver_ALIASFlattening(t *testing.T) {
+	store := &aliasStore{
+		localRecordStore: localRecordStore{
+			records: []dnsrecords.DNSRecord{
+				{Name: "example.com", Type: "ALIAS", Value: "lb.cloud.example.net.", TTL: 300},
+				{Name: "loop.example.com", Type: "ANAME", Value: "loop.example.com.", TTL: 300},
+			},
+		},
+		servers: []dnsservers.DNSServer{{Address: "8.8.8.8", Port: "53", Active: true}},
+	}
+	aliasTarget := func(name string) (string, uint32, bool) {
+		for _, rec := range store.records {
+			if dnsrecords.IsFlattenedType(rec.Type) && strings.EqualFold(dns.Fqdn(rec.Name), dns.Fqdn(name)) {
+				return dns.Fqdn(rec.Value), rec.TTL, true
+			}
+		}
+		return "", 0, false
+	}
+	rec := &recordingUpstream{}
+	var outcome string
+	r := New(Config{
+		Store:           store,
+		Upstream:        rec,
+		UpstreamTimeout: 2 * time.Second,
+		ALIASTarget:     aliasTarget,
+		QueryObserver: func(_, _, oc, _, _ string, _ time.Duration, _ string, _ QueryNotes) {
+			outcome = oc
+		},
+	})
+	ask := func(name string) *dns.Msg {
+		rec.reset()
+		q := dns.Question{Name: name, Qtype: dns.TypeA, Qclass: dns.ClassINET}
+		msg := new(dns.Msg)
+		msg.SetQuestion(q.Name, q.Qtype)
+		r.HandleQuestion(context.Background(), q, msg)
+		return msg
+	}
+
+	msg := ask("example.com.")
+	if got := rec.recorded(); len(got) != 1 || got[0].name != "lb.cloud.example.net." {
+		t.Fatalf("target not resolved upstream: %v", got)
+	}
+	if len(msg.Answer) != 1 || !msg.Authoritative || outcome != "upstream" {
+		t.Fatalf("first answer=%v outcome=%s", msg.Answer, outcome)
+	}
+	if a, ok := msg.Answer[0].(*dns.A); !ok || a.Hdr.Name != "example.com." || a.Hdr.Ttl != 60 {
+		t.Fatalf("flattened RR = %v (want owner name, TTL min(300, 60))", msg.Answer[0])
+	}
+
+	msg = ask("example.com.")
+	if len(rec.recorded()) != 0 || len(msg.Answer) != 1 || outcome != "local" {
+		t.Fatalf("second query: upstream=%v answer=%v outcome=%s", rec.recorded(), msg.Answer, outcome)
+	}
+
+	if rrs := r.FlattenALIAS(context.Background(), "example.com"); len(rrs) != 2 {
+		t.Fatalf("FlattenALIAS = %v, want A and AAAA", rrs)
+	}
+
+	msg = ask("loop.example.com.")
+	if msg.Rcode != dns.RcodeSuccess || len(msg.Answer) != 0 {
+		t.Fatalf("self-referencing ANAME: rcode=%d answer=%v", msg.Rcode, msg.Answer)
+	}
+}
+
+func TestResolver_ALIASFlatteningUpstreamFailure(t *testing.T) {
+	store := &aliasStore{
+		localRecordStore: localRecordStore{
+			records: []dnsrecords.DNSRecord{{Name: "example.com", Type: "ALIAS", Value: "lb.cloud.example.net.", TTL: 300}},
+		},
+		servers: []dnsservers.DNSServer{{Address: "8.8.8.8", Port: "53", Active: true}},
+	}
+	aliasTarget := func(name string) (string, uint32, bool) {
+		if strings.EqualFold(dns.Fqdn(name), "example.com.") {
+			return "lb.cloud.example.net.", 300, true
+		}
+		return "", 0, false
+	}
+	rec := &recordingUpstream{}
+	up := &upstreamFailAddrs{fail: map[string]struct{}{"8.8.8.8:53": {}}, rec: rec}
+	r := New(Config{Store: store, Upstream: up, UpstreamTimeout: 2 * time.Second, ALIASTarget: aliasTarget})
+	key := flattenKey("example.com.", dns.TypeA)
+	ask := func() *dns.Msg {
+		q := dns.Question{Name: "example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+		msg := new(dns.Msg)
+		msg.SetQuestion(q.Name, q.Qtype)
+		r.HandleQuestion(context.Background(), q, msg)
+		return msg
+	}
+
+	// A failed lookup is not remembered as "no addresses".
+	if msg := ask(); len(msg.Answer) != 0 {
+		t.Fatalf("answer with the upstream down: %v", msg.Answer)
+	}
+	if e := r.flattened.entries[key]; e != nil {
+		t.Fatalf("failed lookup stored: %+v", e)
+	}
+	up.fail = nil
+	if msg := ask(); len(msg.Answer) != 1 {
+		t.Fatalf("answer once the upstream is back: %v", msg.Answer)
+	}
+
+	// A background refresh that fails keeps the answer it had.
+	up.fail = map[string]struct{}{"8.8.8.8:53": {}}
+	r.flattened.mu.Lock()
+	r.flattened.entries[key].expires = time.Now().Add(5 * time.Second)
+	r.flattened.mu.Unlock()
+	rec.reset()
+	if msg := ask(); len(msg.Answer) != 1 {
+		t.Fatalf("answer before the refresh: %v", msg.Answer)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(rec.recorded()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if msg := ask(); len(msg.Answer) != 1 {
+		t.Fatalf("answer after a failed refresh: %v", msg.Answer)
+	}
+}
+
+func TestFlattenTable_DropsStaleEntries(t *testing.T) {
+	var tbl flattenTable
+	now := time.Now()
+	rr := []dns.RR{&dns.A{Hdr: dns.RR_Header{Name: "a.example.", Rrtype: dns.TypeA, Class: dns.ClassINET}, A: net.IPv4(192, 0, 2, 1)}}
+	tbl.put("a|A", "t1.example.", rr, 60, now)
+	tbl.put("b|A", "t2.example.", rr, 10, now)
+	if _, _, ok := tbl.get("a|A", "t9.example.", now); ok || tbl.entries["a|A"] != nil {
+		t.Fatal("entry for a retargeted ALIAS kept")
+	}
+	tbl.put("c|A", "t3.example.", rr, 60, now.Add(flattenSweepInterval))
+	if tbl.entries["b|A"] != nil || tbl.entries["c|A"] == nil {
+		t.Fatalf("sweep left %v", tbl.entries)
+	}
+}
+
+func TestResolver_GeoAnswers(t *testing.T) {
+	store := &aliasStore{
+		localRecordStore: localRecordStore{
+			records: []dnsrecords.DNSRecord{
+				{Name: "web01.corp", Type: "A", Value: "10.0.0.5", TTL: 300},
+			},
+			config: config.Config{CacheRecords: true},
+		},
+		servers: []dnsservers.DNSServer{{Address: "8.8.8.8", Port: "53", Active: true}},
+	}
+	inEU := func(ip string) bool { return strings.HasPrefix(ip, "10.1.") }
+	geoLookup := func(name, rt, client string) ([]dns.RR, string, bool) {
+		var rr dns.RR
+		label := "default"
+		switch {
+		case name == "www.corp." && rt == "A" && inEU(client):
+			rr, label = &dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP("10.1.0.5")}, "region eu"
+		case name == "www.corp." && rt == "A":
+			rr = &dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP("10.9.9.9")}
+		case name == "cdn.corp." && rt == "CNAME" && inEU(client):
+			rr, label = &dns.CNAME{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60}, Target: "web01.corp."}, "region eu"
+		case name == "cdn.corp." && rt == "CNAME":
+			rr = &dns.CNAME{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60}, Target: "www.example.com."}
+		default:
+			return nil, "", false
+		}
+		return []dns.RR{rr}, label, true
+	}
+	rec := &recordingUpstream{}
+	var notes QueryNotes
+	r := New(Config{
+		Store:           store,
+		Upstream:        rec,
+		UpstreamTimeout: 2 * time.Second,
+		GeoLookup:       geoLookup,
+		QueryObserver: func(_, _, _, _, _ string, _ time.Duration, _ string, n QueryNotes) {
+			notes = n
+		},
+	})
+	ask := func(name, client string, ecs *dns.EDNS0_SUBNET) *dns.Msg {
+		rec.reset()
+		q := dns.Question{Name: name, Qtype: dns.TypeA, Qclass: dns.ClassINET}
+		req := new(dns.Msg)
+		req.SetQuestion(q.Name, q.Qtype)
+		if ecs != nil {
+			req.SetEdns0(1232, false)
+			req.IsEdns0().Option = append(req.IsEdns0().Option, ecs)
+		}
+		ctx := ContextWithClientIP(ContextWithRequest(context.Background(), req), client)
+		msg := new(dns.Msg)
+		msg.SetQuestion(q.Name, q.Qtype)
+		r.HandleQuestion(ctx, q, msg)
+		return msg
+	}
+	first := func(msg *dns.Msg) string {
+		if len(msg.Answer) == 0 {
+			return ""
+		}
+		if a, ok := msg.Answer[len(msg.Answer)-1].(*dns.A); ok {
+			return a.A.String()
+		}
+		return msg.Answer[len(msg.Answer)-1].String()
+	}
+
+	if msg := ask("www.corp.", "10.1.2.3", nil); first(msg) != "10.1.0.5" || notes.Geo != "region eu" {
+		t.Fatalf("client in region: answer=%v geo=%q", msg.Answer, notes.Geo)
+	}
+	if msg := ask("www.corp.", "192.0.2.1", nil); first(msg) != "10.9.9.9" || notes.Geo != "default" {
+		t.Fatalf("client outside regions: answer=%v geo=%q", msg.Answer, notes.Geo)
+	}
+
+	ecs := &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: net.ParseIP("10.1.7.0").To4()}
+	msg := ask("www.corp.", "192.0.2.1", ecs)
+	if first(msg) != "10.1.0.5" {
+		t.Fatalf("ECS subnet ignored: answer=%v", msg.Answer)
+	}
+	var scope uint8
+	if opt := msg.IsEdns0(); opt != nil {
+		for _, o := range opt.Option {
+			if s, ok := o.(*dns.EDNS0_SUBNET); ok {
+				scope = s.SourceScope
+			}
+		}
+	}
+	if scope != 24 {
+		t.Fatalf("ECS scope = %d, want 24", scope)
+	}
+
+	msg = ask("cdn.corp.", "10.1.2.3", nil)
+	if len(msg.Answer) != 2 || first(msg) != "10.0.0.5" || notes.Geo != "region eu" || len(rec.recorded()) != 0 {
+		t.Fatalf("geo CNAME: answer=%v geo=%q upstream=%v", msg.Answer, notes.Geo, rec.recorded())
+	}
+	msg = ask("cdn.corp.", "192.0.2.1", nil)
+	if got := rec.recorded(); len(got) != 1 || got[0].name != "www.example.com." || len(msg.Answer) != 2 {
+		t.Fatalf("default CNAME: answer=%v upstream=%v", msg.Answer, got)
+	}
+	for _, c := range store.GetCacheRecords() {
+		if strings.EqualFold(c.DNSRecord.Name, "cdn.corp.") {
+			t.Fatalf("geo chain cached under the owner: %+v", c)
+		}
+	}
+	if msg = ask("cdn.corp.", "10.1.2.3", nil); first(msg) != "10.0.0.5" {
+		t.Fatalf("region client after default chain: answer=%v", msg.Answer)
+	}
+}
+
+func TestResolver_LocalRecordsOfUnknownType(t *testing.T) {
+	store := &localRecordStore{records: []dnsrecords.DNSRecord{
+		{Name: "private.corp", Type: "TYPE65534", Value: `\# 4 0A000001`, TTL: 60},
+	}}
+	r := New(Config{Store: store, Upstream: &recordingUpstream{}, UpstreamTimeout: time.Second})
+	q := dns.Question{Name: "private.corp.", Qtype: 65534, Qclass: dns.ClassINET}
+	msg := new(dns.Msg)
+	msg.SetQuestion(q.Name, q.Qtype)
+	r.HandleQuestion(context.Background(), q, msg)
+	if len(msg.Answer) != 1 || msg.Answer[0].Header().Rrtype != 65534 || !msg.Authoritative {
+		t.Fatalf("answer = %v", msg.Answer)
+	}
+}
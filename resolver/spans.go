@@ -0,0 +1,65 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/dnsservers"
+	"dnsplane/tracing"
+)
+
+// queryUpstream asks srv, recording the attempt in the query trace (POST /dns/query) and as a span. The
+// caller has already counted it with upstreamStart.
+func (r *Resolver) queryUpstream(ctx context.Context, question dns.Question, srv dnsservers.UpstreamEndpoint) (*dns.Msg, error) {
+	_, span := tracing.Start(ctx, "dns.upstream", tracing.KindClient)
+	t1 := time.Now()
+	resp, err := r.upstream.Query(ctx, question, srv)
+	traceFromContext(ctx).upstreamDone(srv, resp, err, time.Since(t1))
+	if span != nil {
+		span.SetAttr("server.address", srv.Addr)
+		span.SetAttr("network.transport", srv.Transport)
+		switch {
+		case errors.Is(err, context.Canceled):
+			// Another upstream answered first.
+			span.SetAttr("dnsplane.cancelled", true)
+		case err != nil:
+			span.SetError(err.Error())
+		case resp != nil:
+			span.SetAttr("dns.response.code", dns.RcodeToString[resp.Rcode])
+			span.SetAttr("dns.answers", len(resp.Answer))
+		}
+		span.End()
+	}
+	return resp, err
+}
+
+// endLookupSpan ends the span of the local and cache lookup with what each found.
+func endLookupSpan(span *tracing.Span, local []dns.RR, cache *dns.RR, cacheRRs []dns.RR, stale, skipCache bool) {
+	if span == nil {
+		return
+	}
+	span.SetAttr("dnsplane.local", hitOrMiss(len(local) > 0))
+	switch {
+	case skipCache:
+		span.SetAttr("dnsplane.cache", "skipped")
+	case cache == nil && len(cacheRRs) == 0:
+		span.SetAttr("dnsplane.cache", "miss")
+	case stale:
+		span.SetAttr("dnsplane.cache", "stale")
+	default:
+		span.SetAttr("dnsplane.cache", "hit")
+	}
+	span.End()
+}
+
+func hitOrMiss(hit bool) string {
+	if hit {
+		return "hit"
+	}
+	return "miss"
+}
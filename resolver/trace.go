@@ -0,0 +1,378 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/dnsrecordcache"
+	"dnsplane/dnsservers"
+	"dnsplane/policy"
+)
+
+// Path selects the layers a traced query may use.
+type Path string
+
+const (
+	// PathNormal resolves as for a client: policy, local, cache, adblock, upstreams.
+	PathNormal Path = "normal"
+	// PathCacheOnly answers from the cache or not at all.
+	PathCacheOnly Path = "cache"
+	// PathLocalOnly answers from local data (records, built-in and empty zones) and refuses the rest, as for
+	// a local_only client.
+	PathLocalOnly Path = "local"
+	// PathUpstreamOnly asks the upstreams the query would be forwarded to, skipping local data, the cache,
+	// and adblock.
+	PathUpstreamOnly Path = "upstream"
+)
+
+// Trace is what the resolver did for one traced query.
+type Trace struct {
+	Path  Path        `json:"path"`
+	Steps []TraceStep `json:"steps"`
+	// Upstreams lists every upstream asked, in the order they replied; Used marks the one that answered.
+	Upstreams []TraceUpstream `json:"upstreams"`
+	// DNSSEC is the validation outcome of an upstream answer (off, insecure, verified, bogus) or "signed" for
+	// a local answer signed for a DO query.
+	DNSSEC string `json:"dnssec,omitempty"`
+	// Outcome is local, cache, upstream, none, blocked, or refused, as in the dashboard resolution log.
+	Outcome    string  `json:"outcome"`
+	Upstream   string  `json:"upstream,omitempty"`
+	Record     string  `json:"record,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// TraceStep is one layer consulted for Name (the question, or the target of an alias or safe-search answer
+// resolved on its behalf): Result is hit, miss, skipped, blocked, allowed, refused, or a policy
+// action. AtMs is the time since the query started.
+type TraceStep struct {
+	Name   string  `json:"name"`
+	Layer  string  `json:"layer"`
+	Result string  `json:"result"`
+	Detail string  `json:"detail,omitempty"`
+	AtMs   float64 `json:"at_ms"`
+}
+
+// TraceUpstream is one upstream query.
+type TraceUpstream struct {
+	Server     string  `json:"server"`
+	Transport  string  `json:"transport"`
+	DurationMs float64 `json:"duration_ms"`
+	Rcode      string  `json:"rcode,omitempty"`
+	Answers    int     `json:"answers"`
+	Error      string  `json:"error,omitempty"`
+	Used       bool    `json:"used"`
+}
+
+type traceCtxKey struct{}
+
+// tracer collects a Trace while the resolver runs; its methods do nothing on a nil tracer, so the resolver
+// calls them unconditionally.
+type tracer struct {
+	mu       sync.Mutex
+	t        Trace
+	start    time.Time
+	inflight sync.WaitGroup
+}
+
+func traceFromContext(ctx context.Context) *tracer {
+	if ctx == nil {
+		return nil
+	}
+	v, _ := ctx.Value(traceCtxKey{}).(*tracer)
+	return v
+}
+
+func msSince(t time.Time) float64 {
+	return float64(time.Since(t).Microseconds()) / 1000
+}
+
+func (tr *tracer) step(name, layer, result, detail string) {
+	if tr == nil {
+		return
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.t.Steps = append(tr.t.Steps, TraceStep{Name: name, Layer: layer, Result: result, Detail: detail, AtMs: msSince(tr.start)})
+}
+
+// upstreamStart announces an upstream query; upstreamDone must follow.
+func (tr *tracer) upstreamStart() {
+	if tr != nil {
+		tr.inflight.Add(1)
+	}
+}
+
+func (tr *tracer) upstreamDone(ep dnsservers.UpstreamEndpoint, msg *dns.Msg, err error, elapsed time.Duration) {
+	if tr == nil {
+		return
+	}
+	defer tr.inflight.Done()
+	u := TraceUpstream{Server: ep.HealthKey(), Transport: ep.Transport, DurationMs: float64(elapsed.Microseconds()) / 1000}
+	if err != nil {
+		u.Error = err.Error()
+	}
+	if msg != nil {
+		u.Rcode = dns.RcodeToString[msg.Rcode]
+		u.Answers = len(msg.Answer)
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.t.Upstreams = append(tr.t.Upstreams, u)
+}
+
+func (tr *tracer) dnssec(outcome string) {
+	if tr == nil {
+		return
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.t.DNSSEC = outcome
+}
+
+// finish records the outcome of the traced question; inner resolves (alias targets, safe search) report to
+// an observeSink instead and do not reach it.
+func (tr *tracer) finish(outcome, upstream, record string) {
+	if tr == nil {
+		return
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.t.Outcome, tr.t.Upstream, tr.t.Record = outcome, upstream, record
+}
+
+// fastLookup records the combined local and cache lookup of resolveFastPath.
+func (tr *tracer) fastLookup(name string, local []dns.RR, cache *dns.RR, cacheRRs []dns.RR, stale, skipCache bool) {
+	if tr == nil {
+		return
+	}
+	if len(local) > 0 {
+		tr.step(name, "local", "hit", rrOneLine(local[0]))
+		return
+	}
+	tr.step(name, "local", "miss", "")
+	var hit string
+	switch {
+	case len(cacheRRs) > 0:
+		hit = rrOneLine(cacheRRs[0])
+	case cache != nil:
+		hit = rrOneLine(*cache)
+	}
+	switch {
+	case skipCache:
+		tr.step(name, "cache", "skipped", "local answers only or policy upstreams")
+	case hit == "":
+		tr.step(name, "cache", "miss", "")
+	case stale:
+		tr.step(name, "cache", "hit", hit+" (stale)")
+	default:
+		tr.step(name, "cache", "hit", hit)
+	}
+}
+
+// traceSigned notes a local answer that the DNSSEC signer signed.
+func traceSigned(ctx context.Context, response *dns.Msg) {
+	for _, rr := range response.Answer {
+		if rr.Header().Rrtype == dns.TypeRRSIG {
+			traceFromContext(ctx).dnssec("signed")
+			return
+		}
+	}
+}
+
+func policyActionString(d policy.Decision) string {
+	switch d.Action {
+	case policy.Block:
+		return "block"
+	case policy.SafeSearch:
+		return "safe_search"
+	}
+	if len(d.Upstreams) > 0 {
+		return "upstreams"
+	}
+	return "allow"
+}
+
+// TraceQuery resolves question along path and reports each layer it consulted. It runs through the same
+// code as HandleQuestion (ctx carries the request, client IP, and ACL marks as for a client query) but
+// counts nothing: statistics, the dashboard log, upstream health, and the cache are left as they were.
+// With upstreams set, those servers are asked instead of the selected ones (path upstream-only). The
+// answer goes into response.
+func (r *Resolver) TraceQuery(ctx context.Context, question dns.Question, path Path, upstreams []dnsservers.UpstreamEndpoint, response *dns.Msg) Trace {
+	tr := &tracer{start: time.Now(), t: Trace{Path: path}}
+	if r == nil || r.store == nil {
+		return tr.t
+	}
+	q := r.quietCopy()
+	ctx = context.WithValue(ctx, traceCtxKey{}, tr)
+	switch {
+	case len(upstreams) > 0 || path == PathUpstreamOnly:
+		tr.t.Path = PathUpstreamOnly
+		q.resolveUpstreamOnly(ctx, question, upstreams, response)
+	case path == PathCacheOnly:
+		q.resolveCacheOnly(ctx, question, response)
+	case path == PathLocalOnly:
+		q.HandleQuestion(contextWithCacheBypass(ContextWithNoRecursion(ctx)), question, response)
+	default:
+		tr.t.Path = PathNormal
+		q.HandleQuestion(ctx, question, response)
+	}
+	// Upstreams that lost the race are cancelled; wait for them so the trace lists every one.
+	tr.inflight.Wait()
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	t := tr.t
+	t.DurationMs = msSince(tr.start)
+	t.Steps = slices.Clone(t.Steps)
+	t.Upstreams = slices.Clone(t.Upstreams)
+	for i := range t.Upstreams {
+		t.Upstreams[i].Used = t.Outcome == "upstream" && t.Upstreams[i].Server == t.Upstream
+	}
+	return t
+}
+
+// resolveCacheOnly answers from the cache alone: the full cached answer when there is one, else a single
+// cached record.
+func (r *Resolver) resolveCacheOnly(ctx context.Context, question dns.Question, response *dns.Msg) {
+	t0 := time.Now()
+	tr := traceFromContext(ctx)
+	if !r.store.GetResolverSettings().CacheRecords {
+		tr.step(question.Name, "cache", "skipped", "cache_records is off")
+		r.observeQuery(ctx, question, "none", "", "no answer", t0)
+		return
+	}
+	recordType := recordTypeString(question.Qtype)
+	if rs, ok := r.store.(interface {
+		LookupCacheRRSet(qname, recordType string) []dns.RR
+	}); ok {
+		if rrs := rs.LookupCacheRRSet(question.Name, recordType); len(rrs) > 0 {
+			tr.step(question.Name, "cache", "hit", rrOneLine(rrs[0]))
+			r.processCachedUpstreamRRs(question, rrs, response)
+			r.observeQuery(ctx, question, "cache", "", rrOneLine(rrs[0]), t0)
+			return
+		}
+	}
+	if rr := r.store.LookupCacheRR(question.Name, recordType); rr != nil {
+		tr.step(question.Name, "cache", "hit", rrOneLine(*rr))
+		r.processCacheRecord(question, rr, response)
+		r.observeQuery(ctx, question, "cache", "", rrOneLine(*rr), t0)
+		return
+	}
+	tr.step(question.Name, "cache", "miss", "")
+	r.observeQuery(ctx, question, "none", "", "no answer", t0)
+}
+
+// resolveUpstreamOnly asks servers, or the upstreams the question would be forwarded to (forward zone,
+// policy group, whitelist, fallback), all at once and waits for every reply. The first answer with records is
+// used, as on the normal path; a negative reply leaves the response empty.
+func (r *Resolver) resolveUpstreamOnly(ctx context.Context, question dns.Question, servers []dnsservers.UpstreamEndpoint, response *dns.Msg) {
+	t0 := time.Now()
+	tr := traceFromContext(ctx)
+	if len(servers) == 0 {
+		servers = r.tracedUpstreams(ctx, question)
+	} else {
+		tr.step(question.Name, "upstream", "selected", endpointList(servers))
+	}
+	if len(servers) == 0 {
+		tr.step(question.Name, "upstream", "miss", "no upstream to ask")
+		r.observeQuery(ctx, question, "none", "", "no answer", t0)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.upstreamTimeout)
+	defer cancel()
+	results := make(chan *upstreamResult, len(servers))
+	for _, srv := range servers {
+		tr.upstreamStart()
+		go func() {
+			resp, err := r.queryUpstream(ctx, question, srv)
+			results <- &upstreamResult{endpoint: srv, msg: resp, err: err}
+		}()
+	}
+	var first *upstreamResult
+	for range servers {
+		up := <-results
+		if first == nil && up.err == nil && up.msg != nil && up.msg.Rcode == dns.RcodeSuccess && len(up.msg.Answer) > 0 {
+			first = up
+		}
+	}
+	if first == nil {
+		r.observeQuery(ctx, question, "none", "", "no answer", t0)
+		return
+	}
+	r.processUpstreamAnswer(contextWithCacheBypass(ctx), question, first.msg, response)
+	r.observeQuery(ctx, question, "upstream", first.endpoint.HealthKey(), firstAnswerSummary(first.msg), t0)
+}
+
+// tracedUpstreams picks the upstreams as resolveFastPath does, including a policy group's own servers.
+func (r *Resolver) tracedUpstreams(ctx context.Context, question dns.Question) []dnsservers.UpstreamEndpoint {
+	tr := traceFromContext(ctx)
+	var servers []dnsservers.UpstreamEndpoint
+	if d := r.evaluatePolicy(ctx, question); d.Matched() && d.Action == policy.Allow && len(d.Upstreams) > 0 {
+		servers = d.Upstreams
+		tr.step(question.Name, "policy", "upstreams", "group "+d.Group)
+	} else {
+		servers = r.selectUpstreams(r.store.GetResolverSettings(), question)
+	}
+	if zone := r.forwardZone(question.Name); zone != nil {
+		tr.step(question.Name, "forward_zone", "match", zone.Name)
+		servers = zone.Servers
+	}
+	servers = r.store.FilterHealthyUpstreamEndpoints(servers)
+	tr.step(question.Name, "upstream", "selected", endpointList(servers))
+	return servers
+}
+
+func endpointList(eps []dnsservers.UpstreamEndpoint) string {
+	names := make([]string, 0, len(eps))
+	for _, ep := range eps {
+		names = append(names, ep.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// quietCopy returns a resolver over the same data that records nothing: no statistics, no dashboard
+// observer, no cache writes, no upstream health updates, and no query log lines.
+func (r *Resolver) quietCopy() *Resolver {
+	return &Resolver{
+		store:           quietStore{r.store},
+		upstream:        r.upstream,
+		errorLogger:     r.errorLogger,
+		upstreamTimeout: r.upstreamTimeout,
+		dnssecSigner:    r.dnssecSigner,
+		policy:          r.policy,
+		clientMAC:       r.clientMAC,
+		forwardZones:    r.forwardZones,
+		localZones:      r.localZones,
+		aliasTarget:     r.aliasTarget,
+		geoLookup:       r.geoLookup,
+		quiet:           true,
+	}
+}
+
+// quietStore passes reads through and drops the writes a query would make.
+type quietStore struct {
+	Store
+}
+
+func (quietStore) UpdateCacheRecords([]dnsrecordcache.CacheRecord) {}
+func (quietStore) IncrementCacheHits()                             {}
+func (quietStore) IncrementQueriesAnswered()                       {}
+func (quietStore) IncrementTotalBlocks()                           {}
+func (quietStore) RecordUpstreamForwardSuccess(string)             {}
+
+// LookupCacheRRSet forwards to the wrapped store when it has the lookup (embedding only promotes the
+// methods of Store).
+func (s quietStore) LookupCacheRRSet(qname, recordType string) []dns.RR {
+	if rs, ok := s.Store.(interface {
+		LookupCacheRRSet(qname, recordType string) []dns.RR
+	}); ok {
+		return rs.LookupCacheRRSet(qname, recordType)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package resolver
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"dnsplane/config"
+	"dnsplane/dnsrecordcache"
+	"dnsplane/dnsrecords"
+	"dnsplane/dnsservers"
+)
+
+// countingStore counts the writes a query makes to the store.
+type countingStore struct {
+	upstreamOnlyStore
+	writes atomic.Int32
+}
+
+func (s *countingStore) UpdateCacheRecords([]dnsrecordcache.CacheRecord) { s.writes.Add(1) }
+func (s *countingStore) IncrementCacheHits()                             { s.writes.Add(1) }
+func (s *countingStore) IncrementQueriesAnswered()                       { s.writes.Add(1) }
+func (s *countingStore) IncrementTotalBlocks()                           { s.writes.Add(1) }
+func (s *countingStore) RecordUpstreamForwardSuccess(string)             { s.writes.Add(1) }
+
+func traceLayers(t Trace) []string {
+	var out []string
+	for _, s := range t.Steps {
+		out = append(out, s.Layer+":"+s.Result)
+	}
+	return out
+}
+
+func TestTraceQuery_NormalLeavesStatsAlone(t *testing.T) {
+	store := &countingStore{upstreamOnlyStore: upstreamOnlyStore{
+		servers: []dnsservers.DNSServer{{Address: "8.8.8.8", Port: "53", Active: true}},
+		config:  config.Config{CacheRecords: true},
+	}}
+	var observed atomic.Int32
+	r := New(Config{
+		Store:         store,
+		Upstream:      &recordingUpstream{},
+		QueryObserver: func(string, string, string, string, string, time.Duration, string, QueryNotes) { observed.Add(1) },
+	})
+	q := dns.Question{Name: "www.example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+	msg := new(dns.Msg)
+	msg.SetQuestion(q.Name, q.Qtype)
+	tr := r.TraceQuery(context.Background(), q, PathNormal, nil, msg)
+
+	if len(msg.Answer) != 1 || tr.Outcome != "upstream" || tr.Upstream != "8.8.8.8:53" {
+		t.Fatalf("answer %v, trace %+v", msg.Answer, tr)
+	}
+	want := []string{"local:miss", "cache:miss", "adblock:allowed", "upstream:selected"}
+	if got := traceLayers(tr); !slices.Equal(got, want) {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+	if len(tr.Upstreams) != 1 || !tr.Upstreams[0].Used || tr.Upstreams[0].Rcode != "NOERROR" {
+		t.Errorf("upstreams = %+v", tr.Upstreams)
+	}
+	if n := store.writes.Load(); n != 0 {
+		t.Errorf("store writes = %d, want 0", n)
+	}
+	if n := observed.Load(); n != 0 {
+		t.Errorf("observer called %d times", n)
+	}
+}
+
+func TestTraceQuery_Paths(t *testing.T) {
+	local := &localRecordStore{records: []dnsrecords.DNSRecord{{Name: "host.lan.", Type: "A", Value: "10.0.0.1", TTL: 60}}}
+	up := &recordingUpstream{}
+	r := New(Config{Store: local, Upstream: up})
+	ask := func(name string, path Path, servers []dnsservers.UpstreamEndpoint) (*dns.Msg, Trace) {
+		q := dns.Question{Name: name, Qtype: dns.TypeA, Qclass: dns.ClassINET}
+		msg := new(dns.Msg)
+		msg.SetQuestion(q.Name, q.Qtype)
+		return msg, r.TraceQuery(ContextWithRequest(context.Background(), msg.Copy()), q, path, servers, msg)
+	}
+
+	if _, tr := ask("host.lan.", PathLocalOnly, nil); tr.Outcome != "local" {
+		t.Errorf("local-only, local name: %+v", tr)
+	}
+	if msg, tr := ask("other.example.", PathLocalOnly, nil); tr.Outcome != "refused" || msg.Rcode != dns.RcodeRefused {
+		t.Errorf("local-only, remote name: rcode %d, %+v", msg.Rcode, tr)
+	}
+	if _, tr := ask("host.lan.", PathCacheOnly, nil); tr.Outcome != "none" {
+		t.Errorf("cache-only: %+v", tr)
+	}
+	if len(up.recorded()) != 0 {
+		t.Fatalf("upstream asked: %+v", up.recorded())
+	}
+	servers := []dnsservers.UpstreamEndpoint{{Addr: "9.9.9.9:53", Transport: "udp"}}
+	msg, tr := ask("host.lan.", PathNormal, servers)
+	if tr.Path != PathUpstreamOnly || tr.Outcome != "upstream" || len(msg.Answer) != 1 {
+		t.Errorf("specific upstream: %+v", tr)
+	}
+	if got := up.recorded(); len(got) != 1 || got[0].server != "9.9.9.9:53" {
+		t.Errorf("upstream queries = %+v", got)
+	}
+}
@@ -9,12 +9,17 @@ import (
 
 	"github.com/miekg/dns"
 
+	"dnsplane/dnscookie"
 	"dnsplane/dnsservers"
 )
 
+// upstreamCookieUDPSize is the EDNS payload advertised when a query gains OPT only to carry a cookie.
+const upstreamCookieUDPSize = 1232
+
 // DNSClient implements UpstreamClient using github.com/miekg/dns.
 type DNSClient struct {
-	client *dns.Client
+	client  *dns.Client
+	cookies *dnscookie.Jar
 }
 
 // NewDNSClient creates an upstream client with the desired dial timeout.
@@ -29,6 +34,13 @@ func NewDNSClient(timeout time.Duration) *DNSClient {
 	}
 }
 
+// EnableCookies sends RFC 7873 client cookies to udp/tcp/dot upstreams and echoes the server cookie
+// each upstream returned on later queries.
+func (c *DNSClient) EnableCookies() *DNSClient {
+	c.cookies = dnscookie.NewJar()
+	return c
+}
+
 func (c *DNSClient) clientFor(ep dnsservers.UpstreamEndpoint) *dns.Client {
 	if c == nil || c.client == nil {
 		return nil
@@ -62,6 +74,24 @@ func (c *DNSClient) Query(ctx context.Context, question dns.Question, ep dnsserv
 	}
 	message := new(dns.Msg)
 	message.SetQuestion(question.Name, question.Qtype)
+	if c.cookies == nil || cl.Net == "https" {
+		resp, _, err := cl.ExchangeContext(ctx, message, ep.Addr)
+		return resp, err
+	}
+	client := c.cookies.Prepare(message, ep.Addr, upstreamCookieUDPSize)
 	resp, _, err := cl.ExchangeContext(ctx, message, ep.Addr)
+	if err != nil {
+		return resp, err
+	}
+	c.cookies.Observe(ep.Addr, client, resp)
+	if resp.Rcode == dns.RcodeBadCookie {
+		// RFC 7873 §5.3: retry once with the server cookie the upstream just sent.
+		message.Id = dns.Id()
+		c.cookies.Prepare(message, ep.Addr, upstreamCookieUDPSize)
+		resp, _, err = cl.ExchangeContext(ctx, message, ep.Addr)
+		if err == nil {
+			c.cookies.Observe(ep.Addr, client, resp)
+		}
+	}
 	return resp, err
 }
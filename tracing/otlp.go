@@ -0,0 +1,235 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dnsplane/config"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultEndpoint is where spans go when tracing.endpoint is empty: a collector on this host.
+	DefaultEndpoint = "http://localhost:4318/v1/traces"
+
+	queueSize      = 4096
+	batchSize      = 512
+	exportInterval = 5 * time.Second
+	exportTimeout  = 10 * time.Second
+	errorLogEvery  = time.Minute
+	scopeName      = "dnsplane"
+)
+
+// exporter batches ended spans and hands them to the OpenTelemetry OTLP/HTTP exporter. Spans that do not fit
+// in the queue are dropped and counted rather than slowing queries down.
+type exporter struct {
+	url      string
+	client   sdktrace.SpanExporter
+	err      error // why client is nil
+	resource *resource.Resource
+	logger   *slog.Logger
+
+	queue   chan *Span
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+	lastLog time.Time
+}
+
+// Endpoint returns the OTLP/HTTP traces URL for cfg: the default when empty, and /v1/traces appended to a
+// URL without a path.
+func Endpoint(cfg config.TracingConfig) string {
+	e := strings.TrimSpace(cfg.Endpoint)
+	if e == "" {
+		return DefaultEndpoint
+	}
+	if u, err := url.Parse(e); err == nil && (u.Path == "" || u.Path == "/") {
+		u.Path = "/v1/traces"
+		return u.String()
+	}
+	return e
+}
+
+func newExporter(cfg config.TracingConfig, logger *slog.Logger) *exporter {
+	service := strings.TrimSpace(cfg.ServiceName)
+	if service == "" {
+		service = "dnsplane"
+	}
+	e := &exporter{
+		url:      Endpoint(cfg),
+		resource: resource.NewSchemaless(attribute.String("service.name", service)),
+		logger:   logger,
+		queue:    make(chan *Span, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	// Failed exports are counted and dropped, not retried: the queue keeps filling while one is in flight.
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(e.url),
+		otlptracehttp.WithTimeout(exportTimeout),
+		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}),
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	client, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		e.err = err
+	} else {
+		e.client = client
+	}
+	go e.run()
+	return e
+}
+
+func (e *exporter) enqueue(spans ...*Span) {
+	for _, s := range spans {
+		select {
+		case e.queue <- s:
+		default:
+			e.dropped.Add(1)
+		}
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.export(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.stop:
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+					if len(batch) >= batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// shutdown exports what is queued and stops, waiting until ctx is done at most.
+func (e *exporter) shutdown(ctx context.Context) {
+	e.once.Do(func() { close(e.stop) })
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+	}
+	if e.client != nil {
+		_ = e.client.Shutdown(ctx)
+	}
+}
+
+func (e *exporter) export(spans []*Span) {
+	err := e.err
+	if e.client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		err = e.client.ExportSpans(ctx, e.snapshots(spans))
+		cancel()
+	}
+	if err == nil {
+		return
+	}
+	e.dropped.Add(uint64(len(spans)))
+	// One line a minute at most: a collector that is down would otherwise log every few seconds.
+	if e.logger != nil && time.Since(e.lastLog) >= errorLogEvery {
+		e.lastLog = time.Now()
+		e.logger.Warn("tracing: export failed", "endpoint", e.url, "spans", len(spans), "dropped_total", e.dropped.Load(), "error", err)
+	}
+}
+
+// snapshots copies ended spans into the SDK's read-only form. Sampling is decided here rather than by the
+// SDK, so every span handed over is marked sampled.
+func (e *exporter) snapshots(spans []*Span) []sdktrace.ReadOnlySpan {
+	out := make([]sdktrace.ReadOnlySpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		stub := tracetest.SpanStub{
+			Name:                 s.name,
+			SpanContext:          spanContext(s.tr.id, s.id),
+			SpanKind:             oteltrace.SpanKind(s.kind),
+			StartTime:            s.start,
+			EndTime:              s.end,
+			Resource:             e.resource,
+			InstrumentationScope: instrumentation.Scope{Name: scopeName},
+		}
+		if s.parent != ([8]byte{}) {
+			stub.Parent = spanContext(s.tr.id, s.parent)
+		}
+		for _, a := range s.attrs {
+			stub.Attributes = append(stub.Attributes, keyValue(a))
+		}
+		if s.failed {
+			stub.Status = sdktrace.Status{Code: codes.Error, Description: s.message}
+		}
+		s.mu.Unlock()
+		out = append(out, stub.Snapshot())
+	}
+	return out
+}
+
+func spanContext(traceID [16]byte, spanID [8]byte) oteltrace.SpanContext {
+	return oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: oteltrace.FlagsSampled,
+	})
+}
+
+func keyValue(a attr) attribute.KeyValue {
+	switch x := a.value.(type) {
+	case bool:
+		return attribute.Bool(a.key, x)
+	case int:
+		return attribute.Int(a.key, x)
+	case int64:
+		return attribute.Int64(a.key, x)
+	case uint32:
+		return attribute.Int64(a.key, int64(x))
+	case float64:
+		return attribute.Float64(a.key, x)
+	case string:
+		return attribute.String(a.key, x)
+	default:
+		return attribute.String(a.key, fmt.Sprint(a.value))
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+
+// Package tracing records a trace per DNS query and exports the kept ones to an OpenTelemetry collector over
+// OTLP/HTTP. Spans travel in the context and are nil-safe: with tracing off, or for a query sampling has
+// already dropped, Start returns a nil span and every method on it does nothing.
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dnsplane/config"
+)
+
+// Kind is the OpenTelemetry span kind.
+type Kind int
+
+const (
+	KindInternal Kind = 1 // a step inside dnsplane
+	KindServer   Kind = 2 // the inbound query
+	KindClient   Kind = 3 // a query dnsplane sends upstream
+)
+
+// Tracer decides which traces to keep and hands them to its exporter.
+type Tracer struct {
+	cfg    config.TracingConfig
+	ratio  float64
+	slow   time.Duration
+	errors bool
+	exp    *exporter
+}
+
+var current atomic.Pointer[Tracer]
+
+// configMu serialises Configure and Shutdown, so two reloads do not both replace the same tracer.
+var configMu sync.Mutex
+
+// Configure starts, stops, or replaces the tracer to match cfg. An unchanged cfg leaves the running tracer and
+// its queue alone; a replaced one is flushed in the background.
+func Configure(cfg config.TracingConfig, logger *slog.Logger) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	old := current.Load()
+	if old != nil && cfg.Enabled && reflect.DeepEqual(old.cfg, cfg) {
+		return
+	}
+	var t *Tracer
+	if cfg.Enabled {
+		t = &Tracer{
+			cfg:    cfg,
+			ratio:  cfg.SampleRatio,
+			slow:   time.Duration(cfg.SlowMs) * time.Millisecond,
+			errors: cfg.Errors,
+			exp:    newExporter(cfg, logger),
+		}
+	}
+	current.Store(t)
+	if old != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+			defer cancel()
+			old.exp.shutdown(ctx)
+		}()
+	}
+}
+
+// Shutdown stops tracing and exports what is queued, waiting until ctx is done at most.
+func Shutdown(ctx context.Context) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	if old := current.Swap(nil); old != nil {
+		old.exp.shutdown(ctx)
+	}
+}
+
+// Enabled reports whether queries are being traced.
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the span ctx carries, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Start begins a span under the one ctx carries, or a new trace when it carries none. A new trace is recorded
+// when head sampling keeps it or a tail rule (slow_ms, errors) might; otherwise Start returns a nil span.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	var tr *trace
+	if parent != nil {
+		tr = parent.tr
+	} else {
+		t := current.Load()
+		if t == nil {
+			return ctx, nil
+		}
+		sampled := t.ratio >= 1 || (t.ratio > 0 && rand.Float64() < t.ratio)
+		if !sampled && t.slow <= 0 && !t.errors {
+			return ctx, nil
+		}
+		tr = &trace{tracer: t, sampled: sampled}
+		binary.BigEndian.PutUint64(tr.id[:8], nonZero())
+		binary.BigEndian.PutUint64(tr.id[8:], rand.Uint64())
+	}
+	s := &Span{tr: tr, name: name, kind: kind, start: time.Now()}
+	binary.BigEndian.PutUint64(s.id[:], nonZero())
+	if parent != nil {
+		s.parent = parent.id
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func nonZero() uint64 {
+	for {
+		if n := rand.Uint64(); n != 0 {
+			return n
+		}
+	}
+}
+
+// trace is the spans of one query. Spans are held until the root ends, then exported together or dropped;
+// children that end after the root (upstreams that lost the race) follow on their own when it was kept.
+type trace struct {
+	tracer  *Tracer
+	id      [16]byte
+	sampled bool
+
+	mu     sync.Mutex
+	spans  []*Span
+	failed bool
+	done   bool
+	keep   bool
+}
+
+func (tr *trace) ended(s *Span) {
+	tr.mu.Lock()
+	if s.failed {
+		tr.failed = true
+	}
+	if tr.done {
+		keep := tr.keep
+		tr.mu.Unlock()
+		if keep {
+			tr.tracer.exp.enqueue(s)
+		}
+		return
+	}
+	tr.spans = append(tr.spans, s)
+	if s.parent != ([8]byte{}) {
+		tr.mu.Unlock()
+		return
+	}
+	t := tr.tracer
+	tr.done = true
+	tr.keep = tr.sampled || (t.slow > 0 && s.end.Sub(s.start) >= t.slow) || (t.errors && tr.failed)
+	spans, keep := tr.spans, tr.keep
+	tr.spans = nil
+	tr.mu.Unlock()
+	if keep {
+		t.exp.enqueue(spans...)
+	}
+}
+
+// Span is one timed step of a query.
+type Span struct {
+	tr     *trace
+	id     [8]byte
+	parent [8]byte
+	name   string
+	kind   Kind
+
+	mu      sync.Mutex
+	start   time.Time
+	end     time.Time
+	attrs   []attr
+	failed  bool
+	message string
+}
+
+type attr struct {
+	key   string
+	value any
+}
+
+// SetAttr sets an attribute; value is a string, bool, int, int64, uint32, or float64.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attrs {
+		if s.attrs[i].key == key {
+			s.attrs[i].value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, attr{key, value})
+}
+
+// SetError marks the span failed, which keeps the trace when errors is set.
+func (s *Span) SetError(msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.failed, s.message = true, msg
+	s.mu.Unlock()
+}
+
+// End finishes the span. Ending the root decides whether the trace is exported; later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tr.ended(s)
+}
@@ -0,0 +1,212 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"dnsplane/config"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector stands in for an OpenTelemetry collector's OTLP/HTTP receiver.
+type collector struct {
+	*httptest.Server
+	mu      sync.Mutex
+	spans   []*tracepb.Span
+	scope   string
+	service string
+	header  http.Header
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		var req coltracepb.ExportTraceServiceRequest
+		if err == nil {
+			err = proto.Unmarshal(body, &req)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.header = r.Header.Clone()
+		for _, rs := range req.ResourceSpans {
+			for _, a := range rs.Resource.GetAttributes() {
+				if a.Key == "service.name" {
+					c.service = a.Value.GetStringValue()
+				}
+			}
+			for _, ss := range rs.ScopeSpans {
+				c.scope = ss.Scope.GetName()
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		resp, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *collector) received() []*tracepb.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*tracepb.Span(nil), c.spans...)
+}
+
+func (c *collector) roots() []string {
+	var out []string
+	for _, s := range c.received() {
+		if len(s.ParentSpanId) == 0 {
+			out = append(out, s.Name)
+		}
+	}
+	return out
+}
+
+// run configures tracing against c, calls fn, and flushes.
+func run(t *testing.T, c *collector, cfg config.TracingConfig, fn func()) {
+	t.Helper()
+	cfg.Enabled = true
+	cfg.Endpoint = c.URL
+	Configure(cfg, nil)
+	fn()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	Shutdown(ctx)
+}
+
+func attrValue(s *tracepb.Span, key string) *commonpb.AnyValue {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	c := newCollector(t)
+	run(t, c, config.TracingConfig{SampleRatio: 1, ServiceName: "dns-edge", Headers: map[string]string{"X-Api-Key": "k"}}, func() {
+		ctx, root := Start(context.Background(), "dns.query", KindServer)
+		root.SetAttr("dns.question.name", "example.com.")
+		root.SetAttr("dns.answers", 2)
+		_, child := Start(ctx, "dns.upstream", KindClient)
+		child.SetError("i/o timeout")
+		child.End()
+		root.End()
+	})
+
+	spans := c.received()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child, root := spans[0], spans[1]
+	if root.Name != "dns.query" || root.Kind != tracepb.Span_SPAN_KIND_SERVER || len(root.ParentSpanId) != 0 {
+		t.Errorf("root = %v", root)
+	}
+	if child.Kind != tracepb.Span_SPAN_KIND_CLIENT || !bytes.Equal(child.TraceId, root.TraceId) ||
+		!bytes.Equal(child.ParentSpanId, root.SpanId) || len(root.TraceId) != 16 || len(root.SpanId) != 8 {
+		t.Errorf("ids: root %x/%x, child %x/%x parent %x", root.TraceId, root.SpanId, child.TraceId, child.SpanId, child.ParentSpanId)
+	}
+	if child.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR || child.Status.GetMessage() != "i/o timeout" {
+		t.Errorf("child status = %v", child.Status)
+	}
+	if v := attrValue(root, "dns.question.name"); v.GetStringValue() != "example.com." {
+		t.Errorf("dns.question.name = %v", v)
+	}
+	if v := attrValue(root, "dns.answers"); v == nil || v.GetIntValue() != 2 {
+		t.Errorf("dns.answers = %v", v)
+	}
+	if root.StartTimeUnixNano == 0 || root.EndTimeUnixNano < root.StartTimeUnixNano {
+		t.Errorf("root ends before it starts: %d > %d", root.StartTimeUnixNano, root.EndTimeUnixNano)
+	}
+	if c.service != "dns-edge" || c.scope != "dnsplane" || c.header.Get("X-Api-Key") != "k" {
+		t.Errorf("service %q, scope %q, headers %v", c.service, c.scope, c.header)
+	}
+}
+
+func TestTailSampling(t *testing.T) {
+	c := newCollector(t)
+	run(t, c, config.TracingConfig{SampleRatio: 0, SlowMs: 20, Errors: true}, func() {
+		_, fast := Start(context.Background(), "fast", KindServer)
+		fast.End()
+
+		_, slow := Start(context.Background(), "slow", KindServer)
+		time.Sleep(25 * time.Millisecond)
+		slow.End()
+
+		ctx, failed := Start(context.Background(), "failed", KindServer)
+		_, up := Start(ctx, "dns.upstream", KindClient)
+		up.SetError("connection refused")
+		up.End()
+		failed.End()
+
+		// A child ending after its kept root still goes out.
+		ctx, late := Start(context.Background(), "late", KindServer)
+		late.SetError("SERVFAIL")
+		_, loser := Start(ctx, "dns.upstream", KindClient)
+		late.End()
+		loser.End()
+	})
+
+	if roots := c.roots(); !slices.Equal(roots, []string{"slow", "failed", "late"}) {
+		t.Errorf("kept roots = %v, want [slow failed late]", roots)
+	}
+	if n := len(c.received()); n != 5 {
+		t.Errorf("got %d spans, want 5 (3 roots, 2 children)", n)
+	}
+}
+
+func TestHeadSamplingOffRecordsNothing(t *testing.T) {
+	c := newCollector(t)
+	run(t, c, config.TracingConfig{SampleRatio: 0}, func() {
+		ctx, span := Start(context.Background(), "dns.query", KindServer)
+		if span != nil || SpanFromContext(ctx) != nil {
+			t.Error("span recorded with sampling off and no tail rules")
+		}
+		span.SetAttr("k", "v")
+		span.SetError("x")
+		span.End()
+	})
+	if n := len(c.received()); n != 0 {
+		t.Errorf("exported %d spans", n)
+	}
+	if _, span := Start(context.Background(), "dns.query", KindServer); span != nil || Enabled() {
+		t.Error("span recorded with tracing off")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                                 DefaultEndpoint,
+		"http://otel:4318":                 "http://otel:4318/v1/traces",
+		"https://otel.example/":            "https://otel.example/v1/traces",
+		"https://otel.example/otlp/traces": "https://otel.example/otlp/traces",
+	} {
+		if got := Endpoint(config.TracingConfig{Endpoint: in}); got != want {
+			t.Errorf("Endpoint(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
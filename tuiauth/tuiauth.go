@@ -0,0 +1,278 @@
+// Package tuiauth logs in remote TUI users by password or client certificate and locks out repeated failures.
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package tuiauth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dnsplane/certmatch"
+	"dnsplane/config"
+)
+
+// hashIterations is the PBKDF2-SHA256 work factor for new password hashes.
+const hashIterations = 600000
+
+const hashScheme = "pbkdf2-sha256"
+
+// ErrDenied is returned for a wrong or missing credential. It does not say which part was wrong.
+var ErrDenied = errors.New("login failed")
+
+// LockedError is returned while an address or user is locked out.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return "too many failed logins; locked until " + e.Until.Format(time.RFC3339)
+}
+
+// NormalizeRole returns the canonical role name; "" is read-only.
+func NormalizeRole(role string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case "", config.TUIRoleReadOnly, "readonly", "ro":
+		return config.TUIRoleReadOnly, nil
+	case config.TUIRoleAdmin:
+		return config.TUIRoleAdmin, nil
+	}
+	return "", fmt.Errorf("unknown role %q (want %s or %s)", role, config.TUIRoleAdmin, config.TUIRoleReadOnly)
+}
+
+// HashPassword returns a salted PBKDF2-SHA256 hash of password for password_hash.
+func HashPassword(password string) (string, error) {
+	return hashPassword(password, hashIterations)
+}
+
+func hashPassword(password string, iterations int) (string, error) {
+	if password == "" {
+		return "", errors.New("empty password")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, 32)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("%s$%d$%s$%s", hashScheme, iterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// parseHash splits a password_hash into its iteration count, salt, and key.
+func parseHash(hash string) (int, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != hashScheme {
+		return 0, nil, nil, fmt.Errorf("password hash is not %s$<iterations>$<salt>$<key>", hashScheme)
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter < 1 {
+		return 0, nil, nil, fmt.Errorf("bad iteration count %q", parts[1])
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("bad salt: %w", err)
+	}
+	key, err := enc.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return 0, nil, nil, fmt.Errorf("bad key")
+	}
+	return iter, salt, key, nil
+}
+
+// VerifyPassword reports whether password matches hash.
+func VerifyPassword(hash, password string) bool {
+	iter, salt, key, err := parseHash(hash)
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(key))
+	return err == nil && subtle.ConstantTimeCompare(got, key) == 1
+}
+
+// User is a logged-in TUI user.
+type User struct {
+	Name string
+	Role string
+}
+
+// Authenticator checks TUI logins against the configured users. Failed logins count per remote host and
+// per user name; reaching MaxFailures locks that host or name for LockoutSeconds.
+type Authenticator struct {
+	users       []config.TUIUser
+	maxFailures int
+	lockout     time.Duration
+	dummyHash   string // verified for unknown names, so they take as long as known ones
+
+	mu       sync.Mutex
+	failures map[string]*failState
+}
+
+type failState struct {
+	count int
+	last  time.Time
+	until time.Time
+}
+
+// New returns an Authenticator for cfg.Users.
+func New(cfg config.TUIAuthConfig) *Authenticator {
+	a := &Authenticator{
+		users:       cfg.Users,
+		maxFailures: max(cfg.MaxFailures, 1),
+		lockout:     time.Duration(max(cfg.LockoutSeconds, 1)) * time.Second,
+		failures:    make(map[string]*failState),
+	}
+	a.dummyHash, _ = hashPassword("dnsplane", hashIterations)
+	return a
+}
+
+// Check reports users that cannot log in or are misconfigured.
+func (a *Authenticator) Check() []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, u := range a.users {
+		switch {
+		case strings.TrimSpace(u.Name) == "" || strings.ContainsAny(u.Name, " \t"):
+			errs = append(errs, fmt.Errorf("tui user %q: name must be non-empty without spaces", u.Name))
+		case seen[u.Name]:
+			errs = append(errs, fmt.Errorf("tui user %q listed twice; the first entry is used", u.Name))
+		}
+		seen[u.Name] = true
+		if _, err := NormalizeRole(u.Role); err != nil {
+			errs = append(errs, fmt.Errorf("tui user %q: %w", u.Name, err))
+		}
+		if u.PasswordHash == "" && len(u.CertMatch) == 0 && u.AuthorizedKeys == "" {
+			errs = append(errs, fmt.Errorf("tui user %q has no password_hash, cert_match or authorized_keys", u.Name))
+		}
+		if u.PasswordHash != "" {
+			if _, _, _, err := parseHash(u.PasswordHash); err != nil {
+				errs = append(errs, fmt.Errorf("tui user %q: %w", u.Name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// Login authenticates a session from host. A verified client certificate logs in the user whose
+// cert_match fits it (name, when given, must be that user); otherwise name and password must match.
+// Users with an unknown role are refused.
+func (a *Authenticator) Login(name, password string, cert *x509.Certificate, host string, now time.Time) (User, error) {
+	keys := []string{"host:" + host}
+	if name != "" {
+		keys = append(keys, "user:"+name)
+	}
+	if until := a.lockedUntil(keys, now); !until.IsZero() {
+		return User{}, &LockedError{Until: until}
+	}
+	if u, ok := a.match(name, password, cert); ok {
+		if role, err := NormalizeRole(u.Role); err == nil {
+			a.succeeded(keys)
+			return User{Name: u.Name, Role: role}, nil
+		}
+	}
+	a.failed(keys, now)
+	return User{}, ErrDenied
+}
+
+// LoginKey authenticates name from host by SSH public key. authorized reports whether the offered key is
+// listed for the first user named name with authorized_keys. Locked hosts and names are refused before
+// authorized is called, and every refused key counts as a failed login.
+func (a *Authenticator) LoginKey(name, host string, now time.Time, authorized func(config.TUIUser) bool) (User, error) {
+	keys := []string{"host:" + host}
+	if name != "" {
+		keys = append(keys, "user:"+name)
+	}
+	if until := a.lockedUntil(keys, now); !until.IsZero() {
+		return User{}, &LockedError{Until: until}
+	}
+	for _, u := range a.users {
+		if u.Name != name || strings.TrimSpace(u.AuthorizedKeys) == "" {
+			continue
+		}
+		if role, err := NormalizeRole(u.Role); err == nil && authorized(u) {
+			a.succeeded(keys)
+			return User{Name: u.Name, Role: role}, nil
+		}
+		break
+	}
+	a.failed(keys, now)
+	return User{}, ErrDenied
+}
+
+func (a *Authenticator) match(name, password string, cert *x509.Certificate) (config.TUIUser, bool) {
+	if cert != nil {
+		for _, u := range a.users {
+			if (name == "" || u.Name == name) && len(u.CertMatch) > 0 && certmatch.Match(cert, u.CertMatch) {
+				return u, true
+			}
+		}
+	}
+	if name == "" || password == "" {
+		return config.TUIUser{}, false
+	}
+	for _, u := range a.users {
+		if u.Name == name {
+			return u, u.PasswordHash != "" && VerifyPassword(u.PasswordHash, password)
+		}
+	}
+	VerifyPassword(a.dummyHash, password)
+	return config.TUIUser{}, false
+}
+
+func (a *Authenticator) lockedUntil(keys []string, now time.Time) time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var until time.Time
+	for _, k := range keys {
+		if st := a.failures[k]; st != nil && now.Before(st.until) && st.until.After(until) {
+			until = st.until
+		}
+	}
+	return until
+}
+
+func (a *Authenticator) failed(keys []string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.failures) > 4096 {
+		for k, st := range a.failures {
+			if now.Sub(st.last) > a.lockout && !now.Before(st.until) {
+				delete(a.failures, k)
+			}
+		}
+	}
+	for _, k := range keys {
+		st := a.failures[k]
+		// Failures older than a lockout period are forgotten.
+		if st == nil || now.Sub(st.last) > a.lockout {
+			st = &failState{}
+			a.failures[k] = st
+		}
+		st.count++
+		st.last = now
+		if st.count >= a.maxFailures {
+			st.until = now.Add(a.lockout)
+			st.count = 0
+		}
+	}
+}
+
+func (a *Authenticator) succeeded(keys []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, k := range keys {
+		delete(a.failures, k)
+	}
+}
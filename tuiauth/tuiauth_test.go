@@ -0,0 +1,116 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package tuiauth
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+	"time"
+
+	"dnsplane/config"
+)
+
+func TestPasswordHash(t *testing.T) {
+	h, err := hashPassword("s3cret", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyPassword(h, "s3cret") || VerifyPassword(h, "s3cret ") || VerifyPassword("sha256:abc", "s3cret") {
+		t.Fatalf("verify %q", h)
+	}
+	h2, _ := hashPassword("s3cret", 1000)
+	if h == h2 {
+		t.Fatal("hashes without distinct salts")
+	}
+}
+
+func TestLogin(t *testing.T) {
+	alice, _ := hashPassword("alice-pw", 1000)
+	a := New(config.TUIAuthConfig{
+		Users: []config.TUIUser{
+			{Name: "alice", Role: "admin", PasswordHash: alice},
+			{Name: "viewer", CertMatch: []string{"cn:viewer"}},
+		},
+		MaxFailures:    3,
+		LockoutSeconds: 60,
+	})
+	if errs := a.Check(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	now := time.Now()
+	u, err := a.Login("alice", "alice-pw", nil, "192.0.2.1", now)
+	if err != nil || u.Role != config.TUIRoleAdmin {
+		t.Fatalf("password login: %+v %v", u, err)
+	}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "viewer"}}
+	if u, err := a.Login("", "", cert, "192.0.2.1", now); err != nil || u.Name != "viewer" || u.Role != config.TUIRoleReadOnly {
+		t.Fatalf("certificate login: %+v %v", u, err)
+	}
+	if _, err := a.Login("alice", "", cert, "192.0.2.1", now); !errors.Is(err, ErrDenied) {
+		t.Fatalf("certificate of another user: %v", err)
+	}
+
+	// With the failure above, three failures lock the user out from any address, even with the right password.
+	for _, host := range []string{"192.0.2.2", "192.0.2.3"} {
+		if _, err := a.Login("alice", "wrong", nil, host, now); !errors.Is(err, ErrDenied) {
+			t.Fatalf("wrong password: %v", err)
+		}
+	}
+	var locked *LockedError
+	if _, err := a.Login("alice", "alice-pw", nil, "192.0.2.4", now); !errors.As(err, &locked) || !locked.Until.Equal(now.Add(time.Minute)) {
+		t.Fatalf("lockout: %v", err)
+	}
+	// Unknown names count against the address.
+	for i := 0; i < 3; i++ {
+		_, _ = a.Login("nobody", "x", nil, "198.51.100.9", now)
+	}
+	if _, err := a.Login("viewer", "", cert, "198.51.100.9", now); !errors.As(err, &locked) {
+		t.Fatalf("address lockout: %v", err)
+	}
+	if _, err := a.Login("alice", "alice-pw", nil, "192.0.2.4", now.Add(61*time.Second)); err != nil {
+		t.Fatalf("after lockout: %v", err)
+	}
+}
+
+func TestLoginKey(t *testing.T) {
+	a := New(config.TUIAuthConfig{
+		Users: []config.TUIUser{
+			{Name: "alice", Role: "admin", AuthorizedKeys: "/keys/alice"},
+			{Name: "bob", Role: "admin", PasswordHash: "x"},
+		},
+		MaxFailures:    2,
+		LockoutSeconds: 60,
+	})
+	now := time.Now()
+	var checked []string
+	listed := func(ok bool) func(config.TUIUser) bool {
+		return func(u config.TUIUser) bool {
+			checked = append(checked, u.AuthorizedKeys)
+			return ok
+		}
+	}
+	if u, err := a.LoginKey("alice", "192.0.2.1", now, listed(true)); err != nil || u.Role != config.TUIRoleAdmin {
+		t.Fatalf("key login: %+v %v", u, err)
+	}
+	// A user without authorized_keys cannot log in by key; the check is not even made.
+	checked = nil
+	if _, err := a.LoginKey("bob", "192.0.2.2", now, listed(true)); !errors.Is(err, ErrDenied) || len(checked) != 0 {
+		t.Fatalf("user without keys: %v %v", err, checked)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := a.LoginKey("alice", "192.0.2.3", now, listed(false)); !errors.Is(err, ErrDenied) {
+			t.Fatalf("unlisted key: %v", err)
+		}
+	}
+	checked = nil
+	var locked *LockedError
+	if _, err := a.LoginKey("alice", "192.0.2.4", now, listed(true)); !errors.As(err, &locked) || len(checked) != 0 {
+		t.Fatalf("lockout before the key check: %v %v", err, checked)
+	}
+	if _, err := a.LoginKey("alice", "192.0.2.4", now.Add(61*time.Second), listed(true)); err != nil {
+		t.Fatalf("after lockout: %v", err)
+	}
+}
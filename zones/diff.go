@@ -0,0 +1,192 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package zones
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"dnsplane/dnsrecords"
+
+	"github.com/miekg/dns"
+)
+
+// RecordUpdate is a record present before and after an import with a different TTL or metadata.
+type RecordUpdate struct {
+	From dnsrecords.DNSRecord `json:"from"`
+	To   dnsrecords.DNSRecord `json:"to"`
+}
+
+// Change is the outcome of applying imported records to a record set.
+type Change struct {
+	// Records is the full record set after the import.
+	Records   []dnsrecords.DNSRecord `json:"-"`
+	Zones     []string               `json:"zones,omitempty"`
+	Added     []dnsrecords.DNSRecord `json:"added"`
+	Removed   []dnsrecords.DNSRecord `json:"removed"`
+	Updated   []RecordUpdate         `json:"updated"`
+	Unchanged int                    `json:"unchanged"`
+}
+
+// Apply replaces the records in scope with imported and reports the difference. The scope is zone when
+// set, else the zones of the SOA records in imported, else every record. A record in both (same name,
+// type, and value) keeps its id, timestamps, and settings such as geo or health_check; only its TTL is
+// taken from the import, and its tags, owner, comment, and expiry when the imported record has any. When
+// the import holds no SOA record (octoDNS files never do), existing SOA records are kept. Imported records are validated like records added through the API.
+func Apply(current, imported []dnsrecords.DNSRecord, zone string) (Change, error) {
+	var ch Change
+	if zone != "" {
+		ch.Zones = []string{dns.CanonicalName(strings.TrimSpace(zone))}
+	} else {
+		for _, z := range GroupByApex(imported) {
+			if z.Origin != "" {
+				ch.Zones = append(ch.Zones, z.Origin)
+			}
+		}
+	}
+	inScope := func(key string) bool { return true }
+	if len(ch.Zones) > 0 {
+		scoped := make(map[string]bool)
+		for _, z := range ch.Zones {
+			for _, r := range ZoneRecords(current, z) {
+				scoped[recordKey(r)] = true
+			}
+		}
+		inScope = func(key string) bool { return scoped[key] }
+		for _, r := range imported {
+			if !inAnyZone(r.Name, ch.Zones) {
+				return ch, fmt.Errorf("%s %s is outside %s", r.Name, r.Type, strings.Join(ch.Zones, ", "))
+			}
+		}
+	}
+
+	incoming := make(map[string]dnsrecords.DNSRecord, len(imported))
+	keepSOA := true
+	for _, r := range imported {
+		incoming[recordKey(r)] = r
+		if dnsrecords.NormalizeRecordType(r.Type) == "SOA" {
+			keepSOA = false
+		}
+	}
+	kept := make(map[string]bool)
+	out := make([]dnsrecords.DNSRecord, 0, len(current)+len(imported))
+	for _, r := range current {
+		key := recordKey(r)
+		if !inScope(key) {
+			out = append(out, r)
+			continue
+		}
+		if keepSOA && dnsrecords.NormalizeRecordType(r.Type) == "SOA" {
+			ch.Unchanged++
+			out = append(out, r)
+			continue
+		}
+		in, ok := incoming[key]
+		if !ok || kept[key] {
+			ch.Removed = append(ch.Removed, r)
+			continue
+		}
+		kept[key] = true
+		ttlChanged := rrTTL(in) != rrTTL(r)
+		metaChanged := in.HasMetadata() && !sameMetadata(in, r)
+		if ttlChanged || metaChanged {
+			from := r
+			r.TTL = rrTTL(in)
+			if metaChanged {
+				r.Tags, r.Owner, r.Comment, r.ExpiresAt = in.Tags, in.Owner, in.Comment, in.ExpiresAt
+			}
+			r.UpdatedOn = time.Now()
+			ch.Updated = append(ch.Updated, RecordUpdate{From: from, To: r})
+		} else {
+			ch.Unchanged++
+		}
+		out = append(out, r)
+	}
+	for _, r := range imported {
+		key := recordKey(r)
+		if kept[key] {
+			continue
+		}
+		kept[key] = true
+		next, msgs, err := dnsrecords.AddRecord(r, out, false)
+		if err != nil {
+			return ch, fmt.Errorf("%s %s %s: %s", r.Name, r.Type, r.Value, messageText(msgs, err))
+		}
+		if len(next) > len(out) {
+			ch.Added = append(ch.Added, next[len(next)-1])
+		}
+		out = next
+	}
+	ch.Records = out
+	return ch, nil
+}
+
+// Empty reports whether the import changes nothing.
+func (c Change) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Updated) == 0
+}
+
+// Summary returns a one-line count of the changes.
+func (c Change) Summary() string {
+	return fmt.Sprintf("%d added, %d removed, %d updated, %d unchanged", len(c.Added), len(c.Removed), len(c.Updated), c.Unchanged)
+}
+
+// DiffLines returns the changes as zone file lines prefixed with "+", "-", or "~" (TTL or metadata change).
+func (c Change) DiffLines() []string {
+	var out []string
+	for _, r := range sortCanonical(c.Removed) {
+		out = append(out, "- "+diffLine(r))
+	}
+	for _, r := range sortCanonical(c.Added) {
+		out = append(out, "+ "+diffLine(r))
+	}
+	for _, u := range c.Updated {
+		var notes []string
+		if u.From.TTL != u.To.TTL {
+			notes = append(notes, fmt.Sprintf("ttl was %d", u.From.TTL))
+		}
+		if !sameMetadata(u.From, u.To) {
+			notes = append(notes, "metadata changed")
+		}
+		out = append(out, fmt.Sprintf("~ %s (%s)", diffLine(u.To), strings.Join(notes, ", ")))
+	}
+	return out
+}
+
+func diffLine(r dnsrecords.DNSRecord) string {
+	line, err := FormatRecord(r)
+	if err != nil {
+		return fmt.Sprintf("%s\t%d\tIN\t%s\t%s", dns.Fqdn(r.Name), r.TTL, r.Type, r.Value)
+	}
+	return strings.TrimPrefix(line, "; ")
+}
+
+func sameMetadata(a, b dnsrecords.DNSRecord) bool {
+	return slices.Equal(a.Tags, b.Tags) && a.Owner == b.Owner && a.Comment == b.Comment && a.ExpiresAt.Equal(b.ExpiresAt)
+}
+
+func recordKey(r dnsrecords.DNSRecord) string {
+	t := dnsrecords.NormalizeRecordType(r.Type)
+	return dnsrecords.NormalizeRecordNameKey(r.Name) + "|" + t + "|" + dnsrecords.NormalizeRecordValueKey(t, r.Value)
+}
+
+func inAnyZone(name string, zones []string) bool {
+	name = dns.CanonicalName(strings.TrimSpace(name))
+	for _, z := range zones {
+		if dns.IsSubDomain(z, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func messageText(msgs []dnsrecords.Message, err error) string {
+	for _, m := range msgs {
+		if m.Level == dnsrecords.LevelError {
+			return m.Text
+		}
+	}
+	return err.Error()
+}
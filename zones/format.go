@@ -0,0 +1,181 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package zones
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"dnsplane/dnsrecords"
+
+	"github.com/miekg/dns"
+)
+
+// Formats for Export and Import.
+const (
+	FormatBIND    = "bind"
+	FormatJSON    = "json"
+	FormatCSV     = "csv"
+	FormatOctoDNS = "octodns-yaml"
+)
+
+// Formats lists the accepted format names.
+var Formats = []string{FormatBIND, FormatJSON, FormatCSV, FormatOctoDNS}
+
+var csvHeader = []string{"name", "type", "value", "ttl"}
+
+// ParseFormat returns the format named s ("zone" and "yaml" are accepted as aliases); empty means bind.
+func ParseFormat(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", FormatBIND, "zone":
+		return FormatBIND, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatOctoDNS, "octodns", "yaml":
+		return FormatOctoDNS, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want %s)", s, strings.Join(Formats, ", "))
+}
+
+// FormatForPath guesses the format of a file from its extension: .json, .csv, .yaml or .yml, else bind.
+func FormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".csv":
+		return FormatCSV
+	case ".yaml", ".yml":
+		return FormatOctoDNS
+	}
+	return FormatBIND
+}
+
+// ContentType returns the HTTP media type of format.
+func ContentType(format string) string {
+	switch format {
+	case FormatJSON:
+		return "application/json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatOctoDNS:
+		return "application/yaml"
+	}
+	return "text/dns; charset=utf-8"
+}
+
+// Export writes records in format. zone, when set, limits the output to ZoneRecords(records, zone) in
+// canonical order; octodns-yaml needs it, since an octoDNS file holds one zone with relative names.
+func Export(w io.Writer, format string, records []dnsrecords.DNSRecord, zone string) error {
+	if zone != "" {
+		records = ZoneRecords(records, zone)
+	}
+	switch format {
+	case FormatBIND:
+		return WriteZone(w, records)
+	case FormatJSON:
+		if records == nil {
+			records = []dnsrecords.DNSRecord{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Records []dnsrecords.DNSRecord `json:"records"`
+		}{records})
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		for _, r := range records {
+			row := []string{r.Name, dnsrecords.NormalizeRecordType(r.Type), r.Value, strconv.FormatUint(uint64(r.TTL), 10)}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatOctoDNS:
+		if zone == "" {
+			return errors.New("octodns-yaml export needs a zone")
+		}
+		return writeOctoDNS(w, records, dns.CanonicalName(zone))
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// Import reads records in format. zone is the origin of relative names in a BIND file that has no
+// $ORIGIN, and the zone of an octodns-yaml file (required there).
+func Import(r io.Reader, format, zone string) (ParseResult, error) {
+	if zone != "" {
+		zone = dns.CanonicalName(strings.TrimSpace(zone))
+	}
+	var res ParseResult
+	var err error
+	switch format {
+	case FormatBIND:
+		res, err = parseReader(r, zone, "import")
+	case FormatJSON:
+		var in struct {
+			Records []dnsrecords.DNSRecord `json:"records"`
+		}
+		if err = json.NewDecoder(r).Decode(&in); err != nil {
+			return res, fmt.Errorf("json: %w", err)
+		}
+		res.Records = in.Records
+	case FormatCSV:
+		res, err = readCSV(r)
+	case FormatOctoDNS:
+		if zone == "" {
+			return res, errors.New("octodns-yaml import needs a zone")
+		}
+		res, err = readOctoDNS(r, zone)
+	default:
+		return res, fmt.Errorf("unknown format %q", format)
+	}
+	for i := range res.Records {
+		res.Records[i].Name = dnsrecords.CanonicalizeRecordNameForStorage(res.Records[i].Name)
+		res.Records[i].Type = dnsrecords.NormalizeRecordType(res.Records[i].Type)
+	}
+	return res, err
+}
+
+func readCSV(r io.Reader) (ParseResult, error) {
+	var res ParseResult
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	line := 0
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, fmt.Errorf("csv: %w", err)
+		}
+		line++
+		if line == 1 && len(row) > 0 && strings.EqualFold(strings.TrimSpace(row[0]), csvHeader[0]) {
+			continue
+		}
+		if len(row) < 3 || len(row) > 4 {
+			return res, fmt.Errorf("csv row %d: want name,type,value[,ttl]", line)
+		}
+		rec := dnsrecords.DNSRecord{Name: strings.TrimSpace(row[0]), Type: strings.TrimSpace(row[1]), Value: strings.TrimSpace(row[2])}
+		if len(row) == 4 && strings.TrimSpace(row[3]) != "" {
+			ttl, err := strconv.ParseUint(strings.TrimSpace(row[3]), 10, 32)
+			if err != nil {
+				return res, fmt.Errorf("csv row %d: ttl: %w", line, err)
+			}
+			rec.TTL = uint32(ttl)
+		}
+		res.Records = append(res.Records, rec)
+	}
+}
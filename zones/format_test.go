@@ -0,0 +1,270 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package zones_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"dnsplane/dnsrecords"
+	"dnsplane/zones"
+)
+
+func recordKeys(records []dnsrecords.DNSRecord) map[string]bool {
+	out := make(map[string]bool, len(records))
+	for _, r := range records {
+		t := dnsrecords.NormalizeRecordType(r.Type)
+		out[dnsrecords.NormalizeRecordNameKey(r.Name)+" "+t+" "+dnsrecords.NormalizeRecordValueKey(t, r.Value)] = true
+	}
+	return out
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	res, err := zones.ParseFile(filepath.Join("testdata", "alltypes.zone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zoneRecords := zones.ZoneRecords(res.Records, "example.com")
+	for _, tc := range []struct {
+		format string
+		zone   string
+		want   []dnsrecords.DNSRecord
+	}{
+		{format: zones.FormatBIND, want: res.Records},
+		{format: zones.FormatJSON, want: res.Records},
+		{format: zones.FormatCSV, want: res.Records},
+		{format: zones.FormatBIND, zone: "example.com", want: zoneRecords},
+	} {
+		t.Run(tc.format+"/"+tc.zone, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := zones.Export(&b, tc.format, res.Records, tc.zone); err != nil {
+				t.Fatal(err)
+			}
+			got, err := zones.Import(&b, tc.format, tc.zone)
+			if err != nil {
+				t.Fatalf("import: %v\n%s", err, b.String())
+			}
+			want := recordKeys(tc.want)
+			if keys := recordKeys(got.Records); len(keys) != len(want) {
+				t.Fatalf("got %d records, want %d", len(keys), len(want))
+			} else {
+				for k := range want {
+					if !keys[k] {
+						t.Errorf("missing %s", k)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBINDMetadataRoundTrip(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []dnsrecords.DNSRecord{
+		{Name: "old.example.com", Type: "A", Value: "192.0.2.7", TTL: 300, Tags: []string{"migration", "temp"}, Owner: "netops", Comment: "cut over; then remove (ticket 42)", ExpiresAt: expires},
+		{Name: "www.example.com", Type: "A", Value: "192.0.2.8", TTL: 300},
+	}
+	var b bytes.Buffer
+	if err := zones.Export(&b, zones.FormatBIND, records, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := zones.Import(&b, zones.FormatBIND, "example.com")
+	if err != nil {
+		t.Fatalf("import: %v\n%s", err, b.String())
+	}
+	if len(got.Warnings) != 0 {
+		t.Fatalf("warnings: %v", got.Warnings)
+	}
+	byName := map[string]dnsrecords.DNSRecord{}
+	for _, r := range got.Records {
+		byName[r.Name] = r
+	}
+	old := byName["old.example.com"]
+	if !slices.Equal(old.Tags, records[0].Tags) || old.Owner != "netops" || old.Comment != records[0].Comment || !old.ExpiresAt.Equal(expires) {
+		t.Fatalf("metadata lost: %+v\n%s", old, b.String())
+	}
+	if byName["www.example.com"].HasMetadata() {
+		t.Fatalf("unexpected metadata: %+v", byName["www.example.com"])
+	}
+}
+
+func TestOctoDNSRoundTrip(t *testing.T) {
+	res, err := zones.ParseFile(filepath.Join("testdata", "alltypes.zone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := zones.Export(&b, zones.FormatOctoDNS, res.Records, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"'':\n- ttl: 3600\n  type: NS\n  values:\n  - ns1.example.com.\n",
+		"  type: MX\n  value:\n    exchange: mail.example.com.\n    preference: 10\n",
+		"  type: TXT\n  value: 'v=spf1 -all'\n",
+		"_sip._tcp:\n  ttl: 60\n  type: SRV\n  value:\n    port: 5060\n    priority: 10\n    target: sip.example.com.\n    weight: 5\n",
+		"# not expressible in octoDNS: example.com.\t3600\tIN\tLOC\t",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("export lacks %q:\n%s", want, out)
+		}
+	}
+	got, err := zones.Import(strings.NewReader(out), zones.FormatOctoDNS, "example.com")
+	if err != nil {
+		t.Fatalf("import: %v\n%s", err, out)
+	}
+	keys := recordKeys(got.Records)
+	var want int
+	for _, r := range zones.ZoneRecords(res.Records, "example.com") {
+		switch r.Type {
+		case "SOA", "LOC", "HTTPS", "SVCB", "URI", "TYPE65534":
+			continue
+		}
+		want++
+		if k := recordKeys([]dnsrecords.DNSRecord{r}); !keys[firstKey(k)] {
+			t.Errorf("lost %s %s %s", r.Name, r.Type, r.Value)
+		}
+	}
+	if len(keys) != want {
+		t.Fatalf("got %d records, want %d", len(keys), want)
+	}
+}
+
+func firstKey(m map[string]bool) string {
+	for k := range m {
+		return k
+	}
+	return ""
+}
+
+func TestImportOctoDNSHandWritten(t *testing.T) {
+	in := `---
+# hand-written
+'':
+  - type: A
+    values: [192.0.2.1, 192.0.2.2]
+  - type: TXT
+    ttl: 300
+    value: 'k=v\; more'
+www:
+  type: CNAME
+  value: example.com.
+`
+	got, err := zones.Import(strings.NewReader(in), zones.FormatOctoDNS, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := recordKeys(got.Records)
+	for _, want := range []string{
+		`example.com A 192.0.2.1`,
+		`example.com A 192.0.2.2`,
+		`example.com TXT "k=v; more"`,
+		`www.example.com CNAME example.com`,
+	} {
+		if !keys[want] {
+			t.Errorf("missing %s in %v", want, keys)
+		}
+	}
+}
+
+func TestImportOctoDNSFile(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "octodns.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := zones.Import(f, zones.FormatOctoDNS, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := recordKeys(got.Records)
+	for _, want := range []string{
+		`example.com A 192.0.2.10`,
+		`example.com A 192.0.2.11`,
+		`example.com MX 10 mx1.example.com.`,
+		`example.com MX 20 mx2.example.com.`,
+		`example.com TXT "v=spf1 include:_spf.example.net -all"`,
+		`example.com TXT "google-site-verification=abc123"`,
+		`example.com CAA 0 issue "letsencrypt.org"`,
+		`example.com NS ns2.example.net`,
+		`_imaps._tcp.example.com SRV 0 1 993 mail.example.com.`,
+		`_dmarc.example.com TXT "v=DMARC1; p=reject; rua=mailto:dmarc@example.com"`,
+		`*.dev.example.com A 192.0.2.20`,
+		`www.example.com CNAME example.com`,
+		`www2.example.com CNAME example.com`,
+		`note.example.com TXT "folded text on two lines"`,
+	} {
+		if !keys[want] {
+			t.Errorf("missing %s in %v", want, keys)
+		}
+	}
+	if len(got.Records) != 15 {
+		t.Errorf("got %d records, want 15", len(got.Records))
+	}
+
+	// Malformed YAML is an error, not a silent misread.
+	if _, err := zones.Import(strings.NewReader("www:\n  type: A\n  value: [192.0.2.1\n"), zones.FormatOctoDNS, "example.com."); err == nil {
+		t.Error("unterminated flow list accepted")
+	}
+}
+
+func TestApplyDiff(t *testing.T) {
+	current := []dnsrecords.DNSRecord{
+		{ID: "soa", Name: "example.com", Type: "SOA", Value: "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 3600", TTL: 3600},
+		{ID: "www", Name: "www.example.com", Type: "A", Value: "192.0.2.1", TTL: 3600, Weight: 2},
+		{ID: "old", Name: "old.example.com", Type: "A", Value: "192.0.2.9", TTL: 3600},
+		{ID: "other", Name: "host.example.org", Type: "A", Value: "198.51.100.1", TTL: 60},
+	}
+	zone := `$ORIGIN example.com.
+$TTL 3600
+@	IN	SOA	ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 3600
+www	300	IN	A	192.0.2.1
+new	IN	AAAA	2001:db8::1
+`
+	imported, err := zones.Import(strings.NewReader(zone), zones.FormatBIND, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := zones.Apply(current, imported.Records, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ch.Zones) != 1 || ch.Zones[0] != "example.com." {
+		t.Fatalf("zones %v", ch.Zones)
+	}
+	if len(ch.Added) != 1 || len(ch.Removed) != 1 || len(ch.Updated) != 1 || ch.Unchanged != 1 {
+		t.Fatalf("change: %s", ch.Summary())
+	}
+	wantLines := []string{
+		"- old.example.com.\t3600\tIN\tA\t192.0.2.9",
+		"+ new.example.com.\t3600\tIN\tAAAA\t2001:db8::1",
+		"~ www.example.com.\t300\tIN\tA\t192.0.2.1 (ttl was 3600)",
+	}
+	if got := ch.DiffLines(); strings.Join(got, "\n") != strings.Join(wantLines, "\n") {
+		t.Fatalf("diff:\n%s", strings.Join(got, "\n"))
+	}
+	var www, other bool
+	for _, r := range ch.Records {
+		switch r.ID {
+		case "www":
+			www = r.TTL == 300 && r.Weight == 2
+		case "other":
+			other = true
+		}
+	}
+	if !www || !other || len(ch.Records) != 4 {
+		t.Fatalf("records after import: %+v", ch.Records)
+	}
+
+	if _, err := zones.Apply(current, []dnsrecords.DNSRecord{{Name: "x.example.net", Type: "A", Value: "192.0.2.5"}}, "example.com"); err == nil {
+		t.Fatal("record outside the zone was accepted")
+	}
+	if _, err := zones.Apply(current, []dnsrecords.DNSRecord{{Name: "bad.example.com", Type: "MX", Value: "mail"}}, "example.com"); err == nil {
+		t.Fatal("invalid MX value was accepted")
+	}
+}
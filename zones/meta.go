@@ -0,0 +1,50 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package zones
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"dnsplane/dnsrecords"
+)
+
+// metaCommentPrefix starts the comment that carries a record's tags, owner, comment, and expiry in a
+// zone file, for example: www IN A 192.0.2.1 ; dnsplane: {"tags":["web"],"owner":"netops"}
+const metaCommentPrefix = "dnsplane:"
+
+type recordMeta struct {
+	Tags      []string  `json:"tags,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// metaComment returns the zone file comment for r's metadata, or "" when it has none. Semicolons are
+// escaped since the zone parser re-spaces them inside comments.
+func metaComment(r dnsrecords.DNSRecord) string {
+	if !r.HasMetadata() {
+		return ""
+	}
+	b, err := json.Marshal(recordMeta{Tags: r.Tags, Owner: r.Owner, Comment: r.Comment, ExpiresAt: r.ExpiresAt})
+	if err != nil {
+		return ""
+	}
+	return "; " + metaCommentPrefix + " " + strings.ReplaceAll(string(b), ";", `\u003b`)
+}
+
+// applyMetaComment sets rec's metadata from a comment written by metaComment. Other comments are
+// ignored; a malformed one is reported.
+func applyMetaComment(rec *dnsrecords.DNSRecord, comment string) (warn string) {
+	body, ok := strings.CutPrefix(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment), ";")), metaCommentPrefix)
+	if !ok {
+		return ""
+	}
+	var m recordMeta
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &m); err != nil {
+		return "ignore malformed dnsplane comment at " + rec.Name + " " + rec.Type + ": " + err.Error()
+	}
+	rec.Tags, rec.Owner, rec.Comment, rec.ExpiresAt = m.Tags, m.Owner, m.Comment, m.ExpiresAt
+	return ""
+}
@@ -0,0 +1,388 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package zones
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dnsplane/dnsrecords"
+
+	"github.com/miekg/dns"
+	"gopkg.in/yaml.v3"
+)
+
+// octoFields names the octoDNS value fields of structured types in RDATA order. The quoted fields are
+// character strings in the presentation format.
+var octoFields = map[string]struct {
+	names  []string
+	quoted map[string]bool
+}{
+	"MX":    {names: []string{"preference", "exchange"}},
+	"SRV":   {names: []string{"priority", "weight", "port", "target"}},
+	"CAA":   {names: []string{"flags", "tag", "value"}, quoted: map[string]bool{"value": true}},
+	"NAPTR": {names: []string{"order", "preference", "flags", "service", "regexp", "replacement"}, quoted: map[string]bool{"flags": true, "service": true, "regexp": true}},
+	"SSHFP": {names: []string{"algorithm", "fingerprint_type", "fingerprint"}},
+	"TLSA":  {names: []string{"certificate_usage", "selector", "matching_type", "certificate_association_data"}},
+	"DS":    {names: []string{"key_tag", "algorithm", "digest_type", "digest"}},
+}
+
+// octoSimple are the types whose octoDNS values are plain strings.
+var octoSimple = map[string]bool{"A": true, "AAAA": true, "NS": true, "PTR": true, "CNAME": true, "DNAME": true, "ALIAS": true, "TXT": true, "SPF": true}
+
+type octoRecord struct {
+	typ    string
+	ttl    uint32
+	values []any // string or map[string]any
+}
+
+// writeOctoDNS writes the records of zone as an octoDNS YAML zone file. SOA is left out (octoDNS providers
+// manage it); types octoDNS cannot express are written as comments.
+func writeOctoDNS(w io.Writer, records []dnsrecords.DNSRecord, zone string) error {
+	bw := bufio.NewWriter(w)
+	owners := make(map[string][]*octoRecord)
+	var skipped []string
+	for _, r := range sortCanonical(records) {
+		typ := dnsrecords.NormalizeRecordType(r.Type)
+		if typ == "SOA" {
+			continue
+		}
+		if typ == "ANAME" {
+			typ = "ALIAS"
+		}
+		v, ok := octoValue(typ, r)
+		if !ok {
+			line, _ := FormatRecord(r)
+			skipped = append(skipped, line)
+			continue
+		}
+		owner := strings.TrimSuffix(relativeOwner(r.Name, zone), ".")
+		if owner == "@" {
+			owner = ""
+		}
+		var rec *octoRecord
+		for _, o := range owners[owner] {
+			if o.typ == typ {
+				rec = o
+			}
+		}
+		if rec == nil {
+			rec = &octoRecord{typ: typ, ttl: rrTTL(r)}
+			owners[owner] = append(owners[owner], rec)
+		}
+		rec.ttl = min(rec.ttl, rrTTL(r))
+		rec.values = append(rec.values, v)
+	}
+	names := make([]string, 0, len(owners))
+	for n := range owners {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(bw, "---")
+	for _, n := range names {
+		recs := owners[n]
+		fmt.Fprintf(bw, "%s:\n", yamlString(n))
+		for _, rec := range recs {
+			indent := "  "
+			lead := "  "
+			if len(recs) > 1 {
+				lead = "- "
+			}
+			fmt.Fprintf(bw, "%sttl: %d\n", lead, rec.ttl)
+			fmt.Fprintf(bw, "%stype: %s\n", indent, rec.typ)
+			if len(rec.values) == 1 && !isList(rec.typ) {
+				fmt.Fprintf(bw, "%svalue:", indent)
+				writeYAMLValue(bw, rec.values[0], indent+"  ", false)
+				continue
+			}
+			fmt.Fprintf(bw, "%svalues:\n", indent)
+			for _, v := range rec.values {
+				fmt.Fprintf(bw, "%s-", indent)
+				writeYAMLValue(bw, v, indent+"  ", true)
+			}
+		}
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(bw, "# not expressible in octoDNS: %s\n", s)
+	}
+	return bw.Flush()
+}
+
+// isList reports whether octoDNS writes type with `values` even for one value.
+func isList(typ string) bool {
+	return typ == "NS"
+}
+
+// writeYAMLValue writes v after a "key:" or "-" already on the line; map keys go on following lines at
+// indent, except the first key after "-", which stays on the dash line.
+func writeYAMLValue(w *bufio.Writer, v any, indent string, afterDash bool) {
+	switch v := v.(type) {
+	case string:
+		fmt.Fprintf(w, " %s\n", yamlString(v))
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if !afterDash {
+			fmt.Fprintln(w)
+		}
+		for i, k := range keys {
+			sep := indent
+			if i == 0 && afterDash {
+				sep = " "
+			}
+			switch f := v[k].(type) {
+			case uint64:
+				fmt.Fprintf(w, "%s%s: %d\n", sep, k, f)
+			default:
+				fmt.Fprintf(w, "%s%s: %s\n", sep, k, yamlString(fmt.Sprint(f)))
+			}
+		}
+	}
+}
+
+// octoValue returns the octoDNS value of r: a string for simple types, a field map for structured ones.
+func octoValue(typ string, r dnsrecords.DNSRecord) (any, bool) {
+	if octoSimple[typ] {
+		switch typ {
+		case "TXT", "SPF":
+			rr, err := dnsrecords.RecordToRR(r)
+			if err != nil {
+				return nil, false
+			}
+			var chunks []string
+			for _, t := range splitRData(dnsrecords.RDataString(rr)) {
+				chunks = append(chunks, t.text)
+			}
+			return strings.ReplaceAll(strings.Join(chunks, ""), ";", `\;`), true
+		case "A", "AAAA":
+			return strings.TrimSpace(r.Value), true
+		}
+		return dns.Fqdn(strings.TrimSpace(r.Value)), true
+	}
+	spec, ok := octoFields[typ]
+	if !ok {
+		return nil, false
+	}
+	rdata, err := dnsrecords.ParseRData(typ, r.Value)
+	if err != nil {
+		return nil, false
+	}
+	toks := splitRData(rdata)
+	if len(toks) != len(spec.names) {
+		return nil, false
+	}
+	out := make(map[string]any, len(toks))
+	for i, name := range spec.names {
+		if n, err := strconv.ParseUint(toks[i].text, 10, 32); err == nil && !spec.quoted[name] && !toks[i].quoted {
+			out[name] = n
+		} else {
+			out[name] = toks[i].text
+		}
+	}
+	return out, true
+}
+
+type rdataToken struct {
+	text   string
+	quoted bool
+}
+
+// splitRData splits presentation-format RDATA into fields; quoted strings lose their quotes and \"
+// escapes. Other escapes are kept as written.
+func splitRData(s string) []rdataToken {
+	var out []rdataToken
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == ' ' || s[i] == '\t':
+			i++
+		case s[i] == '"':
+			var b strings.Builder
+			i++
+			for i < len(s) && s[i] != '"' {
+				if s[i] == '\\' && i+1 < len(s) {
+					if s[i+1] != '"' {
+						b.WriteByte('\\')
+					}
+					i++
+				}
+				b.WriteByte(s[i])
+				i++
+			}
+			i++
+			out = append(out, rdataToken{text: b.String(), quoted: true})
+		default:
+			j := i
+			for j < len(s) && s[j] != ' ' && s[j] != '\t' {
+				j++
+			}
+			out = append(out, rdataToken{text: s[i:j]})
+			i = j
+		}
+	}
+	return out
+}
+
+func quoteCharString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// octoYAMLRecord is one record of an octoDNS zone file. value holds one value and values a list; a value is
+// a string for simple types and a field mapping for structured ones.
+type octoYAMLRecord struct {
+	Type   string    `yaml:"type"`
+	TTL    *uint32   `yaml:"ttl"`
+	Value  yaml.Node `yaml:"value"`
+	Values yaml.Node `yaml:"values"`
+}
+
+// readOctoDNS reads an octoDNS YAML zone file for zone.
+func readOctoDNS(r io.Reader, zone string) (ParseResult, error) {
+	var res ParseResult
+	var owners map[string]yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&owners); err != nil {
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		return res, fmt.Errorf("yaml: %w", err)
+	}
+	names := make([]string, 0, len(owners))
+	for n := range owners {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, owner := range names {
+		name := zone
+		if owner != "" {
+			name = owner + "." + zone
+		}
+		node := resolveYAMLAlias(owners[owner])
+		var recs []octoYAMLRecord
+		switch node.Kind {
+		case yaml.SequenceNode:
+			if err := node.Decode(&recs); err != nil {
+				return res, fmt.Errorf("%q: %w", owner, err)
+			}
+		case yaml.MappingNode:
+			var rec octoYAMLRecord
+			if err := node.Decode(&rec); err != nil {
+				return res, fmt.Errorf("%q: %w", owner, err)
+			}
+			recs = []octoYAMLRecord{rec}
+		default:
+			return res, fmt.Errorf("%q: want a record or a list of records", owner)
+		}
+		for _, rec := range recs {
+			out, warn, err := octoRecords(name, rec)
+			if err != nil {
+				return res, fmt.Errorf("%q: %w", owner, err)
+			}
+			if warn != "" {
+				res.Warnings = append(res.Warnings, warn)
+			}
+			res.Records = append(res.Records, out...)
+		}
+	}
+	return res, nil
+}
+
+func octoRecords(name string, rec octoYAMLRecord) ([]dnsrecords.DNSRecord, string, error) {
+	typ := dnsrecords.NormalizeRecordType(rec.Type)
+	if typ == "" {
+		return nil, "", fmt.Errorf("record without type")
+	}
+	var ttl uint32
+	if rec.TTL != nil {
+		ttl = *rec.TTL
+	}
+	var values []*yaml.Node
+	switch list := resolveYAMLAlias(rec.Values); {
+	case list.Kind == yaml.SequenceNode:
+		values = list.Content
+	case list.Kind != 0:
+		values = []*yaml.Node{&rec.Values}
+	case rec.Value.Kind != 0:
+		values = []*yaml.Node{&rec.Value}
+	}
+	_, structured := octoFields[typ]
+	if !octoSimple[typ] && !structured {
+		return nil, fmt.Sprintf("%s %s: type not supported, skipped", name, typ), nil
+	}
+	var out []dnsrecords.DNSRecord
+	for _, v := range values {
+		value, err := octoRData(typ, v)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", typ, err)
+		}
+		out = append(out, dnsrecords.DNSRecord{Name: name, Type: typ, Value: value, TTL: ttl})
+	}
+	return out, "", nil
+}
+
+// resolveYAMLAlias follows *alias references to the anchored node.
+func resolveYAMLAlias(n yaml.Node) *yaml.Node {
+	p := &n
+	for p.Kind == yaml.AliasNode && p.Alias != nil {
+		p = p.Alias
+	}
+	return p
+}
+
+// octoRData builds the presentation-format RDATA of one octoDNS value.
+func octoRData(typ string, v *yaml.Node) (string, error) {
+	v = resolveYAMLAlias(*v)
+	if octoSimple[typ] {
+		var s string
+		if v.Kind != yaml.ScalarNode || v.Decode(&s) != nil {
+			return "", fmt.Errorf("want a string value")
+		}
+		if typ != "TXT" && typ != "SPF" {
+			return s, nil
+		}
+		s = strings.ReplaceAll(s, `\;`, ";")
+		var chunks []string
+		for len(s) > 255 {
+			chunks = append(chunks, quoteCharString(s[:255]))
+			s = s[255:]
+		}
+		return strings.Join(append(chunks, quoteCharString(s)), " "), nil
+	}
+	var m map[string]string
+	if v.Kind != yaml.MappingNode || v.Decode(&m) != nil {
+		return "", fmt.Errorf("want a mapping of scalar fields")
+	}
+	spec := octoFields[typ]
+	parts := make([]string, 0, len(spec.names))
+	for _, name := range spec.names {
+		f, ok := m[name]
+		if !ok {
+			return "", fmt.Errorf("missing %s", name)
+		}
+		if spec.quoted[name] {
+			f = quoteCharString(f)
+		}
+		parts = append(parts, f)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+var (
+	yamlPlain    = regexp.MustCompile(`^[A-Za-z0-9_.*][A-Za-z0-9_.*:/@+=,-]*$`)
+	yamlNonPlain = regexp.MustCompile(`^([-+]?[0-9]+|[-+]?([0-9]*\.[0-9]+|[0-9]+\.[0-9]*)([eE][-+]?[0-9]+)?|[-+]?[0-9]+(:[0-5]?[0-9])+(\.[0-9]*)?|(?i:true|false|yes|no|on|off|y|n|null|~|\.inf|\.nan))$`)
+)
+
+// yamlString returns s as a YAML scalar that reads back as the same string.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !yamlNonPlain.MatchString(s) && !strings.HasSuffix(s, ":") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
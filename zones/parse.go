@@ -1,4 +1,4 @@
-// Package zones parses BIND-style zone files into dnsplane dnsrecords.
+// Package zones converts between dnsplane dnsrecords and zone data: BIND zone files, JSON, CSV, and octoDNS YAML.
 // Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
 // SPDX-License-Identifier: GPL-2.0-only
 package zones
@@ -21,7 +21,9 @@ type ParseResult struct {
 	Warnings []string
 }
 
-// ParseFile reads a zone file from path and converts supported RRs to DNSRecord.
+// ParseFile reads a zone file from path and converts supported RRs to DNSRecord. Supported are A, AAAA,
+// CNAME, DNAME, NS, PTR, MX, TXT, SPF, SOA, SRV, CAA, NAPTR, TLSA, SSHFP, HTTPS, SVCB, DS, LOC, URI, and
+// any type unknown to the parser (stored as TYPEnnn in RFC 3597 form); others are skipped with a warning.
 func ParseFile(path string) (ParseResult, error) {
 	f, err := os.Open(path) // #nosec G304 -- path is caller-supplied zone file argument
 	if err != nil {
@@ -33,7 +35,12 @@ func ParseFile(path string) (ParseResult, error) {
 
 // ParseReader parses zone data from r. fileHint is used for parser diagnostics ($INCLUDE resolution).
 func ParseReader(r io.Reader, fileHint string) (ParseResult, error) {
-	zp := dns.NewZoneParser(r, "", fileHint)
+	return parseReader(r, "", fileHint)
+}
+
+// parseReader parses zone data from r with relative names taken relative to origin until a $ORIGIN.
+func parseReader(r io.Reader, origin, fileHint string) (ParseResult, error) {
+	zp := dns.NewZoneParser(r, origin, fileHint)
 	zp.SetIncludeAllowed(false)
 	var out ParseResult
 	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
@@ -47,6 +54,9 @@ func ParseReader(r io.Reader, fileHint string) (ParseResult, error) {
 		if !okConv {
 			continue
 		}
+		if warn := applyMetaComment(&rec, zp.Comment()); warn != "" {
+			out.Warnings = append(out.Warnings, warn)
+		}
 		out.Records = append(out.Records, rec)
 	}
 	if err := zp.Err(); err != nil {
@@ -60,11 +70,14 @@ func rrToRecord(rr dns.RR) (rec dnsrecords.DNSRecord, ok bool, warn string) {
 	name := dnsrecords.CanonicalizeRecordNameForStorage(h.Name)
 	ttl := h.Ttl
 	typ := dns.TypeToString[h.Rrtype]
-	if typ == "" {
-		return dnsrecords.DNSRecord{}, false, fmt.Sprintf("skip unknown RR type %d at %s", h.Rrtype, name)
-	}
 
 	switch v := rr.(type) {
+	case *dns.RFC3597:
+		// Types the parser does not know are kept in RFC 3597 form (TYPEnnn \# len hex).
+		typ = "TYPE" + strconv.Itoa(int(h.Rrtype))
+		return dnsrecords.DNSRecord{Name: name, Type: typ, Value: dnsrecords.RDataString(rr), TTL: ttl}, true, ""
+	case *dns.SRV, *dns.CAA, *dns.NAPTR, *dns.TLSA, *dns.SSHFP, *dns.HTTPS, *dns.SVCB, *dns.DS, *dns.LOC, *dns.URI, *dns.DNAME, *dns.SPF:
+		return dnsrecords.DNSRecord{Name: name, Type: typ, Value: dnsrecords.RDataString(rr), TTL: ttl}, true, ""
 	case *dns.A:
 		if v.A == nil {
 			return dnsrecords.DNSRecord{}, false, ""
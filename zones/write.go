@@ -0,0 +1,227 @@
+// Copyright 2024-2026 George (earentir) Pantazis (https://earentir.dev)
+// SPDX-License-Identifier: GPL-2.0-only
+package zones
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"dnsplane/dnsrecords"
+
+	"github.com/miekg/dns"
+)
+
+// Zone is the records of one zone. Origin is the absolute SOA owner; the group of records outside every
+// zone has an empty Origin.
+type Zone struct {
+	Origin  string
+	Records []dnsrecords.DNSRecord
+}
+
+// GroupByApex splits records into zones by their SOA records: each record belongs to the deepest SOA
+// owner at or above its name. Zones are sorted by origin, records in canonical order with the SOA first.
+// Records outside every zone come last in a group with an empty Origin.
+func GroupByApex(records []dnsrecords.DNSRecord) []Zone {
+	var origins []string
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if dnsrecords.NormalizeRecordType(r.Type) != "SOA" {
+			continue
+		}
+		o := dns.CanonicalName(strings.TrimSpace(r.Name))
+		if !seen[o] {
+			seen[o] = true
+			origins = append(origins, o)
+		}
+	}
+	byOrigin := make(map[string][]dnsrecords.DNSRecord, len(origins))
+	var rest []dnsrecords.DNSRecord
+	for _, r := range records {
+		if o := apexOf(r.Name, origins); o != "" {
+			byOrigin[o] = append(byOrigin[o], r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	sort.Slice(origins, func(i, j int) bool { return canonicalNameLess(origins[i], origins[j]) })
+	out := make([]Zone, 0, len(origins)+1)
+	for _, o := range origins {
+		out = append(out, Zone{Origin: o, Records: sortCanonical(byOrigin[o])})
+	}
+	if len(rest) > 0 {
+		out = append(out, Zone{Records: sortCanonical(rest)})
+	}
+	return out
+}
+
+// ZoneRecords returns the records of zone: those GroupByApex assigns to it when zone has an SOA record,
+// else every record at or below zone.
+func ZoneRecords(records []dnsrecords.DNSRecord, zone string) []dnsrecords.DNSRecord {
+	zone = dns.CanonicalName(strings.TrimSpace(zone))
+	for _, z := range GroupByApex(records) {
+		if z.Origin == zone {
+			return z.Records
+		}
+	}
+	var out []dnsrecords.DNSRecord
+	for _, r := range records {
+		if dns.IsSubDomain(zone, dns.CanonicalName(strings.TrimSpace(r.Name))) {
+			out = append(out, r)
+		}
+	}
+	return sortCanonical(out)
+}
+
+func apexOf(name string, origins []string) string {
+	name = dns.CanonicalName(strings.TrimSpace(name))
+	best := ""
+	for _, o := range origins {
+		if dns.IsSubDomain(o, name) && (best == "" || dns.CountLabel(o) > dns.CountLabel(best)) {
+			best = o
+		}
+	}
+	return best
+}
+
+// sortCanonical returns records in RFC 4034 canonical name order, then by type code and value, with SOA
+// records first.
+func sortCanonical(records []dnsrecords.DNSRecord) []dnsrecords.DNSRecord {
+	out := append([]dnsrecords.DNSRecord(nil), records...)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		ta, tb := dnsrecords.NormalizeRecordType(a.Type), dnsrecords.NormalizeRecordType(b.Type)
+		if (ta == "SOA") != (tb == "SOA") {
+			return ta == "SOA"
+		}
+		na, nb := dns.CanonicalName(strings.TrimSpace(a.Name)), dns.CanonicalName(strings.TrimSpace(b.Name))
+		if na != nb {
+			return canonicalNameLess(na, nb)
+		}
+		if ca, cb := typeCode(ta), typeCode(tb); ca != cb {
+			return ca < cb
+		}
+		return dnsrecords.NormalizeRecordValueKey(ta, a.Value) < dnsrecords.NormalizeRecordValueKey(tb, b.Value)
+	})
+	return out
+}
+
+// canonicalNameLess compares lowercase absolute names label by label from the root.
+func canonicalNameLess(a, b string) bool {
+	la, lb := dns.SplitDomainName(a), dns.SplitDomainName(b)
+	for i, j := len(la)-1, len(lb)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if la[i] != lb[j] {
+			return la[i] < lb[j]
+		}
+	}
+	return len(la) < len(lb)
+}
+
+// typeCode orders ALIAS/ANAME and unknown mnemonics after every real type.
+func typeCode(recordType string) int {
+	if t, ok := dns.StringToType[recordType]; ok {
+		return int(t)
+	}
+	var n int
+	if _, err := fmt.Sscanf(recordType, "TYPE%d", &n); err == nil {
+		return n
+	}
+	return 1 << 16
+}
+
+// WriteZone writes records as BIND zone file text, one block per zone from GroupByApex. Each block starts
+// with $ORIGIN and $TTL (the SOA TTL), owners are written relative to the origin and TTLs only where they
+// differ from $TTL. Records outside every zone follow under "$ORIGIN ." with absolute names. ALIAS/ANAME
+// rows have no wire form and are written as comments. Tags, owner, comment, and expiry follow a record
+// as a "; dnsplane: {...}" comment, which the parser reads back.
+func WriteZone(w io.Writer, records []dnsrecords.DNSRecord) error {
+	bw := bufio.NewWriter(w)
+	for i, z := range GroupByApex(records) {
+		if i > 0 {
+			if _, err := bw.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+		if err := writeZoneBlock(bw, z); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func writeZoneBlock(w *bufio.Writer, z Zone) error {
+	var defTTL uint32
+	origin := z.Origin
+	if origin == "" {
+		origin = "."
+		if _, err := w.WriteString("$ORIGIN .\n"); err != nil {
+			return err
+		}
+	} else {
+		defTTL = rrTTL(z.Records[0])
+		if _, err := fmt.Fprintf(w, "$ORIGIN %s\n$TTL %d\n", origin, defTTL); err != nil {
+			return err
+		}
+	}
+	for _, r := range z.Records {
+		typ := dnsrecords.NormalizeRecordType(r.Type)
+		fields := []string{relativeOwner(r.Name, origin)}
+		if t := rrTTL(r); t != defTTL || origin == "." {
+			fields = append(fields, fmt.Sprintf("%d", t))
+		}
+		fields = append(fields, "IN", typ)
+		prefix := ""
+		if dnsrecords.IsFlattenedType(typ) {
+			prefix = "; "
+			fields = append(fields, dns.Fqdn(strings.TrimSpace(r.Value)))
+		} else {
+			rr, err := dnsrecords.RecordToRR(r)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", r.Name, typ, err)
+			}
+			fields = append(fields, dnsrecords.RDataString(rr))
+		}
+		line := prefix + strings.Join(fields, "\t")
+		if c := metaComment(r); c != "" {
+			line += "\t" + c
+		}
+		if _, err := w.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rrTTL(r dnsrecords.DNSRecord) uint32 {
+	if r.TTL == 0 {
+		return 3600
+	}
+	return r.TTL
+}
+
+func relativeOwner(name, origin string) string {
+	name = dns.CanonicalName(strings.TrimSpace(name))
+	if origin == "." {
+		return name
+	}
+	if name == origin {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+origin)
+}
+
+// FormatRecord returns r as one zone file line with an absolute owner name.
+func FormatRecord(r dnsrecords.DNSRecord) (string, error) {
+	name := dns.Fqdn(strings.TrimSpace(r.Name))
+	typ := dnsrecords.NormalizeRecordType(r.Type)
+	if dnsrecords.IsFlattenedType(typ) {
+		return fmt.Sprintf("; %s\t%d\tIN\t%s\t%s", name, r.TTL, typ, dns.Fqdn(strings.TrimSpace(r.Value))), nil
+	}
+	rdata, err := dnsrecords.ParseRData(typ, r.Value)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w", name, typ, err)
+	}
+	return fmt.Sprintf("%s\t%d\tIN\t%s\t%s", name, r.TTL, typ, rdata), nil
+}